@@ -2,89 +2,199 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
-	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
-	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
-	"github.com/joho/godotenv"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
 )
 
 func main() {
-	// Load .env file from current directory
-	if err := godotenv.Load(".env"); err != nil {
-		log.Printf("Warning: .env file not found: %v", err)
-	}
-
-	ctx := context.Background()
+	cli.Exit(run())
+}
 
-	// Load configurations
-	openaiConfig := clients.LoadConfigFromEnv()
-	vsConfig := vectorstore.LoadConfigFromEnv()
+// run plans, searches, and answers one query
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
 
-	debug := openaiConfig.Debug
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
 
-	if debug {
-		fmt.Printf("DEBUG mode is ON\n")
+	// Resolve flags, the query, and their environment fallbacks
+	opts, err := parseOptions(os.Args[1:], os.Getenv, osStdin(), os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+	if opts.Output != "" {
+		if err := checkOutputPath(opts.Output, opts.Force); err != nil {
+			return fmt.Errorf("%w: %w", cli.ErrConfig, err)
+		}
 	}
 
-	// Create Azure OpenAI clients
-	openaiClients, err := clients.NewOpenAIClients(openaiConfig)
+	logger, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn)
+	if err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("agent")
+	if err != nil {
+		return err
+	}
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
 	if err != nil {
-		log.Fatalf("Failed to create OpenAI clients: %v", err)
+		return err
 	}
+	defer stopMetrics()
 
-	// Connect to vector store
-	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	cfg, err := backend.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, Chat: true, DocumentDB: true})
 	if err != nil {
-		log.Fatalf("Failed to connect to vector store: %v", err)
+		return err
+	}
+	// --timeout replaces the budget of the whole run
+	runBudget := cfg.Timeouts.Budget(timeouts.StageRun)
+	if opts.Timeout > 0 {
+		runBudget.Timeout, runBudget.Setting = opts.Timeout, "--timeout"
 	}
-	defer store.Close(ctx)
 
-	// Create vector search tool
-	searchTool := agents.NewVectorSearchTool(openaiClients, store, debug)
+	// stdout carries only the result: the answer, or the JSON document with --json.
+	// Progress, the run summary, and diagnostics go to stderr.
+	out := os.Stderr
 
-	// Create agents
-	plannerAgent := agents.NewPlannerAgent(openaiClients, searchTool, debug)
-	synthesizerAgent := agents.NewSynthesizerAgent(openaiClients, debug)
+	// Every run gets a session ID that flows through the context to all layers
+	sessionID := opts.SessionID
+	if sessionID == "" {
+		sessionID = session.NewID()
+	}
+	// Ctrl+C or SIGTERM cancels the run, and a second one exits immediately; the run
+	// budget bounds it, so a stuck model call or search can't hang forever
+	ctx, stop := cli.RunContext(os.Stderr, runBudget)
+	defer stop(&err)
+
+	ctx = session.WithID(ctx, sessionID)
+	ctx = session.WithRequestID(ctx, session.NewRequestID())
+	logger.DebugContext(ctx, "agent run started", "timeout", runBudget.Timeout)
 
-	// Get query from environment or use default
-	query := os.Getenv("QUERY")
-	if query == "" {
-		query = "quintessential lodging near running trails, eateries, retail"
+	// Create Azure OpenAI clients and connect to the vector store, or use the offline stand-ins
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+
+	// Load the vector index before the run, so its first search isn't the slow one
+	backend.WarmUp(ctx, services.Store, opts.Warmup, out)
+
+	// Build the planner → synthesizer pipeline
+	pipeline := agents.NewDefaultPipeline(services.Models, services.Store, cfg.Planner, cfg.Synthesizer, cfg.Timeouts)
+	pipeline.SetOutput(out)
+	history := agents.NewHistoryWriterFromEnv(services.Store)
+
+	query := opts.Query
+	nearestNeighbors := opts.K
+
+	fmt.Fprintf(out, "\nSession: %s\n", sessionID)
+	fmt.Fprintf(out, "Query: %s\n", query)
+	fmt.Fprintf(out, "Nearest Neighbors: %d\n", nearestNeighbors)
+
+	// Run the pipeline
+	start := time.Now()
+	state := agents.NewPipelineState(query, nearestNeighbors)
+	state.EphemeralDatabase = services.EphemeralDatabase()
+	if err := pipeline.Run(ctx, state); err != nil {
+		summary := agents.BuildRunSummary(state, services.Models.Usage().Snapshot(), time.Since(start), err)
+		history.Record(ctx, state, summary)
+		services.RecordRun(ctx, "agent", map[string]int64{"results": int64(len(state.Results))}, err)
+		if opts.JSON {
+			if encErr := emitJSON(os.Stdout, opts, state, summary, err); encErr != nil {
+				logger.ErrorContext(ctx, "failed to write JSON output", "err", encErr)
+			}
+		} else {
+			reportFailure(out, err, state.Results)
+			summary.Render(out)
+			if logger.Enabled(ctx, slog.LevelDebug) {
+				summary.RenderBreakdown(out)
+			}
+		}
+		return fmt.Errorf("agent run failed: %w", err)
 	}
 
-	// Get nearest neighbors from environment or use default
-	nearestNeighbors := 5
-	if nnStr := os.Getenv("NEAREST_NEIGHBORS"); nnStr != "" {
-		if nn, err := strconv.Atoi(nnStr); err == nil {
-			nearestNeighbors = nn
+	summary := agents.BuildRunSummary(state, services.Models.Usage().Snapshot(), time.Since(start), nil)
+	history.Record(ctx, state, summary)
+	services.RecordRun(ctx, "agent", map[string]int64{"results": int64(len(state.Results))}, nil)
+	if opts.JSON {
+		if err := emitJSON(os.Stdout, opts, state, summary, nil); err != nil {
+			return fmt.Errorf("failed to write JSON output: %w", err)
 		}
+		return nil
 	}
 
-	fmt.Printf("\nQuery: %s\n", query)
-	fmt.Printf("Nearest Neighbors: %d\n", nearestNeighbors)
+	// Display final answer
+	fmt.Fprintln(out, "\n--- FINAL ANSWER ---")
+	fmt.Fprintln(os.Stdout, state.Answer)
+	// Offline runs aren't stored anywhere feedback could refer to
+	if !services.Offline {
+		fmt.Fprintf(out, "\nResult: %s (rate it with: go run ./cmd/feedback %s up|down)\n", state.ResultID, state.ResultID)
+	}
 
-	// Run planner agent
-	hotelContext, err := plannerAgent.Run(ctx, query, nearestNeighbors)
-	if err != nil {
-		log.Fatalf("Planner agent failed: %v", err)
+	summary.Render(out)
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		summary.RenderBreakdown(out)
 	}
 
-	if debug {
-		fmt.Printf("\n--- HOTEL CONTEXT ---\n%s\n", hotelContext)
+	if opts.Output != "" {
+		if err := writeOutputFile(opts.Output, []byte(state.Answer+"\n"), opts.Force); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "\nAnswer saved to %s\n", opts.Output)
 	}
+	return nil
+}
 
-	// Run synthesizer agent
-	finalAnswer, err := synthesizerAgent.Run(ctx, query, hotelContext)
+// emitJSON prints the JSON document to stdout and, with --output, saves the same bytes
+func emitJSON(stdout io.Writer, opts *options, state *agents.PipelineState, summary agents.RunSummary, runErr error) error {
+	data, err := encodeJSON(state, summary, runErr)
 	if err != nil {
-		log.Fatalf("Synthesizer agent failed: %v", err)
+		return err
+	}
+	if _, err := stdout.Write(data); err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		return nil
 	}
+	return writeOutputFile(opts.Output, data, opts.Force)
+}
 
-	// Display final answer
-	fmt.Println("\n--- FINAL ANSWER ---")
-	fmt.Println(finalAnswer)
+// reportFailure prints the stage that was in progress when the run timed out and
+// any results gathered before it
+func reportFailure(w io.Writer, err error, partial []models.HotelSearchResult) {
+	var stageErr *agents.StageError
+	if !errors.As(err, &stageErr) || !stageErr.TimedOut() {
+		return
+	}
+
+	fmt.Fprintf(w, "\n--- TIMED OUT ---\n")
+	fmt.Fprintf(w, "Stage in progress: %s\n", stageErr.Stage)
+
+	if len(partial) == 0 {
+		fmt.Fprintln(w, "No results were gathered before the timeout")
+		return
+	}
+
+	fmt.Fprintln(w, "Partial results gathered before the timeout:")
+	for i, result := range partial {
+		fmt.Fprintf(w, "Hotel #%d: %s, Score: %.6f\n", i+1, result.Hotel.HotelName, result.Score)
+	}
 }
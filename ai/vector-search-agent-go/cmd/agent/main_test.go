@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+)
+
+// agentProcessEnv marks the test binary's child process that runs the agent
+const agentProcessEnv = "AGENT_TEST_PROCESS"
+
+// TestAgentProcess runs the agent's main when the test binary is started by runAgent
+func TestAgentProcess(t *testing.T) {
+	if os.Getenv(agentProcessEnv) != "1" {
+		t.Skip("runs only as the child process of runAgent")
+	}
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	os.Args = append([]string{"agent"}, args...)
+	main()
+}
+
+// runAgent runs the agent in offline mode with args in a child process and returns
+// what it wrote to stdout and stderr
+func runAgent(t *testing.T, args ...string) (string, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^TestAgentProcess$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(),
+		agentProcessEnv+"=1",
+		"OFFLINE_MODE=true",
+		"DATA_FILE_WITHOUT_VECTORS=../../../data/Hotels.json",
+		"AZD_ENV_NAME=",
+		"LOG_LEVEL=trace",
+		// The agent checks itself for leaked goroutines on exit and fails if it finds any
+		"LEAK_CHECK=true",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("agent failed: %v\nstderr:\n%s", err, stderr.String())
+	}
+	return stdout.String(), stderr.String()
+}
+
+func TestStdoutCarriesOnlyTheResult(t *testing.T) {
+	const query = "quiet hotel near the beach with a pool"
+
+	t.Run("answer", func(t *testing.T) {
+		stdout, stderr := runAgent(t, "--k", "3", query)
+
+		// stdout is the offline model's answer and nothing else
+		if !strings.HasPrefix(stdout, offline.AnswerPrefix) || strings.Contains(stdout, "\n---") || strings.Contains(stdout, "level=") {
+			t.Errorf("stdout is not just the answer:\n%s", stdout)
+		}
+		for _, want := range []string{"Session:", "--- PLANNER ---", "Hotel #1:", "--- SYNTHESIZER ---", "--- FINAL ANSWER ---", "--- RUN SUMMARY ---", "level=TRACE"} {
+			if strings.Contains(stdout, want) {
+				t.Errorf("stdout contains %q:\n%s", want, stdout)
+			}
+			if !strings.Contains(stderr, want) {
+				t.Errorf("stderr is missing %q", want)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		stdout, stderr := runAgent(t, "--json", "--k", "3", query)
+
+		// stdout is exactly one JSON document
+		dec := json.NewDecoder(strings.NewReader(stdout))
+		var envelope map[string]any
+		if err := dec.Decode(&envelope); err != nil {
+			t.Fatalf("stdout is not a JSON document: %v\n%s", err, stdout)
+		}
+		if dec.More() {
+			t.Errorf("stdout has more than the JSON document:\n%s", stdout)
+		}
+		if answer, _ := envelope["answer"].(string); !strings.HasPrefix(answer, offline.AnswerPrefix) {
+			t.Errorf("envelope answer = %q, want the offline model's answer", answer)
+		}
+		if !strings.Contains(stderr, "--- PLANNER ---") {
+			t.Errorf("stderr is missing the progress output:\n%s", stderr)
+		}
+	})
+}
+
+func TestWarmUpBeforeRun(t *testing.T) {
+	stdout, stderr := runAgent(t, "--warmup", "3", "--k", "3", "quiet hotel near the beach")
+
+	if !strings.Contains(stderr, "Warming up the vector index (3 searches)") || !strings.Contains(stderr, "vector index warmed up") {
+		t.Errorf("stderr is missing the warm-up:\n%s", stderr)
+	}
+	if !strings.HasPrefix(stdout, offline.AnswerPrefix) {
+		t.Errorf("stdout = %q, want the answer after the warm-up", stdout)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	defaultQuery = "quintessential lodging near running trails, eateries, retail"
+	defaultK     = 5
+)
+
+// options holds the resolved cmd/agent settings. Flags take precedence over
+// environment variables, which take precedence over the defaults. The query can
+// also come from positional arguments or stdin; see resolveQuery.
+type options struct {
+	Query      string
+	K          int
+	Debug      bool
+	Verbosity  cli.Verbosity
+	ConfigFile string
+	JSON       bool
+	// Output is a file that also receives the answer, or the JSON document with --json
+	Output    string
+	Force     bool
+	Timeout   time.Duration
+	SessionID string
+	// Warmup is the number of throwaway searches issued before the run
+	Warmup int
+}
+
+// parseOptions resolves options from command-line arguments, stdin, and environment variables.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, stdin stdinSource, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	defaults, err := envDefaults(getenv)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return nil, err
+	}
+	opts := *defaults
+
+	fs.StringVar(&opts.Query, "query", opts.Query, "Hotel search query (env QUERY)")
+	fs.StringVar(&opts.Query, "q", opts.Query, "Shorthand for --query")
+	fs.IntVar(&opts.K, "k", opts.K, fmt.Sprintf("Number of nearest neighbors to retrieve, %d-%d; values outside are clamped (env NEAREST_NEIGHBORS)", vectorstore.MinK, vectorstore.MaxK()))
+	fs.BoolVar(&opts.Debug, "debug", opts.Debug, "Enable debug output, same as -vv (env DEBUG)")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+	fs.BoolVar(&opts.JSON, "json", opts.JSON, "Print the result as JSON instead of text")
+	fs.StringVar(&opts.Output, "output", "", "Also save the final answer, or the JSON document with --json, to this file; parent directories are created")
+	fs.BoolVar(&opts.Force, "force", false, "Allow --output to replace an existing file")
+	fs.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "Deadline for the whole agent run, e.g. 90s or 5m; 0 uses the run budget (env RUN_TIMEOUT)")
+	fs.StringVar(&opts.SessionID, "session", opts.SessionID, "Session ID for logs and results; a UUID is generated when empty (env SESSION_ID)")
+	fs.IntVar(&opts.Warmup, "warmup", opts.Warmup, "Throwaway searches issued before the run to warm up the vector index (env WARMUP_SEARCHES)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: agent [flags] [query ...]\n\n")
+		fmt.Fprintf(output, "Runs the planner and synthesizer agents for a hotel search query.\n")
+		fmt.Fprintf(output, "Flags take precedence over the environment variables shown in parentheses.\n\n")
+		fmt.Fprintf(output, "The query is taken from the first of: --query, positional arguments (joined with\n")
+		fmt.Fprintf(output, "spaces), stdin (when the only argument is \"-\" or stdin is piped), QUERY, the default.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	flagSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "query" || f.Name == "q" {
+			flagSet = true
+		}
+	})
+
+	query, err := resolveQuery(opts.Query, flagSet, fs.Args(), stdin, defaults.Query)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+	opts.Query = query
+
+	if opts.Debug && opts.Verbosity < 2 {
+		opts.Verbosity = 2
+	}
+
+	opts.K = vectorstore.ClampK(context.Background(), opts.K)
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// envDefaults reads the environment fallbacks for every flag
+func envDefaults(getenv func(string) string) (*options, error) {
+	opts := &options{
+		Query:     defaultQuery,
+		K:         defaultK,
+		SessionID: getenv("SESSION_ID"),
+	}
+
+	if query := getenv("QUERY"); query != "" {
+		opts.Query = query
+	}
+
+	if nnStr := getenv("NEAREST_NEIGHBORS"); nnStr != "" {
+		nn, err := strconv.Atoi(nnStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NEAREST_NEIGHBORS %q: must be an integer", nnStr)
+		}
+		opts.K = nn
+	}
+
+	if warmupStr := getenv("WARMUP_SEARCHES"); warmupStr != "" {
+		warmup, err := strconv.Atoi(warmupStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WARMUP_SEARCHES %q: must be an integer", warmupStr)
+		}
+		opts.Warmup = warmup
+	}
+
+	return opts, nil
+}
+
+// validate checks the resolved values
+func (o *options) validate() error {
+	if o.Timeout < 0 {
+		return errors.New("invalid timeout: must not be negative")
+	}
+	if o.Force && o.Output == "" {
+		return errors.New("--force can only be used with --output")
+	}
+	if o.Warmup < 0 {
+		return errors.New("invalid warmup: must not be negative")
+	}
+	return nil
+}
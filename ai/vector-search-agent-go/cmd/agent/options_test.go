@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a getenv function over a fixed set of variables
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestParseOptionsPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want options
+	}{
+		{
+			name: "defaults",
+			want: options{Query: defaultQuery, K: defaultK},
+		},
+		{
+			name: "env fallbacks",
+			env:  map[string]string{"QUERY": "pet friendly", "NEAREST_NEIGHBORS": "8", "DEBUG": "true", "SESSION_ID": "s-env"},
+			// DEBUG is resolved with LOG_LEVEL when logging is set up, not as a flag default
+			want: options{Query: "pet friendly", K: 8, SessionID: "s-env"},
+		},
+		{
+			name: "flags win over env",
+			args: []string{"-q", "beach hotel", "--k", "3", "-v", "--json", "--timeout", "2m", "--session", "s-flag"},
+			env:  map[string]string{"QUERY": "pet friendly", "NEAREST_NEIGHBORS": "8", "DEBUG": "1", "SESSION_ID": "s-env"},
+			want: options{Query: "beach hotel", K: 3, Verbosity: 1, JSON: true, Timeout: 2 * time.Minute, SessionID: "s-flag"},
+		},
+		{
+			name: "debug flag is -vv",
+			args: []string{"--debug"},
+			want: options{Query: defaultQuery, K: defaultK, Debug: true, Verbosity: 2},
+		},
+		{
+			name: "-vvv",
+			args: []string{"-vvv"},
+			want: options{Query: defaultQuery, K: defaultK, Verbosity: 3},
+		},
+		{
+			name: "long query flag",
+			args: []string{"--query", "spa resort"},
+			want: options{Query: "spa resort", K: defaultK},
+		},
+		{
+			name: "output with force",
+			args: []string{"--output", "answers/pool.txt", "--force"},
+			want: options{Query: defaultQuery, K: defaultK, Output: "answers/pool.txt", Force: true},
+		},
+		{
+			name: "flag fixes invalid env k",
+			args: []string{"--k", "20"},
+			env:  map[string]string{"NEAREST_NEIGHBORS": "50"},
+			want: options{Query: defaultQuery, K: 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, env(tt.env), stdinSource{}, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if *got != tt.want {
+				t.Errorf("options = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsClampsK(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		max  string
+		want int
+	}{
+		{"zero", []string{"--k", "0"}, nil, "", 1},
+		{"negative", []string{"--k", "-4"}, nil, "", 1},
+		{"above the maximum", []string{"--k", "21"}, nil, "", 20},
+		{"env above the maximum", nil, map[string]string{"NEAREST_NEIGHBORS": "50"}, "", 20},
+		{"raised maximum", []string{"--k", "50"}, nil, "100", 50},
+		{"lowered maximum", []string{"--k", "8"}, nil, "5", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAX_NEAREST_NEIGHBORS", tt.max)
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, env(tt.env), stdinSource{}, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if got.K != tt.want {
+				t.Errorf("k = %d, want %d", got.K, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsUsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"non-integer k", []string{"--k", "2.5"}, nil, "invalid value \"2.5\""},
+		{"non-integer env k", nil, map[string]string{"NEAREST_NEIGHBORS": "many"}, "invalid NEAREST_NEIGHBORS"},
+		{"non-duration timeout", []string{"--timeout", "soon"}, nil, "invalid value \"soon\""},
+		{"negative timeout", []string{"--timeout", "-1s"}, nil, "invalid timeout"},
+		{"non-integer env warmup", nil, map[string]string{"WARMUP_SEARCHES": "some"}, "invalid WARMUP_SEARCHES"},
+		{"negative warmup", []string{"--warmup", "-1"}, nil, "invalid warmup"},
+		{"unknown flag", []string{"--verbose"}, nil, "flag provided but not defined"},
+		{"force without output", []string{"--force"}, nil, "--force can only be used with --output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, env(tt.env), stdinSource{}, &out); err == nil || errors.Is(err, flag.ErrHelp) {
+				t.Fatalf("parseOptions error = %v, want a usage error", err)
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output does not report %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestParseOptionsHelp(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := parseOptions([]string{"--help"}, env(nil), stdinSource{}, &out); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("err = %v, want flag.ErrHelp", err)
+	}
+	for _, want := range []string{"-query", "-k", "-json", "-timeout", "-session", "env QUERY", "env NEAREST_NEIGHBORS", "env RUN_TIMEOUT", "env SESSION_ID"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("help is missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// errOutputExists is returned when --output names an existing file and --force is not set
+var errOutputExists = errors.New("output file already exists; pass --force to overwrite it")
+
+// checkOutputPath fails early when path exists and may not be overwritten, so a run
+// isn't wasted on a result that can't be saved
+func checkOutputPath(path string, force bool) error {
+	if force {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s: %w", path, errOutputExists)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to check output file: %w", err)
+	}
+	return nil
+}
+
+// writeOutputFile writes data to a temporary file next to path and renames it into
+// place, creating parent directories as needed. Readers never see a partial file.
+// Unless force is set, it refuses to replace an existing file.
+func writeOutputFile(path string, data []byte, force bool) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := checkOutputPath(path, force); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// CreateTemp uses 0600; saved answers are meant to be shared
+	if err := tmp.Chmod(0o644); err != nil {
+		return fmt.Errorf("failed to set output file permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if force {
+		return renameInto(tmp.Name(), path)
+	}
+	// Link fails if path appeared since the check, so a concurrent writer's file survives
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s: %w", path, errOutputExists)
+		}
+		// Some file systems don't support hard links; fall back to a plain rename
+		return renameInto(tmp.Name(), path)
+	}
+	return nil
+}
+
+// renameInto moves the finished temporary file to path
+func renameInto(tmp, path string) error {
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to move output file into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+)
+
+// readOnlyFile returns the contents of the single file in dir, failing if a temporary
+// file was left behind
+func readOnlyFile(t *testing.T, dir string) (string, []byte) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Fatalf("directory holds %v, want a single file", names)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries[0].Name(), data
+}
+
+// onlyEntry returns the name of the single entry in dir, or "" when there are others
+func onlyEntry(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		return ""
+	}
+	return entries[0].Name()
+}
+
+func TestWriteOutputFileCreatesDirectories(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "answers", "2025")
+	path := filepath.Join(dir, "pool.txt")
+
+	if err := writeOutputFile(path, []byte("Try Ocean Retreat.\n"), false); err != nil {
+		t.Fatal(err)
+	}
+	name, data := readOnlyFile(t, dir)
+	if name != "pool.txt" || string(data) != "Try Ocean Retreat.\n" {
+		t.Errorf("wrote %s = %q", name, data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o644 {
+		t.Errorf("permissions = %o, want 644", perm)
+	}
+}
+
+func TestWriteOutputFileOverwriteGuard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "answer.txt")
+	if err := os.WriteFile(path, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkOutputPath(path, false); !errors.Is(err, errOutputExists) {
+		t.Errorf("checkOutputPath = %v, want errOutputExists", err)
+	}
+	if err := writeOutputFile(path, []byte("replacement"), false); !errors.Is(err, errOutputExists) {
+		t.Fatalf("writeOutputFile = %v, want errOutputExists", err)
+	}
+	// The refused write leaves the original untouched and no temporary file behind
+	if _, data := readOnlyFile(t, dir); string(data) != "original" {
+		t.Errorf("contents = %q, want the original", data)
+	}
+
+	if err := checkOutputPath(path, true); err != nil {
+		t.Errorf("checkOutputPath with force = %v", err)
+	}
+	if err := writeOutputFile(path, []byte("replacement"), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, data := readOnlyFile(t, dir); string(data) != "replacement" {
+		t.Errorf("contents = %q, want the replacement", data)
+	}
+}
+
+func TestWriteOutputFileIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	// The destination is a non-empty directory, so moving the finished file into place fails
+	path := filepath.Join(dir, "answer.txt")
+	if err := os.MkdirAll(filepath.Join(path, "keep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeOutputFile(path, []byte("replacement"), true); err == nil {
+		t.Fatal("writeOutputFile replaced a directory")
+	}
+	// Neither the destination nor a temporary file shows a partial write
+	if name := onlyEntry(t, dir); name != "answer.txt" {
+		t.Errorf("directory holds %q, want only the original destination", name)
+	}
+	if _, err := os.Stat(filepath.Join(path, "keep")); err != nil {
+		t.Errorf("destination was modified: %v", err)
+	}
+}
+
+func TestCheckOutputPathMissingFile(t *testing.T) {
+	if err := checkOutputPath(filepath.Join(t.TempDir(), "missing", "answer.txt"), false); err != nil {
+		t.Errorf("checkOutputPath = %v, want nil for a new file", err)
+	}
+}
+
+func TestEmitJSONSavesSameDocument(t *testing.T) {
+	dir := t.TempDir()
+	opts := &options{Output: filepath.Join(dir, "result.json")}
+	state := agents.NewPipelineState("quiet hotel", 2)
+	state.Answer = "Try Ocean Retreat."
+
+	var stdout bytes.Buffer
+	if err := emitJSON(&stdout, opts, state, agents.RunSummary{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, saved := readOnlyFile(t, dir)
+	if stdout.Len() == 0 || !bytes.Equal(saved, stdout.Bytes()) {
+		t.Errorf("saved %q, printed %q, want the same document", saved, stdout.String())
+	}
+
+	// Without --output only stdout receives the document
+	stdout.Reset()
+	if err := emitJSON(&stdout, &options{}, state, agents.RunSummary{}, nil); err != nil || stdout.Len() == 0 {
+		t.Errorf("emitJSON = %v, printed %q", err, stdout.String())
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/results"
+)
+
+// encodeJSON encodes the run as the shared result envelope printed by --json
+func encodeJSON(state *agents.PipelineState, summary agents.RunSummary, runErr error) ([]byte, error) {
+	return results.MarshalIndent(results.New(state, summary, runErr))
+}
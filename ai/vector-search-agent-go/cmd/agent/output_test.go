@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/results"
+)
+
+func TestEncodeJSON(t *testing.T) {
+	state := agents.NewPipelineState("quiet hotel", 2)
+	state.SessionID = "session-1"
+	state.Answer = "Try Ocean Retreat."
+	state.Results = []models.HotelSearchResult{
+		{Hotel: models.HotelForVectorStore{HotelID: "7", HotelName: "Ocean Retreat"}, Score: 0.91},
+		{Hotel: models.HotelForVectorStore{HotelID: "12", HotelName: "City Inn"}, Score: 0.77},
+	}
+	state.Citations = agents.BuildCitations(state.Answer, state.Results)
+
+	data, err := encodeJSON(state, agents.RunSummary{Total: time.Second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(data, []byte("}\n")) || bytes.Count(data, []byte("\n")) < 2 {
+		t.Errorf("output is not indented JSON ending in a newline:\n%s", data)
+	}
+
+	got, err := results.Decode(data)
+	if err != nil {
+		t.Fatalf("output is not a result envelope: %v\n%s", err, data)
+	}
+	if got.SchemaVersion != results.SchemaVersion || got.SessionID != "session-1" || got.ResultID != state.ResultID || got.K != 2 || got.Answer != state.Answer || got.Error != nil {
+		t.Errorf("output = %+v", got)
+	}
+	if len(got.Results) != 2 || got.Results[1].Rank != 2 || got.Results[1].HotelID != "12" {
+		t.Errorf("results = %+v, want ranked hotels", got.Results)
+	}
+	if len(got.Citations) != 1 || got.Citations[0].HotelID != "7" {
+		t.Errorf("citations = %+v, want Ocean Retreat", got.Citations)
+	}
+}
+
+func TestEncodeJSONFailedRun(t *testing.T) {
+	runErr := &agents.StageError{Stage: "planner", Err: errors.New("content filtered")}
+	data, err := encodeJSON(agents.NewPipelineState("quiet hotel", 2), agents.RunSummary{}, runErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := results.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Error == nil || got.Error.Stage != "planner" || got.Error.Message != runErr.Error() {
+		t.Errorf("error = %+v, want the planner failure", got.Error)
+	}
+	if !bytes.Contains(data, []byte(`"citations": []`)) || !bytes.Contains(data, []byte(`"results": []`)) {
+		t.Errorf("empty lists should encode as [], got:\n%s", data)
+	}
+}
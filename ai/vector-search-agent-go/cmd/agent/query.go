@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxStdinQueryBytes bounds how much of stdin is read as a query
+const maxStdinQueryBytes = 64 << 10
+
+// stdinSource is where a query can be read from when none is given on the command line.
+// Piped is true when stdin is a pipe or file rather than a terminal.
+type stdinSource struct {
+	Reader io.Reader
+	Piped  bool
+}
+
+// osStdin describes the process's stdin
+func osStdin() stdinSource {
+	info, err := os.Stdin.Stat()
+	piped := err == nil && info.Mode()&os.ModeCharDevice == 0
+	return stdinSource{Reader: os.Stdin, Piped: piped}
+}
+
+// resolveQuery picks the query by precedence: --query flag, positional arguments,
+// stdin ("-" or piped), then fallback, which already holds QUERY or the default.
+// Empty or whitespace-only input from any source is an error.
+func resolveQuery(flagQuery string, flagSet bool, positional []string, stdin stdinSource, fallback string) (string, error) {
+	var query, source string
+	switch {
+	case flagSet:
+		query, source = flagQuery, "--query"
+	case len(positional) == 1 && positional[0] == "-":
+		q, err := readStdinQuery(stdin.Reader)
+		if err != nil {
+			return "", err
+		}
+		query, source = q, "stdin"
+	case len(positional) > 0:
+		query, source = strings.Join(positional, " "), "arguments"
+	case stdin.Piped:
+		q, err := readStdinQuery(stdin.Reader)
+		if err != nil {
+			return "", err
+		}
+		query, source = q, "stdin"
+	default:
+		query, source = fallback, "QUERY"
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("empty query from %s", source)
+	}
+	return query, nil
+}
+
+// readStdinQuery reads the whole of r as one query
+func readStdinQuery(r io.Reader) (string, error) {
+	if r == nil {
+		return "", errors.New("no stdin to read the query from")
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxStdinQueryBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read query from stdin: %w", err)
+	}
+	if len(data) > maxStdinQueryBytes {
+		return "", fmt.Errorf("query from stdin is longer than %d bytes", maxStdinQueryBytes)
+	}
+	return string(data), nil
+}
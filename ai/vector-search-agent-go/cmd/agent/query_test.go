@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func piped(s string) stdinSource {
+	return stdinSource{Reader: strings.NewReader(s), Piped: true}
+}
+
+func TestResolveQueryPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin stdinSource
+		env   map[string]string
+		want  string
+	}{
+		{"default", nil, stdinSource{}, nil, defaultQuery},
+		{"env", nil, stdinSource{}, map[string]string{"QUERY": "pet friendly"}, "pet friendly"},
+		{"piped stdin over env", nil, piped("quiet hotel\n"), map[string]string{"QUERY": "pet friendly"}, "quiet hotel"},
+		{"multi-line stdin", nil, piped("hotel with\na rooftop bar\n"), nil, "hotel with\na rooftop bar"},
+		{"positional over stdin", []string{"beach", "resort"}, piped("quiet hotel"), nil, "beach resort"},
+		{"positional with shell characters", []string{"hotel $5 & 'cheap'"}, stdinSource{}, nil, "hotel $5 & 'cheap'"},
+		{"dash reads stdin", []string{"-"}, stdinSource{Reader: strings.NewReader("from a terminal")}, nil, "from a terminal"},
+		{"flag over positional", []string{"--query", "spa", "beach", "resort"}, piped("quiet hotel"), map[string]string{"QUERY": "pet friendly"}, "spa"},
+		{"flags before positional", []string{"--k", "3", "downtown", "loft"}, stdinSource{}, nil, "downtown loft"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions(tt.args, env(tt.env), tt.stdin, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if opts.Query != tt.want {
+				t.Errorf("query = %q, want %q", opts.Query, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveQueryEmptyInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin stdinSource
+		env   map[string]string
+		want  string
+	}{
+		{"empty flag", []string{"--query", "  "}, stdinSource{}, nil, "empty query from --query"},
+		{"blank positional", []string{" ", "\t"}, stdinSource{}, nil, "empty query from arguments"},
+		{"empty pipe", nil, piped(""), nil, "empty query from stdin"},
+		{"whitespace pipe", nil, piped(" \n\n"), nil, "empty query from stdin"},
+		{"dash without stdin", []string{"-"}, stdinSource{}, nil, "no stdin to read the query from"},
+		{"blank env", nil, stdinSource{}, map[string]string{"QUERY": "   "}, "empty query from QUERY"},
+		{"read error", nil, stdinSource{Reader: iotest.ErrReader(iotest.ErrTimeout), Piped: true}, nil, "failed to read query from stdin"},
+		{"oversized stdin", nil, piped(strings.Repeat("a", maxStdinQueryBytes+1)), nil, "longer than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, env(tt.env), tt.stdin, &out); err == nil {
+				t.Fatal("parseOptions accepted an empty query")
+			}
+			if !strings.Contains(out.String(), tt.want) || !strings.Contains(out.String(), "Usage: agent") {
+				t.Errorf("output does not report %q with the usage:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/results"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// runner runs the agent pipeline over a state
+type runner interface {
+	Run(ctx context.Context, state *agents.PipelineState) error
+}
+
+// record is one line of the JSONL output: the shared result envelope plus the
+// query's ID and position in the input
+type record struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+	results.RunResult
+}
+
+// batch runs queries through the pipeline with bounded concurrency
+type batch struct {
+	pipeline    runner
+	history     *agents.HistoryWriter // nil unless HISTORY_ENABLED is set
+	concurrency int
+	timeout     time.Duration
+	// ephemeralDatabase is reported in every record when EPHEMERAL_DATABASE is set
+	ephemeralDatabase string
+}
+
+// run executes every query and writes one record per query to w, in input order.
+// A failing query is recorded with its error and does not stop the batch. It returns
+// the number of failed queries.
+func (b *batch) run(ctx context.Context, queries []batchQuery, w io.Writer, progress func(record)) (int, error) {
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range queries {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	records := make(chan record)
+	var wg sync.WaitGroup
+	for range max(b.concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				records <- b.runOne(ctx, i, queries[i])
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(records)
+	}()
+
+	// Records finish out of order; buffer them so the output follows the input
+	enc := json.NewEncoder(w)
+	pending := make(map[int]record)
+	next, failed := 0, 0
+	var writeErr error
+	for rec := range records {
+		if rec.Error != nil {
+			failed++
+		}
+		if progress != nil {
+			progress(rec)
+		}
+		pending[rec.Index] = rec
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if writeErr == nil {
+				if err := enc.Encode(r); err != nil {
+					writeErr = fmt.Errorf("failed to write record: %w", err)
+				}
+			}
+		}
+	}
+
+	if writeErr != nil {
+		return failed, writeErr
+	}
+	return failed, ctx.Err()
+}
+
+// runOne runs a single query under its own deadline, session, request ID, and usage
+// tracker
+func (b *batch) runOne(ctx context.Context, index int, q batchQuery) record {
+	usage := clients.NewUsageTracker()
+	ctx = session.WithID(ctx, q.ID)
+	ctx = session.WithRequestID(ctx, session.NewRequestID())
+	ctx = clients.WithUsageTracker(ctx, usage)
+	ctx, cancel := timeouts.WithBudget(ctx, timeouts.Budget{Stage: "query " + q.ID, Timeout: b.timeout, Setting: "--timeout"})
+	defer cancel()
+
+	start := time.Now()
+	state := agents.NewPipelineState(q.Query, q.K)
+	state.EphemeralDatabase = b.ephemeralDatabase
+	err := b.pipeline.Run(ctx, state)
+	elapsed := time.Since(start)
+	summary := agents.BuildRunSummary(state, usage.Snapshot(), elapsed, err)
+	b.history.Record(ctx, state, summary)
+
+	return record{ID: q.ID, Index: index, RunResult: results.New(state, summary, err)}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/results"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+)
+
+// fakeRunner answers each query from a fixed set of hotels. Queries containing
+// "fail" fail in the synthesizer stage; queries containing "slow" wait before answering.
+type fakeRunner struct {
+	mu          sync.Mutex
+	sessions    []string
+	inFlight    int
+	maxInFlight int
+	delay       time.Duration
+	afterRun    func(query string)
+}
+
+func (f *fakeRunner) Run(ctx context.Context, state *agents.PipelineState) error {
+	f.mu.Lock()
+	f.sessions = append(f.sessions, session.FromContext(ctx))
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.Contains(state.Query, "slow") {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return &agents.StageError{Stage: "synthesizer", Err: ctx.Err()}
+		}
+	}
+
+	state.SearchQuery = "refined " + state.Query
+	for i := range state.NearestNeighbors {
+		hotel := models.HotelForVectorStore{HotelID: string(rune('1' + i))}
+		state.Results = append(state.Results, models.HotelSearchResult{Hotel: hotel, Score: 0.9 - float64(i)/10})
+	}
+
+	if strings.Contains(state.Query, "fail") {
+		return &agents.StageError{Stage: "synthesizer", Err: errors.New("content filtered")}
+	}
+	state.Answer = "Answer to " + state.Query
+	if f.afterRun != nil {
+		f.afterRun(state.Query)
+	}
+	return nil
+}
+
+// memoryHistory is an in-memory history store
+type memoryHistory struct {
+	mu      sync.Mutex
+	records []models.HistoryRecord
+}
+
+func (m *memoryHistory) InsertHistory(ctx context.Context, record models.HistoryRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+	return nil
+}
+
+// readRecords decodes the JSONL output
+func readRecords(t *testing.T, out *bytes.Buffer) []record {
+	t.Helper()
+	var records []record
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestBatchRunWritesRecordPerQuery(t *testing.T) {
+	runner := &fakeRunner{delay: 20 * time.Millisecond}
+	b := &batch{pipeline: runner, concurrency: 2, timeout: time.Second}
+	queries := []batchQuery{
+		{ID: "q1", Query: "slow pool hotel", K: 2},
+		{ID: "q2", Query: "fail please", K: 1},
+		{ID: "q3", Query: "beach resort", K: 3},
+	}
+
+	var out bytes.Buffer
+	var progressed []string
+	failed, err := b.run(context.Background(), queries, &out, func(rec record) {
+		progressed = append(progressed, rec.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	if len(progressed) != 3 {
+		t.Errorf("progress reported %v, want every query", progressed)
+	}
+
+	records := readRecords(t, &out)
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	// Records follow the input order even though q1 finishes last
+	for i, rec := range records {
+		q := queries[i]
+		if rec.ID != q.ID || rec.Index != i || rec.Query != q.Query || rec.K != q.K {
+			t.Errorf("record %d = %+v, want query %+v", i, rec, q)
+		}
+		if len(rec.Results) != q.K || rec.Results[0].HotelID != "1" || rec.Results[0].Score != 0.9 {
+			t.Errorf("record %s results = %+v", rec.ID, rec.Results)
+		}
+		if rec.SearchQuery != "refined "+q.Query || rec.Usage == nil {
+			t.Errorf("record %s = %+v", rec.ID, rec)
+		}
+	}
+	if records[0].Answer != "Answer to slow pool hotel" || records[0].Error != nil || records[0].Durations.TotalMs < 20 {
+		t.Errorf("slow record = %+v", records[0])
+	}
+
+	// A failing query is recorded with its error and does not stop the batch
+	failedRec := records[1]
+	if failedRec.Answer != "" || failedRec.Error == nil || failedRec.Error.Stage != "synthesizer" || failedRec.Error.TimedOut || !strings.Contains(failedRec.Error.Message, "content filtered") {
+		t.Errorf("failed record = %+v", failedRec)
+	}
+	if records[2].Answer != "Answer to beach resort" {
+		t.Errorf("record after a failure = %+v", records[2])
+	}
+
+	// Each query runs in its own session, named after its ID
+	if strings.Join(slices.Sorted(slices.Values(runner.sessions)), ",") != "q1,q2,q3" {
+		t.Errorf("sessions = %v", runner.sessions)
+	}
+}
+
+func TestBatchRecordsAreResultEnvelopes(t *testing.T) {
+	b := &batch{pipeline: &fakeRunner{}, concurrency: 1, timeout: time.Second}
+	var out bytes.Buffer
+	if _, err := b.run(context.Background(), []batchQuery{{ID: "q1", Query: "pool", K: 2}}, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each line decodes as the envelope cmd/agent --json and POST /chat return
+	line := bytes.TrimSpace(out.Bytes())
+	got, err := results.Decode(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := readRecords(t, bytes.NewBuffer(line))
+	if !reflect.DeepEqual(got, records[0].RunResult) || got.SchemaVersion != results.SchemaVersion || got.K != 2 {
+		t.Errorf("decoded envelope = %+v, want %+v", got, records[0].RunResult)
+	}
+}
+
+func TestBatchRunRecordsTimeouts(t *testing.T) {
+	b := &batch{pipeline: &fakeRunner{delay: time.Hour}, concurrency: 1, timeout: 10 * time.Millisecond}
+
+	var out bytes.Buffer
+	failed, err := b.run(context.Background(), []batchQuery{{ID: "q1", Query: "slow", K: 1}}, &out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := readRecords(t, &out)
+	if failed != 1 || len(records) != 1 || records[0].Error == nil || !records[0].Error.TimedOut || records[0].Error.Stage != "synthesizer" {
+		t.Errorf("failed %d, records %+v, want one timed-out record", failed, records)
+	}
+}
+
+func TestBatchRunBoundsConcurrency(t *testing.T) {
+	runner := &fakeRunner{delay: 5 * time.Millisecond}
+	b := &batch{pipeline: runner, concurrency: 3, timeout: time.Second}
+
+	queries := make([]batchQuery, 12)
+	for i := range queries {
+		queries[i] = batchQuery{ID: string(rune('a' + i)), Query: "slow", K: 1}
+	}
+	if _, err := b.run(context.Background(), queries, &bytes.Buffer{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if runner.maxInFlight > 3 || runner.maxInFlight < 2 {
+		t.Errorf("max in flight = %d, want 2-3", runner.maxInFlight)
+	}
+}
+
+func TestBatchRunStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := &fakeRunner{afterRun: func(query string) {
+		if query == "a" {
+			cancel()
+		}
+	}}
+	b := &batch{pipeline: runner, concurrency: 1, timeout: time.Second}
+
+	queries := []batchQuery{{ID: "q1", Query: "a", K: 1}, {ID: "q2", Query: "b", K: 1}, {ID: "q3", Query: "c", K: 1}}
+	var out bytes.Buffer
+	_, err := b.run(ctx, queries, &out, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	// Records finished before the cancellation are still written; queries already
+	// handed to a worker are recorded as cancelled
+	records := readRecords(t, &out)
+	if len(records) == 0 || records[0].ID != "q1" || records[0].Answer != "Answer to a" {
+		t.Fatalf("records = %+v, want q1 answered", records)
+	}
+	for _, rec := range records[1:] {
+		if rec.Error == nil || !strings.Contains(rec.Error.Message, "context canceled") {
+			t.Errorf("record %s after cancellation = %+v", rec.ID, rec)
+		}
+	}
+}
+
+func TestBatchRunRecordsHistory(t *testing.T) {
+	store := &memoryHistory{}
+	b := &batch{pipeline: &fakeRunner{}, history: agents.NewHistoryWriter(store), concurrency: 2, timeout: time.Second}
+
+	queries := []batchQuery{{ID: "q1", Query: "pool", K: 1}, {ID: "q2", Query: "fail please", K: 1}}
+	if _, err := b.run(context.Background(), queries, &bytes.Buffer{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Failed queries are recorded too, with their error
+	var got []string
+	for _, rec := range store.records {
+		got = append(got, rec.Query+"|"+rec.Error)
+	}
+	slices.Sort(got)
+	want := []string{"fail please|synthesizer stage failed: content filtered", "pool|"}
+	if !slices.Equal(got, want) {
+		t.Errorf("history = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run executes every query in the queries file and writes one JSONL record per query
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("batch")
+	if err != nil {
+		return err
+	}
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	file, err := os.Open(opts.QueriesFile)
+	if err != nil {
+		return fmt.Errorf("failed to open queries file: %w", err)
+	}
+	queries, err := readQueries(file, opts.K)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%w: invalid queries file %s: %w", cli.ErrData, opts.QueriesFile, err)
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("%w: queries file %s contains no queries", cli.ErrData, opts.QueriesFile)
+	}
+
+	cfg, err := backend.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, Chat: true, DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Stop starting new queries on Ctrl+C; finished records are still written
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	// Create Azure OpenAI clients and connect to the vector store, or use the offline stand-ins
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+
+	out, err := os.Create(opts.Out)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer out.Close()
+
+	// Progress output from concurrent queries would interleave; the per-query lines replace it
+	pipeline := agents.NewDefaultPipeline(services.Models, services.Store, cfg.Planner, cfg.Synthesizer, cfg.Timeouts)
+	pipeline.SetOutput(io.Discard)
+
+	b := &batch{
+		pipeline:    pipeline,
+		history:     agents.NewHistoryWriterFromEnv(services.Store),
+		concurrency: opts.Concurrency,
+		timeout:     opts.Timeout,
+
+		ephemeralDatabase: services.EphemeralDatabase(),
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %d queries (concurrency %d) → %s\n", len(queries), opts.Concurrency, opts.Out)
+
+	done := 0
+	failed, err := b.run(ctx, queries, out, func(rec record) {
+		done++
+		status := "ok"
+		if rec.Error != nil {
+			status = "FAILED: " + rec.Error.Message
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s (%.0fms) %s\n", done, len(queries), rec.ID, rec.Durations.TotalMs, status)
+	})
+	services.RecordRun(ctx, "batch", map[string]int64{"queries": int64(done), "failed": int64(failed)}, err)
+	if err != nil {
+		return fmt.Errorf("batch stopped after %d of %d queries: %w", done, len(queries), err)
+	}
+
+	fmt.Printf("\nCompleted %d queries, %d failed. Results written to %s\n", len(queries), failed, opts.Out)
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d queries failed", cli.ErrPartial, failed, len(queries))
+	}
+	return nil
+}
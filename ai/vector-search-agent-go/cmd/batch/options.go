@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	defaultK           = 5
+	defaultConcurrency = 2
+	defaultOut         = "batch-results.jsonl"
+)
+
+// options holds the resolved cmd/batch settings
+type options struct {
+	QueriesFile string
+	Out         string
+	K           int
+	Concurrency int
+	Timeout     time.Duration
+	Verbosity   cli.Verbosity
+	ConfigFile  string
+}
+
+// parseOptions resolves options from command-line arguments and environment variables.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	defaults, err := envDefaults(getenv)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return nil, err
+	}
+	opts := *defaults
+
+	fs.StringVar(&opts.QueriesFile, "queries-file", opts.QueriesFile, "File with one query per line, or JSONL objects with \"query\" and optional \"k\" and \"id\" (required)")
+	fs.StringVar(&opts.Out, "out", opts.Out, "JSONL output file")
+	fs.IntVar(&opts.K, "k", opts.K, fmt.Sprintf("Default number of nearest neighbors, %d-%d; values outside are clamped (env NEAREST_NEIGHBORS)", vectorstore.MinK, vectorstore.MaxK()))
+	fs.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Number of queries run at once (env BATCH_CONCURRENCY)")
+	fs.DurationVar(&opts.Timeout, "timeout", opts.Timeout, "Deadline for each query's agent run; 0 bounds each query only by the stage budgets and the run's")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: batch --queries-file FILE [flags]\n\n")
+		fmt.Fprintf(output, "Runs every query in a file through the agent pipeline and writes one JSONL record per query.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	opts.K = vectorstore.ClampK(context.Background(), opts.K)
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// envDefaults reads the environment fallbacks for every flag
+func envDefaults(getenv func(string) string) (*options, error) {
+	opts := &options{
+		Out:         defaultOut,
+		K:           defaultK,
+		Concurrency: defaultConcurrency,
+	}
+
+	if nnStr := getenv("NEAREST_NEIGHBORS"); nnStr != "" {
+		nn, err := strconv.Atoi(nnStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NEAREST_NEIGHBORS %q: must be an integer", nnStr)
+		}
+		opts.K = nn
+	}
+
+	if cStr := getenv("BATCH_CONCURRENCY"); cStr != "" {
+		c, err := strconv.Atoi(cStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BATCH_CONCURRENCY %q: must be an integer", cStr)
+		}
+		opts.Concurrency = c
+	}
+
+	return opts, nil
+}
+
+// validate checks the resolved values
+func (o *options) validate() error {
+	if o.QueriesFile == "" {
+		return errors.New("--queries-file is required")
+	}
+	if o.Concurrency < 1 {
+		return errors.New("invalid concurrency: must be at least 1")
+	}
+	if o.Timeout < 0 {
+		return errors.New("invalid timeout: must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want options
+	}{
+		{
+			name: "defaults",
+			args: []string{"--queries-file", "queries.txt"},
+			want: options{QueriesFile: "queries.txt", Out: defaultOut, K: defaultK, Concurrency: defaultConcurrency},
+		},
+		{
+			name: "env",
+			args: []string{"--queries-file", "queries.txt"},
+			env:  map[string]string{"NEAREST_NEIGHBORS": "3", "BATCH_CONCURRENCY": "8"},
+			want: options{QueriesFile: "queries.txt", Out: defaultOut, K: 3, Concurrency: 8},
+		},
+		{
+			name: "flags override env",
+			args: []string{"--queries-file", "q.jsonl", "--out", "out.jsonl", "--k", "10", "--concurrency", "4", "--timeout", "1m"},
+			env:  map[string]string{"NEAREST_NEIGHBORS": "3", "BATCH_CONCURRENCY": "8"},
+			want: options{QueriesFile: "q.jsonl", Out: "out.jsonl", K: 10, Concurrency: 4, Timeout: time.Minute},
+		},
+		{
+			name: "k clamped",
+			args: []string{"--queries-file", "q", "--k", "21"},
+			want: options{QueriesFile: "q", Out: defaultOut, K: vectorstore.DefaultMaxK, Concurrency: defaultConcurrency},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if *opts != tt.want {
+				t.Errorf("options = %+v, want %+v", *opts, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"missing queries file", nil, nil, "--queries-file is required"},
+		{"non-integer k", []string{"--queries-file", "q", "--k", "2.5"}, nil, `invalid value "2.5" for flag -k`},
+		{"concurrency", []string{"--queries-file", "q", "--concurrency", "0"}, nil, "invalid concurrency"},
+		{"timeout", []string{"--queries-file", "q", "--timeout", "-1s"}, nil, "invalid timeout"},
+		{"bad env k", []string{"--queries-file", "q"}, map[string]string{"NEAREST_NEIGHBORS": "many"}, `invalid NEAREST_NEIGHBORS "many"`},
+		{"bad env concurrency", []string{"--queries-file", "q"}, map[string]string{"BATCH_CONCURRENCY": "x"}, `invalid BATCH_CONCURRENCY "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out); err == nil {
+				t.Fatal("parseOptions accepted invalid options")
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// batchQuery is one entry of the queries file
+type batchQuery struct {
+	ID    string `json:"id"`
+	Query string `json:"query"`
+	K     int    `json:"k"`
+}
+
+// readQueries parses the queries file. Each non-blank line is either plain query
+// text or a JSON object with "query" and optional "k" and "id". Lines starting with
+// # are comments. Entries without k use defaultK, and every k is clamped with
+// vectorstore.ClampK.
+func readQueries(r io.Reader, defaultK int) ([]batchQuery, error) {
+	var queries []batchQuery
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		q := batchQuery{Query: line}
+		if strings.HasPrefix(line, "{") {
+			q = batchQuery{}
+			if err := json.Unmarshal([]byte(line), &q); err != nil {
+				return nil, fmt.Errorf("line %d: invalid JSON: %w", lineNo, err)
+			}
+			if strings.TrimSpace(q.Query) == "" {
+				return nil, fmt.Errorf("line %d: \"query\" is required", lineNo)
+			}
+		}
+		if q.K == 0 {
+			q.K = defaultK
+		}
+		q.K = vectorstore.ClampK(context.Background(), q.K)
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("q%d", len(queries)+1)
+		}
+		queries = append(queries, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries: %w", err)
+	}
+	return queries, nil
+}
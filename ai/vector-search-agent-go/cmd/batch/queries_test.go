@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func TestReadQueries(t *testing.T) {
+	input := `# regression queries
+hotel near Times Square
+
+{"query": "cheap motel with parking", "k": 3}
+{"id": "spa", "query": "luxury spa resort"}
+{"query": "every hotel", "k": 500}
+{"query": "no hotel", "k": -1}
+`
+	queries, err := readQueries(strings.NewReader(input), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []batchQuery{
+		{ID: "q1", Query: "hotel near Times Square", K: 5},
+		{ID: "q2", Query: "cheap motel with parking", K: 3},
+		{ID: "spa", Query: "luxury spa resort", K: 5},
+		{ID: "q4", Query: "every hotel", K: vectorstore.DefaultMaxK},
+		{ID: "q5", Query: "no hotel", K: vectorstore.MinK},
+	}
+	if len(queries) != len(want) {
+		t.Fatalf("got %d queries, want %d", len(queries), len(want))
+	}
+	for i := range want {
+		if queries[i] != want[i] {
+			t.Errorf("query %d = %+v, want %+v", i, queries[i], want[i])
+		}
+	}
+}
+
+func TestReadQueriesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"invalid JSON", "ok\n{\"query\": ", "line 2: invalid JSON"},
+		{"missing query", `{"k": 3}`, `line 1: "query" is required`},
+		{"non-integer k", `{"query": "pool", "k": 2.5}`, "line 1: invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readQueries(strings.NewReader(tt.input), 5)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
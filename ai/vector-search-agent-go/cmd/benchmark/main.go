@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/quality"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run measures search latency and recall@k over the benchmark queries
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	queries, err := resolveQueries(opts)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C or SIGTERM cancels the run; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	// Create Azure OpenAI clients
+	openaiClients, err := clients.NewOpenAIClients(cfg.OpenAI)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenAI clients: %w", err)
+	}
+
+	// Connect to vector store
+	vsConfig := cfg.VectorStore
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	index, err := store.FindVectorIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to inspect vector index: %w", err)
+	}
+	if index == nil {
+		log.Printf("Warning: no vector index found on %s; searches will fail until one is created", vsConfig.EmbeddedField)
+	}
+
+	// Load every stored vector once for the exact-search baseline
+	var corpus []bench.Doc
+	_, err = store.ExportHotels(ctx, vectorstore.ExportOptions{IncludeVectors: true}, func(hotel models.HotelForVectorStore) error {
+		corpus = append(corpus, bench.Doc{ID: hotel.HotelID, Vector: hotel.DescriptionVector})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load vectors for the exact baseline: %w", err)
+	}
+	if len(corpus) == 0 {
+		return fmt.Errorf("%w: collection %s is empty; run cmd/upload first", cli.ErrData, vsConfig.CollectionName)
+	}
+
+	baseline := &quality.Baseline{Searcher: store, Corpus: corpus}
+	if index != nil {
+		baseline.Similarity = index.Similarity
+	}
+	r := &runner{embedder: openaiClients, searcher: baseline, k: opts.K}
+
+	fmt.Fprintf(os.Stderr, "Benchmarking %d queries x %d iterations (k=%d, concurrency %d) against %d documents\n",
+		len(queries), opts.Iterations, opts.K, opts.Concurrency, len(corpus))
+
+	backend.WarmUp(ctx, store, opts.IndexWarmup, os.Stderr)
+	if opts.Warmup > 0 {
+		fmt.Fprintf(os.Stderr, "Warming up (%d iteration(s) per query)...\n", opts.Warmup)
+		r.warmUp(ctx, queries, opts.Warmup)
+	}
+
+	fmt.Fprintln(os.Stderr, "Measuring...")
+	samples, wall := r.measure(ctx, queries, opts.Iterations, opts.Concurrency)
+
+	rep := buildReport(queries, samples, wall)
+	rep.Index = newIndexReport(vsConfig, len(corpus), index)
+	rep.Settings = settingsReport{
+		K:           opts.K,
+		Iterations:  opts.Iterations,
+		Warmup:      opts.Warmup,
+		IndexWarmup: opts.IndexWarmup,
+		Concurrency: opts.Concurrency,
+		Queries:     len(queries),
+	}
+	rep.render(os.Stdout)
+
+	if opts.JSONOut != "" {
+		if err := rep.writeJSON(opts.JSONOut); err != nil {
+			return err
+		}
+		fmt.Printf("\nJSON report written to %s\n", opts.JSONOut)
+	}
+
+	switch {
+	case rep.Errors == rep.Operations:
+		return fmt.Errorf("all %d operations failed: %w", rep.Operations, firstError(samples))
+	case rep.Errors > 0:
+		return fmt.Errorf("%w: %d of %d operations failed", cli.ErrPartial, rep.Errors, rep.Operations)
+	}
+	return nil
+}
+
+// resolveQueries reads the queries file or generates queries from the data file
+func resolveQueries(opts *options) ([]string, error) {
+	if opts.QueriesFile != "" {
+		return bench.LoadQueries(opts.QueriesFile)
+	}
+
+	hotels, err := vectorstore.LoadHotels(opts.DataFile)
+	if err != nil {
+		return nil, err
+	}
+	queries := bench.GenerateQueries(hotels, opts.Generate)
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no hotels in %s to generate queries from", opts.DataFile)
+	}
+	return queries, nil
+}
+
+// firstError returns the first failed sample's error
+func firstError(samples []sample) error {
+	for _, s := range samples {
+		if s.err != nil {
+			return s.err
+		}
+	}
+	return nil
+}
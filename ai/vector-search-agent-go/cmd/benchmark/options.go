@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	defaultIterations = 5
+	defaultWarmup     = 1
+	defaultK          = 5
+	defaultGenerate   = 10
+	defaultDataFile   = "../data/Hotels.json"
+)
+
+// options holds the resolved cmd/benchmark settings
+type options struct {
+	QueriesFile string
+	DataFile    string
+	Generate    int
+	Iterations  int
+	Warmup      int
+	IndexWarmup int
+	K           int
+	Concurrency int
+	JSONOut     string
+	Verbosity   cli.Verbosity
+	ConfigFile  string
+}
+
+// parseOptions resolves options from command-line arguments, using getenv for defaults.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("benchmark", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	opts := options{DataFile: getenv("DATA_FILE_WITHOUT_VECTORS")}
+	if opts.DataFile == "" {
+		opts.DataFile = defaultDataFile
+	}
+	if value := getenv("WARMUP_SEARCHES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			err = fmt.Errorf("invalid WARMUP_SEARCHES %q: must be an integer", value)
+			fmt.Fprintln(output, err)
+			return nil, err
+		}
+		opts.IndexWarmup = n
+	}
+
+	fs.StringVar(&opts.QueriesFile, "queries", "", "File with one query per line; blank lines and lines starting with # are ignored")
+	fs.StringVar(&opts.DataFile, "data", opts.DataFile, "Hotel data file used to generate queries when --queries is not set (env DATA_FILE_WITHOUT_VECTORS)")
+	fs.IntVar(&opts.Generate, "generate", defaultGenerate, "Number of queries to generate from the data file when --queries is not set")
+	fs.IntVar(&opts.Iterations, "iterations", defaultIterations, "Measured iterations per query")
+	fs.IntVar(&opts.Warmup, "warmup", defaultWarmup, "Unmeasured warm-up iterations per query")
+	fs.IntVar(&opts.IndexWarmup, "index-warmup", opts.IndexWarmup, "Throwaway searches with random vectors issued before the warm-up, to load the vector index (env WARMUP_SEARCHES)")
+	fs.IntVar(&opts.K, "k", defaultK, "Number of nearest neighbors to retrieve and score recall@k against")
+	fs.IntVar(&opts.Concurrency, "concurrency", 1, "Number of queries in flight at once")
+	fs.StringVar(&opts.JSONOut, "json", "", "Also write a JSON report to this file")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: benchmark [flags]\n\n")
+		fmt.Fprintf(output, "Measures embedding and vector search latency and recall@k against exact search.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// validate checks option ranges
+func (o *options) validate() error {
+	switch {
+	case o.Iterations < 1:
+		return errors.New("--iterations must be at least 1")
+	case o.Warmup < 0:
+		return errors.New("--warmup must not be negative")
+	case o.IndexWarmup < 0:
+		return errors.New("--index-warmup must not be negative")
+	case o.K < 1:
+		return errors.New("--k must be at least 1")
+	case o.K > vectorstore.MaxK():
+		return fmt.Errorf("--k must be at most %d; raise MAX_NEAREST_NEIGHBORS to search more", vectorstore.MaxK())
+	case o.Concurrency < 1:
+		return errors.New("--concurrency must be at least 1")
+	case o.QueriesFile == "" && o.Generate < 1:
+		return errors.New("--generate must be at least 1 when --queries is not set")
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// indexReport records which index was measured, for provenance
+type indexReport struct {
+	Database   string `json:"database"`
+	Collection string `json:"collection"`
+	Documents  int    `json:"documents"`
+	Name       string `json:"name,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Similarity string `json:"similarity,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"`
+}
+
+// settingsReport records the benchmark parameters
+type settingsReport struct {
+	K           int `json:"k"`
+	Iterations  int `json:"iterations"`
+	Warmup      int `json:"warmup"`
+	IndexWarmup int `json:"indexWarmup"`
+	Concurrency int `json:"concurrency"`
+	Queries     int `json:"queries"`
+}
+
+// queryReport holds per-query results
+type queryReport struct {
+	Query  string             `json:"query"`
+	Recall float64            `json:"recall"`
+	Search bench.LatencyStats `json:"search"`
+	Errors int                `json:"errors"`
+}
+
+// report is the full benchmark result
+type report struct {
+	GeneratedAt  time.Time          `json:"generatedAt"`
+	Build        buildinfo.Info     `json:"build"`
+	Index        indexReport        `json:"index"`
+	Settings     settingsReport     `json:"settings"`
+	Embedding    bench.LatencyStats `json:"embedding"`
+	Search       bench.LatencyStats `json:"search"`
+	Recall       float64            `json:"recall"`
+	MinRecall    float64            `json:"minRecall"`
+	Operations   int                `json:"operations"`
+	Errors       int                `json:"errors"`
+	WallSeconds  float64            `json:"wallSeconds"`
+	OpsPerSecond float64            `json:"opsPerSecond"`
+	Queries      []queryReport      `json:"queries"`
+}
+
+// newIndexReport describes the measured collection and its vector index, if any
+func newIndexReport(config *vectorstore.VectorStoreConfig, documents int, info *vectorstore.VectorIndexInfo) indexReport {
+	r := indexReport{Database: config.DatabaseName, Collection: config.CollectionName, Documents: documents}
+	if info != nil {
+		r.Name = info.Name
+		r.Field = info.Field
+		r.Kind = info.Kind
+		r.Similarity = info.Similarity
+		r.Dimensions = info.Dimensions
+	}
+	return r
+}
+
+// buildReport aggregates samples into a report. Recall is averaged over successful
+// operations; failed operations are counted but excluded from latency statistics.
+func buildReport(queries []string, samples []sample, wall time.Duration) report {
+	r := report{
+		GeneratedAt: time.Now().UTC(),
+		Build:       buildinfo.Read(),
+		Operations:  len(samples),
+		WallSeconds: wall.Seconds(),
+		MinRecall:   1,
+	}
+
+	var embeds, searches []time.Duration
+	perQuery := make([][]time.Duration, len(queries))
+	recallSum := make([]float64, len(queries))
+	errors := make([]int, len(queries))
+	var totalRecall float64
+
+	for _, s := range samples {
+		if s.err != nil {
+			r.Errors++
+			errors[s.query]++
+			continue
+		}
+		embeds = append(embeds, s.embed)
+		searches = append(searches, s.search)
+		perQuery[s.query] = append(perQuery[s.query], s.search)
+		recallSum[s.query] += s.recall
+		totalRecall += s.recall
+	}
+
+	r.Embedding = bench.Summarize(embeds)
+	r.Search = bench.Summarize(searches)
+	if ok := len(searches); ok > 0 {
+		r.Recall = totalRecall / float64(ok)
+	} else {
+		r.MinRecall = 0
+	}
+	if wall > 0 {
+		r.OpsPerSecond = float64(len(searches)) / wall.Seconds()
+	}
+
+	for i, query := range queries {
+		q := queryReport{Query: query, Search: bench.Summarize(perQuery[i]), Errors: errors[i]}
+		if n := len(perQuery[i]); n > 0 {
+			q.Recall = recallSum[i] / float64(n)
+			r.MinRecall = min(r.MinRecall, q.Recall)
+		}
+		r.Queries = append(r.Queries, q)
+	}
+
+	return r
+}
+
+// render prints the human-readable report
+func (r *report) render(w io.Writer) {
+	fmt.Fprintln(w, "\n=== BENCHMARK ===")
+	index := r.Index.Kind
+	if index == "" {
+		index = "none found"
+	}
+	fmt.Fprintf(w, "Index: %s (%s, %s, %d dims) on %s.%s, %d documents\n",
+		r.Index.Name, index, r.Index.Similarity, r.Index.Dimensions, r.Index.Database, r.Index.Collection, r.Index.Documents)
+	fmt.Fprintf(w, "Settings: k=%d, %d queries x %d iterations, %d warm-up, %d index warm-up searches, concurrency %d\n\n",
+		r.Settings.K, r.Settings.Queries, r.Settings.Iterations, r.Settings.Warmup, r.Settings.IndexWarmup, r.Settings.Concurrency)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tCOUNT\tP50\tP95\tP99\tMEAN\tMAX")
+	for _, row := range []struct {
+		name  string
+		stats bench.LatencyStats
+	}{{"embedding", r.Embedding}, {"search", r.Search}} {
+		s := row.stats
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", row.name, s.Count, bench.Round(s.P50), bench.Round(s.P95), bench.Round(s.P99), bench.Round(s.Mean), bench.Round(s.Max))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRecall@%d: %.3f mean, %.3f worst query\n", r.Settings.K, r.Recall, r.MinRecall)
+	fmt.Fprintf(w, "Throughput: %.1f ops/s over %.2fs\n", r.OpsPerSecond, r.WallSeconds)
+	if r.Errors > 0 {
+		fmt.Fprintf(w, "Errors: %d of %d operations\n", r.Errors, r.Operations)
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUERY\tRECALL\tSEARCH P50\tERRORS")
+	for _, q := range r.Queries {
+		fmt.Fprintf(tw, "%s\t%.2f\t%s\t%d\n", q.Query, q.Recall, bench.Round(q.Search.P50), q.Errors)
+	}
+	tw.Flush()
+}
+
+// writeJSON writes the report to path
+func (r *report) writeJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBuildReportAggregates(t *testing.T) {
+	queries := []string{"pool", "parking"}
+	errTimeout := errors.New("timeout")
+	samples := []sample{
+		{query: 0, embed: 5 * time.Millisecond, search: 10 * time.Millisecond, recall: 1},
+		{query: 0, embed: 7 * time.Millisecond, search: 30 * time.Millisecond, recall: 0.8},
+		{query: 1, embed: 6 * time.Millisecond, search: 20 * time.Millisecond, recall: 0.6},
+		{query: 1, err: errTimeout},
+	}
+
+	r := buildReport(queries, samples, 2*time.Second)
+
+	if r.Operations != 4 || r.Errors != 1 {
+		t.Errorf("operations %d, errors %d, want 4 and 1", r.Operations, r.Errors)
+	}
+	if r.Search.Count != 3 || r.Search.P50 != 20*time.Millisecond || r.Search.Max != 30*time.Millisecond {
+		t.Errorf("search stats = %+v", r.Search)
+	}
+	if r.Embedding.Count != 3 || r.Embedding.Mean != 6*time.Millisecond {
+		t.Errorf("embedding stats = %+v", r.Embedding)
+	}
+	if math.Abs(r.Recall-0.8) > 1e-9 || math.Abs(r.MinRecall-0.6) > 1e-9 {
+		t.Errorf("recall %v, min %v, want 0.8 and 0.6", r.Recall, r.MinRecall)
+	}
+	if r.OpsPerSecond != 1.5 {
+		t.Errorf("ops/s = %v, want 1.5 (failed operations excluded)", r.OpsPerSecond)
+	}
+
+	if len(r.Queries) != 2 {
+		t.Fatalf("got %d query reports, want 2", len(r.Queries))
+	}
+	if q := r.Queries[0]; q.Search.Count != 2 || math.Abs(q.Recall-0.9) > 1e-9 || q.Errors != 0 {
+		t.Errorf("query 0 = %+v", q)
+	}
+	if q := r.Queries[1]; q.Search.Count != 1 || q.Recall != 0.6 || q.Errors != 1 {
+		t.Errorf("query 1 = %+v", q)
+	}
+}
+
+func TestBuildReportAllFailed(t *testing.T) {
+	r := buildReport([]string{"pool"}, []sample{{err: errors.New("down")}, {err: errors.New("down")}}, time.Second)
+
+	if r.Errors != 2 || r.Search.Count != 0 || r.Recall != 0 || r.MinRecall != 0 || r.OpsPerSecond != 0 {
+		t.Errorf("report = %+v, want only errors", r)
+	}
+}
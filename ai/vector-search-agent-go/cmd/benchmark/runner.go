@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/quality"
+)
+
+// embedder generates query embeddings
+type embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// sample is the measurement of one embed-and-search operation
+type sample struct {
+	query  int
+	embed  time.Duration
+	search time.Duration
+	recall float64
+	err    error
+}
+
+// runner executes benchmark operations against the embedder and index
+type runner struct {
+	embedder embedder
+	// searcher runs the measured approximate search and the exact baseline
+	searcher quality.Searcher
+	k        int
+}
+
+// runOnce embeds one query, searches the index, and scores recall@k against exact search
+func (r *runner) runOnce(ctx context.Context, queryIndex int, query string) sample {
+	s := sample{query: queryIndex}
+
+	start := time.Now()
+	vector, err := r.embedder.GenerateEmbedding(ctx, query)
+	s.embed = time.Since(start)
+	if err != nil {
+		s.err = err
+		return s
+	}
+
+	start = time.Now()
+	results, err := r.searcher.VectorSearch(ctx, vector, r.k)
+	s.search = time.Since(start)
+	if err != nil {
+		s.err = err
+		return s
+	}
+
+	exact, err := r.searcher.ExactSearch(ctx, vector, r.k)
+	if err != nil {
+		s.err = err
+		return s
+	}
+	s.recall = quality.Overlap(results, exact, r.k)
+	return s
+}
+
+// warmUp runs each query n times without recording measurements
+func (r *runner) warmUp(ctx context.Context, queries []string, n int) {
+	for range n {
+		for i, query := range queries {
+			if ctx.Err() != nil {
+				return
+			}
+			r.runOnce(ctx, i, query)
+		}
+	}
+}
+
+// measure runs every query iterations times with up to concurrency operations in flight
+// and returns the samples along with the wall time of the whole run
+func (r *runner) measure(ctx context.Context, queries []string, iterations, concurrency int) ([]sample, time.Duration) {
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for range iterations {
+			for i := range queries {
+				select {
+				case jobs <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var samples []sample
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				s := r.runOnce(ctx, i, queries[i])
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return samples, time.Since(start)
+}
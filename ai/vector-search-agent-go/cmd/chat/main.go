@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const defaultK = 5
+
+func main() {
+	cli.Exit(run())
+}
+
+// run holds an interactive conversation until the user quits
+func run() error {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	k := defaultK
+	if nnStr := os.Getenv("NEAREST_NEIGHBORS"); nnStr != "" {
+		nn, err := strconv.Atoi(nnStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid NEAREST_NEIGHBORS %q: must be an integer\n", nnStr)
+			return cli.Usage(fmt.Errorf("invalid NEAREST_NEIGHBORS %q", nnStr))
+		}
+		k = nn
+	}
+
+	flag.IntVar(&k, "k", k, fmt.Sprintf("Initial number of nearest neighbors, %d-%d; values outside are clamped (env NEAREST_NEIGHBORS)", vectorstore.MinK, vectorstore.MaxK()))
+	var debug bool
+	var verbosity cli.Verbosity
+	var configFile string
+	flag.BoolVar(&debug, "debug", false, "Start with debug output enabled, same as -vv (env DEBUG)")
+	cli.AddVerbosityFlags(flag.CommandLine, &verbosity)
+	cli.AddConfigFlag(flag.CommandLine, &configFile)
+	cli.AddVersionFlag(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: chat [flags]\n\n")
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), cli.ExitCodesHelp)
+	}
+	flag.Parse()
+
+	k = vectorstore.ClampK(context.Background(), k)
+
+	if debug && verbosity < 2 {
+		verbosity = 2
+	}
+	if _, err := cli.SetupLogging(verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("chat")
+	if err != nil {
+		return err
+	}
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+	level := logging.Level()
+
+	// One session ID covers every turn of the chat
+	sessionID := session.NewID()
+	ctx := session.WithID(context.Background(), sessionID)
+
+	// Clients are created once and reused across turns
+	cfg, err := backend.LoadConfig(configFile, config.Requirements{Embedding: true, Chat: true, DocumentDB: true})
+	if err != nil {
+		return err
+	}
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+
+	searchTool := agents.NewVectorSearchTool(services.Models, services.Store)
+	searchTool.SetFormatter(cfg.Synthesizer.Formatter())
+	planner := agents.NewPlannerAgent(services.Models, searchTool, cfg.Planner, cfg.Timeouts)
+	if cfg.Planner.RoomSearch {
+		planner.SetRoomTool(agents.NewRoomSearchTool(services.Models, services.Store))
+	}
+	synthesizer := agents.NewSynthesizerAgent(services.Models, cfg.Synthesizer, cfg.Timeouts)
+
+	stream := &streamWriter{w: os.Stdout}
+	synthesizer.SetStreamWriter(stream)
+
+	// Ctrl-C cancels the turn in progress instead of exiting
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	fmt.Fprintf(os.Stderr, "Session: %s\n", sessionID)
+
+	r := &repl{
+		conversation: agents.NewConversation(planner, synthesizer),
+		in:           os.Stdin,
+		out:          os.Stdout,
+		stream:       stream,
+		k:            k,
+		debug:        level <= slog.LevelDebug,
+		// /debug switches to debug level or finer, and back to warn or quieter
+		setDebug: func(debug bool) {
+			if debug {
+				logging.SetLevel(min(level, slog.LevelDebug))
+			} else {
+				logging.SetLevel(max(level, slog.LevelWarn))
+			}
+		},
+		interrupts: interrupts,
+	}
+
+	if err := r.run(ctx); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/hints"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// commandKind identifies what a line of REPL input asks for
+type commandKind int
+
+const (
+	commandEmpty commandKind = iota
+	commandAsk
+	commandReset
+	commandK
+	commandDebug
+	commandHelp
+	commandQuit
+)
+
+// command is one parsed line of REPL input
+type command struct {
+	kind     commandKind
+	question string
+	k        int
+}
+
+// parseCommand parses a line of input. Lines starting with "/" are REPL commands;
+// anything else is a question for the agents.
+func parseCommand(line string) (command, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return command{kind: commandEmpty}, nil
+	}
+	if !strings.HasPrefix(line, "/") {
+		return command{kind: commandAsk, question: line}, nil
+	}
+
+	fields := strings.Fields(line)
+	switch strings.ToLower(fields[0]) {
+	case "/reset":
+		return command{kind: commandReset}, nil
+	case "/debug":
+		return command{kind: commandDebug}, nil
+	case "/help":
+		return command{kind: commandHelp}, nil
+	case "/quit", "/exit":
+		return command{kind: commandQuit}, nil
+	case "/k":
+		if len(fields) != 2 {
+			return command{}, errors.New("usage: /k <n>")
+		}
+		k, err := vectorstore.ParseK(context.Background(), fields[1])
+		if err != nil {
+			return command{}, fmt.Errorf("k must be an integer from %d to %d", vectorstore.MinK, vectorstore.MaxK())
+		}
+		return command{kind: commandK, k: k}, nil
+	default:
+		return command{}, fmt.Errorf("unknown command %s (type /help for commands)", fields[0])
+	}
+}
+
+// asker answers questions while keeping conversation history between turns
+type asker interface {
+	Ask(ctx context.Context, question string, nearestNeighbors int) (string, error)
+	Reset()
+}
+
+// repl runs the read-eval-print loop over a conversation
+type repl struct {
+	conversation asker
+	in           io.Reader
+	out          io.Writer
+	// stream receives answer tokens from the synthesizer; it reports whether anything was streamed
+	stream   *streamWriter
+	k        int
+	debug    bool
+	setDebug func(debug bool)
+	// interrupts cancels the turn in progress, e.g. when the user presses Ctrl-C
+	interrupts <-chan os.Signal
+}
+
+// run reads lines until /quit or end of input
+func (r *repl) run(ctx context.Context) error {
+	scanner := bufio.NewScanner(r.in)
+
+	fmt.Fprintln(r.out, "Hotel search chat. Type a question, or /help for commands.")
+	fmt.Fprintln(r.out, "Ctrl-C cancels the current turn; /quit or Ctrl-D exits.")
+
+	for {
+		fmt.Fprint(r.out, "\n> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return scanner.Err()
+		}
+
+		cmd, err := parseCommand(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(r.out, "Error: %v\n", err)
+			continue
+		}
+
+		switch cmd.kind {
+		case commandEmpty:
+		case commandQuit:
+			return nil
+		case commandHelp:
+			r.printHelp()
+		case commandReset:
+			r.conversation.Reset()
+			fmt.Fprintln(r.out, "Conversation reset.")
+		case commandK:
+			r.k = cmd.k
+			fmt.Fprintf(r.out, "Nearest neighbors set to %d.\n", r.k)
+		case commandDebug:
+			r.debug = !r.debug
+			if r.setDebug != nil {
+				r.setDebug(r.debug)
+			}
+			fmt.Fprintf(r.out, "Debug mode %s.\n", onOff(r.debug))
+		case commandAsk:
+			r.turn(ctx, cmd.question)
+		}
+	}
+}
+
+// turn runs one question through the conversation under its own request ID. An interrupt
+// cancels only this turn; the stage budgets of the planner and synthesizer bound it.
+func (r *repl) turn(ctx context.Context, question string) {
+	turnCtx, cancel := context.WithCancel(session.WithRequestID(ctx, session.NewRequestID()))
+	defer cancel()
+
+	// Drop interrupts received while waiting at the prompt
+	r.drainInterrupts()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-r.interrupts:
+			cancel()
+		case <-done:
+		}
+	}()
+
+	if r.stream != nil {
+		r.stream.reset()
+	}
+
+	answer, err := r.conversation.Ask(turnCtx, question, r.k)
+	switch {
+	case err != nil && errors.Is(turnCtx.Err(), context.Canceled) && ctx.Err() == nil:
+		fmt.Fprintln(r.out, "\nTurn cancelled.")
+	case err != nil:
+		fmt.Fprintf(r.out, "\nError: %v\n", err)
+		hints.Write(r.out, err)
+	case r.stream != nil && r.stream.wrote():
+		fmt.Fprintln(r.out)
+	default:
+		fmt.Fprintf(r.out, "\n--- ANSWER ---\n%s\n", answer)
+	}
+}
+
+// drainInterrupts discards any pending interrupt signals
+func (r *repl) drainInterrupts() {
+	for {
+		select {
+		case <-r.interrupts:
+		default:
+			return
+		}
+	}
+}
+
+// printHelp lists the REPL commands
+func (r *repl) printHelp() {
+	fmt.Fprintln(r.out, "Commands:")
+	fmt.Fprintln(r.out, "  /reset   forget previous turns and retrieved hotels")
+	fmt.Fprintf(r.out, "  /k <n>   set the number of nearest neighbors (%d-%d, currently %d)\n", vectorstore.MinK, vectorstore.MaxK(), r.k)
+	fmt.Fprintf(r.out, "  /debug   toggle debug output (currently %s)\n", onOff(r.debug))
+	fmt.Fprintln(r.out, "  /quit    exit")
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// streamWriter forwards streamed answer tokens, printing a header before the first one
+type streamWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.written == 0 && len(p) > 0 {
+		if _, err := io.WriteString(s.w, "\n--- ANSWER ---\n"); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.w.Write(p)
+	s.written += n
+	return n, err
+}
+
+func (s *streamWriter) reset() {
+	s.written = 0
+}
+
+func (s *streamWriter) wrote() bool {
+	return s.written > 0
+}
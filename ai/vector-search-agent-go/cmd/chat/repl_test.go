@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    command
+		wantErr string
+	}{
+		{"", command{kind: commandEmpty}, ""},
+		{"   ", command{kind: commandEmpty}, ""},
+		{"quiet hotel near the beach", command{kind: commandAsk, question: "quiet hotel near the beach"}, ""},
+		{"  /reset ", command{kind: commandReset}, ""},
+		{"/DEBUG", command{kind: commandDebug}, ""},
+		{"/help", command{kind: commandHelp}, ""},
+		{"/quit", command{kind: commandQuit}, ""},
+		{"/exit", command{kind: commandQuit}, ""},
+		{"/k 7", command{kind: commandK, k: 7}, ""},
+		{"/k", command{}, "usage: /k <n>"},
+		{"/k 0", command{kind: commandK, k: 1}, ""},
+		{"/k 21", command{kind: commandK, k: 20}, ""},
+		{"/k many", command{}, "k must be an integer from 1 to 20"},
+		{"/k 2.5", command{}, "k must be an integer from 1 to 20"},
+		{"/bogus", command{}, "unknown command /bogus"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCommand(tt.line)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseCommand(%q) error = %v, want %q", tt.line, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseCommand(%q) = %+v, %v, want %+v", tt.line, got, err, tt.want)
+		}
+	}
+}
+
+// fakeAsker records questions and answers them, optionally streaming or blocking until cancelled
+type fakeAsker struct {
+	questions []string
+	ks        []int
+	resets    int
+	stream    io.Writer
+	block     chan struct{}
+}
+
+func (f *fakeAsker) Ask(ctx context.Context, question string, nearestNeighbors int) (string, error) {
+	f.questions = append(f.questions, question)
+	f.ks = append(f.ks, nearestNeighbors)
+	if f.block != nil {
+		close(f.block)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	answer := "answer to " + question
+	if f.stream != nil {
+		io.WriteString(f.stream, answer)
+	}
+	return answer, nil
+}
+
+func (f *fakeAsker) Reset() {
+	f.resets++
+}
+
+func TestREPLTurnLoop(t *testing.T) {
+	asker := &fakeAsker{}
+	var out strings.Builder
+	var debugCalls []bool
+	r := &repl{
+		conversation: asker,
+		in:           strings.NewReader("quiet hotel\n/k 3\n\n/bogus\ndoes it have parking?\n/debug\n/reset\n/quit\nnever asked\n"),
+		out:          &out,
+		k:            5,
+		setDebug:     func(debug bool) { debugCalls = append(debugCalls, debug) },
+	}
+
+	if err := r.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"quiet hotel", "does it have parking?"}; strings.Join(asker.questions, "|") != strings.Join(want, "|") {
+		t.Errorf("questions = %q, want %q", asker.questions, want)
+	}
+	if len(asker.ks) != 2 || asker.ks[0] != 5 || asker.ks[1] != 3 {
+		t.Errorf("k per turn = %v, want [5 3]", asker.ks)
+	}
+	if asker.resets != 1 || len(debugCalls) != 1 || !debugCalls[0] {
+		t.Errorf("resets = %d, debug calls = %v, want 1 reset and debug on", asker.resets, debugCalls)
+	}
+	for _, want := range []string{
+		"--- ANSWER ---\nanswer to quiet hotel\n",
+		"Nearest neighbors set to 3.",
+		"Error: unknown command /bogus",
+		"Debug mode on.",
+		"Conversation reset.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestREPLStreamsAnswer(t *testing.T) {
+	var out strings.Builder
+	stream := &streamWriter{w: &out}
+	r := &repl{
+		conversation: &fakeAsker{stream: stream},
+		in:           strings.NewReader("quiet hotel\nspa\n"),
+		out:          &out,
+		stream:       stream,
+		k:            5,
+	}
+
+	if err := r.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "--- ANSWER ---"); got != 2 {
+		t.Errorf("answer header printed %d times, want once per turn:\n%s", got, out.String())
+	}
+	if strings.Count(out.String(), "answer to quiet hotel") != 1 {
+		t.Errorf("streamed answer printed again after streaming:\n%s", out.String())
+	}
+}
+
+func TestREPLInterruptCancelsTurnOnly(t *testing.T) {
+	started := make(chan struct{})
+	asker := &fakeAsker{block: started}
+	interrupts := make(chan os.Signal, 1)
+
+	reader, writer := io.Pipe()
+	var out strings.Builder
+	r := &repl{conversation: asker, in: reader, out: &out, k: 5, interrupts: interrupts}
+
+	done := make(chan error, 1)
+	go func() { done <- r.run(context.Background()) }()
+
+	io.WriteString(writer, "quiet hotel\n")
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("turn did not start")
+	}
+	interrupts <- os.Interrupt
+
+	// The session survives the cancelled turn and keeps reading input
+	io.WriteString(writer, "/quit\n")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("REPL did not exit after /quit")
+	}
+	if !strings.Contains(out.String(), "Turn cancelled.") {
+		t.Errorf("output does not report the cancelled turn:\n%s", out.String())
+	}
+}
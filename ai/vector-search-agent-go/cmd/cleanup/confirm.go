@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// confirm asks the user to type expected, described by what, and reports whether they
+// did. End of input counts as a refusal; cancelling ctx abandons the prompt.
+func confirm(ctx context.Context, in io.Reader, out io.Writer, what, expected string) (bool, error) {
+	fmt.Fprintf(out, "\nType the %s (%s) to confirm: ", what, expected)
+
+	type answer struct {
+		line string
+		err  error
+	}
+	answers := make(chan answer, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		answers <- answer{line, err}
+	}()
+
+	select {
+	case a := <-answers:
+		if a.err != nil && a.err != io.EOF {
+			return false, fmt.Errorf("failed to read confirmation: %w", a.err)
+		}
+		return strings.TrimSpace(a.line) == expected, nil
+	case <-ctx.Done():
+		fmt.Fprintln(out)
+		return false, ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"exact name", "vectorSearchDB\n", true},
+		{"surrounding whitespace", "  vectorSearchDB \r\n", true},
+		{"no trailing newline", "vectorSearchDB", true},
+		{"wrong name", "otherDB\n", false},
+		{"wrong case", "vectorsearchdb\n", false},
+		{"yes is not the name", "yes\n", false},
+		{"empty line", "\n", false},
+		{"end of input", "", false},
+		{"only the first line counts", "wrong\nvectorSearchDB\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			ok, err := confirm(context.Background(), strings.NewReader(tt.input), &out, "database name", "vectorSearchDB")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("confirm(%q) = %v, want %v", tt.input, ok, tt.want)
+			}
+			if !strings.Contains(out.String(), "Type the database name (vectorSearchDB) to confirm: ") {
+				t.Errorf("prompt = %q", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmReadError(t *testing.T) {
+	errRead := errors.New("terminal closed")
+	ok, err := confirm(context.Background(), iotest.ErrReader(errRead), &bytes.Buffer{}, "database name", "vectorSearchDB")
+	if ok || !errors.Is(err, errRead) {
+		t.Errorf("confirm = %v, %v; want false and the read error", ok, err)
+	}
+}
+
+func TestConfirmCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in, _ := io.Pipe() // never written: the prompt waits until ctx is cancelled
+	cancel()
+
+	ok, err := confirm(ctx, in, &bytes.Buffer{}, "database name", "vectorSearchDB")
+	if ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("confirm = %v, %v; want false and context.Canceled", ok, err)
+	}
+}
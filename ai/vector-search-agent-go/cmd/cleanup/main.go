@@ -2,38 +2,173 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
 
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load .env file from current directory
-	if err := godotenv.Load(".env"); err != nil {
-		log.Printf("Warning: .env file not found: %v", err)
+	cli.Exit(run())
+}
+
+// run drops the database, collection, or documents selected by the flags
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
 	}
 
-	ctx := context.Background()
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
 
 	// Load configuration
-	vsConfig := vectorstore.LoadConfigFromEnv()
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{DocumentDB: true})
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Connecting to database: %s\n", vsConfig.DatabaseName)
+	// Ctrl+C or SIGTERM cancels the prompt or the pending operation
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	vsConfig := cfg.VectorStore
+
+	// Cleanup has no results: what it will delete, the prompt, and progress are all
+	// diagnostics, so they go to stderr
+	out := os.Stderr
+
+	if opts.mode() == modeEphemeral {
+		return cleanupEphemeral(ctx, out, vsConfig, opts)
+	}
+
+	fmt.Fprintf(out, "Connecting to database: %s\n", vsConfig.DatabaseName)
 
 	// Connect to vector store
 	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
 	if err != nil {
-		log.Fatalf("Failed to connect to vector store: %v", err)
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	// Show exactly what will be destroyed before asking
+	if err := describe(ctx, out, store, vsConfig, opts.mode()); err != nil {
+		return fmt.Errorf("failed to inspect database: %w", err)
+	}
+
+	if !opts.Yes {
+		ok, err := confirm(ctx, os.Stdin, out, "database name", vsConfig.DatabaseName)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("confirmation did not match; nothing was deleted")
+		}
+	}
+
+	switch opts.mode() {
+	case modeCollection:
+		fmt.Fprintf(out, "\nDropping collection: %s\n", vsConfig.CollectionName)
+		if err := store.DropCollection(ctx); err != nil {
+			return fmt.Errorf("failed to drop collection: %w", err)
+		}
+		fmt.Fprintln(out, "Collection dropped successfully!")
+
+	case modeData:
+		fmt.Fprintf(out, "\nDeleting documents from collection: %s\n", vsConfig.CollectionName)
+		deleted, err := store.DeleteDocuments(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete documents: %w", err)
+		}
+		fmt.Fprintf(out, "Deleted %d documents; the collection and its indexes were kept.\n", deleted)
+
+	default:
+		fmt.Fprintf(out, "\nDeleting database: %s\n", vsConfig.DatabaseName)
+		if err := store.DeleteDatabase(ctx); err != nil {
+			return fmt.Errorf("failed to delete database: %w", err)
+		}
+		fmt.Fprintln(out, "Database deleted successfully!")
+	}
+	return nil
+}
+
+// cleanupEphemeral drops the ephemeral databases older than --ephemeral-older-than,
+// listing them and asking for confirmation on out
+func cleanupEphemeral(ctx context.Context, out io.Writer, vsConfig *vectorstore.VectorStoreConfig, opts *options) error {
+	// Connect without creating an ephemeral database of this run
+	vsConfig.Ephemeral = false
+	fmt.Fprintf(out, "Connecting to find ephemeral databases: %s*\n", vsConfig.EphemeralPrefix)
+
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	declined := false
+	dropped, err := vectorstore.CleanupEphemeral(ctx, store, vsConfig.EphemeralPrefix, opts.EphemeralOlderThan, func(expired []string) (bool, error) {
+		fmt.Fprintf(out, "\nThis will permanently delete %d ephemeral databases older than %s:\n", len(expired), opts.EphemeralOlderThan)
+		for _, name := range expired {
+			fmt.Fprintf(out, "  %s\n", name)
+		}
+		if opts.Yes {
+			return true, nil
+		}
+		ok, err := confirm(ctx, os.Stdin, out, "ephemeral prefix", vsConfig.EphemeralPrefix)
+		declined = !ok
+		return ok, err
+	})
+	for _, name := range dropped {
+		fmt.Fprintf(out, "Dropped %s\n", name)
 	}
-	defer store.Close(ctx)
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to clean up ephemeral databases: %w", err)
+	case declined:
+		return errors.New("confirmation did not match; nothing was deleted")
+	case len(dropped) == 0:
+		fmt.Fprintf(out, "No ephemeral databases older than %s.\n", opts.EphemeralOlderThan)
+	}
+	return nil
+}
 
-	// Delete database
-	fmt.Printf("\nDeleting database: %s\n", vsConfig.DatabaseName)
-	if err := store.DeleteDatabase(ctx); err != nil {
-		log.Fatalf("Failed to delete database: %v", err)
+// describe prints what the selected mode will destroy
+func describe(ctx context.Context, w io.Writer, store *vectorstore.VectorStore, config *vectorstore.VectorStoreConfig, mode cleanupMode) error {
+	count, err := store.CountDocuments(ctx)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Database deleted successfully!")
+	fmt.Fprintln(w, "\nThis will permanently delete:")
+	switch mode {
+	case modeCollection:
+		fmt.Fprintf(w, "  Collection: %s.%s (%d documents, including its indexes)\n", config.DatabaseName, config.CollectionName, count)
+	case modeData:
+		fmt.Fprintf(w, "  Documents:  %d in %s.%s (the collection and its indexes are kept)\n", count, config.DatabaseName, config.CollectionName)
+	default:
+		collections, err := store.ListCollections(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  Database:   %s\n", config.DatabaseName)
+		fmt.Fprintf(w, "  Collections (%d): %s\n", len(collections), strings.Join(collections, ", "))
+		fmt.Fprintf(w, "  Documents in %s: %d\n", config.CollectionName, count)
+	}
+	return nil
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+)
+
+// cleanupMode selects what cmd/cleanup destroys
+type cleanupMode int
+
+const (
+	modeDatabase cleanupMode = iota
+	modeCollection
+	modeData
+	modeEphemeral
+)
+
+// options holds the resolved cmd/cleanup settings
+type options struct {
+	CollectionOnly bool
+	DataOnly       bool
+	// EphemeralOlderThan, when positive, drops the ephemeral databases older than it
+	// instead of the configured database
+	EphemeralOlderThan time.Duration
+	Yes                bool
+	Verbosity          cli.Verbosity
+	ConfigFile         string
+}
+
+// mode returns the cleanup mode selected by the flags
+func (o *options) mode() cleanupMode {
+	switch {
+	case o.CollectionOnly:
+		return modeCollection
+	case o.DataOnly:
+		return modeData
+	case o.EphemeralOlderThan > 0:
+		return modeEphemeral
+	default:
+		return modeDatabase
+	}
+}
+
+// parseOptions resolves options from command-line arguments.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var opts options
+	fs.BoolVar(&opts.CollectionOnly, "collection-only", false, "Drop only the configured collection instead of the whole database")
+	fs.BoolVar(&opts.DataOnly, "data-only", false, "Delete the documents in the configured collection but keep the collection and its indexes")
+	fs.DurationVar(&opts.EphemeralOlderThan, "ephemeral-older-than", 0, "Drop the databases created by EPHEMERAL_DATABASE=true (named with EPHEMERAL_DATABASE_PREFIX) more than this long ago, such as 24h")
+	fs.BoolVar(&opts.Yes, "yes", false, "Skip the confirmation prompt, for automation")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: cleanup [flags]\n\n")
+		fmt.Fprintf(output, "Drops the configured database (by default), collection, or documents, or old ephemeral databases.\n")
+		fmt.Fprintf(output, "You are asked to type the database name, or the ephemeral prefix, to confirm unless --yes is given.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch {
+	case opts.CollectionOnly && opts.DataOnly:
+		err = errors.New("--collection-only and --data-only cannot be used together")
+	case opts.EphemeralOlderThan < 0:
+		err = errors.New("--ephemeral-older-than must not be negative")
+	case opts.EphemeralOlderThan > 0 && (opts.CollectionOnly || opts.DataOnly):
+		err = errors.New("--ephemeral-older-than cannot be used with --collection-only or --data-only")
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestParseOptionsFlagMatrix(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantMode cleanupMode
+		wantYes  bool
+		wantErr  bool
+	}{
+		{nil, modeDatabase, false, false},
+		{[]string{"--yes"}, modeDatabase, true, false},
+		{[]string{"--collection-only"}, modeCollection, false, false},
+		{[]string{"--collection-only", "--yes"}, modeCollection, true, false},
+		{[]string{"--data-only"}, modeData, false, false},
+		{[]string{"--data-only", "--yes"}, modeData, true, false},
+		{[]string{"--collection-only", "--data-only"}, 0, false, true},
+		{[]string{"--collection-only", "--data-only", "--yes"}, 0, false, true},
+		{[]string{"--collection-only=false", "--data-only"}, modeData, false, false},
+		{[]string{"--ephemeral-older-than", "24h"}, modeEphemeral, false, false},
+		{[]string{"--ephemeral-older-than", "24h", "--yes"}, modeEphemeral, true, false},
+		{[]string{"--ephemeral-older-than", "24h", "--data-only"}, 0, false, true},
+		{[]string{"--ephemeral-older-than", "-1h"}, 0, false, true},
+		{[]string{"--ephemeral-older-than", "soon"}, 0, false, true},
+		{[]string{"--unknown"}, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions(tt.args, &out)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseOptions accepted %v", tt.args)
+				}
+				if !strings.Contains(out.String(), "Usage: cleanup") {
+					t.Errorf("usage not printed:\n%s", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.mode() != tt.wantMode || opts.Yes != tt.wantYes {
+				t.Errorf("mode %v, yes %v; want %v, %v", opts.mode(), opts.Yes, tt.wantMode, tt.wantYes)
+			}
+		})
+	}
+}
+
+func TestParseOptionsExclusiveModesMessage(t *testing.T) {
+	var out bytes.Buffer
+	_, err := parseOptions([]string{"--collection-only", "--data-only"}, &out)
+	if err == nil || !strings.Contains(out.String(), "--collection-only and --data-only cannot be used together") {
+		t.Errorf("err = %v, output:\n%s", err, out.String())
+	}
+}
+
+func TestParseOptionsHelp(t *testing.T) {
+	var out bytes.Buffer
+	_, err := parseOptions([]string{"-h"}, &out)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("err = %v, want flag.ErrHelp", err)
+	}
+	for _, want := range []string{"-collection-only", "-data-only", "-ephemeral-older-than", "-yes", "type the database name"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("help is missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+)
+
+// Output formats
+const (
+	formatEnv      = "env"
+	formatMarkdown = "markdown"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run writes the documentation of the environment variables, generated from the tags
+// of config.Config
+func run() error {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	opts, err := parseOptions(os.Args[1:], os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	var out bytes.Buffer
+	if err := generate(&out, opts.Format); err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		_, err = os.Stdout.Write(out.Bytes())
+		return err
+	}
+	return os.WriteFile(opts.Output, out.Bytes(), 0o644)
+}
+
+// generate writes the documentation in format
+func generate(w io.Writer, format string) error {
+	if format == formatMarkdown {
+		return config.WriteMarkdown(w)
+	}
+	return config.WriteEnvExample(w)
+}
+
+// options holds the resolved cmd/envdoc settings
+type options struct {
+	Format string
+	Output string
+}
+
+// parseOptions resolves options from command-line arguments.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("envdoc", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var opts options
+	fs.StringVar(&opts.Format, "format", formatEnv, "Output format: env, a .env.example, or markdown, the table of the README")
+	fs.StringVar(&opts.Output, "output", "", "File to write instead of stdout")
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: envdoc [flags]\n\n")
+		fmt.Fprintf(output, "Writes the documentation of the environment variables, generated from the config definitions.\n")
+		fmt.Fprintf(output, "Regenerate .env.example with: go run ./cmd/envdoc --output .env.example\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch {
+	case opts.Format != formatEnv && opts.Format != formatMarkdown:
+		err = fmt.Errorf("--format must be %s or %s, got %q", formatEnv, formatMarkdown, opts.Format)
+	case fs.NArg() > 0:
+		err = errors.New("envdoc takes no arguments")
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
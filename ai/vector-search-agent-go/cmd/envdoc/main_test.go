@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Markers around the generated table in the README
+const (
+	readmeStart = "<!-- envdoc:start -->\n"
+	readmeEnd   = "<!-- envdoc:end -->"
+)
+
+func TestEnvExampleIsCurrent(t *testing.T) {
+	var want bytes.Buffer
+	if err := generate(&want, formatEnv); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../.env.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf(".env.example is out of date; regenerate it with go run ./cmd/envdoc --output .env.example\nwant:\n%s", want.String())
+	}
+}
+
+func TestReadmeTableIsCurrent(t *testing.T) {
+	var want bytes.Buffer
+	if err := generate(&want, formatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	readme, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rest, ok := strings.Cut(string(readme), readmeStart)
+	table, _, ok2 := strings.Cut(rest, readmeEnd)
+	if !ok || !ok2 {
+		t.Fatalf("README has no %q ... %q section", readmeStart, readmeEnd)
+	}
+	if table != want.String() {
+		t.Errorf("the README's environment table is out of date; replace it with the output of go run ./cmd/envdoc --format markdown\nwant:\n%s", want.String())
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    options
+		wantErr bool
+	}{
+		{"defaults", nil, options{Format: formatEnv}, false},
+		{"markdown to a file", []string{"--format", "markdown", "--output", "env.md"}, options{Format: formatMarkdown, Output: "env.md"}, false},
+		{"unknown format", []string{"--format", "yaml"}, options{}, true},
+		{"argument", []string{"extra"}, options{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions(tt.args, &out)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(out.String(), "Usage: envdoc") {
+					t.Errorf("err = %v, output:\n%s; want an error with the usage", err, out.String())
+				}
+				return
+			}
+			if err != nil || *opts != tt.want {
+				t.Errorf("options = %+v (%v), want %+v", opts, err, tt.want)
+			}
+		})
+	}
+}
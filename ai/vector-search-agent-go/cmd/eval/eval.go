@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/quality"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	// copyBatchSize is the number of documents inserted per call when filling a collection
+	copyBatchSize = 100
+	// canaryK is the number of neighbors requested by the readiness check
+	canaryK = 5
+	// dropTimeout bounds the cleanup of each temporary collection, which runs even
+	// after the evaluation was cancelled
+	dropTimeout = 30 * time.Second
+)
+
+// evalCollection is the subset of the vector store used for one temporary collection
+type evalCollection interface {
+	InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error
+	CreateVectorIndexWithSpec(ctx context.Context, spec vectorstore.VectorIndexSpec) error
+	// DropVectorIndex removes the index between the combinations of a --grid sweep
+	DropVectorIndex(ctx context.Context, name string) error
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+	DropCollection(ctx context.Context) error
+}
+
+// evalQuery is a query with its embedding and exact nearest neighbors, computed once
+// and shared by every algorithm
+type evalQuery struct {
+	vector []float32
+	exact  []models.HotelSearchResult
+}
+
+// evaluator builds one temporary collection per index spec and measures it
+type evaluator struct {
+	// open returns the temporary collection with the given name
+	open func(name string) evalCollection
+	// prefix starts every temporary collection name
+	prefix string
+	// indexName is the name the vector index is created with, for dropping it
+	indexName string
+
+	docs         []models.HotelForVectorStore
+	queries      []evalQuery
+	k            int
+	iterations   int
+	readyTimeout time.Duration
+	pollInterval time.Duration
+	out          io.Writer
+}
+
+// run evaluates every spec in order. A failure in one spec is recorded in its
+// result and does not stop the others; only cancellation of ctx does.
+func (e *evaluator) run(ctx context.Context, specs []vectorstore.VectorIndexSpec) []algorithmResult {
+	results := make([]algorithmResult, 0, len(specs))
+	for _, spec := range specs {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Fprintf(e.out, "\n== %s ==\n", spec)
+		result := e.evaluate(ctx, spec)
+		if result.Error != "" {
+			fmt.Fprintf(e.out, "Failed: %s\n", result.Error)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// evaluate copies the documents into a new collection, builds the index, measures
+// every query, and drops the collection whatever happened
+func (e *evaluator) evaluate(ctx context.Context, spec vectorstore.VectorIndexSpec) (result algorithmResult) {
+	result = algorithmResult{
+		Algorithm:  spec.Algorithm,
+		Spec:       spec.String(),
+		Index:      spec,
+		Collection: fmt.Sprintf("%s_%s", e.prefix, strings.TrimPrefix(spec.Algorithm, "vector-")),
+	}
+	coll := e.open(result.Collection)
+
+	defer func() {
+		dropCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), dropTimeout)
+		defer cancel()
+		if err := coll.DropCollection(dropCtx); err != nil {
+			result.CleanupError = err.Error()
+			slog.WarnContext(ctx, "failed to drop temporary collection", "collection", result.Collection, "err", err)
+			fmt.Fprintf(e.out, "Warning: could not drop %s; drop it manually\n", result.Collection)
+		}
+	}()
+
+	fmt.Fprintf(e.out, "Copying %d documents into %s...\n", len(e.docs), result.Collection)
+	if err := e.copyDocs(ctx, coll); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	e.buildAndMeasure(ctx, coll, spec, &result)
+	return result
+}
+
+// buildAndMeasure creates the index for spec on coll, waits for it to serve searches,
+// and measures every query, recording the outcome in result. It reports whether the
+// index was created, so a sweep knows to drop it before the next build.
+func (e *evaluator) buildAndMeasure(ctx context.Context, coll evalCollection, spec vectorstore.VectorIndexSpec, result *algorithmResult) (created bool) {
+	fmt.Fprintln(e.out, "Building index...")
+	start := time.Now()
+	if err := coll.CreateVectorIndexWithSpec(ctx, spec); err != nil {
+		result.Error = err.Error()
+		return false
+	}
+	if err := e.waitReady(ctx, coll); err != nil {
+		result.Error = err.Error()
+		return true
+	}
+	result.BuildSeconds = time.Since(start).Seconds()
+
+	fmt.Fprintf(e.out, "Measuring %d queries x %d iterations...\n", len(e.queries), e.iterations)
+	e.measure(ctx, coll, result)
+	switch {
+	case ctx.Err() != nil:
+		result.Error = fmt.Sprintf("interrupted after %d searches", result.Searches)
+	case result.Errors == result.Searches:
+		result.Error = fmt.Sprintf("all %d searches failed: %s", result.Searches, result.firstErr)
+	}
+	return true
+}
+
+// copyDocs inserts the documents in batches
+func (e *evaluator) copyDocs(ctx context.Context, coll evalCollection) error {
+	for start := 0; start < len(e.docs); start += copyBatchSize {
+		end := min(start+copyBatchSize, len(e.docs))
+		if err := coll.InsertHotelsWithEmbeddings(ctx, e.docs[start:end]); err != nil {
+			return fmt.Errorf("failed to copy documents: %w", err)
+		}
+	}
+	return nil
+}
+
+// waitReady polls with a search for the first document's own vector until the index
+// returns results or the ready timeout elapses
+func (e *evaluator) waitReady(ctx context.Context, coll evalCollection) error {
+	if len(e.docs) == 0 {
+		return errors.New("no documents to search")
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.readyTimeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		results, err := coll.VectorSearch(ctx, e.docs[0].DescriptionVector, canaryK)
+		if err == nil && len(results) > 0 {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = errors.New("canary search returned no results")
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("index not ready after %s: %w", e.readyTimeout, lastErr)
+		case <-time.After(e.pollInterval):
+		}
+	}
+}
+
+// measure runs every query iterations times and records latency and recall@k
+func (e *evaluator) measure(ctx context.Context, coll evalCollection, result *algorithmResult) {
+	var latencies []time.Duration
+	var recallSum float64
+	result.MinRecall = 1
+
+queries:
+	for _, q := range e.queries {
+		var queryRecall float64
+		var ok int
+		for range e.iterations {
+			if ctx.Err() != nil {
+				break queries
+			}
+			result.Searches++
+
+			start := time.Now()
+			found, err := coll.VectorSearch(ctx, q.vector, e.k)
+			elapsed := time.Since(start)
+			if err != nil {
+				result.Errors++
+				if result.firstErr == "" {
+					result.firstErr = err.Error()
+				}
+				continue
+			}
+
+			recall := quality.Overlap(found, q.exact, e.k)
+			latencies = append(latencies, elapsed)
+			recallSum += recall
+			queryRecall += recall
+			ok++
+		}
+		if ok > 0 {
+			result.MinRecall = min(result.MinRecall, queryRecall/float64(ok))
+		}
+	}
+
+	result.Search = bench.Summarize(latencies)
+	if n := len(latencies); n > 0 {
+		result.Recall = recallSum / float64(n)
+	} else {
+		result.MinRecall = 0
+	}
+}
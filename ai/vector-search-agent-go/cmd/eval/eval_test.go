@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// fakeCollection is an in-memory temporary collection. Searches return the stored
+// documents in insertion order, so recall depends only on which documents were copied.
+type fakeCollection struct {
+	name      string
+	docs      []models.HotelForVectorStore
+	spec      vectorstore.VectorIndexSpec
+	insertErr error
+	indexErr  error
+	searchErr error
+	dropErr   error
+	// notReady is the number of canary searches that return no results
+	notReady int
+	// onSearch runs before each search
+	onSearch func()
+	searches int
+	dropped  bool
+	dropCtx  error
+
+	// rejectSpec fails the index builds it returns true for
+	rejectSpec func(spec vectorstore.VectorIndexSpec) bool
+	// built lists every index created, and droppedIndexes every index dropped, in order
+	built          []vectorstore.VectorIndexSpec
+	droppedIndexes []string
+}
+
+func (c *fakeCollection) InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	if c.insertErr != nil {
+		return c.insertErr
+	}
+	c.docs = append(c.docs, hotels...)
+	return nil
+}
+
+func (c *fakeCollection) CreateVectorIndexWithSpec(ctx context.Context, spec vectorstore.VectorIndexSpec) error {
+	c.spec = spec
+	if c.rejectSpec != nil && c.rejectSpec(spec) {
+		return errors.New("invalid index parameters")
+	}
+	if c.indexErr == nil {
+		c.built = append(c.built, spec)
+	}
+	return c.indexErr
+}
+
+func (c *fakeCollection) DropVectorIndex(ctx context.Context, name string) error {
+	c.droppedIndexes = append(c.droppedIndexes, name)
+	return nil
+}
+
+func (c *fakeCollection) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	c.searches++
+	if c.onSearch != nil {
+		c.onSearch()
+	}
+	if c.notReady > 0 {
+		c.notReady--
+		return nil, nil
+	}
+	if c.searchErr != nil {
+		return nil, c.searchErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var results []models.HotelSearchResult
+	for _, doc := range c.docs[:min(k, len(c.docs))] {
+		results = append(results, models.HotelSearchResult{Hotel: doc, Score: 1})
+	}
+	return results, nil
+}
+
+func (c *fakeCollection) DropCollection(ctx context.Context) error {
+	c.dropped = true
+	c.dropCtx = ctx.Err()
+	return c.dropErr
+}
+
+// fakeDatabase hands out fake collections and records the order they were opened in
+type fakeDatabase struct {
+	mu          sync.Mutex
+	opened      []string
+	collections map[string]*fakeCollection
+	// configure adjusts each collection when it is opened
+	configure func(name string, c *fakeCollection)
+}
+
+func (d *fakeDatabase) open(name string) evalCollection {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := &fakeCollection{name: name}
+	if d.configure != nil {
+		d.configure(name, c)
+	}
+	if d.collections == nil {
+		d.collections = make(map[string]*fakeCollection)
+	}
+	d.collections[name] = c
+	d.opened = append(d.opened, name)
+	return c
+}
+
+func evalDocs(n int) []models.HotelForVectorStore {
+	docs := make([]models.HotelForVectorStore, n)
+	for i := range docs {
+		docs[i] = models.HotelForVectorStore{HotelID: string(rune('a' + i)), DescriptionVector: []float32{float32(i), 1}}
+	}
+	return docs
+}
+
+func evalSpecs(algorithms ...string) []vectorstore.VectorIndexSpec {
+	specs := make([]vectorstore.VectorIndexSpec, len(algorithms))
+	for i, algorithm := range algorithms {
+		specs[i] = vectorstore.VectorIndexSpec{Algorithm: algorithm, Similarity: "COS", Dimensions: 2}
+	}
+	return specs
+}
+
+// exactResults returns an exact baseline of the hotels with ids, nearest first
+func exactResults(ids ...string) []models.HotelSearchResult {
+	results := make([]models.HotelSearchResult, len(ids))
+	for i, id := range ids {
+		results[i] = models.HotelSearchResult{Hotel: models.HotelForVectorStore{HotelID: id}, Score: 1 - float64(i)/10}
+	}
+	return results
+}
+
+func newTestEvaluator(db *fakeDatabase, docs int) *evaluator {
+	return &evaluator{
+		open:   db.open,
+		prefix: "hotels_eval_1",
+		docs:   evalDocs(docs),
+		queries: []evalQuery{
+			{vector: []float32{1, 0}, exact: exactResults("a", "b")},
+			{vector: []float32{0, 1}, exact: exactResults("a", "c")},
+		},
+		k:            2,
+		iterations:   3,
+		readyTimeout: time.Second,
+		pollInterval: time.Millisecond,
+		out:          io.Discard,
+	}
+}
+
+func TestEvaluatorRunsEveryAlgorithmInOrder(t *testing.T) {
+	db := &fakeDatabase{}
+	e := newTestEvaluator(db, 250)
+
+	results := e.run(context.Background(), evalSpecs(vectorstore.AlgorithmIVF, vectorstore.AlgorithmHNSW, vectorstore.AlgorithmDiskANN))
+
+	wantNames := []string{"hotels_eval_1_ivf", "hotels_eval_1_hnsw", "hotels_eval_1_diskann"}
+	if !slices.Equal(db.opened, wantNames) {
+		t.Fatalf("opened %v, want %v", db.opened, wantNames)
+	}
+	for i, result := range results {
+		coll := db.collections[wantNames[i]]
+		if result.Error != "" || result.CleanupError != "" {
+			t.Errorf("%s failed: %+v", result.Algorithm, result)
+		}
+		if result.Collection != wantNames[i] || coll.spec.Algorithm != result.Algorithm {
+			t.Errorf("result %d = %+v, index built with %+v", i, result, coll.spec)
+		}
+		if len(coll.docs) != 250 || !coll.dropped {
+			t.Errorf("%s: copied %d documents, dropped %v", result.Collection, len(coll.docs), coll.dropped)
+		}
+		// The fake returns a and b for every query: full recall for the first, half for the second
+		if result.Searches != 6 || result.Errors != 0 || result.Search.Count != 6 || result.Recall != 0.75 || result.MinRecall != 0.5 {
+			t.Errorf("%s measurements = %+v", result.Algorithm, result)
+		}
+	}
+}
+
+func TestEvaluatorFailureDoesNotStopOthers(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(c *fakeCollection)
+		wantErr   string
+	}{
+		{"copy", func(c *fakeCollection) { c.insertErr = errors.New("quota exceeded") }, "failed to copy documents: quota exceeded"},
+		{"index build", func(c *fakeCollection) { c.indexErr = errors.New("unsupported algorithm") }, "unsupported algorithm"},
+		{"never ready", func(c *fakeCollection) { c.notReady = 1 << 30 }, "index not ready after 20ms: canary search returned no results"},
+		{"canary errors", func(c *fakeCollection) { c.searchErr = errors.New("timeout") }, "index not ready after 20ms: timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+				if strings.HasSuffix(name, "_hnsw") {
+					tt.configure(c)
+				}
+			}}
+			e := newTestEvaluator(db, 3)
+			e.readyTimeout = 20 * time.Millisecond
+
+			results := e.run(context.Background(), evalSpecs(vectorstore.AlgorithmIVF, vectorstore.AlgorithmHNSW, vectorstore.AlgorithmDiskANN))
+			if len(results) != 3 {
+				t.Fatalf("got %d results, want 3", len(results))
+			}
+			if !strings.Contains(results[1].Error, tt.wantErr) {
+				t.Errorf("hnsw error = %q, want %q", results[1].Error, tt.wantErr)
+			}
+			for _, i := range []int{0, 2} {
+				if results[i].Error != "" || results[i].Searches != 6 {
+					t.Errorf("%s after a failure = %+v", results[i].Algorithm, results[i])
+				}
+			}
+			for name, c := range db.collections {
+				if !c.dropped {
+					t.Errorf("%s was not dropped", name)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluatorAllSearchesFailed(t *testing.T) {
+	db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+		// The canary search succeeds; every measured search fails
+		c.onSearch = func() {
+			if c.searches > 1 {
+				c.searchErr = errors.New("throttled")
+			}
+		}
+	}}
+	e := newTestEvaluator(db, 3)
+
+	results := e.run(context.Background(), evalSpecs(vectorstore.AlgorithmIVF))
+	if results[0].Error != "all 6 searches failed: throttled" || results[0].Errors != 6 || results[0].MinRecall != 0 {
+		t.Errorf("result = %+v", results[0])
+	}
+}
+
+func TestEvaluatorDropsCollectionAfterCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+		// Cancel during the first measured search of the first algorithm
+		c.onSearch = func() {
+			if c.searches == 2 {
+				cancel()
+			}
+		}
+	}}
+	e := newTestEvaluator(db, 3)
+
+	results := e.run(ctx, evalSpecs(vectorstore.AlgorithmIVF, vectorstore.AlgorithmHNSW))
+	if len(results) != 1 || !strings.HasPrefix(results[0].Error, "interrupted after") {
+		t.Fatalf("results = %+v, want the first algorithm interrupted and the second skipped", results)
+	}
+	coll := db.collections["hotels_eval_1_ivf"]
+	if !coll.dropped || coll.dropCtx != nil {
+		t.Errorf("dropped %v with ctx err %v, want a drop with a live context", coll.dropped, coll.dropCtx)
+	}
+	if len(db.opened) != 1 {
+		t.Errorf("opened %v after cancellation", db.opened)
+	}
+}
+
+func TestEvaluatorRecordsCleanupFailure(t *testing.T) {
+	db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+		c.dropErr = errors.New("not authorized")
+	}}
+	e := newTestEvaluator(db, 3)
+	var out bytes.Buffer
+	e.out = &out
+
+	results := e.run(context.Background(), evalSpecs(vectorstore.AlgorithmIVF))
+	if results[0].Error != "" || results[0].CleanupError != "not authorized" {
+		t.Errorf("result = %+v, want a cleanup error only", results[0])
+	}
+	if !strings.Contains(out.String(), "could not drop hotels_eval_1_ivf") {
+		t.Errorf("output is missing the cleanup warning:\n%s", out.String())
+	}
+}
+
+func TestReportRender(t *testing.T) {
+	rep := report{
+		Source:   sourceReport{Database: "Hotels", Collection: "hotel_data", Documents: 50},
+		Settings: settingsReport{K: 5, Iterations: 3, Queries: 10, Similarity: "COS"},
+		Results: []algorithmResult{
+			{Algorithm: vectorstore.AlgorithmIVF, BuildSeconds: 1.25, Recall: 0.9, MinRecall: 0.6, Searches: 30},
+			{Algorithm: vectorstore.AlgorithmHNSW, Error: "unsupported tier", CleanupError: "timeout"},
+		},
+	}
+	if rep.failed() != 1 {
+		t.Errorf("failed() = %d, want 1", rep.failed())
+	}
+
+	var out bytes.Buffer
+	rep.render(&out)
+	for _, want := range []string{"Source: Hotels.hotel_data, 50 documents", "RECALL@5", "vector-ivf", "1.2s", "0.900", "0/30", "FAILED: unsupported tier (not dropped)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+}
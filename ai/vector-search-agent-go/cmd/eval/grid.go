@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"gopkg.in/yaml.v3"
+)
+
+// grid is the layout of a --grid file: the index parameters to sweep, one entry per
+// algorithm. Parameters left out of an entry keep their value from the environment.
+type grid struct {
+	Sweeps []gridSweep `yaml:"sweeps" json:"sweeps"`
+}
+
+// gridSweep lists the values to try for each parameter of one algorithm
+type gridSweep struct {
+	Algorithm      string `yaml:"algorithm" json:"algorithm"`
+	NumLists       []int  `yaml:"numLists" json:"numLists"`
+	M              []int  `yaml:"m" json:"m"`
+	EfConstruction []int  `yaml:"efConstruction" json:"efConstruction"`
+	MaxDegree      []int  `yaml:"maxDegree" json:"maxDegree"`
+	LBuild         []int  `yaml:"lBuild" json:"lBuild"`
+}
+
+// gridParam links a sweep parameter to the spec field it sets
+type gridParam struct {
+	name      string
+	algorithm string
+	values    func(s *gridSweep) []int
+	set       func(spec *vectorstore.VectorIndexSpec, value int)
+}
+
+// gridParams lists every sweep parameter in expansion order
+var gridParams = []gridParam{
+	{"numLists", vectorstore.AlgorithmIVF, func(s *gridSweep) []int { return s.NumLists }, func(spec *vectorstore.VectorIndexSpec, v int) { spec.NumLists = v }},
+	{"m", vectorstore.AlgorithmHNSW, func(s *gridSweep) []int { return s.M }, func(spec *vectorstore.VectorIndexSpec, v int) { spec.M = v }},
+	{"efConstruction", vectorstore.AlgorithmHNSW, func(s *gridSweep) []int { return s.EfConstruction }, func(spec *vectorstore.VectorIndexSpec, v int) { spec.EfConstruction = v }},
+	{"maxDegree", vectorstore.AlgorithmDiskANN, func(s *gridSweep) []int { return s.MaxDegree }, func(spec *vectorstore.VectorIndexSpec, v int) { spec.MaxDegree = v }},
+	{"lBuild", vectorstore.AlgorithmDiskANN, func(s *gridSweep) []int { return s.LBuild }, func(spec *vectorstore.VectorIndexSpec, v int) { spec.LBuild = v }},
+}
+
+// loadGrid parses a YAML or JSON grid file, chosen by extension. Unknown keys are
+// errors, so a misspelled parameter can't silently fall back to its default.
+func loadGrid(path string) (*grid, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grid file: %w", err)
+	}
+
+	var g grid
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&g)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&g)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid grid file %s: %w", path, err)
+	}
+	return &g, nil
+}
+
+// expand returns every combination of the grid's parameter values as a copy of base,
+// sweep by sweep and in gridParams order within a sweep. Duplicates are dropped.
+func (g *grid) expand(base vectorstore.VectorIndexSpec) ([]vectorstore.VectorIndexSpec, error) {
+	var specs []vectorstore.VectorIndexSpec
+	seen := make(map[vectorstore.VectorIndexSpec]bool)
+	for i := range g.Sweeps {
+		sweep := &g.Sweeps[i]
+		algorithm, ok := algorithmName(sweep.Algorithm)
+		if !ok {
+			return nil, fmt.Errorf("sweep %d: unsupported algorithm %q: use ivf, hnsw, or diskann", i+1, sweep.Algorithm)
+		}
+
+		spec := base
+		spec.Algorithm = algorithm
+		combos := []vectorstore.VectorIndexSpec{spec}
+		for _, param := range gridParams {
+			values := param.values(sweep)
+			if len(values) == 0 {
+				continue
+			}
+			if param.algorithm != algorithm {
+				return nil, fmt.Errorf("sweep %d: %s does not apply to %s", i+1, param.name, algorithm)
+			}
+
+			next := make([]vectorstore.VectorIndexSpec, 0, len(combos)*len(values))
+			for _, combo := range combos {
+				for _, value := range values {
+					if value < 1 {
+						return nil, fmt.Errorf("sweep %d: %s values must be at least 1, got %d", i+1, param.name, value)
+					}
+					param.set(&combo, value)
+					next = append(next, combo)
+				}
+			}
+			combos = next
+		}
+
+		for _, combo := range combos {
+			if !seen[combo] {
+				seen[combo] = true
+				specs = append(specs, combo)
+			}
+		}
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("the grid has no sweeps")
+	}
+	return specs, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func TestGridExpand(t *testing.T) {
+	base := envSpec()
+	hnsw := func(m, ef int) string {
+		s := base
+		s.Algorithm, s.M, s.EfConstruction = vectorstore.AlgorithmHNSW, m, ef
+		return s.String()
+	}
+	ivf := func(lists int) string {
+		s := base
+		s.NumLists = lists
+		return s.String()
+	}
+
+	tests := []struct {
+		name    string
+		grid    grid
+		want    []string
+		wantErr string
+	}{
+		{
+			"cartesian product",
+			grid{Sweeps: []gridSweep{{Algorithm: "hnsw", M: []int{8, 16}, EfConstruction: []int{32, 64}}}},
+			[]string{hnsw(8, 32), hnsw(8, 64), hnsw(16, 32), hnsw(16, 64)},
+			"",
+		},
+		{
+			"unlisted parameters keep the env value",
+			grid{Sweeps: []gridSweep{{Algorithm: "vector-hnsw", M: []int{32}}}},
+			[]string{hnsw(32, 64)},
+			"",
+		},
+		{
+			"several algorithms",
+			grid{Sweeps: []gridSweep{{Algorithm: "ivf", NumLists: []int{1, 10}}, {Algorithm: "hnsw"}}},
+			[]string{ivf(1), ivf(10), hnsw(16, 64)},
+			"",
+		},
+		{
+			"duplicates dropped",
+			grid{Sweeps: []gridSweep{{Algorithm: "ivf", NumLists: []int{5, 5}}, {Algorithm: "ivf", NumLists: []int{5, 10}}}},
+			[]string{ivf(5), ivf(10)},
+			"",
+		},
+		{"no sweeps", grid{}, nil, "the grid has no sweeps"},
+		{"unknown algorithm", grid{Sweeps: []gridSweep{{Algorithm: "flat"}}}, nil, `sweep 1: unsupported algorithm "flat"`},
+		{"parameter of another algorithm", grid{Sweeps: []gridSweep{{Algorithm: "ivf"}, {Algorithm: "ivf", M: []int{8}}}}, nil, "sweep 2: m does not apply to vector-ivf"},
+		{"zero value", grid{Sweeps: []gridSweep{{Algorithm: "diskann", MaxDegree: []int{0}}}}, nil, "maxDegree values must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := tt.grid.expand(base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, spec := range specs {
+				got = append(got, spec.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("specs =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadGrid(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	yamlGrid := write("grid.yaml", "sweeps:\n  - algorithm: hnsw\n    m: [8, 16]\n  - algorithm: diskann\n    lBuild: [10]\n")
+	jsonGrid := write("grid.json", `{"sweeps": [{"algorithm": "hnsw", "m": [8, 16]}, {"algorithm": "diskann", "lBuild": [10]}]}`)
+	for _, path := range []string{yamlGrid, jsonGrid} {
+		g, err := loadGrid(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.Sweeps) != 2 || !slices.Equal(g.Sweeps[0].M, []int{8, 16}) || !slices.Equal(g.Sweeps[1].LBuild, []int{10}) {
+			t.Errorf("%s parsed as %+v", filepath.Base(path), g)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"typo.yaml": "sweeps:\n  - algorithm: hnsw\n    efConstrution: [32]\n",
+		"typo.json": `{"sweeps": [{"algorithm": "hnsw", "efConstrution": [32]}]}`,
+		"bad.yaml":  "sweeps: [",
+	} {
+		if _, err := loadGrid(write(name, content)); err == nil || !strings.Contains(err.Error(), "invalid grid file") {
+			t.Errorf("%s: err = %v, want an invalid grid file error", name, err)
+		}
+	}
+
+	if _, err := loadGrid(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("missing grid file loaded")
+	}
+}
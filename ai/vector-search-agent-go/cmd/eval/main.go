@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/quality"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run builds each index algorithm on a temporary collection and compares them, or
+// with --grid sweeps index parameters and recommends the best combination
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Getenv, vectorstore.IndexSpecFromEnv(), os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	queries, err := resolveQueries(opts)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, DocumentDB: true, VectorIndex: true})
+	if err != nil {
+		return err
+	}
+
+	// Stop after the current step on Ctrl+C; temporary collections are still dropped
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	// Create Azure OpenAI clients
+	openaiClients, err := clients.NewOpenAIClients(cfg.OpenAI)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenAI clients: %w", err)
+	}
+
+	// Connect to vector store
+	vsConfig := cfg.VectorStore
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	// Load every stored document once: it is copied into each temporary collection
+	// and its vector is part of the exact-search baseline
+	var docs []models.HotelForVectorStore
+	var corpus []bench.Doc
+	_, err = store.ExportHotels(ctx, vectorstore.ExportOptions{IncludeVectors: true}, func(hotel models.HotelForVectorStore) error {
+		docs = append(docs, hotel)
+		corpus = append(corpus, bench.Doc{ID: hotel.HotelID, Vector: hotel.DescriptionVector})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load documents: %w", err)
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("%w: collection %s is empty; run cmd/upload first", cli.ErrData, vsConfig.CollectionName)
+	}
+	if dims := len(docs[0].DescriptionVector); dims != opts.Specs[0].Dimensions {
+		return fmt.Errorf("%w: %w: stored vectors have %d dimensions but EMBEDDING_DIMENSIONS is %d", cli.ErrData, vectorstore.ErrDimensionMismatch, dims, opts.Specs[0].Dimensions)
+	}
+
+	similarity := opts.Specs[0].Similarity
+	baseline := &quality.Baseline{Corpus: corpus, Similarity: similarity}
+	fmt.Fprintf(os.Stderr, "Embedding %d queries...\n", len(queries))
+	evalQueries := make([]evalQuery, 0, len(queries))
+	for _, query := range queries {
+		vector, err := openaiClients.GenerateEmbedding(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to embed query %q: %w", query, err)
+		}
+		exact, err := baseline.ExactSearch(ctx, vector, opts.K)
+		if err != nil {
+			return err
+		}
+		evalQueries = append(evalQueries, evalQuery{vector: vector, exact: exact})
+	}
+
+	e := &evaluator{
+		open:         func(name string) evalCollection { return store.WithCollection(name) },
+		prefix:       fmt.Sprintf("%s_eval_%d", vsConfig.CollectionName, time.Now().Unix()),
+		indexName:    vsConfig.IndexName,
+		docs:         docs,
+		queries:      evalQueries,
+		k:            opts.K,
+		iterations:   opts.Iterations,
+		readyTimeout: opts.ReadyTimeout,
+		pollInterval: opts.PollInterval,
+		out:          os.Stdout,
+	}
+
+	rep := report{
+		GeneratedAt: time.Now().UTC(),
+		Build:       buildinfo.Read(),
+		Source:      sourceReport{Database: vsConfig.DatabaseName, Collection: vsConfig.CollectionName, Documents: len(docs)},
+		Settings:    settingsReport{K: opts.K, Iterations: opts.Iterations, Queries: len(queries), Similarity: similarity},
+	}
+
+	if opts.GridFile != "" {
+		fmt.Fprintf(os.Stderr, "Sweeping %d index combinations from %s\n", len(opts.Specs), opts.GridFile)
+		return tune(ctx, e, opts, &rep, os.Stdout)
+	}
+
+	rep.Results = e.run(ctx, opts.Specs)
+	rep.render(os.Stdout)
+
+	if opts.JSONOut != "" {
+		if err := rep.writeJSON(opts.JSONOut); err != nil {
+			return err
+		}
+		fmt.Printf("\nJSON report written to %s\n", opts.JSONOut)
+	}
+
+	failed := rep.failed()
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case failed == len(rep.Results):
+		return fmt.Errorf("all %d algorithms failed", failed)
+	case failed > 0:
+		return fmt.Errorf("%w: %d of %d algorithms failed", cli.ErrPartial, failed, len(rep.Results))
+	}
+	return nil
+}
+
+// resolveQueries reads the queries file or generates queries from the data file
+func resolveQueries(opts *options) ([]string, error) {
+	if opts.QueriesFile != "" {
+		return bench.LoadQueries(opts.QueriesFile)
+	}
+
+	hotels, err := vectorstore.LoadHotels(opts.DataFile)
+	if err != nil {
+		return nil, err
+	}
+	queries := bench.GenerateQueries(hotels, opts.Generate)
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no hotels in %s to generate queries from", opts.DataFile)
+	}
+	return queries, nil
+}
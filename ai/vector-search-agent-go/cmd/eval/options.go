@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	defaultAlgorithms   = "ivf,hnsw,diskann"
+	defaultIterations   = 3
+	defaultK            = 5
+	defaultGenerate     = 10
+	defaultDataFile     = "../data/Hotels.json"
+	defaultReadyTimeout = 2 * time.Minute
+	defaultPollInterval = 2 * time.Second
+	defaultMinRecall    = 0.95
+	defaultTuneResults  = "eval-tune.json"
+)
+
+// options holds the resolved cmd/eval settings
+type options struct {
+	// Specs holds one index spec per algorithm to evaluate, in flag order
+	Specs        []vectorstore.VectorIndexSpec
+	QueriesFile  string
+	DataFile     string
+	Generate     int
+	Iterations   int
+	K            int
+	JSONOut      string
+	ReadyTimeout time.Duration
+	PollInterval time.Duration
+	// GridFile switches to tuning: Specs holds every combination in the grid, measured
+	// on one temporary collection, and the results are ranked against MinRecall
+	GridFile  string
+	MinRecall float64
+	// Resume keeps the results already in JSONOut and measures only the rest of the grid
+	Resume     bool
+	Verbosity  cli.Verbosity
+	ConfigFile string
+}
+
+// parseOptions resolves options from command-line arguments, using getenv for defaults
+// and defaults (from vectorstore.IndexSpecFromEnv) for the index parameters.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, defaults vectorstore.VectorIndexSpec, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	opts := options{DataFile: getenv("DATA_FILE_WITHOUT_VECTORS")}
+	if opts.DataFile == "" {
+		opts.DataFile = defaultDataFile
+	}
+
+	var algorithms string
+	spec := defaults
+	fs.StringVar(&algorithms, "algorithms", defaultAlgorithms, "Comma-separated index algorithms to compare: ivf, hnsw, diskann (or their vector- names)")
+	fs.StringVar(&spec.Similarity, "similarity", spec.Similarity, "Similarity metric for every index and the exact baseline: COS, IP, or L2 (env VECTOR_SIMILARITY)")
+	fs.StringVar(&opts.QueriesFile, "queries", "", "File with one query per line; blank lines and lines starting with # are ignored")
+	fs.StringVar(&opts.DataFile, "data", opts.DataFile, "Hotel data file used to generate queries when --queries is not set (env DATA_FILE_WITHOUT_VECTORS)")
+	fs.IntVar(&opts.Generate, "generate", defaultGenerate, "Number of queries to generate from the data file when --queries is not set")
+	fs.IntVar(&opts.Iterations, "iterations", defaultIterations, "Measured searches per query and algorithm")
+	fs.IntVar(&opts.K, "k", defaultK, "Number of nearest neighbors to retrieve and score recall@k against")
+	fs.StringVar(&opts.JSONOut, "json", "", "Also write a JSON report to this file (with --grid, default "+defaultTuneResults+", rewritten after every combination)")
+	fs.DurationVar(&opts.ReadyTimeout, "ready-timeout", defaultReadyTimeout, "How long to wait for each index to serve a canary search")
+	fs.DurationVar(&opts.PollInterval, "poll-interval", defaultPollInterval, "Delay between readiness checks")
+	fs.StringVar(&opts.GridFile, "grid", "", "YAML or JSON file of index parameters to sweep; ranks every combination instead of comparing --algorithms")
+	fs.Float64Var(&opts.MinRecall, "min-recall", defaultMinRecall, "With --grid, the recall@k a combination needs to be recommended, from 0 to 1")
+	fs.BoolVar(&opts.Resume, "resume", false, "With --grid, keep the results already in the --json file and measure only the remaining combinations")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: eval [flags]\n\n")
+		fmt.Fprintf(output, "Copies the vectorized documents into one temporary collection per index algorithm,\n")
+		fmt.Fprintf(output, "measures search latency and recall@k against exact search, and drops each copy.\n")
+		fmt.Fprintf(output, "Algorithm parameters come from IVF_NUM_LISTS, HNSW_M, HNSW_EF_CONSTRUCTION,\n")
+		fmt.Fprintf(output, "DISKANN_MAX_DEGREE, and DISKANN_L_BUILD.\n")
+		fmt.Fprintf(output, "With --grid, sweeps the listed parameter combinations on one temporary collection,\n")
+		fmt.Fprintf(output, "ranks them, and prints the recommended settings as environment variables.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	algorithmsSet := false
+	fs.Visit(func(f *flag.Flag) {
+		algorithmsSet = algorithmsSet || f.Name == "algorithms"
+	})
+
+	err := opts.validate()
+	switch {
+	case err != nil:
+	case opts.GridFile != "" && algorithmsSet:
+		err = errors.New("--grid and --algorithms cannot be used together; list the algorithms in the grid file")
+	case opts.GridFile != "":
+		opts.Specs, err = parseGrid(opts.GridFile, spec)
+		if opts.JSONOut == "" {
+			opts.JSONOut = defaultTuneResults
+		}
+	default:
+		opts.Specs, err = parseAlgorithms(algorithms, spec)
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// parseAlgorithms returns one copy of spec per algorithm in list, accepting short names
+// such as hnsw for vector-hnsw
+func parseAlgorithms(list string, spec vectorstore.VectorIndexSpec) ([]vectorstore.VectorIndexSpec, error) {
+	if err := checkSimilarity(spec.Similarity); err != nil {
+		return nil, err
+	}
+
+	var specs []vectorstore.VectorIndexSpec
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		name, ok := algorithmName(name)
+		if !ok {
+			return nil, fmt.Errorf("unsupported algorithm %q in --algorithms: use ivf, hnsw, or diskann", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		s := spec
+		s.Algorithm = name
+		specs = append(specs, s)
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("--algorithms must list at least one algorithm")
+	}
+	return specs, nil
+}
+
+// parseGrid returns every combination in the grid file, starting from spec
+func parseGrid(path string, spec vectorstore.VectorIndexSpec) ([]vectorstore.VectorIndexSpec, error) {
+	if err := checkSimilarity(spec.Similarity); err != nil {
+		return nil, err
+	}
+	g, err := loadGrid(path)
+	if err != nil {
+		return nil, err
+	}
+	return g.expand(spec)
+}
+
+// checkSimilarity rejects unsupported --similarity values
+func checkSimilarity(similarity string) error {
+	switch similarity {
+	case "COS", "IP", "L2":
+		return nil
+	}
+	return fmt.Errorf("unsupported --similarity %q: use COS, IP, or L2", similarity)
+}
+
+// algorithmName returns the full name of an index algorithm, accepting short names
+// such as hnsw for vector-hnsw, and reports whether it is supported
+func algorithmName(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "vector-") {
+		name = "vector-" + name
+	}
+	switch name {
+	case vectorstore.AlgorithmIVF, vectorstore.AlgorithmHNSW, vectorstore.AlgorithmDiskANN:
+		return name, true
+	}
+	return name, false
+}
+
+// validate checks option ranges
+func (o *options) validate() error {
+	switch {
+	case o.Iterations < 1:
+		return errors.New("--iterations must be at least 1")
+	case o.K < 1:
+		return errors.New("--k must be at least 1")
+	case o.K > vectorstore.MaxK():
+		// recall@k is scored against k exact neighbors, so a k the store would clamp
+		// is refused rather than scored against fewer results
+		return fmt.Errorf("--k must be at most %d; raise MAX_NEAREST_NEIGHBORS to search more", vectorstore.MaxK())
+	case o.QueriesFile == "" && o.Generate < 1:
+		return errors.New("--generate must be at least 1 when --queries is not set")
+	case o.ReadyTimeout <= 0 || o.PollInterval <= 0:
+		return errors.New("--ready-timeout and --poll-interval must be positive")
+	case o.MinRecall < 0 || o.MinRecall > 1:
+		return errors.New("--min-recall must be between 0 and 1")
+	case o.Resume && o.GridFile == "":
+		return errors.New("--resume only applies to --grid")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func envSpec() vectorstore.VectorIndexSpec {
+	return vectorstore.VectorIndexSpec{
+		Algorithm: vectorstore.AlgorithmIVF, Similarity: "COS", Dimensions: 1536,
+		NumLists: 10, M: 16, EfConstruction: 64, MaxDegree: 20, LBuild: 10,
+	}
+}
+
+func noEnv(string) string { return "" }
+
+func TestParseOptionsAlgorithms(t *testing.T) {
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{defaultAlgorithms, []string{vectorstore.AlgorithmIVF, vectorstore.AlgorithmHNSW, vectorstore.AlgorithmDiskANN}},
+		{"HNSW, vector-ivf", []string{vectorstore.AlgorithmHNSW, vectorstore.AlgorithmIVF}},
+		{"diskann,,diskann", []string{vectorstore.AlgorithmDiskANN}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions([]string{"--algorithms", tt.list, "--similarity", "L2"}, noEnv, envSpec(), &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			var got []string
+			for _, spec := range opts.Specs {
+				got = append(got, spec.Algorithm)
+				if spec.Similarity != "L2" || spec.M != 16 || spec.NumLists != 10 {
+					t.Errorf("spec = %+v, want the env parameters with L2", spec)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("algorithms = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsDefaults(t *testing.T) {
+	env := map[string]string{"DATA_FILE_WITHOUT_VECTORS": "hotels.json"}
+	opts, err := parseOptions(nil, func(name string) string { return env[name] }, envSpec(), &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.DataFile != "hotels.json" || opts.Iterations != defaultIterations || opts.K != defaultK ||
+		opts.Generate != defaultGenerate || opts.ReadyTimeout != defaultReadyTimeout || len(opts.Specs) != 3 {
+		t.Errorf("defaults = %+v", opts)
+	}
+}
+
+func TestParseOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"algorithm", []string{"--algorithms", "ivf,flat"}, `unsupported algorithm "vector-flat"`},
+		{"no algorithms", []string{"--algorithms", " , "}, "--algorithms must list at least one algorithm"},
+		{"similarity", []string{"--similarity", "cosine"}, `unsupported --similarity "cosine"`},
+		{"iterations", []string{"--iterations", "0"}, "--iterations must be at least 1"},
+		{"k", []string{"--k", "0"}, "--k must be at least 1"},
+		{"k above max", []string{"--k", "21"}, "--k must be at most 20"},
+		{"generate", []string{"--generate", "0"}, "--generate must be at least 1"},
+		{"timeout", []string{"--poll-interval", "0s"}, "--ready-timeout and --poll-interval must be positive"},
+		{"min recall", []string{"--min-recall", "1.5"}, "--min-recall must be between 0 and 1"},
+		{"resume without grid", []string{"--resume"}, "--resume only applies to --grid"},
+		{"grid and algorithms", []string{"--grid", "grid.yaml", "--algorithms", "ivf"}, "--grid and --algorithms cannot be used together"},
+		{"missing grid", []string{"--grid", "testdata/missing.yaml"}, "failed to read grid file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, noEnv, envSpec(), &out); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+			if !strings.Contains(out.String(), "Usage: eval") {
+				t.Errorf("usage was not printed:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestParseOptionsGrid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grid.yaml")
+	if err := os.WriteFile(path, []byte("sweeps:\n  - algorithm: hnsw\n    m: [8, 16]\n  - algorithm: ivf\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	opts, err := parseOptions([]string{"--grid", path, "--min-recall", "0.9", "--resume", "--similarity", "IP"}, noEnv, envSpec(), &out)
+	if err != nil {
+		t.Fatalf("parseOptions: %v\n%s", err, out.String())
+	}
+	if len(opts.Specs) != 3 || opts.Specs[0].M != 8 || opts.Specs[1].M != 16 || opts.Specs[2].Algorithm != vectorstore.AlgorithmIVF {
+		t.Errorf("specs = %+v, want hnsw m=8, m=16, then ivf", opts.Specs)
+	}
+	for _, spec := range opts.Specs {
+		if spec.Similarity != "IP" || spec.EfConstruction != 64 {
+			t.Errorf("spec = %+v, want the env parameters with IP", spec)
+		}
+	}
+	if opts.JSONOut != defaultTuneResults || opts.MinRecall != 0.9 || !opts.Resume {
+		t.Errorf("options = %+v, want the default results file", opts)
+	}
+
+	opts, err = parseOptions([]string{"--grid", path, "--json", "sweep.json"}, noEnv, envSpec(), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.JSONOut != "sweep.json" || opts.MinRecall != defaultMinRecall {
+		t.Errorf("options = %+v, want --json kept", opts)
+	}
+}
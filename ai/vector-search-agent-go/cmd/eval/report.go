@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// algorithmResult holds the measurements for one index algorithm
+type algorithmResult struct {
+	Algorithm    string                      `json:"algorithm"`
+	Spec         string                      `json:"spec"`
+	Index        vectorstore.VectorIndexSpec `json:"index"`
+	Collection   string                      `json:"collection"`
+	BuildSeconds float64                     `json:"buildSeconds"`
+	Search       bench.LatencyStats          `json:"search"`
+	Recall       float64                     `json:"recall"`
+	MinRecall    float64                     `json:"minRecall"`
+	Searches     int                         `json:"searches"`
+	Errors       int                         `json:"errors"`
+	// Error is set when the algorithm could not be evaluated
+	Error string `json:"error,omitempty"`
+	// CleanupError is set when the temporary collection could not be dropped
+	CleanupError string `json:"cleanupError,omitempty"`
+
+	firstErr string
+}
+
+// sourceReport describes the collection the documents were copied from
+type sourceReport struct {
+	Database   string `json:"database"`
+	Collection string `json:"collection"`
+	Documents  int    `json:"documents"`
+}
+
+// settingsReport records the evaluation parameters
+type settingsReport struct {
+	K          int    `json:"k"`
+	Iterations int    `json:"iterations"`
+	Queries    int    `json:"queries"`
+	Similarity string `json:"similarity"`
+}
+
+// report is the full evaluation result
+type report struct {
+	GeneratedAt time.Time         `json:"generatedAt"`
+	Build       buildinfo.Info    `json:"build"`
+	Source      sourceReport      `json:"source"`
+	Settings    settingsReport    `json:"settings"`
+	Results     []algorithmResult `json:"results"`
+	// Tuning is set for a --grid sweep
+	Tuning *tuningReport `json:"tuning,omitempty"`
+}
+
+// failed returns the number of algorithms that could not be evaluated
+func (r *report) failed() int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// render prints the comparison table
+func (r *report) render(w io.Writer) {
+	fmt.Fprintln(w, "\n=== INDEX COMPARISON ===")
+	fmt.Fprintf(w, "Source: %s.%s, %d documents\n", r.Source.Database, r.Source.Collection, r.Source.Documents)
+	fmt.Fprintf(w, "Settings: k=%d, %d queries x %d iterations, similarity %s\n\n",
+		r.Settings.K, r.Settings.Queries, r.Settings.Iterations, r.Settings.Similarity)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ALGORITHM\tBUILD\tP50\tP95\tP99\tRECALL@%d\tWORST\tERRORS\tSTATUS\n", r.Settings.K)
+	for _, res := range r.Results {
+		status := "ok"
+		if res.Error != "" {
+			status = "FAILED: " + res.Error
+		}
+		if res.CleanupError != "" {
+			status += " (not dropped)"
+		}
+		s := res.Search
+		fmt.Fprintf(tw, "%s\t%.1fs\t%s\t%s\t%s\t%.3f\t%.3f\t%d/%d\t%s\n",
+			res.Algorithm, res.BuildSeconds, bench.Round(s.P50), bench.Round(s.P95), bench.Round(s.P99),
+			res.Recall, res.MinRecall, res.Errors, res.Searches, status)
+	}
+	tw.Flush()
+}
+
+// writeJSON writes the report to path through a temporary file that is renamed into
+// place, so a sweep interrupted while saving keeps its previous results
+func (r *report) writeJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// loadReport reads a report written by writeJSON. A missing file yields nil.
+func loadReport(path string) (*report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return &r, nil
+}
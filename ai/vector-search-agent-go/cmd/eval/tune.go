@@ -0,0 +1,239 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// tuningReport records a --grid sweep
+type tuningReport struct {
+	Grid         string  `json:"grid"`
+	MinRecall    float64 `json:"minRecall"`
+	Combinations int     `json:"combinations"`
+	// Partial is set while the sweep runs and when it stopped before the last combination
+	Partial     bool                         `json:"partial"`
+	Recommended *vectorstore.VectorIndexSpec `json:"recommended,omitempty"`
+}
+
+// tune sweeps opts.Specs on one temporary collection, rewriting the report at
+// opts.JSONOut after every combination, and prints the ranked results with the
+// recommended settings. With opts.Resume, combinations measured successfully by an
+// earlier run of the same sweep are kept and not measured again.
+func tune(ctx context.Context, e *evaluator, opts *options, rep *report, out io.Writer) error {
+	rep.Tuning = &tuningReport{Grid: opts.GridFile, MinRecall: opts.MinRecall, Combinations: len(opts.Specs), Partial: true}
+
+	var done []algorithmResult
+	if opts.Resume {
+		previous, err := loadReport(opts.JSONOut)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			if previous.Source != rep.Source || previous.Settings != rep.Settings || previous.Tuning == nil {
+				return fmt.Errorf("%w: %s was written by a sweep with other settings or data; delete it or run without --resume", cli.ErrData, opts.JSONOut)
+			}
+			// Failed combinations are measured again
+			for _, result := range previous.Results {
+				if result.Error == "" {
+					done = append(done, result)
+				}
+			}
+		}
+	}
+
+	results, err := e.sweep(ctx, opts.Specs, done, func(results []algorithmResult) error {
+		rep.Results = results
+		return rep.writeJSON(opts.JSONOut)
+	})
+	rep.Results = results
+	rep.Tuning.Partial = len(results) < len(opts.Specs)
+	if best, _ := rep.recommend(); best != nil {
+		rep.Tuning.Recommended = &best.Index
+	}
+	if saveErr := rep.writeJSON(opts.JSONOut); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	rep.renderTuning(out)
+	fmt.Fprintf(out, "\nJSON report written to %s\n", opts.JSONOut)
+
+	failed := rep.failed()
+	switch {
+	case err != nil:
+		return err
+	case ctx.Err() != nil:
+		fmt.Fprintf(out, "Run again with --resume to measure the remaining %d combinations.\n", len(opts.Specs)-len(results))
+		return ctx.Err()
+	case failed == len(rep.Results):
+		return fmt.Errorf("all %d combinations failed", failed)
+	case failed > 0:
+		return fmt.Errorf("%w: %d of %d combinations failed", cli.ErrPartial, failed, len(rep.Results))
+	}
+	return nil
+}
+
+// sweep measures each spec on one temporary collection, dropping the index and building
+// the next one in its place. done holds results from an earlier run whose specs are not
+// measured again. save is called with every result so far after each spec, so an
+// interrupted sweep loses at most the combination in progress, which is discarded
+// rather than ranked on a partial measurement.
+func (e *evaluator) sweep(ctx context.Context, specs []vectorstore.VectorIndexSpec, done []algorithmResult, save func([]algorithmResult) error) ([]algorithmResult, error) {
+	results := slices.Clone(done)
+	measured := make(map[vectorstore.VectorIndexSpec]bool, len(done))
+	for _, result := range done {
+		measured[result.Index] = true
+	}
+	var pending []vectorstore.VectorIndexSpec
+	for _, spec := range specs {
+		if !measured[spec] {
+			pending = append(pending, spec)
+		}
+	}
+	if len(done) > 0 {
+		fmt.Fprintf(e.out, "Resuming: %d of %d combinations already measured\n", len(specs)-len(pending), len(specs))
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	name := e.prefix + "_tune"
+	coll := e.open(name)
+	defer func() {
+		dropCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), dropTimeout)
+		defer cancel()
+		if err := coll.DropCollection(dropCtx); err != nil {
+			slog.WarnContext(ctx, "failed to drop temporary collection", "collection", name, "err", err)
+			fmt.Fprintf(e.out, "Warning: could not drop %s; drop it manually\n", name)
+		}
+	}()
+
+	fmt.Fprintf(e.out, "Copying %d documents into %s...\n", len(e.docs), name)
+	if err := e.copyDocs(ctx, coll); err != nil {
+		return results, err
+	}
+
+	indexed := false
+	for i, spec := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		fmt.Fprintf(e.out, "\n== [%d/%d] %s ==\n", len(specs)-len(pending)+i+1, len(specs), spec)
+
+		if indexed {
+			if err := coll.DropVectorIndex(ctx, e.indexName); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				return results, fmt.Errorf("failed to drop the index before the next combination: %w", err)
+			}
+		}
+
+		result := algorithmResult{Algorithm: spec.Algorithm, Spec: spec.String(), Index: spec, Collection: name}
+		indexed = e.buildAndMeasure(ctx, coll, spec, &result)
+		if ctx.Err() != nil {
+			fmt.Fprintln(e.out, "Interrupted; this combination was not recorded")
+			break
+		}
+		if result.Error != "" {
+			fmt.Fprintf(e.out, "Failed: %s\n", result.Error)
+		}
+
+		results = append(results, result)
+		if err := save(results); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// rank orders results for the tuning table. Combinations that reach minRecall come
+// first, fastest p95 search first and then fastest build; the rest follow by falling
+// recall; failed combinations come last.
+func rank(results []algorithmResult, minRecall float64) []algorithmResult {
+	tier := func(r algorithmResult) int {
+		switch {
+		case r.Error != "":
+			return 2
+		case r.Recall >= minRecall:
+			return 0
+		}
+		return 1
+	}
+
+	ranked := slices.Clone(results)
+	slices.SortStableFunc(ranked, func(a, b algorithmResult) int {
+		if c := cmp.Compare(tier(a), tier(b)); c != 0 {
+			return c
+		}
+		switch tier(a) {
+		case 0:
+			return cmp.Or(cmp.Compare(a.Search.P95, b.Search.P95), cmp.Compare(a.BuildSeconds, b.BuildSeconds))
+		case 1:
+			return cmp.Or(cmp.Compare(b.Recall, a.Recall), cmp.Compare(a.Search.P95, b.Search.P95))
+		}
+		return 0
+	})
+	return ranked
+}
+
+// recommend returns the best-ranked combination and whether it reached the minimum
+// recall, or nil when no combination was measured successfully
+func (r *report) recommend() (*algorithmResult, bool) {
+	ranked := rank(r.Results, r.Tuning.MinRecall)
+	if len(ranked) == 0 || ranked[0].Error != "" {
+		return nil, false
+	}
+	return &ranked[0], ranked[0].Recall >= r.Tuning.MinRecall
+}
+
+// renderTuning prints the ranked sweep table and the recommended settings
+func (r *report) renderTuning(w io.Writer) {
+	fmt.Fprintln(w, "\n=== INDEX TUNING ===")
+	fmt.Fprintf(w, "Source: %s.%s, %d documents\n", r.Source.Database, r.Source.Collection, r.Source.Documents)
+	fmt.Fprintf(w, "Settings: k=%d, %d queries x %d iterations, similarity %s, minimum recall %.2f\n",
+		r.Settings.K, r.Settings.Queries, r.Settings.Iterations, r.Settings.Similarity, r.Tuning.MinRecall)
+	if r.Tuning.Partial {
+		fmt.Fprintf(w, "Partial results: %d of %d combinations measured\n", len(r.Results), r.Tuning.Combinations)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "RANK\tINDEX\tBUILD\tP50\tP95\tP99\tRECALL@%d\tWORST\tERRORS\tSTATUS\n", r.Settings.K)
+	for i, res := range rank(r.Results, r.Tuning.MinRecall) {
+		status := "ok"
+		switch {
+		case res.Error != "":
+			status = "FAILED: " + res.Error
+		case res.Recall < r.Tuning.MinRecall:
+			status = "below minimum recall"
+		}
+		s := res.Search
+		fmt.Fprintf(tw, "%d\t%s\t%.1fs\t%s\t%s\t%s\t%.3f\t%.3f\t%d/%d\t%s\n",
+			i+1, res.Spec, res.BuildSeconds, bench.Round(s.P50), bench.Round(s.P95), bench.Round(s.P99),
+			res.Recall, res.MinRecall, res.Errors, res.Searches, status)
+	}
+	tw.Flush()
+
+	best, ok := r.recommend()
+	switch {
+	case best == nil:
+		fmt.Fprintln(w, "\nNo combination was measured successfully; nothing to recommend.")
+		return
+	case ok:
+		fmt.Fprintf(w, "\nRecommended: %s\n", best.Spec)
+	default:
+		fmt.Fprintf(w, "\nNo combination reached recall@%d of %.2f; the highest recall was %s\n", r.Settings.K, r.Tuning.MinRecall, best.Spec)
+	}
+	fmt.Fprintln(w, "Paste into .env, then run cmd/reindex to apply:")
+	fmt.Fprintf(w, "  %s\n", strings.Join(best.Index.EnvLines(), "\n  "))
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// searchesPerSpec is the canary search plus 2 queries x 3 iterations of newTestEvaluator
+const searchesPerSpec = 7
+
+func hnswSpecs(ms ...int) []vectorstore.VectorIndexSpec {
+	specs := make([]vectorstore.VectorIndexSpec, len(ms))
+	for i, m := range ms {
+		specs[i] = vectorstore.VectorIndexSpec{Algorithm: vectorstore.AlgorithmHNSW, Similarity: "COS", Dimensions: 2, M: m, EfConstruction: 64}
+	}
+	return specs
+}
+
+func newTestTuner(db *fakeDatabase) *evaluator {
+	e := newTestEvaluator(db, 3)
+	e.indexName = "vectorIndex"
+	return e
+}
+
+func tuneOptions(t *testing.T, specs []vectorstore.VectorIndexSpec) *options {
+	return &options{Specs: specs, GridFile: "grid.yaml", MinRecall: 0.5, JSONOut: filepath.Join(t.TempDir(), "tune.json")}
+}
+
+func tuneReport() *report {
+	return &report{
+		Source:   sourceReport{Database: "Hotels", Collection: "hotels", Documents: 3},
+		Settings: settingsReport{K: 2, Iterations: 3, Queries: 2, Similarity: "COS"},
+	}
+}
+
+func TestSweepRebuildsIndexOnOneCollection(t *testing.T) {
+	db := &fakeDatabase{}
+	e := newTestTuner(db)
+	specs := hnswSpecs(8, 16, 32)
+
+	var saved []int
+	results, err := e.sweep(context.Background(), specs, nil, func(results []algorithmResult) error {
+		saved = append(saved, len(results))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(db.opened, []string{"hotels_eval_1_tune"}) {
+		t.Fatalf("opened %v, want one temporary collection", db.opened)
+	}
+	coll := db.collections["hotels_eval_1_tune"]
+	if len(coll.docs) != 3 || !coll.dropped {
+		t.Errorf("copied %d documents, dropped %v; want 3 copied once and the collection dropped", len(coll.docs), coll.dropped)
+	}
+	if !slices.Equal(coll.built, specs) || !slices.Equal(coll.droppedIndexes, []string{"vectorIndex", "vectorIndex"}) {
+		t.Errorf("built %v and dropped %v, want each spec built with the index dropped in between", coll.built, coll.droppedIndexes)
+	}
+	if !slices.Equal(saved, []int{1, 2, 3}) {
+		t.Errorf("saved after %v results, want after every combination", saved)
+	}
+	for i, result := range results {
+		if result.Index != specs[i] || result.Error != "" || result.Searches != 6 || result.Recall != 0.75 {
+			t.Errorf("result %d = %+v", i, result)
+		}
+	}
+}
+
+func TestSweepContinuesAfterFailedBuild(t *testing.T) {
+	db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+		c.rejectSpec = func(spec vectorstore.VectorIndexSpec) bool { return spec.M == 16 }
+	}}
+	e := newTestTuner(db)
+
+	results, err := e.sweep(context.Background(), hnswSpecs(8, 16, 32), nil, func([]algorithmResult) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results[1].Error != "invalid index parameters" || results[2].Error != "" {
+		t.Fatalf("results = %+v, want only the second combination failed", results)
+	}
+	// Nothing was built for the failed combination, so only the first index is dropped
+	coll := db.collections["hotels_eval_1_tune"]
+	if len(coll.droppedIndexes) != 1 {
+		t.Errorf("dropped %d indexes, want 1", len(coll.droppedIndexes))
+	}
+}
+
+func TestSweepStopsOnSaveError(t *testing.T) {
+	db := &fakeDatabase{}
+	saveErr := errors.New("disk full")
+
+	results, err := newTestTuner(db).sweep(context.Background(), hnswSpecs(8, 16), nil, func([]algorithmResult) error { return saveErr })
+	if !errors.Is(err, saveErr) || len(results) != 1 {
+		t.Fatalf("err = %v with %d results, want the save error after the first combination", err, len(results))
+	}
+	if !db.collections["hotels_eval_1_tune"].dropped {
+		t.Error("temporary collection was not dropped")
+	}
+}
+
+func TestTuneSavesPartialResultsAndResumes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel during the second combination's measurements
+	db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+		c.onSearch = func() {
+			if c.searches == searchesPerSpec+2 {
+				cancel()
+			}
+		}
+	}}
+	specs := hnswSpecs(8, 16, 32)
+	opts := tuneOptions(t, specs)
+
+	err := tune(ctx, newTestTuner(db), opts, tuneReport(), io.Discard)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	saved, err := loadReport(opts.JSONOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Results) != 1 || saved.Results[0].Index != specs[0] || !saved.Tuning.Partial {
+		t.Fatalf("saved report = %+v, want the first combination only, marked partial", saved)
+	}
+	if !db.collections["hotels_eval_1_tune"].dropped {
+		t.Error("temporary collection was not dropped after the interruption")
+	}
+
+	// The resumed sweep measures only the two remaining combinations
+	resumed := &fakeDatabase{}
+	opts.Resume = true
+	var out bytes.Buffer
+	e := newTestTuner(resumed)
+	e.out = &out
+	if err := tune(context.Background(), e, opts, tuneReport(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if built := resumed.collections["hotels_eval_1_tune"].built; !slices.Equal(built, specs[1:]) {
+		t.Errorf("resumed sweep built %v, want %v", built, specs[1:])
+	}
+
+	final, err := loadReport(opts.JSONOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(final.Results) != 3 || final.Tuning.Partial || final.Tuning.Recommended == nil {
+		t.Errorf("final report = %+v, want all 3 combinations and a recommendation", final.Tuning)
+	}
+	// The first combination's latencies survive the round trip through the file
+	if final.Results[0].Search.P50 == 0 || final.Results[0].Search.P50 != saved.Results[0].Search.P50 {
+		t.Errorf("resumed P50 = %v, want %v", final.Results[0].Search.P50, saved.Results[0].Search.P50)
+	}
+	if !strings.Contains(out.String(), "Resuming: 1 of 3 combinations already measured") {
+		t.Errorf("output is missing the resume notice:\n%s", out.String())
+	}
+}
+
+func TestTuneResumeRejectsOtherSweep(t *testing.T) {
+	opts := tuneOptions(t, hnswSpecs(8))
+	if err := tune(context.Background(), newTestTuner(&fakeDatabase{}), opts, tuneReport(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	rep := tuneReport()
+	rep.Settings.K = 10
+	opts.Resume = true
+	db := &fakeDatabase{}
+	err := tune(context.Background(), newTestTuner(db), opts, rep, io.Discard)
+	if !errors.Is(err, cli.ErrData) || !strings.Contains(err.Error(), "other settings or data") {
+		t.Fatalf("err = %v, want a settings mismatch", err)
+	}
+	if len(db.opened) != 0 {
+		t.Errorf("opened %v after the mismatch", db.opened)
+	}
+}
+
+func TestTuneReportsFailedCombinations(t *testing.T) {
+	db := &fakeDatabase{configure: func(name string, c *fakeCollection) {
+		c.rejectSpec = func(spec vectorstore.VectorIndexSpec) bool { return spec.M == 16 }
+	}}
+	err := tune(context.Background(), newTestTuner(db), tuneOptions(t, hnswSpecs(8, 16)), tuneReport(), io.Discard)
+	if !errors.Is(err, cli.ErrPartial) {
+		t.Errorf("err = %v, want ErrPartial", err)
+	}
+}
+
+func tuneResult(name string, recall float64, p95Ms int, buildSeconds float64) algorithmResult {
+	return algorithmResult{
+		Spec:         name,
+		Recall:       recall,
+		BuildSeconds: buildSeconds,
+		Search:       bench.LatencyStats{P95: time.Duration(p95Ms) * time.Millisecond},
+	}
+}
+
+func TestRank(t *testing.T) {
+	failed := tuneResult("failed", 0, 0, 0)
+	failed.Error = "invalid index parameters"
+	results := []algorithmResult{
+		failed,
+		tuneResult("low recall", 0.80, 5, 1),
+		tuneResult("slow", 0.99, 40, 1),
+		tuneResult("fast, slow build", 0.95, 10, 9),
+		tuneResult("lower recall", 0.70, 1, 1),
+		tuneResult("fast", 0.96, 10, 2),
+	}
+
+	var got []string
+	for _, result := range rank(results, 0.9) {
+		got = append(got, result.Spec)
+	}
+	want := []string{"fast", "fast, slow build", "slow", "low recall", "lower recall", "failed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("rank = %v, want %v", got, want)
+	}
+	if results[0].Spec != "failed" {
+		t.Error("rank reordered its input")
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	failed := tuneResult("failed", 0, 0, 0)
+	failed.Error = "boom"
+	tests := []struct {
+		name     string
+		results  []algorithmResult
+		want     string
+		wantMeet bool
+	}{
+		{"meets minimum", []algorithmResult{tuneResult("a", 0.8, 1, 1), tuneResult("b", 0.95, 9, 1)}, "b", true},
+		{"best below minimum", []algorithmResult{tuneResult("a", 0.8, 1, 1), tuneResult("b", 0.85, 9, 1)}, "b", false},
+		{"all failed", []algorithmResult{failed}, "", false},
+		{"nothing measured", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := report{Results: tt.results, Tuning: &tuningReport{MinRecall: 0.9}}
+			best, meets := rep.recommend()
+			got := ""
+			if best != nil {
+				got = best.Spec
+			}
+			if got != tt.want || meets != tt.wantMeet {
+				t.Errorf("recommend() = %q, %v; want %q, %v", got, meets, tt.want, tt.wantMeet)
+			}
+		})
+	}
+}
+
+func TestRenderTuning(t *testing.T) {
+	best := tuneResult("vector-hnsw (m=32)", 0.98, 12, 3)
+	best.Index = vectorstore.VectorIndexSpec{Algorithm: vectorstore.AlgorithmHNSW, Similarity: "COS", Dimensions: 1536, M: 32, EfConstruction: 128}
+	rep := tuneReport()
+	rep.Results = []algorithmResult{tuneResult("vector-ivf (numLists=1)", 0.7, 5, 1), best}
+	rep.Tuning = &tuningReport{MinRecall: 0.9, Combinations: 4, Partial: true}
+
+	var out bytes.Buffer
+	rep.renderTuning(&out)
+	for _, want := range []string{
+		"Partial results: 2 of 4 combinations measured",
+		"RANK", "RECALL@2",
+		"1     vector-hnsw (m=32)",
+		"below minimum recall",
+		"Recommended: vector-hnsw (m=32)",
+		"  VECTOR_INDEX_ALGORITHM=vector-hnsw\n  VECTOR_SIMILARITY=COS\n  HNSW_M=32\n  HNSW_EF_CONSTRUCTION=128\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
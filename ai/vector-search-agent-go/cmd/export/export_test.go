@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// fakeExporter streams a fixed set of documents, projecting out vectors like the store does
+type fakeExporter struct {
+	docs []models.HotelForVectorStore
+	err  error
+}
+
+func (f *fakeExporter) ExportHotels(ctx context.Context, opts vectorstore.ExportOptions, fn func(models.HotelForVectorStore) error) (int, error) {
+	count := 0
+	for _, doc := range f.docs {
+		if !opts.IncludeVectors {
+			doc.DescriptionVector = nil
+		}
+		if err := fn(doc); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, f.err
+}
+
+func exportFixture() []models.HotelForVectorStore {
+	return []models.HotelForVectorStore{
+		{
+			HotelID:            "1",
+			HotelName:          "Stay-Kay City Hotel",
+			Description:        "Close to Times Square.",
+			Category:           "Boutique",
+			Tags:               []string{"view", "concierge"},
+			LastRenovationDate: time.Date(2022, 1, 18, 0, 0, 0, 0, time.UTC),
+			Rating:             3.6,
+			Address:            models.Address{City: "New York", StateProvince: "NY"},
+			DescriptionVector:  []float32{0.0123456789, -0.98765432, 1e-7, 0.5},
+			SchemaVersion:      models.CurrentSchemaVersion,
+		},
+		{
+			HotelID:           "2",
+			HotelName:         "Old Century Hotel",
+			Description:       "A nineteenth century plaza.",
+			Category:          "Boutique",
+			Tags:              []string{"pool"},
+			ParkingIncluded:   true,
+			Rating:            3.6,
+			DescriptionVector: []float32{-0.25, 0.75, 0.3333333, -1},
+			SchemaVersion:     models.CurrentSchemaVersion,
+		},
+	}
+}
+
+// readJSONL decodes one hotel per line, as an import of the JSONL format would
+func readJSONL(t *testing.T, path string) []models.Hotel {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var hotels []models.Hotel
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var hotel models.Hotel
+		if err := json.Unmarshal(scanner.Bytes(), &hotel); err != nil {
+			t.Fatalf("line %d: %v", len(hotels)+1, err)
+		}
+		hotels = append(hotels, hotel)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return hotels
+}
+
+// importExport reads an export back with the loader used for uploads
+func importExport(t *testing.T, opts *options) []models.HotelForVectorStore {
+	t.Helper()
+	var hotels []models.Hotel
+	if opts.Format == formatJSONL {
+		hotels = readJSONL(t, opts.Out)
+	} else {
+		var err error
+		hotels, err = vectorstore.LoadHotels(opts.Out)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	docs := make([]models.HotelForVectorStore, len(hotels))
+	for i, hotel := range hotels {
+		docs[i] = hotel.ToVectorStore()
+	}
+	return docs
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, format := range []string{formatJSON, formatJSONL} {
+		for _, noVectors := range []bool{false, true} {
+			name := format
+			if noVectors {
+				name += " without vectors"
+			}
+			t.Run(name, func(t *testing.T) {
+				opts := &options{Out: filepath.Join(t.TempDir(), "export."+format), Format: format, NoVectors: noVectors}
+
+				count, err := export(context.Background(), &fakeExporter{docs: exportFixture()}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != 2 {
+					t.Errorf("exported %d documents, want 2", count)
+				}
+
+				want := exportFixture()
+				if noVectors {
+					for i := range want {
+						want[i].DescriptionVector = nil
+					}
+				}
+				if got := importExport(t, opts); !reflect.DeepEqual(got, want) {
+					t.Errorf("round trip =\n%+v\nwant\n%+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestExportEmptyCollection(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{formatJSON, "[]\n"},
+		{formatJSONL, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			opts := &options{Out: filepath.Join(t.TempDir(), "export."+tt.format), Format: tt.format}
+
+			count, err := export(context.Background(), &fakeExporter{}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != 0 {
+				t.Errorf("exported %d documents, want 0", count)
+			}
+
+			data, err := os.ReadFile(opts.Out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("output = %q, want %q", data, tt.want)
+			}
+			if got := importExport(t, opts); len(got) != 0 {
+				t.Errorf("imported %d documents from an empty export", len(got))
+			}
+		})
+	}
+}
+
+func TestExportFailureLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	opts := &options{Out: filepath.Join(dir, "export.json"), Format: formatJSON}
+
+	errCursor := errors.New("cursor error")
+	if _, err := export(context.Background(), &fakeExporter{docs: exportFixture(), err: errCursor}, opts); !errors.Is(err, errCursor) {
+		t.Fatalf("err = %v, want %v", err, errCursor)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("failed export left %d files behind", len(entries))
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:         "0 B",
+		1023:      "1023 B",
+		1024:      "1.0 KiB",
+		1536:      "1.5 KiB",
+		5 << 20:   "5.0 MiB",
+		3 << 30:   "3.0 GiB",
+		1<<40 + 1: "1.0 TiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
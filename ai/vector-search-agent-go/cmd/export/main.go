@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run writes the collection to the output file
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	// Load configuration
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C or SIGTERM cancels the run; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	vsConfig := cfg.VectorStore
+
+	fmt.Fprintf(os.Stderr, "Connecting to database: %s\n", vsConfig.DatabaseName)
+
+	// Connect to vector store
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	fmt.Fprintf(os.Stderr, "Exporting collection %s to %s (%s)\n", vsConfig.CollectionName, opts.Out, opts.Format)
+
+	count, err := export(ctx, store, opts)
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	info, err := os.Stat(opts.Out)
+	if err != nil {
+		return fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	if count == 0 {
+		log.Printf("Warning: collection %s is empty; wrote an empty export", vsConfig.CollectionName)
+	}
+	fmt.Printf("Exported %d documents to %s (%s)\n", count, opts.Out, formatBytes(info.Size()))
+	return nil
+}
+
+// exporter streams the documents of a collection
+type exporter interface {
+	ExportHotels(ctx context.Context, opts vectorstore.ExportOptions, fn func(models.HotelForVectorStore) error) (int, error)
+}
+
+// export writes the collection to a temporary file next to opts.Out and renames it
+// into place once complete, so a failed export never leaves a truncated file behind
+func export(ctx context.Context, store exporter, opts *options) (int, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(opts.Out), filepath.Base(opts.Out)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// CreateTemp uses 0600; exports are meant to be shared
+	if err := tmp.Chmod(0o644); err != nil {
+		return 0, fmt.Errorf("failed to set output file permissions: %w", err)
+	}
+
+	w := newDocumentWriter(tmp, opts.Format)
+	count, err := store.ExportHotels(ctx, vectorstore.ExportOptions{IncludeVectors: !opts.NoVectors}, func(hotel models.HotelForVectorStore) error {
+		return w.Write(hotel)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := w.Close(); err != nil {
+		return count, fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return count, fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), opts.Out); err != nil {
+		return count, fmt.Errorf("failed to move output file into place: %w", err)
+	}
+
+	return count, nil
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+)
+
+// Supported output formats
+const (
+	formatJSON  = "json"
+	formatJSONL = "jsonl"
+)
+
+// options holds the resolved cmd/export settings
+type options struct {
+	Out        string
+	Format     string
+	NoVectors  bool
+	Verbosity  cli.Verbosity
+	ConfigFile string
+}
+
+// parseOptions resolves options from command-line arguments.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var opts options
+	fs.StringVar(&opts.Out, "out", "", "Output file path (default \"hotels_export.<format>\")")
+	fs.StringVar(&opts.Format, "format", formatJSON, "Output format: json (an array, readable by upload --data-with-vectors) or jsonl (one document per line)")
+	fs.BoolVar(&opts.NoVectors, "no-vectors", false, "Strip embeddings from the exported documents")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: export [flags]\n\n")
+		fmt.Fprintf(output, "Writes every document in the configured collection to a file.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.Format != formatJSON && opts.Format != formatJSONL {
+		err := fmt.Errorf("--format must be %q or %q, got %q", formatJSON, formatJSONL, opts.Format)
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		err := errors.New("unexpected arguments: export takes flags only")
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+	if opts.Out == "" {
+		opts.Out = "hotels_export." + opts.Format
+	}
+
+	return &opts, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want options
+	}{
+		{"defaults", nil, options{Out: "hotels_export.json", Format: formatJSON}},
+		{"jsonl default name", []string{"--format", "jsonl"}, options{Out: "hotels_export.jsonl", Format: formatJSONL}},
+		{"explicit out", []string{"--out", "backup.json", "--no-vectors"}, options{Out: "backup.json", Format: formatJSON, NoVectors: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if *got != tt.want {
+				t.Errorf("options = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsUsageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown format", []string{"--format", "csv"}, `--format must be "json" or "jsonl", got "csv"`},
+		{"positional argument", []string{"backup.json"}, "unexpected arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, &out); err == nil {
+				t.Fatal("parseOptions accepted invalid arguments")
+			}
+			if !strings.Contains(out.String(), tt.want) || !strings.Contains(out.String(), "Usage: export") {
+				t.Errorf("output is missing %q or the usage:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// documentWriter streams documents to w as a JSON array or as JSON Lines.
+// Only one document is held in memory at a time.
+type documentWriter struct {
+	w      *bufio.Writer
+	format string
+	count  int
+}
+
+// newDocumentWriter returns a writer for the given format
+func newDocumentWriter(w io.Writer, format string) *documentWriter {
+	return &documentWriter{w: bufio.NewWriter(w), format: format}
+}
+
+// Write appends one document
+func (d *documentWriter) Write(doc any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	if d.format == formatJSON {
+		sep := ",\n  "
+		if d.count == 0 {
+			sep = "[\n  "
+		}
+		if _, err := d.w.WriteString(sep); err != nil {
+			return err
+		}
+	}
+
+	if _, err := d.w.Write(data); err != nil {
+		return err
+	}
+	if d.format == formatJSONL {
+		if err := d.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	d.count++
+	return nil
+}
+
+// Close terminates the output and flushes buffered data. A JSON export of zero
+// documents is still a valid empty array.
+func (d *documentWriter) Close() error {
+	if d.format == formatJSON {
+		closing := "\n]\n"
+		if d.count == 0 {
+			closing = "[]\n"
+		}
+		if _, err := d.w.WriteString(closing); err != nil {
+			return err
+		}
+	}
+	return d.w.Flush()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run summarizes the recorded answer feedback
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C or SIGTERM cancels the run; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	// Connect to vector store
+	store, err := vectorstore.NewVectorStore(ctx, cfg.VectorStore)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	created, err := store.SaveFeedback(ctx, opts.Feedback)
+	if err != nil {
+		return err
+	}
+
+	action := "Updated"
+	if created {
+		action = "Recorded"
+	}
+	fmt.Printf("%s %s feedback for result %s in %s\n", action, opts.Feedback.Rating, opts.Feedback.ResultID, cfg.VectorStore.FeedbackCollection)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// options holds the resolved cmd/feedback settings
+type options struct {
+	Feedback   models.Feedback
+	Verbosity  cli.Verbosity
+	ConfigFile string
+}
+
+// parseOptions resolves options from command-line arguments: the result ID, the rating,
+// and an optional comment, given as --comment or as the remaining arguments.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("feedback", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var opts options
+	fb := &opts.Feedback
+	fs.StringVar(&fb.Comment, "comment", "", "Optional comment about the answer")
+	fs.StringVar(&fb.SessionID, "session", "", "Session ID printed with the answer, linking the feedback to that session")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: feedback [flags] <resultId> up|down [comment ...]\n\n")
+		fmt.Fprintf(output, "Records a thumbs-up or thumbs-down for an agent answer. Rating the same\n")
+		fmt.Fprintf(output, "result again replaces the earlier rating and comment.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	err := opts.resolve(fs.Args())
+	if err == nil {
+		err = fb.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// resolve fills the result ID, rating, and positional comment from args
+func (o *options) resolve(args []string) error {
+	if len(args) < 2 {
+		return errors.New("a result ID and a rating (up or down) are required")
+	}
+	o.Feedback.ResultID = args[0]
+	o.Feedback.Rating = args[1]
+
+	if comment := strings.Join(args[2:], " "); comment != "" {
+		if o.Feedback.Comment != "" {
+			return errors.New("give the comment either with --comment or as arguments, not both")
+		}
+		o.Feedback.Comment = comment
+	}
+	return nil
+}
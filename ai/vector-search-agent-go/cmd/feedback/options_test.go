@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const resultID = "3f2b8c1e-6d4a-4f0e-9b7a-2c5d8e1f0a93"
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantRating  string
+		wantComment string
+		wantSession string
+	}{
+		{"rating only", []string{resultID, "up"}, "up", "", ""},
+		{"positional comment", []string{resultID, "Down", "too", "far", "away"}, "down", "too far away", ""},
+		{"flag comment and session", []string{"--comment", "great pool", "--session", "session-1", resultID, "up"}, "up", "great pool", "session-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions(tt.args, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			fb := opts.Feedback
+			if fb.ResultID != resultID || fb.Rating != tt.wantRating || fb.Comment != tt.wantComment || fb.SessionID != tt.wantSession {
+				t.Errorf("feedback = %+v", fb)
+			}
+		})
+	}
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no arguments", nil, "a result ID and a rating (up or down) are required"},
+		{"no rating", []string{resultID}, "a result ID and a rating (up or down) are required"},
+		{"bad result ID", []string{"abc", "up"}, `invalid result ID "abc"`},
+		{"bad rating", []string{resultID, "5"}, `invalid rating "5"`},
+		{"two comments", []string{"--comment", "a", resultID, "up", "b"}, "either with --comment or as arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, &out); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+			if !strings.Contains(out.String(), "Usage: feedback") {
+				t.Errorf("usage was not printed:\n%s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+)
+
+const descriptionSystemPrompt = `You write descriptions for a sample hotel dataset.
+Write two or three sentences in the style of a hotel listing. Do not invent a different
+name, city, or category than the ones given. Return only the description.`
+
+// describeWithLLM replaces the description of every hotel with one written by chat, with
+// up to concurrency requests in flight. A hotel whose request fails keeps its Markov
+// description; describeWithLLM returns how many did. It stops early when ctx is cancelled.
+func describeWithLLM(ctx context.Context, chat agents.ChatModel, hotels []models.Hotel, concurrency int, reporter *progress.Reporter) (int, error) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		fallbacks int
+	)
+	jobs := make(chan int)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				description, err := chat.ChatCompletion(ctx, descriptionSystemPrompt, descriptionPrompt(&hotels[i]))
+				description = strings.TrimSpace(description)
+				if err != nil || description == "" {
+					if ctx.Err() == nil {
+						slog.WarnContext(ctx, "keeping markov description", "hotelId", hotels[i].HotelID, "err", err)
+					}
+					mu.Lock()
+					fallbacks++
+					mu.Unlock()
+					reporter.Fail()
+					continue
+				}
+				hotels[i].Description = description
+				reporter.Done()
+			}
+		}()
+	}
+
+send:
+	for i := range hotels {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	reporter.Finish()
+
+	return fallbacks, ctx.Err()
+}
+
+// descriptionPrompt asks for a description matching the generated fields of hotel
+func descriptionPrompt(hotel *models.Hotel) string {
+	return fmt.Sprintf("Hotel: %s\nCategory: %s\nCity: %s, %s\nAmenities: %s\nRating: %.1f",
+		hotel.HotelName, hotel.Category, hotel.Address.City, hotel.Address.StateProvince,
+		strings.Join(hotel.Tags, ", "), hotel.Rating)
+}
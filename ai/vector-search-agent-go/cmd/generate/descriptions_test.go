@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+)
+
+// fakeChat writes a description naming the hotel, and fails for hotels named "fail"
+type fakeChat struct {
+	agents.ChatModel
+}
+
+func (fakeChat) ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	if strings.Contains(userMessage, "Hotel: fail") {
+		return "", errors.New("content filtered")
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(userMessage, "Hotel: "), "\n")
+	return "  Welcome to " + name + ".\n", nil
+}
+
+func TestDescribeWithLLM(t *testing.T) {
+	hotels := []models.Hotel{
+		{HotelID: "gen-1", HotelName: "Silver Bay Inn", Description: "markov one."},
+		{HotelID: "gen-2", HotelName: "fail", Description: "markov two."},
+		{HotelID: "gen-3", HotelName: "Pine Gate Hotel", Description: "markov three."},
+	}
+
+	reporter := progress.New(&bytes.Buffer{}, len(hotels), false)
+	fallbacks, err := describeWithLLM(context.Background(), fakeChat{}, hotels, 2, reporter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallbacks != 1 {
+		t.Errorf("fallbacks = %d, want 1", fallbacks)
+	}
+
+	// A failed request keeps the Markov description
+	want := []string{"Welcome to Silver Bay Inn.", "markov two.", "Welcome to Pine Gate Hotel."}
+	for i, hotel := range hotels {
+		if hotel.Description != want[i] {
+			t.Errorf("hotel %s description = %q, want %q", hotel.HotelID, hotel.Description, want[i])
+		}
+	}
+	if snap := reporter.Snapshot(); snap.Processed != 3 || snap.Failed != 1 {
+		t.Errorf("progress = %+v", snap)
+	}
+}
+
+func TestDescribeWithLLMStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	hotels := make([]models.Hotel, 10)
+	_, err := describeWithLLM(ctx, fakeChat{}, hotels, 1, progress.New(&bytes.Buffer{}, len(hotels), false))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
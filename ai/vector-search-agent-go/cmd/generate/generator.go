@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+const (
+	minTags = 2
+	maxTags = 5
+	// locationJitter is how far, in degrees, a hotel may sit from its city center
+	locationJitter = 0.05
+)
+
+// city is a place generated hotels are located in
+type city struct {
+	name, state, postalPrefix string
+	lat, lon                  float64
+}
+
+var cities = []city{
+	{"New York", "NY", "100", 40.7128, -74.0060},
+	{"Seattle", "WA", "981", 47.6062, -122.3321},
+	{"San Francisco", "CA", "941", 37.7749, -122.4194},
+	{"Los Angeles", "CA", "900", 34.0522, -118.2437},
+	{"Chicago", "IL", "606", 41.8781, -87.6298},
+	{"Boston", "MA", "021", 42.3601, -71.0589},
+	{"Austin", "TX", "787", 30.2672, -97.7431},
+	{"Denver", "CO", "802", 39.7392, -104.9903},
+	{"Miami", "FL", "331", 25.7617, -80.1918},
+	{"Atlanta", "GA", "303", 33.7490, -84.3880},
+	{"Nashville", "TN", "372", 36.1627, -86.7816},
+	{"New Orleans", "LA", "701", 29.9511, -90.0715},
+	{"Portland", "OR", "972", 45.5152, -122.6784},
+	{"San Diego", "CA", "921", 32.7157, -117.1611},
+	{"Phoenix", "AZ", "850", 33.4484, -112.0740},
+	{"Minneapolis", "MN", "554", 44.9778, -93.2650},
+	{"Philadelphia", "PA", "191", 39.9526, -75.1652},
+	{"Washington", "DC", "200", 38.9072, -77.0369},
+	{"Honolulu", "HI", "968", 21.3069, -157.8583},
+	{"Anchorage", "AK", "995", 61.2181, -149.9003},
+}
+
+var (
+	nameAdjectives = []string{"Silver", "Harbor", "Maple", "Grand", "Blue", "Sunset", "Pine", "Royal", "Golden", "Cedar", "Lakeside", "Old Town", "Riverside", "Summit", "Coral", "Ivy", "Stone", "Willow", "Copper", "Meadow"}
+	nameNouns      = []string{"Ridge", "Bay", "Garden", "Park", "Point", "Crossing", "Springs", "Hollow", "Gate", "Square", "Landing", "Valley", "Creek", "Heights", "Court"}
+	streetNames    = []string{"Main St", "Oak Ave", "Pine St", "2nd Ave", "Lake Shore Dr", "Market St", "Broadway", "Elm St", "Park Ave", "River Rd", "Harbor Blvd", "Sunset Blvd"}
+
+	// nameKinds ends a hotel name according to its category
+	nameKinds = map[string][]string{
+		"Boutique":       {"Hotel", "Inn", "House"},
+		"Budget":         {"Motel", "Inn", "Lodge"},
+		"Extended-Stay":  {"Suites", "Residences", "Extended Stay"},
+		"Luxury":         {"Palace", "Grand Hotel", "Hotel & Spa"},
+		"Resort and Spa": {"Resort", "Resort & Spa", "Retreat"},
+		"Suite":          {"Suites", "Hotel"},
+	}
+	defaultNameKinds = []string{"Hotel"}
+
+	renovatedFrom = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	renovatedTo   = time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// roomType is a kind of room and its nightly rate range
+type roomType struct {
+	name             string
+	minRate, maxRate float64
+}
+
+var (
+	roomTypes = []roomType{
+		{"Budget Room", 60, 120},
+		{"Standard Room", 90, 180},
+		{"Deluxe Room", 150, 280},
+		{"Suite", 220, 450},
+	}
+	bedOptions = []string{"1 King Bed", "1 Queen Bed", "2 Double Beds", "2 Queen Beds"}
+	roomViews  = []string{"City View", "Cityside", "Mountain View", "Waterfront View", "Amenities"}
+	roomTags   = []string{"jacuzzi tub", "tv", "vcr/dvd", "coffee maker", "suite", "bathroom shower"}
+)
+
+// generator produces synthetic hotels from the categories, tags, and description text
+// of a real dataset. All randomness comes from rng, so a seed reproduces a run.
+type generator struct {
+	rng        *rand.Rand
+	chain      *markovChain
+	categories []string
+	tags       []string
+	idPrefix   string
+	idWidth    int
+}
+
+// newGenerator creates a generator trained on source that numbers count hotels after idPrefix
+func newGenerator(source []models.Hotel, seed uint64, idPrefix string, count int) (*generator, error) {
+	categories, tags := map[string]bool{}, map[string]bool{}
+	descriptions := make([]string, 0, len(source))
+	for _, hotel := range source {
+		if hotel.Category != "" {
+			categories[hotel.Category] = true
+		}
+		for _, tag := range hotel.Tags {
+			tags[tag] = true
+		}
+		descriptions = append(descriptions, hotel.Description)
+	}
+	if len(categories) == 0 || len(tags) < maxTags {
+		return nil, fmt.Errorf("source data needs at least one category and %d distinct tags, found %d and %d", maxTags, len(categories), len(tags))
+	}
+
+	return &generator{
+		rng:        rand.New(rand.NewPCG(seed, seed)),
+		chain:      newMarkovChain(descriptions),
+		categories: sortedKeys(categories),
+		tags:       sortedKeys(tags),
+		idPrefix:   idPrefix,
+		idWidth:    len(strconv.Itoa(count)),
+	}, nil
+}
+
+// sortedKeys returns the keys of set in order, so sampling doesn't depend on map iteration
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hotel generates the hotel numbered n, starting at 1, with a Markov description
+func (g *generator) hotel(n int) models.Hotel {
+	category := pick(g.rng, g.categories)
+	c := pick(g.rng, cities)
+	lat := c.lat + (g.rng.Float64()*2-1)*locationJitter
+	lon := c.lon + (g.rng.Float64()*2-1)*locationJitter
+
+	return models.Hotel{
+		HotelID:            fmt.Sprintf("%s%0*d", g.idPrefix, g.idWidth, n),
+		HotelName:          g.name(category),
+		Description:        g.chain.description(g.rng),
+		Category:           category,
+		Tags:               g.sampleTags(),
+		ParkingIncluded:    g.rng.IntN(2) == 0,
+		LastRenovationDate: g.renovationDate(),
+		Rating:             math.Round((1+g.rng.Float64()*4)*10) / 10,
+		Address: models.Address{
+			StreetAddress: fmt.Sprintf("%d %s", 1+g.rng.IntN(9999), pick(g.rng, streetNames)),
+			City:          c.name,
+			StateProvince: c.state,
+			PostalCode:    fmt.Sprintf("%s%02d", c.postalPrefix, g.rng.IntN(100)),
+			Country:       "USA",
+		},
+		Location: models.NewGeoJSONPoint(round6(lon), round6(lat)),
+		Rooms:    g.rooms(),
+	}
+}
+
+// name builds a hotel name such as "Silver Ridge Inn" that fits category
+func (g *generator) name(category string) string {
+	kinds, ok := nameKinds[category]
+	if !ok {
+		kinds = defaultNameKinds
+	}
+	return pick(g.rng, nameAdjectives) + " " + pick(g.rng, nameNouns) + " " + pick(g.rng, kinds)
+}
+
+// sampleTags returns two to five distinct tags in source order
+func (g *generator) sampleTags() []string {
+	n := minTags + g.rng.IntN(maxTags-minTags+1)
+	indexes := g.rng.Perm(len(g.tags))[:n]
+	sort.Ints(indexes)
+	tags := make([]string, n)
+	for i, index := range indexes {
+		tags[i] = g.tags[index]
+	}
+	return tags
+}
+
+// renovationDate returns a random midnight UTC between renovatedFrom and renovatedTo
+func (g *generator) renovationDate() time.Time {
+	days := int(renovatedTo.Sub(renovatedFrom).Hours() / 24)
+	return renovatedFrom.AddDate(0, 0, g.rng.IntN(days+1))
+}
+
+// rooms returns one to four rooms
+func (g *generator) rooms() []models.Room {
+	rooms := make([]models.Room, 1+g.rng.IntN(4))
+	for i := range rooms {
+		t := pick(g.rng, roomTypes)
+		beds := pick(g.rng, bedOptions)
+		rooms[i] = models.Room{
+			Description:    fmt.Sprintf("%s, %s (%s)", t.name, beds, pick(g.rng, roomViews)),
+			Type:           t.name,
+			BaseRate:       math.Round((t.minRate+g.rng.Float64()*(t.maxRate-t.minRate))*100) / 100,
+			BedOptions:     beds,
+			SleepsCount:    2 + 2*g.rng.IntN(2),
+			SmokingAllowed: g.rng.IntN(4) == 0,
+			Tags:           []string{pick(g.rng, roomTags)},
+		}
+	}
+	return rooms
+}
+
+// pick returns a random element of items
+func pick[T any](rng *rand.Rand, items []T) T {
+	return items[rng.IntN(len(items))]
+}
+
+// round6 rounds a coordinate to six decimal places, as in the real data
+func round6(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
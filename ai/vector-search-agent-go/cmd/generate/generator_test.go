@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// sourceHotels is a small stand-in for the real dataset
+func sourceHotels() []models.Hotel {
+	return []models.Hotel{
+		{HotelID: "1", Category: "Boutique", Tags: []string{"pool", "view", "wifi"}, Description: "A quiet hotel near the river. Guests enjoy the rooftop pool and free wifi."},
+		{HotelID: "2", Category: "Luxury", Tags: []string{"spa", "pool", "bar"}, Description: "An elegant stay downtown. The spa is open late and the bar serves local wine."},
+		{HotelID: "3", Category: "Budget", Tags: []string{"parking", "wifi"}, Description: "Simple rooms close to the airport. Free parking is included with every stay."},
+	}
+}
+
+func generate(t *testing.T, seed uint64, count int) []models.Hotel {
+	t.Helper()
+	gen, err := newGenerator(sourceHotels(), seed, defaultIDPrefix, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hotels := make([]models.Hotel, count)
+	for i := range hotels {
+		hotels[i] = gen.hotel(i + 1)
+	}
+	return hotels
+}
+
+func TestGeneratedHotelsAreValid(t *testing.T) {
+	source := sourceHotels()
+	var categories, tags []string
+	for _, hotel := range source {
+		categories = append(categories, hotel.Category)
+		tags = append(tags, hotel.Tags...)
+	}
+
+	hotels := generate(t, 7, 200)
+	for _, hotel := range hotels {
+		if hotel.HotelName == "" || hotel.Description == "" || !strings.HasSuffix(hotel.Description, ".") {
+			t.Errorf("hotel %s has name %q, description %q", hotel.HotelID, hotel.HotelName, hotel.Description)
+		}
+		if !slices.Contains(categories, hotel.Category) {
+			t.Errorf("hotel %s category %q is not in the source data", hotel.HotelID, hotel.Category)
+		}
+		if len(hotel.Tags) < minTags || len(hotel.Tags) > maxTags {
+			t.Errorf("hotel %s has %d tags", hotel.HotelID, len(hotel.Tags))
+		}
+		for i, tag := range hotel.Tags {
+			if !slices.Contains(tags, tag) || slices.Contains(hotel.Tags[:i], tag) {
+				t.Errorf("hotel %s tags %v are not distinct source tags", hotel.HotelID, hotel.Tags)
+			}
+		}
+		if hotel.Rating < 1 || hotel.Rating > 5 {
+			t.Errorf("hotel %s rating %v is outside 1-5", hotel.HotelID, hotel.Rating)
+		}
+		if hotel.LastRenovationDate.Before(renovatedFrom) || hotel.LastRenovationDate.After(renovatedTo) {
+			t.Errorf("hotel %s renovated %v", hotel.HotelID, hotel.LastRenovationDate)
+		}
+
+		address := hotel.Address
+		if address.StreetAddress == "" || address.City == "" || address.StateProvince == "" || len(address.PostalCode) != 5 || address.Country != "USA" {
+			t.Errorf("hotel %s address = %+v", hotel.HotelID, address)
+		}
+		i := slices.IndexFunc(cities, func(c city) bool { return c.name == address.City })
+		if i < 0 || cities[i].state != address.StateProvince || !strings.HasPrefix(address.PostalCode, cities[i].postalPrefix) {
+			t.Errorf("hotel %s address %+v does not match a city", hotel.HotelID, address)
+			continue
+		}
+
+		// GeoJSON points are longitude first and near the city they claim
+		if hotel.Location == nil || hotel.Location.Type != "Point" || len(hotel.Location.Coordinates) != 2 {
+			t.Fatalf("hotel %s location = %+v", hotel.HotelID, hotel.Location)
+		}
+		lon, lat := hotel.Location.Coordinates[0], hotel.Location.Coordinates[1]
+		if abs(lon-cities[i].lon) > locationJitter+1e-6 || abs(lat-cities[i].lat) > locationJitter+1e-6 {
+			t.Errorf("hotel %s at %v, %v is not in %s", hotel.HotelID, lat, lon, address.City)
+		}
+
+		if len(hotel.Rooms) < 1 || len(hotel.Rooms) > 4 {
+			t.Errorf("hotel %s has %d rooms", hotel.HotelID, len(hotel.Rooms))
+		}
+		for _, r := range hotel.Rooms {
+			j := slices.IndexFunc(roomTypes, func(rt roomType) bool { return rt.name == r.Type })
+			if j < 0 || r.BaseRate < roomTypes[j].minRate || r.BaseRate > roomTypes[j].maxRate {
+				t.Errorf("hotel %s room = %+v", hotel.HotelID, r)
+			}
+		}
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestGeneratedHotelIDsAreUnique(t *testing.T) {
+	hotels := generate(t, 1, 1000)
+	seen := map[string]bool{}
+	for i, hotel := range hotels {
+		// IDs are padded to the width of the count, so they sort in generation order
+		if want := fmt.Sprintf("gen-%04d", i+1); hotel.HotelID != want {
+			t.Errorf("hotel %d ID = %q, want %q", i, hotel.HotelID, want)
+		}
+		if seen[hotel.HotelID] {
+			t.Errorf("duplicate HotelId %q", hotel.HotelID)
+		}
+		seen[hotel.HotelID] = true
+	}
+	if err := checkIDs(hotels, sourceHotels()); err != nil {
+		t.Errorf("checkIDs: %v", err)
+	}
+}
+
+func TestCheckIDsRejectsCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		hotels []models.Hotel
+		source []models.Hotel
+	}{
+		{"with source", []models.Hotel{{HotelID: "gen-1"}, {HotelID: "2"}}, sourceHotels()},
+		{"within generated", []models.Hotel{{HotelID: "gen-1"}, {HotelID: "gen-1"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkIDs(tt.hotels, tt.source)
+			if !errors.Is(err, cli.ErrData) || !strings.Contains(err.Error(), "--id-prefix") {
+				t.Errorf("checkIDs = %v, want a data error naming --id-prefix", err)
+			}
+		})
+	}
+}
+
+func TestGeneratorIsReproducible(t *testing.T) {
+	first, second := generate(t, 42, 20), generate(t, 42, 20)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed generated different hotels")
+	}
+	if reflect.DeepEqual(first, generate(t, 43, 20)) {
+		t.Error("different seeds generated the same hotels")
+	}
+}
+
+func TestNewGeneratorRejectsThinSource(t *testing.T) {
+	source := []models.Hotel{{HotelID: "1", Category: "Boutique", Tags: []string{"pool", "wifi"}}}
+	if _, err := newGenerator(source, 1, defaultIDPrefix, 10); err == nil {
+		t.Error("newGenerator accepted a source with too few tags")
+	}
+}
+
+func TestWriteHotelsRoundTrips(t *testing.T) {
+	hotels := generate(t, 3, 5)
+	path := filepath.Join(t.TempDir(), "hotels.json")
+	if err := writeHotels(path, hotels); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file loads the way cmd/upload reads its data file
+	loaded, err := vectorstore.LoadHotels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(hotels) {
+		t.Fatalf("loaded %d hotels, want %d", len(loaded), len(hotels))
+	}
+	for i := range loaded {
+		got, want := loaded[i], hotels[i]
+		if got.HotelID != want.HotelID || got.Description != want.Description || !slices.Equal(got.Tags, want.Tags) ||
+			!got.LastRenovationDate.Equal(want.LastRenovationDate) || !slices.Equal(got.Location.Coordinates, want.Location.Coordinates) {
+			t.Errorf("hotel %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/upload"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run writes a synthetic hotel dataset
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	// Resolve flags and their environment fallbacks
+	opts, err := parseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("generate")
+	if err != nil {
+		return err
+	}
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	llm := opts.Descriptions == descriptionsLLM
+	if llm && offline.Enabled(os.Getenv) {
+		return fmt.Errorf("%w: --descriptions llm needs Azure OpenAI; use --descriptions markov with OFFLINE_MODE", cli.ErrConfig)
+	}
+
+	// Azure settings are only needed for LLM descriptions and uploads
+	cfg, err := backend.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: opts.Upload, DocumentDB: opts.Upload, VectorIndex: opts.Upload, Chat: llm})
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	// Status goes to stderr when the hotels are written to stdout
+	status := io.Writer(os.Stdout)
+	statusFile := os.Stdout
+	if opts.Out == "-" {
+		status, statusFile = os.Stderr, os.Stderr
+	}
+
+	// The real data supplies the vocabulary and the HotelIds generated ones must avoid
+	source, err := vectorstore.LoadHotels(opts.DataFile)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", opts.DataFile, err)
+	}
+	gen, err := newGenerator(source, opts.Seed, opts.IDPrefix, opts.Count)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", cli.ErrData, opts.DataFile, err)
+	}
+
+	hotels := make([]models.Hotel, opts.Count)
+	for i := range hotels {
+		hotels[i] = gen.hotel(i + 1)
+	}
+	if err := checkIDs(hotels, source); err != nil {
+		return err
+	}
+
+	// Models are only needed for LLM descriptions and embeddings
+	var services *backend.Backend
+	var provider backend.Models
+	if opts.Upload {
+		services, err = backend.Open(ctx, cfg, os.Stderr)
+		if err != nil {
+			return err
+		}
+		defer services.Close(context.Background())
+		provider = services.Models
+	} else if llm {
+		provider, err = backend.OpenModels(cfg, os.Stderr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if llm {
+		fmt.Fprintf(status, "Writing %d descriptions with the chat deployment...\n", len(hotels))
+		reporter := progress.New(status, len(hotels), progress.IsTerminal(statusFile))
+		fallbacks, err := describeWithLLM(ctx, provider, hotels, opts.Concurrency, reporter)
+		if err != nil {
+			return err
+		}
+		if fallbacks > 0 {
+			fmt.Fprintf(status, "%d descriptions failed and kept their Markov text\n", fallbacks)
+		}
+	}
+
+	if opts.Out != "" {
+		if err := writeHotels(opts.Out, hotels); err != nil {
+			return err
+		}
+		if opts.Out != "-" {
+			fmt.Fprintf(status, "Generated %d hotels (seed %d, %s descriptions) in %s\n", len(hotels), opts.Seed, opts.Descriptions, opts.Out)
+		}
+	}
+
+	if !opts.Upload {
+		return nil
+	}
+
+	u := &upload.Uploader{
+		Embedder:       provider,
+		Store:          services.Store,
+		Usage:          provider.Usage(),
+		Out:            status,
+		Dimensions:     vectorstore.EmbeddingDimensionsFromEnv(),
+		EmbeddingModel: services.EmbeddingModel(),
+		TTY:            progress.IsTerminal(statusFile),
+		RetryBackoff:   upload.DefaultRetryBackoff,
+	}
+	summary, err := u.Run(ctx, &upload.Options{
+		Hotels:             hotels,
+		BatchSize:          opts.BatchSize,
+		Concurrency:        opts.Concurrency,
+		Checkpoint:         defaultCheckpoint,
+		CheckpointInterval: upload.DefaultCheckpointInterval,
+	})
+	summary.Render(status)
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%w: upload incomplete: %d documents failed to embed", cli.ErrPartial, summary.Failed)
+	}
+	return nil
+}
+
+// checkIDs reports an error if a generated HotelId repeats or is taken by the source data
+func checkIDs(hotels, source []models.Hotel) error {
+	taken := make(map[string]bool, len(source)+len(hotels))
+	for _, hotel := range source {
+		taken[hotel.HotelID] = true
+	}
+	for _, hotel := range hotels {
+		if taken[hotel.HotelID] {
+			return fmt.Errorf("%w: generated HotelId %q collides with an existing one; choose another --id-prefix", cli.ErrData, hotel.HotelID)
+		}
+		taken[hotel.HotelID] = true
+	}
+	return nil
+}
+
+// writeHotels writes hotels as an indented JSON array to path, or to stdout when path is "-"
+func writeHotels(path string, hotels []models.Hotel) (err error) {
+	w := io.Writer(os.Stdout)
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("failed to write %s: %w", path, closeErr)
+			}
+		}()
+		w = file
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(hotels); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
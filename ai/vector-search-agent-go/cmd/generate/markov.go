@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math/rand/v2"
+	"strings"
+)
+
+const (
+	// maxSentenceWords stops a sentence that never reaches a word ending in a period
+	maxSentenceWords = 50
+	minSentences     = 2
+	maxSentences     = 3
+)
+
+// prefix is the two words that select the next word of a chain
+type prefix [2]string
+
+// markovChain is an order-2 word chain trained on real descriptions. Transitions are
+// kept in training order, so a seeded walk is reproducible.
+type markovChain struct {
+	starts []prefix
+	next   map[prefix][]string
+}
+
+// newMarkovChain trains a chain on texts, splitting them into sentences at words
+// ending in a period, question mark, or exclamation mark
+func newMarkovChain(texts []string) *markovChain {
+	c := &markovChain{next: make(map[prefix][]string)}
+	for _, text := range texts {
+		words := strings.Fields(text)
+		sentenceStart := true
+		for i := 0; i+1 < len(words); i++ {
+			if sentenceStart {
+				c.starts = append(c.starts, prefix{words[i], words[i+1]})
+			}
+			sentenceStart = endsSentence(words[i])
+			if i+2 < len(words) && !endsSentence(words[i+1]) {
+				key := prefix{words[i], words[i+1]}
+				c.next[key] = append(c.next[key], words[i+2])
+			}
+		}
+	}
+	return c
+}
+
+// endsSentence reports whether word closes a sentence
+func endsSentence(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+}
+
+// description walks the chain for two or three sentences. It returns "" when the chain
+// was trained on nothing.
+func (c *markovChain) description(rng *rand.Rand) string {
+	if len(c.starts) == 0 {
+		return ""
+	}
+	sentences := minSentences + rng.IntN(maxSentences-minSentences+1)
+	parts := make([]string, 0, sentences)
+	for range sentences {
+		parts = append(parts, c.sentence(rng))
+	}
+	return strings.Join(parts, " ")
+}
+
+// sentence walks the chain from a random sentence start until a word ends the sentence
+func (c *markovChain) sentence(rng *rand.Rand) string {
+	key := c.starts[rng.IntN(len(c.starts))]
+	words := []string{key[0], key[1]}
+	for len(words) < maxSentenceWords && !endsSentence(words[len(words)-1]) {
+		candidates := c.next[key]
+		if len(candidates) == 0 {
+			break
+		}
+		word := candidates[rng.IntN(len(candidates))]
+		words = append(words, word)
+		key = prefix{key[1], word}
+	}
+
+	last := words[len(words)-1]
+	if !endsSentence(last) {
+		words[len(words)-1] = strings.TrimRight(last, ",;:") + "."
+	}
+	return strings.Join(words, " ")
+}
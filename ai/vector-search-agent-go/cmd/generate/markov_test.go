@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestMarkovChainDescription(t *testing.T) {
+	chain := newMarkovChain([]string{
+		"The hotel has a pool. The pool is heated!",
+		"Rooms face the sea. Is the sea warm?",
+	})
+	rng := rand.New(rand.NewPCG(1, 1))
+
+	for range 50 {
+		description := chain.description(rng)
+		sentences := strings.FieldsFunc(description, func(r rune) bool { return r == '.' || r == '!' || r == '?' })
+		if len(sentences) < minSentences || len(sentences) > maxSentences || !endsSentence(description) {
+			t.Fatalf("description %q does not have two or three sentences", description)
+		}
+		// Every word comes from the training text
+		for _, word := range strings.Fields(description) {
+			if !strings.Contains("The hotel has a pool. The pool is heated! Rooms face the sea. Is the sea warm?", word) {
+				t.Fatalf("description %q has untrained word %q", description, word)
+			}
+		}
+	}
+}
+
+func TestMarkovChainEndsUnfinishedSentences(t *testing.T) {
+	chain := newMarkovChain([]string{"no punctuation here,"})
+	got := chain.sentence(rand.New(rand.NewPCG(1, 1)))
+	if got != "no punctuation here." {
+		t.Errorf("sentence = %q, want it closed with a period", got)
+	}
+}
+
+func TestMarkovChainUntrained(t *testing.T) {
+	if got := newMarkovChain(nil).description(rand.New(rand.NewPCG(1, 1))); got != "" {
+		t.Errorf("description = %q, want empty", got)
+	}
+}
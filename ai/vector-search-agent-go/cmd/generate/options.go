@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/upload"
+)
+
+// Description sources for --descriptions
+const (
+	descriptionsMarkov = "markov"
+	descriptionsLLM    = "llm"
+)
+
+const (
+	defaultCount      = 1000
+	defaultSeed       = 1
+	defaultOut        = "hotels_generated.json"
+	defaultDataFile   = "../data/Hotels.json"
+	defaultIDPrefix   = "gen-"
+	defaultCheckpoint = ".generate-checkpoint.json"
+)
+
+// options holds the resolved cmd/generate settings
+type options struct {
+	Count        int
+	Seed         uint64
+	Out          string
+	DataFile     string
+	IDPrefix     string
+	Descriptions string
+	Upload       bool
+	BatchSize    int
+	Concurrency  int
+	Verbosity    cli.Verbosity
+	ConfigFile   string
+}
+
+// parseOptions resolves options from command-line arguments, using getenv for defaults.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	opts := options{DataFile: getenv("DATA_FILE_WITHOUT_VECTORS")}
+	if opts.DataFile == "" {
+		opts.DataFile = defaultDataFile
+	}
+
+	fs.IntVar(&opts.Count, "n", defaultCount, "Number of hotels to generate (shorthand for --count)")
+	fs.IntVar(&opts.Count, "count", defaultCount, "Number of hotels to generate")
+	fs.Uint64Var(&opts.Seed, "seed", defaultSeed, "Random seed; the same seed and data file give the same hotels (LLM descriptions excepted)")
+	fs.StringVar(&opts.Out, "out", defaultOut, "Output JSON file, - for stdout, or empty to skip writing when --upload is set")
+	fs.StringVar(&opts.DataFile, "data", opts.DataFile, "Real hotel data file that supplies categories, tags, and description text, and whose HotelIds are reserved (env DATA_FILE_WITHOUT_VECTORS)")
+	fs.StringVar(&opts.IDPrefix, "id-prefix", defaultIDPrefix, "Prefix of the generated HotelIds")
+	fs.StringVar(&opts.Descriptions, "descriptions", descriptionsMarkov, "How descriptions are written: markov (a word chain trained on the data file) or llm (the chat deployment)")
+	fs.BoolVar(&opts.Upload, "upload", false, "Embed the generated hotels and insert them into the vector store, as cmd/upload does")
+	fs.IntVar(&opts.BatchSize, "batch-size", upload.DefaultBatchSize, "Documents per insert batch with --upload")
+	fs.IntVar(&opts.Concurrency, "concurrency", upload.DefaultConcurrency, "Embedding or description requests in flight at once")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: generate [flags]\n\n")
+		fmt.Fprintf(output, "Generates synthetic hotels in the Hotels.json format for scale testing, and\n")
+		fmt.Fprintf(output, "optionally uploads them. Markov descriptions need no configuration.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// validate checks that the options are consistent
+func (o *options) validate() error {
+	if o.Count < 1 {
+		return fmt.Errorf("invalid count %d: must be at least 1", o.Count)
+	}
+	if o.Descriptions != descriptionsMarkov && o.Descriptions != descriptionsLLM {
+		return fmt.Errorf("invalid --descriptions %q: use %s or %s", o.Descriptions, descriptionsMarkov, descriptionsLLM)
+	}
+	if o.IDPrefix == "" {
+		return errors.New("--id-prefix must not be empty; it keeps generated HotelIds apart from the real ones")
+	}
+	if o.Out == "" && !o.Upload {
+		return errors.New("--out may only be empty with --upload")
+	}
+	if o.BatchSize < 1 {
+		return fmt.Errorf("invalid batch size %d: must be at least 1", o.BatchSize)
+	}
+	if o.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d: must be at least 1", o.Concurrency)
+	}
+	return nil
+}
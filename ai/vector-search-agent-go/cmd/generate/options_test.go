@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/upload"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want options
+	}{
+		{"defaults", nil, nil, options{
+			Count: defaultCount, Seed: defaultSeed, Out: defaultOut, DataFile: defaultDataFile, IDPrefix: defaultIDPrefix,
+			Descriptions: descriptionsMarkov, BatchSize: upload.DefaultBatchSize, Concurrency: upload.DefaultConcurrency,
+		}},
+		{"env data file", nil, map[string]string{"DATA_FILE_WITHOUT_VECTORS": "real.json"}, options{
+			Count: defaultCount, Seed: defaultSeed, Out: defaultOut, DataFile: "real.json", IDPrefix: defaultIDPrefix,
+			Descriptions: descriptionsMarkov, BatchSize: upload.DefaultBatchSize, Concurrency: upload.DefaultConcurrency,
+		}},
+		{
+			"flags", []string{"-n", "50", "--seed", "9", "--out", "", "--data", "flag.json", "--id-prefix", "x-", "--descriptions", "llm", "--upload", "--batch-size", "5", "--concurrency", "2"},
+			map[string]string{"DATA_FILE_WITHOUT_VECTORS": "real.json"},
+			options{Count: 50, Seed: 9, DataFile: "flag.json", IDPrefix: "x-", Descriptions: descriptionsLLM, Upload: true, BatchSize: 5, Concurrency: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if *got != tt.want {
+				t.Errorf("options = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"zero count", []string{"--count", "0"}, "invalid count 0"},
+		{"unknown descriptions", []string{"--descriptions", "gpt"}, "invalid --descriptions"},
+		{"empty prefix", []string{"--id-prefix", ""}, "--id-prefix must not be empty"},
+		{"no output", []string{"--out", ""}, "--out may only be empty"},
+		{"zero batch size", []string{"--batch-size", "0"}, "invalid batch size 0"},
+		{"zero concurrency", []string{"--concurrency", "0"}, "invalid concurrency 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, func(string) string { return "" }, &out); err == nil {
+				t.Fatal("parseOptions accepted invalid options")
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
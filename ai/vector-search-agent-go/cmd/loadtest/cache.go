@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+)
+
+// embeddingCache is the file layout of cached query embeddings. Vectors are only reused
+// when the deployment and dimensions match the current configuration.
+type embeddingCache struct {
+	Deployment string               `json:"deployment"`
+	Dimensions int                  `json:"dimensions"`
+	Vectors    map[string][]float32 `json:"vectors"`
+}
+
+// queryEmbeddings returns an embedding for every query, reading them from the cache file
+// at path when possible and embedding the rest. New embeddings are written back to path.
+// An empty path disables the file. It also returns how many queries were embedded.
+func queryEmbeddings(ctx context.Context, path, deployment string, dimensions int, embedder agents.Embedder, queries []string) ([][]float32, int, error) {
+	cache := embeddingCache{Deployment: deployment, Dimensions: dimensions, Vectors: map[string][]float32{}}
+	if path != "" {
+		stored, err := readEmbeddingCache(path)
+		switch {
+		case err != nil:
+			return nil, 0, err
+		case stored == nil:
+		case stored.Deployment != deployment || stored.Dimensions != dimensions:
+			slog.Info("ignoring embedding cache for another deployment", "file", path, "deployment", stored.Deployment, "dimensions", stored.Dimensions)
+		default:
+			cache.Vectors = stored.Vectors
+		}
+	}
+
+	vectors := make([][]float32, len(queries))
+	embedded := 0
+	for i, query := range queries {
+		if vector, ok := cache.Vectors[query]; ok && len(vector) == dimensions {
+			vectors[i] = vector
+			continue
+		}
+		vector, err := embedder.GenerateEmbedding(ctx, query)
+		if err != nil {
+			return nil, embedded, fmt.Errorf("failed to embed query %q: %w", query, err)
+		}
+		vectors[i] = vector
+		cache.Vectors[query] = vector
+		embedded++
+	}
+
+	if path != "" && embedded > 0 {
+		data, err := json.Marshal(cache)
+		if err != nil {
+			return nil, embedded, fmt.Errorf("failed to encode embedding cache: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return nil, embedded, fmt.Errorf("failed to write embedding cache: %w", err)
+		}
+	}
+	return vectors, embedded, nil
+}
+
+// readEmbeddingCache reads the cache file at path, returning nil when it doesn't exist
+func readEmbeddingCache(path string) (*embeddingCache, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	var cache embeddingCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("invalid embedding cache %s: %w; delete it to start over", path, err)
+	}
+	if cache.Vectors == nil {
+		cache.Vectors = map[string][]float32{}
+	}
+	return &cache, nil
+}
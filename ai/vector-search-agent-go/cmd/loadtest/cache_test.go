@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// countingEmbedder returns a vector of dimensions copies of the query length and
+// records the queries it embedded
+type countingEmbedder struct {
+	dimensions int
+	embedded   []string
+	err        error
+}
+
+func (e *countingEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.embedded = append(e.embedded, text)
+	vector := make([]float32, e.dimensions)
+	for i := range vector {
+		vector[i] = float32(len(text))
+	}
+	return vector, nil
+}
+
+func TestQueryEmbeddingsReusesCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	queries := []string{"pool", "spa"}
+
+	first := &countingEmbedder{dimensions: 2}
+	vectors, embedded, err := queryEmbeddings(context.Background(), path, "small", 2, first, queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedded != 2 || len(vectors) != 2 || vectors[0][0] != 4 || vectors[1][0] != 3 {
+		t.Fatalf("embedded %d, vectors %v", embedded, vectors)
+	}
+
+	// A second run only embeds the query it hasn't seen
+	second := &countingEmbedder{dimensions: 2}
+	vectors, embedded, err = queryEmbeddings(context.Background(), path, "small", 2, second, append(queries, "beach"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embedded != 1 || !slices.Equal(second.embedded, []string{"beach"}) || vectors[2][0] != 5 {
+		t.Errorf("embedded %v, vectors %v", second.embedded, vectors)
+	}
+}
+
+func TestQueryEmbeddingsIgnoresOtherDeployments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if _, _, err := queryEmbeddings(context.Background(), path, "small", 2, &countingEmbedder{dimensions: 2}, []string{"pool"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		deployment string
+		dimensions int
+	}{
+		{"deployment", "large", 2},
+		{"dimensions", "small", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &countingEmbedder{dimensions: tt.dimensions}
+			if _, embedded, err := queryEmbeddings(context.Background(), "", tt.deployment, tt.dimensions, e, []string{"pool"}); err != nil || embedded != 1 {
+				t.Errorf("embedded %d, err %v, want the query embedded again", embedded, err)
+			}
+		})
+	}
+}
+
+func TestQueryEmbeddingsErrors(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := queryEmbeddings(context.Background(), corrupt, "small", 2, &countingEmbedder{dimensions: 2}, []string{"pool"}); err == nil {
+		t.Error("queryEmbeddings accepted a corrupt cache")
+	}
+
+	// A failed embedding leaves no cache file behind
+	path := filepath.Join(dir, "cache.json")
+	boom := errors.New("boom")
+	if _, _, err := queryEmbeddings(context.Background(), path, "small", 2, &countingEmbedder{err: boom}, []string{"pool"}); !errors.Is(err, boom) {
+		t.Errorf("err = %v, want the embedding error", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("cache file written after a failure: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run drives concurrent searches at the target rate and reports latency
+func run() error {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("loadtest")
+	if err != nil {
+		return err
+	}
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	queries, err := resolveQueries(opts)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+
+	cfg, err := backend.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, Chat: opts.Agent, DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C stops starting operations; the report covers what finished
+	ctx, stop := cli.NotifyContext(context.Background(), os.Stderr)
+	defer stop()
+
+	// Create Azure OpenAI clients and connect to the vector store, or use the offline stand-ins
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+
+	// Every model request made by the load test passes this limiter, so a high target
+	// can't spend more quota than --openai-rps allows
+	models := &limitedModels{Models: services.Models, limiter: clients.NewRateLimiter(opts.OpenAIRPS)}
+
+	var op operation
+	switch {
+	case opts.StoreOnly:
+		deployment := os.Getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT")
+		if services.Offline {
+			deployment = offline.EmbeddingDeployment
+		}
+		vectors, embedded, err := queryEmbeddings(ctx, opts.EmbeddingCache, deployment, vectorstore.EmbeddingDimensionsFromEnv(), models, queries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Query embeddings: %d cached, %d generated\n", len(queries)-embedded, embedded)
+		op = storeOnlyOperation(services.Store, vectors, opts.K)
+	case opts.Agent:
+		pipeline := agents.NewDefaultPipeline(models, services.Store, cfg.Planner, cfg.Synthesizer, cfg.Timeouts)
+		op = agentOperation(pipeline, queries, opts.K)
+	default:
+		op = searchOperation(models, services.Store, queries, opts.K)
+	}
+
+	// Report only the usage of the load itself, not of warming the embedding cache
+	services.Models.Usage().Reset()
+
+	sched := &scheduler{op: op, queries: len(queries), qps: opts.QPS, concurrency: opts.Concurrency, timeout: opts.Timeout}
+	fmt.Fprintf(os.Stderr, "Running %s load for %s with %d queries...\n", opts.mode(), opts.Duration, len(queries))
+	results, err := runQuiet(opts.Agent, func() []stepResult {
+		return sched.run(ctx, opts.Duration, opts.RampSteps)
+	})
+	if err != nil {
+		return err
+	}
+
+	rep := buildReport(settingsReport{
+		Mode:            opts.mode(),
+		DurationSeconds: opts.Duration.Seconds(),
+		TargetQPS:       opts.QPS,
+		Concurrency:     opts.Concurrency,
+		RampSteps:       opts.RampSteps,
+		TimeoutSeconds:  opts.Timeout.Seconds(),
+		K:               opts.K,
+		Queries:         len(queries),
+		OpenAIRPS:       opts.OpenAIRPS,
+	}, results, services.Models.Usage().Snapshot())
+	rep.render(os.Stdout)
+
+	if opts.JSONOut != "" {
+		if err := rep.writeJSON(opts.JSONOut); err != nil {
+			return err
+		}
+		fmt.Printf("\nJSON report written to %s\n", opts.JSONOut)
+	}
+
+	failed := rep.Totals.Errors.total()
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		fmt.Println("\nLoad test cancelled; the report covers the operations that finished.")
+		return ctx.Err()
+	case rep.Totals.Operations > 0 && failed == rep.Totals.Operations:
+		return fmt.Errorf("all %d operations failed", rep.Totals.Operations)
+	case failed > 0:
+		return fmt.Errorf("%w: %d of %d operations failed", cli.ErrPartial, failed, rep.Totals.Operations)
+	}
+	return nil
+}
+
+// runQuiet calls run, discarding anything written to stdout meanwhile when quiet is set.
+// The agents print each planner and synthesizer step, which would bury the report.
+func runQuiet(quiet bool, run func() []stepResult) ([]stepResult, error) {
+	if !quiet {
+		return run(), nil
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+	return run(), nil
+}
+
+// resolveQueries reads the queries file or generates queries from the data file
+func resolveQueries(opts *options) ([]string, error) {
+	if opts.QueriesFile != "" {
+		return bench.LoadQueries(opts.QueriesFile)
+	}
+
+	hotels, err := vectorstore.LoadHotels(opts.DataFile)
+	if err != nil {
+		return nil, err
+	}
+	queries := bench.GenerateQueries(hotels, opts.Generate)
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no hotels in %s to generate queries from", opts.DataFile)
+	}
+	return queries, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/openai/openai-go/v3"
+)
+
+// Error categories in the report
+const (
+	categoryThrottled = "throttled"
+	categoryLimited   = "limited"
+	categoryTimeout   = "timeout"
+	categoryOther     = "other"
+)
+
+// categories lists the error categories in report order
+var categories = []string{categoryThrottled, categoryLimited, categoryTimeout, categoryOther}
+
+// classify assigns a failed operation to an error category: throttled by Azure OpenAI
+// (429), held back by the load test's own rate limit, timed out, or anything else
+func classify(err error) string {
+	var netErr net.Error
+	switch {
+	case clients.StatusCode(err) == http.StatusTooManyRequests:
+		return categoryThrottled
+	case errors.Is(err, clients.ErrRateLimited):
+		return categoryLimited
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return categoryTimeout
+	}
+	return categoryOther
+}
+
+// limitedModels holds every model request of the wrapped models to a rate limit, on top
+// of any limit the clients enforce themselves
+type limitedModels struct {
+	backend.Models
+	limiter *clients.RateLimiter
+}
+
+// GenerateEmbedding waits for the limiter, then generates an embedding
+func (m *limitedModels) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return m.Models.GenerateEmbedding(ctx, text)
+}
+
+// ChatCompletionWithTools waits for the limiter, then runs the planner completion
+func (m *limitedModels) ChatCompletionWithTools(ctx context.Context, systemPrompt, userMessage string, tools []openai.ChatCompletionToolUnionParam) (*openai.ChatCompletion, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return m.Models.ChatCompletionWithTools(ctx, systemPrompt, userMessage, tools)
+}
+
+// ChatCompletion waits for the limiter, then runs the synthesizer completion
+func (m *limitedModels) ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return m.Models.ChatCompletion(ctx, systemPrompt, userMessage)
+}
+
+// ChatCompletionStream waits for the limiter, then streams the synthesizer completion
+func (m *limitedModels) ChatCompletionStream(ctx context.Context, systemPrompt, userMessage string, w io.Writer) (string, error) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return m.Models.ChatCompletionStream(ctx, systemPrompt, userMessage, w)
+}
+
+// searchOperation embeds the query and searches the vector store
+func searchOperation(embedder agents.Embedder, searcher agents.Searcher, queries []string, k int) operation {
+	return func(ctx context.Context, query int) error {
+		vector, err := embedder.GenerateEmbedding(ctx, queries[query])
+		if err != nil {
+			return err
+		}
+		_, err = searcher.VectorSearch(ctx, vector, k)
+		return err
+	}
+}
+
+// storeOnlyOperation searches the vector store with a precomputed query embedding
+func storeOnlyOperation(searcher agents.Searcher, vectors [][]float32, k int) operation {
+	return func(ctx context.Context, query int) error {
+		_, err := searcher.VectorSearch(ctx, vectors[query], k)
+		return err
+	}
+}
+
+// agentOperation runs the query through the full agent pipeline
+func agentOperation(pipeline *agents.Pipeline, queries []string, k int) operation {
+	return func(ctx context.Context, query int) error {
+		return pipeline.Run(ctx, agents.NewPipelineState(queries[query], k))
+	}
+}
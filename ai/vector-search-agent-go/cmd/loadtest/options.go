@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	defaultDuration       = 30 * time.Second
+	defaultConcurrency    = 8
+	defaultTimeout        = 10 * time.Second
+	defaultK              = 5
+	defaultGenerate       = 20
+	defaultDataFile       = "../data/Hotels.json"
+	defaultOpenAIRPS      = 5
+	defaultEmbeddingCache = ".loadtest-embeddings.json"
+)
+
+// options holds the resolved cmd/loadtest settings
+type options struct {
+	QueriesFile string
+	DataFile    string
+	Generate    int
+	Duration    time.Duration
+	// QPS is the target rate of new operations; 0 runs Concurrency operations back to back
+	QPS float64
+	// Concurrency is the number of workers, or the cap on operations in flight when QPS is set
+	Concurrency int
+	RampSteps   int
+	Timeout     time.Duration
+	K           int
+	StoreOnly   bool
+	Agent       bool
+	// OpenAIRPS caps the model requests started per second by the load test
+	OpenAIRPS      float64
+	EmbeddingCache string
+	JSONOut        string
+	Verbosity      cli.Verbosity
+	ConfigFile     string
+}
+
+// mode names the operation each load test request performs
+func (o *options) mode() string {
+	switch {
+	case o.StoreOnly:
+		return "store-only"
+	case o.Agent:
+		return "agent"
+	}
+	return "search"
+}
+
+// parseOptions resolves options from command-line arguments, using getenv for defaults.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	opts := options{DataFile: getenv("DATA_FILE_WITHOUT_VECTORS"), OpenAIRPS: defaultOpenAIRPS}
+	if opts.DataFile == "" {
+		opts.DataFile = defaultDataFile
+	}
+	if value := getenv("AZURE_OPENAI_MAX_RPS"); value != "" {
+		rps, err := strconv.ParseFloat(value, 64)
+		if err != nil || rps < 0 {
+			err := fmt.Errorf("invalid AZURE_OPENAI_MAX_RPS %q: must be a non-negative number", value)
+			fmt.Fprintln(output, err)
+			return nil, err
+		}
+		opts.OpenAIRPS = rps
+	}
+
+	fs.StringVar(&opts.QueriesFile, "queries", "", "File with one query per line; blank lines and lines starting with # are ignored")
+	fs.StringVar(&opts.DataFile, "data", opts.DataFile, "Hotel data file used to generate queries when --queries is not set (env DATA_FILE_WITHOUT_VECTORS)")
+	fs.IntVar(&opts.Generate, "generate", defaultGenerate, "Number of queries to generate from the data file when --queries is not set")
+	fs.DurationVar(&opts.Duration, "duration", defaultDuration, "How long to generate load")
+	fs.Float64Var(&opts.QPS, "qps", 0, "Target operations started per second; 0 runs --concurrency operations back to back")
+	fs.IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Workers, or the most operations in flight when --qps is set")
+	fs.IntVar(&opts.RampSteps, "ramp-steps", 1, "Split the run into this many equal steps, raising the load linearly to the target")
+	fs.DurationVar(&opts.Timeout, "timeout", defaultTimeout, "Time limit for each operation")
+	fs.IntVar(&opts.K, "k", defaultK, "Number of nearest neighbors per search")
+	fs.BoolVar(&opts.StoreOnly, "store-only", false, "Search with cached query embeddings, so only the vector store is loaded")
+	fs.BoolVar(&opts.Agent, "agent", false, "Run the full agent pipeline (planner, search, synthesizer) instead of embedding and search")
+	fs.Float64Var(&opts.OpenAIRPS, "openai-rps", opts.OpenAIRPS, "Most model requests started per second; 0 removes the load test's own cap (env AZURE_OPENAI_MAX_RPS)")
+	fs.StringVar(&opts.EmbeddingCache, "embedding-cache", defaultEmbeddingCache, "File caching query embeddings for --store-only; empty keeps them in memory only")
+	fs.StringVar(&opts.JSONOut, "json-out", "", "Also write the report as JSON to this file")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: loadtest [flags]\n\n")
+		fmt.Fprintf(output, "Sends concurrent search traffic for a fixed duration and reports achieved\n")
+		fmt.Fprintf(output, "throughput, latency percentiles, and errors by category.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// validate checks that the options are consistent
+func (o *options) validate() error {
+	if o.StoreOnly && o.Agent {
+		return errors.New("--store-only and --agent cannot be used together")
+	}
+	if o.Duration <= 0 {
+		return errors.New("invalid duration: must be positive")
+	}
+	if o.QPS < 0 {
+		return errors.New("invalid qps: must not be negative")
+	}
+	if o.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d: must be at least 1", o.Concurrency)
+	}
+	if o.RampSteps < 1 {
+		return fmt.Errorf("invalid ramp steps %d: must be at least 1", o.RampSteps)
+	}
+	if o.Timeout <= 0 {
+		return errors.New("invalid timeout: must be positive")
+	}
+	if o.K < 1 {
+		return fmt.Errorf("invalid k %d: must be at least 1", o.K)
+	}
+	if maxK := vectorstore.MaxK(); o.K > maxK {
+		// the store would clamp it, and the report would show a k that wasn't searched
+		return fmt.Errorf("invalid k %d: must be at most %d (MAX_NEAREST_NEIGHBORS)", o.K, maxK)
+	}
+	if o.OpenAIRPS < 0 {
+		return errors.New("invalid openai-rps: must not be negative")
+	}
+	if o.QueriesFile == "" && o.Generate < 1 {
+		return fmt.Errorf("invalid generate count %d: must be at least 1", o.Generate)
+	}
+	return nil
+}
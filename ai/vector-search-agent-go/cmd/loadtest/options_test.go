@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseOptions(t *testing.T) {
+	defaults := options{
+		DataFile: defaultDataFile, Generate: defaultGenerate, Duration: defaultDuration, Concurrency: defaultConcurrency,
+		RampSteps: 1, Timeout: defaultTimeout, K: defaultK, OpenAIRPS: defaultOpenAIRPS, EmbeddingCache: defaultEmbeddingCache,
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		want     func(o *options)
+		wantMode string
+	}{
+		{"defaults", nil, nil, func(o *options) {}, "search"},
+		{"env", nil, map[string]string{"DATA_FILE_WITHOUT_VECTORS": "real.json", "AZURE_OPENAI_MAX_RPS": "2.5"}, func(o *options) {
+			o.DataFile, o.OpenAIRPS = "real.json", 2.5
+		}, "search"},
+		{"flags override env", []string{"--openai-rps", "0", "--qps", "20", "--ramp-steps", "4", "--store-only"}, map[string]string{"AZURE_OPENAI_MAX_RPS": "2.5"}, func(o *options) {
+			o.OpenAIRPS, o.QPS, o.RampSteps, o.StoreOnly = 0, 20, 4, true
+		}, "store-only"},
+		{"agent", []string{"--agent", "--duration", "1m", "--queries", "q.txt", "--generate", "0"}, nil, func(o *options) {
+			o.Agent, o.Duration, o.QueriesFile, o.Generate = true, time.Minute, "q.txt", 0
+		}, "agent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			want := defaults
+			tt.want(&want)
+			if *got != want {
+				t.Errorf("options = %+v, want %+v", *got, want)
+			}
+			if got.mode() != tt.wantMode {
+				t.Errorf("mode = %q, want %q", got.mode(), tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestParseOptionsRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"both modes", []string{"--store-only", "--agent"}, nil, "cannot be used together"},
+		{"zero duration", []string{"--duration", "0s"}, nil, "invalid duration"},
+		{"negative qps", []string{"--qps", "-1"}, nil, "invalid qps"},
+		{"zero concurrency", []string{"--concurrency", "0"}, nil, "invalid concurrency 0"},
+		{"zero ramp steps", []string{"--ramp-steps", "0"}, nil, "invalid ramp steps 0"},
+		{"zero timeout", []string{"--timeout", "0s"}, nil, "invalid timeout"},
+		{"zero k", []string{"--k", "0"}, nil, "invalid k 0"},
+		{"k above max", []string{"--k", "21"}, nil, "invalid k 21: must be at most 20"},
+		{"negative openai rps", []string{"--openai-rps", "-2"}, nil, "invalid openai-rps"},
+		{"invalid env rps", nil, map[string]string{"AZURE_OPENAI_MAX_RPS": "fast"}, "invalid AZURE_OPENAI_MAX_RPS"},
+		{"nothing to generate", []string{"--generate", "0"}, nil, "invalid generate count 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out); err == nil {
+				t.Fatal("parseOptions accepted invalid options")
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+)
+
+// errorReport counts failed operations by category
+type errorReport struct {
+	Throttled int `json:"throttled"`
+	Limited   int `json:"limited"`
+	Timeout   int `json:"timeout"`
+	Other     int `json:"other"`
+}
+
+// add counts one error of category
+func (e *errorReport) add(category string) {
+	switch category {
+	case categoryThrottled:
+		e.Throttled++
+	case categoryLimited:
+		e.Limited++
+	case categoryTimeout:
+		e.Timeout++
+	default:
+		e.Other++
+	}
+}
+
+// total returns the number of failed operations
+func (e errorReport) total() int {
+	return e.Throttled + e.Limited + e.Timeout + e.Other
+}
+
+// stepReport summarizes one load level, or the whole run
+type stepReport struct {
+	Step        int                `json:"step,omitempty"`
+	TargetQPS   float64            `json:"targetQps,omitempty"`
+	Workers     int                `json:"workers,omitempty"`
+	Operations  int                `json:"operations"`
+	Succeeded   int                `json:"succeeded"`
+	Missed      int                `json:"missed"`
+	WallSeconds float64            `json:"wallSeconds"`
+	AchievedQPS float64            `json:"achievedQps"`
+	Latency     bench.LatencyStats `json:"latency"`
+	Errors      errorReport        `json:"errors"`
+}
+
+// settingsReport records the load test parameters
+type settingsReport struct {
+	Mode            string  `json:"mode"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	TargetQPS       float64 `json:"targetQps,omitempty"`
+	Concurrency     int     `json:"concurrency"`
+	RampSteps       int     `json:"rampSteps"`
+	TimeoutSeconds  float64 `json:"timeoutSeconds"`
+	K               int     `json:"k"`
+	Queries         int     `json:"queries"`
+	OpenAIRPS       float64 `json:"openaiRps,omitempty"`
+}
+
+// report is the full load test result
+type report struct {
+	GeneratedAt time.Time                 `json:"generatedAt"`
+	Build       buildinfo.Info            `json:"build"`
+	Settings    settingsReport            `json:"settings"`
+	Totals      stepReport                `json:"totals"`
+	Steps       []stepReport              `json:"steps"`
+	Usage       []clients.DeploymentUsage `json:"usage"`
+	// SampleErrors holds the first error message seen in each category
+	SampleErrors map[string]string `json:"sampleErrors,omitempty"`
+}
+
+// buildReport aggregates step results. Latency statistics and achieved QPS count only
+// successful operations.
+func buildReport(settings settingsReport, results []stepResult, usage []clients.DeploymentUsage) report {
+	r := report{GeneratedAt: time.Now().UTC(), Build: buildinfo.Read(), Settings: settings, Usage: usage}
+
+	var all []time.Duration
+	for i, result := range results {
+		step, latencies := summarizeStep(result, r.recordSample)
+		step.Step = i + 1
+		r.Steps = append(r.Steps, step)
+
+		all = append(all, latencies...)
+		r.Totals.Operations += step.Operations
+		r.Totals.Succeeded += step.Succeeded
+		r.Totals.Missed += step.Missed
+		r.Totals.WallSeconds += step.WallSeconds
+		r.Totals.Errors.Throttled += step.Errors.Throttled
+		r.Totals.Errors.Limited += step.Errors.Limited
+		r.Totals.Errors.Timeout += step.Errors.Timeout
+		r.Totals.Errors.Other += step.Errors.Other
+	}
+	r.Totals.Latency = bench.Summarize(all)
+	if r.Totals.WallSeconds > 0 {
+		r.Totals.AchievedQPS = float64(r.Totals.Succeeded) / r.Totals.WallSeconds
+	}
+	return r
+}
+
+// summarizeStep builds the report of one step and returns its successful latencies.
+// Each failure is passed to sample with its category.
+func summarizeStep(result stepResult, sample func(category string, err error)) (stepReport, []time.Duration) {
+	step := stepReport{
+		TargetQPS:   result.qps,
+		Workers:     result.workers,
+		Operations:  len(result.outcomes),
+		Missed:      result.missed,
+		WallSeconds: result.wall.Seconds(),
+	}
+
+	var latencies []time.Duration
+	for _, o := range result.outcomes {
+		if o.err != nil {
+			category := classify(o.err)
+			step.Errors.add(category)
+			sample(category, o.err)
+			continue
+		}
+		latencies = append(latencies, o.latency)
+	}
+	step.Succeeded = len(latencies)
+	step.Latency = bench.Summarize(latencies)
+	if step.WallSeconds > 0 {
+		step.AchievedQPS = float64(step.Succeeded) / step.WallSeconds
+	}
+	return step, latencies
+}
+
+// recordSample keeps the first error message of each category
+func (r *report) recordSample(category string, err error) {
+	if r.SampleErrors == nil {
+		r.SampleErrors = map[string]string{}
+	}
+	if _, ok := r.SampleErrors[category]; !ok {
+		r.SampleErrors[category] = err.Error()
+	}
+}
+
+// render prints the human-readable report
+func (r *report) render(w io.Writer) {
+	fmt.Fprintln(w, "\n=== LOAD TEST ===")
+	s := r.Settings
+	load := fmt.Sprintf("%d workers", s.Concurrency)
+	if s.TargetQPS > 0 {
+		load = fmt.Sprintf("%.1f qps target, at most %d in flight", s.TargetQPS, s.Concurrency)
+	}
+	fmt.Fprintf(w, "Mode: %s, %s, %s over %d step(s), k=%d, %d queries, %s timeout\n",
+		s.Mode, load, time.Duration(s.DurationSeconds*float64(time.Second)), s.RampSteps, s.K, s.Queries, time.Duration(s.TimeoutSeconds*float64(time.Second)))
+	if s.OpenAIRPS > 0 {
+		fmt.Fprintf(w, "Model requests capped at %g per second\n", s.OpenAIRPS)
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tLOAD\tOPS\tOK\tQPS\tP50\tP95\tP99\tTHROTTLED\tLIMITED\tTIMEOUT\tOTHER\tMISSED")
+	if len(r.Steps) > 1 {
+		for _, step := range r.Steps {
+			writeStepRow(tw, strconv.Itoa(step.Step), stepLoad(step), step)
+		}
+	}
+	writeStepRow(tw, "total", "", r.Totals)
+	tw.Flush()
+
+	for _, category := range categories {
+		if msg, ok := r.SampleErrors[category]; ok {
+			fmt.Fprintf(w, "\nFirst %s error: %s", category, msg)
+		}
+	}
+	if len(r.SampleErrors) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	if len(r.Usage) > 0 {
+		fmt.Fprintln(w, "\nModel usage:")
+		for _, u := range r.Usage {
+			fmt.Fprintf(w, "  %s: %d calls, %d tokens", u.Deployment, u.Calls, u.TotalTokens())
+			if u.PriceKnown {
+				fmt.Fprintf(w, " (~$%.4f)", u.EstimatedCost)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// stepLoad describes the load level of a step
+func stepLoad(step stepReport) string {
+	if step.TargetQPS > 0 {
+		return fmt.Sprintf("%.1f qps", step.TargetQPS)
+	}
+	return fmt.Sprintf("%d workers", step.Workers)
+}
+
+// writeStepRow writes one row of the step table
+func writeStepRow(w io.Writer, name, load string, step stepReport) {
+	l := step.Latency
+	fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+		name, load, step.Operations, step.Succeeded, step.AchievedQPS,
+		bench.Round(l.P50), bench.Round(l.P95), bench.Round(l.P99),
+		step.Errors.Throttled, step.Errors.Limited, step.Errors.Timeout, step.Errors.Other, step.Missed)
+}
+
+// writeJSON writes the report to path
+func (r *report) writeJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
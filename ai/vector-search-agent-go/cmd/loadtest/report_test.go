@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/openai/openai-go/v3"
+)
+
+// timeoutError is a network error that timed out
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"throttled", fmt.Errorf("embedding: %w", &openai.Error{StatusCode: http.StatusTooManyRequests}), categoryThrottled},
+		{"client limit", fmt.Errorf("embedding: %w", clients.ErrRateLimited), categoryLimited},
+		{"deadline", fmt.Errorf("search: %w", context.DeadlineExceeded), categoryTimeout},
+		{"network timeout", fmt.Errorf("search: %w", timeoutError{}), categoryTimeout},
+		{"server error", &openai.Error{StatusCode: http.StatusInternalServerError}, categoryOther},
+		{"other", errors.New("boom"), categoryOther},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.err); got != tt.want {
+			t.Errorf("%s: classify = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	results := []stepResult{
+		{workers: 1, wall: time.Second, outcomes: []outcome{
+			{latency: 10 * time.Millisecond},
+			{latency: 30 * time.Millisecond},
+			{err: clients.ErrRateLimited},
+		}},
+		{workers: 2, wall: time.Second, missed: 1, outcomes: []outcome{
+			{latency: 20 * time.Millisecond},
+			{err: context.DeadlineExceeded},
+			{err: errors.New("first other")},
+			{err: errors.New("second other")},
+		}},
+	}
+
+	r := buildReport(settingsReport{Mode: "search", Concurrency: 2, RampSteps: 2}, results, nil)
+	if len(r.Steps) != 2 || r.Steps[0].Step != 1 || r.Steps[1].Step != 2 {
+		t.Fatalf("steps = %+v", r.Steps)
+	}
+	if s := r.Steps[0]; s.Operations != 3 || s.Succeeded != 2 || s.AchievedQPS != 2 || s.Errors.Limited != 1 || s.Latency.Count != 2 {
+		t.Errorf("step 1 = %+v", s)
+	}
+
+	totals := r.Totals
+	want := errorReport{Limited: 1, Timeout: 1, Other: 2}
+	if totals.Operations != 7 || totals.Succeeded != 3 || totals.Missed != 1 || totals.Errors != want || totals.AchievedQPS != 1.5 {
+		t.Errorf("totals = %+v", totals)
+	}
+	if totals.Latency.Count != 3 || totals.Latency.Max != 30*time.Millisecond {
+		t.Errorf("total latency = %+v, want the successful operations only", totals.Latency)
+	}
+	if r.SampleErrors[categoryOther] != "first other" || len(r.SampleErrors) != 3 {
+		t.Errorf("sample errors = %v", r.SampleErrors)
+	}
+
+	var out bytes.Buffer
+	r.render(&out)
+	for _, want := range []string{"Mode: search, 2 workers", "1 workers", "2 workers", "total", "First other error: first other"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestLimitedModelsWaitForLimiter(t *testing.T) {
+	m := &limitedModels{Models: offline.NewModel(offline.NewFakeEmbedder(8)), limiter: clients.NewRateLimiter(1)}
+	if _, err := m.GenerateEmbedding(context.Background(), "pool"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second request would start a second later, after the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := m.ChatCompletion(ctx, "system", "hotels with a pool"); classify(err) != categoryLimited {
+		t.Errorf("err = %v, want a client-side limit", err)
+	}
+}
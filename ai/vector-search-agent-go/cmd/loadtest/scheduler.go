@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// operation performs one load test request for the query at index query
+type operation func(ctx context.Context, query int) error
+
+// outcome is the result of one operation
+type outcome struct {
+	latency time.Duration
+	err     error
+}
+
+// stepResult collects the outcomes of one load level
+type stepResult struct {
+	// qps is the target rate of an open-loop step, workers the worker count of a closed-loop one
+	qps      float64
+	workers  int
+	wall     time.Duration
+	outcomes []outcome
+	// missed counts operations an open-loop step skipped because too many were in flight
+	missed int
+}
+
+// scheduler issues operations at a target rate (open loop) or from a fixed number of
+// workers (closed loop), cycling through the query pool
+type scheduler struct {
+	op          operation
+	queries     int
+	qps         float64
+	concurrency int
+	timeout     time.Duration
+
+	next atomic.Uint64
+}
+
+// run generates load for duration, split into steps equal parts whose level rises
+// linearly to the target. It stops early when ctx is cancelled; operations cancelled
+// that way are not recorded.
+func (s *scheduler) run(ctx context.Context, duration time.Duration, steps int) []stepResult {
+	stepDuration := duration / time.Duration(steps)
+	results := make([]stepResult, 0, steps)
+	for i := 1; i <= steps && ctx.Err() == nil; i++ {
+		fraction := float64(i) / float64(steps)
+		if s.qps > 0 {
+			results = append(results, s.openStep(ctx, s.qps*fraction, stepDuration))
+		} else {
+			workers := max(1, int(math.Ceil(float64(s.concurrency)*fraction)))
+			results = append(results, s.closedStep(ctx, workers, stepDuration))
+		}
+	}
+	return results
+}
+
+// closedStep runs workers operations back to back until d has passed, then waits for
+// the last ones to finish
+func (s *scheduler) closedStep(ctx context.Context, workers int, d time.Duration) stepResult {
+	result := stepResult{workers: workers}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	end := start.Add(d)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil && time.Now().Before(end) {
+				o, ok := s.runOne(ctx)
+				if !ok {
+					return
+				}
+				mu.Lock()
+				result.outcomes = append(result.outcomes, o)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.wall = time.Since(start)
+	return result
+}
+
+// openStep starts operations evenly spaced at qps until d has passed, then waits for
+// those in flight. A start that would exceed the concurrency cap is skipped and counted
+// as missed, so a slow backend shows up as missed operations rather than an unbounded queue.
+func (s *scheduler) openStep(ctx context.Context, qps float64, d time.Duration) stepResult {
+	result := stepResult{qps: qps}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, s.concurrency)
+
+	interval := time.Duration(float64(time.Second) / qps)
+	start := time.Now()
+	end := start.Add(d)
+	for next := start; next.Before(end); next = next.Add(interval) {
+		if !sleepUntil(ctx, next) {
+			break
+		}
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			result.missed++
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-inFlight }()
+			if o, ok := s.runOne(ctx); ok {
+				mu.Lock()
+				result.outcomes = append(result.outcomes, o)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.wall = time.Since(start)
+	return result
+}
+
+// runOne runs the operation for the next query in the pool. It reports false when the
+// operation was cut short by ctx being cancelled.
+func (s *scheduler) runOne(ctx context.Context) (outcome, bool) {
+	query := int((s.next.Add(1) - 1) % uint64(s.queries))
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.op(opCtx, query)
+	if err != nil && ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return outcome{}, false
+	}
+	return outcome{latency: time.Since(start), err: err}, true
+}
+
+// sleepUntil waits until t and reports whether ctx is still live
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOp is an operation that records the queries it served and how many ran at once.
+// Each call takes delay, and fails with err when set.
+type fakeOp struct {
+	mu          sync.Mutex
+	queries     []int
+	inFlight    int
+	maxInFlight int
+	delay       time.Duration
+	err         error
+	onCall      func()
+}
+
+func (f *fakeOp) run(ctx context.Context, query int) error {
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	onCall := f.onCall
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if onCall != nil {
+		onCall()
+	}
+	select {
+	case <-time.After(f.delay):
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestSchedulerClosedLoop(t *testing.T) {
+	op := &fakeOp{delay: 5 * time.Millisecond}
+	s := &scheduler{op: op.run, queries: 3, concurrency: 3, timeout: time.Second}
+
+	results := s.run(context.Background(), 60*time.Millisecond, 1)
+	if len(results) != 1 {
+		t.Fatalf("got %d steps, want 1", len(results))
+	}
+	step := results[0]
+	if step.workers != 3 || step.qps != 0 || step.missed != 0 {
+		t.Errorf("step = %+v", step)
+	}
+	if len(step.outcomes) < 9 || len(step.outcomes) != len(op.queries) {
+		t.Errorf("%d outcomes for %d calls, want at least 9", len(step.outcomes), len(op.queries))
+	}
+	if op.maxInFlight > 3 {
+		t.Errorf("max in flight = %d, want at most 3", op.maxInFlight)
+	}
+	if step.wall < 60*time.Millisecond {
+		t.Errorf("wall = %v, want the full duration", step.wall)
+	}
+
+	// Queries cycle through the pool
+	counts := make([]int, 3)
+	for _, q := range op.queries {
+		counts[q]++
+	}
+	if slices.Max(counts)-slices.Min(counts) > 1 {
+		t.Errorf("query counts = %v, want an even spread", counts)
+	}
+}
+
+func TestSchedulerOpenLoop(t *testing.T) {
+	op := &fakeOp{}
+	s := &scheduler{op: op.run, queries: 5, qps: 100, concurrency: 4, timeout: time.Second}
+
+	results := s.run(context.Background(), 100*time.Millisecond, 1)
+	step := results[0]
+	// Starts are 10ms apart, so about ten fit in the step
+	if n := len(step.outcomes); n < 8 || n > 10 {
+		t.Errorf("%d operations started, want about 10", n)
+	}
+	if step.qps != 100 || step.missed != 0 {
+		t.Errorf("step = %+v", step)
+	}
+}
+
+func TestSchedulerOpenLoopCountsMissedStarts(t *testing.T) {
+	op := &fakeOp{delay: 50 * time.Millisecond}
+	s := &scheduler{op: op.run, queries: 1, qps: 200, concurrency: 1, timeout: time.Second}
+
+	step := s.run(context.Background(), 100*time.Millisecond, 1)[0]
+	if op.maxInFlight != 1 {
+		t.Errorf("max in flight = %d, want the cap of 1", op.maxInFlight)
+	}
+	if step.missed == 0 || len(step.outcomes)+step.missed < 15 {
+		t.Errorf("%d ran and %d missed, want most starts missed", len(step.outcomes), step.missed)
+	}
+}
+
+func TestSchedulerRampsLinearly(t *testing.T) {
+	closed := &scheduler{op: (&fakeOp{}).run, queries: 1, concurrency: 8, timeout: time.Second}
+	var workers []int
+	for _, step := range closed.run(context.Background(), 40*time.Millisecond, 4) {
+		workers = append(workers, step.workers)
+	}
+	if !slices.Equal(workers, []int{2, 4, 6, 8}) {
+		t.Errorf("workers = %v, want 2,4,6,8", workers)
+	}
+
+	open := &scheduler{op: (&fakeOp{}).run, queries: 1, qps: 100, concurrency: 8, timeout: time.Second}
+	var rates []float64
+	for _, step := range open.run(context.Background(), 40*time.Millisecond, 4) {
+		rates = append(rates, step.qps)
+	}
+	if !slices.Equal(rates, []float64{25, 50, 75, 100}) {
+		t.Errorf("rates = %v, want 25,50,75,100", rates)
+	}
+}
+
+func TestSchedulerRecordsTimeoutsAndErrors(t *testing.T) {
+	slow := &scheduler{op: (&fakeOp{delay: time.Hour}).run, queries: 1, concurrency: 1, timeout: 5 * time.Millisecond}
+	step := slow.run(context.Background(), 20*time.Millisecond, 1)[0]
+	if len(step.outcomes) == 0 || !errors.Is(step.outcomes[0].err, context.DeadlineExceeded) {
+		t.Errorf("outcomes = %+v, want timeouts", step.outcomes)
+	}
+
+	failing := &scheduler{op: (&fakeOp{err: errors.New("boom")}).run, queries: 1, concurrency: 1, timeout: time.Second}
+	step = failing.run(context.Background(), 10*time.Millisecond, 1)[0]
+	if len(step.outcomes) == 0 || step.outcomes[0].err == nil {
+		t.Errorf("outcomes = %+v, want errors", step.outcomes)
+	}
+}
+
+func TestSchedulerStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &fakeOp{delay: time.Hour}
+	op.onCall = cancel
+	s := &scheduler{op: op.run, queries: 1, concurrency: 2, timeout: time.Hour}
+
+	start := time.Now()
+	results := s.run(ctx, time.Hour, 3)
+	if time.Since(start) > time.Second {
+		t.Fatal("run did not stop when cancelled")
+	}
+	// Operations cut short by the cancellation are not recorded, and no later step starts
+	if len(results) != 1 || len(results[0].outcomes) != 0 {
+		t.Errorf("results = %+v, want one step without outcomes", results)
+	}
+}
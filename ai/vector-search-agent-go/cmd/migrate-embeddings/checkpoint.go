@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// checkpoint records the completed migration steps and the work done so far, so an
+// interrupted migration can resume. Which documents already have new embeddings is
+// read from the target, so a crash between writes loses nothing.
+type checkpoint struct {
+	path string
+	file checkpointFile
+}
+
+// checkpointFile is the on-disk format of a checkpoint
+type checkpointFile struct {
+	// Fingerprint identifies the migration; a checkpoint for other settings can't be resumed
+	Fingerprint  string    `json:"fingerprint"`
+	Completed    []string  `json:"completed"`
+	Migrated     int       `json:"migrated"`
+	PromptTokens int64     `json:"promptTokens"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// errCheckpointMismatch is returned when resuming from a checkpoint for other settings
+var errCheckpointMismatch = errors.New("checkpoint was written for a different migration")
+
+// newCheckpoint starts an empty checkpoint at path
+func newCheckpoint(path, fingerprint string) *checkpoint {
+	return &checkpoint{path: path, file: checkpointFile{Fingerprint: fingerprint}}
+}
+
+// loadCheckpoint reads the checkpoint at path. A missing file yields an empty checkpoint.
+func loadCheckpoint(path, fingerprint string) (*checkpoint, error) {
+	c := newCheckpoint(path, fingerprint)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.file); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	if c.file.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("%w: %s (fingerprint %s, now %s); delete it or run without --resume", errCheckpointMismatch, path, c.file.Fingerprint, fingerprint)
+	}
+	return c, nil
+}
+
+// Done reports whether a step completed
+func (c *checkpoint) Done(step string) bool {
+	return slices.Contains(c.file.Completed, step)
+}
+
+// Complete records a completed step and saves the checkpoint
+func (c *checkpoint) Complete(step string) error {
+	if !c.Done(step) {
+		c.file.Completed = append(c.file.Completed, step)
+	}
+	return c.Save()
+}
+
+// Add records migrated documents and the tokens spent on them, and saves the checkpoint
+func (c *checkpoint) Add(documents int, promptTokens int64) error {
+	c.file.Migrated += documents
+	c.file.PromptTokens += promptTokens
+	return c.Save()
+}
+
+// Save writes the checkpoint through a temporary file that is renamed into place
+func (c *checkpoint) Save() error {
+	c.file.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(c.file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the checkpoint file after a completed migration
+func (c *checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// fingerprint hashes the settings that decide what a migration writes where
+func fingerprint(parts ...string) string {
+	data, _ := json.Marshal(parts)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
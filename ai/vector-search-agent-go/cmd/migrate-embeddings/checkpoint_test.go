@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp, err := loadCheckpoint(path, "fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Done(stepEmbed) || cp.file.Migrated != 0 {
+		t.Fatalf("missing checkpoint loaded as %+v, want an empty one", cp.file)
+	}
+
+	if err := cp.Add(3, 120); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Add(2, 80); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Complete(stepEmbed); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Complete(stepEmbed); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadCheckpoint(path, "fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.file.Completed, []string{stepEmbed}) || loaded.file.Migrated != 5 || loaded.file.PromptTokens != 200 {
+		t.Errorf("loaded checkpoint = %+v, want embed completed with 5 documents and 200 tokens", loaded.file)
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint still exists after Remove: %v", err)
+	}
+	if err := loaded.Remove(); err != nil {
+		t.Errorf("removing a missing checkpoint: %v", err)
+	}
+}
+
+func TestLoadCheckpointErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "checkpoint.json")
+	if err := newCheckpoint(path, fingerprint("large", "3072")).Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCheckpoint(path, fingerprint("large", "1536")); !errors.Is(err, errCheckpointMismatch) {
+		t.Errorf("err = %v, want errCheckpointMismatch", err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCheckpoint(bad, "fp"); err == nil {
+		t.Error("corrupt checkpoint loaded")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if fingerprint("a", "b") != fingerprint("a", "b") {
+		t.Error("fingerprint is not stable")
+	}
+	if fingerprint("ab", "c") == fingerprint("a", "bc") {
+		t.Error("fingerprint does not separate its parts")
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/runmetrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run re-embeds every document with the target embedding model
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, DocumentDB: true, VectorIndex: true})
+	if err != nil {
+		return err
+	}
+	vsConfig := cfg.VectorStore
+	if err := opts.resolveTargets(vsConfig); err != nil {
+		return fmt.Errorf("%w: %w", cli.ErrConfig, err)
+	}
+
+	// Ctrl+C or SIGTERM stops after the current batch is written and checkpointed
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	// The new model gets its own clients, so usage counts only migration calls
+	openaiConfig := *cfg.OpenAI
+	openaiConfig.EmbeddingDeployment = opts.Deployment
+	openaiConfig.EmbeddingDimensions = opts.Dimensions
+	openaiClients, err := clients.NewOpenAIClients(&openaiConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenAI clients: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Connecting to database: %s\n", vsConfig.DatabaseName)
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	var target *vectorstore.VectorStore
+	switch opts.Mode {
+	case modeField:
+		target = store.WithVectorField(opts.TargetField, opts.TargetIndex)
+		fmt.Fprintf(os.Stderr, "Migrating %s.%s to field %s, index %s\n", vsConfig.CollectionName, vsConfig.EmbeddedField, opts.TargetField, opts.TargetIndex)
+	case modeCollection:
+		target = store.WithCollection(opts.TargetCollection).WithVectorField(vsConfig.EmbeddedField, opts.TargetIndex)
+		fmt.Fprintf(os.Stderr, "Migrating %s to collection %s, index %s\n", vsConfig.CollectionName, opts.TargetCollection, opts.TargetIndex)
+	}
+
+	fp := fingerprint(vsConfig.DatabaseName, vsConfig.CollectionName, vsConfig.EmbeddedField,
+		opts.Deployment, strconv.Itoa(opts.Dimensions), opts.Mode, opts.TargetField, opts.TargetIndex, opts.TargetCollection)
+	cp := newCheckpoint(opts.Checkpoint, fp)
+	if opts.Resume {
+		if cp, err = loadCheckpoint(opts.Checkpoint, fp); err != nil {
+			return fmt.Errorf("%w: %w", cli.ErrConfig, err)
+		}
+	}
+
+	spec := vectorstore.IndexSpecFromEnv()
+	spec.Dimensions = opts.Dimensions
+
+	m := &migrator{
+		source:        store,
+		target:        target,
+		embedder:      openaiClients,
+		usage:         openaiClients.Usage(),
+		deployment:    opts.Deployment,
+		copyDocuments: opts.Mode == modeCollection,
+		spec:          spec,
+		checkpoint:    cp,
+		batchSize:     opts.BatchSize,
+		concurrency:   opts.Concurrency,
+		verifySamples: opts.VerifySamples,
+		verifyMin:     opts.VerifyMin,
+		readyTimeout:  opts.ReadyTimeout,
+		pollInterval:  opts.PollInterval,
+		tty:           progress.IsTerminal(os.Stdout),
+		out:           os.Stdout,
+	}
+
+	// Record the run in METRICS_COLLECTION when it is set, counting only the documents
+	// this run migrated; the checkpoint's count spans every run of the migration
+	var recorder *runmetrics.Recorder
+	if vsConfig.MetricsCollection != "" {
+		recorder = runmetrics.New(store, cfg.Runtime.MetricsEnvironment, opts.Deployment)
+	}
+	defer recorder.Close()
+	resumedFrom := cp.file.Migrated
+
+	err = m.run(ctx)
+	recorder.Record(ctx, runmetrics.Run{
+		Command:   "migrate-embeddings",
+		Usage:     openaiClients.Usage().Snapshot(),
+		Documents: map[string]int64{"migrated": int64(cp.file.Migrated - resumedFrom)},
+		Err:       err,
+	})
+	printCost(os.Stdout, opts.Deployment, cp)
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("\nMigration cancelled by user. Rerun with --resume to continue from %s.\n", opts.Checkpoint)
+		return err
+	}
+	if errors.Is(err, errVerifyFailed) {
+		fmt.Println("\nThe new embeddings are in place but did not pass verification; readers were not switched.")
+		return fmt.Errorf("%w: %w", cli.ErrData, err)
+	}
+	if err != nil {
+		fmt.Printf("\nRerun with --resume to continue from %s.\n", opts.Checkpoint)
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	printCutover(os.Stdout, opts, vsConfig)
+	if err := cp.Remove(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nMigration completed successfully!")
+	return nil
+}
+
+// printCost reports the documents migrated and the tokens spent across every run of
+// this migration, with an estimated cost when the model's price is known
+func printCost(w io.Writer, deployment string, cp *checkpoint) {
+	fmt.Fprintf(w, "\nDocuments migrated: %d\n", cp.file.Migrated)
+	fmt.Fprintf(w, "Embedding tokens:   %d\n", cp.file.PromptTokens)
+	if pricing, ok := clients.PricingFor(deployment); ok {
+		fmt.Fprintf(w, "Estimated cost:     $%.4f\n", float64(cp.file.PromptTokens)*pricing.PromptPer1M/1e6)
+	} else {
+		fmt.Fprintf(w, "Estimated cost:     unknown (no price for %s)\n", deployment)
+	}
+}
+
+// printCutover tells the operator which settings switch readers to the new embeddings
+// and how to remove the old ones afterwards
+func printCutover(w io.Writer, opts *options, current *vectorstore.VectorStoreConfig) {
+	fmt.Fprintln(w, "\nTo switch readers to the new embeddings, set these and restart them:")
+	fmt.Fprintf(w, "  AZURE_OPENAI_EMBEDDING_DEPLOYMENT=%s\n", opts.Deployment)
+	fmt.Fprintf(w, "  AZURE_OPENAI_EMBEDDING_DIMENSIONS=%d\n", opts.Dimensions)
+	fmt.Fprintf(w, "  EMBEDDING_DIMENSIONS=%d\n", opts.Dimensions)
+	switch opts.Mode {
+	case modeField:
+		fmt.Fprintf(w, "  EMBEDDED_FIELD=%s\n", opts.TargetField)
+		fmt.Fprintf(w, "  AZURE_DOCUMENTDB_INDEX_NAME=%s\n", opts.TargetIndex)
+		fmt.Fprintf(w, "\nOnce no reader uses them, drop index %s and unset field %s in collection %s.\n", current.IndexName, current.EmbeddedField, current.CollectionName)
+		fmt.Fprintln(w, "Stored content hashes still name the old model, so the next `cmd/upload --changed-only` re-embeds every hotel.")
+	case modeCollection:
+		fmt.Fprintf(w, "  AZURE_DOCUMENTDB_COLLECTION=%s\n", opts.TargetCollection)
+		if opts.TargetIndex != current.IndexName {
+			fmt.Fprintf(w, "  AZURE_DOCUMENTDB_INDEX_NAME=%s\n", opts.TargetIndex)
+		}
+		fmt.Fprintf(w, "\nOnce no reader uses it, drop collection %s.\n", current.CollectionName)
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"golang.org/x/sync/errgroup"
+)
+
+// Migration steps, in the order they run. Each is recorded in the checkpoint once it
+// completes, so a resumed migration skips it.
+const (
+	stepEmbed  = "embed"
+	stepIndex  = "index"
+	stepVerify = "verify"
+)
+
+// verifyK is the number of neighbors requested by each verification search
+const verifyK = 5
+
+// sourceStore is the subset of the vector store the documents are read from
+type sourceStore interface {
+	CountDocuments(ctx context.Context) (int64, error)
+	ExportHotels(ctx context.Context, opts vectorstore.ExportOptions, fn func(models.HotelForVectorStore) error) (int, error)
+}
+
+// targetStore is the subset of the vector store the new embeddings are written to:
+// the source collection with another vector field, or another collection
+type targetStore interface {
+	HotelIDsWithVectors(ctx context.Context) (map[string]bool, error)
+	UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) error
+	SetVectors(ctx context.Context, vectors map[string][]float32) error
+	FindVectorIndex(ctx context.Context) (*vectorstore.VectorIndexInfo, error)
+	CreateVectorIndexWithSpec(ctx context.Context, spec vectorstore.VectorIndexSpec) error
+	SampleVector(ctx context.Context) (string, []float32, error)
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+	ExportHotels(ctx context.Context, opts vectorstore.ExportOptions, fn func(models.HotelForVectorStore) error) (int, error)
+}
+
+// embedder generates embeddings with the new model
+type embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// errVerifyFailed reports that too few sample searches found their own document
+var errVerifyFailed = errors.New("verification failed")
+
+// errStopSampling ends the sample read once enough documents were collected
+var errStopSampling = errors.New("enough samples")
+
+// migrator re-embeds the source documents into the target, indexes the new vectors,
+// and verifies them with sample searches
+type migrator struct {
+	source   sourceStore
+	target   targetStore
+	embedder embedder
+	// usage records the tokens spent by embedder; nil when not tracked
+	usage      *clients.UsageTracker
+	deployment string
+	// copyDocuments upserts each source document into the target before its vector is
+	// written, for migrations into another collection
+	copyDocuments bool
+	spec          vectorstore.VectorIndexSpec
+	checkpoint    *checkpoint
+
+	batchSize     int
+	concurrency   int
+	verifySamples int
+	verifyMin     float64
+	readyTimeout  time.Duration
+	pollInterval  time.Duration
+	tty           bool
+	out           io.Writer
+}
+
+// run performs the steps the checkpoint does not record as completed: embed, index,
+// then verify
+func (m *migrator) run(ctx context.Context) error {
+	steps := []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{stepEmbed, m.embed},
+		{stepIndex, m.index},
+		{stepVerify, m.verify},
+	}
+
+	for _, step := range steps {
+		if m.checkpoint.Done(step.name) {
+			fmt.Fprintf(m.out, "Skipping %s: completed in an earlier run\n", step.name)
+			continue
+		}
+		if err := step.run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+		if err := m.checkpoint.Complete(step.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// embed streams the source documents in batches, generating new embeddings for those
+// the target does not have yet and writing each batch before reading the next
+func (m *migrator) embed(ctx context.Context) error {
+	done, err := m.target.HotelIDsWithVectors(ctx)
+	if err != nil {
+		return err
+	}
+	total, err := m.source.CountDocuments(ctx)
+	if err != nil {
+		return err
+	}
+
+	remaining := max(int(total)-len(done), 0)
+	fmt.Fprintf(m.out, "\nEmbedding %d documents with %s (%d already migrated)...\n", remaining, m.deployment, len(done))
+
+	reporter := progress.New(m.out, remaining, m.tty)
+	batch := make([]models.HotelForVectorStore, 0, m.batchSize)
+	_, err = m.source.ExportHotels(ctx, vectorstore.ExportOptions{}, func(hotel models.HotelForVectorStore) error {
+		if done[hotel.HotelID] {
+			return nil
+		}
+		batch = append(batch, hotel)
+		if len(batch) < m.batchSize {
+			return nil
+		}
+		err := m.writeBatch(ctx, batch, reporter)
+		batch = batch[:0]
+		return err
+	})
+	if err == nil {
+		err = m.writeBatch(ctx, batch, reporter)
+	}
+	reporter.Finish()
+	return err
+}
+
+// writeBatch embeds a batch with up to concurrency requests in flight, writes the
+// vectors, and records the batch and its tokens in the checkpoint
+func (m *migrator) writeBatch(ctx context.Context, batch []models.HotelForVectorStore, reporter *progress.Reporter) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	tokensBefore := m.promptTokens()
+
+	vectors := make([][]float32, len(batch))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(m.concurrency)
+	for i, hotel := range batch {
+		g.Go(func() error {
+			vector, err := m.embedder.GenerateEmbedding(gctx, hotel.Description)
+			if err != nil {
+				return fmt.Errorf("failed to embed hotel %s: %w", hotel.HotelID, err)
+			}
+			if len(vector) != m.spec.Dimensions {
+				return fmt.Errorf("%w: %s returned %d dimensions for hotel %s, expected %d", vectorstore.ErrDimensionMismatch, m.deployment, len(vector), hotel.HotelID, m.spec.Dimensions)
+			}
+			vectors[i] = vector
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	byID := make(map[string][]float32, len(batch))
+	for i, hotel := range batch {
+		byID[hotel.HotelID] = vectors[i]
+	}
+
+	if m.copyDocuments {
+		docs := make([]models.HotelForVectorStore, len(batch))
+		for i, hotel := range batch {
+			// The vector is written by SetVectors, under the target's field name
+			hotel.DescriptionVector = nil
+			hotel.ContentHash = hotel.HashContent(m.deployment)
+			docs[i] = hotel
+		}
+		if err := m.target.UpsertHotels(ctx, docs); err != nil {
+			return err
+		}
+	}
+	if err := m.target.SetVectors(ctx, byID); err != nil {
+		return err
+	}
+
+	for range batch {
+		reporter.Done()
+	}
+	return m.checkpoint.Add(len(batch), m.promptTokens()-tokensBefore)
+}
+
+// index creates the vector index on the new embeddings, unless an earlier run already
+// did, and waits until it serves searches
+func (m *migrator) index(ctx context.Context) error {
+	existing, err := m.target.FindVectorIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		fmt.Fprintf(m.out, "\nVector index already exists: %s\n", existing)
+	} else {
+		fmt.Fprintf(m.out, "\nCreating vector index: %s\n", m.spec)
+		if err := m.target.CreateVectorIndexWithSpec(ctx, m.spec); err != nil {
+			return err
+		}
+	}
+
+	sampleID, sample, err := m.target.SampleVector(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot run a canary search: %w", err)
+	}
+
+	fmt.Fprintln(m.out, "Waiting for the index to serve a canary search...")
+	start := time.Now()
+	if err := m.waitReady(ctx, sample); err != nil {
+		return err
+	}
+	fmt.Fprintf(m.out, "Canary search for hotel %s returned results after %s\n", sampleID, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// waitReady polls with a canary search until it returns results or the ready timeout
+// elapses
+func (m *migrator) waitReady(ctx context.Context, sample []float32) error {
+	ctx, cancel := context.WithTimeout(ctx, m.readyTimeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		results, err := m.target.VectorSearch(ctx, sample, verifyK)
+		if err == nil && len(results) > 0 {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = errors.New("canary search returned no results")
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("index not ready after %s: %w", m.readyTimeout, lastErr)
+		case <-time.After(m.pollInterval):
+		}
+	}
+}
+
+// verify embeds the descriptions of sample documents with the new model and searches
+// the new index with them. Enough searches must return their own document.
+func (m *migrator) verify(ctx context.Context) error {
+	var samples []models.HotelForVectorStore
+	_, err := m.target.ExportHotels(ctx, vectorstore.ExportOptions{}, func(hotel models.HotelForVectorStore) error {
+		samples = append(samples, hotel)
+		if len(samples) == m.verifySamples {
+			return errStopSampling
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopSampling) {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("%w: the target has no documents", errVerifyFailed)
+	}
+
+	fmt.Fprintf(m.out, "\nVerifying %d sample searches against the new index...\n", len(samples))
+	found := 0
+	for _, hotel := range samples {
+		vector, err := m.embedder.GenerateEmbedding(ctx, hotel.Description)
+		if err != nil {
+			return fmt.Errorf("failed to embed sample hotel %s: %w", hotel.HotelID, err)
+		}
+		results, err := m.target.VectorSearch(ctx, vector, verifyK)
+		if err != nil {
+			return err
+		}
+		if containsHotel(results, hotel.HotelID) {
+			found++
+		} else {
+			fmt.Fprintf(m.out, "  hotel %s (%s) was not in its own top %d\n", hotel.HotelID, hotel.HotelName, verifyK)
+		}
+	}
+
+	share := float64(found) / float64(len(samples))
+	fmt.Fprintf(m.out, "%d of %d sample searches returned their own document (%.0f%%, need %.0f%%)\n", found, len(samples), share*100, m.verifyMin*100)
+	if share < m.verifyMin {
+		return fmt.Errorf("%w: %d of %d sample searches returned their own document", errVerifyFailed, found, len(samples))
+	}
+	return nil
+}
+
+// promptTokens returns the prompt tokens recorded for the new deployment so far
+func (m *migrator) promptTokens() int64 {
+	if m.usage == nil {
+		return 0
+	}
+	var tokens int64
+	for _, usage := range m.usage.Snapshot() {
+		tokens += usage.PromptTokens
+	}
+	return tokens
+}
+
+// containsHotel reports whether results include the hotel with id
+func containsHotel(results []models.HotelSearchResult, id string) bool {
+	for _, result := range results {
+		if result.Hotel.HotelID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const testDeployment = "text-embedding-3-large"
+
+// events records the store and embedder calls of a migration in order
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+// fakeSource is the current collection, read in HotelId order
+type fakeSource struct {
+	docs []models.HotelForVectorStore
+}
+
+func (s *fakeSource) CountDocuments(ctx context.Context) (int64, error) {
+	return int64(len(s.docs)), nil
+}
+
+func (s *fakeSource) ExportHotels(ctx context.Context, opts vectorstore.ExportOptions, fn func(models.HotelForVectorStore) error) (int, error) {
+	for i, doc := range s.docs {
+		if err := fn(doc); err != nil {
+			return i, err
+		}
+	}
+	return len(s.docs), nil
+}
+
+// fakeTarget holds the new vectors by HotelId. Searches return the documents whose
+// vector equals the query, so a sample search finds its own document.
+type fakeTarget struct {
+	events   *events
+	source   *fakeSource
+	vectors  map[string][]float32
+	upserted []models.HotelForVectorStore
+	index    *vectorstore.VectorIndexSpec
+	// existing is reported by FindVectorIndex as an index from an earlier run
+	existing bool
+	// notReady is the number of canary searches that return no results
+	notReady int
+	// miss makes every search return no results
+	miss   bool
+	setErr error
+}
+
+func newFakeTarget(ev *events, source *fakeSource) *fakeTarget {
+	return &fakeTarget{events: ev, source: source, vectors: make(map[string][]float32)}
+}
+
+func (t *fakeTarget) HotelIDsWithVectors(ctx context.Context) (map[string]bool, error) {
+	ids := make(map[string]bool, len(t.vectors))
+	for id := range t.vectors {
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+func (t *fakeTarget) UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	t.events.add("upsert")
+	t.upserted = append(t.upserted, hotels...)
+	return nil
+}
+
+func (t *fakeTarget) SetVectors(ctx context.Context, vectors map[string][]float32) error {
+	if t.setErr != nil {
+		return t.setErr
+	}
+	t.events.add(fmt.Sprintf("write %d", len(vectors)))
+	for id, vector := range vectors {
+		t.vectors[id] = vector
+	}
+	return nil
+}
+
+func (t *fakeTarget) FindVectorIndex(ctx context.Context) (*vectorstore.VectorIndexInfo, error) {
+	if t.existing {
+		return &vectorstore.VectorIndexInfo{Name: "vectorIndex_new", Field: "vector_new", Kind: "vector-ivf"}, nil
+	}
+	return nil, nil
+}
+
+func (t *fakeTarget) CreateVectorIndexWithSpec(ctx context.Context, spec vectorstore.VectorIndexSpec) error {
+	t.events.add("index")
+	t.index = &spec
+	return nil
+}
+
+func (t *fakeTarget) SampleVector(ctx context.Context) (string, []float32, error) {
+	for _, doc := range t.source.docs {
+		if vector, ok := t.vectors[doc.HotelID]; ok {
+			return doc.HotelID, vector, nil
+		}
+	}
+	return "", nil, errors.New("no documents with vectors")
+}
+
+func (t *fakeTarget) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	t.events.add("search")
+	if t.notReady > 0 {
+		t.notReady--
+		return nil, nil
+	}
+	if t.miss {
+		return nil, nil
+	}
+	var results []models.HotelSearchResult
+	for _, doc := range t.source.docs {
+		if slices.Equal(t.vectors[doc.HotelID], queryVector) {
+			results = append(results, models.HotelSearchResult{Hotel: doc, Score: 1})
+		}
+	}
+	return results[:min(k, len(results))], nil
+}
+
+func (t *fakeTarget) ExportHotels(ctx context.Context, opts vectorstore.ExportOptions, fn func(models.HotelForVectorStore) error) (int, error) {
+	exported := 0
+	for _, doc := range t.source.docs {
+		if _, ok := t.vectors[doc.HotelID]; !ok {
+			continue
+		}
+		if err := fn(doc); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	return exported, nil
+}
+
+// fakeEmbedder derives a two-dimensional vector from the text and records one prompt
+// token per word. Texts containing failOn fail.
+type fakeEmbedder struct {
+	mu     sync.Mutex
+	usage  *clients.UsageTracker
+	failOn string
+	dims   int
+	texts  []string
+}
+
+func (e *fakeEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	e.mu.Lock()
+	e.texts = append(e.texts, text)
+	e.mu.Unlock()
+	if e.failOn != "" && strings.Contains(text, e.failOn) {
+		return nil, errors.New("embedding failed")
+	}
+	e.usage.Record(testDeployment, int64(len(strings.Fields(text))), 0)
+	vector := []float32{float32(len(text)), float32(text[len(text)-1])}
+	return vector[:min(e.dims, len(vector))], nil
+}
+
+func migrateDocs(n int) []models.HotelForVectorStore {
+	docs := make([]models.HotelForVectorStore, n)
+	for i := range docs {
+		docs[i] = models.HotelForVectorStore{
+			HotelID:           fmt.Sprintf("%02d", i+1),
+			HotelName:         fmt.Sprintf("Hotel %d", i+1),
+			Description:       fmt.Sprintf("Description of hotel %02d", i+1),
+			DescriptionVector: []float32{9, 9, 9},
+		}
+	}
+	return docs
+}
+
+func newTestMigrator(t *testing.T, source *fakeSource, target *fakeTarget, embedder *fakeEmbedder, cp *checkpoint) *migrator {
+	t.Helper()
+	return &migrator{
+		source:        source,
+		target:        target,
+		embedder:      embedder,
+		usage:         embedder.usage,
+		deployment:    testDeployment,
+		spec:          vectorstore.VectorIndexSpec{Algorithm: "vector-ivf", Similarity: "COS", Dimensions: 2},
+		checkpoint:    cp,
+		batchSize:     3,
+		concurrency:   2,
+		verifySamples: 4,
+		verifyMin:     0.8,
+		readyTimeout:  time.Second,
+		pollInterval:  time.Millisecond,
+		out:           io.Discard,
+	}
+}
+
+func newTestEmbedder() *fakeEmbedder {
+	return &fakeEmbedder{usage: clients.NewUsageTracker(), dims: 2}
+}
+
+func testCheckpoint(t *testing.T) *checkpoint {
+	return newCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"), "fp")
+}
+
+func TestMigrateRunsStepsInOrder(t *testing.T) {
+	ev := &events{}
+	source := &fakeSource{docs: migrateDocs(7)}
+	target := newFakeTarget(ev, source)
+	embedder := newTestEmbedder()
+	cp := testCheckpoint(t)
+
+	if err := newTestMigrator(t, source, target, embedder, cp).run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three batches are written before the index is created, then the canary search
+	// and the four sample searches run against it
+	want := []string{"write 3", "write 3", "write 1", "index", "search", "search", "search", "search", "search"}
+	if !slices.Equal(ev.list, want) {
+		t.Errorf("events = %v, want %v", ev.list, want)
+	}
+	if !slices.Equal(cp.file.Completed, []string{stepEmbed, stepIndex, stepVerify}) {
+		t.Errorf("completed steps = %v", cp.file.Completed)
+	}
+	if len(target.vectors) != 7 || target.index == nil || target.index.Dimensions != 2 {
+		t.Errorf("target has %d vectors and index %+v, want 7 and a 2-dimension index", len(target.vectors), target.index)
+	}
+	// Seven descriptions of four words each, plus the four verification samples
+	if cp.file.Migrated != 7 || cp.file.PromptTokens != 7*4 {
+		t.Errorf("checkpoint migrated %d with %d tokens, want 7 with 28", cp.file.Migrated, cp.file.PromptTokens)
+	}
+	if len(target.upserted) != 0 {
+		t.Errorf("field mode upserted %d documents, want none", len(target.upserted))
+	}
+}
+
+func TestMigrateCopiesDocumentsInCollectionMode(t *testing.T) {
+	ev := &events{}
+	source := &fakeSource{docs: migrateDocs(4)}
+	target := newFakeTarget(ev, source)
+	m := newTestMigrator(t, source, target, newTestEmbedder(), testCheckpoint(t))
+	m.copyDocuments = true
+
+	if err := m.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(target.upserted) != 4 {
+		t.Fatalf("upserted %d documents, want 4", len(target.upserted))
+	}
+	for _, doc := range target.upserted {
+		if doc.DescriptionVector != nil {
+			t.Errorf("hotel %s was copied with its old vector", doc.HotelID)
+		}
+		if doc.ContentHash != doc.HashContent(testDeployment) {
+			t.Errorf("hotel %s has content hash %q, want the hash for %s", doc.HotelID, doc.ContentHash, testDeployment)
+		}
+	}
+	// Each batch's documents are copied before their vectors are written
+	if ev.list[0] != "upsert" || ev.list[1] != "write 3" {
+		t.Errorf("events = %v, want each upsert before its write", ev.list)
+	}
+}
+
+func TestMigrateResumesAfterFailedBatch(t *testing.T) {
+	ev := &events{}
+	source := &fakeSource{docs: migrateDocs(8)}
+	target := newFakeTarget(ev, source)
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	failing := newTestEmbedder()
+	failing.failOn = "hotel 05"
+	m := newTestMigrator(t, source, target, failing, newCheckpoint(path, "fp"))
+	m.concurrency = 1
+	err := m.run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "embed: failed to embed hotel 05") {
+		t.Fatalf("err = %v, want the embedding failure", err)
+	}
+	if len(target.vectors) != 3 || target.index != nil {
+		t.Fatalf("after the failure the target has %d vectors and index %v, want the first batch only", len(target.vectors), target.index)
+	}
+
+	cp, err := loadCheckpoint(path, "fp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Done(stepEmbed) || cp.file.Migrated != 3 {
+		t.Fatalf("checkpoint = %+v, want 3 migrated and no completed steps", cp.file)
+	}
+
+	embedder := newTestEmbedder()
+	if err := newTestMigrator(t, source, target, embedder, cp).run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Documents that already have a new vector are not embedded again; the four
+	// verification samples are
+	var embedded []string
+	for _, text := range embedder.texts[:5] {
+		embedded = append(embedded, text[len(text)-2:])
+	}
+	slices.Sort(embedded)
+	if want := []string{"04", "05", "06", "07", "08"}; !slices.Equal(embedded, want) {
+		t.Errorf("resumed run embedded hotels %v, want %v", embedded, want)
+	}
+	if len(embedder.texts) != 5+4 {
+		t.Errorf("resumed run made %d embedding calls, want 9", len(embedder.texts))
+	}
+	if cp.file.Migrated != 8 || len(target.vectors) != 8 {
+		t.Errorf("migrated %d, target has %d vectors, want 8", cp.file.Migrated, len(target.vectors))
+	}
+}
+
+func TestMigrateSkipsCompletedSteps(t *testing.T) {
+	ev := &events{}
+	source := &fakeSource{docs: migrateDocs(4)}
+	target := newFakeTarget(ev, source)
+	for _, doc := range source.docs {
+		vector, _ := newTestEmbedder().GenerateEmbedding(context.Background(), doc.Description)
+		target.vectors[doc.HotelID] = vector
+	}
+
+	cp := testCheckpoint(t)
+	cp.file.Completed = []string{stepEmbed, stepIndex}
+	var out bytes.Buffer
+	m := newTestMigrator(t, source, target, newTestEmbedder(), cp)
+	m.out = &out
+
+	if err := m.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if target.index != nil || slices.Contains(ev.list, "write 4") {
+		t.Errorf("events = %v, want only the verification searches", ev.list)
+	}
+	for _, want := range []string{"Skipping embed: completed in an earlier run", "Skipping index: completed in an earlier run", "4 of 4 sample searches"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output is missing %q:\n%s", want, out.String())
+		}
+	}
+	if !cp.Done(stepVerify) {
+		t.Error("verify was not recorded as completed")
+	}
+}
+
+func TestMigrateIndexStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   bool
+		notReady   int
+		timeout    time.Duration
+		wantCreate bool
+		wantErr    string
+	}{
+		{"creates the index", false, 0, time.Second, true, ""},
+		{"keeps an existing index", true, 0, time.Second, false, ""},
+		{"waits for the canary", false, 3, time.Second, true, ""},
+		{"gives up after the ready timeout", false, 1 << 30, 20 * time.Millisecond, true, "index not ready after 20ms: canary search returned no results"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &fakeSource{docs: migrateDocs(2)}
+			target := newFakeTarget(&events{}, source)
+			target.vectors["01"] = []float32{1, 2}
+			target.existing = tt.existing
+			target.notReady = tt.notReady
+			m := newTestMigrator(t, source, target, newTestEmbedder(), testCheckpoint(t))
+			m.readyTimeout = tt.timeout
+
+			err := m.index(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if created := target.index != nil; created != tt.wantCreate {
+				t.Errorf("index created = %v, want %v", created, tt.wantCreate)
+			}
+		})
+	}
+}
+
+func TestMigrateVerifyFailure(t *testing.T) {
+	source := &fakeSource{docs: migrateDocs(3)}
+	target := newFakeTarget(&events{}, source)
+	cp := testCheckpoint(t)
+	cp.file.Completed = []string{stepEmbed, stepIndex}
+	for _, doc := range source.docs {
+		target.vectors[doc.HotelID] = []float32{1, 2}
+	}
+	target.miss = true
+
+	err := newTestMigrator(t, source, target, newTestEmbedder(), cp).run(context.Background())
+	if !errors.Is(err, errVerifyFailed) || !strings.Contains(err.Error(), "0 of 3 sample searches") {
+		t.Fatalf("err = %v, want errVerifyFailed", err)
+	}
+	if cp.Done(stepVerify) {
+		t.Error("a failed verification was recorded as completed")
+	}
+}
+
+func TestMigrateRejectsWrongDimensions(t *testing.T) {
+	source := &fakeSource{docs: migrateDocs(2)}
+	target := newFakeTarget(&events{}, source)
+	embedder := newTestEmbedder()
+	embedder.dims = 1
+
+	err := newTestMigrator(t, source, target, embedder, testCheckpoint(t)).run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "returned 1 dimensions for hotel") {
+		t.Fatalf("err = %v, want a dimension mismatch", err)
+	}
+	if len(target.vectors) != 0 {
+		t.Errorf("wrote %d vectors of the wrong size", len(target.vectors))
+	}
+}
+
+func TestMigrateStopsOnWriteError(t *testing.T) {
+	source := &fakeSource{docs: migrateDocs(5)}
+	target := newFakeTarget(&events{}, source)
+	target.setErr = errors.New("write failed")
+	cp := testCheckpoint(t)
+
+	err := newTestMigrator(t, source, target, newTestEmbedder(), cp).run(context.Background())
+	if !errors.Is(err, target.setErr) {
+		t.Fatalf("err = %v, want the write error", err)
+	}
+	if cp.file.Migrated != 0 || cp.Done(stepEmbed) {
+		t.Errorf("checkpoint = %+v, want nothing recorded", cp.file)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Migration targets
+const (
+	modeField      = "field"
+	modeCollection = "collection"
+)
+
+const (
+	defaultBatchSize     = 50
+	defaultConcurrency   = 4
+	defaultCheckpoint    = ".migrate-checkpoint.json"
+	defaultVerifySamples = 10
+	defaultVerifyMin     = 0.8
+	defaultReadyTimeout  = 5 * time.Minute
+	defaultPollInterval  = 5 * time.Second
+)
+
+// options holds the resolved cmd/migrate-embeddings settings. Flags take precedence
+// over environment variables, which take precedence over the defaults.
+type options struct {
+	// Deployment and Dimensions select the new embedding model
+	Deployment string
+	Dimensions int
+	// Mode is modeField or modeCollection
+	Mode string
+	// TargetField, TargetIndex, and TargetCollection default to names derived from
+	// the current ones and the deployment; see resolveTargets
+	TargetField      string
+	TargetIndex      string
+	TargetCollection string
+
+	BatchSize     int
+	Concurrency   int
+	Checkpoint    string
+	Resume        bool
+	VerifySamples int
+	VerifyMin     float64
+	ReadyTimeout  time.Duration
+	PollInterval  time.Duration
+
+	Verbosity  cli.Verbosity
+	ConfigFile string
+}
+
+// parseOptions resolves options from command-line arguments and environment variables.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("migrate-embeddings", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	opts := options{
+		Deployment:    getenv("MIGRATE_TARGET_DEPLOYMENT"),
+		Mode:          modeField,
+		BatchSize:     defaultBatchSize,
+		Concurrency:   defaultConcurrency,
+		Checkpoint:    defaultCheckpoint,
+		VerifySamples: defaultVerifySamples,
+		VerifyMin:     defaultVerifyMin,
+		ReadyTimeout:  defaultReadyTimeout,
+		PollInterval:  defaultPollInterval,
+	}
+	if value := getenv("MIGRATE_TARGET_DIMENSIONS"); value != "" {
+		dimensions, err := strconv.Atoi(value)
+		if err != nil {
+			err = fmt.Errorf("invalid MIGRATE_TARGET_DIMENSIONS %q: must be an integer", value)
+			fmt.Fprintln(output, err)
+			return nil, err
+		}
+		opts.Dimensions = dimensions
+	}
+
+	fs.StringVar(&opts.Deployment, "target-deployment", opts.Deployment, "Embedding deployment to migrate to, such as text-embedding-3-large (env MIGRATE_TARGET_DEPLOYMENT)")
+	fs.IntVar(&opts.Dimensions, "dimensions", opts.Dimensions, "Dimensions of the new embeddings (env MIGRATE_TARGET_DIMENSIONS)")
+	fs.StringVar(&opts.Mode, "mode", opts.Mode, "Where the new embeddings go: field (a new vector field in the same collection) or collection (a copy of the collection)")
+	fs.StringVar(&opts.TargetField, "target-field", "", "field mode: vector field for the new embeddings (default EMBEDDED_FIELD_<deployment>)")
+	fs.StringVar(&opts.TargetIndex, "target-index", "", "Vector index name for the new embeddings (default AZURE_DOCUMENTDB_INDEX_NAME_<deployment> in field mode, AZURE_DOCUMENTDB_INDEX_NAME in collection mode)")
+	fs.StringVar(&opts.TargetCollection, "target-collection", "", "collection mode: collection for the migrated documents (default AZURE_DOCUMENTDB_COLLECTION_<deployment>)")
+	fs.IntVar(&opts.BatchSize, "batch-size", opts.BatchSize, "Documents per write batch")
+	fs.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Embedding requests in flight at once")
+	fs.StringVar(&opts.Checkpoint, "checkpoint", opts.Checkpoint, "Checkpoint file recording the completed steps")
+	fs.BoolVar(&opts.Resume, "resume", false, "Continue an interrupted migration from its checkpoint, skipping completed steps")
+	fs.IntVar(&opts.VerifySamples, "verify-samples", opts.VerifySamples, "Documents whose descriptions are searched against the new index")
+	fs.Float64Var(&opts.VerifyMin, "verify-min", opts.VerifyMin, "Share of sample searches that must return their own document, from 0 to 1")
+	fs.DurationVar(&opts.ReadyTimeout, "ready-timeout", opts.ReadyTimeout, "How long to wait for the new index to serve searches")
+	fs.DurationVar(&opts.PollInterval, "poll-interval", opts.PollInterval, "Delay between index readiness checks")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: migrate-embeddings --target-deployment NAME --dimensions N [flags]\n\n")
+		fmt.Fprintf(output, "Re-embeds the collection with another embedding model next to the current embeddings,\n")
+		fmt.Fprintf(output, "indexes and verifies them, and prints how to switch readers over.\n")
+		fmt.Fprintf(output, "Flags take precedence over the environment variables shown in parentheses.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// validate checks the options before anything is read or written
+func (o *options) validate() error {
+	if o.Deployment == "" {
+		return errors.New("--target-deployment is required")
+	}
+	if o.Dimensions < 1 {
+		return errors.New("--dimensions is required and must be at least 1")
+	}
+	switch o.Mode {
+	case modeField:
+		if o.TargetCollection != "" {
+			return errors.New("--target-collection only applies to --mode collection")
+		}
+	case modeCollection:
+		if o.TargetField != "" {
+			return errors.New("--target-field only applies to --mode field")
+		}
+	default:
+		return fmt.Errorf("unsupported --mode %q: use %s or %s", o.Mode, modeField, modeCollection)
+	}
+	if o.BatchSize < 1 || o.Concurrency < 1 {
+		return errors.New("--batch-size and --concurrency must be at least 1")
+	}
+	if o.VerifySamples < 1 {
+		return errors.New("--verify-samples must be at least 1")
+	}
+	if o.VerifyMin < 0 || o.VerifyMin > 1 {
+		return errors.New("--verify-min must be between 0 and 1")
+	}
+	if o.ReadyTimeout <= 0 || o.PollInterval <= 0 {
+		return errors.New("--ready-timeout and --poll-interval must be positive")
+	}
+	return nil
+}
+
+// resolveTargets fills in the target names left empty, from the current settings and
+// a suffix derived from the deployment, and rejects targets that would overwrite the
+// current embeddings
+func (o *options) resolveTargets(current *vectorstore.VectorStoreConfig) error {
+	suffix := nameSuffix(o.Deployment)
+	switch o.Mode {
+	case modeField:
+		if o.TargetField == "" {
+			o.TargetField = current.EmbeddedField + "_" + suffix
+		}
+		if o.TargetIndex == "" {
+			o.TargetIndex = current.IndexName + "_" + suffix
+		}
+		if o.TargetField == current.EmbeddedField {
+			return fmt.Errorf("--target-field %s is the current vector field; pick another", o.TargetField)
+		}
+		if o.TargetIndex == current.IndexName {
+			return fmt.Errorf("--target-index %s is the current vector index; pick another", o.TargetIndex)
+		}
+	case modeCollection:
+		if o.TargetCollection == "" {
+			o.TargetCollection = current.CollectionName + "_" + suffix
+		}
+		if o.TargetIndex == "" {
+			o.TargetIndex = current.IndexName
+		}
+		if o.TargetCollection == current.CollectionName {
+			return fmt.Errorf("--target-collection %s is the current collection; pick another", o.TargetCollection)
+		}
+	}
+	return nil
+}
+
+// nameSuffix turns a deployment name into a suffix for field, index, and collection
+// names: letters and digits are kept and everything else becomes an underscore
+func nameSuffix(deployment string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, deployment)
+}
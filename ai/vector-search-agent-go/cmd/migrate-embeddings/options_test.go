@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// defaultOptions returns the parsed options for the required settings and nothing else
+func defaultOptions(deployment string, dimensions int) options {
+	return options{
+		Deployment:    deployment,
+		Dimensions:    dimensions,
+		Mode:          modeField,
+		BatchSize:     defaultBatchSize,
+		Concurrency:   defaultConcurrency,
+		Checkpoint:    defaultCheckpoint,
+		VerifySamples: defaultVerifySamples,
+		VerifyMin:     defaultVerifyMin,
+		ReadyTimeout:  defaultReadyTimeout,
+		PollInterval:  defaultPollInterval,
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	collection := defaultOptions("large", 3072)
+	collection.Mode = modeCollection
+	collection.TargetCollection = "hotels_v2"
+
+	tuned := defaultOptions("large", 256)
+	tuned.BatchSize = 10
+	tuned.Concurrency = 1
+	tuned.Resume = true
+	tuned.VerifySamples = 3
+	tuned.VerifyMin = 1
+	tuned.ReadyTimeout = time.Minute
+	tuned.PollInterval = time.Second
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want options
+	}{
+		{"env", nil, map[string]string{"MIGRATE_TARGET_DEPLOYMENT": "large", "MIGRATE_TARGET_DIMENSIONS": "3072"}, defaultOptions("large", 3072)},
+		{"flags", []string{"--target-deployment", "large", "--dimensions", "3072"}, nil, defaultOptions("large", 3072)},
+		{
+			"flags override env", []string{"--target-deployment", "small", "--dimensions", "512"},
+			map[string]string{"MIGRATE_TARGET_DEPLOYMENT": "large", "MIGRATE_TARGET_DIMENSIONS": "3072"},
+			defaultOptions("small", 512),
+		},
+		{"collection mode", []string{"--mode", "collection", "--target-collection", "hotels_v2"}, map[string]string{"MIGRATE_TARGET_DEPLOYMENT": "large", "MIGRATE_TARGET_DIMENSIONS": "3072"}, collection},
+		{
+			"tuning flags",
+			[]string{"--dimensions", "256", "--batch-size", "10", "--concurrency", "1", "--resume", "--verify-samples", "3", "--verify-min", "1", "--ready-timeout", "1m", "--poll-interval", "1s"},
+			map[string]string{"MIGRATE_TARGET_DEPLOYMENT": "large"},
+			tuned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("options = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsRejectsInvalidSettings(t *testing.T) {
+	required := []string{"--target-deployment", "large", "--dimensions", "3072"}
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr string
+	}{
+		{"no deployment", []string{"--dimensions", "3072"}, nil, "--target-deployment is required"},
+		{"no dimensions", []string{"--target-deployment", "large"}, nil, "--dimensions is required"},
+		{"bad env dimensions", nil, map[string]string{"MIGRATE_TARGET_DIMENSIONS": "many"}, `invalid MIGRATE_TARGET_DIMENSIONS "many"`},
+		{"unknown mode", append(required, "--mode", "in-place"), nil, `unsupported --mode "in-place"`},
+		{"collection in field mode", append(required, "--target-collection", "hotels_v2"), nil, "--target-collection only applies to --mode collection"},
+		{"field in collection mode", append(required, "--mode", "collection", "--target-field", "vector_v2"), nil, "--target-field only applies to --mode field"},
+		{"zero batch size", append(required, "--batch-size", "0"), nil, "--batch-size and --concurrency must be at least 1"},
+		{"zero samples", append(required, "--verify-samples", "0"), nil, "--verify-samples must be at least 1"},
+		{"verify min above 1", append(required, "--verify-min", "1.5"), nil, "--verify-min must be between 0 and 1"},
+		{"zero poll interval", append(required, "--poll-interval", "0s"), nil, "--ready-timeout and --poll-interval must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), tt.wantErr) {
+				t.Errorf("output does not report the error:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestResolveTargets(t *testing.T) {
+	current := &vectorstore.VectorStoreConfig{CollectionName: "hotels", IndexName: "vectorIndex", EmbeddedField: "DescriptionVector"}
+	tests := []struct {
+		name    string
+		opts    options
+		want    [3]string // field, index, collection
+		wantErr string
+	}{
+		{"field defaults", options{Deployment: "text-embedding-3-large", Mode: modeField}, [3]string{"DescriptionVector_text_embedding_3_large", "vectorIndex_text_embedding_3_large", ""}, ""},
+		{"field names kept", options{Deployment: "large", Mode: modeField, TargetField: "v2", TargetIndex: "idx2"}, [3]string{"v2", "idx2", ""}, ""},
+		{"collection defaults", options{Deployment: "large", Mode: modeCollection}, [3]string{"", "vectorIndex", "hotels_large"}, ""},
+		{"current field", options{Deployment: "large", Mode: modeField, TargetField: "DescriptionVector"}, [3]string{}, "is the current vector field"},
+		{"current index", options{Deployment: "large", Mode: modeField, TargetIndex: "vectorIndex"}, [3]string{}, "is the current vector index"},
+		{"current collection", options{Deployment: "large", Mode: modeCollection, TargetCollection: "hotels"}, [3]string{}, "is the current collection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := opts.resolveTargets(current)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := [3]string{opts.TargetField, opts.TargetIndex, opts.TargetCollection}; got != tt.want {
+				t.Errorf("targets = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintCutover(t *testing.T) {
+	current := &vectorstore.VectorStoreConfig{CollectionName: "hotels", IndexName: "vectorIndex", EmbeddedField: "DescriptionVector"}
+
+	var field bytes.Buffer
+	printCutover(&field, &options{Deployment: "large", Dimensions: 3072, Mode: modeField, TargetField: "v2", TargetIndex: "idx2"}, current)
+	for _, want := range []string{"AZURE_OPENAI_EMBEDDING_DEPLOYMENT=large", "EMBEDDING_DIMENSIONS=3072", "EMBEDDED_FIELD=v2", "AZURE_DOCUMENTDB_INDEX_NAME=idx2", "drop index vectorIndex"} {
+		if !strings.Contains(field.String(), want) {
+			t.Errorf("field mode output is missing %q:\n%s", want, field.String())
+		}
+	}
+
+	var collection bytes.Buffer
+	printCutover(&collection, &options{Deployment: "large", Dimensions: 3072, Mode: modeCollection, TargetCollection: "hotels_large", TargetIndex: "vectorIndex"}, current)
+	if !strings.Contains(collection.String(), "AZURE_DOCUMENTDB_COLLECTION=hotels_large") || strings.Contains(collection.String(), "INDEX_NAME") {
+		t.Errorf("collection mode output = %q, want the collection and no index change", collection.String())
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run drops the vector index and recreates it with the requested parameters
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	opts, err := parseOptions(os.Args[1:], vectorstore.IndexSpecFromEnv(), os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
+	}
+
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	// Load configuration
+	cfg, err := cli.LoadConfig(opts.ConfigFile, config.Requirements{DocumentDB: true, VectorIndex: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C or SIGTERM cancels the run; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	vsConfig := cfg.VectorStore
+
+	fmt.Fprintf(os.Stderr, "Connecting to database: %s\n", vsConfig.DatabaseName)
+
+	// Connect to vector store
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	r := &reindexer{
+		store:        store,
+		out:          os.Stdout,
+		readyTimeout: opts.ReadyTimeout,
+		pollInterval: opts.PollInterval,
+	}
+
+	if err := r.run(ctx, opts.Spec); err != nil {
+		if errors.Is(err, errUnindexed) {
+			fmt.Fprintf(os.Stderr, "\nThe old index was dropped and collection %s has NO vector index; vector searches will fail.\n", vsConfig.CollectionName)
+			fmt.Fprintln(os.Stderr, "To recover, either:")
+			fmt.Fprintln(os.Stderr, "  - fix the parameters and run reindex again, or")
+			fmt.Fprintln(os.Stderr, "  - run `go run ./cmd/upload --index-only` to create the index from the settings in .env")
+			fmt.Fprintln(os.Stderr)
+		}
+		return fmt.Errorf("reindex failed: %w", err)
+	}
+
+	fmt.Println("\nReindex completed successfully!")
+	return nil
+}
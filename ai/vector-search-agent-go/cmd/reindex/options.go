@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const (
+	defaultReadyTimeout = 2 * time.Minute
+	defaultPollInterval = 2 * time.Second
+)
+
+// options holds the resolved cmd/reindex settings
+type options struct {
+	Spec         vectorstore.VectorIndexSpec
+	ReadyTimeout time.Duration
+	PollInterval time.Duration
+	Verbosity    cli.Verbosity
+	ConfigFile   string
+}
+
+// parseOptions resolves options from command-line arguments, using env (via
+// vectorstore.IndexSpecFromEnv) for the index defaults.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, defaults vectorstore.VectorIndexSpec, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	opts := options{Spec: defaults}
+	spec := &opts.Spec
+	fs.StringVar(&spec.Algorithm, "algorithm", spec.Algorithm, "Index algorithm: vector-ivf, vector-hnsw, or vector-diskann (env VECTOR_INDEX_ALGORITHM)")
+	fs.StringVar(&spec.Similarity, "similarity", spec.Similarity, "Similarity metric: COS, IP, or L2 (env VECTOR_SIMILARITY)")
+	fs.IntVar(&spec.Dimensions, "dimensions", spec.Dimensions, "Embedding dimensions (env EMBEDDING_DIMENSIONS)")
+	fs.IntVar(&spec.NumLists, "ivf-num-lists", spec.NumLists, "vector-ivf: number of clusters (env IVF_NUM_LISTS)")
+	fs.IntVar(&spec.M, "hnsw-m", spec.M, "vector-hnsw: max connections per layer (env HNSW_M)")
+	fs.IntVar(&spec.EfConstruction, "hnsw-ef-construction", spec.EfConstruction, "vector-hnsw: build candidate list size (env HNSW_EF_CONSTRUCTION)")
+	fs.IntVar(&spec.MaxDegree, "diskann-max-degree", spec.MaxDegree, "vector-diskann: max graph degree (env DISKANN_MAX_DEGREE)")
+	fs.IntVar(&spec.LBuild, "diskann-l-build", spec.LBuild, "vector-diskann: build candidate list size (env DISKANN_L_BUILD)")
+	fs.DurationVar(&opts.ReadyTimeout, "ready-timeout", defaultReadyTimeout, "How long to wait for the new index to serve a canary search")
+	fs.DurationVar(&opts.PollInterval, "poll-interval", defaultPollInterval, "Delay between readiness checks")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: reindex [flags]\n\n")
+		fmt.Fprintf(output, "Drops the existing vector index and creates a new one with the given settings.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	return &opts, nil
+}
+
+// validate checks the spec before anything is dropped
+func (o *options) validate() error {
+	switch o.Spec.Algorithm {
+	case vectorstore.AlgorithmIVF, vectorstore.AlgorithmHNSW, vectorstore.AlgorithmDiskANN:
+	default:
+		return fmt.Errorf("unsupported --algorithm %q: use vector-ivf, vector-hnsw, or vector-diskann", o.Spec.Algorithm)
+	}
+	switch o.Spec.Similarity {
+	case "COS", "IP", "L2":
+	default:
+		return fmt.Errorf("unsupported --similarity %q: use COS, IP, or L2", o.Spec.Similarity)
+	}
+	if o.Spec.Dimensions < 1 {
+		return fmt.Errorf("--dimensions must be at least 1")
+	}
+	if o.ReadyTimeout <= 0 || o.PollInterval <= 0 {
+		return fmt.Errorf("--ready-timeout and --poll-interval must be positive")
+	}
+	return nil
+}
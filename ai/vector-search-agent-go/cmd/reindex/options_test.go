@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func envSpec() vectorstore.VectorIndexSpec {
+	return vectorstore.VectorIndexSpec{
+		Algorithm: vectorstore.AlgorithmIVF, Similarity: "COS", Dimensions: 1536,
+		NumLists: 10, M: 16, EfConstruction: 64, MaxDegree: 20, LBuild: 10,
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	var out bytes.Buffer
+	opts, err := parseOptions([]string{"--algorithm", "vector-hnsw", "--hnsw-m", "32", "--ready-timeout", "30s"}, envSpec(), &out)
+	if err != nil {
+		t.Fatalf("parseOptions: %v\n%s", err, out.String())
+	}
+
+	want := envSpec()
+	want.Algorithm = vectorstore.AlgorithmHNSW
+	want.M = 32
+	if opts.Spec != want {
+		t.Errorf("spec = %+v, want %+v", opts.Spec, want)
+	}
+	if opts.ReadyTimeout != 30*time.Second || opts.PollInterval != defaultPollInterval {
+		t.Errorf("timeouts = %s/%s", opts.ReadyTimeout, opts.PollInterval)
+	}
+
+	defaults, err := parseOptions(nil, envSpec(), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults.Spec != envSpec() || defaults.ReadyTimeout != defaultReadyTimeout {
+		t.Errorf("defaults = %+v, want the env spec", defaults)
+	}
+}
+
+func TestParseOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"algorithm", []string{"--algorithm", "vector-flat"}, `unsupported --algorithm "vector-flat"`},
+		{"similarity", []string{"--similarity", "cosine"}, `unsupported --similarity "cosine"`},
+		{"dimensions", []string{"--dimensions", "0"}, "--dimensions must be at least 1"},
+		{"timeout", []string{"--ready-timeout", "0s"}, "--ready-timeout and --poll-interval must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, envSpec(), &out); err == nil {
+				t.Fatal("parseOptions accepted an invalid spec")
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
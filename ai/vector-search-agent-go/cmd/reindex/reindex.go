@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// canaryK is the number of neighbors requested by the canary search
+const canaryK = 5
+
+// indexStore is the subset of the vector store used by reindex
+type indexStore interface {
+	FindVectorIndex(ctx context.Context) (*vectorstore.VectorIndexInfo, error)
+	DropVectorIndex(ctx context.Context, name string) error
+	CreateVectorIndexWithSpec(ctx context.Context, spec vectorstore.VectorIndexSpec) error
+	SampleVector(ctx context.Context) (string, []float32, error)
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// errUnindexed reports that the old index was dropped but the new one was not created
+var errUnindexed = errors.New("the collection is now unindexed")
+
+// reindexer drops the current vector index and replaces it
+type reindexer struct {
+	store        indexStore
+	out          io.Writer
+	readyTimeout time.Duration
+	pollInterval time.Duration
+}
+
+// run performs the reindex: describe, check, drop, create, wait, canary, describe
+func (r *reindexer) run(ctx context.Context, spec vectorstore.VectorIndexSpec) error {
+	before, err := r.store.FindVectorIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if before != nil {
+		fmt.Fprintf(r.out, "Current index: %s\n", before)
+	} else {
+		fmt.Fprintln(r.out, "Current index: none")
+	}
+	fmt.Fprintf(r.out, "New index:     %s\n", spec)
+
+	// Check the stored vectors against the new spec while the old index still works
+	sampleID, sample, err := r.store.SampleVector(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot run a canary search: %w", err)
+	}
+	if len(sample) != spec.Dimensions {
+		return fmt.Errorf("%w: stored vectors have %d dimensions but the new index expects %d; nothing was changed", vectorstore.ErrDimensionMismatch, len(sample), spec.Dimensions)
+	}
+
+	if before != nil {
+		fmt.Fprintf(r.out, "\nDropping index %s...\n", before.Name)
+		if err := r.store.DropVectorIndex(ctx, before.Name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(r.out, "Creating new index...")
+	if err := r.store.CreateVectorIndexWithSpec(ctx, spec); err != nil {
+		return fmt.Errorf("%w: %w", errUnindexed, err)
+	}
+
+	fmt.Fprintln(r.out, "Waiting for the index to serve a canary search...")
+	start := time.Now()
+	found, err := r.waitReady(ctx, sampleID, sample)
+	if err != nil {
+		return err
+	}
+	if found {
+		fmt.Fprintf(r.out, "Canary search returned hotel %s after %s\n", sampleID, time.Since(start).Round(time.Millisecond))
+	} else {
+		fmt.Fprintf(r.out, "Warning: canary search returned results, but not hotel %s whose own vector was the query\n", sampleID)
+	}
+
+	after, err := r.store.FindVectorIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if after == nil {
+		return fmt.Errorf("%w: the new index was created but is not listed", errUnindexed)
+	}
+	fmt.Fprintf(r.out, "\nBefore: %s\n", describe(before))
+	fmt.Fprintf(r.out, "After:  %s\n", describe(after))
+
+	return nil
+}
+
+// waitReady polls with a canary search until it returns results or the ready timeout
+// elapses. It reports whether the sample document was among the results.
+func (r *reindexer) waitReady(ctx context.Context, sampleID string, sample []float32) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.readyTimeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		results, err := r.store.VectorSearch(ctx, sample, canaryK)
+		if err == nil && len(results) > 0 {
+			for _, result := range results {
+				if result.Hotel.HotelID == sampleID {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = errors.New("canary search returned no results")
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("index not ready after %s: %w", r.readyTimeout, lastErr)
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// describe renders an index description, or "none"
+func describe(info *vectorstore.VectorIndexInfo) string {
+	if info == nil {
+		return "none"
+	}
+	return info.String()
+}
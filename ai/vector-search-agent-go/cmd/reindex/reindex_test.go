@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// fakeIndexStore records the reindex calls in order. Searches fail until
+// readyAfter of them have been made.
+type fakeIndexStore struct {
+	calls []string
+
+	index      *vectorstore.VectorIndexInfo
+	findErr    error
+	sampleID   string
+	sample     []float32
+	sampleErr  error
+	dropErr    error
+	createErr  error
+	readyAfter int
+	searches   int
+	resultIDs  []string
+	hideIndex  bool
+}
+
+func (f *fakeIndexStore) FindVectorIndex(ctx context.Context) (*vectorstore.VectorIndexInfo, error) {
+	f.calls = append(f.calls, "find")
+	if f.hideIndex && len(f.calls) > 1 {
+		return nil, nil
+	}
+	return f.index, f.findErr
+}
+
+func (f *fakeIndexStore) DropVectorIndex(ctx context.Context, name string) error {
+	f.calls = append(f.calls, "drop "+name)
+	if f.dropErr != nil {
+		return f.dropErr
+	}
+	f.index = nil
+	return nil
+}
+
+func (f *fakeIndexStore) CreateVectorIndexWithSpec(ctx context.Context, spec vectorstore.VectorIndexSpec) error {
+	f.calls = append(f.calls, "create "+spec.Algorithm)
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.index = &vectorstore.VectorIndexInfo{
+		Name: "vectorIndex", Field: "DescriptionVector", Kind: spec.Algorithm,
+		Similarity: spec.Similarity, Dimensions: spec.Dimensions,
+		Parameters: map[string]int{"m": spec.M, "efConstruction": spec.EfConstruction},
+	}
+	return nil
+}
+
+func (f *fakeIndexStore) SampleVector(ctx context.Context) (string, []float32, error) {
+	f.calls = append(f.calls, "sample")
+	return f.sampleID, f.sample, f.sampleErr
+}
+
+func (f *fakeIndexStore) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	f.calls = append(f.calls, "search")
+	f.searches++
+	if f.searches <= f.readyAfter {
+		return nil, errors.New("index is still building")
+	}
+	results := make([]models.HotelSearchResult, len(f.resultIDs))
+	for i, id := range f.resultIDs {
+		results[i] = models.HotelSearchResult{Hotel: models.HotelForVectorStore{HotelID: id}, Score: 1 - float64(i)/10}
+	}
+	return results, nil
+}
+
+func newFakeIndexStore() *fakeIndexStore {
+	return &fakeIndexStore{
+		index: &vectorstore.VectorIndexInfo{
+			Name: "vectorIndex", Field: "DescriptionVector", Kind: vectorstore.AlgorithmIVF,
+			Similarity: "COS", Dimensions: 3, Parameters: map[string]int{"numLists": 10},
+		},
+		sampleID:  "7",
+		sample:    []float32{0.1, 0.2, 0.3},
+		resultIDs: []string{"7", "3"},
+	}
+}
+
+func hnswSpec() vectorstore.VectorIndexSpec {
+	return vectorstore.VectorIndexSpec{Algorithm: vectorstore.AlgorithmHNSW, Similarity: "COS", Dimensions: 3, M: 16, EfConstruction: 64}
+}
+
+func newTestReindexer(store indexStore, out *bytes.Buffer) *reindexer {
+	return &reindexer{store: store, out: out, readyTimeout: time.Second, pollInterval: time.Millisecond}
+}
+
+func TestReindexSequence(t *testing.T) {
+	store := newFakeIndexStore()
+	var out bytes.Buffer
+
+	if err := newTestReindexer(store, &out).run(context.Background(), hnswSpec()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "find,sample,drop vectorIndex,create vector-hnsw,search,find"
+	if got := strings.Join(store.calls, ","); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+	for _, line := range []string{
+		"Current index: vectorIndex on DescriptionVector: vector-ivf (similarity=COS, dimensions=3, numLists=10)",
+		"New index:     vector-hnsw (similarity=COS, dimensions=3, m=16, efConstruction=64)",
+		"Canary search returned hotel 7",
+		"Before: vectorIndex on DescriptionVector: vector-ivf",
+		"After:  vectorIndex on DescriptionVector: vector-hnsw (similarity=COS, dimensions=3, efConstruction=64, m=16)",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output is missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestReindexWithoutExistingIndex(t *testing.T) {
+	store := newFakeIndexStore()
+	store.index = nil
+	var out bytes.Buffer
+
+	if err := newTestReindexer(store, &out).run(context.Background(), hnswSpec()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(store.calls, ","); got != "find,sample,create vector-hnsw,search,find" {
+		t.Errorf("calls = %s, want no drop", got)
+	}
+	if !strings.Contains(out.String(), "Current index: none") || !strings.Contains(out.String(), "Before: none") {
+		t.Errorf("output does not report the missing index:\n%s", out.String())
+	}
+}
+
+func TestReindexWaitsForReadiness(t *testing.T) {
+	store := newFakeIndexStore()
+	store.readyAfter = 3
+	var out bytes.Buffer
+
+	if err := newTestReindexer(store, &out).run(context.Background(), hnswSpec()); err != nil {
+		t.Fatal(err)
+	}
+	if store.searches != 4 {
+		t.Errorf("ran %d canary searches, want 4", store.searches)
+	}
+}
+
+func TestReindexCanaryMissesSample(t *testing.T) {
+	store := newFakeIndexStore()
+	store.resultIDs = []string{"3", "4"}
+	var out bytes.Buffer
+
+	if err := newTestReindexer(store, &out).run(context.Background(), hnswSpec()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Warning: canary search returned results, but not hotel 7") {
+		t.Errorf("output is missing the canary warning:\n%s", out.String())
+	}
+}
+
+func TestReindexFailures(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name          string
+		setup         func(*fakeIndexStore)
+		spec          vectorstore.VectorIndexSpec
+		wantCalls     string
+		wantUnindexed bool
+		wantErr       string
+	}{
+		{
+			name:      "describe fails",
+			setup:     func(f *fakeIndexStore) { f.findErr = errBoom },
+			wantCalls: "find",
+			wantErr:   "boom",
+		},
+		{
+			name:      "no sample vector",
+			setup:     func(f *fakeIndexStore) { f.sampleErr = errBoom },
+			wantCalls: "find,sample",
+			wantErr:   "cannot run a canary search: boom",
+		},
+		{
+			name:      "dimension mismatch changes nothing",
+			setup:     func(f *fakeIndexStore) {},
+			spec:      vectorstore.VectorIndexSpec{Algorithm: vectorstore.AlgorithmHNSW, Similarity: "COS", Dimensions: 1536},
+			wantCalls: "find,sample",
+			wantErr:   "stored vectors have 3 dimensions but the new index expects 1536; nothing was changed",
+		},
+		{
+			name:      "drop fails keeps the old index",
+			setup:     func(f *fakeIndexStore) { f.dropErr = errBoom },
+			wantCalls: "find,sample,drop vectorIndex",
+			wantErr:   "boom",
+		},
+		{
+			name:          "create fails leaves the collection unindexed",
+			setup:         func(f *fakeIndexStore) { f.createErr = errBoom },
+			wantCalls:     "find,sample,drop vectorIndex,create vector-hnsw",
+			wantUnindexed: true,
+			wantErr:       "the collection is now unindexed: boom",
+		},
+		{
+			name:          "new index not listed",
+			setup:         func(f *fakeIndexStore) { f.hideIndex = true },
+			wantCalls:     "find,sample,drop vectorIndex,create vector-hnsw,search,find",
+			wantUnindexed: true,
+			wantErr:       "the new index was created but is not listed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeIndexStore()
+			tt.setup(store)
+			spec := tt.spec
+			if spec.Algorithm == "" {
+				spec = hnswSpec()
+			}
+
+			err := newTestReindexer(store, &bytes.Buffer{}).run(context.Background(), spec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if errors.Is(err, errUnindexed) != tt.wantUnindexed {
+				t.Errorf("errors.Is(err, errUnindexed) = %v, want %v", !tt.wantUnindexed, tt.wantUnindexed)
+			}
+			if got := strings.Join(store.calls, ","); got != tt.wantCalls {
+				t.Errorf("calls = %s, want %s", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestReindexReadyTimeout(t *testing.T) {
+	store := newFakeIndexStore()
+	store.readyAfter = 1 << 30
+	r := newTestReindexer(store, &bytes.Buffer{})
+	r.readyTimeout = 20 * time.Millisecond
+
+	err := r.run(context.Background(), hnswSpec())
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("index not ready after %s: index is still building", r.readyTimeout)) {
+		t.Fatalf("err = %v, want a readiness timeout", err)
+	}
+	if store.searches < 2 {
+		t.Errorf("ran %d canary searches before giving up, want several", store.searches)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const defaultK = 5
+
+// searchResult is one row of the --json output
+type searchResult struct {
+	Rank       int     `json:"rank"`
+	Score      float64 `json:"score"`
+	RawScore   float64 `json:"rawScore"`
+	Provenance string  `json:"provenance,omitempty"`
+	HotelID    string  `json:"hotelId"`
+	HotelName  string  `json:"hotelName"`
+	Category   string  `json:"category"`
+	Rating     float64 `json:"rating"`
+	City       string  `json:"city"`
+}
+
+func main() {
+	cli.Exit(run())
+}
+
+// run embeds the query and prints the nearest hotels
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	query := os.Getenv("QUERY")
+	k := defaultK
+	if nnStr := os.Getenv("NEAREST_NEIGHBORS"); nnStr != "" {
+		nn, err := strconv.Atoi(nnStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid NEAREST_NEIGHBORS %q: must be an integer\n", nnStr)
+			return cli.Usage(fmt.Errorf("invalid NEAREST_NEIGHBORS %q", nnStr))
+		}
+		k = nn
+	}
+	var asJSON bool
+	var verbosity cli.Verbosity
+	var configFile string
+
+	flag.StringVar(&query, "query", query, "Search query (env QUERY)")
+	flag.StringVar(&query, "q", query, "Shorthand for --query")
+	flag.IntVar(&k, "k", k, fmt.Sprintf("Number of nearest neighbors, %d-%d; values outside are clamped (env NEAREST_NEIGHBORS)", vectorstore.MinK, vectorstore.MaxK()))
+	flag.BoolVar(&asJSON, "json", false, "Print results as JSON instead of a table")
+	cli.AddVerbosityFlags(flag.CommandLine, &verbosity)
+	cli.AddConfigFlag(flag.CommandLine, &configFile)
+	cli.AddVersionFlag(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: search [flags]\n\n")
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), cli.ExitCodesHelp)
+	}
+	flag.Parse()
+
+	if _, err := cli.SetupLogging(verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("search")
+	if err != nil {
+		return err
+	}
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "a query is required: use --query or set QUERY")
+		flag.Usage()
+		return cli.Usage(errors.New("a query is required"))
+	}
+
+	cfg, err := backend.LoadConfig(configFile, config.Requirements{Embedding: true, DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C or SIGTERM cancels the search; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+	ctx = session.WithRequestID(ctx, session.NewRequestID())
+	k = vectorstore.ClampK(ctx, k)
+
+	// Create Azure OpenAI clients and connect to the vector store, or use the offline stand-ins
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+
+	results, err := search(ctx, services.Models, services.Store, query, k)
+	services.RecordRun(ctx, "search", map[string]int64{"results": int64(len(results))}, err)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		err = writeJSON(os.Stdout, results)
+	} else {
+		err = writeTable(os.Stdout, results)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+
+	// Exit non-zero so scripts can detect an empty or missing index
+	if len(results) == 0 {
+		return fmt.Errorf("%w: no results found; has the data been uploaded with cmd/upload?", cli.ErrData)
+	}
+	return nil
+}
+
+// embedder generates the query embedding
+type embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// searcher finds the hotels nearest to a query vector
+type searcher interface {
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// search embeds the query and returns the k nearest hotels
+func search(ctx context.Context, e embedder, s searcher, query string, k int) ([]models.HotelSearchResult, error) {
+	embedding, err := e.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	results, err := s.VectorSearch(ctx, embedding, k)
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+
+	return results, nil
+}
+
+// writeTable prints results as an aligned table
+func writeTable(w io.Writer, results []models.HotelSearchResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSCORE\tPROVENANCE\tHOTEL\tCATEGORY\tRATING\tCITY")
+	for i, result := range results {
+		hotel := result.Hotel
+		provenance := result.Provenance
+		if provenance == "" {
+			provenance = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%.6f\t%s\t%s\t%s\t%.1f\t%s\n", rank(i, result), result.Score, provenance, hotel.HotelName, hotel.Category, hotel.Rating, hotel.Address.City)
+	}
+	return tw.Flush()
+}
+
+// rank returns the rank of the result at index i, counting from 1 when the store
+// didn't rank it
+func rank(i int, result models.HotelSearchResult) int {
+	if result.Rank > 0 {
+		return result.Rank
+	}
+	return i + 1
+}
+
+// writeJSON prints results as a JSON array
+func writeJSON(w io.Writer, results []models.HotelSearchResult) error {
+	rows := make([]searchResult, 0, len(results))
+	for i, result := range results {
+		hotel := result.Hotel
+		rows = append(rows, searchResult{
+			Rank:       rank(i, result),
+			Score:      result.Score,
+			RawScore:   result.RawScore,
+			Provenance: result.Provenance,
+			HotelID:    hotel.HotelID,
+			HotelName:  hotel.HotelName,
+			Category:   hotel.Category,
+			Rating:     hotel.Rating,
+			City:       hotel.Address.City,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// fakeEmbedder embeds known queries as fixed vectors
+type fakeEmbedder map[string][]float32
+
+func (f fakeEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vector, ok := f[text]
+	if !ok {
+		return nil, errors.New("embedding deployment not found")
+	}
+	return vector, nil
+}
+
+// memoryStore ranks its hotels by exact cosine similarity to the query vector
+type memoryStore []models.HotelForVectorStore
+
+func (m memoryStore) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	results := make([]models.HotelSearchResult, 0, len(m))
+	for _, hotel := range m {
+		score := cosine(queryVector, hotel.DescriptionVector)
+		results = append(results, models.HotelSearchResult{Hotel: hotel, Score: score, RawScore: score, Provenance: models.ProvenanceExact})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	results = results[:min(k, len(results))]
+	models.RankResults(results)
+	return results, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func testStore() memoryStore {
+	hotel := func(id, name, category, city string, rating float64, vector ...float32) models.HotelForVectorStore {
+		h := models.HotelForVectorStore{HotelID: id, HotelName: name, Category: category, Rating: rating, DescriptionVector: vector}
+		h.Address.City = city
+		return h
+	}
+	return memoryStore{
+		hotel("1", "Ocean Retreat", "Resort and Spa", "Miami", 4.5, 1, 0, 0),
+		hotel("2", "City Inn", "Budget", "Boston", 3.1, 0, 1, 0),
+		hotel("3", "Harbor View", "Boutique", "Seattle", 4.2, 0.8, 0.6, 0),
+	}
+}
+
+func TestSearchRanksByScore(t *testing.T) {
+	embedder := fakeEmbedder{"beach resort": {1, 0, 0}}
+
+	results, err := search(context.Background(), embedder, testStore(), "beach resort", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Hotel.HotelName != "Ocean Retreat" || results[1].Hotel.HotelName != "Harbor View" {
+		t.Fatalf("results = %+v, want Ocean Retreat then Harbor View", results)
+	}
+
+	var table bytes.Buffer
+	if err := writeTable(&table, results); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "RANK") {
+		t.Fatalf("table = %q, want a header and two rows", table.String())
+	}
+	for i, want := range [][]string{{"1", "1.000000", "exact", "Ocean Retreat", "Resort and Spa", "4.5", "Miami"}, {"2", "0.800000", "exact", "Harbor View", "Boutique", "4.2", "Seattle"}} {
+		if got := strings.Join(strings.Fields(lines[i+1]), " "); got != strings.Join(want, " ") {
+			t.Errorf("row %d = %q, want %q", i+1, got, strings.Join(want, " "))
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, results); err != nil {
+		t.Fatal(err)
+	}
+	var rows []searchResult
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1] != (searchResult{Rank: 2, Score: results[1].Score, RawScore: results[1].Score, Provenance: "exact", HotelID: "3", HotelName: "Harbor View", Category: "Boutique", Rating: 4.2, City: "Seattle"}) {
+		t.Errorf("rows = %+v", rows)
+	}
+}
+
+func TestSearchEmptyStore(t *testing.T) {
+	results, err := search(context.Background(), fakeEmbedder{"spa": {1, 0, 0}}, memoryStore{}, "spa", 5)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("search = %v, %v, want no results", results, err)
+	}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, results); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("empty JSON = %q, want []", out.String())
+	}
+}
+
+func TestSearchEmbeddingError(t *testing.T) {
+	if _, err := search(context.Background(), fakeEmbedder{}, testStore(), "spa", 5); err == nil || !strings.Contains(err.Error(), "failed to generate embedding") {
+		t.Errorf("err = %v, want an embedding error", err)
+	}
+}
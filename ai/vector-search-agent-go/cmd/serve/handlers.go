@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/hints"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/results"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultK = 5
+
+	// filterOverfetch is how many candidates per requested result are retrieved when
+	// filters are applied, since filtering happens after the nearest-neighbor search
+	filterOverfetch = 4
+)
+
+// searchFilters narrows search results. Zero values mean "no filter".
+type searchFilters struct {
+	Category        string  `json:"category"`
+	City            string  `json:"city"`
+	MinRating       float64 `json:"minRating"`
+	ParkingIncluded *bool   `json:"parkingIncluded"`
+}
+
+// empty reports whether no filter is set
+func (f searchFilters) empty() bool {
+	return f == searchFilters{}
+}
+
+// match reports whether a hotel passes every filter
+func (f searchFilters) match(hotel models.HotelForVectorStore) bool {
+	if f.Category != "" && !strings.EqualFold(hotel.Category, f.Category) {
+		return false
+	}
+	if f.City != "" && !strings.EqualFold(hotel.Address.City, f.City) {
+		return false
+	}
+	if hotel.Rating < f.MinRating {
+		return false
+	}
+	if f.ParkingIncluded != nil && hotel.ParkingIncluded != *f.ParkingIncluded {
+		return false
+	}
+	return true
+}
+
+// searchRequest is the POST /search body
+type searchRequest struct {
+	Query   string        `json:"query"`
+	K       int           `json:"k"`
+	Filters searchFilters `json:"filters"`
+}
+
+// searchResponse is the POST /search response
+type searchResponse struct {
+	Query   string                   `json:"query"`
+	K       int                      `json:"k"`
+	Results []results.RetrievedHotel `json:"results"`
+}
+
+// chatRequest is the POST /chat body
+type chatRequest struct {
+	Query     string `json:"query"`
+	K         int    `json:"k"`
+	SessionID string `json:"sessionId"`
+}
+
+// feedbackRequest is the POST /feedback body
+type feedbackRequest struct {
+	ResultID  string `json:"resultId"`
+	Rating    string `json:"rating"`
+	Comment   string `json:"comment"`
+	SessionID string `json:"sessionId"`
+}
+
+// feedbackResponse is the POST /feedback response
+type feedbackResponse struct {
+	ResultID string `json:"resultId"`
+	Rating   string `json:"rating"`
+	Created  bool   `json:"created"`
+}
+
+// handleSearch embeds the query and returns the nearest hotels, optionally filtered
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var req searchRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if e, ok := validateQuery(r.Context(), req.Query, &req.K); !ok {
+		writeError(w, http.StatusBadRequest, e)
+		return
+	}
+	if req.Filters.MinRating < 0 || req.Filters.MinRating > 5 {
+		writeError(w, http.StatusBadRequest, apiError{Code: codeInvalidRequest, Field: "filters.minRating", Message: "minRating must be between 0 and 5"})
+		return
+	}
+
+	ctx := r.Context()
+	vector, err := s.embedder.GenerateEmbedding(ctx, req.Query)
+	if err != nil {
+		s.fail(w, r, "embedding failed", err)
+		return
+	}
+
+	fetch := req.K
+	if !req.Filters.empty() {
+		fetch = min(req.K*filterOverfetch, vectorstore.MaxK())
+	}
+	found, err := s.searcher.VectorSearch(ctx, vector, fetch)
+	if err != nil {
+		s.fail(w, r, "vector search failed", err)
+		return
+	}
+
+	matched := make([]models.HotelSearchResult, 0, req.K)
+	for _, result := range found {
+		if !req.Filters.match(result.Hotel) {
+			continue
+		}
+		matched = append(matched, result)
+		if len(matched) == req.K {
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, searchResponse{Query: req.Query, K: req.K, Results: results.NewRetrievedHotels(matched)})
+}
+
+// handleChat runs the agent pipeline and returns the answer with citations and usage
+func (s *server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if e, ok := validateQuery(r.Context(), req.Query, &req.K); !ok {
+		writeError(w, http.StatusBadRequest, e)
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = uuid.NewString()
+	}
+
+	usage := clients.NewUsageTracker()
+	ctx := session.WithID(r.Context(), req.SessionID)
+	ctx = clients.WithUsageTracker(ctx, usage)
+
+	start := time.Now()
+	state := agents.NewPipelineState(req.Query, req.K)
+	state.SessionID = req.SessionID
+	state.RequestID = session.RequestID(ctx)
+	state.EphemeralDatabase = s.ephemeralDatabase
+	if err := s.pipeline.Run(ctx, state); err != nil {
+		s.history.Record(ctx, state, agents.BuildRunSummary(state, usage.Snapshot(), time.Since(start), err))
+		s.fail(w, r.WithContext(ctx), "agent run failed", err)
+		return
+	}
+
+	summary := agents.BuildRunSummary(state, usage.Snapshot(), time.Since(start), nil)
+	s.history.Record(ctx, state, summary)
+	writeJSON(w, http.StatusOK, results.New(state, summary, nil))
+}
+
+// handleFeedback records a rating for a /chat answer. Rating the same resultId again
+// replaces the earlier rating: 201 means created, 200 means updated.
+func (s *server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	var req feedbackRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	feedback := models.Feedback{ResultID: req.ResultID, SessionID: req.SessionID, Rating: req.Rating, Comment: req.Comment}
+	if err := feedback.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: codeInvalidRequest, Message: err.Error()})
+		return
+	}
+
+	created, err := s.feedback.SaveFeedback(r.Context(), feedback)
+	if err != nil {
+		s.fail(w, r, "saving feedback failed", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, feedbackResponse{ResultID: feedback.ResultID, Rating: feedback.Rating, Created: created})
+}
+
+// decode reads a JSON body into v, writing a 400 response and returning false on failure
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, apiError{Code: codeInvalidJSON, Message: fmt.Sprintf("invalid JSON body: %v", err)})
+		return false
+	}
+	return true
+}
+
+// validateQuery checks the query, defaults k when unset, and clamps it with
+// vectorstore.ClampK
+func validateQuery(ctx context.Context, query string, k *int) (apiError, bool) {
+	if strings.TrimSpace(query) == "" {
+		return apiError{Code: codeInvalidRequest, Field: "query", Message: "query is required"}, false
+	}
+	if *k == 0 {
+		*k = defaultK
+	}
+	*k = vectorstore.ClampK(ctx, *k)
+	return apiError{}, true
+}
+
+// fail logs err and writes a 504 for timeouts or a 502 for upstream failures, with the
+// hint of a failure the hints package recognizes, such as a missing vector index
+func (s *server) fail(w http.ResponseWriter, r *http.Request, message string, err error) {
+	s.logger.ErrorContext(r.Context(), message, "err", err)
+
+	status, e := http.StatusBadGateway, apiError{Code: codeUpstream, Message: message}
+	if errors.Is(err, context.DeadlineExceeded) {
+		status, e = http.StatusGatewayTimeout, apiError{Code: codeTimeout, Message: fmt.Sprintf("%s: request timed out after %s", message, s.requestTimeout)}
+	}
+	if h, ok := hints.Lookup(err); ok {
+		e.Hint, e.Docs = h.Hint, h.DocURL
+	}
+	writeError(w, status, e)
+}
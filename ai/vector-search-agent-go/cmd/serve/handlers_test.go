@@ -0,0 +1,686 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/results"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry/telemetrytest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/google/uuid"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// fakeEmbedder returns a fixed vector, or err
+type fakeEmbedder struct {
+	err error
+}
+
+func (f *fakeEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+// fakeSearcher returns the first k of its hotels and records the k it was asked for
+type fakeSearcher struct {
+	results []models.HotelSearchResult
+	err     error
+	gotK    int
+}
+
+func (f *fakeSearcher) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	f.gotK = k
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.results[:min(k, len(f.results))], nil
+}
+
+// fakeRunner stands in for the agent pipeline: it searches with the raw query
+// and answers from the top result. It records the session, request, and span it ran under.
+type fakeRunner struct {
+	searcher  *fakeSearcher
+	err       error
+	block     bool
+	session   string
+	requestID string
+	span      oteltrace.SpanContext
+}
+
+func (f *fakeRunner) Run(ctx context.Context, state *agents.PipelineState) error {
+	f.session = session.FromContext(ctx)
+	f.requestID = session.RequestID(ctx)
+	f.span = oteltrace.SpanContextFromContext(ctx)
+	if f.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if f.err != nil {
+		return f.err
+	}
+
+	results, err := f.searcher.VectorSearch(ctx, nil, state.NearestNeighbors)
+	if err != nil {
+		return err
+	}
+	state.SearchQuery = state.Query
+	state.Results = results
+	state.Answer = "Try " + results[0].Hotel.HotelName + " [1]."
+	state.Citations = []agents.Citation{{HotelID: results[0].Hotel.HotelID, HotelName: results[0].Hotel.HotelName, Score: results[0].Score, Rank: 1}}
+	return nil
+}
+
+func serveHotel(id, name, category, city string, rating float64, parking bool, score float64) models.HotelSearchResult {
+	hotel := models.HotelForVectorStore{HotelID: id, HotelName: name, Category: category, Rating: rating, ParkingIncluded: parking}
+	hotel.Address.City = city
+	return models.HotelSearchResult{Hotel: hotel, Score: score}
+}
+
+// memoryFeedback is an in-memory feedback store keyed by result ID
+type memoryFeedback struct {
+	records map[string]models.Feedback
+	err     error
+}
+
+func (m *memoryFeedback) SaveFeedback(ctx context.Context, feedback models.Feedback) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.records == nil {
+		m.records = make(map[string]models.Feedback)
+	}
+	_, exists := m.records[feedback.ResultID]
+	m.records[feedback.ResultID] = feedback
+	return !exists, nil
+}
+
+// memoryHistory is an in-memory history store, or fails with err
+type memoryHistory struct {
+	records []models.HistoryRecord
+	err     error
+}
+
+func (m *memoryHistory) InsertHistory(ctx context.Context, record models.HistoryRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.records = append(m.records, record)
+	return nil
+}
+
+func newTestServer() (*server, *fakeSearcher, *fakeRunner, *bytes.Buffer) {
+	searcher := &fakeSearcher{results: []models.HotelSearchResult{
+		serveHotel("1", "Stay-Kay City Hotel", "Boutique", "New York", 3.6, false, 0.91),
+		serveHotel("10", "Countryside Hotel", "Extended-Stay", "Durham", 2.7, true, 0.84),
+		serveHotel("11", "Royal Cottage Resort", "Luxury", "Bothell", 4.2, true, 0.77),
+		serveHotel("12", "Winter Panorama Resort", "Resort and Spa", "Wilsonville", 4.5, false, 0.63),
+	}}
+	runner := &fakeRunner{searcher: searcher}
+	var logs bytes.Buffer
+	s := &server{
+		embedder:       &fakeEmbedder{},
+		searcher:       searcher,
+		pipeline:       runner,
+		requestTimeout: time.Second,
+		logger:         slog.New(logging.ContextHandler{Handler: slog.NewJSONHandler(&logs, nil)}),
+	}
+	return s, searcher, runner, &logs
+}
+
+// post sends body to path through the full handler chain and decodes the JSON response into v
+func post(t *testing.T, h http.Handler, path, body string, v any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("invalid JSON response: %v\n%s", err, rec.Body.String())
+		}
+	}
+	return rec
+}
+
+func TestSearchReturnsRankedHits(t *testing.T) {
+	s, searcher, _, _ := newTestServer()
+
+	var resp searchResponse
+	rec := post(t, s.routes(), "/search", `{"query": "hotel near Times Square", "k": 2}`, &resp)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if searcher.gotK != 2 {
+		t.Errorf("searched for %d neighbors, want 2", searcher.gotK)
+	}
+	want := []results.RetrievedHotel{
+		{Rank: 1, Score: 0.91, HotelID: "1", HotelName: "Stay-Kay City Hotel", Category: "Boutique", Rating: 3.6, City: "New York"},
+		{Rank: 2, Score: 0.84, HotelID: "10", HotelName: "Countryside Hotel", Category: "Extended-Stay", Rating: 2.7, City: "Durham"},
+	}
+	if resp.Query != "hotel near Times Square" || resp.K != 2 || len(resp.Results) != 2 || resp.Results[0] != want[0] || resp.Results[1] != want[1] {
+		t.Errorf("response = %+v, want %+v", resp, want)
+	}
+}
+
+func TestSearchDefaultsK(t *testing.T) {
+	s, searcher, _, _ := newTestServer()
+
+	var resp searchResponse
+	post(t, s.routes(), "/search", `{"query": "pool"}`, &resp)
+	if resp.K != defaultK || searcher.gotK != defaultK {
+		t.Errorf("k = %d, searched %d, want %d", resp.K, searcher.gotK, defaultK)
+	}
+}
+
+func TestSearchClampsK(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"negative", `{"query": "pool", "k": -1}`, vectorstore.MinK},
+		{"too large", `{"query": "pool", "k": 21}`, vectorstore.DefaultMaxK},
+		{"overfetch", `{"query": "pool", "k": 15, "filters": {"minRating": 1}}`, vectorstore.DefaultMaxK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, searcher, _, _ := newTestServer()
+
+			var resp searchResponse
+			rec := post(t, s.routes(), "/search", tt.body, &resp)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			if searcher.gotK != tt.want {
+				t.Errorf("searched for %d neighbors, want %d", searcher.gotK, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters string
+		wantIDs []string
+	}{
+		{"category", `{"category": "luxury"}`, []string{"11"}},
+		{"city", `{"city": "new york"}`, []string{"1"}},
+		{"min rating", `{"minRating": 4}`, []string{"11", "12"}},
+		{"parking", `{"parkingIncluded": true}`, []string{"10", "11"}},
+		{"no parking", `{"parkingIncluded": false}`, []string{"1", "12"}},
+		{"combined", `{"minRating": 3, "parkingIncluded": true}`, []string{"11"}},
+		{"no match", `{"city": "Paris"}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, searcher, _, _ := newTestServer()
+
+			var resp searchResponse
+			rec := post(t, s.routes(), "/search", `{"query": "hotel", "k": 2, "filters": `+tt.filters+`}`, &resp)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+
+			// Filters apply after the search, so extra candidates are fetched
+			if searcher.gotK != 2*filterOverfetch {
+				t.Errorf("searched for %d neighbors, want %d", searcher.gotK, 2*filterOverfetch)
+			}
+			var ids []string
+			for i, hit := range resp.Results {
+				ids = append(ids, hit.HotelID)
+				if hit.Rank != i+1 {
+					t.Errorf("hit %s has rank %d, want %d", hit.HotelID, hit.Rank, i+1)
+				}
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("hotels = %v, want %v", ids, tt.wantIDs)
+			}
+			if resp.Results == nil {
+				t.Error("results is null, want an empty array")
+			}
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		wantCode  string
+		wantField string
+	}{
+		{"search malformed JSON", "/search", `{"query": `, codeInvalidJSON, ""},
+		{"search unknown field", "/search", `{"query": "pool", "limit": 3}`, codeInvalidJSON, ""},
+		{"search missing query", "/search", `{"k": 3}`, codeInvalidRequest, "query"},
+		{"search blank query", "/search", `{"query": "   "}`, codeInvalidRequest, "query"},
+		{"search non-integer k", "/search", `{"query": "pool", "k": 2.5}`, codeInvalidJSON, ""},
+		{"search min rating", "/search", `{"query": "pool", "filters": {"minRating": 6}}`, codeInvalidRequest, "filters.minRating"},
+		{"chat missing query", "/chat", `{"sessionId": "abc"}`, codeInvalidRequest, "query"},
+		{"chat malformed JSON", "/chat", `not json`, codeInvalidJSON, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, searcher, _, _ := newTestServer()
+
+			var resp struct {
+				Error apiError `json:"error"`
+			}
+			rec := post(t, s.routes(), tt.path, tt.body, &resp)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", rec.Code)
+			}
+			if resp.Error.Code != tt.wantCode || resp.Error.Field != tt.wantField || resp.Error.Message == "" {
+				t.Errorf("error = %+v, want code %q field %q", resp.Error, tt.wantCode, tt.wantField)
+			}
+			if searcher.gotK != 0 {
+				t.Error("searched despite an invalid request")
+			}
+		})
+	}
+}
+
+func TestSearchUpstreamFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(*server, *fakeSearcher)
+		wantStatus int
+		wantCode   string
+		wantHint   bool
+	}{
+		{"embedding fails", func(s *server, _ *fakeSearcher) { s.embedder = &fakeEmbedder{err: errors.New("rate limited")} }, http.StatusBadGateway, codeUpstream, false},
+		{"search fails", func(_ *server, f *fakeSearcher) { f.err = errors.New("connection reset") }, http.StatusBadGateway, codeUpstream, false},
+		{"search times out", func(_ *server, f *fakeSearcher) { f.err = context.DeadlineExceeded }, http.StatusGatewayTimeout, codeTimeout, false},
+		{"index missing", func(_ *server, f *fakeSearcher) { f.err = vectorstore.ErrIndexMissing }, http.StatusBadGateway, codeUpstream, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, searcher, _, logs := newTestServer()
+			tt.setup(s, searcher)
+
+			var resp struct {
+				Error apiError `json:"error"`
+			}
+			rec := post(t, s.routes(), "/search", `{"query": "pool"}`, &resp)
+			if rec.Code != tt.wantStatus || resp.Error.Code != tt.wantCode {
+				t.Errorf("status %d code %q, want %d %q", rec.Code, resp.Error.Code, tt.wantStatus, tt.wantCode)
+			}
+			if gotHint := resp.Error.Hint != "" && resp.Error.Docs != ""; gotHint != tt.wantHint {
+				t.Errorf("hint %q docs %q, want a hint: %v", resp.Error.Hint, resp.Error.Docs, tt.wantHint)
+			}
+			// Upstream details are logged, not returned to the client
+			if strings.Contains(resp.Error.Message, "connection reset") || strings.Contains(resp.Error.Message, "rate limited") {
+				t.Errorf("error message leaks the upstream error: %q", resp.Error.Message)
+			}
+			if !strings.Contains(logs.String(), `"level":"ERROR"`) {
+				t.Errorf("failure was not logged at error level:\n%s", logs.String())
+			}
+		})
+	}
+}
+
+func TestChatReturnsAnswerAndCitations(t *testing.T) {
+	s, _, runner, _ := newTestServer()
+
+	var resp results.RunResult
+	rec := post(t, s.routes(), "/chat", `{"query": "quiet hotel", "k": 3, "sessionId": "session-42"}`, &resp)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if resp.SchemaVersion != results.SchemaVersion || resp.K != 3 {
+		t.Errorf("schema version %d, k %d, want the current envelope for k=3", resp.SchemaVersion, resp.K)
+	}
+	if resp.SessionID != "session-42" || runner.session != "session-42" {
+		t.Errorf("session = %q (pipeline saw %q), want session-42", resp.SessionID, runner.session)
+	}
+	if _, err := uuid.Parse(resp.ResultID); err != nil {
+		t.Errorf("resultId = %q, want a UUID", resp.ResultID)
+	}
+	if resp.Answer != "Try Stay-Kay City Hotel [1]." || resp.SearchQuery != "quiet hotel" {
+		t.Errorf("answer %q, search query %q", resp.Answer, resp.SearchQuery)
+	}
+	if len(resp.Citations) != 1 || resp.Citations[0].HotelID != "1" {
+		t.Errorf("citations = %+v", resp.Citations)
+	}
+	if len(resp.Results) != 3 || resp.Results[2].Rank != 3 {
+		t.Errorf("results = %+v, want 3 ranked hits", resp.Results)
+	}
+	// The fake pipeline makes no model calls, so the per-request tracker stays empty
+	if len(resp.Usage) != 0 {
+		t.Errorf("usage = %+v, want none", resp.Usage)
+	}
+	// The response is the shared envelope, readable with the same decoder as the other surfaces
+	if decoded, err := results.Decode(rec.Body.Bytes()); err != nil || !reflect.DeepEqual(decoded, resp) {
+		t.Errorf("Decode = %+v, %v, want %+v", decoded, err, resp)
+	}
+}
+
+func TestChatGeneratesSessionID(t *testing.T) {
+	s, _, runner, _ := newTestServer()
+
+	var first, second results.RunResult
+	post(t, s.routes(), "/chat", `{"query": "pool"}`, &first)
+	post(t, s.routes(), "/chat", `{"query": "pool"}`, &second)
+
+	if first.SessionID == "" || first.SessionID == second.SessionID {
+		t.Errorf("session IDs = %q and %q, want distinct generated IDs", first.SessionID, second.SessionID)
+	}
+	if runner.session != second.SessionID {
+		t.Errorf("pipeline saw session %q, want %q", runner.session, second.SessionID)
+	}
+}
+
+func TestChatTimesOut(t *testing.T) {
+	s, _, runner, _ := newTestServer()
+	s.requestTimeout = 20 * time.Millisecond
+	runner.block = true
+
+	var resp struct {
+		Error apiError `json:"error"`
+	}
+	rec := post(t, s.routes(), "/chat", `{"query": "pool"}`, &resp)
+	if rec.Code != http.StatusGatewayTimeout || resp.Error.Code != codeTimeout {
+		t.Errorf("status %d code %q, want 504 timeout", rec.Code, resp.Error.Code)
+	}
+	if !strings.Contains(resp.Error.Message, "request timed out after 20ms") {
+		t.Errorf("message = %q", resp.Error.Message)
+	}
+}
+
+func TestChatPipelineFailure(t *testing.T) {
+	s, _, runner, _ := newTestServer()
+	runner.err = errors.New("synthesizer failed")
+
+	var resp struct {
+		Error apiError `json:"error"`
+	}
+	rec := post(t, s.routes(), "/chat", `{"query": "pool"}`, &resp)
+	if rec.Code != http.StatusBadGateway || resp.Error.Code != codeUpstream {
+		t.Errorf("status %d code %q, want 502 upstream_error", rec.Code, resp.Error.Code)
+	}
+}
+
+func TestRoutesAndRequestLog(t *testing.T) {
+	s, _, _, logs := newTestServer()
+	h := s.routes()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Errorf("healthz = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"goVersion"`) {
+		t.Errorf("version = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /search = %d, want 405", rec.Code)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d log records, want one per request", len(records))
+	}
+	first := records[0]
+	if first["msg"] != "request" || first["method"] != "GET" || first["path"] != "/healthz" || first["status"] != float64(200) {
+		t.Errorf("log record = %v", first)
+	}
+	if records[2]["status"] != float64(http.StatusMethodNotAllowed) {
+		t.Errorf("log record = %v, want status 405", records[2])
+	}
+}
+
+func TestRequestIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string // empty means a generated ID
+	}{
+		{"incoming", "req-abc", "req-abc"},
+		{"generated", "", ""},
+		{"invalid incoming", "not valid\tid", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, runner, logs := newTestServer()
+
+			req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(`{"query":"quiet hotel"}`))
+			if tt.header != "" {
+				req.Header.Set(session.RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+
+			id := rec.Header().Get(session.RequestIDHeader)
+			if tt.want != "" && id != tt.want {
+				t.Errorf("%s = %q, want %q", session.RequestIDHeader, id, tt.want)
+			}
+			if _, err := uuid.Parse(id); tt.want == "" && err != nil {
+				t.Errorf("%s = %q, want a generated UUID", session.RequestIDHeader, id)
+			}
+
+			// The envelope, the pipeline, and the request log all carry the same ID
+			var resp results.RunResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.RequestID != id || runner.requestID != id {
+				t.Errorf("envelope requestId %q, pipeline %q, want %q", resp.RequestID, runner.requestID, id)
+			}
+			var record map[string]any
+			if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+				t.Fatalf("invalid log line %q: %v", logs.String(), err)
+			}
+			if record["msg"] != "request" || record["requestId"] != id {
+				t.Errorf("log record = %v, want requestId %q", record, id)
+			}
+		})
+	}
+}
+
+func TestRequestsContinueIncomingTrace(t *testing.T) {
+	exporter := telemetrytest.Record(t)
+	s, _, runner, _ := newTestServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(`{"query":"quiet hotel"}`))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	span := telemetrytest.Span(t, exporter.GetSpans(), "POST /chat")
+	if span.SpanKind != oteltrace.SpanKindServer {
+		t.Errorf("span kind = %v, want server", span.SpanKind)
+	}
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+	if !span.Parent.IsRemote() || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("parent = %v, want the caller's remote span", span.Parent.SpanID())
+	}
+	if got, _ := telemetrytest.Attr(span, semconv.HTTPResponseStatusCodeKey); got.AsInt64() != http.StatusOK {
+		t.Errorf("%s = %v, want 200", semconv.HTTPResponseStatusCodeKey, got.Emit())
+	}
+	if runner.span.SpanID() != span.SpanContext.SpanID() {
+		t.Errorf("pipeline ran under span %v, want the request span %v", runner.span.SpanID(), span.SpanContext.SpanID())
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	recorder := metrics.NewPrometheus()
+	recorder.Search(30*time.Millisecond, nil)
+	s, _, _, _ := newTestServer()
+	s.metrics = recorder.Handler()
+
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(rec.Body)
+	if err != nil {
+		t.Fatalf("invalid exposition format: %v", err)
+	}
+	if family, ok := families["searches_total"]; !ok || family.GetMetric()[0].GetCounter().GetValue() != 1 {
+		t.Errorf("searches_total = %v, want 1", family)
+	}
+
+	// Without a recorder the route is not served
+	s.metrics = nil
+	rec = httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /metrics without a recorder = %d, want 404", rec.Code)
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	s, _, _, _ := newTestServer()
+
+	body := `{"query": "` + strings.Repeat("a", maxBodyBytes) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/search", io.NopCloser(strings.NewReader(body)))
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), codeInvalidJSON) {
+		t.Errorf("oversized body = %d %s, want 400 invalid_json", rec.Code, rec.Body.String())
+	}
+}
+
+func TestFeedbackCreatesThenUpdates(t *testing.T) {
+	s, _, _, _ := newTestServer()
+	store := &memoryFeedback{}
+	s.feedback = store
+	h := s.routes()
+	resultID := uuid.NewString()
+
+	var resp feedbackResponse
+	rec := post(t, h, "/feedback", `{"resultId": "`+resultID+`", "rating": "UP", "sessionId": "session-42"}`, &resp)
+	if rec.Code != http.StatusCreated || !resp.Created || resp.Rating != models.RatingUp || resp.ResultID != resultID {
+		t.Fatalf("first rating = %d %+v, want 201 created", rec.Code, resp)
+	}
+
+	rec = post(t, h, "/feedback", `{"resultId": "`+resultID+`", "rating": "down", "comment": " too far from the beach "}`, &resp)
+	if rec.Code != http.StatusOK || resp.Created || resp.Rating != models.RatingDown {
+		t.Fatalf("second rating = %d %+v, want 200 updated", rec.Code, resp)
+	}
+
+	if len(store.records) != 1 {
+		t.Fatalf("store has %d records, want 1", len(store.records))
+	}
+	if got := store.records[resultID]; got.Rating != models.RatingDown || got.Comment != "too far from the beach" {
+		t.Errorf("stored feedback = %+v", got)
+	}
+}
+
+func TestFeedbackErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		storeErr   error
+		wantStatus int
+		wantCode   string
+	}{
+		{"invalid result ID", `{"resultId": "abc", "rating": "up"}`, nil, http.StatusBadRequest, codeInvalidRequest},
+		{"invalid rating", `{"resultId": "` + uuid.NewString() + `", "rating": "meh"}`, nil, http.StatusBadRequest, codeInvalidRequest},
+		{"store failure", `{"resultId": "` + uuid.NewString() + `", "rating": "up"}`, errors.New("connection reset"), http.StatusBadGateway, codeUpstream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _, _ := newTestServer()
+			store := &memoryFeedback{err: tt.storeErr}
+			s.feedback = store
+
+			var resp struct {
+				Error apiError `json:"error"`
+			}
+			rec := post(t, s.routes(), "/feedback", tt.body, &resp)
+			if rec.Code != tt.wantStatus || resp.Error.Code != tt.wantCode {
+				t.Errorf("status %d, error %+v, want %d %s", rec.Code, resp.Error, tt.wantStatus, tt.wantCode)
+			}
+			if len(store.records) != 0 {
+				t.Errorf("stored %v after an error", store.records)
+			}
+		})
+	}
+}
+
+func TestChatRecordsHistory(t *testing.T) {
+	s, _, _, _ := newTestServer()
+	store := &memoryHistory{}
+	s.history = agents.NewHistoryWriter(store)
+
+	var resp results.RunResult
+	rec := post(t, s.routes(), "/chat", `{"query": "quiet hotel", "sessionId": "session-42"}`, &resp)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.records) != 1 {
+		t.Fatalf("recorded %d runs, want 1", len(store.records))
+	}
+	if got := store.records[0]; got.ResultID != resp.ResultID || got.Query != "quiet hotel" || got.Answer != resp.Answer || len(got.Results) != len(resp.Results) {
+		t.Errorf("history record = %+v, want the response %+v", got, resp)
+	}
+}
+
+func TestChatAnswersWhenHistoryFails(t *testing.T) {
+	s, _, _, _ := newTestServer()
+	s.history = agents.NewHistoryWriter(&memoryHistory{err: errors.New("connection reset")})
+
+	var resp results.RunResult
+	rec := post(t, s.routes(), "/chat", `{"query": "quiet hotel"}`, &resp)
+	if rec.Code != http.StatusOK || resp.Answer == "" {
+		t.Errorf("status = %d, answer %q, want the answer despite the history failure", rec.Code, resp.Answer)
+	}
+}
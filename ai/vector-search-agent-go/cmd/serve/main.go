@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+)
+
+const (
+	defaultPort           = "8080"
+	defaultRequestTimeout = 60 * time.Second
+	shutdownTimeout       = 15 * time.Second
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run serves the HTTP API until interrupted
+func run() error {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	warmup := 0
+	if value := os.Getenv("WARMUP_SEARCHES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: invalid WARMUP_SEARCHES %q", cli.ErrConfig, value)
+		}
+		warmup = n
+	}
+
+	var verbosity cli.Verbosity
+	var configFile string
+	flag.IntVar(&warmup, "warmup", warmup, "Throwaway searches issued at startup to warm up the vector index (env WARMUP_SEARCHES)")
+	cli.AddVerbosityFlags(flag.CommandLine, &verbosity)
+	cli.AddConfigFlag(flag.CommandLine, &configFile)
+	cli.AddVersionFlag(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: serve [flags]\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Serves /search and /chat over HTTP on PORT (default 8080).\n\n")
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), cli.ExitCodesHelp)
+	}
+	flag.Parse()
+	if warmup < 0 {
+		return cli.Usage(errors.New("invalid warmup: must not be negative"))
+	}
+
+	logger, err := cli.SetupLogging(verbosity, os.Getenv, slog.LevelInfo)
+	if err != nil {
+		return err
+	}
+	flush, err := cli.SetupTracing("serve")
+	if err != nil {
+		return err
+	}
+	defer flush()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = defaultPort
+	}
+
+	requestTimeout := defaultRequestTimeout
+	if value := os.Getenv("SERVE_REQUEST_TIMEOUT"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%w: invalid SERVE_REQUEST_TIMEOUT %q", cli.ErrConfig, value)
+		}
+		requestTimeout = d
+	}
+
+	cfg, err := backend.LoadConfig(configFile, config.Requirements{Embedding: true, Chat: true, DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Stop on Ctrl+C or SIGTERM, letting in-flight requests finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Create Azure OpenAI clients and connect to the vector store once for all requests,
+	// or use the offline stand-ins
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+
+	// Warm up before serving and before metrics are recorded, so neither the first
+	// requests nor /metrics see the throwaway searches
+	backend.WarmUp(ctx, services.Store, warmup, os.Stderr)
+
+	// Count searches, OpenAI requests, and runs for /metrics
+	recorder := metrics.NewPrometheus()
+	metrics.SetDefault(recorder)
+
+	// Progress output from concurrent requests would interleave; the request log replaces it
+	pipeline := agents.NewDefaultPipeline(services.Models, services.Store, cfg.Planner, cfg.Synthesizer, cfg.Timeouts)
+	pipeline.SetOutput(io.Discard)
+
+	srv := &server{
+		embedder:       services.Models,
+		searcher:       services.Store,
+		feedback:       services.Store,
+		history:        agents.NewHistoryWriterFromEnv(services.Store),
+		metrics:        recorder.Handler(),
+		pipeline:       pipeline,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+
+		ephemeralDatabase: services.EphemeralDatabase(),
+	}
+
+	httpServer := &http.Server{
+		Addr:              ":" + port,
+		Handler:           srv.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", httpServer.Addr, "requestTimeout", requestTimeout)
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+	case <-ctx.Done():
+		logger.Info("shutting down", "timeout", shutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("shutdown did not complete", "err", err)
+		}
+	}
+	return nil
+}
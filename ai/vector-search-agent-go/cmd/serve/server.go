@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// maxBodyBytes bounds request bodies
+const maxBodyBytes = 1 << 20
+
+// embedder generates query embeddings
+type embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// searcher runs vector searches
+type searcher interface {
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// feedbackStore records ratings of answers
+type feedbackStore interface {
+	SaveFeedback(ctx context.Context, feedback models.Feedback) (bool, error)
+}
+
+// runner runs the agent pipeline over a state
+type runner interface {
+	Run(ctx context.Context, state *agents.PipelineState) error
+}
+
+// server serves the HTTP API. The embedder, searcher, and pipeline are shared by all requests.
+type server struct {
+	embedder       embedder
+	searcher       searcher
+	pipeline       runner
+	feedback       feedbackStore
+	history        *agents.HistoryWriter // nil unless HISTORY_ENABLED is set
+	metrics        http.Handler          // serves /metrics; nil leaves it unrouted
+	requestTimeout time.Duration
+	logger         *slog.Logger
+	// ephemeralDatabase is reported in /chat results when EPHEMERAL_DATABASE is set
+	ephemeralDatabase string
+}
+
+// routes returns the handler for all endpoints, wrapped in tracing, request IDs, request
+// logging, and timeouts
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /search", s.handleSearch)
+	mux.HandleFunc("POST /chat", s.handleChat)
+	mux.HandleFunc("POST /feedback", s.handleFeedback)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, buildinfo.Read())
+	})
+	if s.metrics != nil {
+		mux.Handle("GET /metrics", s.metrics)
+	}
+	return traceRequests(withRequestID(s.logRequests(s.withTimeout(mux))))
+}
+
+// withRequestID gives each request a correlation ID, the caller's X-Request-ID when it
+// sends a valid one or a new one otherwise. The ID is carried by the request context, so
+// every log record of the request includes it, and is echoed in the response header.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(session.RequestIDHeader)
+		if !session.ValidRequestID(id) {
+			id = session.NewRequestID()
+		}
+		w.Header().Set(session.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(session.WithRequestID(r.Context(), id)))
+	})
+}
+
+// withTimeout bounds each request by the server's request timeout
+func (s *server) withTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), s.requestTimeout)
+		defer cancel()
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// statusRecorder captures the response status for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status and forwards it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests writes one structured log record per request
+func (s *server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		s.logger.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr,
+		)
+	})
+}
+
+// traceRequests records a server span per request, continuing the caller's trace when
+// the request carries a traceparent header
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := telemetry.StartServer(r.Context(), propagation.HeaderCarrier(r.Header), r.Method+" "+r.URL.Path,
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// apiError is the machine-readable error body
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	// Hint and Docs say what to check for a failure the hints package recognizes
+	Hint string `json:"hint,omitempty"`
+	Docs string `json:"docs,omitempty"`
+}
+
+// Error codes returned in apiError.Code
+const (
+	codeInvalidJSON    = "invalid_json"
+	codeInvalidRequest = "invalid_request"
+	codeTimeout        = "timeout"
+	codeUpstream       = "upstream_error"
+)
+
+// writeError writes {"error": {...}} with the given status
+func writeError(w http.ResponseWriter, status int, e apiError) {
+	writeJSON(w, status, map[string]apiError{"error": e})
+}
+
+// writeJSON writes v as the response body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
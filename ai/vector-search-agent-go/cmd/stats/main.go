@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run reports document counts and vector index health, or with --runs the token usage
+// of the last runs recorded in METRICS_COLLECTION
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	var verbosity cli.Verbosity
+	var configFile string
+	asJSON := flag.Bool("json", false, "Print the report as JSON")
+	migrate := flag.Bool("migrate", false, "Upgrade documents below the current schema version before reporting")
+	runs := flag.Int("runs", 0, "Summarize the token usage, cost, and duration of the last `N` runs recorded in METRICS_COLLECTION instead")
+	cli.AddVerbosityFlags(flag.CommandLine, &verbosity)
+	cli.AddConfigFlag(flag.CommandLine, &configFile)
+	cli.AddVersionFlag(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: stats [flags]\n\n")
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), cli.ExitCodesHelp)
+	}
+	flag.Parse()
+	if *runs < 0 {
+		return cli.Usage(fmt.Errorf("--runs must not be negative, got %d", *runs))
+	}
+
+	if _, err := cli.SetupLogging(verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	// Load configuration
+	cfg, err := cli.LoadConfig(configFile, config.Requirements{DocumentDB: true})
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C or SIGTERM cancels the run; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	vsConfig := cfg.VectorStore
+
+	// Connect to vector store
+	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+	defer store.Close(context.Background())
+
+	if *runs > 0 {
+		return reportRuns(ctx, store, vsConfig.MetricsCollection, *runs, *asJSON)
+	}
+
+	exists, err := store.CollectionExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if exists && *migrate {
+		result, err := store.Migrate(ctx)
+		if err != nil {
+			return err
+		}
+		// Keep stdout for the report, which may be JSON
+		fmt.Fprintf(os.Stderr, "Migrated %d documents to schema version %d\n", result.Migrated, models.CurrentSchemaVersion)
+	}
+
+	var stats *vectorstore.CollectionStats
+	var indexes []vectorstore.IndexInfo
+	var versions map[int]int64
+	if exists {
+		if stats, err = store.Stats(ctx); err != nil {
+			return err
+		}
+		if indexes, err = store.ListIndexes(ctx); err != nil {
+			return err
+		}
+		if versions, err = store.SchemaVersions(ctx); err != nil {
+			return err
+		}
+	}
+
+	r := buildReport(vsConfig, stats, indexes, vectorstore.EmbeddingDimensionsFromEnv())
+	r.addSchemaVersions(versions)
+	if *asJSON {
+		if err := r.writeJSON(os.Stdout); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+	} else {
+		r.render(os.Stdout)
+	}
+
+	if len(r.Problems) > 0 {
+		return fmt.Errorf("%w: found %d problem(s)", cli.ErrData, len(r.Problems))
+	}
+	return nil
+}
+
+// reportRuns summarizes the last n runs recorded in the metrics collection
+func reportRuns(ctx context.Context, store *vectorstore.VectorStore, collection string, n int, asJSON bool) error {
+	if collection == "" {
+		return fmt.Errorf("%w: --runs reads the runs recorded in METRICS_COLLECTION, which is not set", cli.ErrConfig)
+	}
+	records, err := store.ListRunMetrics(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	r := buildRunsReport(collection, records)
+	if asJSON {
+		if err := r.writeJSON(os.Stdout); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		return nil
+	}
+	r.render(os.Stdout)
+	return nil
+}
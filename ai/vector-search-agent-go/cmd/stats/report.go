@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// indexReport is one index in the report
+type indexReport struct {
+	Name   string            `json:"name"`
+	Keys   map[string]string `json:"keys"`
+	Vector *vectorReport     `json:"vector,omitempty"`
+
+	keyOrder []string
+}
+
+// vectorReport describes a vector index
+type vectorReport struct {
+	Field      string         `json:"field"`
+	Algorithm  string         `json:"algorithm"`
+	Similarity string         `json:"similarity"`
+	Dimensions int            `json:"dimensions"`
+	Parameters map[string]int `json:"parameters,omitempty"`
+}
+
+// report is the collection health report
+type report struct {
+	Database       string        `json:"database"`
+	Collection     string        `json:"collection"`
+	Exists         bool          `json:"exists"`
+	Documents      int64         `json:"documents"`
+	TotalSize      int64         `json:"totalSizeBytes"`
+	AvgSize        int64         `json:"avgDocumentSizeBytes"`
+	StorageSize    int64         `json:"storageSizeBytes"`
+	TotalIndexSize int64         `json:"totalIndexSizeBytes"`
+	SchemaVersions map[int]int64 `json:"schemaVersions,omitempty"`
+	Indexes        []indexReport `json:"indexes"`
+	Problems       []string      `json:"problems"`
+}
+
+// buildReport assembles the report and flags obvious problems. stats and indexes
+// are nil when the collection does not exist.
+func buildReport(config *vectorstore.VectorStoreConfig, stats *vectorstore.CollectionStats, indexes []vectorstore.IndexInfo, expectedDimensions int) *report {
+	r := &report{
+		Database:   config.DatabaseName,
+		Collection: config.CollectionName,
+		Exists:     stats != nil,
+		Indexes:    []indexReport{},
+		Problems:   []string{},
+	}
+
+	if stats == nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("collection %s does not exist; run cmd/upload", config.CollectionName))
+		return r
+	}
+
+	r.Documents = stats.Count
+	r.TotalSize = stats.Size
+	r.AvgSize = stats.AvgObjSize
+	r.StorageSize = stats.StorageSize
+	r.TotalIndexSize = stats.TotalIndexSize
+
+	var vector *vectorstore.VectorIndexInfo
+	for _, index := range indexes {
+		ir := indexReport{Name: index.Name, Keys: make(map[string]string, len(index.Keys))}
+		for _, key := range index.Keys {
+			ir.Keys[key.Field] = key.Type
+			ir.keyOrder = append(ir.keyOrder, key.Field)
+		}
+		if v := index.Vector; v != nil {
+			ir.Vector = &vectorReport{
+				Field:      v.Field,
+				Algorithm:  v.Kind,
+				Similarity: v.Similarity,
+				Dimensions: v.Dimensions,
+				Parameters: v.Parameters,
+			}
+			if v.Field == config.EmbeddedField {
+				vector = v
+			}
+		}
+		r.Indexes = append(r.Indexes, ir)
+	}
+
+	if r.Documents == 0 {
+		r.Problems = append(r.Problems, "collection has zero documents; run cmd/upload")
+	}
+	if vector == nil {
+		r.Problems = append(r.Problems, fmt.Sprintf("no vector index on %s; run `go run ./cmd/upload --index-only`", config.EmbeddedField))
+	} else if vector.Dimensions != expectedDimensions {
+		r.Problems = append(r.Problems, fmt.Sprintf("vector index has %d dimensions but EMBEDDING_DIMENSIONS is %d; rebuild with cmd/reindex or fix the setting", vector.Dimensions, expectedDimensions))
+	}
+
+	return r
+}
+
+// addSchemaVersions records the documents' schema versions and flags documents below
+// the current version
+func (r *report) addSchemaVersions(versions map[int]int64) {
+	r.SchemaVersions = versions
+	var outdated int64
+	for version, count := range versions {
+		if version < models.CurrentSchemaVersion {
+			outdated += count
+		}
+	}
+	if outdated > 0 {
+		r.Problems = append(r.Problems, fmt.Sprintf("%d documents have a schema version below %d; run `go run ./cmd/stats --migrate`", outdated, models.CurrentSchemaVersion))
+	}
+}
+
+// render prints the human-readable report
+func (r *report) render(w io.Writer) {
+	fmt.Fprintf(w, "Collection: %s.%s\n", r.Database, r.Collection)
+	if r.Exists {
+		fmt.Fprintf(w, "Documents:  %d\n", r.Documents)
+		fmt.Fprintf(w, "Data size:  %s total, %s average per document\n", formatBytes(r.TotalSize), formatBytes(r.AvgSize))
+		fmt.Fprintf(w, "Storage:    %s data, %s indexes\n", formatBytes(r.StorageSize), formatBytes(r.TotalIndexSize))
+		if len(r.SchemaVersions) > 0 {
+			fmt.Fprintf(w, "Schema:     %s\n", vectorstore.FormatSchemaVersions(r.SchemaVersions))
+		}
+
+		fmt.Fprintf(w, "\nIndexes (%d):\n", len(r.Indexes))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tKEYS\tDETAILS")
+		for _, index := range r.Indexes {
+			keys := make([]string, len(index.keyOrder))
+			for i, field := range index.keyOrder {
+				keys[i] = field + ": " + index.Keys[field]
+			}
+			details := "-"
+			if v := index.Vector; v != nil {
+				details = fmt.Sprintf("%s, %s, %d dims%s", v.Algorithm, v.Similarity, v.Dimensions, formatParameters(v.Parameters))
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", index.Name, strings.Join(keys, ", "), details)
+		}
+		tw.Flush()
+	}
+
+	if len(r.Problems) == 0 {
+		fmt.Fprintln(w, "\nNo problems found.")
+		return
+	}
+	fmt.Fprintf(w, "\nProblems (%d):\n", len(r.Problems))
+	for _, problem := range r.Problems {
+		fmt.Fprintf(w, "  ✖ %s\n", problem)
+	}
+}
+
+// writeJSON writes the report as indented JSON
+func (r *report) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// formatParameters renders algorithm parameters in a stable order
+func formatParameters(params map[string]int) string {
+	var parts []string
+	for _, key := range []string{"numLists", "m", "efConstruction", "maxDegree", "lBuild"} {
+		if value, ok := params[key]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%d", key, value))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(parts, ", ")
+}
+
+// formatBytes renders a byte count in human-readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func statsConfig() *vectorstore.VectorStoreConfig {
+	return &vectorstore.VectorStoreConfig{DatabaseName: "Hotels", CollectionName: "hotels_ivf", EmbeddedField: "DescriptionVector"}
+}
+
+func cannedStats() *vectorstore.CollectionStats {
+	return &vectorstore.CollectionStats{Count: 50, Size: 512000, AvgObjSize: 10240, StorageSize: 786432, TotalIndexSize: 98304, IndexCount: 3}
+}
+
+func cannedIndexes(dimensions int) []vectorstore.IndexInfo {
+	return []vectorstore.IndexInfo{
+		{Name: "_id_", Keys: []vectorstore.IndexKey{{Field: "_id", Type: "1"}}},
+		{Name: "HotelId_1", Keys: []vectorstore.IndexKey{{Field: "HotelId", Type: "1"}}},
+		{
+			Name: "vectorIndex",
+			Keys: []vectorstore.IndexKey{{Field: "DescriptionVector", Type: "cosmosSearch"}},
+			Vector: &vectorstore.VectorIndexInfo{
+				Name: "vectorIndex", Field: "DescriptionVector", Kind: "vector-ivf",
+				Similarity: "COS", Dimensions: dimensions, Parameters: map[string]int{"numLists": 10},
+			},
+		},
+	}
+}
+
+func TestRenderHealthyReport(t *testing.T) {
+	r := buildReport(statsConfig(), cannedStats(), cannedIndexes(1536), 1536)
+
+	var out bytes.Buffer
+	r.render(&out)
+
+	want := `Collection: Hotels.hotels_ivf
+Documents:  50
+Data size:  500.0 KiB total, 10.0 KiB average per document
+Storage:    768.0 KiB data, 96.0 KiB indexes
+
+Indexes (3):
+  NAME         KEYS                             DETAILS
+  _id_         _id: 1                           -
+  HotelId_1    HotelId: 1                       -
+  vectorIndex  DescriptionVector: cosmosSearch  vector-ivf, COS, 1536 dims, numLists=10
+
+No problems found.
+`
+	if out.String() != want {
+		t.Errorf("report =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestReportProblems(t *testing.T) {
+	empty := cannedStats()
+	empty.Count = 0
+
+	tests := []struct {
+		name    string
+		stats   *vectorstore.CollectionStats
+		indexes []vectorstore.IndexInfo
+		want    []string
+	}{
+		{"healthy", cannedStats(), cannedIndexes(1536), nil},
+		{"missing collection", nil, nil, []string{"collection hotels_ivf does not exist; run cmd/upload"}},
+		{"zero documents", empty, cannedIndexes(1536), []string{"collection has zero documents; run cmd/upload"}},
+		{
+			"no vector index", cannedStats(), cannedIndexes(1536)[:2],
+			[]string{"no vector index on DescriptionVector; run `go run ./cmd/upload --index-only`"},
+		},
+		{
+			"dimension mismatch", cannedStats(), cannedIndexes(3072),
+			[]string{"vector index has 3072 dimensions but EMBEDDING_DIMENSIONS is 1536; rebuild with cmd/reindex or fix the setting"},
+		},
+		{
+			"empty and unindexed", empty, nil,
+			[]string{"collection has zero documents; run cmd/upload", "no vector index on DescriptionVector; run `go run ./cmd/upload --index-only`"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildReport(statsConfig(), tt.stats, tt.indexes, 1536)
+			if strings.Join(r.Problems, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("problems = %q, want %q", r.Problems, tt.want)
+			}
+
+			var out bytes.Buffer
+			r.render(&out)
+			for _, problem := range tt.want {
+				if !strings.Contains(out.String(), "  ✖ "+problem) {
+					t.Errorf("rendered report is missing %q:\n%s", problem, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMissingCollection(t *testing.T) {
+	var out bytes.Buffer
+	buildReport(statsConfig(), nil, nil, 1536).render(&out)
+
+	want := "Collection: Hotels.hotels_ivf\n\nProblems (1):\n  ✖ collection hotels_ivf does not exist; run cmd/upload\n"
+	if out.String() != want {
+		t.Errorf("report = %q, want %q", out.String(), want)
+	}
+}
+
+func TestReportJSON(t *testing.T) {
+	r := buildReport(statsConfig(), cannedStats(), cannedIndexes(1536), 1536)
+
+	var out bytes.Buffer
+	if err := r.writeJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if decoded["documents"] != float64(50) || decoded["avgDocumentSizeBytes"] != float64(10240) || decoded["exists"] != true {
+		t.Errorf("report fields = %v", decoded)
+	}
+	if problems, ok := decoded["problems"].([]any); !ok || len(problems) != 0 {
+		t.Errorf("problems = %v, want an empty array", decoded["problems"])
+	}
+
+	indexes := decoded["indexes"].([]any)
+	vector := indexes[2].(map[string]any)["vector"].(map[string]any)
+	if vector["algorithm"] != "vector-ivf" || vector["dimensions"] != float64(1536) || vector["similarity"] != "COS" {
+		t.Errorf("vector index = %v", vector)
+	}
+	if _, ok := indexes[0].(map[string]any)["vector"]; ok {
+		t.Error("non-vector index has a vector description")
+	}
+}
+
+func TestFormatParameters(t *testing.T) {
+	tests := []struct {
+		params map[string]int
+		want   string
+	}{
+		{nil, ""},
+		{map[string]int{"numLists": 10}, ", numLists=10"},
+		{map[string]int{"efConstruction": 64, "m": 16}, ", m=16, efConstruction=64"},
+		{map[string]int{"lBuild": 10, "maxDegree": 20, "unknown": 1}, ", maxDegree=20, lBuild=10"},
+	}
+	for _, tt := range tests {
+		if got := formatParameters(tt.params); got != tt.want {
+			t.Errorf("formatParameters(%v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestReportSchemaVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		versions    map[int]int64
+		wantLine    string
+		wantProblem string
+	}{
+		{"current", map[int]int64{models.CurrentSchemaVersion: 50}, "Schema:     v1: 50\n", ""},
+		{"mixed", map[int]int64{0: 12, models.CurrentSchemaVersion: 38}, "Schema:     v0: 12, v1: 38\n", "12 documents have a schema version below 1; run `go run ./cmd/stats --migrate`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildReport(statsConfig(), cannedStats(), cannedIndexes(1536), 1536)
+			r.addSchemaVersions(tt.versions)
+			if strings.Join(r.Problems, "\n") != tt.wantProblem {
+				t.Errorf("problems = %q, want %q", r.Problems, tt.wantProblem)
+			}
+
+			var out bytes.Buffer
+			r.render(&out)
+			if !strings.Contains(out.String(), "Storage:    768.0 KiB data, 96.0 KiB indexes\n"+tt.wantLine) {
+				t.Errorf("rendered report is missing %q:\n%s", tt.wantLine, out.String())
+			}
+
+			out.Reset()
+			if err := r.writeJSON(&out); err != nil {
+				t.Fatal(err)
+			}
+			var decoded struct {
+				SchemaVersions map[int]int64 `json:"schemaVersions"`
+			}
+			if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.SchemaVersions, tt.versions) {
+				t.Errorf("JSON schemaVersions = %v, want %v", decoded.SchemaVersions, tt.versions)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// runsReport summarizes the runs recorded in the metrics collection
+type runsReport struct {
+	Collection string     `json:"collection"`
+	Runs       int        `json:"runs"`
+	From       time.Time  `json:"from,omitzero"`
+	To         time.Time  `json:"to,omitzero"`
+	Groups     []runGroup `json:"groups"`
+	Total      runGroup   `json:"total"`
+}
+
+// runGroup totals the runs of one command in one environment
+type runGroup struct {
+	Environment      string           `json:"environment,omitempty"`
+	Command          string           `json:"command,omitempty"`
+	Runs             int              `json:"runs"`
+	Failed           int              `json:"failed"`
+	PromptTokens     int64            `json:"promptTokens"`
+	CompletionTokens int64            `json:"completionTokens"`
+	EmbeddingTokens  int64            `json:"embeddingTokens"`
+	EstimatedCost    float64          `json:"estimatedCost"`
+	Documents        map[string]int64 `json:"documents,omitempty"`
+	AvgDurationMs    int64            `json:"avgDurationMs"`
+
+	durationMs int64
+}
+
+// add counts one run in g
+func (g *runGroup) add(run models.RunMetrics) {
+	g.Runs++
+	if run.Error != "" {
+		g.Failed++
+	}
+	g.PromptTokens += run.PromptTokens
+	g.CompletionTokens += run.CompletionTokens
+	g.EmbeddingTokens += run.EmbeddingTokens
+	g.EstimatedCost += run.EstimatedCost
+	for name, n := range run.Documents {
+		if g.Documents == nil {
+			g.Documents = make(map[string]int64)
+		}
+		g.Documents[name] += n
+	}
+	g.durationMs += run.DurationMs
+	g.AvgDurationMs = g.durationMs / int64(g.Runs)
+}
+
+// buildRunsReport totals runs, newest first as ListRunMetrics returns them, by
+// environment and command
+func buildRunsReport(collection string, runs []models.RunMetrics) *runsReport {
+	r := &runsReport{Collection: collection, Runs: len(runs), Groups: []runGroup{}}
+	groups := make(map[[2]string]*runGroup)
+	for _, run := range runs {
+		key := [2]string{run.Environment, run.Command}
+		g, ok := groups[key]
+		if !ok {
+			g = &runGroup{Environment: run.Environment, Command: run.Command}
+			groups[key] = g
+		}
+		g.add(run)
+		r.Total.add(run)
+
+		if r.From.IsZero() || run.CreatedAt.Before(r.From) {
+			r.From = run.CreatedAt
+		}
+		if run.CreatedAt.After(r.To) {
+			r.To = run.CreatedAt
+		}
+	}
+
+	for _, g := range groups {
+		r.Groups = append(r.Groups, *g)
+	}
+	slices.SortFunc(r.Groups, func(a, b runGroup) int {
+		return cmp.Or(cmp.Compare(a.Environment, b.Environment), cmp.Compare(a.Command, b.Command))
+	})
+	return r
+}
+
+// render prints the human-readable summary
+func (r *runsReport) render(w io.Writer) {
+	if r.Runs == 0 {
+		fmt.Fprintf(w, "No runs recorded in %s.\n", r.Collection)
+		return
+	}
+	fmt.Fprintf(w, "Last %d runs in %s, %s to %s\n\n", r.Runs, r.Collection, r.From.UTC().Format(time.DateTime), r.To.UTC().Format(time.DateTime+" UTC"))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ENVIRONMENT\tCOMMAND\tRUNS\tFAILED\tPROMPT\tCOMPLETION\tEMBEDDING\tEST. COST\tAVG DURATION")
+	for _, g := range r.Groups {
+		writeRunGroup(tw, cmp.Or(g.Environment, "-"), g.Command, g)
+	}
+	writeRunGroup(tw, "total", "", r.Total)
+	tw.Flush()
+}
+
+// writeRunGroup writes one row of the summary table
+func writeRunGroup(w io.Writer, environment, command string, g runGroup) {
+	duration := time.Duration(g.AvgDurationMs) * time.Millisecond
+	fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%d\t%d\t%d\t$%.6f\t%s\n",
+		environment, command, g.Runs, g.Failed, g.PromptTokens, g.CompletionTokens, g.EmbeddingTokens, g.EstimatedCost, duration.Round(time.Millisecond))
+}
+
+// writeJSON writes the summary as indented JSON
+func (r *runsReport) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// cannedRuns returns recorded runs, newest first as ListRunMetrics returns them
+func cannedRuns() []models.RunMetrics {
+	newest := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	return []models.RunMetrics{
+		{Command: "agent", Environment: "dev", PromptTokens: 400, CompletionTokens: 60, EmbeddingTokens: 7, EstimatedCost: 0.0001, Documents: map[string]int64{"results": 3}, DurationMs: 3000, CreatedAt: newest},
+		{Command: "agent", Environment: "dev", PromptTokens: 200, EmbeddingTokens: 5, Error: "synthesizer stage timed out", DurationMs: 1000, CreatedAt: newest.Add(-time.Hour)},
+		{Command: "upload", Environment: "dev", EmbeddingTokens: 9000, EstimatedCost: 0.00018, Documents: map[string]int64{"inserted": 50, "failed": 0}, DurationMs: 60000, CreatedAt: newest.Add(-2 * time.Hour)},
+		{Command: "agent", Environment: "prod", PromptTokens: 300, CompletionTokens: 40, EmbeddingTokens: 6, EstimatedCost: 0.00008, DurationMs: 2000, CreatedAt: newest.Add(-24 * time.Hour)},
+	}
+}
+
+func TestBuildRunsReport(t *testing.T) {
+	r := buildRunsReport("run_metrics", cannedRuns())
+
+	if r.Runs != 4 || !r.From.Equal(time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)) || !r.To.Equal(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("report covers %d runs from %v to %v, want 4 over the last day", r.Runs, r.From, r.To)
+	}
+
+	// Grouped by environment, then command
+	if len(r.Groups) != 3 {
+		t.Fatalf("groups = %+v, want dev/agent, dev/upload, and prod/agent", r.Groups)
+	}
+	agent := r.Groups[0]
+	if agent.Environment != "dev" || agent.Command != "agent" || agent.Runs != 2 || agent.Failed != 1 ||
+		agent.PromptTokens != 600 || agent.CompletionTokens != 60 || agent.EmbeddingTokens != 12 || agent.AvgDurationMs != 2000 {
+		t.Errorf("dev agent = %+v", agent)
+	}
+	if !maps.Equal(agent.Documents, map[string]int64{"results": 3}) {
+		t.Errorf("dev agent documents = %v, want 3 results", agent.Documents)
+	}
+	if upload := r.Groups[1]; upload.Command != "upload" || upload.Documents["inserted"] != 50 {
+		t.Errorf("dev upload = %+v", upload)
+	}
+	if prod := r.Groups[2]; prod.Environment != "prod" || prod.Runs != 1 {
+		t.Errorf("prod agent = %+v", prod)
+	}
+
+	total := r.Total
+	if total.Runs != 4 || total.Failed != 1 || total.EmbeddingTokens != 9018 || total.PromptTokens != 900 || total.AvgDurationMs != 16500 {
+		t.Errorf("total = %+v", total)
+	}
+}
+
+func TestRenderRunsReport(t *testing.T) {
+	var buf bytes.Buffer
+	buildRunsReport("run_metrics", cannedRuns()).render(&buf)
+	got := buf.String()
+
+	for _, want := range []string{
+		"Last 4 runs in run_metrics, 2026-10-15 09:30:00 to 2026-10-16 09:30:00 UTC",
+		"ENVIRONMENT", "EST. COST",
+		"dev          agent    2     1       600     60          12         $0.000100  2s",
+		"dev          upload   1     0       0       0           9000       $0.000180  1m0s",
+		"total                 4     1       900     100         9018       $0.000360  16.5s",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary is missing %q:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	buildRunsReport("run_metrics", nil).render(&buf)
+	if got := buf.String(); got != "No runs recorded in run_metrics.\n" {
+		t.Errorf("empty summary = %q", got)
+	}
+}
+
+func TestRunsReportJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := buildRunsReport("run_metrics", cannedRuns()).writeJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Collection string `json:"collection"`
+		Runs       int    `json:"runs"`
+		Groups     []struct {
+			Environment   string  `json:"environment"`
+			Command       string  `json:"command"`
+			EstimatedCost float64 `json:"estimatedCost"`
+		} `json:"groups"`
+		Total struct {
+			Runs            int   `json:"runs"`
+			EmbeddingTokens int64 `json:"embeddingTokens"`
+		} `json:"total"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Collection != "run_metrics" || decoded.Runs != 4 || len(decoded.Groups) != 3 || decoded.Total.EmbeddingTokens != 9018 {
+		t.Errorf("decoded = %+v", decoded)
+	}
+
+	// No runs still encode an empty list of groups, not null
+	buf.Reset()
+	if err := buildRunsReport("run_metrics", nil).writeJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"groups": []`) || strings.Contains(buf.String(), `"from"`) {
+		t.Errorf("empty report = %s, want an empty list of groups and no time range", buf.String())
+	}
+}
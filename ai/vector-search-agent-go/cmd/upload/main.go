@@ -2,102 +2,152 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
-
-	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
-	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"path/filepath"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/backend"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/upload"
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load .env file from current directory
-	if err := godotenv.Load(".env"); err != nil {
-		log.Printf("Warning: .env file not found: %v", err)
-	}
-
-	ctx := context.Background()
+	cli.Exit(run())
+}
 
-	// Load configurations
-	openaiConfig := clients.LoadConfigFromEnv()
-	vsConfig := vectorstore.LoadConfigFromEnv()
+// run loads, embeds, and inserts the hotel data, then creates the vector index
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
 
-	debug := openaiConfig.Debug
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
 
-	if debug {
-		fmt.Printf("DEBUG mode is ON\n")
+	// Resolve flags and their environment fallbacks
+	opts, err := parseOptions(os.Args[1:], os.Getenv, os.Stderr)
+	if err != nil {
+		return cli.Usage(err)
 	}
 
-	// Get data file path
-	dataFile := os.Getenv("DATA_FILE_WITHOUT_VECTORS")
-	if dataFile == "" {
-		dataFile = "../data/Hotels.json"
+	if _, err := cli.SetupLogging(opts.Verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
 	}
-
-	fmt.Printf("Loading hotels from: %s\n", dataFile)
-
-	// Load hotels from JSON
-	hotels, err := vectorstore.LoadHotelsFromJSON(dataFile)
+	flush, err := cli.SetupTracing("upload")
 	if err != nil {
-		log.Fatalf("Failed to load hotels: %v", err)
+		return err
 	}
-
-	fmt.Printf("Loaded %d hotels\n", len(hotels))
-
-	// Create Azure OpenAI clients
-	openaiClients, err := clients.NewOpenAIClients(openaiConfig)
+	defer flush()
+	stopMetrics, err := cli.SetupMetrics(os.Getenv)
 	if err != nil {
-		log.Fatalf("Failed to create OpenAI clients: %v", err)
+		return err
 	}
+	defer stopMetrics()
 
-	// Connect to vector store
-	store, err := vectorstore.NewVectorStore(ctx, vsConfig)
+	cfg, err := backend.LoadConfig(opts.ConfigFile, config.Requirements{Embedding: true, DocumentDB: true, VectorIndex: true})
 	if err != nil {
-		log.Fatalf("Failed to connect to vector store: %v", err)
+		return err
 	}
-	defer store.Close(ctx)
 
-	// Convert hotels and generate embeddings
-	fmt.Println("\nGenerating embeddings and preparing documents...")
-	hotelsWithVectors := make([]models.HotelForVectorStore, 0, len(hotels))
+	// Ctrl+C or SIGTERM stops the upload after in-flight batches are inserted and checkpointed
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+
+	u := &upload.Uploader{
+		Out:          os.Stdout,
+		Dimensions:   vectorstore.EmbeddingDimensionsFromEnv(),
+		TTY:          progress.IsTerminal(os.Stdout),
+		RetryBackoff: upload.DefaultRetryBackoff,
+	}
 
-	for i, hotel := range hotels {
-		// Convert to vector store format
-		hotelVS := hotel.ToVectorStore()
+	// Create Azure OpenAI clients and connect to the vector store, or use the offline stand-ins
+	services, err := backend.Open(ctx, cfg, os.Stderr)
+	if err != nil {
+		return err
+	}
+	defer services.Close(context.Background())
+	u.Store = services.Store
+	u.EmbeddingModel = services.EmbeddingModel()
+
+	// Embeddings are only generated when loading data without vectors
+	if !opts.IndexOnly && !opts.Precomputed() {
+		u.Embedder = services.Models
+		u.Usage = services.Models.Usage()
+	}
 
-		// Generate embedding from the Description field
-		embedding, err := openaiClients.GenerateEmbedding(ctx, hotel.Description)
+	reportConfig := newReportConfig(opts, services, u.Dimensions)
+	if opts.RetryFromReport != "" {
+		retry, err := upload.LoadFailureReport(opts.RetryFromReport)
 		if err != nil {
-			log.Printf("Warning: Failed to generate embedding for hotel %s: %v", hotel.HotelName, err)
-			continue
+			return err
 		}
-
-		hotelVS.DescriptionVector = embedding
-		hotelsWithVectors = append(hotelsWithVectors, hotelVS)
-
-		if debug && (i+1)%10 == 0 {
-			fmt.Printf("Processed %d/%d hotels\n", i+1, len(hotels))
+		if retry.Fingerprint != reportConfig.Fingerprint() {
+			return fmt.Errorf("%w: %s was written for a different data file, deployment, dimensions, or collection (fingerprint %s, now %s)",
+				cli.ErrConfig, opts.RetryFromReport, retry.Fingerprint, reportConfig.Fingerprint())
 		}
+		if len(retry.Failures) == 0 {
+			fmt.Printf("%s lists no failed documents; nothing to retry\n", opts.RetryFromReport)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Retrying %d failed documents from %s\n", len(retry.Failures), opts.RetryFromReport)
+		opts.Only = retry.HotelIDs()
+		// A failed insert may have stored part of its batch; --changed-only upserts, so
+		// those documents are replaced rather than duplicated
+		opts.SkipExisting = !opts.ChangedOnly
 	}
 
-	fmt.Printf("Generated embeddings for %d hotels\n", len(hotelsWithVectors))
-
-	// Insert documents into vector store
-	fmt.Println("\nInserting documents into vector store...")
-	if err := store.InsertHotelsWithEmbeddings(ctx, hotelsWithVectors); err != nil {
-		log.Fatalf("Failed to insert hotels: %v", err)
+	summary, err := u.Run(ctx, &opts.Options)
+	services.RecordRun(ctx, "upload", map[string]int64{"loaded": int64(summary.Loaded), "embedded": int64(summary.Embedded), "inserted": int64(summary.Inserted), "failed": int64(summary.Failed)}, err)
+	summary.Render(os.Stdout)
+	if opts.FailureReport != "" {
+		if writeErr := upload.NewFailureReport(summary, reportConfig, err).Write(opts.FailureReport); writeErr != nil {
+			log.Printf("Warning: %v", writeErr)
+		} else {
+			fmt.Printf("\nFailure report with %d documents written to %s\n", len(summary.FailedHotels), opts.FailureReport)
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("\nUpload cancelled by user. %d documents were inserted; rerun with --resume to continue.\n", summary.Inserted)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%w: upload incomplete: %d documents were invalid or failed to embed", cli.ErrPartial, summary.Failed)
 	}
 
-	fmt.Printf("Successfully inserted %d documents\n", len(hotelsWithVectors))
+	fmt.Println("\nData upload complete!")
+	return nil
+}
 
-	// Create vector index
-	fmt.Println("\nCreating vector index...")
-	if err := store.CreateVectorIndex(ctx); err != nil {
-		log.Fatalf("Failed to create vector index: %v", err)
+// newReportConfig describes what this run uploads where, for the failure report
+func newReportConfig(opts *options, services *backend.Backend, dimensions int) upload.ReportConfig {
+	config := upload.ReportConfig{DataFile: cleanDataPath(opts.DataFile), Dimensions: dimensions}
+	if opts.Precomputed() {
+		config.DataFile = cleanDataPath(opts.VectorsFile)
+		config.Precomputed = true
+	}
+	if services.Config != nil {
+		config.EmbeddingDeployment = services.Config.OpenAI.EmbeddingDeployment
+		config.Database = services.Config.VectorStore.DatabaseName
+		config.Collection = services.Config.VectorStore.CollectionName
 	}
+	return config
+}
 
-	fmt.Println("Vector index created successfully")
-	fmt.Println("\nData upload complete!")
+// cleanDataPath normalizes a local data file path; URLs are kept as given
+func cleanDataPath(path string) string {
+	if vectorstore.IsURL(path) {
+		return path
+	}
+	return filepath.Clean(path)
 }
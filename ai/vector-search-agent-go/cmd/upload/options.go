@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/upload"
+)
+
+const (
+	defaultDataFile   = "../data/Hotels.json"
+	defaultCheckpoint = ".upload-checkpoint.json"
+)
+
+// options holds the resolved cmd/upload settings. Flags take precedence over
+// environment variables, which take precedence over the defaults.
+type options struct {
+	upload.Options
+	// FailureReport is where the list of failed documents is written
+	FailureReport string
+	// RetryFromReport is a failure report whose documents are the only ones uploaded
+	RetryFromReport string
+	Verbosity       cli.Verbosity
+	ConfigFile      string
+}
+
+// parseOptions resolves options from command-line arguments and environment variables.
+// It returns flag.ErrHelp when help was requested; any other error is a usage error
+// that has already been reported to output along with the usage text.
+func parseOptions(args []string, getenv func(string) string, output io.Writer) (*options, error) {
+	fs := flag.NewFlagSet("upload", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	defaults, err := envDefaults(getenv)
+	if err != nil {
+		fmt.Fprintln(output, err)
+		return nil, err
+	}
+	opts := *defaults
+
+	fs.StringVar(&opts.DataFile, "data", opts.DataFile, "Hotel data file to load and embed (env DATA_FILE_WITHOUT_VECTORS)")
+	fs.StringVar(&opts.VectorsFile, "data-with-vectors", opts.VectorsFile, "Pre-vectorized hotel data file; skips embedding generation (env DATA_FILE_WITH_VECTORS)")
+	fs.BoolVar(&opts.SkipIndex, "skip-index", opts.SkipIndex, "Load, embed, and insert documents without creating the vector index (env UPLOAD_SKIP_INDEX)")
+	fs.BoolVar(&opts.IndexOnly, "index-only", opts.IndexOnly, "Only create the vector index on existing data (env UPLOAD_INDEX_ONLY)")
+	fs.BoolVar(&opts.SkipExisting, "skip-existing", opts.SkipExisting, "Skip hotels whose HotelId is already in the collection (env UPLOAD_SKIP_EXISTING)")
+	fs.BoolVar(&opts.ChangedOnly, "changed-only", opts.ChangedOnly, "Embed and upsert only hotels that are new or whose content changed since they were stored (env UPLOAD_CHANGED_ONLY)")
+	fs.BoolVar(&opts.Prune, "prune", opts.Prune, "With --changed-only, delete stored hotels that are no longer in the data file (env UPLOAD_PRUNE)")
+	fs.BoolVar(&opts.EmbedFrench, "embed-french", opts.EmbedFrench, "Also embed Description_fr, for hotels that have it, and create the French vector index (env EMBED_FRENCH)")
+	fs.BoolVar(&opts.EmbedRooms, "embed-rooms", opts.EmbedRooms, "Also store each room as an embedded document in the rooms collection and create its vector index (env EMBED_ROOMS)")
+	fs.Func("field-weights", "Embed weighted hotel fields instead of the Description, e.g. Description:1,Tags:2,Category:1 (env EMBED_FIELD_WEIGHTS)", func(spec string) error {
+		weights, err := models.ParseFieldWeights(spec)
+		if err != nil {
+			return err
+		}
+		opts.FieldWeights = weights
+		return nil
+	})
+	fs.BoolVar(&opts.Strict, "strict", opts.Strict, "Stop at the first invalid hotel instead of skipping it and listing it with the failed documents (env UPLOAD_STRICT)")
+	fs.IntVar(&opts.BatchSize, "batch-size", opts.BatchSize, "Documents per insert batch (env UPLOAD_BATCH_SIZE)")
+	fs.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "Embedding requests in flight at once (env EMBEDDING_CONCURRENCY)")
+	fs.StringVar(&opts.Checkpoint, "checkpoint", opts.Checkpoint, "Checkpoint file recording uploaded HotelIds (env UPLOAD_CHECKPOINT)")
+	fs.DurationVar(&opts.CheckpointInterval, "checkpoint-interval", opts.CheckpointInterval, "Minimum time between checkpoint writes (env UPLOAD_CHECKPOINT_INTERVAL)")
+	fs.BoolVar(&opts.Resume, "resume", opts.Resume, "Skip hotels recorded in the checkpoint by an interrupted run (env UPLOAD_RESUME)")
+	fs.StringVar(&opts.FailureReport, "failure-report", opts.FailureReport, "Write the failed documents, their phase, and their errors to this JSON file (env UPLOAD_FAILURE_REPORT)")
+	fs.StringVar(&opts.RetryFromReport, "retry-from-report", "", "Upload only the documents listed in this failure report, skipping those already in the collection")
+	cli.AddVerbosityFlags(fs, &opts.Verbosity)
+	cli.AddConfigFlag(fs, &opts.ConfigFile)
+	cli.AddVersionFlag(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: upload [flags]\n\n")
+		fmt.Fprintf(output, "Embeds the hotel data, inserts it into DocumentDB, and creates the vector index.\n")
+		fmt.Fprintf(output, "Flags take precedence over the environment variables shown in parentheses.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprint(output, cli.ExitCodesHelp)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(output, err)
+		fs.Usage()
+		return nil, err
+	}
+
+	dataFileSet := getenv("DATA_FILE_WITHOUT_VECTORS") != ""
+	fs.Visit(func(f *flag.Flag) {
+		dataFileSet = dataFileSet || f.Name == "data"
+	})
+	if opts.Precomputed() && dataFileSet && !opts.IndexOnly {
+		fmt.Fprintf(output, "Notice: both data files are set; uploading pre-vectorized %s and ignoring %s\n", opts.VectorsFile, opts.DataFile)
+	}
+
+	return &opts, nil
+}
+
+// envDefaults reads the environment fallbacks for every flag
+func envDefaults(getenv func(string) string) (*options, error) {
+	opts := &options{Options: upload.Options{
+		DataFile:           getenv("DATA_FILE_WITHOUT_VECTORS"),
+		VectorsFile:        getenv("DATA_FILE_WITH_VECTORS"),
+		SkipIndex:          envBool(getenv, "UPLOAD_SKIP_INDEX"),
+		IndexOnly:          envBool(getenv, "UPLOAD_INDEX_ONLY"),
+		SkipExisting:       envBool(getenv, "UPLOAD_SKIP_EXISTING"),
+		ChangedOnly:        envBool(getenv, "UPLOAD_CHANGED_ONLY"),
+		Prune:              envBool(getenv, "UPLOAD_PRUNE"),
+		Strict:             envBool(getenv, "UPLOAD_STRICT"),
+		EmbedFrench:        envBool(getenv, "EMBED_FRENCH"),
+		EmbedRooms:         envBool(getenv, "EMBED_ROOMS"),
+		BatchSize:          upload.DefaultBatchSize,
+		Concurrency:        upload.DefaultConcurrency,
+		Checkpoint:         getenv("UPLOAD_CHECKPOINT"),
+		CheckpointInterval: upload.DefaultCheckpointInterval,
+		Resume:             envBool(getenv, "UPLOAD_RESUME"),
+	}, FailureReport: getenv("UPLOAD_FAILURE_REPORT")}
+	if opts.DataFile == "" {
+		opts.DataFile = defaultDataFile
+	}
+
+	if spec := getenv("EMBED_FIELD_WEIGHTS"); spec != "" {
+		weights, err := models.ParseFieldWeights(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMBED_FIELD_WEIGHTS %q: %w", spec, err)
+		}
+		opts.FieldWeights = weights
+	}
+	if opts.Checkpoint == "" {
+		opts.Checkpoint = defaultCheckpoint
+	}
+
+	if sizeStr := getenv("UPLOAD_BATCH_SIZE"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPLOAD_BATCH_SIZE %q: must be an integer", sizeStr)
+		}
+		opts.BatchSize = size
+	}
+
+	if concurrencyStr := getenv("EMBEDDING_CONCURRENCY"); concurrencyStr != "" {
+		concurrency, err := strconv.Atoi(concurrencyStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMBEDDING_CONCURRENCY %q: must be an integer", concurrencyStr)
+		}
+		opts.Concurrency = concurrency
+	}
+
+	if intervalStr := getenv("UPLOAD_CHECKPOINT_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UPLOAD_CHECKPOINT_INTERVAL %q: must be a duration such as 5s", intervalStr)
+		}
+		opts.CheckpointInterval = interval
+	}
+
+	return opts, nil
+}
+
+// validate checks the upload options and the report flags
+func (o *options) validate() error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.RetryFromReport != "" && o.IndexOnly {
+		return errors.New("--retry-from-report and --index-only cannot be used together")
+	}
+	if o.RetryFromReport != "" && o.Prune {
+		return errors.New("--retry-from-report and --prune cannot be used together")
+	}
+	return nil
+}
+
+// envBool reports whether an environment variable is set to "true" or "1"
+func envBool(getenv func(string) string, name string) bool {
+	value := getenv(name)
+	return value == "true" || value == "1"
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/upload"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want options
+	}{
+		{"defaults", nil, nil, defaultOptions(upload.Options{})},
+		{"env", nil, map[string]string{"DATA_FILE_WITHOUT_VECTORS": "hotels.json", "UPLOAD_SKIP_INDEX": "true"}, defaultOptions(upload.Options{DataFile: "hotels.json", SkipIndex: true})},
+		{"env index only", nil, map[string]string{"UPLOAD_INDEX_ONLY": "1"}, defaultOptions(upload.Options{IndexOnly: true})},
+		{"flags override env", []string{"--data", "other.json", "--skip-index=false", "--index-only"}, map[string]string{"DATA_FILE_WITHOUT_VECTORS": "hotels.json", "UPLOAD_SKIP_INDEX": "true"}, defaultOptions(upload.Options{DataFile: "other.json", IndexOnly: true})},
+		{
+			"checkpoint env", nil,
+			map[string]string{"UPLOAD_CHECKPOINT": "cp.json", "UPLOAD_CHECKPOINT_INTERVAL": "1s", "UPLOAD_BATCH_SIZE": "4", "UPLOAD_RESUME": "true"},
+			defaultOptions(upload.Options{Checkpoint: "cp.json", CheckpointInterval: time.Second, BatchSize: 4, Resume: true}),
+		},
+		{
+			"checkpoint flags", []string{"--checkpoint", "flag.json", "--checkpoint-interval", "0s", "--batch-size", "2", "--resume"},
+			map[string]string{"UPLOAD_CHECKPOINT": "cp.json", "UPLOAD_CHECKPOINT_INTERVAL": "1s"},
+			defaultOptions(upload.Options{Checkpoint: "flag.json", BatchSize: 2, Resume: true}),
+		},
+		{"env skip existing", nil, map[string]string{"UPLOAD_SKIP_EXISTING": "true"}, defaultOptions(upload.Options{SkipExisting: true})},
+		{"flag skip existing", []string{"--skip-existing=false"}, map[string]string{"UPLOAD_SKIP_EXISTING": "true"}, defaultOptions(upload.Options{})},
+		{"env concurrency", nil, map[string]string{"EMBEDDING_CONCURRENCY": "8"}, defaultOptions(upload.Options{Concurrency: 8})},
+		{"flag concurrency", []string{"--concurrency", "2"}, map[string]string{"EMBEDDING_CONCURRENCY": "8"}, defaultOptions(upload.Options{Concurrency: 2})},
+		{"env changed only", nil, map[string]string{"UPLOAD_CHANGED_ONLY": "true", "UPLOAD_PRUNE": "1"}, defaultOptions(upload.Options{ChangedOnly: true, Prune: true})},
+		{"flag changed only", []string{"--changed-only", "--prune=false"}, map[string]string{"UPLOAD_PRUNE": "true"}, defaultOptions(upload.Options{ChangedOnly: true})},
+		{"env strict", nil, map[string]string{"UPLOAD_STRICT": "true"}, defaultOptions(upload.Options{Strict: true})},
+		{"flag strict", []string{"--strict=false"}, map[string]string{"UPLOAD_STRICT": "1"}, defaultOptions(upload.Options{})},
+		{"env embed french", nil, map[string]string{"EMBED_FRENCH": "true"}, defaultOptions(upload.Options{EmbedFrench: true})},
+		{"flag embed french", []string{"--embed-french"}, nil, defaultOptions(upload.Options{EmbedFrench: true})},
+		{
+			"env field weights", nil, map[string]string{"EMBED_FIELD_WEIGHTS": "Description:1,Tags:2"},
+			defaultOptions(upload.Options{FieldWeights: models.FieldWeights{{Field: "Description", Weight: 1}, {Field: "Tags", Weight: 2}}}),
+		},
+		{
+			"flag field weights", []string{"--field-weights", "Category:3"}, map[string]string{"EMBED_FIELD_WEIGHTS": "Description:1,Tags:2"},
+			defaultOptions(upload.Options{FieldWeights: models.FieldWeights{{Field: "Category", Weight: 3}}}),
+		},
+		{"env failure report", nil, map[string]string{"UPLOAD_FAILURE_REPORT": "env.json"}, withReports(defaultOptions(upload.Options{}), "env.json", "")},
+		{
+			"report flags", []string{"--failure-report", "flag.json", "--retry-from-report", "previous.json"},
+			map[string]string{"UPLOAD_FAILURE_REPORT": "env.json"},
+			withReports(defaultOptions(upload.Options{}), "flag.json", "previous.json"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err != nil {
+				t.Fatalf("parseOptions: %v\n%s", err, out.String())
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("options = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// defaultOptions fills the unset fields of opts with the defaults. A zero CheckpointInterval
+// stays zero when Checkpoint is set, so tests can ask for a flush after every batch.
+func defaultOptions(opts upload.Options) options {
+	if opts.DataFile == "" {
+		opts.DataFile = defaultDataFile
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = upload.DefaultBatchSize
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = upload.DefaultConcurrency
+	}
+	if opts.Checkpoint == "" {
+		opts.Checkpoint = defaultCheckpoint
+		opts.CheckpointInterval = upload.DefaultCheckpointInterval
+	}
+	return options{Options: opts}
+}
+
+// withReports sets the failure report options
+func withReports(opts options, failureReport, retryFromReport string) options {
+	opts.FailureReport = failureReport
+	opts.RetryFromReport = retryFromReport
+	return opts
+}
+
+func TestParseOptionsRejectsBothModes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"flags", []string{"--skip-index", "--index-only"}, nil},
+		{"env", nil, map[string]string{"UPLOAD_SKIP_INDEX": "true", "UPLOAD_INDEX_ONLY": "true"}},
+		{"flag and env", []string{"--index-only"}, map[string]string{"UPLOAD_SKIP_INDEX": "1"}},
+		{"retry from report", []string{"--retry-from-report", "failures.json", "--index-only"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out); err == nil {
+				t.Fatal("parseOptions accepted conflicting modes")
+			}
+			if !strings.Contains(out.String(), "cannot be used together") {
+				t.Errorf("output does not explain the usage error:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestParseOptionsRejectsChangedOnlyConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"prune without changed only", []string{"--prune"}, nil, "--prune requires --changed-only"},
+		{"changed only and skip existing", []string{"--changed-only"}, map[string]string{"UPLOAD_SKIP_EXISTING": "true"}, "--changed-only and --skip-existing cannot be used together"},
+		{"changed only and index only", []string{"--changed-only", "--index-only"}, nil, "--changed-only and --index-only cannot be used together"},
+		{"retry and prune", []string{"--retry-from-report", "failures.json", "--changed-only", "--prune"}, nil, "--retry-from-report and --prune cannot be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out); err == nil {
+				t.Fatal("parseOptions accepted conflicting options")
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestParseOptionsRejectsInvalidCheckpointSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want string
+	}{
+		{"zero batch size", []string{"--batch-size", "0"}, nil, "invalid batch size 0"},
+		{"non-integer env batch size", nil, map[string]string{"UPLOAD_BATCH_SIZE": "ten"}, "invalid UPLOAD_BATCH_SIZE"},
+		{"zero concurrency", []string{"--concurrency", "0"}, nil, "invalid concurrency 0"},
+		{"non-integer env concurrency", nil, map[string]string{"EMBEDDING_CONCURRENCY": "many"}, "invalid EMBEDDING_CONCURRENCY"},
+		{"negative interval", []string{"--checkpoint-interval", "-1s"}, nil, "invalid checkpoint interval"},
+		{"non-duration env interval", nil, map[string]string{"UPLOAD_CHECKPOINT_INTERVAL": "often"}, "invalid UPLOAD_CHECKPOINT_INTERVAL"},
+		{"unknown env field weight", nil, map[string]string{"EMBED_FIELD_WEIGHTS": "Rating:2"}, "invalid EMBED_FIELD_WEIGHTS"},
+		{"weight out of range", []string{"--field-weights", "Tags:9"}, nil, "must be an integer from 0 to 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out); err == nil {
+				t.Fatal("parseOptions accepted invalid settings")
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestParseOptionsDataFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantFile   string
+		wantNotice bool
+	}{
+		{"without vectors", nil, map[string]string{"DATA_FILE_WITHOUT_VECTORS": "plain.json"}, "plain.json", false},
+		{"with vectors", nil, map[string]string{"DATA_FILE_WITH_VECTORS": "vectors.json"}, "vectors.json", false},
+		{"both env vars", nil, map[string]string{"DATA_FILE_WITHOUT_VECTORS": "plain.json", "DATA_FILE_WITH_VECTORS": "vectors.json"}, "vectors.json", true},
+		{"data flag and vectors env", []string{"--data", "plain.json"}, map[string]string{"DATA_FILE_WITH_VECTORS": "vectors.json"}, "vectors.json", true},
+		{"vectors flag", []string{"--data-with-vectors", "flag.json"}, nil, "flag.json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts, err := parseOptions(tt.args, func(key string) string { return tt.env[key] }, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := opts.DataFile
+			if opts.Precomputed() {
+				got = opts.VectorsFile
+			}
+			if got != tt.wantFile {
+				t.Errorf("data file = %q, want %q", got, tt.wantFile)
+			}
+			if got := strings.Contains(out.String(), "Notice: both data files are set"); got != tt.wantNotice {
+				t.Errorf("notice printed = %v, want %v:\n%s", got, tt.wantNotice, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// checkStatus is the outcome of one check
+type checkStatus int
+
+const (
+	statusPass checkStatus = iota
+	statusFail
+	statusSkip
+)
+
+func (s checkStatus) symbol() string {
+	switch s {
+	case statusPass:
+		return "✔"
+	case statusFail:
+		return "✖"
+	default:
+		return "-"
+	}
+}
+
+// checkResult reports what a check found and, on failure, how to fix it
+type checkResult struct {
+	status      checkStatus
+	detail      string
+	remediation string
+}
+
+func passed(format string, args ...any) checkResult {
+	return checkResult{status: statusPass, detail: fmt.Sprintf(format, args...)}
+}
+
+func failed(detail, remediation string) checkResult {
+	return checkResult{status: statusFail, detail: detail, remediation: remediation}
+}
+
+func skipped(reason string) checkResult {
+	return checkResult{status: statusSkip, detail: "skipped: " + reason}
+}
+
+// check is one independent verification step
+type check struct {
+	name string
+	run  func(ctx context.Context) checkResult
+}
+
+// runChecks runs every check in order, even after failures, and prints a report to w.
+// It returns true only if no check failed or was skipped.
+func runChecks(ctx context.Context, checks []check, perCheckTimeout time.Duration, w io.Writer) bool {
+	results := make([]checkResult, len(checks))
+	for i, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, perCheckTimeout)
+		results[i] = c.run(checkCtx)
+		cancel()
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tCHECK\tDETAIL")
+	ok := true
+	for i, c := range checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", results[i].status.symbol(), c.name, results[i].detail)
+		if results[i].status != statusPass {
+			ok = false
+		}
+	}
+	tw.Flush()
+
+	if ok {
+		fmt.Fprintln(w, "\nAll checks passed.")
+		return true
+	}
+
+	fmt.Fprintln(w, "\nHow to fix:")
+	for i, c := range checks {
+		if results[i].remediation != "" {
+			fmt.Fprintf(w, "✖ %s: %s\n", c.name, results[i].remediation)
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func TestRunChecksReportsEveryCheck(t *testing.T) {
+	var order []string
+	record := func(name string, result checkResult) check {
+		return check{name, func(ctx context.Context) checkResult {
+			order = append(order, name)
+			return result
+		}}
+	}
+
+	var out bytes.Buffer
+	ok := runChecks(context.Background(), []check{
+		record("Environment variables", passed("%d required variables set", 7)),
+		record("DocumentDB connection", failed("auth failed", "Run `az login`.")),
+		record("Collection", skipped("no DocumentDB connection")),
+		record("OpenAI endpoint", passed("reachable")),
+	}, time.Second, &out)
+
+	if ok {
+		t.Error("runChecks reported success with a failed check")
+	}
+	if got := strings.Join(order, ","); got != "Environment variables,DocumentDB connection,Collection,OpenAI endpoint" {
+		t.Errorf("checks ran in order %s, want all in order", got)
+	}
+	for _, want := range []string{
+		"✔  Environment variables  7 required variables set",
+		"✖  DocumentDB connection  auth failed",
+		"-  Collection             skipped: no DocumentDB connection",
+		"How to fix:\n✖ DocumentDB connection: Run `az login`.",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunChecksAllPassed(t *testing.T) {
+	var out bytes.Buffer
+	ok := runChecks(context.Background(), []check{
+		{"Environment variables", func(ctx context.Context) checkResult { return passed("ok") }},
+	}, time.Second, &out)
+
+	if !ok || !strings.Contains(out.String(), "All checks passed.") || strings.Contains(out.String(), "How to fix") {
+		t.Errorf("ok = %v, report:\n%s", ok, out.String())
+	}
+}
+
+func TestRunChecksAppliesPerCheckTimeout(t *testing.T) {
+	var out bytes.Buffer
+	slow := check{"Slow", func(ctx context.Context) checkResult {
+		<-ctx.Done()
+		return failed(ctx.Err().Error(), "Check your network.")
+	}}
+	next := check{"Next", func(ctx context.Context) checkResult { return passed("ran") }}
+
+	start := time.Now()
+	runChecks(context.Background(), []check{slow, next}, 20*time.Millisecond, &out)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runChecks took %v, want the slow check cut off", elapsed)
+	}
+	if !strings.Contains(out.String(), "context deadline exceeded") || !strings.Contains(out.String(), "ran") {
+		t.Errorf("report:\n%s", out.String())
+	}
+}
+
+func TestCheckEnv(t *testing.T) {
+	for _, name := range []string{"AZURE_OPENAI_ENDPOINT", "AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "AZURE_OPENAI_PLANNER_DEPLOYMENT", "AZURE_OPENAI_SYNTH_DEPLOYMENT", "AZURE_DOCUMENTDB_DATABASENAME", "AZURE_DOCUMENTDB_COLLECTION", "AZURE_DOCUMENTDB_INDEX_NAME"} {
+		t.Setenv(name, "set")
+	}
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://my-openai.openai.azure.com/")
+	t.Setenv("EMBEDDING_DIMENSIONS", "1536")
+	t.Setenv("USE_PASSWORDLESS", "false")
+	t.Setenv("AZURE_OPENAI_SYNTH_DEPLOYMENT", "")
+	t.Setenv("AZURE_DOCUMENTDB_CLUSTER", "")
+	t.Setenv("AZURE_DOCUMENTDB_CONNECTION_STRING", "")
+
+	v := &verifier{vsConfig: &vectorstore.VectorStoreConfig{}}
+	result := v.checkEnv(context.Background())
+	if result.status != statusFail || !strings.Contains(result.detail, "AZURE_OPENAI_SYNTH_DEPLOYMENT is required") ||
+		!strings.Contains(result.detail, "AZURE_DOCUMENTDB_CONNECTION_STRING is required") || strings.Contains(result.detail, "AZURE_OPENAI_ENDPOINT") {
+		t.Errorf("checkEnv = %+v", result)
+	}
+}
+
+func TestChecksSkipWithoutConnection(t *testing.T) {
+	v := &verifier{vsConfig: &vectorstore.VectorStoreConfig{}, openaiConfig: &clients.OpenAIConfig{}}
+	for _, c := range []func(context.Context) checkResult{v.checkCollection, v.checkIndex, v.checkEndpoint, v.checkEmbeddingDeployment, v.checkChatDeployment("", "AZURE_OPENAI_PLANNER_DEPLOYMENT")} {
+		if result := c(context.Background()); result.status != statusSkip {
+			t.Errorf("check = %+v, want skipped", result)
+		}
+	}
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	v := &verifier{openaiConfig: &clients.OpenAIConfig{Endpoint: server.URL}}
+	if result := v.checkEndpoint(context.Background()); result.status != statusPass || !strings.Contains(result.detail, "HTTP 401") {
+		t.Errorf("reachable endpoint = %+v, want a pass with the status", result)
+	}
+
+	server.Close()
+	if result := v.checkEndpoint(context.Background()); result.status != statusFail || result.remediation == "" {
+		t.Errorf("unreachable endpoint = %+v, want a failure with remediation", result)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func main() {
+	cli.Exit(run())
+}
+
+// run checks the configuration, database, and model deployments in order
+func run() (err error) {
+	// --version and the version subcommand print build information and exit
+	if cli.HandleVersion(os.Args[1:], os.Stdout) {
+		return nil
+	}
+
+	// Load .env and the azd environment, without overriding the process environment
+	cli.LoadEnv()
+
+	var verbosity cli.Verbosity
+	var configFile string
+	cli.AddVerbosityFlags(flag.CommandLine, &verbosity)
+	cli.AddConfigFlag(flag.CommandLine, &configFile)
+	cli.AddVersionFlag(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: verify [flags]\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Checks the configuration, DocumentDB, and the Azure OpenAI deployments.\n\n")
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), cli.ExitCodesHelp)
+	}
+	flag.Parse()
+
+	if _, err := cli.SetupLogging(verbosity, os.Getenv, slog.LevelWarn); err != nil {
+		return err
+	}
+
+	cfg, err := cli.LoadConfig(configFile, config.Requirements{})
+	if err != nil {
+		return err
+	}
+
+	v := &verifier{
+		openaiConfig: cfg.OpenAI,
+		vsConfig:     cfg.VectorStore,
+		dimensions:   vectorstore.EmbeddingDimensionsFromEnv(),
+	}
+
+	// Ctrl+C or SIGTERM cancels the run; RUN_TIMEOUT bounds it
+	ctx, stop := cli.RunContext(os.Stderr, cfg.Timeouts.Budget(timeouts.StageRun))
+	defer stop(&err)
+	defer v.close(ctx)
+
+	fmt.Println("Verifying environment and infrastructure...")
+	fmt.Println()
+
+	if !runChecks(ctx, v.checks(), 30*time.Second, os.Stdout) {
+		return errors.New("one or more checks failed")
+	}
+	return nil
+}
+
+// verifier holds the configuration and the clients created by earlier checks
+type verifier struct {
+	openaiConfig *clients.OpenAIConfig
+	vsConfig     *vectorstore.VectorStoreConfig
+	dimensions   int
+
+	store         *vectorstore.VectorStore
+	openaiClients *clients.OpenAIClients
+}
+
+// checks returns the checks in the order they run
+func (v *verifier) checks() []check {
+	return []check{
+		{"Environment variables", v.checkEnv},
+		{"DocumentDB connection", v.checkMongo},
+		{"Collection", v.checkCollection},
+		{"Vector index", v.checkIndex},
+		{"OpenAI endpoint", v.checkEndpoint},
+		{"Embedding deployment", v.checkEmbeddingDeployment},
+		{"Planner deployment", v.checkChatDeployment(v.openaiConfig.PlannerDeployment, "AZURE_OPENAI_PLANNER_DEPLOYMENT")},
+		{"Synthesizer deployment", v.checkChatDeployment(v.openaiConfig.SynthDeployment, "AZURE_OPENAI_SYNTH_DEPLOYMENT")},
+	}
+}
+
+func (v *verifier) close(ctx context.Context) {
+	if v.store != nil {
+		v.store.Close(ctx)
+	}
+}
+
+// checkEnv reports every missing or malformed variable for the selected authentication methods
+func (v *verifier) checkEnv(ctx context.Context) checkResult {
+	err := config.ValidateEnvironment(os.Getenv, config.Requirements{Embedding: true, Chat: true, DocumentDB: true, VectorIndex: true})
+	var envErr *config.EnvironmentError
+	if errors.As(err, &envErr) {
+		problems := make([]string, 0, len(envErr.Problems))
+		for _, p := range envErr.Problems {
+			problems = append(problems, p.String())
+		}
+		return failed(strings.Join(problems, "; "), "Set the variables in .env (see the Installation section of the README), or run `azd env get-values > .env` after `azd up`.")
+	}
+	return passed("required variables set")
+}
+
+// checkMongo connects to DocumentDB and verifies authentication with a ping
+func (v *verifier) checkMongo(ctx context.Context) checkResult {
+	store, err := vectorstore.NewVectorStore(ctx, v.vsConfig)
+	if err != nil {
+		remediation := "Check AZURE_DOCUMENTDB_CONNECTION_STRING, and that your client IP is allowed by the cluster firewall."
+		if v.vsConfig.UsePasswordless || (v.vsConfig.ConnectionString == "" && v.vsConfig.ClusterName != "") {
+			remediation = "Run `az login`, check AZURE_DOCUMENTDB_CLUSTER, and make sure your identity has been added as a DocumentDB user with read/write access."
+		}
+		return failed(err.Error(), remediation)
+	}
+
+	v.store = store
+	return passed("connected to database %s", v.vsConfig.DatabaseName)
+}
+
+// checkCollection verifies the collection exists and holds documents
+func (v *verifier) checkCollection(ctx context.Context) checkResult {
+	if v.store == nil {
+		return skipped("no DocumentDB connection")
+	}
+
+	exists, err := v.store.CollectionExists(ctx)
+	if err != nil {
+		return failed(err.Error(), "Make sure your identity or user has read access to the database.")
+	}
+	if !exists {
+		return failed(fmt.Sprintf("collection %s not found in %s", v.vsConfig.CollectionName, v.vsConfig.DatabaseName), "Run `go run ./cmd/upload` to load the hotel data.")
+	}
+
+	count, err := v.store.CountDocuments(ctx)
+	if err != nil {
+		return failed(err.Error(), "Make sure your identity or user has read access to the collection.")
+	}
+	if count == 0 {
+		return failed(fmt.Sprintf("collection %s is empty", v.vsConfig.CollectionName), "Run `go run ./cmd/upload` to load the hotel data.")
+	}
+
+	return passed("%s has %d documents", v.vsConfig.CollectionName, count)
+}
+
+// checkIndex verifies the vector index exists and matches EMBEDDING_DIMENSIONS
+func (v *verifier) checkIndex(ctx context.Context) checkResult {
+	if v.store == nil {
+		return skipped("no DocumentDB connection")
+	}
+
+	index, err := v.store.FindVectorIndex(ctx)
+	if err != nil {
+		return failed(err.Error(), "Make sure your identity or user can list indexes on the collection.")
+	}
+	if index == nil {
+		return failed(fmt.Sprintf("no vector index on field %s", v.vsConfig.EmbeddedField), "Run `go run ./cmd/upload`, which creates the vector index after inserting documents.")
+	}
+	if index.Dimensions != v.dimensions {
+		return failed(
+			fmt.Sprintf("index %s has %d dimensions, EMBEDDING_DIMENSIONS is %d", index.Name, index.Dimensions, v.dimensions),
+			"Set EMBEDDING_DIMENSIONS to match your embedding model, then run `go run ./cmd/cleanup` and `go run ./cmd/upload` to rebuild the index.",
+		)
+	}
+
+	return passed("%s (%s, %s, %d dimensions)", index.Name, index.Kind, index.Similarity, index.Dimensions)
+}
+
+// checkEndpoint verifies the OpenAI endpoint resolves and answers HTTP requests
+func (v *verifier) checkEndpoint(ctx context.Context) checkResult {
+	if v.openaiConfig.Endpoint == "" {
+		return skipped("AZURE_OPENAI_ENDPOINT is not set")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.openaiConfig.Endpoint, nil)
+	if err != nil {
+		return failed(err.Error(), "AZURE_OPENAI_ENDPOINT should look like https://your-openai-instance.openai.azure.com/")
+	}
+
+	// Any HTTP response, even 401 or 404, proves the endpoint is reachable
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return failed(err.Error(), "Check AZURE_OPENAI_ENDPOINT and that your network allows access to the Azure OpenAI resource.")
+	}
+	resp.Body.Close()
+
+	return passed("%s responded with HTTP %d", v.openaiConfig.Endpoint, resp.StatusCode)
+}
+
+// clients creates the OpenAI clients on first use
+func (v *verifier) clients() (*clients.OpenAIClients, error) {
+	if v.openaiClients != nil {
+		return v.openaiClients, nil
+	}
+	openaiClients, err := clients.NewOpenAIClients(v.openaiConfig)
+	if err != nil {
+		return nil, err
+	}
+	v.openaiClients = openaiClients
+	return openaiClients, nil
+}
+
+// checkEmbeddingDeployment generates one embedding and compares its size with EMBEDDING_DIMENSIONS
+func (v *verifier) checkEmbeddingDeployment(ctx context.Context) checkResult {
+	if v.openaiConfig.EmbeddingDeployment == "" {
+		return skipped("AZURE_OPENAI_EMBEDDING_DEPLOYMENT is not set")
+	}
+
+	openaiClients, err := v.clients()
+	if err != nil {
+		return failed(err.Error(), "Check AZURE_OPENAI_ENDPOINT and your credentials (`az login` or AZURE_OPENAI_API_KEY).")
+	}
+
+	embedding, err := openaiClients.GenerateEmbedding(ctx, "verify")
+	if err != nil {
+		return failed(err.Error(), deploymentRemediation("AZURE_OPENAI_EMBEDDING_DEPLOYMENT"))
+	}
+	if len(embedding) != v.dimensions {
+		return failed(
+			fmt.Sprintf("%s returned %d dimensions, EMBEDDING_DIMENSIONS is %d", v.openaiConfig.EmbeddingDeployment, len(embedding), v.dimensions),
+			"Set EMBEDDING_DIMENSIONS to the size returned by your embedding model.",
+		)
+	}
+
+	return passed("%s returned %d dimensions", v.openaiConfig.EmbeddingDeployment, len(embedding))
+}
+
+// checkChatDeployment returns a check that sends a one-token completion to a chat deployment
+func (v *verifier) checkChatDeployment(name, envName string) func(ctx context.Context) checkResult {
+	return func(ctx context.Context) checkResult {
+		if name == "" {
+			return skipped(envName + " is not set")
+		}
+
+		openaiClients, err := v.clients()
+		if err != nil {
+			return failed(err.Error(), "Check AZURE_OPENAI_ENDPOINT and your credentials (`az login` or AZURE_OPENAI_API_KEY).")
+		}
+
+		if err := openaiClients.PingChatDeployment(ctx, name); err != nil {
+			return failed(err.Error(), deploymentRemediation(envName))
+		}
+		return passed("%s responded", name)
+	}
+}
+
+// deploymentRemediation explains the usual causes of a failing deployment call
+func deploymentRemediation(envName string) string {
+	return fmt.Sprintf("Check that %s matches a deployment name in your Azure OpenAI resource, and that your identity has the Cognitive Services OpenAI User role.", envName)
+}
@@ -2,32 +2,160 @@ package agents
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
 	"github.com/openai/openai-go/v3"
 )
 
+// PlannerConfig holds planner agent settings. The tags describe the environment
+// variable of each field; see config.Variables.
+type PlannerConfig struct {
+	// Fallback runs the vector search directly with the raw user query when the planner model is unavailable
+	Fallback bool `env:"PLANNER_FALLBACK" for:"chat" format:"bool" default:"true" desc:"Search with the raw request when the planner model fails, instead of failing the run"`
+	// SearchConcurrency bounds how many sub-query searches run at once
+	SearchConcurrency int `env:"SEARCH_CONCURRENCY" for:"chat" format:"positive-int" default:"3" desc:"Largest number of the planner's searches that run at once"`
+	// RoomSearch offers the planner the search_rooms tool, for stores with a rooms collection
+	RoomSearch bool `env:"ROOM_SEARCH" for:"chat" format:"bool" default:"false" desc:"Offer the planner a search of ROOMS_COLLECTION, for data uploaded with EMBED_ROOMS"`
+}
+
+// LoadPlannerConfigFromEnv loads planner settings from environment variables, with
+// defaults for anything unset or invalid
+func LoadPlannerConfigFromEnv() *PlannerConfig {
+	fallback, err := strconv.ParseBool(os.Getenv("PLANNER_FALLBACK"))
+	if err != nil {
+		fallback = true
+	}
+	roomSearch, _ := strconv.ParseBool(os.Getenv("ROOM_SEARCH"))
+
+	searchConcurrency := 3
+	if scStr := os.Getenv("SEARCH_CONCURRENCY"); scStr != "" {
+		if sc, err := strconv.Atoi(scStr); err == nil && sc > 0 {
+			searchConcurrency = sc
+		}
+	}
+
+	return &PlannerConfig{
+		Fallback:          fallback,
+		SearchConcurrency: searchConcurrency,
+		RoomSearch:        roomSearch,
+	}
+}
+
 // PlannerAgent orchestrates the tool calling
 type PlannerAgent struct {
-	openAIClients *clients.OpenAIClients
-	searchTool    *VectorSearchTool
-	debug         bool
+	progress
+	chat       ChatModel
+	searchTool *VectorSearchTool
+	roomTool   *RoomSearchTool
+	reranker   Reranker
+	config     *PlannerConfig
+	timeouts   timeouts.Config
 }
 
 // NewPlannerAgent creates a new planner agent
-func NewPlannerAgent(openaiClients *clients.OpenAIClients, searchTool *VectorSearchTool, debug bool) *PlannerAgent {
+func NewPlannerAgent(chat ChatModel, searchTool *VectorSearchTool, config *PlannerConfig, budgets timeouts.Config) *PlannerAgent {
 	return &PlannerAgent{
-		openAIClients: openaiClients,
-		searchTool:    searchTool,
-		debug:         debug,
+		chat:       chat,
+		searchTool: searchTool,
+		config:     config,
+		timeouts:   budgets,
+	}
+}
+
+// SetRoomTool offers the planner the room search tool alongside the hotel search
+func (a *PlannerAgent) SetRoomTool(tool *RoomSearchTool) {
+	a.roomTool = tool
+}
+
+// SetOutput sets the destination for the planner's progress output
+func (a *PlannerAgent) SetOutput(w io.Writer) {
+	a.progress.SetOutput(w)
+}
+
+// Name returns the pipeline stage name
+func (a *PlannerAgent) Name() string {
+	return StagePlanner
+}
+
+// Run executes the planner agent workflow, storing the search results and the
+// formatted hotel context in the pipeline state. The hotels of matched rooms follow the
+// hotel results in state.Results, so they can be cited.
+func (a *PlannerAgent) Run(ctx context.Context, state *PipelineState) error {
+	outcome, err := a.search(ctx, state.Query, state.NearestNeighbors)
+	state.SearchQuery = outcome.query
+	state.PlanningBypassed = outcome.bypassed
+
+	if errors.Is(err, ErrNoResults) {
+		// An empty result is a valid outcome; the synthesizer answers it deterministically
+		a.println("No matching hotels found")
+		state.Results = nil
+		state.Rooms = nil
+		state.Context = ""
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	outcome.results = a.rerank(ctx, state.Query, outcome.results)
+	state.Results = append(outcome.results, roomHotels(outcome.rooms, outcome.results)...)
+	models.RankResults(state.Results)
+	state.Rooms = outcome.rooms
+
+	done := trace.Start(ctx, trace.EventContextAssembly)
+	var sections []string
+	if len(outcome.results) > 0 {
+		sections = append(sections, a.searchTool.Format(state.Results[:len(outcome.results)]))
+	}
+	if len(outcome.rooms) > 0 {
+		sections = append(sections, FormatRoomResults(outcome.rooms))
+	}
+	state.Context = strings.Join(sections, "\n\n")
+	done(nil)
+
+	logging.Trace(ctx, "hotel context", "context", state.Context)
+
+	return nil
+}
+
+// Search executes the planner workflow and returns the structured search results
+func (a *PlannerAgent) Search(ctx context.Context, userQuery string, nearestNeighbors int) ([]models.HotelSearchResult, error) {
+	outcome, err := a.search(ctx, userQuery, nearestNeighbors)
+	if err != nil {
+		return outcome.results, err
 	}
+	return a.rerank(ctx, userQuery, outcome.results), nil
 }
 
-// Run executes the planner agent workflow
-func (a *PlannerAgent) Run(ctx context.Context, userQuery string, nearestNeighbors int) (string, error) {
-	fmt.Println("\n--- PLANNER ---")
+// searchOutcome describes what the planner searched for and what it found
+type searchOutcome struct {
+	query    string
+	bypassed bool
+	results  []models.HotelSearchResult
+	// rooms holds the room search results, grouped by hotel, when the planner searched rooms
+	rooms []models.HotelRooms
+}
+
+// maxToolCallRepairs is how many times the planner is asked again after its tool call
+// breaks the tool's schema
+const maxToolCallRepairs = 1
+
+// search asks the planner model for a tool call and executes it
+func (a *PlannerAgent) search(ctx context.Context, userQuery string, nearestNeighbors int) (searchOutcome, error) {
+	a.println("\n--- PLANNER ---")
 
 	userMessage := fmt.Sprintf(
 		`Search for hotels matching this request: "%s". Use nearestNeighbors=%d.`,
@@ -35,75 +163,433 @@ func (a *PlannerAgent) Run(ctx context.Context, userQuery string, nearestNeighbo
 		nearestNeighbors,
 	)
 
-	// Get tool definition
-	toolDef := a.searchTool.GetToolDefinition()
+	// Get tool definitions
+	tools := []openai.ChatCompletionToolUnionParam{a.searchTool.GetToolDefinition()}
+	if a.roomTool != nil {
+		tools = append(tools, a.roomTool.GetToolDefinition())
+	}
+
+	// Call the planner, asking it to repair a tool call whose arguments break the schema
+	var calls []*toolArguments
+	for attempt := 0; ; attempt++ {
+		var resp *openai.ChatCompletion
+		err := runStage(ctx, a.timeouts.Budget(StagePlanner), func(ctx context.Context) error {
+			var err error
+			resp, err = a.chat.ChatCompletionWithTools(ctx, prompts.PlannerSystemPrompt, userMessage, tools)
+			if err != nil {
+				return fmt.Errorf("planner failed: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			if a.config.Fallback && clients.IsModelUnavailable(err) {
+				return a.fallbackSearch(ctx, userQuery, nearestNeighbors, err)
+			}
+			return searchOutcome{}, err
+		}
 
-	// Call planner with tool definitions
-	resp, err := a.openAIClients.ChatCompletionWithTools(ctx, prompts.PlannerSystemPrompt, userMessage, []openai.ChatCompletionToolUnionParam{toolDef})
-	if err != nil {
-		return "", fmt.Errorf("planner failed: %w", err)
+		calls, err = a.readToolCalls(ctx, resp)
+		var argsErr *ToolArgumentsError
+		if errors.As(err, &argsErr) && attempt < maxToolCallRepairs {
+			slog.WarnContext(ctx, "planner tool call broke the schema, asking for a repair", "tool", argsErr.Tool, "err", err)
+			trace.FromContext(ctx).Annotate(StagePlanner, fmt.Sprintf("repairing tool call: %v", err))
+			userMessage = prompts.RepairToolCallMessage(userMessage, argsErr.Error())
+			continue
+		}
+		if err != nil {
+			return searchOutcome{}, err
+		}
+		break
+	}
+
+	var subQueries, roomQueries []*toolArguments
+	for _, args := range calls {
+		slog.DebugContext(ctx, "planner selected tool", "tool", args.tool, "query", args.Query, "k", args.NearestNeighbors)
+
+		a.printf("Tool: %s\n", args.tool)
+		a.printf("Query: %s\n", args.Query)
+		a.printf("K: %d\n", args.NearestNeighbors)
+		if args.Language != "" {
+			a.printf("Language: %s\n", args.Language)
+		}
+
+		if args.tool == prompts.RoomsToolName {
+			roomQueries = append(roomQueries, args)
+		} else {
+			subQueries = append(subQueries, args)
+		}
+	}
+
+	if len(roomQueries) > 0 {
+		return a.searchRooms(ctx, subQueries, roomQueries, nearestNeighbors)
 	}
+	return a.searchHotels(ctx, subQueries, nearestNeighbors)
+}
 
-	// Extract tool call
-	toolName, argsMap, err := clients.ExtractToolCall(resp)
+// readToolCalls extracts the planner's tool calls and validates their arguments.
+// Arguments that break a tool's schema give a *ToolArgumentsError.
+func (a *PlannerAgent) readToolCalls(ctx context.Context, resp *openai.ChatCompletion) ([]*toolArguments, error) {
+	// Several calls are sub-queries of a decomposed request
+	toolCalls, err := clients.ExtractToolCalls(resp)
 	if err != nil {
-		return "", fmt.Errorf("failed to extract tool call: %w", err)
+		return nil, fmt.Errorf("failed to extract tool call: %w", err)
+	}
+
+	calls := make([]*toolArguments, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		schema, ok := schemaFor(toolCall.Name)
+		if !ok || toolCall.Name == prompts.RoomsToolName && a.roomTool == nil {
+			return nil, fmt.Errorf("unexpected tool called: %s", toolCall.Name)
+		}
+
+		args, err := parseToolArgumentsFromMap(ctx, schema, toolCall.Args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tool arguments: %w", err)
+		}
+		args.tool = toolCall.Name
+		calls = append(calls, args)
 	}
+	return calls, nil
+}
+
+// searchHotels runs the hotel search tool calls, fusing the results of several sub-queries
+func (a *PlannerAgent) searchHotels(ctx context.Context, subQueries []*toolArguments, nearestNeighbors int) (searchOutcome, error) {
+	// Execute the tool
+	if len(subQueries) == 1 {
+		results, err := a.executeTool(ctx, subQueries[0].Query, subQueries[0].Language, subQueries[0].NearestNeighbors)
+		a.printResults(results)
+		return searchOutcome{query: subQueries[0].Query, results: results}, err
+	}
+
+	queries := make([]string, len(subQueries))
+	for i, subQuery := range subQueries {
+		queries[i] = subQuery.Query
+	}
+
+	results, err := fuseSubQueryResults(ctx, a.executeSubQueries(ctx, subQueries), nearestNeighbors)
+	a.printResults(results)
+	return searchOutcome{query: strings.Join(queries, " | "), results: results}, err
+}
 
-	if toolName != prompts.ToolName {
-		return "", fmt.Errorf("unexpected tool called: %s", toolName)
+// searchRooms runs the room search tool calls, and the hotel search calls the planner
+// made alongside them. Rooms found by several queries are kept once, with their best
+// score. It returns ErrNoResults only when neither search matched anything.
+func (a *PlannerAgent) searchRooms(ctx context.Context, subQueries, roomQueries []*toolArguments, nearestNeighbors int) (searchOutcome, error) {
+	var outcome searchOutcome
+	var queries []string
+	if len(subQueries) > 0 {
+		var err error
+		outcome, err = a.searchHotels(ctx, subQueries, nearestNeighbors)
+		if err != nil && !errors.Is(err, ErrNoResults) {
+			return outcome, err
+		}
+		queries = append(queries, outcome.query)
 	}
 
-	// Parse arguments using typed struct
-	args, err := parseToolArgumentsFromMap(argsMap)
+	best := make(map[string]models.RoomSearchResult)
+	var order []string
+	for _, roomQuery := range roomQueries {
+		groups, err := a.executeRoomTool(ctx, roomQuery.Query, roomQuery.NearestNeighbors)
+		if errors.Is(err, ErrNoResults) {
+			continue
+		}
+		if err != nil {
+			return outcome, err
+		}
+		for _, group := range groups {
+			for _, room := range group.Rooms {
+				previous, ok := best[room.Room.RoomID]
+				if !ok {
+					order = append(order, room.Room.RoomID)
+				}
+				if !ok || room.Score > previous.Score {
+					best[room.Room.RoomID] = room
+				}
+			}
+		}
+		queries = append(queries, roomQuery.Query)
+	}
+
+	rooms := make([]models.RoomSearchResult, len(order))
+	for i, id := range order {
+		rooms[i] = best[id]
+	}
+	outcome.rooms = models.GroupRoomsByHotel(rooms)
+	outcome.query = strings.Join(queries, " | ")
+	for i, group := range outcome.rooms {
+		a.printf("Hotel rooms #%d: %s, %d rooms, Score: %.6f\n", i+1, group.HotelName, len(group.Rooms), group.Score)
+	}
+
+	if len(outcome.results) == 0 && len(outcome.rooms) == 0 {
+		return outcome, ErrNoResults
+	}
+	return outcome, nil
+}
+
+// executeRoomTool runs the room search tool under the tool stage deadline
+func (a *PlannerAgent) executeRoomTool(ctx context.Context, query string, nearestNeighbors int) (_ []models.HotelRooms, err error) {
+	done := trace.Start(ctx, trace.EventToolExecution)
+	defer func() { done(err) }()
+
+	var groups []models.HotelRooms
+	err = runStage(ctx, a.timeouts.Budget(StageTool), func(ctx context.Context) error {
+		var err error
+		groups, err = a.roomTool.Search(ctx, query, nearestNeighbors)
+		if err != nil {
+			return fmt.Errorf("room search tool execution failed: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to parse tool arguments: %w", err)
+		return nil, err
 	}
 
-	// Use default if nearestNeighbors not provided
-	if args.NearestNeighbors == 0 {
-		args.NearestNeighbors = nearestNeighbors
+	return groups, nil
+}
+
+// printResults prints the ranked hotels returned by the search
+func (a *PlannerAgent) printResults(results []models.HotelSearchResult) {
+	for i, result := range results {
+		a.printf("Hotel #%d: %s, Score: %.6f\n", i+1, result.Hotel.HotelName, result.Score)
 	}
+}
 
-	fmt.Printf("Tool: %s\n", toolName)
-	fmt.Printf("Query: %s\n", args.Query)
-	fmt.Printf("K: %d\n", args.NearestNeighbors)
+// executeTool runs the search tool under the tool stage deadline
+func (a *PlannerAgent) executeTool(ctx context.Context, query, language string, nearestNeighbors int) (_ []models.HotelSearchResult, err error) {
+	done := trace.Start(ctx, trace.EventToolExecution)
+	defer func() { done(err) }()
 
-	// Execute the tool
-	searchResults, err := a.searchTool.Execute(ctx, args.Query, args.NearestNeighbors)
+	var searchResults []models.HotelSearchResult
+	err = runStage(ctx, a.timeouts.Budget(StageTool), func(ctx context.Context) error {
+		var err error
+		searchResults, err = a.searchTool.SearchLanguage(ctx, query, language, nearestNeighbors)
+		if err != nil {
+			return fmt.Errorf("search tool execution failed: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("search tool execution failed: %w", err)
+		return nil, err
 	}
 
 	return searchResults, nil
 }
 
+// fallbackSearch bypasses planning and searches with the raw user query when the planner model is unavailable
+func (a *PlannerAgent) fallbackSearch(ctx context.Context, userQuery string, nearestNeighbors int, plannerErr error) (searchOutcome, error) {
+	slog.WarnContext(ctx, "planner model unavailable, bypassing planning", "status", clients.StatusCode(plannerErr), "err", plannerErr)
+	trace.FromContext(ctx).Annotate(StagePlanner, fmt.Sprintf("planning bypassed: planner model unavailable (HTTP %d)", clients.StatusCode(plannerErr)))
+
+	a.printf("Planning bypassed: planner model unavailable (HTTP %d)\n", clients.StatusCode(plannerErr))
+	a.printf("Tool: %s\n", prompts.ToolName)
+	a.printf("Query: %s\n", userQuery)
+	a.printf("K: %d\n", nearestNeighbors)
+
+	results, err := a.executeTool(ctx, userQuery, "", nearestNeighbors)
+	a.printResults(results)
+	return searchOutcome{query: userQuery, bypassed: true, results: results}, err
+}
+
+// SynthesizerConfig holds the settings rendered into the synthesizer prompts. The tags
+// describe the environment variable of each field; see config.Variables.
+type SynthesizerConfig struct {
+	TopN     int    `env:"SYNTH_TOP_N" for:"chat" format:"positive-int" default:"3" desc:"Number of hotels the synthesizer compares"`
+	MaxWords int    `env:"SYNTH_MAX_WORDS" for:"chat" format:"positive-int" default:"220" desc:"Word limit of the answer"`
+	Language string `env:"SYNTH_LANGUAGE" for:"chat" default:"English" example:"French" desc:"Language of the answer"`
+	// ContextFormat, ContextFields, and ContextExclude choose how the hotels in the
+	// context the synthesizer reads are rendered; see Formatter
+	ContextFormat  string   `env:"SYNTH_CONTEXT_FORMAT" for:"chat" format:"oneof:text,json" default:"text" desc:"Format of the hotels in the synthesizer's context: text or json"`
+	ContextFields  []string `env:"SYNTH_CONTEXT_FIELDS" for:"chat" example:"HotelName,Rating,Description" desc:"Comma-separated display fields to show the synthesizer, in order; unset shows every field"`
+	ContextExclude []string `env:"SYNTH_CONTEXT_EXCLUDE" for:"chat" example:"IsDeleted,Address.PostalCode" desc:"Comma-separated display fields to leave out of the synthesizer's context"`
+}
+
+// Formatter returns the formatter that renders the hotels in the synthesizer's context
+func (c *SynthesizerConfig) Formatter() vectorstore.Formatter {
+	return vectorstore.Formatter{Fields: c.ContextFields, Exclude: c.ContextExclude, Style: c.ContextFormat}
+}
+
+// LoadSynthesizerConfigFromEnv loads synthesizer settings from environment variables
+func LoadSynthesizerConfigFromEnv() *SynthesizerConfig {
+	config := &SynthesizerConfig{
+		TopN:          prompts.DefaultTopN,
+		MaxWords:      prompts.DefaultMaxWords,
+		Language:      prompts.DefaultLanguage,
+		ContextFormat: vectorstore.StyleText,
+	}
+
+	if topNStr := os.Getenv("SYNTH_TOP_N"); topNStr != "" {
+		if topN, err := strconv.Atoi(topNStr); err == nil && topN > 0 {
+			config.TopN = topN
+		}
+	}
+	if maxWordsStr := os.Getenv("SYNTH_MAX_WORDS"); maxWordsStr != "" {
+		if maxWords, err := strconv.Atoi(maxWordsStr); err == nil && maxWords > 0 {
+			config.MaxWords = maxWords
+		}
+	}
+	if language := os.Getenv("SYNTH_LANGUAGE"); language != "" {
+		config.Language = language
+	}
+	if style, ok := vectorstore.ParseFormatterStyle(os.Getenv("SYNTH_CONTEXT_FORMAT")); ok {
+		config.ContextFormat = style
+	}
+	config.ContextFields = fieldList(os.Getenv("SYNTH_CONTEXT_FIELDS"))
+	config.ContextExclude = fieldList(os.Getenv("SYNTH_CONTEXT_EXCLUDE"))
+
+	return config
+}
+
+// fieldList splits a comma-separated list of display field names, or returns nil when
+// it names none
+func fieldList(s string) []string {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
 // SynthesizerAgent generates final recommendations
 type SynthesizerAgent struct {
-	openAIClients *clients.OpenAIClients
-	debug         bool
+	progress
+	chat     ChatModel
+	config   *SynthesizerConfig
+	timeouts timeouts.Config
+	stream   io.Writer
 }
 
 // NewSynthesizerAgent creates a new synthesizer agent
-func NewSynthesizerAgent(openaiClients *clients.OpenAIClients, debug bool) *SynthesizerAgent {
+func NewSynthesizerAgent(chat ChatModel, config *SynthesizerConfig, budgets timeouts.Config) *SynthesizerAgent {
 	return &SynthesizerAgent{
-		openAIClients: openaiClients,
-		debug:         debug,
+		chat:     chat,
+		config:   config,
+		timeouts: budgets,
+	}
+}
+
+// SetStreamWriter makes the synthesizer write answer tokens to w as they arrive.
+// A nil writer restores buffered completions.
+func (a *SynthesizerAgent) SetStreamWriter(w io.Writer) {
+	a.stream = w
+}
+
+// complete calls the synthesizer deployment, streaming when a stream writer is set
+func (a *SynthesizerAgent) complete(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	if a.stream != nil {
+		return a.chat.ChatCompletionStream(ctx, systemPrompt, userMessage, a.stream)
+	}
+	return a.chat.ChatCompletion(ctx, systemPrompt, userMessage)
+}
+
+// promptData builds the template data for the synthesizer prompts
+func (a *SynthesizerAgent) promptData(userQuery, hotelContext string) prompts.SynthesizerPromptData {
+	return prompts.SynthesizerPromptData{
+		UserQuery:   userQuery,
+		ToolSummary: hotelContext,
+		TopN:        a.config.TopN,
+		MaxWords:    a.config.MaxWords,
+		Language:    a.config.Language,
+	}
+}
+
+// Name returns the pipeline stage name
+func (a *SynthesizerAgent) Name() string {
+	return StageSynthesizer
+}
+
+// Run synthesizes the final answer from the hotel context in the pipeline state
+func (a *SynthesizerAgent) Run(ctx context.Context, state *PipelineState) error {
+	answer, err := a.Synthesize(ctx, state.Query, state.Context)
+	if err != nil {
+		return err
 	}
+
+	state.Answer = answer
+	return nil
 }
 
-// Run executes the synthesizer agent workflow
-func (a *SynthesizerAgent) Run(ctx context.Context, userQuery, hotelContext string) (string, error) {
-	fmt.Println("\n--- SYNTHESIZER ---")
-	fmt.Printf("Context size: %d characters\n", len(hotelContext))
+// Synthesize generates the recommendation for a query and hotel context. With an empty hotel
+// context it returns prompts.NoResultsAnswer without calling the model, so it can't invent recommendations.
+func (a *SynthesizerAgent) Synthesize(ctx context.Context, userQuery, hotelContext string) (string, error) {
+	a.println("\n--- SYNTHESIZER ---")
+	a.printf("Context size: %d characters\n", len(hotelContext))
+
+	if strings.TrimSpace(hotelContext) == "" {
+		slog.DebugContext(ctx, "synthesizer skipped, no hotels in context")
+		return prompts.NoResultsAnswer, nil
+	}
+
+	data := a.promptData(userQuery, hotelContext)
+
+	systemPrompt, err := prompts.RenderSynthesizerSystemPrompt(data)
+	if err != nil {
+		return "", err
+	}
+
+	userMessage, err := prompts.RenderSynthesizerUserPrompt(data)
+	if err != nil {
+		return "", err
+	}
 
-	userMessage := prompts.CreateSynthesizerUserPrompt(userQuery, hotelContext)
+	slog.DebugContext(ctx, "synthesizer started", "contextChars", len(hotelContext), "topN", a.config.TopN)
 
 	// Call synthesizer (no tools)
-	finalAnswer, err := a.openAIClients.ChatCompletion(ctx, prompts.SynthesizerSystemPrompt, userMessage)
+	var finalAnswer string
+	err = runStage(ctx, a.timeouts.Budget(StageSynthesizer), func(ctx context.Context) error {
+		var err error
+		finalAnswer, err = a.complete(ctx, systemPrompt, userMessage)
+		if err != nil {
+			return fmt.Errorf("synthesizer failed: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("synthesizer failed: %w", err)
+		return "", err
 	}
 
 	return finalAnswer, nil
 }
+
+// RunFollowUp answers a follow-up question from previously retrieved hotels without a new search
+func (a *SynthesizerAgent) RunFollowUp(ctx context.Context, question, previousQuery, hotelContext, reference string) (string, error) {
+	a.println("\n--- SYNTHESIZER (follow-up) ---")
+	a.printf("Context size: %d characters\n", len(hotelContext))
+
+	data := prompts.FollowUpPromptData{
+		Question:      question,
+		PreviousQuery: previousQuery,
+		ToolSummary:   hotelContext,
+		Reference:     reference,
+		MaxWords:      a.config.MaxWords,
+		Language:      a.config.Language,
+	}
+
+	systemPrompt, err := prompts.RenderFollowUpSystemPrompt(data)
+	if err != nil {
+		return "", err
+	}
+
+	userMessage, err := prompts.RenderFollowUpUserPrompt(data)
+	if err != nil {
+		return "", err
+	}
+
+	slog.DebugContext(ctx, "synthesizer answering follow-up", "contextChars", len(hotelContext), "reference", reference)
+
+	var answer string
+	err = runStage(ctx, a.timeouts.Budget(StageSynthesizer), func(ctx context.Context) error {
+		var err error
+		answer, err = a.complete(ctx, systemPrompt, userMessage)
+		if err != nil {
+			return fmt.Errorf("synthesizer follow-up failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return answer, nil
+}
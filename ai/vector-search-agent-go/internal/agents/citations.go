@@ -0,0 +1,74 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// StageCitations is the pipeline stage name of the citation agent
+const StageCitations = "citations"
+
+// Citation links the final answer back to a retrieved hotel document
+type Citation struct {
+	HotelID   string  `json:"hotelId"`
+	HotelName string  `json:"hotelName"`
+	Score     float64 `json:"score"`
+	Rank      int     `json:"rank"`
+}
+
+// BuildCitations returns citations for the retrieved hotels the answer actually mentions,
+// in retrieval rank order
+func BuildCitations(answer string, results []models.HotelSearchResult) []Citation {
+	var citations []Citation
+	for i, result := range results {
+		if !MentionsHotel(answer, result.Hotel.HotelName) {
+			continue
+		}
+		citations = append(citations, Citation{
+			HotelID:   result.Hotel.HotelID,
+			HotelName: result.Hotel.HotelName,
+			Score:     result.Score,
+			Rank:      i + 1,
+		})
+	}
+	return citations
+}
+
+// FormatCitations renders citations as a machine-parsable line, e.g.
+// "Sources: [23] Sublime Palace, [41] Royal Cottage"
+func FormatCitations(citations []Citation) string {
+	if len(citations) == 0 {
+		return ""
+	}
+
+	sources := make([]string, len(citations))
+	for i, citation := range citations {
+		sources[i] = fmt.Sprintf("[%s] %s", citation.HotelID, citation.HotelName)
+	}
+	return "Sources: " + strings.Join(sources, ", ")
+}
+
+// CitationAgent appends the hotels referenced by the answer as a sources section
+type CitationAgent struct{}
+
+// NewCitationAgent creates a new citation agent
+func NewCitationAgent() *CitationAgent {
+	return &CitationAgent{}
+}
+
+// Name returns the pipeline stage name
+func (a *CitationAgent) Name() string {
+	return StageCitations
+}
+
+// Run stores the citations in the state and appends them to the answer
+func (a *CitationAgent) Run(ctx context.Context, state *PipelineState) error {
+	state.Citations = BuildCitations(state.Answer, state.Results)
+	if sources := FormatCitations(state.Citations); sources != "" {
+		state.Answer = strings.TrimRight(state.Answer, "\n") + "\n\n" + sources
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package agents
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+func citationResults() []models.HotelSearchResult {
+	names := []string{"Ocean Retreat", "Sublime Palace", "City Inn", "Royal Cottage", "Harbor View"}
+	ids := []string{"7", "23", "12", "41", "3"}
+	scores := []float64{0.91, 0.84, 0.77, 0.63, 0.58}
+	results := make([]models.HotelSearchResult, len(names))
+	for i := range names {
+		results[i] = models.HotelSearchResult{
+			Hotel: models.HotelForVectorStore{HotelID: ids[i], HotelName: names[i]},
+			Score: scores[i],
+		}
+	}
+	return results
+}
+
+func TestBuildCitationsForMentionedHotels(t *testing.T) {
+	answer := "I recommend royal cottage for the quiet garden. As an alternative, the Sublime  Palace has a rooftop pool."
+
+	got := BuildCitations(answer, citationResults())
+	want := []Citation{
+		{HotelID: "23", HotelName: "Sublime Palace", Score: 0.84, Rank: 2},
+		{HotelID: "41", HotelName: "Royal Cottage", Score: 0.63, Rank: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("citations = %+v, want %+v", got, want)
+	}
+	if sources := FormatCitations(got); sources != "Sources: [23] Sublime Palace, [41] Royal Cottage" {
+		t.Errorf("sources = %q", sources)
+	}
+}
+
+func TestMentionsHotel(t *testing.T) {
+	tests := []struct {
+		text, name string
+		want       bool
+	}{
+		{"Stay at the Royal Cottage.", "Royal Cottage", true},
+		{"ROYAL-COTTAGE is best", "Royal Cottage", true},
+		{"the royal cottages nearby", "Royal Cottage", false},
+		{"City Innovation Hub", "City Inn", false},
+		{"anything", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := MentionsHotel(tt.text, tt.name); got != tt.want {
+			t.Errorf("MentionsHotel(%q, %q) = %v, want %v", tt.text, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCitationAgentAppendsSources(t *testing.T) {
+	state := &PipelineState{Answer: "Try Harbor View.\n", Results: citationResults()}
+	if err := NewCitationAgent().Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(state.Answer, "Try Harbor View.\n\nSources: [3] Harbor View") {
+		t.Errorf("answer = %q", state.Answer)
+	}
+
+	state = &PipelineState{Answer: "Nothing fits.", Results: citationResults()}
+	if err := NewCitationAgent().Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Answer != "Nothing fits." || state.Citations != nil {
+		t.Errorf("answer without mentions got sources: %q, %+v", state.Answer, state.Citations)
+	}
+}
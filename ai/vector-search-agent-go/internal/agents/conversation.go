@@ -0,0 +1,243 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Turn records a single question and answer in a conversation
+type Turn struct {
+	SessionID string
+	Question  string
+	Answer    string
+	FollowUp  bool
+	Results   []models.HotelSearchResult
+}
+
+// Conversation keeps the retrieved hotels between turns so follow-up questions
+// can be answered without another embedding or search
+type Conversation struct {
+	planner     *PlannerAgent
+	synthesizer *SynthesizerAgent
+	turns       []Turn
+	lastQuery   string
+	lastResults []models.HotelSearchResult
+}
+
+// NewConversation creates a new conversation over the planner and synthesizer agents
+func NewConversation(planner *PlannerAgent, synthesizer *SynthesizerAgent) *Conversation {
+	return &Conversation{
+		planner:     planner,
+		synthesizer: synthesizer,
+	}
+}
+
+// Ask answers a question, either from the previously retrieved hotels (follow-up)
+// or by running a new search through the planner
+func (c *Conversation) Ask(ctx context.Context, question string, nearestNeighbors int) (string, error) {
+	if IsFollowUp(question, c.lastResults) {
+		return c.answerFollowUp(ctx, question)
+	}
+
+	results, err := c.planner.Search(ctx, question, nearestNeighbors)
+	if errors.Is(err, ErrNoResults) {
+		c.lastQuery = ""
+		c.lastResults = nil
+		c.turns = append(c.turns, Turn{SessionID: session.FromContext(ctx), Question: question, Answer: prompts.NoResultsAnswer})
+		return prompts.NoResultsAnswer, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := c.synthesizer.Synthesize(ctx, question, c.planner.searchTool.Format(results))
+	if err != nil {
+		return "", err
+	}
+
+	c.lastQuery = question
+	c.lastResults = results
+	c.turns = append(c.turns, Turn{SessionID: session.FromContext(ctx), Question: question, Answer: answer, Results: results})
+
+	return answer, nil
+}
+
+// answerFollowUp sends the prior results in their presented order plus the new question to the synthesizer
+func (c *Conversation) answerFollowUp(ctx context.Context, question string) (string, error) {
+	reference := ""
+	if idx, ok := ResolveReference(question, c.lastResults); ok {
+		reference = fmt.Sprintf("Hotel #%d (%s)", idx+1, c.lastResults[idx].Hotel.HotelName)
+	}
+
+	answer, err := c.synthesizer.RunFollowUp(ctx, question, c.lastQuery, formatNumberedResults(c.planner.searchTool.formatter, c.lastResults), reference)
+	if err != nil {
+		return "", err
+	}
+
+	c.turns = append(c.turns, Turn{SessionID: session.FromContext(ctx), Question: question, Answer: answer, FollowUp: true, Results: c.lastResults})
+
+	return answer, nil
+}
+
+// Turns returns the conversation history
+func (c *Conversation) Turns() []Turn {
+	return c.turns
+}
+
+// LastResults returns the hotels retrieved by the most recent search turn
+func (c *Conversation) LastResults() []models.HotelSearchResult {
+	return c.lastResults
+}
+
+// Reset clears the conversation history and the retrieved hotels
+func (c *Conversation) Reset() {
+	c.turns = nil
+	c.lastQuery = ""
+	c.lastResults = nil
+}
+
+// formatNumberedResults formats results with formatter, prefixed with their presented position
+func formatNumberedResults(formatter vectorstore.Formatter, results []models.HotelSearchResult) string {
+	formatted := make([]string, 0, len(results))
+	for i, result := range results {
+		formatted = append(formatted, fmt.Sprintf("Hotel #%d\n%s", i+1, formatter.FormatHotel(result)))
+	}
+	return strings.Join(formatted, "\n\n")
+}
+
+// ordinalWords maps ordinal references to 1-based positions
+var ordinalWords = map[string]int{
+	"first": 1, "1st": 1,
+	"second": 2, "2nd": 2,
+	"third": 3, "3rd": 3,
+	"fourth": 4, "4th": 4,
+	"fifth": 5, "5th": 5,
+	"sixth": 6, "6th": 6,
+	"seventh": 7, "7th": 7,
+	"eighth": 8, "8th": 8,
+	"ninth": 9, "9th": 9,
+	"tenth": 10, "10th": 10,
+}
+
+// anaphoraWords are references that only make sense against previous results
+var anaphoraWords = []string{
+	"it", "its", "they", "them", "those", "these", "that one", "this one",
+	"that hotel", "this hotel", "the one", "which one", "which of", "either", "both",
+}
+
+// newSearchWords indicate the user wants a fresh search
+var newSearchWords = []string{
+	"find", "search", "look for", "looking for", "show me", "recommend",
+	"suggest", "other hotels", "any other", "instead", "something else",
+}
+
+// IsFollowUp classifies a question as a follow-up about the previously retrieved hotels.
+// Without previous results every question is a new search.
+func IsFollowUp(question string, previous []models.HotelSearchResult) bool {
+	if len(previous) == 0 {
+		return false
+	}
+
+	// A new search may mention positions or names ("the last weekend of June",
+	// "near First Avenue"), so its intent wins over any reference
+	text := " " + strings.Join(tokenize(question), " ") + " "
+	for _, phrase := range newSearchWords {
+		if strings.Contains(text, " "+phrase+" ") {
+			return false
+		}
+	}
+
+	if _, ok := ResolveReference(question, previous); ok {
+		return true
+	}
+
+	for _, phrase := range anaphoraWords {
+		if strings.Contains(text, " "+phrase+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveReference resolves references like "the second one", "#2", "the last one",
+// or a hotel name against the previously presented order. It returns a 0-based index.
+func ResolveReference(question string, previous []models.HotelSearchResult) (int, bool) {
+	if len(previous) == 0 {
+		return 0, false
+	}
+
+	for i, result := range previous {
+		if MentionsHotel(question, result.Hotel.HotelName) {
+			return i, true
+		}
+	}
+
+	tokens := tokenize(question)
+	for i, token := range tokens {
+		position := 0
+		switch {
+		case ordinalWords[token] > 0 && isReferenceNoun(tokens, i+1):
+			position = ordinalWords[token]
+		case token == "last" && isReferenceNoun(tokens, i+1):
+			position = len(previous)
+		case (token == "number" || token == "no" || token == "hotel") && i+1 < len(tokens):
+			position, _ = strconv.Atoi(tokens[i+1])
+		}
+		if position >= 1 && position <= len(previous) {
+			return position - 1, true
+		}
+	}
+
+	// "#2" style references
+	if idx := strings.Index(question, "#"); idx >= 0 {
+		digits := strings.TrimLeftFunc(question[idx+1:], unicode.IsSpace)
+		end := strings.IndexFunc(digits, func(r rune) bool { return !unicode.IsDigit(r) })
+		if end < 0 {
+			end = len(digits)
+		}
+		if position, err := strconv.Atoi(digits[:end]); err == nil && position >= 1 && position <= len(previous) {
+			return position - 1, true
+		}
+	}
+
+	return 0, false
+}
+
+// referenceNouns complete an ordinal into a reference to a presented hotel, as in
+// "the second one" or "the last option"
+var referenceNouns = map[string]bool{
+	"one": true, "hotel": true, "option": true, "result": true, "pick": true, "choice": true,
+}
+
+// isReferenceNoun reports whether tokens[i] is a reference noun
+func isReferenceNoun(tokens []string, i int) bool {
+	return i < len(tokens) && referenceNouns[tokens[i]]
+}
+
+// MentionsHotel reports whether text mentions the hotel name, ignoring case, punctuation, and
+// spacing differences. It is the single name-verification check used for follow-up
+// references and citations.
+func MentionsHotel(text, hotelName string) bool {
+	name := strings.Join(tokenize(hotelName), " ")
+	if name == "" {
+		return false
+	}
+	return strings.Contains(" "+strings.Join(tokenize(text), " ")+" ", " "+name+" ")
+}
+
+// tokenize lowercases text and splits it into words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
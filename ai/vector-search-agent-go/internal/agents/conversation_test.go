@@ -0,0 +1,122 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestConversationFollowUpSkipsSearch(t *testing.T) {
+	llm := &fakeLLM{answer: "answer"}
+	store := &fakeSearcher{hotels: sampleResults(3)}
+	conv := NewConversation(newTestAgents(llm, store, timeouts.Default()))
+	ctx := context.Background()
+
+	if _, err := conv.Ask(ctx, "quiet hotel with a pool", 3); err != nil {
+		t.Fatal(err)
+	}
+	if store.searches != 1 || llm.embeddings != 1 {
+		t.Fatalf("first turn: %d searches, %d embeddings, want 1 each", store.searches, llm.embeddings)
+	}
+
+	if _, err := conv.Ask(ctx, "does the second one have parking?", 3); err != nil {
+		t.Fatal(err)
+	}
+	if store.searches != 1 || llm.embeddings != 1 || llm.plans != 1 {
+		t.Errorf("follow-up searched again: %d searches, %d embeddings, %d plans", store.searches, llm.embeddings, llm.plans)
+	}
+
+	turns := conv.Turns()
+	if len(turns) != 2 || turns[0].FollowUp || !turns[1].FollowUp {
+		t.Fatalf("turns = %+v, want a search turn then a follow-up", turns)
+	}
+	followUpPrompt := llm.prompts[len(llm.prompts)-1]
+	if !strings.Contains(followUpPrompt, "Hotel #2 (Hotel 2)") {
+		t.Errorf("follow-up prompt does not name the referenced hotel:\n%s", followUpPrompt)
+	}
+}
+
+func TestIsFollowUp(t *testing.T) {
+	previous := sampleResults(3)
+	tests := []struct {
+		question string
+		want     bool
+	}{
+		{"does the second one have parking?", true},
+		{"what about the last one", true},
+		{"is Hotel 3 near the beach?", true},
+		{"does it have wifi?", true},
+		{"tell me about #2", true},
+		{"find a hotel for the last weekend of June", false},
+		{"search near First Avenue", false},
+		{"find hotels like the second one but cheaper", false},
+		{"luxury hotel with a spa", false},
+		{"cheap hotel first floor rooms", false},
+	}
+	for _, tt := range tests {
+		if got := IsFollowUp(tt.question, previous); got != tt.want {
+			t.Errorf("IsFollowUp(%q) = %v, want %v", tt.question, got, tt.want)
+		}
+	}
+
+	if IsFollowUp("does it have wifi?", nil) {
+		t.Error("a question without previous results must be a new search")
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	previous := sampleResults(4)
+	tests := []struct {
+		question string
+		want     int
+		ok       bool
+	}{
+		{"the second one", 1, true},
+		{"the 3rd option", 2, true},
+		{"the last one", 3, true},
+		{"hotel 2", 1, true},
+		{"#4 please", 3, true},
+		{"tell me more about Hotel 1", 0, true},
+		{"the last weekend", 0, false},
+		{"second floor rooms", 0, false},
+		{"the fifth one", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveReference(tt.question, previous)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("ResolveReference(%q) = %d, %v, want %d, %v", tt.question, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConversationTurnsCarrySessionID(t *testing.T) {
+	llm := &fakeLLM{answer: "answer"}
+	conv := NewConversation(newTestAgents(llm, &fakeSearcher{hotels: sampleResults(2)}, timeouts.Default()))
+	ctx := session.WithID(context.Background(), "session-42")
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logging.New(&logs, slog.LevelDebug))
+
+	for _, question := range []string{"hotel with parking", "does the first one have wifi?"} {
+		if _, err := conv.Ask(ctx, question, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, turn := range conv.Turns() {
+		if turn.SessionID != "session-42" {
+			t.Errorf("turn %d session = %q, want session-42", i, turn.SessionID)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if !strings.Contains(line, "sessionId=session-42") {
+			t.Errorf("log line missing the session ID: %s", line)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/openai/openai-go/v3"
+)
+
+// fakeLLM embeds every text as a fixed vector (or its entry in vectors), plans by calling
+// the search tool with the user message (or once per entry in subQueries), and answers
+// with a fixed string. Each entry in roomQueries adds a search_rooms call to the plan.
+// It records every call.
+type fakeLLM struct {
+	mu          sync.Mutex
+	answer      string
+	plannerErr  error
+	subQueries  []string
+	roomQueries []string
+	vectors     map[string][]float32
+	// planDelay and answerDelay hold the planner and synthesizer calls until they
+	// elapse or the context ends
+	planDelay   time.Duration
+	answerDelay time.Duration
+	embeddings  int
+	embedded    []string
+	plans       int
+	prompts     []string
+}
+
+func (f *fakeLLM) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.embeddings++
+	f.embedded = append(f.embedded, text)
+	if vector, ok := f.vectors[text]; ok {
+		return vector, nil
+	}
+	return []float32{1, 0, 0}, nil
+}
+
+func (f *fakeLLM) ChatCompletionWithTools(ctx context.Context, systemPrompt, userMessage string, tools []openai.ChatCompletionToolUnionParam) (*openai.ChatCompletion, error) {
+	f.mu.Lock()
+	f.plans++
+	f.mu.Unlock()
+	if err := sleep(ctx, f.planDelay); err != nil {
+		return nil, err
+	}
+	if f.plannerErr != nil {
+		return nil, f.plannerErr
+	}
+	queries := f.subQueries
+	if len(queries) == 0 && len(f.roomQueries) == 0 {
+		queries = []string{userMessage}
+	}
+	calls := make([]toolCall, 0, len(queries)+len(f.roomQueries))
+	for _, query := range queries {
+		calls = append(calls, toolCall{name: "search_hotels_collection", query: query})
+	}
+	for _, query := range f.roomQueries {
+		calls = append(calls, toolCall{name: "search_rooms", query: query})
+	}
+	return toolCallsCompletion(5, calls...), nil
+}
+
+func (f *fakeLLM) ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	if err := sleep(ctx, f.answerDelay); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, userMessage)
+	return f.answer, nil
+}
+
+func (f *fakeLLM) ChatCompletionStream(ctx context.Context, systemPrompt, userMessage string, w io.Writer) (string, error) {
+	answer, err := f.ChatCompletion(ctx, systemPrompt, userMessage)
+	if err != nil {
+		return "", err
+	}
+	for _, word := range strings.SplitAfter(answer, " ") {
+		if _, err := io.WriteString(w, word); err != nil {
+			return "", err
+		}
+	}
+	return answer, nil
+}
+
+// sleep waits for d or until ctx ends
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// toolCall is one tool call of a planned completion
+type toolCall struct {
+	name  string
+	query string
+}
+
+// toolCallsCompletion builds a completion that makes the given tool calls
+func toolCallsCompletion(k int, calls ...toolCall) *openai.ChatCompletion {
+	toolCalls := make([]any, len(calls))
+	for i, call := range calls {
+		args, _ := json.Marshal(map[string]any{"query": call.query, "nearestNeighbors": k})
+		toolCalls[i] = map[string]any{
+			"id":       fmt.Sprintf("call_%d", i+1),
+			"type":     "function",
+			"function": map[string]any{"name": call.name, "arguments": string(args)},
+		}
+	}
+	raw, _ := json.Marshal(map[string]any{
+		"id":     "chatcmpl-test",
+		"object": "chat.completion",
+		"model":  "fake",
+		"choices": []any{map[string]any{
+			"index":         0,
+			"finish_reason": "tool_calls",
+			"message": map[string]any{
+				"role":       "assistant",
+				"tool_calls": toolCalls,
+			},
+		}},
+	})
+	var completion openai.ChatCompletion
+	if err := json.Unmarshal(raw, &completion); err != nil {
+		panic(err)
+	}
+	return &completion
+}
+
+// fakeSearcher returns its hotels scoring at least minScore, at most k of them, and counts
+// searches. When search is set, it answers instead of hotels once delay has elapsed.
+type fakeSearcher struct {
+	mu       sync.Mutex
+	hotels   []models.HotelSearchResult
+	minScore float64
+	delay    time.Duration
+	search   func(ctx context.Context, queryVector []float32) ([]models.HotelSearchResult, error)
+	searches int
+	// gotK is the k of the last search
+	gotK int
+}
+
+func (f *fakeSearcher) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	f.mu.Lock()
+	f.searches++
+	f.gotK = k
+	f.mu.Unlock()
+	if err := sleep(ctx, f.delay); err != nil {
+		return nil, err
+	}
+	if f.search != nil {
+		return f.search(ctx, queryVector)
+	}
+	var results []models.HotelSearchResult
+	for _, hotel := range f.hotels {
+		if hotel.Score >= f.minScore && len(results) < k {
+			results = append(results, hotel)
+		}
+	}
+	return results, nil
+}
+
+// newTestAgents builds the planner and synthesizer over the fakes with the default settings,
+// discarding their progress output
+func newTestAgents(llm *fakeLLM, store *fakeSearcher, budgets timeouts.Config) (*PlannerAgent, *SynthesizerAgent) {
+	tool := NewVectorSearchTool(llm, store)
+	planner := NewPlannerAgent(llm, tool, &PlannerConfig{Fallback: true}, budgets)
+	synthesizer := NewSynthesizerAgent(llm, LoadSynthesizerConfigFromEnv(), budgets)
+	planner.SetOutput(io.Discard)
+	synthesizer.SetOutput(io.Discard)
+	return planner, synthesizer
+}
+
+// sampleResults returns n hotels named "Hotel 1" through "Hotel n" with descending scores
+func sampleResults(n int) []models.HotelSearchResult {
+	results := make([]models.HotelSearchResult, n)
+	for i := range results {
+		results[i] = models.HotelSearchResult{
+			Hotel: models.HotelForVectorStore{HotelID: fmt.Sprint(i + 1), HotelName: fmt.Sprintf("Hotel %d", i+1)},
+			Score: 0.9 - float64(i)/10,
+		}
+	}
+	return results
+}
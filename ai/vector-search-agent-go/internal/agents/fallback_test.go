@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestPlannerFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		fallback bool
+		wantErr  bool
+	}{
+		{"throttled", http.StatusTooManyRequests, true, false},
+		{"deployment missing", http.StatusNotFound, true, false},
+		{"service failing", http.StatusServiceUnavailable, true, false},
+		{"bad request", http.StatusBadRequest, true, true},
+		{"fallback disabled", http.StatusTooManyRequests, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &fakeLLM{plannerErr: &openai.Error{StatusCode: tt.status}}
+			store := &fakeSearcher{hotels: sampleResults(5)}
+			tool := NewVectorSearchTool(llm, store)
+			planner := NewPlannerAgent(llm, tool, &PlannerConfig{Fallback: tt.fallback}, timeouts.Default())
+
+			results, err := planner.Search(context.Background(), "pet friendly hotel", 3)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected the planner error")
+				}
+				if store.searches != 0 {
+					t.Errorf("searched %d times without a fallback", store.searches)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if store.searches != 1 || len(results) != 3 {
+				t.Errorf("searches = %d, results = %d; want the fallback search for 3 hotels", store.searches, len(results))
+			}
+			if len(llm.embedded) != 1 || llm.embedded[0] != "pet friendly hotel" {
+				t.Errorf("embedded %q, want the raw user query", llm.embedded)
+			}
+		})
+	}
+}
+
+func TestLoadPlannerConfigFromEnv(t *testing.T) {
+	for value, want := range map[string]bool{"": true, "true": true, "false": false, "0": false} {
+		t.Setenv("PLANNER_FALLBACK", value)
+		if got := LoadPlannerConfigFromEnv().Fallback; got != want {
+			t.Errorf("PLANNER_FALLBACK=%q: Fallback = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients/clientstest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenHotels are the hotels the golden pipeline run finds: a fully described one and
+// a sparse one with empty tags and a zero renovation date
+func goldenHotels() []models.HotelSearchResult {
+	return []models.HotelSearchResult{
+		{
+			Hotel: models.HotelForVectorStore{
+				HotelID:            "1",
+				HotelName:          "Ocean Retreat",
+				Description:        "Quiet rooms steps from the beach, with a rooftop pool.",
+				Category:           "Resort and Spa",
+				Tags:               []string{"pool", "beach access", "view"},
+				ParkingIncluded:    true,
+				LastRenovationDate: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+				Rating:             4.6,
+				Address:            models.Address{StreetAddress: "1 Shore Rd", City: "San Diego", StateProvince: "CA", PostalCode: "92101", Country: "USA"},
+			},
+			Score: 0.91,
+		},
+		{
+			Hotel: models.HotelForVectorStore{
+				HotelID:     "2",
+				HotelName:   "Harbor Inn",
+				Description: "A small inn by the harbor.",
+				Category:    "Budget",
+				Tags:        []string{},
+				Rating:      3,
+			},
+			Score: 0.87,
+		},
+	}
+}
+
+// TestModelRequestsGolden runs the pipeline against a fake Azure OpenAI resource and
+// compares the planner and synthesizer requests, as sent over the wire, with golden
+// files. A change to a prompt, a tool definition, or the hotel formatting shows up as a
+// diff of the files.
+func TestModelRequestsGolden(t *testing.T) {
+	srv := clientstest.NewServer(t)
+	srv.Script("plan-deployment", clientstest.ToolCalls(clientstest.ToolCall{
+		Name:      prompts.ToolName,
+		Arguments: `{"query":"quiet hotel near the beach","nearestNeighbors":2}`,
+	}))
+	llm, err := clients.NewOpenAIClients(&clients.OpenAIConfig{
+		Endpoint:            "https://fake.openai.azure.com",
+		BaseURL:             srv.URL,
+		APIKey:              "test-key",
+		EmbeddingDeployment: "embed-deployment",
+		PlannerDeployment:   "plan-deployment",
+		SynthDeployment:     "synth-deployment",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	budgets := timeouts.Default()
+	planner := NewPlannerAgent(llm, NewVectorSearchTool(llm, &fakeSearcher{hotels: goldenHotels()}), &PlannerConfig{}, budgets)
+	synthesizer := NewSynthesizerAgent(llm, &SynthesizerConfig{
+		TopN:     prompts.DefaultTopN,
+		MaxWords: prompts.DefaultMaxWords,
+		Language: prompts.DefaultLanguage,
+	}, budgets)
+	pipeline := NewPipeline(planner, synthesizer)
+	pipeline.SetOutput(io.Discard)
+
+	if err := pipeline.Run(context.Background(), NewPipelineState("quiet hotel near the beach", 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		deployment string
+		golden     string
+	}{
+		{"plan-deployment", "planner_request.golden"},
+		{"synth-deployment", "synthesizer_request.golden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			for _, req := range srv.Requests() {
+				if req.Deployment == tt.deployment {
+					assertGolden(t, tt.golden, req.Body)
+					return
+				}
+			}
+			t.Fatalf("no request to %s", tt.deployment)
+		})
+	}
+}
+
+// assertGolden compares body, as indented JSON with the prompts' characters unescaped,
+// with testdata/name, rewriting the file when -update is set
+func assertGolden(t *testing.T, name string, body map[string]any) {
+	t.Helper()
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(body); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s does not match the request; run go test -update and review the diff\ngot:\n%s", path, got)
+	}
+}
@@ -0,0 +1,86 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// historyWriteTimeout bounds a history write, which runs even when the run's own
+// context has expired so that timed-out runs are still recorded
+const historyWriteTimeout = 10 * time.Second
+
+// HistoryStore persists history records
+type HistoryStore interface {
+	InsertHistory(ctx context.Context, record models.HistoryRecord) error
+}
+
+// HistoryWriter records each agent run in the history collection. A nil writer
+// records nothing, so callers need not check whether history is enabled.
+type HistoryWriter struct {
+	store HistoryStore
+}
+
+// NewHistoryWriter returns a writer that records every run in store
+func NewHistoryWriter(store HistoryStore) *HistoryWriter {
+	return &HistoryWriter{store: store}
+}
+
+// NewHistoryWriterFromEnv returns a writer for store when HISTORY_ENABLED is true, or nil
+func NewHistoryWriterFromEnv(store HistoryStore) *HistoryWriter {
+	enabled, _ := strconv.ParseBool(os.Getenv("HISTORY_ENABLED"))
+	if !enabled {
+		return nil
+	}
+	return NewHistoryWriter(store)
+}
+
+// Record stores the query, answer, retrieved hotels, usage, and latency of a finished run.
+// A failed write is logged as a warning and never fails the run.
+func (w *HistoryWriter) Record(ctx context.Context, state *PipelineState, summary RunSummary) {
+	if w == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), historyWriteTimeout)
+	defer cancel()
+
+	if err := w.store.InsertHistory(ctx, NewHistoryRecord(state, summary)); err != nil {
+		slog.WarnContext(ctx, "failed to record history", "resultId", state.ResultID, "err", err)
+	}
+}
+
+// NewHistoryRecord builds the history record of a run from its state and summary
+func NewHistoryRecord(state *PipelineState, summary RunSummary) models.HistoryRecord {
+	record := models.HistoryRecord{
+		ResultID:    state.ResultID,
+		SessionID:   state.SessionID,
+		RequestID:   state.RequestID,
+		Query:       state.Query,
+		SearchQuery: state.SearchQuery,
+		Results:     make([]models.HistoryResult, 0, len(state.Results)),
+		Answer:      state.Answer,
+		Usage:       make([]models.HistoryUsage, 0, len(summary.Usage)),
+		LatencyMs:   summary.Total.Milliseconds(),
+		Error:       summary.Err,
+		CreatedAt:   time.Now().UTC(),
+		Build:       buildinfo.Read(),
+	}
+	for _, result := range state.Results {
+		record.Results = append(record.Results, models.HistoryResult{HotelID: result.Hotel.HotelID, Score: result.Score})
+	}
+	for _, u := range summary.Usage {
+		record.Usage = append(record.Usage, models.HistoryUsage{
+			Deployment:       u.Deployment,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			EstimatedCost:    u.EstimatedCost,
+		})
+	}
+	return record
+}
@@ -0,0 +1,152 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeHistoryStore records inserted history, or fails with err
+type fakeHistoryStore struct {
+	records []models.HistoryRecord
+	err     error
+	ctxErr  error
+}
+
+func (f *fakeHistoryStore) InsertHistory(ctx context.Context, record models.HistoryRecord) error {
+	f.ctxErr = ctx.Err()
+	if f.err != nil {
+		return f.err
+	}
+	f.records = append(f.records, record)
+	return nil
+}
+
+func historyState() (*PipelineState, RunSummary) {
+	state := NewPipelineState("quiet hotel near the beach", 2)
+	state.SessionID = "session-1"
+	state.SearchQuery = "quiet beach hotel"
+	state.Answer = "Try Ocean Retreat [1]."
+	state.Results = sampleResults(2)
+	summary := RunSummary{
+		Total: 1500 * time.Millisecond,
+		Usage: []clients.DeploymentUsage{
+			{Deployment: "gpt-4o-mini", Calls: 1, PromptTokens: 120, CompletionTokens: 30, EstimatedCost: 0.001},
+		},
+	}
+	return state, summary
+}
+
+func TestNewHistoryRecord(t *testing.T) {
+	state, summary := historyState()
+
+	record := NewHistoryRecord(state, summary)
+	if record.ResultID != state.ResultID || record.SessionID != "session-1" || record.Query != state.Query ||
+		record.SearchQuery != "quiet beach hotel" || record.Answer != state.Answer || record.LatencyMs != 1500 || record.Error != "" {
+		t.Errorf("record = %+v", record)
+	}
+	wantResults := []models.HistoryResult{
+		{HotelID: state.Results[0].Hotel.HotelID, Score: state.Results[0].Score},
+		{HotelID: state.Results[1].Hotel.HotelID, Score: state.Results[1].Score},
+	}
+	if !slices.Equal(record.Results, wantResults) {
+		t.Errorf("results = %+v, want %+v", record.Results, wantResults)
+	}
+	if want := (models.HistoryUsage{Deployment: "gpt-4o-mini", PromptTokens: 120, CompletionTokens: 30, EstimatedCost: 0.001}); len(record.Usage) != 1 || record.Usage[0] != want {
+		t.Errorf("usage = %+v, want %+v", record.Usage, want)
+	}
+	if record.Build != buildinfo.Read() {
+		t.Errorf("build = %+v, want the running binary", record.Build)
+	}
+	if time.Since(record.CreatedAt) > time.Minute || record.CreatedAt.Location() != time.UTC {
+		t.Errorf("createdAt = %v, want now in UTC", record.CreatedAt)
+	}
+}
+
+func TestHistoryDocumentShape(t *testing.T) {
+	state := NewPipelineState("pool", 3)
+	record := NewHistoryRecord(state, RunSummary{Total: time.Second, Err: "planner timed out"})
+
+	data, err := bson.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"resultId", "sessionId", "query", "results", "answer", "usage", "latencyMs", "error", "createdAt", "build"} {
+		if _, ok := doc[key]; !ok {
+			t.Errorf("document is missing %q: %v", key, doc)
+		}
+	}
+	// A failed run stores empty arrays rather than nulls, and omits the unset search query
+	if results, ok := doc["results"].(bson.A); !ok || len(results) != 0 {
+		t.Errorf("results = %#v, want an empty array", doc["results"])
+	}
+	if usage, ok := doc["usage"].(bson.A); !ok || len(usage) != 0 {
+		t.Errorf("usage = %#v, want an empty array", doc["usage"])
+	}
+	if _, ok := doc["searchQuery"]; ok {
+		t.Errorf("searchQuery stored for a run without one: %v", doc)
+	}
+	if doc["error"] != "planner timed out" || doc["latencyMs"] != int64(1000) {
+		t.Errorf("document = %v", doc)
+	}
+}
+
+func TestHistoryWriterRecords(t *testing.T) {
+	store := &fakeHistoryStore{}
+	state, summary := historyState()
+
+	// A run whose context has already expired is still recorded
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewHistoryWriter(store).Record(ctx, state, summary)
+
+	if len(store.records) != 1 || store.records[0].ResultID != state.ResultID {
+		t.Fatalf("records = %+v, want the run", store.records)
+	}
+	if store.ctxErr != nil {
+		t.Errorf("write context err = %v, want a live context", store.ctxErr)
+	}
+}
+
+func TestHistoryWriterFailsSoftly(t *testing.T) {
+	handler := &capturingHandler{level: slog.LevelWarn}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(handler))
+
+	state, summary := historyState()
+	NewHistoryWriter(&fakeHistoryStore{err: errors.New("connection reset")}).Record(context.Background(), state, summary)
+
+	if !slices.Equal(handler.messages, []string{"failed to record history"}) {
+		t.Errorf("logged %v, want one warning", handler.messages)
+	}
+}
+
+func TestHistoryWriterFromEnv(t *testing.T) {
+	tests := map[string]bool{"": false, "false": false, "not-a-bool": false, "true": true, "1": true}
+
+	for value, enabled := range tests {
+		t.Setenv("HISTORY_ENABLED", value)
+		w := NewHistoryWriterFromEnv(&fakeHistoryStore{})
+		if (w != nil) != enabled {
+			t.Errorf("HISTORY_ENABLED=%q: writer %v, want enabled %v", value, w, enabled)
+		}
+	}
+
+	// A disabled writer is nil and records nothing
+	var disabled *HistoryWriter
+	state, summary := historyState()
+	disabled.Record(context.Background(), state, summary)
+}
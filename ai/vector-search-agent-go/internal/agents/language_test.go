@@ -0,0 +1,85 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// fakeLanguageSearcher is a fakeSearcher with separate French results, recording the
+// languages searched
+type fakeLanguageSearcher struct {
+	fakeSearcher
+	french    []models.HotelSearchResult
+	languages []string
+}
+
+func (f *fakeLanguageSearcher) VectorSearchLanguage(ctx context.Context, language string, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	f.languages = append(f.languages, language)
+	return f.french, nil
+}
+
+func TestSearchLanguage(t *testing.T) {
+	english := sampleResults(2)
+	french := []models.HotelSearchResult{{Hotel: models.HotelForVectorStore{HotelID: "9", HotelName: "Hôtel Neuf"}, Score: 0.8}}
+
+	tests := []struct {
+		name          string
+		language      string
+		french        []models.HotelSearchResult
+		wantFirst     string
+		wantLanguages []string
+		wantEnglish   int
+	}{
+		{"default is English", "", french, "1", nil, 1},
+		{"French", "fr", french, "9", []string{"fr"}, 0},
+		{"French name", "French", french, "9", []string{"fr"}, 0},
+		{"no French vectors falls back", "fr", nil, "1", []string{"fr"}, 1},
+		{"unsupported language searches English", "de", french, "1", nil, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searcher := &fakeLanguageSearcher{fakeSearcher: fakeSearcher{hotels: english}, french: tt.french}
+			tool := NewVectorSearchTool(&fakeLLM{}, searcher)
+
+			results, err := tool.SearchLanguage(context.Background(), "quiet hotel", tt.language, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if results[0].Hotel.HotelID != tt.wantFirst {
+				t.Errorf("first result = %s, want %s", results[0].Hotel.HotelID, tt.wantFirst)
+			}
+			if len(searcher.languages) != len(tt.wantLanguages) || (len(tt.wantLanguages) > 0 && searcher.languages[0] != tt.wantLanguages[0]) {
+				t.Errorf("languages searched = %v, want %v", searcher.languages, tt.wantLanguages)
+			}
+			if searcher.searches != tt.wantEnglish {
+				t.Errorf("English searches = %d, want %d", searcher.searches, tt.wantEnglish)
+			}
+		})
+	}
+}
+
+func TestSearchLanguageWithoutLanguageSearcher(t *testing.T) {
+	searcher := &fakeSearcher{hotels: sampleResults(1)}
+	tool := NewVectorSearchTool(&fakeLLM{}, searcher)
+
+	results, err := tool.SearchLanguage(context.Background(), "quiet hotel", "fr", 5)
+	if err != nil || len(results) != 1 || searcher.searches != 1 {
+		t.Errorf("results = %+v, %v, searches = %d, want the English search", results, err, searcher.searches)
+	}
+}
+
+func TestParseToolArgumentsLanguage(t *testing.T) {
+	args, err := parseToolArgumentsFromMap(context.Background(), hotelSearchSchema, map[string]any{"query": "hôtel calme", "nearestNeighbors": float64(3), "language": "fr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.Language != "fr" || args.NearestNeighbors != 3 {
+		t.Errorf("args = %+v", args)
+	}
+	if args, _ := parseToolArgumentsFromMap(context.Background(), hotelSearchSchema, map[string]any{"query": "quiet hotel", "nearestNeighbors": float64(3)}); args.Language != "" {
+		t.Errorf("language without argument = %q", args.Language)
+	}
+}
@@ -0,0 +1,72 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// capturingHandler records the messages of the records it handles at or above its level
+type capturingHandler struct {
+	mu       sync.Mutex
+	level    slog.Level
+	messages []string
+}
+
+func (h *capturingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *capturingHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, record.Message)
+	return nil
+}
+
+func (h *capturingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *capturingHandler) WithGroup(string) slog.Handler { return h }
+
+func TestPipelineLogLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  slog.Level
+		want   []string
+		hidden []string
+	}{
+		{"warn", slog.LevelWarn, nil, []string{"pipeline stage finished", "planner selected tool", "hotel context"}},
+		{"info", slog.LevelInfo, []string{"pipeline stage finished"}, []string{"planner selected tool", "vector search completed", "hotel context"}},
+		{"debug", slog.LevelDebug, []string{"pipeline stage finished", "planner selected tool", "vector search completed", "synthesizer started"}, []string{"hotel context"}},
+		{"trace", logging.LevelTrace, []string{"pipeline stage finished", "planner selected tool", "vector search completed", "synthesizer started", "hotel context"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &capturingHandler{level: tt.level}
+			defer slog.SetDefault(slog.Default())
+			slog.SetDefault(slog.New(handler))
+
+			planner, synthesizer := newTestAgents(&fakeLLM{answer: "Hotel 1 [1]"}, &fakeSearcher{hotels: sampleResults(3)}, timeouts.Default())
+			if err := NewPipeline(planner, synthesizer).Run(context.Background(), NewPipelineState("quiet hotel", 3)); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, msg := range tt.want {
+				if !slices.Contains(handler.messages, msg) {
+					t.Errorf("missing %q at %s: %v", msg, tt.level, handler.messages)
+				}
+			}
+			for _, msg := range tt.hidden {
+				if slices.Contains(handler.messages, msg) {
+					t.Errorf("%q logged at %s", msg, tt.level)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// subQueryResult holds the outcome of one sub-query search
+type subQueryResult struct {
+	index   int
+	query   string
+	results []models.HotelSearchResult
+	err     error
+}
+
+// executeSubQueries runs the sub-query searches concurrently, bounded by the configured
+// search concurrency. Every sub-query runs to completion (or cancellation) regardless of
+// the others failing, and the outcomes are returned in sub-query order.
+func (a *PlannerAgent) executeSubQueries(ctx context.Context, subQueries []*toolArguments) []subQueryResult {
+	outcomes := make([]subQueryResult, len(subQueries))
+
+	var g errgroup.Group
+	g.SetLimit(max(a.config.SearchConcurrency, 1))
+
+	for i, subQuery := range subQueries {
+		g.Go(func() error {
+			results, err := a.executeTool(ctx, subQuery.Query, subQuery.Language, subQuery.NearestNeighbors)
+			if errors.Is(err, ErrNoResults) {
+				err = nil
+			}
+			outcomes[i] = subQueryResult{index: i, query: subQuery.Query, results: results, err: err}
+			return nil
+		})
+	}
+	g.Wait()
+
+	return outcomes
+}
+
+// fuseSubQueryResults merges sub-query results in sub-query order, keeping the best score for
+// hotels found by several sub-queries, and returns the top nearestNeighbors hotels by score,
+// ranked and marked as fused. RawScore keeps the engine score of the best match.
+// Failed sub-queries are skipped; their errors are returned joined alongside the partial results.
+func fuseSubQueryResults(ctx context.Context, outcomes []subQueryResult, nearestNeighbors int) ([]models.HotelSearchResult, error) {
+	var errs []error
+	var fused []models.HotelSearchResult
+	positions := make(map[string]int)
+
+	for _, outcome := range outcomes {
+		if outcome.err != nil {
+			errs = append(errs, fmt.Errorf("sub-query #%d %q: %w", outcome.index+1, outcome.query, outcome.err))
+			continue
+		}
+		for _, result := range outcome.results {
+			if pos, ok := positions[result.Hotel.HotelID]; ok {
+				if result.Score > fused[pos].Score {
+					fused[pos].Score = result.Score
+					fused[pos].RawScore = result.RawScore
+				}
+				continue
+			}
+			positions[result.Hotel.HotelID] = len(fused)
+			fused = append(fused, result)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if len(errs) == len(outcomes) {
+		return nil, err
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "some sub-queries failed, continuing with partial results", "failed", len(errs), "total", len(outcomes), "err", err)
+		trace.FromContext(ctx).Annotate(StageTool, fmt.Sprintf("%d of %d sub-queries failed: %v", len(errs), len(outcomes), err))
+	}
+
+	// Stable sort keeps sub-query order for equal scores so the merge is deterministic
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	if nearestNeighbors > 0 && len(fused) > nearestNeighbors {
+		fused = fused[:nearestNeighbors]
+	}
+
+	if len(fused) == 0 {
+		return nil, ErrNoResults
+	}
+	for i := range fused {
+		fused[i].Provenance = models.ProvenanceFused
+	}
+	models.RankResults(fused)
+
+	return fused, nil
+}
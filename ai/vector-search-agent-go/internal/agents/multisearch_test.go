@@ -0,0 +1,188 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+)
+
+// subQueryFixture plans three sub-queries whose embeddings select the hotels each search returns
+type subQueryFixture struct {
+	queries []string
+	hotels  [][]models.HotelSearchResult
+	errs    []error
+	delays  []time.Duration
+}
+
+func hotel(id string, score float64) models.HotelSearchResult {
+	return models.HotelSearchResult{Hotel: models.HotelForVectorStore{HotelID: id, HotelName: "Hotel " + id}, Score: score}
+}
+
+func (f subQueryFixture) planner(concurrency int) *PlannerAgent {
+	llm := &fakeLLM{subQueries: f.queries, vectors: make(map[string][]float32)}
+	for i, query := range f.queries {
+		llm.vectors[query] = []float32{float32(i)}
+	}
+
+	store := &fakeSearcher{search: func(ctx context.Context, queryVector []float32) ([]models.HotelSearchResult, error) {
+		i := int(queryVector[0])
+		if i < len(f.delays) {
+			if err := sleep(ctx, f.delays[i]); err != nil {
+				return nil, err
+			}
+		}
+		if i < len(f.errs) && f.errs[i] != nil {
+			return nil, f.errs[i]
+		}
+		return f.hotels[i], nil
+	}}
+
+	tool := NewVectorSearchTool(llm, store)
+	planner := NewPlannerAgent(llm, tool, &PlannerConfig{SearchConcurrency: concurrency}, timeouts.Default())
+	planner.SetOutput(io.Discard)
+	return planner
+}
+
+func hotelIDs(results []models.HotelSearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.Hotel.HotelID
+	}
+	return ids
+}
+
+func TestSubQueriesOverlap(t *testing.T) {
+	const delay = 60 * time.Millisecond
+	fixture := subQueryFixture{
+		queries: []string{"pool", "parking", "beach"},
+		hotels:  [][]models.HotelSearchResult{{hotel("a", 0.9)}, {hotel("b", 0.8)}, {hotel("c", 0.7)}},
+		delays:  []time.Duration{delay, delay, delay},
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		check       func(elapsed time.Duration) bool
+		want        string
+	}{
+		{"parallel", 3, func(elapsed time.Duration) bool { return elapsed < 2*delay }, "under 2 delays"},
+		{"sequential", 1, func(elapsed time.Duration) bool { return elapsed >= 3*delay }, "at least 3 delays"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planner := fixture.planner(tt.concurrency)
+
+			start := time.Now()
+			results, err := planner.Search(context.Background(), "quiet hotel", 5)
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 3 {
+				t.Errorf("got %d hotels, want 3", len(results))
+			}
+			if !tt.check(elapsed) {
+				t.Errorf("elapsed %v with concurrency %d, want %s of %v", elapsed, tt.concurrency, tt.want, delay)
+			}
+		})
+	}
+}
+
+func TestSubQueryMergeIsDeterministic(t *testing.T) {
+	// The first sub-query finishes last, but ties still keep sub-query order
+	fixture := subQueryFixture{
+		queries: []string{"pool", "parking"},
+		hotels: [][]models.HotelSearchResult{
+			{hotel("a", 0.5), hotel("b", 0.7)},
+			{hotel("c", 0.5), hotel("a", 0.9)},
+		},
+		delays: []time.Duration{40 * time.Millisecond, 0},
+	}
+	planner := fixture.planner(2)
+
+	for range 3 {
+		results, err := planner.Search(context.Background(), "quiet hotel", 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := hotelIDs(results), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("merged order = %v, want %v", got, want)
+		}
+		if results[0].Score != 0.9 {
+			t.Errorf("hotel a score = %v, want the best score 0.9", results[0].Score)
+		}
+	}
+}
+
+func TestSubQueryFailureKeepsOtherResults(t *testing.T) {
+	errStore := errors.New("store unavailable")
+	fixture := subQueryFixture{
+		queries: []string{"pool", "parking", "beach"},
+		hotels:  [][]models.HotelSearchResult{{hotel("a", 0.9)}, nil, {hotel("c", 0.7)}},
+		errs:    []error{nil, errStore, nil},
+	}
+	planner := fixture.planner(3)
+
+	tr := trace.New("session-1")
+	results, err := planner.Search(trace.WithTrace(context.Background(), tr), "quiet hotel", 5)
+	if err != nil {
+		t.Fatalf("partial failure returned %v, want the other sub-queries' results", err)
+	}
+	if got, want := hotelIDs(results), []string{"a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+
+	var noted bool
+	for _, event := range tr.Events() {
+		noted = noted || strings.Contains(event.Note, "1 of 3 sub-queries failed")
+	}
+	if !noted {
+		t.Errorf("trace %+v does not record the failed sub-query", tr.Events())
+	}
+}
+
+func TestSubQueryAllFailedReturnsErrors(t *testing.T) {
+	errStore := errors.New("store unavailable")
+	outcomes := []subQueryResult{
+		{index: 0, query: "pool", err: errStore},
+		{index: 1, query: "parking", err: errStore},
+	}
+
+	results, err := fuseSubQueryResults(context.Background(), outcomes, 5)
+	if results != nil || !errors.Is(err, errStore) {
+		t.Fatalf("fuse = %v, %v, want no results and the joined errors", results, err)
+	}
+	if !strings.Contains(err.Error(), `sub-query #2 "parking"`) {
+		t.Errorf("error %q does not name the failed sub-query", err)
+	}
+}
+
+func TestSubQueriesStopOnCancel(t *testing.T) {
+	fixture := subQueryFixture{
+		queries: []string{"pool", "parking"},
+		hotels:  [][]models.HotelSearchResult{{hotel("a", 0.9)}, {hotel("b", 0.8)}},
+		delays:  []time.Duration{time.Second, time.Second},
+	}
+	planner := fixture.planner(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := planner.Search(ctx, "quiet hotel", 5)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("cancelled search took %v, want in-flight sub-queries stopped", elapsed)
+	}
+}
@@ -0,0 +1,78 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestNoResultsShortCircuits(t *testing.T) {
+	tests := []struct {
+		name  string
+		store *fakeSearcher
+	}{
+		{"empty collection", &fakeSearcher{}},
+		{"all filtered", &fakeSearcher{hotels: sampleResults(3), minScore: 0.95}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &fakeLLM{answer: "invented hotel"}
+			planner, synthesizer := newTestAgents(llm, tt.store, timeouts.Default())
+			ctx := context.Background()
+
+			if _, err := planner.Search(ctx, "quiet hotel with a pool", 3); !errors.Is(err, ErrNoResults) {
+				t.Fatalf("planner.Search error = %v, want ErrNoResults", err)
+			}
+
+			answer, err := NewConversation(planner, synthesizer).Ask(ctx, "quiet hotel with a pool", 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if answer != prompts.NoResultsAnswer {
+				t.Errorf("answer = %q, want %q", answer, prompts.NoResultsAnswer)
+			}
+			if len(llm.prompts) != 0 {
+				t.Errorf("synthesizer called the model %d times, want 0", len(llm.prompts))
+			}
+		})
+	}
+}
+
+func TestSynthesizerEmptyContextSkipsModel(t *testing.T) {
+	llm := &fakeLLM{answer: "invented hotel"}
+	_, synthesizer := newTestAgents(llm, &fakeSearcher{}, timeouts.Default())
+
+	answer, err := synthesizer.Synthesize(context.Background(), "quiet hotel", " \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != prompts.NoResultsAnswer || len(llm.prompts) != 0 {
+		t.Errorf("answer = %q after %d model calls, want the no-results answer without a call", answer, len(llm.prompts))
+	}
+}
+
+func TestConversationForgetsResultsAfterEmptySearch(t *testing.T) {
+	llm := &fakeLLM{answer: "answer"}
+	store := &fakeSearcher{hotels: sampleResults(3)}
+	conv := NewConversation(newTestAgents(llm, store, timeouts.Default()))
+	ctx := context.Background()
+
+	if _, err := conv.Ask(ctx, "quiet hotel with a pool", 3); err != nil {
+		t.Fatal(err)
+	}
+	store.minScore = 1
+	if answer, err := conv.Ask(ctx, "castle with a moat", 3); err != nil || answer != prompts.NoResultsAnswer {
+		t.Fatalf("empty search = %q, %v, want the no-results answer", answer, err)
+	}
+
+	if _, err := conv.Ask(ctx, "does the second one have parking?", 3); err != nil {
+		t.Fatal(err)
+	}
+	if store.searches != 3 {
+		t.Errorf("follow-up after an empty search ran %d searches, want 3 (answered from stale hotels)", store.searches)
+	}
+}
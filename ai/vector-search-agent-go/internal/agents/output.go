@@ -0,0 +1,39 @@
+package agents
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// progress writes an agent's human-readable progress output. The zero value writes to
+// os.Stderr, so stdout carries only a command's result.
+type progress struct {
+	out io.Writer
+}
+
+// SetOutput sets the destination for progress output
+func (p *progress) SetOutput(w io.Writer) {
+	p.out = w
+}
+
+// output returns the destination for progress output
+func (p *progress) output() io.Writer {
+	if p.out == nil {
+		return os.Stderr
+	}
+	return p.out
+}
+
+func (p *progress) printf(format string, args ...any) {
+	fmt.Fprintf(p.output(), format, args...)
+}
+
+func (p *progress) println(args ...any) {
+	fmt.Fprintln(p.output(), args...)
+}
+
+// outputSetter is implemented by stages that write progress output
+type outputSetter interface {
+	SetOutput(w io.Writer)
+}
@@ -0,0 +1,152 @@
+package agents
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// Agent is one stage of a pipeline. Stages read and write the shared PipelineState.
+type Agent interface {
+	Name() string
+	Run(ctx context.Context, state *PipelineState) error
+}
+
+// PipelineState is the state shared by the stages of a pipeline run
+type PipelineState struct {
+	SessionID        string
+	ResultID         string // identifies this answer, for example in feedback
+	RequestID        string // correlates the run's logs, trace events, and errors
+	Query            string
+	NearestNeighbors int
+	// EphemeralDatabase names the database created for the run when EPHEMERAL_DATABASE
+	// is set, so a failed run's database can be found and dropped
+	EphemeralDatabase string
+
+	// SearchQuery is the query the search tool actually ran (the planner's refinement, or the raw query)
+	SearchQuery      string
+	PlanningBypassed bool
+	Results          []models.HotelSearchResult
+	// Rooms holds the rooms found by the room search, grouped by hotel
+	Rooms     []models.HotelRooms
+	Context   string
+	Answer    string
+	Citations []Citation
+
+	Trace *trace.Trace
+}
+
+// NewPipelineState creates the initial state for a pipeline run
+func NewPipelineState(query string, nearestNeighbors int) *PipelineState {
+	return &PipelineState{
+		ResultID:         uuid.NewString(),
+		Query:            query,
+		NearestNeighbors: nearestNeighbors,
+	}
+}
+
+// SpanRun is the name of the OpenTelemetry span of a pipeline run
+const SpanRun = "agent.run"
+
+// Pipeline runs agents in order over a shared state
+type Pipeline struct {
+	stages []Agent
+}
+
+// NewPipeline creates a pipeline from the given stages
+func NewPipeline(stages ...Agent) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// NewDefaultPipeline builds the planner → synthesizer → citations pipeline used by the sample.
+// With plannerConfig.RoomSearch, a store that can search rooms also gets the room search tool.
+func NewDefaultPipeline(llm LLM, store Searcher, plannerConfig *PlannerConfig, synthConfig *SynthesizerConfig, budgets timeouts.Config) *Pipeline {
+	searchTool := NewVectorSearchTool(llm, store)
+	searchTool.SetFormatter(synthConfig.Formatter())
+	planner := NewPlannerAgent(llm, searchTool, plannerConfig, budgets)
+	if roomSearcher, ok := store.(RoomSearcher); ok && plannerConfig.RoomSearch {
+		planner.SetRoomTool(NewRoomSearchTool(llm, roomSearcher))
+	}
+
+	return NewPipeline(
+		planner,
+		NewSynthesizerAgent(llm, synthConfig, budgets),
+		NewCitationAgent(),
+	)
+}
+
+// SetOutput sets the destination for the progress output of every stage that writes any
+func (p *Pipeline) SetOutput(w io.Writer) {
+	for _, stage := range p.stages {
+		if s, ok := stage.(outputSetter); ok {
+			s.SetOutput(w)
+		}
+	}
+}
+
+// Stages returns the pipeline stages in execution order
+func (p *Pipeline) Stages() []Agent {
+	return p.stages
+}
+
+// Run executes each stage in order, recording per-stage timing in the state's trace.
+// The run takes the request ID carried by ctx, or a new one when there is none, so every
+// log record, trace event, and stage error it produces can be correlated. Each run is an
+// OpenTelemetry span with a child span per stage, and is counted in the run metrics. It
+// stops at the first stage that returns an error.
+func (p *Pipeline) Run(ctx context.Context, state *PipelineState) (err error) {
+	if state.SessionID == "" {
+		state.SessionID = session.FromContext(ctx)
+	}
+	if state.RequestID == "" {
+		state.RequestID = session.RequestID(ctx)
+	}
+	if state.RequestID == "" {
+		state.RequestID = session.NewRequestID()
+	}
+	ctx = session.WithRequestID(ctx, state.RequestID)
+	if state.Trace == nil {
+		state.Trace = trace.New(state.SessionID)
+	}
+	if state.Trace.RequestID == "" {
+		state.Trace.RequestID = state.RequestID
+	}
+	ctx = trace.WithTrace(ctx, state.Trace)
+
+	ctx, span := telemetry.Start(ctx, SpanRun, semconv.SessionID(state.SessionID), telemetry.KKey.Int(state.NearestNeighbors))
+	defer func() {
+		span.SetAttributes(telemetry.ResultCountKey.Int(len(state.Results)))
+		telemetry.End(span, err)
+		metrics.Default().Run(err)
+	}()
+
+	for _, stage := range p.stages {
+		start := state.Trace.Now()
+		stageCtx, stageSpan := telemetry.Start(ctx, stage.Name(), telemetry.StageKey.String(stage.Name()))
+		err := stage.Run(stageCtx, state)
+		telemetry.End(stageSpan, err)
+
+		event := trace.Event{Name: stage.Name(), Start: start, Duration: state.Trace.Now().Sub(start)}
+		if err != nil {
+			event.Err = err.Error()
+		}
+		state.Trace.Add(event)
+
+		slog.InfoContext(ctx, "pipeline stage finished", "stage", stage.Name(), "duration", event.Duration, "err", err)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry/telemetrytest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// fakeStage records its name in order and applies fn to the shared state
+type fakeStage struct {
+	name  string
+	order *[]string
+	fn    func(state *PipelineState) error
+}
+
+func (s *fakeStage) Name() string {
+	return s.name
+}
+
+func (s *fakeStage) Run(ctx context.Context, state *PipelineState) error {
+	*s.order = append(*s.order, s.name)
+	if s.fn == nil {
+		return nil
+	}
+	return s.fn(state)
+}
+
+func TestPipelineRunsStagesInOrder(t *testing.T) {
+	var order []string
+	rewrite := &fakeStage{name: "rewrite", order: &order, fn: func(state *PipelineState) error {
+		state.SearchQuery = strings.ToUpper(state.Query)
+		return nil
+	}}
+	retrieve := &fakeStage{name: "retrieve", order: &order, fn: func(state *PipelineState) error {
+		state.Results = sampleResults(state.NearestNeighbors)
+		state.Context = FormatResults(state.Results)
+		return nil
+	}}
+	answer := &fakeStage{name: "answer", order: &order, fn: func(state *PipelineState) error {
+		state.Answer = state.SearchQuery + ": " + state.Results[0].Hotel.HotelName
+		return nil
+	}}
+
+	pipeline := NewPipeline(rewrite, retrieve, answer)
+	state := NewPipelineState("quiet hotel", 2)
+	ctx := session.WithID(context.Background(), "session-1")
+	if err := pipeline.Run(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"rewrite", "retrieve", "answer"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if state.Answer != "QUIET HOTEL: Hotel 1" {
+		t.Errorf("answer = %q, want state to flow between stages", state.Answer)
+	}
+	if state.SessionID != "session-1" || state.Trace == nil || state.Trace.SessionID != "session-1" {
+		t.Errorf("session ID not carried into state and trace: %+v", state)
+	}
+
+	var traced []string
+	for _, event := range state.Trace.Events() {
+		traced = append(traced, event.Name)
+	}
+	if !reflect.DeepEqual(traced, order) {
+		t.Errorf("trace events = %v, want one per stage in order %v", traced, order)
+	}
+}
+
+func TestPipelineStopsAtFirstError(t *testing.T) {
+	var order []string
+	errStage := errors.New("stage failed")
+	pipeline := NewPipeline(
+		&fakeStage{name: "first", order: &order},
+		&fakeStage{name: "failing", order: &order, fn: func(*PipelineState) error { return errStage }},
+		&fakeStage{name: "never", order: &order},
+	)
+
+	state := NewPipelineState("quiet hotel", 3)
+	if err := pipeline.Run(context.Background(), state); !errors.Is(err, errStage) {
+		t.Fatalf("err = %v, want %v", err, errStage)
+	}
+	if want := []string{"first", "failing"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	events := state.Trace.Events()
+	if len(events) != 2 || events[1].Err != errStage.Error() {
+		t.Errorf("trace events = %+v, want the failing stage recorded with its error", events)
+	}
+}
+
+func TestPipelineInsertsStageBetweenAgents(t *testing.T) {
+	llm := &fakeLLM{answer: "answer"}
+	planner, synthesizer := newTestAgents(llm, &fakeSearcher{hotels: sampleResults(3)}, timeouts.Default())
+
+	var order []string
+	rerank := &fakeStage{name: "rerank", order: &order, fn: func(state *PipelineState) error {
+		state.Results = []models.HotelSearchResult{state.Results[2], state.Results[0], state.Results[1]}
+		state.Context = FormatResults(state.Results)
+		return nil
+	}}
+
+	state := NewPipelineState("quiet hotel", 3)
+	if err := NewPipeline(planner, rerank, synthesizer).Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(state.SearchQuery, "quiet hotel") || state.Answer != "answer" {
+		t.Errorf("state = %+v, want planner search query and synthesizer answer", state)
+	}
+	prompt := llm.prompts[len(llm.prompts)-1]
+	if strings.Index(prompt, "Hotel 3") > strings.Index(prompt, "Hotel 1") {
+		t.Errorf("synthesizer did not see the reranked context:\n%s", prompt)
+	}
+
+	// Stage events follow the steps recorded within each stage
+	var traced []string
+	for _, event := range state.Trace.Events() {
+		if event.Name == trace.EventToolExecution || event.Name == trace.EventContextAssembly {
+			continue
+		}
+		if event.Duration > 0 || event.Note == "" {
+			traced = append(traced, event.Name)
+		}
+	}
+	if want := []string{StagePlanner, "rerank", StageSynthesizer}; !reflect.DeepEqual(traced, want) {
+		t.Errorf("trace stages = %v, want %v", traced, want)
+	}
+}
+
+func TestPipelineSetOutput(t *testing.T) {
+	llm := &fakeLLM{answer: "answer"}
+	planner, synthesizer := newTestAgents(llm, &fakeSearcher{}, timeouts.Default())
+
+	var out bytes.Buffer
+	pipeline := NewPipeline(planner, synthesizer, NewCitationAgent())
+	pipeline.SetOutput(&out)
+
+	if err := pipeline.Run(context.Background(), NewPipelineState("quiet hotel", 3)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"--- PLANNER ---", "No matching hotels found", "--- SYNTHESIZER ---"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("progress output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestNewPipelineStateAssignsResultID(t *testing.T) {
+	first, second := NewPipelineState("quiet hotel", 3), NewPipelineState("quiet hotel", 3)
+	if _, err := uuid.Parse(first.ResultID); err != nil {
+		t.Errorf("ResultID = %q, want a UUID", first.ResultID)
+	}
+	if first.ResultID == second.ResultID {
+		t.Errorf("two runs share result ID %s", first.ResultID)
+	}
+}
+
+func TestNewDefaultPipelineFormatsContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []string
+		wantNot []string
+	}{
+		{"default", nil, []string{"--- HOTEL START ---", "HotelName: Hotel 1", "Score: 0.900000"}, []string{`{"HotelId"`}},
+		{"json", map[string]string{"SYNTH_CONTEXT_FORMAT": "JSON"}, []string{`"HotelName":"Hotel 1"`, `"Score":0.900000,"Rank":1}`}, []string{"--- HOTEL START ---"}},
+		{"selected fields", map[string]string{"SYNTH_CONTEXT_FIELDS": "HotelName, Score", "SYNTH_CONTEXT_FORMAT": "xml"}, []string{"HotelName: Hotel 1\nScore: 0.900000"}, []string{"HotelId:"}},
+		{"excluded fields", map[string]string{"SYNTH_CONTEXT_EXCLUDE": "HotelId,IsDeleted"}, []string{"HotelName: Hotel 1"}, []string{"HotelId:", "IsDeleted:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SYNTH_CONTEXT_FORMAT", "SYNTH_CONTEXT_FIELDS", "SYNTH_CONTEXT_EXCLUDE"} {
+				t.Setenv(key, tt.env[key])
+			}
+			llm := &fakeLLM{answer: "answer"}
+			pipeline := NewDefaultPipeline(llm, &fakeSearcher{hotels: sampleResults(2)}, &PlannerConfig{}, LoadSynthesizerConfigFromEnv(), timeouts.Default())
+			pipeline.SetOutput(io.Discard)
+
+			state := NewPipelineState("quiet hotel", 3)
+			if err := pipeline.Run(context.Background(), state); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(state.Context, want) {
+					t.Errorf("context is missing %q:\n%s", want, state.Context)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(state.Context, unwanted) {
+					t.Errorf("context has %q:\n%s", unwanted, state.Context)
+				}
+			}
+			if len(llm.prompts) != 1 || !strings.Contains(llm.prompts[0], tt.want[0]) {
+				t.Errorf("synthesizer prompt does not hold the formatted context: %v", llm.prompts)
+			}
+		})
+	}
+}
+
+func TestPipelineRecordsSpans(t *testing.T) {
+	exporter := telemetrytest.Record(t)
+	searcher := &fakeSearcher{search: func(ctx context.Context, queryVector []float32) ([]models.HotelSearchResult, error) {
+		_, span := telemetry.StartClient(ctx, "aggregate hotels")
+		span.End()
+		return sampleResults(2), nil
+	}}
+	pipeline := NewDefaultPipeline(&fakeLLM{answer: "Hotel 1 is quiet."}, searcher, &PlannerConfig{}, LoadSynthesizerConfigFromEnv(), timeouts.Default())
+	pipeline.SetOutput(io.Discard)
+
+	state := NewPipelineState("quiet hotel", 3)
+	state.SessionID = "session-1"
+	if err := pipeline.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	run := telemetrytest.Span(t, spans, SpanRun)
+	if run.Parent.IsValid() {
+		t.Errorf("%s has parent %v, want a root span", SpanRun, run.Parent.SpanID())
+	}
+	wantAttrs := map[attribute.Key]attribute.Value{
+		semconv.SessionIDKey:     attribute.StringValue("session-1"),
+		telemetry.KKey:           attribute.IntValue(3),
+		telemetry.ResultCountKey: attribute.IntValue(2),
+	}
+	for key, want := range wantAttrs {
+		if got, ok := telemetrytest.Attr(run, key); !ok || got != want {
+			t.Errorf("%s %s = %v, want %v", SpanRun, key, got.Emit(), want.Emit())
+		}
+	}
+
+	for _, name := range []string{StagePlanner, StageSynthesizer, StageCitations} {
+		stage := telemetrytest.Span(t, spans, name)
+		if stage.Parent.SpanID() != run.SpanContext.SpanID() {
+			t.Errorf("stage %s is not a child of %s", name, SpanRun)
+		}
+		if got, _ := telemetrytest.Attr(stage, telemetry.StageKey); got.AsString() != name {
+			t.Errorf("stage %s has %s %q", name, telemetry.StageKey, got.AsString())
+		}
+	}
+
+	// The stage context reaches the store, so its spans nest under the planner
+	search := telemetrytest.Span(t, spans, "aggregate hotels")
+	if planner := telemetrytest.Span(t, spans, StagePlanner); search.Parent.SpanID() != planner.SpanContext.SpanID() {
+		t.Errorf("search span is not a child of the planner stage")
+	}
+}
+
+func TestPipelineSpanRecordsStageError(t *testing.T) {
+	exporter := telemetrytest.Record(t)
+	var order []string
+	errStage := errors.New("stage failed")
+	pipeline := NewPipeline(&fakeStage{name: "failing", order: &order, fn: func(*PipelineState) error { return errStage }})
+
+	if err := pipeline.Run(context.Background(), NewPipelineState("quiet hotel", 3)); !errors.Is(err, errStage) {
+		t.Fatalf("err = %v, want %v", err, errStage)
+	}
+
+	spans := exporter.GetSpans()
+	for _, name := range []string{"failing", SpanRun} {
+		span := telemetrytest.Span(t, spans, name)
+		if span.Status.Code != codes.Error {
+			t.Errorf("span %s status = %v, want error", name, span.Status.Code)
+		}
+	}
+}
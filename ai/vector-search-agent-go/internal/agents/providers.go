@@ -0,0 +1,47 @@
+package agents
+
+import (
+	"context"
+	"io"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/openai/openai-go/v3"
+)
+
+// Embedder turns text into a query vector
+type Embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// ChatModel is the completion API used by the planner and synthesizer
+type ChatModel interface {
+	ChatCompletionWithTools(ctx context.Context, systemPrompt, userMessage string, tools []openai.ChatCompletionToolUnionParam) (*openai.ChatCompletion, error)
+	ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error)
+	ChatCompletionStream(ctx context.Context, systemPrompt, userMessage string, w io.Writer) (string, error)
+}
+
+// LLM is everything the agents need from the model provider. *clients.OpenAIClients
+// implements it for Azure OpenAI, and the offline package for OFFLINE_MODE.
+type LLM interface {
+	Embedder
+	ChatModel
+}
+
+// Searcher finds the hotels nearest to a query vector. *vectorstore.VectorStore
+// implements it for DocumentDB.
+type Searcher interface {
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// RoomSearcher finds the hotel rooms nearest to a query vector. *vectorstore.VectorStore
+// and the offline store implement it.
+type RoomSearcher interface {
+	SearchRooms(ctx context.Context, queryVector []float32, k int) ([]models.RoomSearchResult, error)
+}
+
+// LanguageSearcher is a Searcher that can also search the descriptions in another
+// language. *vectorstore.VectorStore and the offline store implement it.
+type LanguageSearcher interface {
+	Searcher
+	VectorSearchLanguage(ctx context.Context, language string, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
@@ -0,0 +1,64 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+)
+
+// Reranker rescores search results against the user query, for example with a
+// cross-encoder model. It returns one score per result, in the order given; higher is
+// better.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, results []models.HotelSearchResult) ([]float64, error)
+}
+
+// SetReranker makes the planner reorder the hotels it finds by the reranker's scores
+// before they reach the synthesizer. A nil reranker keeps the search order.
+func (a *PlannerAgent) SetReranker(reranker Reranker) {
+	a.reranker = reranker
+}
+
+// rerank reorders results with the planner's reranker, if it has one. When re-ranking
+// fails the search order is kept, so a reranker outage degrades the answer rather than
+// failing it.
+func (a *PlannerAgent) rerank(ctx context.Context, query string, results []models.HotelSearchResult) []models.HotelSearchResult {
+	if a.reranker == nil || len(results) == 0 {
+		return results
+	}
+
+	scores, err := a.reranker.Rerank(ctx, query, results)
+	if err == nil && len(scores) != len(results) {
+		err = fmt.Errorf("reranker returned %d scores for %d results", len(scores), len(results))
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "re-ranking failed, keeping the search order", "err", err)
+		trace.FromContext(ctx).Annotate(StagePlanner, fmt.Sprintf("re-ranking failed: %v", err))
+		return results
+	}
+
+	reranked := rerankResults(results, scores)
+	a.println("Re-ranked:")
+	a.printResults(reranked)
+	return reranked
+}
+
+// rerankResults returns results scored by scores, highest first, and ranked again. Ties
+// keep the search order. RawScore keeps the engine score.
+func rerankResults(results []models.HotelSearchResult, scores []float64) []models.HotelSearchResult {
+	reranked := slices.Clone(results)
+	for i := range reranked {
+		reranked[i].Score = scores[i]
+		reranked[i].Provenance = models.ProvenanceReranked
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	models.RankResults(reranked)
+	return reranked
+}
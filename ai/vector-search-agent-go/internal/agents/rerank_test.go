@@ -0,0 +1,161 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// fakeReranker scores each result by its entry in scores, keyed by hotel ID, and records
+// the results it was asked to rerank
+type fakeReranker struct {
+	scores map[string]float64
+	err    error
+	short  bool
+	seen   []string
+}
+
+func (f *fakeReranker) Rerank(ctx context.Context, query string, results []models.HotelSearchResult) ([]float64, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	scores := make([]float64, 0, len(results))
+	for _, result := range results {
+		f.seen = append(f.seen, result.Hotel.HotelID)
+		scores = append(scores, f.scores[result.Hotel.HotelID])
+	}
+	if f.short {
+		scores = scores[1:]
+	}
+	return scores, nil
+}
+
+// annResult is a hotel as the vector index returns it
+func annResult(id string, score float64, rank int) models.HotelSearchResult {
+	return models.HotelSearchResult{
+		Hotel:      models.HotelForVectorStore{HotelID: id, HotelName: "Hotel " + id},
+		Score:      score,
+		Rank:       rank,
+		RawScore:   score,
+		Provenance: models.ProvenanceANN,
+	}
+}
+
+// fusedSearcher answers the "beach" sub-query with hotels 1 and 2 and the "pool"
+// sub-query with hotels 2 and 3, so hotel 2 is found by both
+func fusedSearcher() *fakeSearcher {
+	return &fakeSearcher{search: func(ctx context.Context, queryVector []float32) ([]models.HotelSearchResult, error) {
+		if queryVector[0] == 1 {
+			return []models.HotelSearchResult{annResult("1", 0.9, 1), annResult("2", 0.6, 2)}, nil
+		}
+		return []models.HotelSearchResult{annResult("2", 0.8, 1), annResult("3", 0.7, 2)}, nil
+	}}
+}
+
+// fusedLLM plans the "beach" and "pool" sub-queries
+func fusedLLM() *fakeLLM {
+	return &fakeLLM{
+		answer:     "Hotel 3 has both.",
+		subQueries: []string{"beach", "pool"},
+		vectors:    map[string][]float32{"beach": {1, 0, 0}, "pool": {0, 1, 0}},
+	}
+}
+
+func TestFusedResultsAreRankedWithRawScores(t *testing.T) {
+	planner, _ := newTestAgents(fusedLLM(), fusedSearcher(), timeouts.Default())
+
+	results, err := planner.Search(context.Background(), "beach and pool", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		id       string
+		score    float64
+		rawScore float64
+	}{{"1", 0.9, 0.9}, {"2", 0.8, 0.8}, {"3", 0.7, 0.7}}
+	if len(results) != len(want) {
+		t.Fatalf("results = %+v, want %d", results, len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Hotel.HotelID != w.id || got.Score != w.score || got.RawScore != w.rawScore || got.Rank != i+1 || got.Provenance != models.ProvenanceFused {
+			t.Errorf("result %d = hotel %s, score %v, raw %v, rank %d, %s; want hotel %s, score %v, raw %v, rank %d, fused",
+				i, got.Hotel.HotelID, got.Score, got.RawScore, got.Rank, got.Provenance, w.id, w.score, w.rawScore, i+1)
+		}
+	}
+}
+
+func TestPipelineFusesAndReranks(t *testing.T) {
+	llm := fusedLLM()
+	reranker := &fakeReranker{scores: map[string]float64{"1": 0.2, "2": 0.5, "3": 0.95}}
+	planner, synthesizer := newTestAgents(llm, fusedSearcher(), timeouts.Default())
+	planner.SetReranker(reranker)
+	pipeline := NewPipeline(planner, synthesizer, NewCitationAgent())
+	pipeline.SetOutput(io.Discard)
+
+	state := NewPipelineState("beach and pool", 5)
+	if err := pipeline.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reranker sees the fused order and reverses it; raw scores stay the engine's
+	if strings.Join(reranker.seen, ",") != "1,2,3" {
+		t.Errorf("reranker saw %v, want the fused order 1,2,3", reranker.seen)
+	}
+	want := []struct {
+		id       string
+		score    float64
+		rawScore float64
+	}{{"3", 0.95, 0.7}, {"2", 0.5, 0.8}, {"1", 0.2, 0.9}}
+	if len(state.Results) != len(want) {
+		t.Fatalf("results = %+v, want %d", state.Results, len(want))
+	}
+	for i, w := range want {
+		got := state.Results[i]
+		if got.Hotel.HotelID != w.id || got.Score != w.score || got.RawScore != w.rawScore || got.Rank != i+1 || got.Provenance != models.ProvenanceReranked {
+			t.Errorf("result %d = hotel %s, score %v, raw %v, rank %d, %s; want hotel %s, score %v, raw %v, rank %d, reranked",
+				i, got.Hotel.HotelID, got.Score, got.RawScore, got.Rank, got.Provenance, w.id, w.score, w.rawScore, i+1)
+		}
+	}
+
+	// The synthesizer reads the reranked order with ranks and provenance
+	first := strings.Index(state.Context, "HotelName: Hotel 3")
+	last := strings.Index(state.Context, "HotelName: Hotel 1")
+	if first < 0 || last < first || !strings.Contains(state.Context, "Score: 0.950000\nRank: 1\nProvenance: reranked") {
+		t.Errorf("context does not follow the reranked order:\n%s", state.Context)
+	}
+	if len(state.Citations) != 1 || state.Citations[0].HotelID != "3" || state.Citations[0].Rank != 1 {
+		t.Errorf("citations = %+v, want hotel 3 at rank 1", state.Citations)
+	}
+}
+
+func TestRerankFailureKeepsSearchOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		reranker *fakeReranker
+	}{
+		{"error", &fakeReranker{err: errors.New("reranker unavailable")}},
+		{"missing scores", &fakeReranker{scores: map[string]float64{"1": 0.1}, short: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planner, _ := newTestAgents(fusedLLM(), fusedSearcher(), timeouts.Default())
+			planner.SetReranker(tt.reranker)
+
+			results, err := planner.Search(context.Background(), "beach and pool", 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 3 || results[0].Hotel.HotelID != "1" || results[0].Provenance != models.ProvenanceFused {
+				t.Errorf("results = %+v, want the fused order", results)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/openai/openai-go/v3"
+)
+
+// RoomSearchTool searches the rooms collection, for requests about specific rooms
+type RoomSearchTool struct {
+	embedder Embedder
+	searcher RoomSearcher
+}
+
+// NewRoomSearchTool creates a new room search tool
+func NewRoomSearchTool(embedder Embedder, searcher RoomSearcher) *RoomSearchTool {
+	return &RoomSearchTool{
+		embedder: embedder,
+		searcher: searcher,
+	}
+}
+
+// Search finds the nearestNeighbors rooms closest to query and returns them grouped by
+// parent hotel, best hotel first. It returns ErrNoResults when no room matches.
+func (t *RoomSearchTool) Search(ctx context.Context, query string, nearestNeighbors int) ([]models.HotelRooms, error) {
+	queryVector, err := t.embedder.GenerateEmbedding(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	results, err := t.searcher.SearchRooms(ctx, queryVector, nearestNeighbors)
+	if err != nil {
+		return nil, fmt.Errorf("room search failed: %w", err)
+	}
+
+	slog.DebugContext(ctx, "room search completed", "query", query, "k", nearestNeighbors, "results", len(results))
+
+	if len(results) == 0 {
+		slog.InfoContext(ctx, "room search matched no rooms", "query", query)
+		return nil, ErrNoResults
+	}
+
+	return models.GroupRoomsByHotel(results), nil
+}
+
+// FormatRoomResults formats room search results, grouped by hotel, for the synthesizer
+func FormatRoomResults(groups []models.HotelRooms) string {
+	formatted := make([]string, 0, len(groups))
+	for _, group := range groups {
+		formatted = append(formatted, vectorstore.FormatHotelRoomsForSynthesizer(group))
+	}
+
+	return strings.Join(formatted, "\n\n")
+}
+
+// GetToolDefinition returns the Azure OpenAI tool definition
+func (t *RoomSearchTool) GetToolDefinition() openai.ChatCompletionToolUnionParam {
+	paramSchema := roomSearchSchema.jsonSchema()
+
+	return openai.ChatCompletionToolUnionParam{
+		OfFunction: &openai.ChatCompletionFunctionToolParam{
+			Function: openai.FunctionDefinitionParam{
+				Name:        prompts.RoomsToolName,
+				Description: openai.String(prompts.RoomsToolDescription),
+				Parameters:  paramSchema,
+			},
+		},
+	}
+}
+
+// roomHotels returns the parent hotel of each room group as a search result scored by
+// its best room, leaving out hotels already in results. The score fuses the room
+// scores, so the hotels are marked as fused.
+func roomHotels(groups []models.HotelRooms, results []models.HotelSearchResult) []models.HotelSearchResult {
+	var hotels []models.HotelSearchResult
+	for _, group := range groups {
+		found := false
+		for _, result := range results {
+			if result.Hotel.HotelID == group.HotelID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			hotels = append(hotels, models.HotelSearchResult{
+				Hotel:      models.HotelForVectorStore{HotelID: group.HotelID, HotelName: group.HotelName},
+				Score:      group.Score,
+				RawScore:   group.Score,
+				Provenance: models.ProvenanceFused,
+			})
+		}
+	}
+	return hotels
+}
@@ -0,0 +1,145 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// fakeRoomSearcher is a fakeSearcher that also returns its rooms, at most k of them
+type fakeRoomSearcher struct {
+	fakeSearcher
+	rooms        []models.RoomSearchResult
+	roomSearches int
+}
+
+func (f *fakeRoomSearcher) SearchRooms(ctx context.Context, queryVector []float32, k int) ([]models.RoomSearchResult, error) {
+	f.mu.Lock()
+	f.roomSearches++
+	f.mu.Unlock()
+	return f.rooms[:min(k, len(f.rooms))], nil
+}
+
+// sampleRooms returns rooms of the sample data's structure in two hotels, in score order
+func sampleRooms() []models.RoomSearchResult {
+	room := func(hotelID, hotelName, roomID, roomType string, rate, score float64) models.RoomSearchResult {
+		return models.RoomSearchResult{
+			Room: models.RoomForVectorStore{
+				RoomID: roomID, HotelID: hotelID, HotelName: hotelName, Type: roomType,
+				Description: roomType + ", 2 Queen Beds", BedOptions: "2 Queen Beds", SleepsCount: 4, BaseRate: rate,
+			},
+			Score: score,
+		}
+	}
+	return []models.RoomSearchResult{
+		room("3", "Gastronomic Landscape Hotel", "3:2", "Suite", 189.99, 0.91),
+		room("1", "Stay-Kay City Hotel", "1:4", "Suite", 199.99, 0.88),
+		room("3", "Gastronomic Landscape Hotel", "3:5", "Deluxe Room", 159.99, 0.85),
+	}
+}
+
+// newRoomPlanner builds a planner with the room search tool over store
+func newRoomPlanner(llm *fakeLLM, store *fakeRoomSearcher) *PlannerAgent {
+	planner, _ := newTestAgents(llm, &store.fakeSearcher, timeouts.Default())
+	planner.SetRoomTool(NewRoomSearchTool(llm, store))
+	return planner
+}
+
+func TestPlannerSearchesRooms(t *testing.T) {
+	llm := &fakeLLM{roomQueries: []string{"suite with two queen beds under $200"}}
+	store := &fakeRoomSearcher{rooms: sampleRooms()}
+	planner := newRoomPlanner(llm, store)
+
+	state := NewPipelineState("suite with two queen beds under $200", 5)
+	if err := planner.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.roomSearches != 1 || store.searches != 0 {
+		t.Errorf("room searches %d, hotel searches %d, want the room search only", store.roomSearches, store.searches)
+	}
+	// Rooms are grouped by hotel, best hotel first
+	if len(state.Rooms) != 2 || state.Rooms[0].HotelID != "3" || len(state.Rooms[0].Rooms) != 2 || state.Rooms[1].HotelID != "1" {
+		t.Fatalf("rooms = %+v, want hotel 3 with 2 rooms, then hotel 1", state.Rooms)
+	}
+	// The parent hotels can be cited
+	if len(state.Results) != 2 || state.Results[0].Hotel.HotelName != "Gastronomic Landscape Hotel" || state.Results[0].Score != 0.91 {
+		t.Errorf("results = %+v, want the parent hotels", state.Results)
+	}
+	if strings.Count(state.Context, "--- HOTEL ROOMS START ---") != 2 || strings.Count(state.Context, "--- ROOM START ---") != 3 ||
+		strings.Contains(state.Context, "--- HOTEL START ---") {
+		t.Errorf("context:\n%s", state.Context)
+	}
+	if state.SearchQuery != "suite with two queen beds under $200" {
+		t.Errorf("search query = %q", state.SearchQuery)
+	}
+}
+
+func TestPlannerSearchesRoomsAndHotels(t *testing.T) {
+	llm := &fakeLLM{subQueries: []string{"hotel near Times Square"}, roomQueries: []string{"suite", "deluxe room"}}
+	store := &fakeRoomSearcher{fakeSearcher: fakeSearcher{hotels: sampleResults(1)}, rooms: sampleRooms()}
+	planner := newRoomPlanner(llm, store)
+
+	state := NewPipelineState("a suite near Times Square", 5)
+	if err := planner.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both room searches found the same rooms; each room is kept once
+	if store.roomSearches != 2 || len(state.Rooms) != 2 || len(state.Rooms[0].Rooms) != 2 {
+		t.Errorf("room searches %d, rooms %+v", store.roomSearches, state.Rooms)
+	}
+	// Hotel 1 was found by both searches and is listed once
+	if len(state.Results) != 2 || state.Results[0].Hotel.HotelID != "1" || state.Results[1].Hotel.HotelID != "3" {
+		t.Errorf("results = %+v, want hotel 1 from the hotel search, then hotel 3", state.Results)
+	}
+	if !strings.Contains(state.Context, "--- HOTEL START ---") || !strings.Contains(state.Context, "--- HOTEL ROOMS START ---") {
+		t.Errorf("context lacks hotels or rooms:\n%s", state.Context)
+	}
+	if state.SearchQuery != "hotel near Times Square | suite | deluxe room" {
+		t.Errorf("search query = %q", state.SearchQuery)
+	}
+}
+
+func TestPlannerNoMatchingRooms(t *testing.T) {
+	llm := &fakeLLM{roomQueries: []string{"treehouse"}}
+	store := &fakeRoomSearcher{}
+	planner := newRoomPlanner(llm, store)
+
+	state := NewPipelineState("treehouse", 5)
+	if err := planner.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Context != "" || state.Rooms != nil || state.Results != nil {
+		t.Errorf("state = %+v, want an empty result", state)
+	}
+}
+
+func TestPlannerRejectsRoomToolWhenNotOffered(t *testing.T) {
+	llm := &fakeLLM{roomQueries: []string{"suite"}}
+	planner, _ := newTestAgents(llm, &fakeSearcher{}, timeouts.Default())
+
+	_, err := planner.Search(context.Background(), "suite", 5)
+	if err == nil || !strings.Contains(err.Error(), "unexpected tool called: search_rooms") || errors.Is(err, ErrNoResults) {
+		t.Errorf("err = %v, want search_rooms rejected", err)
+	}
+}
+
+func TestNewDefaultPipelineRoomSearch(t *testing.T) {
+	for _, roomSearch := range []bool{false, true} {
+		pipeline := NewDefaultPipeline(&fakeLLM{}, &fakeRoomSearcher{}, &PlannerConfig{RoomSearch: roomSearch}, LoadSynthesizerConfigFromEnv(), timeouts.Default())
+		planner := pipeline.Stages()[0].(*PlannerAgent)
+		if (planner.roomTool != nil) != roomSearch {
+			t.Errorf("RoomSearch %v: room tool offered = %v", roomSearch, planner.roomTool != nil)
+		}
+	}
+	// A store without rooms never gets the tool
+	pipeline := NewDefaultPipeline(&fakeLLM{}, &fakeSearcher{}, &PlannerConfig{RoomSearch: true}, LoadSynthesizerConfigFromEnv(), timeouts.Default())
+	if pipeline.Stages()[0].(*PlannerAgent).roomTool != nil {
+		t.Error("room tool offered for a store without rooms")
+	}
+}
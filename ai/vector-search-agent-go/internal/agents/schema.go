@@ -0,0 +1,233 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Argument types of the tool schemas
+const (
+	typeString  = "string"
+	typeInteger = "integer"
+)
+
+// toolParameter is one argument of a search tool
+type toolParameter struct {
+	name        string
+	typ         string
+	description string
+	required    bool
+	// clampK marks a number of nearest neighbors, advertised with the bounds of
+	// vectorstore.ClampK and clamped to them
+	clampK bool
+	// defaultValue, when set, is advertised to the model; validation doesn't fill it in
+	defaultValue any
+	// enum is advertised to the model but not enforced: the tools fall back to
+	// English for a language they don't support
+	enum []string
+}
+
+// toolSchema describes the arguments of a search tool. The parameter schema of the
+// tool definition and the validation of the arguments the planner returns are both
+// built from it, so the two can't disagree.
+type toolSchema struct {
+	tool       string
+	parameters []toolParameter
+}
+
+// hotelSearchSchema is the schema of the hotel search tool
+var hotelSearchSchema = toolSchema{
+	tool: prompts.ToolName,
+	parameters: []toolParameter{
+		{
+			name:        "query",
+			typ:         typeString,
+			description: "Natural language search query describing desired hotel characteristics",
+			required:    true,
+		},
+		{
+			name:         "nearestNeighbors",
+			typ:          typeInteger,
+			description:  "Number of results to return",
+			required:     true,
+			clampK:       true,
+			defaultValue: 5,
+		},
+		{
+			name:        "language",
+			typ:         typeString,
+			description: "Language of the hotel descriptions to search: en (default) or fr",
+			enum:        []string{vectorstore.LanguageEnglish, vectorstore.LanguageFrench},
+		},
+	},
+}
+
+// roomSearchSchema is the schema of the room search tool
+var roomSearchSchema = toolSchema{
+	tool: prompts.RoomsToolName,
+	parameters: []toolParameter{
+		{
+			name:        "query",
+			typ:         typeString,
+			description: "Natural language description of the room wanted: type, beds, occupancy, price, amenities",
+			required:    true,
+		},
+		{
+			name:         "nearestNeighbors",
+			typ:          typeInteger,
+			description:  "Number of rooms to return",
+			required:     true,
+			clampK:       true,
+			defaultValue: 10,
+		},
+	},
+}
+
+// schemaFor returns the schema of the named tool
+func schemaFor(tool string) (toolSchema, bool) {
+	switch tool {
+	case hotelSearchSchema.tool:
+		return hotelSearchSchema, true
+	case roomSearchSchema.tool:
+		return roomSearchSchema, true
+	}
+	return toolSchema{}, false
+}
+
+// jsonSchema returns the JSON Schema of the tool's parameters for the tool definition
+func (s toolSchema) jsonSchema() map[string]any {
+	properties := make(map[string]any, len(s.parameters))
+	required := []string{}
+	for _, p := range s.parameters {
+		property := map[string]any{"type": p.typ, "description": p.description}
+		if p.clampK {
+			maxK := vectorstore.MaxK()
+			property["description"] = fmt.Sprintf("%s (%d-%d)", p.description, vectorstore.MinK, maxK)
+			property["minimum"] = vectorstore.MinK
+			property["maximum"] = maxK
+		}
+		if p.defaultValue != nil {
+			property["default"] = p.defaultValue
+		}
+		if p.enum != nil {
+			property["enum"] = p.enum
+		}
+		properties[p.name] = property
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+// ArgumentViolation is one way a tool call's argument breaks the tool's schema
+type ArgumentViolation struct {
+	// Argument names the argument
+	Argument string
+	// Problem says what is wrong with it, quoting the value the model sent
+	Problem string
+}
+
+// ToolArgumentsError reports the arguments of a tool call that break the tool's schema.
+// It wraps ErrInvalidToolArguments, and the planner answers it by asking the model to
+// repair the call.
+type ToolArgumentsError struct {
+	Tool       string
+	Violations []ArgumentViolation
+}
+
+func (e *ToolArgumentsError) Error() string {
+	problems := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		problems[i] = v.Argument + " " + v.Problem
+	}
+	return fmt.Sprintf("%v to %s: %s", ErrInvalidToolArguments, e.Tool, strings.Join(problems, "; "))
+}
+
+func (e *ToolArgumentsError) Unwrap() error {
+	return ErrInvalidToolArguments
+}
+
+// validate checks args against the schema and returns the value of each argument
+// given, as a string or an int. Models don't always follow the schema, so it accepts
+// what can be read unambiguously: an integer given as a string or a whole float, and
+// null or "" for an argument that wasn't given. A number of nearest neighbors out of
+// bounds is clamped rather than rejected. Unknown arguments are ignored. Every
+// violation is reported in one *ToolArgumentsError.
+func (s toolSchema) validate(ctx context.Context, args map[string]any) (map[string]any, error) {
+	values := make(map[string]any, len(s.parameters))
+	var violations []ArgumentViolation
+	for _, p := range s.parameters {
+		value, problem := p.read(args[p.name])
+		if n, ok := value.(int); ok && p.clampK {
+			value = vectorstore.ClampK(ctx, n)
+		}
+		switch {
+		case problem != "":
+			violations = append(violations, ArgumentViolation{Argument: p.name, Problem: problem})
+		case value != nil:
+			values[p.name] = value
+		case p.required:
+			violations = append(violations, ArgumentViolation{Argument: p.name, Problem: "is missing"})
+		}
+	}
+	if len(violations) > 0 {
+		return nil, &ToolArgumentsError{Tool: s.tool, Violations: violations}
+	}
+	return values, nil
+}
+
+// read returns the value of the parameter in raw, nil when it wasn't given, or a problem
+func (p toolParameter) read(raw any) (any, string) {
+	if raw == nil {
+		return nil, ""
+	}
+	if p.typ == typeInteger {
+		return p.readInteger(raw)
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fragment(raw) + " is not a string"
+	}
+	if strings.TrimSpace(s) == "" {
+		if p.required {
+			return nil, "is empty"
+		}
+		return nil, ""
+	}
+	return s, ""
+}
+
+// readInteger reads a whole number, given as a number or a string. Numbers beyond the
+// int32 range read as its bounds, which are far outside any count a tool accepts.
+func (p toolParameter) readInteger(raw any) (any, string) {
+	var n float64
+	switch v := raw.(type) {
+	case float64:
+		n = v
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return nil, ""
+		}
+		parsed, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fragment(raw) + " is not a number"
+		}
+		n = parsed
+	default:
+		return nil, fragment(raw) + " is not a number"
+	}
+
+	if math.IsInf(n, 0) || math.IsNaN(n) || n != math.Trunc(n) {
+		return nil, fragment(raw) + " is not a whole number"
+	}
+	return int(max(min(n, math.MaxInt32), math.MinInt32)), ""
+}
@@ -0,0 +1,187 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients/clientstest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/openai/openai-go/v3"
+)
+
+func TestParseToolArgumentsReportsEveryViolation(t *testing.T) {
+	_, err := parseToolArgumentsFromMap(context.Background(), roomSearchSchema, decodeArguments(t, `{"query":7,"nearestNeighbors":2.5}`))
+	var argsErr *ToolArgumentsError
+	if !errors.As(err, &argsErr) {
+		t.Fatalf("err = %v, want a *ToolArgumentsError", err)
+	}
+	want := []ArgumentViolation{{"query", "7 is not a string"}, {"nearestNeighbors", "2.5 is not a whole number"}}
+	if argsErr.Tool != prompts.RoomsToolName || !slices.Equal(argsErr.Violations, want) {
+		t.Errorf("error = %+v, want violations %+v of %s", *argsErr, want, prompts.RoomsToolName)
+	}
+	if got := err.Error(); got != "invalid tool arguments to search_rooms: query 7 is not a string; nearestNeighbors 2.5 is not a whole number" {
+		t.Errorf("message = %q", got)
+	}
+}
+
+// TestToolDefinitionsComeFromTheSchemas checks that the parameters advertised to the
+// model are the ones the validator enforces
+func TestToolDefinitionsComeFromTheSchemas(t *testing.T) {
+	tests := []struct {
+		definition openai.ChatCompletionToolUnionParam
+		schema     toolSchema
+	}{
+		{NewVectorSearchTool(nil, nil).GetToolDefinition(), hotelSearchSchema},
+		{NewRoomSearchTool(nil, nil).GetToolDefinition(), roomSearchSchema},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema.tool, func(t *testing.T) {
+			function := tt.definition.OfFunction.Function
+			if function.Name != tt.schema.tool {
+				t.Errorf("tool name = %s, want %s", function.Name, tt.schema.tool)
+			}
+			params := function.Parameters
+			if !reflect.DeepEqual(params["required"], []string{"query", "nearestNeighbors"}) {
+				t.Errorf("required = %v", params["required"])
+			}
+			properties := params["properties"].(map[string]any)
+			if len(properties) != len(tt.schema.parameters) {
+				t.Errorf("properties = %v, want one per schema parameter", properties)
+			}
+			k := properties["nearestNeighbors"].(map[string]any)
+			if k["type"] != "integer" || k["minimum"] != vectorstore.MinK || k["maximum"] != vectorstore.MaxK() {
+				t.Errorf("nearestNeighbors = %v, want an integer from %d to %d", k, vectorstore.MinK, vectorstore.MaxK())
+			}
+
+			// The advertised default is kept, and values beyond the advertised bounds are
+			// clamped to them
+			for n, want := range map[int]int{k["default"].(int): k["default"].(int), vectorstore.MinK - 1: vectorstore.MinK, vectorstore.MaxK() + 1: vectorstore.MaxK()} {
+				values, err := tt.schema.validate(context.Background(), map[string]any{"query": "pool", "nearestNeighbors": float64(n)})
+				if err != nil || values["nearestNeighbors"] != want {
+					t.Errorf("nearestNeighbors %d: values = %v, %v, want %d", n, values, err, want)
+				}
+			}
+		})
+	}
+}
+
+// newRepairPlanner builds a planner whose model is the fake Azure OpenAI resource srv,
+// searching with searcher
+func newRepairPlanner(t *testing.T, srv *clientstest.Server, searcher *fakeSearcher) *PlannerAgent {
+	t.Helper()
+	llm, err := clients.NewOpenAIClients(&clients.OpenAIConfig{
+		Endpoint:            "https://fake.openai.azure.com",
+		BaseURL:             srv.URL,
+		APIKey:              "test-key",
+		EmbeddingDeployment: "embed-deployment",
+		PlannerDeployment:   "plan-deployment",
+		SynthDeployment:     "synth-deployment",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	planner := NewPlannerAgent(llm, NewVectorSearchTool(llm, searcher), &PlannerConfig{}, timeouts.Default())
+	planner.SetOutput(io.Discard)
+	return planner
+}
+
+// plannerMessages returns the user messages of the planner requests srv received
+func plannerMessages(t *testing.T, srv *clientstest.Server) []string {
+	t.Helper()
+	var messages []string
+	for _, req := range srv.Requests() {
+		if req.Deployment != "plan-deployment" {
+			continue
+		}
+		for _, message := range req.Body["messages"].([]any) {
+			if m := message.(map[string]any); m["role"] == "user" {
+				messages = append(messages, m["content"].(string))
+			}
+		}
+	}
+	return messages
+}
+
+func TestPlannerRepairsInvalidToolCall(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		problem   string
+	}{
+		{"fractional count", `{"query":"quiet hotel","nearestNeighbors":2.5}`, "nearestNeighbors 2.5 is not a whole number"},
+		{"wrong type", `{"query":["quiet","hotel"],"nearestNeighbors":3}`, `query ["quiet","hotel"] is not a string`},
+		{"missing field", `{"nearestNeighbors":3}`, "query is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := clientstest.NewServer(t)
+			srv.Script("plan-deployment",
+				clientstest.ToolCalls(clientstest.ToolCall{Name: prompts.ToolName, Arguments: tt.arguments}),
+				clientstest.ToolCalls(clientstest.ToolCall{Name: prompts.ToolName, Arguments: `{"query":"quiet hotel","nearestNeighbors":3}`}),
+			)
+			planner := newRepairPlanner(t, srv, &fakeSearcher{hotels: sampleResults(20)})
+
+			results, err := planner.Search(context.Background(), "quiet hotel", 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 3 {
+				t.Errorf("got %d results, want the 3 the repaired call asked for", len(results))
+			}
+
+			// The second request repeats the search and says what was wrong with the first call
+			messages := plannerMessages(t, srv)
+			if len(messages) != 2 {
+				t.Fatalf("planner requests = %d, want 2", len(messages))
+			}
+			if !strings.HasPrefix(messages[1], messages[0]) || !strings.Contains(messages[1], "rejected") || !strings.Contains(messages[1], tt.problem) {
+				t.Errorf("repair message = %q, want the request and %q", messages[1], tt.problem)
+			}
+		})
+	}
+}
+
+func TestPlannerClampsToolCallNeighbors(t *testing.T) {
+	srv := clientstest.NewServer(t)
+	srv.Script("plan-deployment", clientstest.ToolCalls(clientstest.ToolCall{Name: prompts.ToolName, Arguments: `{"query":"quiet hotel","nearestNeighbors":500}`}))
+	searcher := &fakeSearcher{hotels: sampleResults(30)}
+	planner := newRepairPlanner(t, srv, searcher)
+
+	if _, err := planner.Search(context.Background(), "quiet hotel", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// The call is clamped rather than repaired
+	if got := len(plannerMessages(t, srv)); got != 1 {
+		t.Errorf("planner requests = %d, want 1", got)
+	}
+	if searcher.gotK != vectorstore.DefaultMaxK {
+		t.Errorf("searched for %d neighbors, want %d", searcher.gotK, vectorstore.DefaultMaxK)
+	}
+}
+
+func TestPlannerGivesUpAfterOneRepair(t *testing.T) {
+	srv := clientstest.NewServer(t)
+	invalid := clientstest.ToolCalls(clientstest.ToolCall{Name: prompts.ToolName, Arguments: `{"query":"quiet hotel","nearestNeighbors":0.5}`})
+	srv.Script("plan-deployment", invalid, invalid, invalid)
+	planner := newRepairPlanner(t, srv, &fakeSearcher{hotels: sampleResults(20)})
+
+	_, err := planner.Search(context.Background(), "quiet hotel", 3)
+	var argsErr *ToolArgumentsError
+	if !errors.As(err, &argsErr) || argsErr.Violations[0].Argument != "nearestNeighbors" {
+		t.Fatalf("err = %v, want the *ToolArgumentsError of the repaired call", err)
+	}
+	if got := len(plannerMessages(t, srv)); got != 1+maxToolCallRepairs {
+		t.Errorf("planner requests = %d, want %d", got, 1+maxToolCallRepairs)
+	}
+}
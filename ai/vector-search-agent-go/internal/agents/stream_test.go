@@ -0,0 +1,46 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestSynthesizerStreamsAnswer(t *testing.T) {
+	llm := &fakeLLM{answer: "Stay at Hotel 1 for the pool."}
+	planner, synthesizer := newTestAgents(llm, &fakeSearcher{hotels: sampleResults(3)}, timeouts.Default())
+
+	var stream bytes.Buffer
+	synthesizer.SetStreamWriter(&stream)
+	conv := NewConversation(planner, synthesizer)
+	ctx := context.Background()
+
+	answer, err := conv.Ask(ctx, "quiet hotel with a pool", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.String() != llm.answer || answer != llm.answer {
+		t.Errorf("streamed %q and returned %q, want %q for both", stream.String(), answer, llm.answer)
+	}
+
+	// Follow-ups stream too
+	stream.Reset()
+	if _, err := conv.Ask(ctx, "does the second one have parking?", 3); err != nil {
+		t.Fatal(err)
+	}
+	if stream.String() != llm.answer {
+		t.Errorf("follow-up streamed %q, want %q", stream.String(), llm.answer)
+	}
+
+	// A nil writer restores buffered completions
+	stream.Reset()
+	synthesizer.SetStreamWriter(nil)
+	if _, err := conv.Ask(ctx, "hotel with parking", 3); err != nil {
+		t.Fatal(err)
+	}
+	if stream.Len() != 0 {
+		t.Errorf("buffered completion streamed %q", stream.String())
+	}
+}
@@ -0,0 +1,184 @@
+package agents
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+)
+
+// summaryStages maps the summary rows to the trace events they aggregate
+var summaryStages = []struct {
+	name  string
+	event string
+}{
+	{"embedding", trace.EventEmbedding},
+	{"search", trace.EventVectorSearch},
+	{"planner", trace.EventPlannerCompletion},
+	{"tool", trace.EventToolExecution},
+	{"context", trace.EventContextAssembly},
+	{"synthesizer", trace.EventSynthesizerCompletion},
+}
+
+// StageOther is the summary row for the wall time no other row accounts for, such as
+// re-ranking, citations, and the pipeline itself
+const StageOther = "other"
+
+// StageLatency is the accumulated wall time of one kind of step in a run
+type StageLatency struct {
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+	Count    int           `json:"count"`
+	// Percent is Duration as a percentage of the run's total wall time, to one decimal
+	Percent float64 `json:"percent"`
+}
+
+// RunSummary describes where time and tokens went in one agent run
+type RunSummary struct {
+	Total              time.Duration             `json:"total"`
+	Stages             []StageLatency            `json:"stages"`
+	Usage              []clients.DeploymentUsage `json:"usage"`
+	EstimatedCost      float64                   `json:"estimatedCost"`
+	DocumentsRetrieved int                       `json:"documentsRetrieved"`
+	// DegradedSearch is set when a search ran without its vector index, comparing every
+	// stored vector instead; it says why and that latency is higher than usual
+	DegradedSearch string `json:"degradedSearch,omitempty"`
+	Err            string `json:"error,omitempty"`
+}
+
+// BuildRunSummary builds the summary from the execution trace and token usage of a run.
+// It works on partial state, so failed runs still show where time went.
+func BuildRunSummary(state *PipelineState, usage []clients.DeploymentUsage, total time.Duration, runErr error) RunSummary {
+	summary := RunSummary{
+		Total:              total,
+		Usage:              usage,
+		DocumentsRetrieved: len(state.Results),
+	}
+	if runErr != nil {
+		summary.Err = runErr.Error()
+	}
+
+	events := state.Trace.Events()
+	var accounted time.Duration
+	for _, stage := range summaryStages {
+		latency := StageLatency{Stage: stage.name}
+		for _, event := range events {
+			if event.Name == stage.event {
+				latency.Duration += event.Duration
+				latency.Count++
+			}
+		}
+		if stage.event == trace.EventToolExecution {
+			// The embedding and search rows already hold the time spent inside the tool
+			latency.Duration -= nestedInTool(events)
+		}
+		latency.Percent = percent(latency.Duration, total)
+		accounted += latency.Duration
+		summary.Stages = append(summary.Stages, latency)
+	}
+	other := max(total-accounted, 0)
+	summary.Stages = append(summary.Stages, StageLatency{Stage: StageOther, Duration: other, Percent: percent(other, total)})
+
+	for _, u := range usage {
+		summary.EstimatedCost += u.EstimatedCost
+	}
+	summary.DegradedSearch = degradedSearch(events)
+
+	return summary
+}
+
+// degradedSearch describes the searches of a run that fell back to exact search, or
+// returns "" when every search used the vector index
+func degradedSearch(events []trace.Event) string {
+	for _, event := range events {
+		if event.Name == trace.EventExactFallback {
+			return "exact search (" + event.Note + "); latency is higher than with the vector index, create it with cmd/upload"
+		}
+	}
+	return ""
+}
+
+// nestedInTool returns the time of the embedding and vector search events that ran
+// within a tool execution event
+func nestedInTool(events []trace.Event) time.Duration {
+	var nested time.Duration
+	for _, inner := range events {
+		if inner.Name != trace.EventEmbedding && inner.Name != trace.EventVectorSearch {
+			continue
+		}
+		for _, tool := range events {
+			if tool.Name == trace.EventToolExecution && within(inner, tool) {
+				nested += inner.Duration
+				break
+			}
+		}
+	}
+	return nested
+}
+
+// within reports whether inner started and ended while outer ran
+func within(inner, outer trace.Event) bool {
+	return !inner.Start.Before(outer.Start) && !inner.Start.Add(inner.Duration).After(outer.Start.Add(outer.Duration))
+}
+
+// percent returns d as a percentage of total, rounded to one decimal
+func percent(d, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(d)/float64(total)*1000) / 10
+}
+
+// Render writes the summary as a human-readable block
+func (s RunSummary) Render(w io.Writer) {
+	fmt.Fprintln(w, "\n--- RUN SUMMARY ---")
+	if s.Err != "" {
+		fmt.Fprintf(w, "Status: failed (%s)\n", s.Err)
+	}
+	if s.DegradedSearch != "" {
+		fmt.Fprintf(w, "DEGRADED: %s\n", s.DegradedSearch)
+	}
+	fmt.Fprintf(w, "Total wall time: %s\n", s.Total.Round(time.Millisecond))
+
+	fmt.Fprintln(w, "Latency by stage:")
+	for _, stage := range s.Stages {
+		if stage.Stage == StageOther {
+			fmt.Fprintf(w, "  %-12s %10s\n", stage.Stage, stage.Duration.Round(time.Millisecond))
+			continue
+		}
+		fmt.Fprintf(w, "  %-12s %10s  (%d calls)\n", stage.Stage, stage.Duration.Round(time.Millisecond), stage.Count)
+	}
+
+	fmt.Fprintln(w, "Token usage by deployment:")
+	if len(s.Usage) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	for _, u := range s.Usage {
+		cost := "n/a"
+		if u.PriceKnown {
+			cost = fmt.Sprintf("$%.6f", u.EstimatedCost)
+		}
+		fmt.Fprintf(w, "  %-24s prompt=%d completion=%d calls=%d est. cost=%s\n", u.Deployment, u.PromptTokens, u.CompletionTokens, u.Calls, cost)
+	}
+	fmt.Fprintf(w, "Estimated total cost: $%.6f\n", s.EstimatedCost)
+	fmt.Fprintf(w, "Documents retrieved: %d\n", s.DocumentsRetrieved)
+}
+
+// RenderBreakdown writes the latency of each stage as a table with its share of the
+// total wall time, for debugging slow runs. Concurrent sub-query searches overlap, so
+// the shares can add up to more than 100%.
+func (s RunSummary) RenderBreakdown(w io.Writer) {
+	fmt.Fprintln(w, "\n--- LATENCY BREAKDOWN ---")
+	fmt.Fprintf(w, "%-12s %10s %7s %6s\n", "STAGE", "TIME", "SHARE", "CALLS")
+	for _, stage := range s.Stages {
+		calls := fmt.Sprint(stage.Count)
+		if stage.Stage == StageOther {
+			calls = "-"
+		}
+		fmt.Fprintf(w, "%-12s %10s %6.1f%% %6s\n", stage.Stage, stage.Duration.Round(time.Microsecond), stage.Percent, calls)
+	}
+	fmt.Fprintf(w, "%-12s %10s %6.1f%%\n", "total", s.Total.Round(time.Microsecond), percent(s.Total, s.Total))
+}
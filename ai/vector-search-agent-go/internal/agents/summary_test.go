@@ -0,0 +1,234 @@
+package agents
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+)
+
+// summaryFixture returns a state whose trace holds two embeddings, one search,
+// one planner call and one synthesizer call, plus the usage of those calls
+func summaryFixture() (*PipelineState, []clients.DeploymentUsage) {
+	tr := trace.New("session-1")
+	for _, event := range []trace.Event{
+		{Name: trace.EventPlannerCompletion, Duration: 400 * time.Millisecond},
+		{Name: trace.EventEmbedding, Duration: 30 * time.Millisecond},
+		{Name: trace.EventEmbedding, Duration: 20 * time.Millisecond},
+		{Name: trace.EventVectorSearch, Duration: 15 * time.Millisecond},
+		{Name: trace.EventSynthesizerCompletion, Duration: 1200 * time.Millisecond},
+		{Name: StagePlanner, Duration: 500 * time.Millisecond},
+	} {
+		tr.Add(event)
+	}
+
+	usage := []clients.DeploymentUsage{
+		{Deployment: "gpt-4o", Calls: 1, PromptTokens: 2000, CompletionTokens: 300, EstimatedCost: 0.008, PriceKnown: true},
+		{Deployment: "text-embedding-3-small", Calls: 2, PromptTokens: 40, EstimatedCost: 0.0000008, PriceKnown: true},
+		{Deployment: "custom-planner", Calls: 1, PromptTokens: 500, CompletionTokens: 50},
+	}
+
+	return &PipelineState{Results: sampleResults(4), Trace: tr}, usage
+}
+
+func TestBuildRunSummary(t *testing.T) {
+	state, usage := summaryFixture()
+	summary := BuildRunSummary(state, usage, 2*time.Second, nil)
+
+	want := []StageLatency{
+		{Stage: "embedding", Duration: 50 * time.Millisecond, Count: 2, Percent: 2.5},
+		{Stage: "search", Duration: 15 * time.Millisecond, Count: 1, Percent: 0.8},
+		{Stage: "planner", Duration: 400 * time.Millisecond, Count: 1, Percent: 20},
+		{Stage: "tool"},
+		{Stage: "context"},
+		{Stage: "synthesizer", Duration: 1200 * time.Millisecond, Count: 1, Percent: 60},
+		{Stage: StageOther, Duration: 335 * time.Millisecond, Percent: 16.8},
+	}
+	if len(summary.Stages) != len(want) {
+		t.Fatalf("stages = %+v, want %d", summary.Stages, len(want))
+	}
+	for i, stage := range want {
+		if summary.Stages[i] != stage {
+			t.Errorf("stage %d = %+v, want %+v", i, summary.Stages[i], stage)
+		}
+	}
+	if summary.DocumentsRetrieved != 4 || summary.EstimatedCost != 0.0080008 || summary.Err != "" {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestRenderRunSummary(t *testing.T) {
+	state, usage := summaryFixture()
+	var out bytes.Buffer
+	BuildRunSummary(state, usage, 2*time.Second, nil).Render(&out)
+
+	for _, line := range []string{
+		"Total wall time: 2s",
+		"  embedding          50ms  (2 calls)",
+		"  synthesizer        1.2s  (1 calls)",
+		"  other             335ms",
+		"  gpt-4o                   prompt=2000 completion=300 calls=1 est. cost=$0.008000",
+		"  custom-planner           prompt=500 completion=50 calls=1 est. cost=n/a",
+		"Estimated total cost: $0.008001",
+		"Documents retrieved: 4",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("summary is missing %q:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Status: failed") {
+		t.Errorf("successful run rendered as failed:\n%s", out.String())
+	}
+}
+
+func TestRunSummaryForFailedRun(t *testing.T) {
+	state := NewPipelineState("quiet hotel", 3)
+	state.Trace = trace.New("session-1")
+	state.Trace.Add(trace.Event{Name: trace.EventPlannerCompletion, Duration: 300 * time.Millisecond})
+
+	var out bytes.Buffer
+	BuildRunSummary(state, nil, 350*time.Millisecond, errors.New("tool stage timed out")).Render(&out)
+
+	for _, line := range []string{
+		"Status: failed (tool stage timed out)",
+		"Total wall time: 350ms",
+		"  planner           300ms  (1 calls)",
+		"  search               0s  (0 calls)",
+		"  (none)",
+		"Documents retrieved: 0",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("summary is missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// scriptedStage is a pipeline stage whose steps advance a fake clock
+type scriptedStage struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+func (s *scriptedStage) Name() string {
+	return s.name
+}
+
+func (s *scriptedStage) Run(ctx context.Context, state *PipelineState) error {
+	s.run(ctx)
+	return nil
+}
+
+// step records the event name on the trace carried by ctx, taking d on clock, with
+// nested steps run in between
+func step(ctx context.Context, clock *fakeClock, name string, d time.Duration, nested ...func()) {
+	done := trace.Start(ctx, name)
+	for _, n := range nested {
+		n()
+	}
+	clock.advance(d)
+	done(nil)
+}
+
+func TestLatencyBreakdownWithFakeClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	pipeline := NewPipeline(
+		&scriptedStage{name: StagePlanner, run: func(ctx context.Context) {
+			step(ctx, clock, trace.EventPlannerCompletion, 300*time.Millisecond)
+			// The tool spends 20ms of its own around the embedding and search
+			step(ctx, clock, trace.EventToolExecution, 20*time.Millisecond,
+				func() { step(ctx, clock, trace.EventEmbedding, 40*time.Millisecond) },
+				func() { step(ctx, clock, trace.EventVectorSearch, 60*time.Millisecond) },
+			)
+			step(ctx, clock, trace.EventContextAssembly, 10*time.Millisecond)
+		}},
+		&scriptedStage{name: StageSynthesizer, run: func(ctx context.Context) {
+			step(ctx, clock, trace.EventSynthesizerCompletion, 500*time.Millisecond)
+		}},
+		&scriptedStage{name: StageCitations, run: func(ctx context.Context) {
+			clock.advance(70 * time.Millisecond)
+		}},
+	)
+
+	state := NewPipelineState("quiet hotel", 3)
+	state.Trace = trace.NewWithClock("session-1", clock.Now)
+	start := clock.Now()
+	if err := pipeline.Run(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	summary := BuildRunSummary(state, nil, clock.Now().Sub(start), nil)
+
+	want := []StageLatency{
+		{Stage: "embedding", Duration: 40 * time.Millisecond, Count: 1, Percent: 4},
+		{Stage: "search", Duration: 60 * time.Millisecond, Count: 1, Percent: 6},
+		{Stage: "planner", Duration: 300 * time.Millisecond, Count: 1, Percent: 30},
+		{Stage: "tool", Duration: 20 * time.Millisecond, Count: 1, Percent: 2},
+		{Stage: "context", Duration: 10 * time.Millisecond, Count: 1, Percent: 1},
+		{Stage: "synthesizer", Duration: 500 * time.Millisecond, Count: 1, Percent: 50},
+		{Stage: StageOther, Duration: 70 * time.Millisecond, Percent: 7},
+	}
+	if !reflect.DeepEqual(summary.Stages, want) {
+		t.Errorf("stages = %+v\nwant %+v", summary.Stages, want)
+	}
+
+	// The pipeline times its stages on the same clock
+	for _, event := range state.Trace.Events() {
+		if event.Name == StagePlanner && event.Duration != 430*time.Millisecond {
+			t.Errorf("planner stage took %s, want 430ms", event.Duration)
+		}
+	}
+
+	var out bytes.Buffer
+	summary.RenderBreakdown(&out)
+	for _, line := range []string{
+		"--- LATENCY BREAKDOWN ---",
+		"STAGE              TIME   SHARE  CALLS",
+		"embedding          40ms    4.0%      1",
+		"tool               20ms    2.0%      1",
+		"synthesizer       500ms   50.0%      1",
+		"other              70ms    7.0%      -",
+		"total                1s  100.0%",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("breakdown is missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestRunSummaryForDegradedSearch(t *testing.T) {
+	state, usage := summaryFixture()
+	if summary := BuildRunSummary(state, usage, 2*time.Second, nil); summary.DegradedSearch != "" {
+		t.Errorf("DegradedSearch = %q for a run that used the index", summary.DegradedSearch)
+	}
+
+	state.Trace.Annotate(trace.EventExactFallback, "no vector index on DescriptionVector; compared all 50 documents")
+	summary := BuildRunSummary(state, usage, 2*time.Second, nil)
+	want := "exact search (no vector index on DescriptionVector; compared all 50 documents); latency is higher than with the vector index, create it with cmd/upload"
+	if summary.DegradedSearch != want {
+		t.Errorf("DegradedSearch = %q, want %q", summary.DegradedSearch, want)
+	}
+
+	var out bytes.Buffer
+	summary.Render(&out)
+	if !strings.Contains(out.String(), "DEGRADED: "+want+"\n") {
+		t.Errorf("summary does not report the degraded search:\n%s", out.String())
+	}
+}
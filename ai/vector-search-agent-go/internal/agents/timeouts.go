@@ -0,0 +1,70 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// Stage names used for timeout attribution; their budgets are in timeouts.Config
+const (
+	StagePlanner     = timeouts.StagePlanner
+	StageTool        = timeouts.StageTool
+	StageSynthesizer = timeouts.StageSynthesizer
+)
+
+// StageError reports the stage that was in progress when a run failed
+type StageError struct {
+	Stage   string
+	Timeout time.Duration
+	// Err is the error the stage failed with. When a deadline passed, it is a
+	// *timeouts.Error naming the budget that ran out: the stage's own, that of a call
+	// within it such as CHAT_TIMEOUT, or that of the run.
+	Err error
+	// RequestID is the correlation ID of the failed run, when it has one
+	RequestID string
+}
+
+func (e *StageError) Error() string {
+	var msg string
+	var budget *timeouts.Error
+	switch {
+	case e.TimedOut() && errors.As(e.Err, &budget) && budget.Stage == e.Stage:
+		msg = fmt.Sprintf("%s stage timed out after %s (%s): %v", e.Stage, budget.Timeout, budget.Setting, budget.Err)
+	case e.TimedOut():
+		msg = fmt.Sprintf("%s stage timed out: %v", e.Stage, e.Err)
+	default:
+		msg = fmt.Sprintf("%s stage failed: %v", e.Stage, e.Err)
+	}
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" [request %s]", e.RequestID)
+	}
+	return msg
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// TimedOut reports whether the stage failed because a deadline was exceeded
+func (e *StageError) TimedOut() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// runStage runs fn under the budget of a stage and attributes any failure to the stage
+// and to the request carried by ctx, and a timeout to the budget that ran out
+func runStage(ctx context.Context, budget timeouts.Budget, fn func(ctx context.Context) error) error {
+	err := timeouts.Run(ctx, budget, fn)
+	if err == nil {
+		return nil
+	}
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		return err
+	}
+	return &StageError{Stage: budget.Stage, Timeout: budget.Timeout, Err: err, RequestID: session.RequestID(ctx)}
+}
@@ -0,0 +1,102 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestStageTimeoutAttribution(t *testing.T) {
+	const slow = time.Second
+	tiny := timeouts.Config{Planner: 20 * time.Millisecond, Tool: 20 * time.Millisecond, Synthesizer: 20 * time.Millisecond}
+
+	tests := []struct {
+		stage string
+		llm   *fakeLLM
+		store *fakeSearcher
+	}{
+		{StagePlanner, &fakeLLM{planDelay: slow}, &fakeSearcher{hotels: sampleResults(2)}},
+		{StageTool, &fakeLLM{}, &fakeSearcher{hotels: sampleResults(2), delay: slow}},
+		{StageSynthesizer, &fakeLLM{answerDelay: slow}, &fakeSearcher{hotels: sampleResults(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stage, func(t *testing.T) {
+			planner, synthesizer := newTestAgents(tt.llm, tt.store, tiny)
+			ctx := context.Background()
+
+			start := time.Now()
+			results, err := planner.Search(ctx, "hotel", 2)
+			if err == nil {
+				_, err = synthesizer.Synthesize(ctx, "hotel", FormatResults(results))
+			}
+			if elapsed := time.Since(start); elapsed >= slow {
+				t.Errorf("run took %s; the stage deadline did not cut it short", elapsed)
+			}
+
+			var stageErr *StageError
+			if !errors.As(err, &stageErr) {
+				t.Fatalf("err = %v, want a *StageError", err)
+			}
+			if stageErr.Stage != tt.stage || !stageErr.TimedOut() {
+				t.Errorf("stage = %s, timed out = %v; want %s timed out", stageErr.Stage, stageErr.TimedOut(), tt.stage)
+			}
+			if want := tt.stage + " stage timed out after 20ms (" + tiny.Budget(tt.stage).Setting + ")"; !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("err = %q, want it to start with %q", err, want)
+			}
+			if tt.stage == StageSynthesizer && len(results) != 2 {
+				t.Errorf("partial results = %d, want the 2 retrieved before the synthesizer timed out", len(results))
+			}
+		})
+	}
+}
+
+func TestRunDeadlineAttributedToStage(t *testing.T) {
+	planner, _ := newTestAgents(&fakeLLM{planDelay: time.Second}, &fakeSearcher{}, timeouts.Config{})
+	ctx, cancel := timeouts.WithBudget(context.Background(), timeouts.Budget{Stage: timeouts.StageRun, Timeout: 20 * time.Millisecond, Setting: "RUN_TIMEOUT"})
+	defer cancel()
+
+	_, err := planner.Search(ctx, "hotel", 2)
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != StagePlanner || !stageErr.TimedOut() {
+		t.Fatalf("err = %v, want the planner stage timed out by the run deadline", err)
+	}
+	// The budget that ran out is the run's, not the planner's
+	if want := "planner stage timed out: run timed out after 20ms (RUN_TIMEOUT)"; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("err = %q, want it to start with %q", err, want)
+	}
+}
+
+func TestNestedBudgetAttributedToStage(t *testing.T) {
+	// A chat completion of the planner ran out of CHAT_TIMEOUT, within the planner's budget
+	chatTimeout := &timeouts.Error{Budget: timeouts.Default().Budget(timeouts.StageChat), Err: context.DeadlineExceeded}
+	planner, _ := newTestAgents(&fakeLLM{plannerErr: chatTimeout}, &fakeSearcher{}, timeouts.Default())
+
+	_, err := planner.Search(context.Background(), "hotel", 2)
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.Stage != StagePlanner || !stageErr.TimedOut() {
+		t.Fatalf("err = %v, want the planner stage timed out", err)
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "planner stage timed out: ") || !strings.Contains(msg, "chat timed out after 2m0s (CHAT_TIMEOUT)") {
+		t.Errorf("err = %q, want the planner timed out by the chat budget", msg)
+	}
+}
+
+func TestStageErrorNamesRequest(t *testing.T) {
+	planner, _ := newTestAgents(&fakeLLM{plannerErr: errors.New("model unavailable")}, &fakeSearcher{}, timeouts.Default())
+	ctx := session.WithRequestID(context.Background(), "req-1")
+
+	_, err := planner.Search(ctx, "hotel", 2)
+	var stageErr *StageError
+	if !errors.As(err, &stageErr) || stageErr.RequestID != "req-1" {
+		t.Fatalf("err = %#v, want a *StageError for request req-1", err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "planner stage failed: ") || !strings.HasSuffix(msg, "model unavailable [request req-1]") {
+		t.Errorf("err = %q, want the planner failure tagged with request req-1", msg)
+	}
+}
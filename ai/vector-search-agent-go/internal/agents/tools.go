@@ -2,72 +2,139 @@ package agents
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/prompts"
 	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
 	"github.com/openai/openai-go/v3"
 )
 
+// ErrNoResults signals that the search completed but matched no hotels
+var ErrNoResults = errors.New("no matching hotels found")
+
+// ErrInvalidToolArguments is wrapped by errors about tool call arguments that break the
+// tool's schema, such as a missing query or a nearestNeighbors out of range
+var ErrInvalidToolArguments = errors.New("invalid tool arguments")
+
 // VectorSearchTool implements the hotel search functionality
 type VectorSearchTool struct {
-	openAIClients *clients.OpenAIClients
-	vectorStore   *vectorstore.VectorStore
-	debug         bool
+	embedder  Embedder
+	searcher  Searcher
+	formatter vectorstore.Formatter
 }
 
 // NewVectorSearchTool creates a new vector search tool
-func NewVectorSearchTool(openaiClients *clients.OpenAIClients, vectorStore *vectorstore.VectorStore, debug bool) *VectorSearchTool {
+func NewVectorSearchTool(embedder Embedder, searcher Searcher) *VectorSearchTool {
 	return &VectorSearchTool{
-		openAIClients: openaiClients,
-		vectorStore:   vectorStore,
-		debug:         debug,
+		embedder: embedder,
+		searcher: searcher,
 	}
 }
 
-// Execute performs the vector search
+// SetFormatter sets how Execute and Format render results for the synthesizer; the
+// zero Formatter is the default text layout
+func (t *VectorSearchTool) SetFormatter(formatter vectorstore.Formatter) {
+	t.formatter = formatter
+}
+
+// Execute performs the vector search and formats the results for the synthesizer.
+// It returns ErrNoResults when the search matches no hotels.
 func (t *VectorSearchTool) Execute(ctx context.Context, query string, nearestNeighbors int) (string, error) {
+	results, err := t.Search(ctx, query, nearestNeighbors)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Format(results), nil
+}
+
+// Format formats search results for the synthesizer with the tool's formatter
+func (t *VectorSearchTool) Format(results []models.HotelSearchResult) string {
+	return formatResults(t.formatter, results)
+}
+
+// Search performs the vector search and returns the structured results in ranked order.
+// It returns ErrNoResults when the search matches no hotels.
+func (t *VectorSearchTool) Search(ctx context.Context, query string, nearestNeighbors int) ([]models.HotelSearchResult, error) {
+	return t.SearchLanguage(ctx, query, "", nearestNeighbors)
+}
+
+// SearchLanguage is Search over the hotel descriptions in language, "" meaning English.
+// An unsupported language, or a searcher without French vectors, searches English
+// instead; so does a French search that matches nothing, since hotels without
+// Description_fr have no French vector.
+func (t *VectorSearchTool) SearchLanguage(ctx context.Context, query, language string, nearestNeighbors int) ([]models.HotelSearchResult, error) {
 	// Generate embedding for query
-	queryVector, err := t.openAIClients.GenerateEmbedding(ctx, query)
+	queryVector, err := t.embedder.GenerateEmbedding(ctx, query)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate embedding: %w", err)
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
 	// Perform vector search
-	results, err := t.vectorStore.VectorSearch(ctx, queryVector, nearestNeighbors)
+	language = t.searchLanguage(ctx, language)
+	var results []models.HotelSearchResult
+	if language == vectorstore.LanguageEnglish {
+		results, err = t.searcher.VectorSearch(ctx, queryVector, nearestNeighbors)
+	} else {
+		results, err = t.searcher.(LanguageSearcher).VectorSearchLanguage(ctx, language, queryVector, nearestNeighbors)
+		if err == nil && len(results) == 0 {
+			slog.WarnContext(ctx, "no hotels have vectors in the search language, searching English", "language", language)
+			language = vectorstore.LanguageEnglish
+			results, err = t.searcher.VectorSearch(ctx, queryVector, nearestNeighbors)
+		}
+	}
 	if err != nil {
-		return "", fmt.Errorf("vector search failed: %w", err)
+		return nil, fmt.Errorf("vector search failed: %w", err)
 	}
 
-	// Format results for synthesizer
-	var formattedResults []string
-	for i, result := range results {
-		fmt.Printf("Hotel #%d: %s, Score: %.6f\n", i+1, result.Hotel.HotelName, result.Score)
-		formattedResults = append(formattedResults, vectorstore.FormatHotelForSynthesizer(result))
+	slog.DebugContext(ctx, "vector search completed", "query", query, "language", language, "k", nearestNeighbors, "results", len(results))
+
+	if len(results) == 0 {
+		slog.InfoContext(ctx, "vector search matched no hotels", "query", query, "language", language)
+		return nil, ErrNoResults
 	}
 
-	return strings.Join(formattedResults, "\n\n"), nil
+	return results, nil
+}
+
+// searchLanguage returns the supported language to search for the requested one
+func (t *VectorSearchTool) searchLanguage(ctx context.Context, requested string) string {
+	language, ok := vectorstore.NormalizeLanguage(requested)
+	if !ok {
+		slog.WarnContext(ctx, "unsupported search language, searching English", "language", requested)
+		return vectorstore.LanguageEnglish
+	}
+	if _, ok := t.searcher.(LanguageSearcher); !ok && language != vectorstore.LanguageEnglish {
+		slog.WarnContext(ctx, "searcher has no vectors in the search language, searching English", "language", language)
+		return vectorstore.LanguageEnglish
+	}
+	return language
+}
+
+// FormatResults formats search results for the synthesizer in the default text layout
+func FormatResults(results []models.HotelSearchResult) string {
+	return formatResults(vectorstore.Formatter{}, results)
+}
+
+// formatResults formats search results with formatter, separated by blank lines
+func formatResults(formatter vectorstore.Formatter, results []models.HotelSearchResult) string {
+	formattedResults := make([]string, 0, len(results))
+	for _, result := range results {
+		formattedResults = append(formattedResults, formatter.FormatHotel(result))
+	}
+
+	return strings.Join(formattedResults, "\n\n")
 }
 
 // GetToolDefinition returns the Azure OpenAI tool definition
 func (t *VectorSearchTool) GetToolDefinition() openai.ChatCompletionToolUnionParam {
-	paramSchema := map[string]any{
-		"type": "object",
-		"properties": map[string]any{
-			"query": map[string]any{
-				"type":        "string",
-				"description": "Natural language search query describing desired hotel characteristics",
-			},
-			"nearestNeighbors": map[string]any{
-				"type":        "integer",
-				"description": "Number of results to return (1-20)",
-				"default":     5,
-			},
-		},
-		"required": []string{"query", "nearestNeighbors"},
-	}
+	paramSchema := hotelSearchSchema.jsonSchema()
 
 	return openai.ChatCompletionToolUnionParam{
 		OfFunction: &openai.ChatCompletionFunctionToolParam{
@@ -84,21 +151,31 @@ func (t *VectorSearchTool) GetToolDefinition() openai.ChatCompletionToolUnionPar
 type toolArguments struct {
 	Query            string `json:"query"`
 	NearestNeighbors int    `json:"nearestNeighbors"`
+	Language         string `json:"language,omitempty"`
+	// tool names the tool that was called
+	tool string
 }
 
-// parseToolArgumentsFromMap parses tool arguments from a map
-func parseToolArgumentsFromMap(argsMap map[string]any) (*toolArguments, error) {
-	args := &toolArguments{}
-
-	if query, ok := argsMap["query"].(string); ok {
-		args.Query = query
-	} else {
-		return nil, fmt.Errorf("query argument missing or invalid")
-	}
-
-	if nn, ok := argsMap["nearestNeighbors"].(float64); ok {
-		args.NearestNeighbors = int(nn)
+// parseToolArgumentsFromMap validates the arguments of a call to the tool schema
+// describes and reads them. Arguments that break the schema give a *ToolArgumentsError.
+func parseToolArgumentsFromMap(ctx context.Context, schema toolSchema, argsMap map[string]any) (*toolArguments, error) {
+	values, err := schema.validate(ctx, argsMap)
+	if err != nil {
+		return nil, err
 	}
 
+	args := &toolArguments{}
+	args.Query, _ = values["query"].(string)
+	args.NearestNeighbors, _ = values["nearestNeighbors"].(int)
+	args.Language, _ = values["language"].(string)
 	return args, nil
 }
+
+// fragment renders a value from a tool call as JSON for an error message
+func fragment(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return clients.Fragment(fmt.Sprint(value))
+	}
+	return clients.Fragment(string(data))
+}
@@ -0,0 +1,125 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func TestParseToolArgumentsCoercion(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      toolArguments
+	}{
+		{"number", `{"query":"pool","nearestNeighbors":5}`, toolArguments{Query: "pool", NearestNeighbors: 5}},
+		{"whole float", `{"query":"pool","nearestNeighbors":5.0}`, toolArguments{Query: "pool", NearestNeighbors: 5}},
+		{"string number", `{"query":"pool","nearestNeighbors":"5"}`, toolArguments{Query: "pool", NearestNeighbors: 5}},
+		{"padded string", `{"query":"pool","nearestNeighbors":" 7 "}`, toolArguments{Query: "pool", NearestNeighbors: 7}},
+		{"bounds", `{"query":"pool","nearestNeighbors":20}`, toolArguments{Query: "pool", NearestNeighbors: 20}},
+		{"negative count", `{"query":"pool","nearestNeighbors":-3}`, toolArguments{Query: "pool", NearestNeighbors: 1}},
+		{"zero count", `{"query":"pool","nearestNeighbors":0}`, toolArguments{Query: "pool", NearestNeighbors: 1}},
+		{"large count", `{"query":"pool","nearestNeighbors":500}`, toolArguments{Query: "pool", NearestNeighbors: 20}},
+		{"large string count", `{"query":"pool","nearestNeighbors":"21"}`, toolArguments{Query: "pool", NearestNeighbors: 20}},
+		{"huge count", `{"query":"pool","nearestNeighbors":1e300}`, toolArguments{Query: "pool", NearestNeighbors: 20}},
+		{"null language", `{"query":"pool","nearestNeighbors":1,"language":null}`, toolArguments{Query: "pool", NearestNeighbors: 1}},
+		{"extra fields", `{"query":"pool","nearestNeighbors":3,"filters":{"city":"Seattle"},"reason":"asked"}`, toolArguments{Query: "pool", NearestNeighbors: 3}},
+		{"language", `{"query":"piscine","nearestNeighbors":3,"language":"fr"}`, toolArguments{Query: "piscine", NearestNeighbors: 3, Language: "fr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := parseToolArgumentsFromMap(context.Background(), hotelSearchSchema, decodeArguments(t, tt.arguments))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *args != tt.want {
+				t.Errorf("args = %+v, want %+v", *args, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseToolArgumentsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		message   string
+	}{
+		{"missing query", `{"nearestNeighbors":3}`, "query is missing"},
+		{"null query", `{"query":null,"nearestNeighbors":3}`, "query is missing"},
+		{"blank query", `{"query":"  ","nearestNeighbors":3}`, "query is empty"},
+		{"numeric query", `{"query":42,"nearestNeighbors":3}`, "query 42 is not a string"},
+		{"object query", `{"query":{"text":"pool"},"nearestNeighbors":3}`, `query {"text":"pool"} is not a string`},
+		{"missing count", `{"query":"pool"}`, "nearestNeighbors is missing"},
+		{"null count", `{"query":"pool","nearestNeighbors":null}`, "nearestNeighbors is missing"},
+		{"empty count", `{"query":"pool","nearestNeighbors":""}`, "nearestNeighbors is missing"},
+		{"word count", `{"query":"pool","nearestNeighbors":"five"}`, `nearestNeighbors "five" is not a number`},
+		{"boolean count", `{"query":"pool","nearestNeighbors":true}`, "nearestNeighbors true is not a number"},
+		{"fractional count", `{"query":"pool","nearestNeighbors":2.5}`, "nearestNeighbors 2.5 is not a whole number"},
+		{"fractional string", `{"query":"pool","nearestNeighbors":"2.5"}`, `nearestNeighbors "2.5" is not a whole number`},
+		{"infinite string", `{"query":"pool","nearestNeighbors":"Inf"}`, `nearestNeighbors "Inf" is not a whole number`},
+		{"list language", `{"query":"pool","nearestNeighbors":3,"language":["fr"]}`, `language ["fr"] is not a string`},
+		{"long query object", `{"query":{"text":"` + strings.Repeat("pool ", 100) + `"}}`, `query {"text":"pool pool`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseToolArgumentsFromMap(context.Background(), hotelSearchSchema, decodeArguments(t, tt.arguments))
+			var argsErr *ToolArgumentsError
+			if !errors.As(err, &argsErr) || !errors.Is(err, ErrInvalidToolArguments) {
+				t.Fatalf("err = %v, want a *ToolArgumentsError wrapping ErrInvalidToolArguments", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("err = %v, want it to say %s", err, tt.message)
+			}
+		})
+	}
+}
+
+// FuzzParseToolArguments feeds arbitrary JSON arguments to the tool argument parser,
+// which must never panic and must only accept a usable query and count
+func FuzzParseToolArguments(f *testing.F) {
+	for _, seed := range []string{
+		`{"query":"pool","nearestNeighbors":5}`,
+		`{"query":"pool","nearestNeighbors":"5"}`,
+		`{"query":"pool","nearestNeighbors":null,"language":"fr"}`,
+		`{"query":"","nearestNeighbors":-1}`,
+		`{"query":["pool"],"nearestNeighbors":{"n":1}}`,
+		`{"query":"pool","nearestNeighbors":1e309}`,
+		`{}`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, arguments string) {
+		var argsMap map[string]any
+		if json.Unmarshal([]byte(arguments), &argsMap) != nil {
+			return
+		}
+		args, err := parseToolArgumentsFromMap(context.Background(), hotelSearchSchema, argsMap)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidToolArguments) {
+				t.Errorf("arguments %s: err = %v, want ErrInvalidToolArguments", arguments, err)
+			}
+			return
+		}
+		if strings.TrimSpace(args.Query) == "" || args.NearestNeighbors < vectorstore.MinK || args.NearestNeighbors > vectorstore.MaxK() {
+			t.Errorf("arguments %s gave unusable %+v", arguments, *args)
+		}
+	})
+}
+
+// decodeArguments decodes tool call arguments as the planner receives them
+func decodeArguments(t *testing.T, arguments string) map[string]any {
+	t.Helper()
+	var argsMap map[string]any
+	if err := json.Unmarshal([]byte(arguments), &argsMap); err != nil {
+		t.Fatal(err)
+	}
+	return argsMap
+}
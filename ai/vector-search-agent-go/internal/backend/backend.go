@@ -0,0 +1,182 @@
+// Package backend opens the model and store a command runs against: Azure OpenAI and
+// DocumentDB, or the offline stand-ins when OFFLINE_MODE is set.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/runmetrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Models is what commands use from the model provider. *clients.OpenAIClients and
+// *offline.Model implement it.
+type Models interface {
+	agents.LLM
+	Usage() *clients.UsageTracker
+}
+
+// Store is what commands use from the vector store. *vectorstore.VectorStore and
+// *offline.Store implement it.
+type Store interface {
+	agents.Searcher
+	agents.RoomSearcher
+	agents.HistoryStore
+	InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error
+	CreateVectorIndex(ctx context.Context) error
+	CreateFrenchVectorIndex(ctx context.Context) error
+	InsertRooms(ctx context.Context, rooms []models.RoomForVectorStore) error
+	DeleteRooms(ctx context.Context, hotelIDs []string) (int64, error)
+	CreateRoomsVectorIndex(ctx context.Context) error
+	ExistingHotelIDs(ctx context.Context) (map[string]bool, error)
+	ContentHashes(ctx context.Context) (map[string]string, error)
+	SchemaVersions(ctx context.Context) (map[int]int64, error)
+	UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) error
+	DeleteHotels(ctx context.Context, hotelIDs []string) (int64, error)
+	SaveFeedback(ctx context.Context, feedback models.Feedback) (bool, error)
+	WarmUp(ctx context.Context, n int) error
+	Close(ctx context.Context) error
+}
+
+// Backend holds the model and store of one command run
+type Backend struct {
+	Models  Models
+	Store   Store
+	Offline bool
+	// Config is the loaded configuration; nil in offline mode
+	Config *config.Config
+
+	// metrics records the run when METRICS_COLLECTION is set; nil otherwise
+	metrics *runmetrics.Recorder
+}
+
+// EmbeddingModel names the model that embeds documents, for content hashes: the
+// embedding deployment, or offline.EmbeddingModel for the fake embedder
+func (b *Backend) EmbeddingModel() string {
+	if b.Config == nil {
+		return offline.EmbeddingModel
+	}
+	return b.Config.OpenAI.EmbeddingDeployment
+}
+
+// LoadConfig loads the configuration of a command that can run offline: that of
+// cli.LoadConfig, except that when OFFLINE_MODE is set only the settings of every
+// command, such as RUN_TIMEOUT, are validated, since the Azure ones go unused
+func LoadConfig(path string, req config.Requirements) (*config.Config, error) {
+	if !offline.Enabled(os.Getenv) {
+		return cli.LoadConfig(path, req)
+	}
+	cfg, err := config.Load(path)
+	if err == nil {
+		err = config.ValidateEnvironment(os.Getenv, config.Requirements{})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cli.ErrConfig, err)
+	}
+	return cfg, nil
+}
+
+// Open connects to Azure OpenAI and DocumentDB with cfg, loaded by LoadConfig, writing
+// the name of an ephemeral database to banner. When OFFLINE_MODE is set it skips both,
+// writes the offline banner to banner, and returns the offline model and an in-memory
+// store loaded from the local hotel data. Close the backend when done.
+func Open(ctx context.Context, cfg *config.Config, banner io.Writer) (*Backend, error) {
+	if cfg.Runtime.OfflineMode {
+		offline.PrintBanner(banner)
+		embedder := offline.NewFakeEmbedder(vectorstore.EmbeddingDimensionsFromEnv())
+		store, err := offline.LoadStore(ctx, offline.DataFile(os.Getenv), embedder)
+		if err != nil {
+			return nil, err
+		}
+		return &Backend{Models: offline.NewModel(embedder), Store: store, Offline: true}, nil
+	}
+
+	openaiClients, err := clients.NewOpenAIClients(cfg.OpenAI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAI clients: %w", err)
+	}
+
+	store, err := vectorstore.NewVectorStore(ctx, cfg.VectorStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to vector store: %w", err)
+	}
+
+	if cfg.VectorStore.Ephemeral {
+		fmt.Fprintf(banner, "Ephemeral database: %s\n", cfg.VectorStore.DatabaseName)
+	}
+
+	return &Backend{Models: openaiClients, Store: store, Config: cfg, metrics: runmetrics.FromConfig(store, cfg)}, nil
+}
+
+// EphemeralDatabase returns the database created for this run when EPHEMERAL_DATABASE is
+// set, or "" otherwise
+func (b *Backend) EphemeralDatabase() string {
+	if b.Config == nil || !b.Config.VectorStore.Ephemeral {
+		return ""
+	}
+	return b.Config.VectorStore.DatabaseName
+}
+
+// OpenModels is Open for commands that only call the models: it creates the Azure OpenAI
+// clients, or the offline model when OFFLINE_MODE is set, without connecting to a store
+func OpenModels(cfg *config.Config, banner io.Writer) (Models, error) {
+	if cfg.Runtime.OfflineMode {
+		offline.PrintBanner(banner)
+		return offline.NewModel(offline.NewFakeEmbedder(vectorstore.EmbeddingDimensionsFromEnv())), nil
+	}
+
+	openaiClients, err := clients.NewOpenAIClients(cfg.OpenAI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenAI clients: %w", err)
+	}
+	return openaiClients, nil
+}
+
+// Warmer is a store that can warm up its vector index
+type Warmer interface {
+	WarmUp(ctx context.Context, n int) error
+}
+
+// WarmUp issues n throwaway searches on store before a latency-sensitive run, writing a
+// note to w. It does nothing when n is 0. A failed warm-up is logged and otherwise
+// ignored: the run goes ahead, only with a cold index.
+func WarmUp(ctx context.Context, store Warmer, n int, w io.Writer) {
+	if n <= 0 {
+		return
+	}
+	fmt.Fprintf(w, "Warming up the vector index (%d searches)...\n", n)
+	start := time.Now()
+	if err := store.WarmUp(ctx, n); err != nil {
+		slog.WarnContext(ctx, "vector index warm-up failed; continuing", "err", err)
+		return
+	}
+	slog.InfoContext(ctx, "vector index warmed up", "searches", n, "duration", time.Since(start))
+}
+
+// RecordRun records the token usage, estimated cost, and duration of the run of command
+// in METRICS_COLLECTION, when it is set; documents counts what the run read or wrote.
+// The write finishes in the background, by Close at the latest. A failed write is
+// logged as a warning and never fails the run.
+func (b *Backend) RecordRun(ctx context.Context, command string, documents map[string]int64, err error) {
+	if b.metrics == nil {
+		return
+	}
+	b.metrics.Record(ctx, runmetrics.Run{Command: command, Usage: b.Models.Usage().Snapshot(), Documents: documents, Err: err})
+}
+
+// Close waits for the run metrics still being written, then disconnects from the store
+func (b *Backend) Close(ctx context.Context) error {
+	b.metrics.Close()
+	return b.Store.Close(ctx)
+}
@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/cli"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func TestOpenOffline(t *testing.T) {
+	t.Setenv("OFFLINE_MODE", "true")
+	t.Setenv("DATA_FILE_WITHOUT_VECTORS", "../../../data/Hotels.json")
+	t.Setenv("EMBEDDING_DIMENSIONS", "64")
+	// No Azure settings are needed offline
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	t.Setenv("AZURE_DOCUMENTDB_CONNECTION_STRING", "")
+
+	cfg, err := LoadConfig("", config.Requirements{Embedding: true, Chat: true, DocumentDB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var banner bytes.Buffer
+	b, err := Open(context.Background(), cfg, &banner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close(context.Background())
+
+	if !b.Offline || !strings.Contains(banner.String(), "OFFLINE MODE") {
+		t.Errorf("offline %v, banner %q", b.Offline, banner.String())
+	}
+	ids, err := b.Store.ExistingHotelIDs(context.Background())
+	if err != nil || len(ids) != 50 {
+		t.Errorf("store has %d hotels (%v), want the 50 sample hotels", len(ids), err)
+	}
+	if model := b.EmbeddingModel(); model != offline.EmbeddingModel {
+		t.Errorf("EmbeddingModel() = %q, want %q", model, offline.EmbeddingModel)
+	}
+	vector, err := b.Models.GenerateEmbedding(context.Background(), "pool")
+	if err != nil || len(vector) != 64 {
+		t.Errorf("embedding has %d dimensions (%v), want 64", len(vector), err)
+	}
+	if err := b.Store.WarmUp(context.Background(), 3); err != nil {
+		t.Errorf("WarmUp() = %v", err)
+	}
+}
+
+func TestOpenOfflineMissingData(t *testing.T) {
+	t.Setenv("OFFLINE_MODE", "true")
+	t.Setenv("DATA_FILE_WITHOUT_VECTORS", "testdata/missing.json")
+
+	cfg, err := LoadConfig("", config.Requirements{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(context.Background(), cfg, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "failed to load offline data") {
+		t.Errorf("err = %v, want a data load error", err)
+	}
+}
+
+func TestLoadConfigOfflineValidatesTimeouts(t *testing.T) {
+	t.Setenv("OFFLINE_MODE", "true")
+	t.Setenv("RUN_TIMEOUT", "soon")
+
+	_, err := LoadConfig("", config.Requirements{Embedding: true, Chat: true, DocumentDB: true})
+	if !errors.Is(err, cli.ErrConfig) || !strings.Contains(err.Error(), "RUN_TIMEOUT") {
+		t.Errorf("err = %v, want a configuration error naming RUN_TIMEOUT", err)
+	}
+}
+
+func TestEphemeralDatabase(t *testing.T) {
+	vsConfig := &vectorstore.VectorStoreConfig{DatabaseName: "hotels-ci-20261016t093000-3fa2c1"}
+	b := &Backend{Config: &config.Config{VectorStore: vsConfig}}
+	if got := b.EphemeralDatabase(); got != "" {
+		t.Errorf("EphemeralDatabase() = %q without EPHEMERAL_DATABASE, want none", got)
+	}
+	vsConfig.Ephemeral = true
+	if got := b.EphemeralDatabase(); got != vsConfig.DatabaseName {
+		t.Errorf("EphemeralDatabase() = %q, want %q", got, vsConfig.DatabaseName)
+	}
+	if got := (&Backend{Offline: true}).EphemeralDatabase(); got != "" {
+		t.Errorf("offline EphemeralDatabase() = %q, want none", got)
+	}
+}
+
+// fakeWarmer counts its warm-ups and fails them with err
+type fakeWarmer struct {
+	searches int
+	err      error
+}
+
+func (f *fakeWarmer) WarmUp(ctx context.Context, n int) error {
+	f.searches += n
+	return f.err
+}
+
+func TestWarmUp(t *testing.T) {
+	t.Run("warms up", func(t *testing.T) {
+		store := &fakeWarmer{}
+		var out bytes.Buffer
+		WarmUp(context.Background(), store, 3, &out)
+		if store.searches != 3 || !strings.Contains(out.String(), "Warming up the vector index (3 searches)") {
+			t.Errorf("%d searches, output %q; want 3 searches and a note", store.searches, out.String())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		store := &fakeWarmer{}
+		var out bytes.Buffer
+		WarmUp(context.Background(), store, 0, &out)
+		if store.searches != 0 || out.Len() != 0 {
+			t.Errorf("%d searches, output %q; want nothing", store.searches, out.String())
+		}
+	})
+
+	t.Run("failure is not fatal", func(t *testing.T) {
+		// WarmUp returns nothing to fail on; the caller goes on with its run
+		store := &fakeWarmer{err: errors.New("index not ready")}
+		WarmUp(context.Background(), store, 2, &bytes.Buffer{})
+		if store.searches != 2 {
+			t.Errorf("%d searches, want 2", store.searches)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package bench
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+// Doc is one stored vector used for the exact-search baseline
+type Doc struct {
+	ID     string
+	Vector []float32
+}
+
+// Neighbor is a document found by the exact search and its similarity to the query.
+// Higher is closer for every metric: L2 scores are negated distances.
+type Neighbor struct {
+	ID    string
+	Score float64
+}
+
+// ExactNeighbors returns the IDs of the k documents closest to query by brute force,
+// using the same similarity metric as the index (COS, IP, or L2)
+func ExactNeighbors(query []float32, corpus []Doc, k int, similarity string) []string {
+	neighbors := ExactScored(query, corpus, k, similarity)
+	ids := make([]string, 0, k)
+	for _, n := range neighbors[:min(k, len(neighbors))] {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+// ExactScored returns the k documents closest to query by brute force with their
+// scores, closest first and ties by ID, followed by any further documents that tie
+// with the k-th: an index may return either side of a tie, so recall counts both.
+func ExactScored(query []float32, corpus []Doc, k int, similarity string) []Neighbor {
+	scores := make([]Neighbor, 0, len(corpus))
+	for _, doc := range corpus {
+		if len(doc.Vector) != len(query) {
+			continue
+		}
+		scores = append(scores, Neighbor{ID: doc.ID, Score: score(query, doc.Vector, similarity)})
+	}
+
+	slices.SortFunc(scores, func(a, b Neighbor) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	if k <= 0 {
+		return nil
+	}
+	n := min(k, len(scores))
+	for n > 0 && n < len(scores) && scores[n].Score == scores[k-1].Score {
+		n++
+	}
+	return scores[:n]
+}
+
+// score returns a similarity where higher means closer
+func score(a, b []float32, similarity string) float64 {
+	var dot, normA, normB, dist float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		dist += (x - y) * (x - y)
+	}
+
+	switch strings.ToUpper(similarity) {
+	case "IP":
+		return dot
+	case "L2":
+		return -math.Sqrt(dist)
+	default:
+		if normA == 0 || normB == 0 {
+			return 0
+		}
+		return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	}
+}
@@ -0,0 +1,78 @@
+package bench
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExactNeighbors(t *testing.T) {
+	corpus := []Doc{
+		{ID: "a", Vector: []float32{1, 0}},
+		{ID: "b", Vector: []float32{0, 1}},
+		{ID: "c", Vector: []float32{4, 4}},
+		{ID: "d", Vector: []float32{0.9, 0.1}},
+		{ID: "wrong-dims", Vector: []float32{1, 0, 0}},
+	}
+	query := []float32{1, 0}
+
+	tests := []struct {
+		similarity string
+		k          int
+		want       []string
+	}{
+		// Cosine ignores magnitude: a is identical, d is close, c is at 45 degrees
+		{"COS", 3, []string{"a", "d", "c"}},
+		// Inner product rewards magnitude
+		{"IP", 2, []string{"c", "a"}},
+		// L2 prefers the nearest point
+		{"L2", 2, []string{"a", "d"}},
+		{"cos", 10, []string{"a", "d", "c", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.similarity, func(t *testing.T) {
+			got := ExactNeighbors(query, corpus, tt.k, tt.similarity)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ExactNeighbors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExactNeighborsTiesBreakByID(t *testing.T) {
+	corpus := []Doc{
+		{ID: "2", Vector: []float32{1, 0}},
+		{ID: "1", Vector: []float32{1, 0}},
+		{ID: "3", Vector: []float32{1, 0}},
+	}
+	if got := ExactNeighbors([]float32{1, 0}, corpus, 2, "COS"); !slices.Equal(got, []string{"1", "2"}) {
+		t.Errorf("ExactNeighbors = %v, want [1 2]", got)
+	}
+}
+
+func TestScoreZeroVector(t *testing.T) {
+	if got := score([]float32{0, 0}, []float32{1, 1}, "COS"); got != 0 {
+		t.Errorf("cosine with a zero vector = %v, want 0", got)
+	}
+}
+
+func TestExactScoredKeepsTiesWithTheKth(t *testing.T) {
+	corpus := []Doc{
+		{ID: "far", Vector: []float32{0, 1}},
+		{ID: "c", Vector: []float32{1, 1}},
+		{ID: "b", Vector: []float32{1, 1}},
+		{ID: "a", Vector: []float32{1, 0}},
+	}
+	got := ExactScored([]float32{1, 0}, corpus, 2, "COS")
+	ids := make([]string, len(got))
+	for i, n := range got {
+		ids[i] = n.ID
+	}
+	// b is the 2nd nearest and c ties with it; far does not
+	if !slices.Equal(ids, []string{"a", "b", "c"}) || got[1].Score != got[2].Score {
+		t.Errorf("ExactScored = %+v, want a, then b and c tied", got)
+	}
+	if got := ExactScored([]float32{1, 0}, corpus, 0, "COS"); len(got) != 0 {
+		t.Errorf("ExactScored with k 0 = %+v, want none", got)
+	}
+}
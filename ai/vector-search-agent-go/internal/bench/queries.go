@@ -0,0 +1,57 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// LoadQueries reads one query per line, skipping blank lines and # comments
+func LoadQueries(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queries file: %w", err)
+	}
+	defer file.Close()
+
+	var queries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read queries file: %w", err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("queries file %s contains no queries", path)
+	}
+	return queries, nil
+}
+
+// GenerateQueries builds n natural-language queries that paraphrase hotels in the
+// sample data by category, city, and tags, spread evenly across the data set
+func GenerateQueries(hotels []models.Hotel, n int) []string {
+	if len(hotels) == 0 {
+		return nil
+	}
+	n = min(n, len(hotels))
+
+	queries := make([]string, 0, n)
+	for i := range n {
+		hotel := hotels[i*len(hotels)/n]
+
+		query := fmt.Sprintf("%s hotel in %s", strings.ToLower(hotel.Category), hotel.Address.City)
+		if len(hotel.Tags) > 0 {
+			query += " with " + strings.Join(hotel.Tags[:min(2, len(hotel.Tags))], " and ")
+		}
+		queries = append(queries, query)
+	}
+	return queries
+}
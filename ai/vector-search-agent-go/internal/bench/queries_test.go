@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+func TestLoadQueries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.txt")
+	content := "# benchmark queries\nhotel with a pool\n\n  quiet hotel near the beach  \n# end\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	queries, err := LoadQueries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(queries, []string{"hotel with a pool", "quiet hotel near the beach"}) {
+		t.Errorf("queries = %q", queries)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadQueries(empty); err == nil || !strings.Contains(err.Error(), "contains no queries") {
+		t.Errorf("empty file err = %v", err)
+	}
+}
+
+func TestGenerateQueries(t *testing.T) {
+	hotels := make([]models.Hotel, 4)
+	for i := range hotels {
+		hotels[i] = models.Hotel{Category: "Boutique", Tags: []string{"pool", "view", "bar"}}
+		hotels[i].Address.City = []string{"Seattle", "Boston", "Miami", "Denver"}[i]
+	}
+
+	got := GenerateQueries(hotels, 2)
+	want := []string{"boutique hotel in Seattle with pool and view", "boutique hotel in Miami with pool and view"}
+	if !slices.Equal(got, want) {
+		t.Errorf("GenerateQueries = %q, want %q", got, want)
+	}
+
+	if got := GenerateQueries(hotels, 10); len(got) != 4 {
+		t.Errorf("generated %d queries from 4 hotels, want 4", len(got))
+	}
+	if got := GenerateQueries(nil, 3); got != nil {
+		t.Errorf("GenerateQueries(nil) = %q, want nil", got)
+	}
+}
@@ -0,0 +1,98 @@
+package bench
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+)
+
+// LatencyStats summarizes a set of latency samples
+type LatencyStats struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"-"`
+	Mean  time.Duration `json:"-"`
+	P50   time.Duration `json:"-"`
+	P95   time.Duration `json:"-"`
+	P99   time.Duration `json:"-"`
+	Max   time.Duration `json:"-"`
+
+	// Millisecond values for the JSON report
+	MinMs  float64 `json:"minMs"`
+	MeanMs float64 `json:"meanMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P95Ms  float64 `json:"p95Ms"`
+	P99Ms  float64 `json:"p99Ms"`
+	MaxMs  float64 `json:"maxMs"`
+}
+
+// Summarize computes latency statistics. Percentiles use the nearest-rank method,
+// so every reported value is an observed sample. An empty input yields zero stats.
+func Summarize(samples []time.Duration) LatencyStats {
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, s := range sorted {
+		total += s
+	}
+
+	stats := LatencyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P95:   percentile(sorted, 95),
+		P99:   percentile(sorted, 99),
+		Max:   sorted[len(sorted)-1],
+	}
+	stats.MinMs = ms(stats.Min)
+	stats.MeanMs = ms(stats.Mean)
+	stats.P50Ms = ms(stats.P50)
+	stats.P95Ms = ms(stats.P95)
+	stats.P99Ms = ms(stats.P99)
+	stats.MaxMs = ms(stats.Max)
+	return stats
+}
+
+// UnmarshalJSON reads stats written to a JSON report and restores the durations
+// from their millisecond values
+func (s *LatencyStats) UnmarshalJSON(data []byte) error {
+	type plain LatencyStats
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	s.Min = fromMs(s.MinMs)
+	s.Mean = fromMs(s.MeanMs)
+	s.P50 = fromMs(s.P50Ms)
+	s.P95 = fromMs(s.P95Ms)
+	s.P99 = fromMs(s.P99Ms)
+	s.Max = fromMs(s.MaxMs)
+	return nil
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+// Round trims a duration for display
+func Round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
+
+// ms converts a duration to fractional milliseconds
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// fromMs converts fractional milliseconds to a duration
+func fromMs(ms float64) time.Duration {
+	return time.Duration(math.Round(ms * float64(time.Millisecond)))
+}
@@ -0,0 +1,102 @@
+package bench
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func millis(values ...int) []time.Duration {
+	samples := make([]time.Duration, len(values))
+	for i, v := range values {
+		samples[i] = time.Duration(v) * time.Millisecond
+	}
+	return samples
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		want    LatencyStats
+	}{
+		{"empty", nil, LatencyStats{}},
+		{
+			"single sample",
+			millis(7),
+			LatencyStats{Count: 1, Min: 7 * time.Millisecond, Mean: 7 * time.Millisecond, P50: 7 * time.Millisecond, P95: 7 * time.Millisecond, P99: 7 * time.Millisecond, Max: 7 * time.Millisecond},
+		},
+		{
+			"unsorted input",
+			millis(40, 10, 30, 20),
+			LatencyStats{Count: 4, Min: 10 * time.Millisecond, Mean: 25 * time.Millisecond, P50: 20 * time.Millisecond, P95: 40 * time.Millisecond, P99: 40 * time.Millisecond, Max: 40 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Summarize(tt.samples)
+			if got.Count != tt.want.Count || got.Min != tt.want.Min || got.Mean != tt.want.Mean ||
+				got.P50 != tt.want.P50 || got.P95 != tt.want.P95 || got.P99 != tt.want.P99 || got.Max != tt.want.Max {
+				t.Errorf("Summarize = %+v, want %+v", got, tt.want)
+			}
+			if got.P50Ms != ms(tt.want.P50) || got.MaxMs != ms(tt.want.Max) {
+				t.Errorf("millisecond fields = %v/%v, want %v/%v", got.P50Ms, got.MaxMs, ms(tt.want.P50), ms(tt.want.Max))
+			}
+		})
+	}
+}
+
+func TestSummarizeDoesNotReorderInput(t *testing.T) {
+	samples := millis(3, 1, 2)
+	Summarize(samples)
+	if samples[0] != 3*time.Millisecond || samples[1] != time.Millisecond {
+		t.Errorf("Summarize sorted its input: %v", samples)
+	}
+}
+
+func TestLatencyStatsJSONRoundTrip(t *testing.T) {
+	stats := Summarize([]time.Duration{1500 * time.Microsecond, 20 * time.Millisecond, 3 * time.Second})
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded LatencyStats
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != stats {
+		t.Errorf("decoded %+v, want %+v", decoded, stats)
+	}
+}
+
+func TestPercentileNearestRank(t *testing.T) {
+	// 1..100 ms: the p-th percentile is exactly p ms
+	values := make([]int, 100)
+	for i := range values {
+		values[i] = i + 1
+	}
+	sorted := millis(values...)
+
+	for _, p := range []float64{1, 50, 95, 99, 100} {
+		if got := percentile(sorted, p); got != time.Duration(p)*time.Millisecond {
+			t.Errorf("percentile(%v) = %v, want %vms", p, got, p)
+		}
+	}
+
+	// Ranks round up and are clamped to the samples
+	small := millis(10, 20, 30)
+	tests := map[float64]time.Duration{
+		0:   10 * time.Millisecond,
+		34:  20 * time.Millisecond,
+		66:  20 * time.Millisecond,
+		67:  30 * time.Millisecond,
+		100: 30 * time.Millisecond,
+	}
+	for p, want := range tests {
+		if got := percentile(small, p); got != want {
+			t.Errorf("percentile(%v) of 3 samples = %v, want %v", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package buildinfo reports which build of the sample is running: the module version,
+// the VCS revision it was built from, the Go version, and the versions of the main
+// dependencies. It reads the information the Go toolchain embeds in every binary.
+package buildinfo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"text/tabwriter"
+)
+
+// Module paths of the dependencies whose versions are reported
+const (
+	mongoDriverPath = "go.mongodb.org/mongo-driver"
+	openAIPath      = "github.com/openai/openai-go/v3"
+)
+
+// Unknown stands in for a version the binary doesn't record
+const Unknown = "unknown"
+
+// Info describes one build. Revision, Time, and Dirty are only recorded by go build
+// inside a VCS checkout; go run and go test leave them empty.
+type Info struct {
+	Module      string `json:"module" bson:"module"`
+	Version     string `json:"version" bson:"version"`
+	Revision    string `json:"revision,omitempty" bson:"revision,omitempty"`
+	Time        string `json:"time,omitempty" bson:"time,omitempty"`
+	Dirty       bool   `json:"dirty" bson:"dirty"`
+	GoVersion   string `json:"goVersion" bson:"goVersion"`
+	MongoDriver string `json:"mongoDriver" bson:"mongoDriver"`
+	OpenAI      string `json:"openai" bson:"openai"`
+}
+
+// Read returns the build information of the running binary. It is computed once.
+var Read = sync.OnceValue(func() Info {
+	return FromBuildInfo(debug.ReadBuildInfo())
+})
+
+// FromBuildInfo extracts Info from the result of debug.ReadBuildInfo. When ok is false,
+// as in some test binaries, every field except GoVersion is Unknown.
+func FromBuildInfo(bi *debug.BuildInfo, ok bool) Info {
+	info := Info{
+		Module:      Unknown,
+		Version:     Unknown,
+		GoVersion:   runtime.Version(),
+		MongoDriver: Unknown,
+		OpenAI:      Unknown,
+	}
+	if !ok || bi == nil {
+		return info
+	}
+
+	if bi.Main.Path != "" {
+		info.Module = bi.Main.Path
+	}
+	if bi.Main.Version != "" {
+		info.Version = bi.Main.Version
+	}
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Dirty = setting.Value == "true"
+		}
+	}
+
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		switch dep.Path {
+		case mongoDriverPath:
+			info.MongoDriver = dep.Version
+		case openAIPath:
+			info.OpenAI = dep.Version
+		}
+	}
+	return info
+}
+
+// Render writes the information as an aligned block headed by name
+func (i Info) Render(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %s\n", name, i.Version)
+
+	revision := i.Revision
+	switch {
+	case revision == "":
+		revision = Unknown + " (not recorded; build with go build inside the repository)"
+	case i.Dirty:
+		revision += " (modified)"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "  module:\t%s\n", i.Module)
+	fmt.Fprintf(tw, "  revision:\t%s\n", revision)
+	if i.Time != "" {
+		fmt.Fprintf(tw, "  committed:\t%s\n", i.Time)
+	}
+	fmt.Fprintf(tw, "  go:\t%s\n", i.GoVersion)
+	fmt.Fprintf(tw, "  mongo-driver:\t%s\n", i.MongoDriver)
+	fmt.Fprintf(tw, "  openai-go:\t%s\n", i.OpenAI)
+	tw.Flush()
+}
@@ -0,0 +1,87 @@
+package buildinfo
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Path: "example.com/sample", Version: "v1.2.3"},
+		Deps: []*debug.Module{
+			{Path: mongoDriverPath, Version: "v1.17.0"},
+			{Path: openAIPath, Version: "v3.0.0", Replace: &debug.Module{Path: openAIPath, Version: "v3.1.0"}},
+			{Path: "golang.org/x/text", Version: "v0.20.0"},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2025-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	want := Info{
+		Module:      "example.com/sample",
+		Version:     "v1.2.3",
+		Revision:    "abc123",
+		Time:        "2025-01-02T03:04:05Z",
+		Dirty:       true,
+		GoVersion:   "go1.24.1",
+		MongoDriver: "v1.17.0",
+		OpenAI:      "v3.1.0",
+	}
+	if got := FromBuildInfo(bi, true); got != want {
+		t.Errorf("FromBuildInfo = %+v, want %+v", got, want)
+	}
+}
+
+func TestFromBuildInfoMissing(t *testing.T) {
+	want := Info{Module: Unknown, Version: Unknown, GoVersion: runtime.Version(), MongoDriver: Unknown, OpenAI: Unknown}
+	if got := FromBuildInfo(nil, false); got != want {
+		t.Errorf("FromBuildInfo(nil, false) = %+v, want %+v", got, want)
+	}
+
+	// A build without VCS stamping or the dependencies keeps the placeholders
+	got := FromBuildInfo(&debug.BuildInfo{Main: debug.Module{Path: "example.com/sample"}}, true)
+	if got.Version != Unknown || got.Revision != "" || got.Dirty || got.MongoDriver != Unknown || got.OpenAI != Unknown {
+		t.Errorf("FromBuildInfo = %+v", got)
+	}
+}
+
+func TestReadInTestBinary(t *testing.T) {
+	// Test binaries may or may not carry build information; either way Read fills every field
+	info := Read()
+	if info.Module == "" || info.Version == "" || info.GoVersion == "" || info.MongoDriver == "" || info.OpenAI == "" {
+		t.Errorf("Read = %+v, want every field set", info)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		info Info
+		want []string
+	}{
+		{"recorded", Info{Module: "example.com/sample", Version: "v1.2.3", Revision: "abc123", Time: "2025-01-02T03:04:05Z", Dirty: true, GoVersion: "go1.24.1", MongoDriver: "v1.17.0", OpenAI: "v3.1.0"}, []string{
+			"agent v1.2.3\n", "module:       example.com/sample", "revision:     abc123 (modified)", "committed:    2025-01-02T03:04:05Z", "mongo-driver: v1.17.0", "openai-go:    v3.1.0",
+		}},
+		{"not recorded", FromBuildInfo(nil, false), []string{"agent unknown\n", "revision:     unknown (not recorded"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tt.info.Render(&out, "agent")
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output is missing %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/joho/godotenv"
+)
+
+// Sources of configuration variables, reported at debug level
+const (
+	sourceProcess = "environment"
+	sourceDotEnv  = ".env"
+	sourceUnset   = "unset"
+)
+
+// configVariables returns the variables reported with their source once logging is
+// set up: every variable of config.Variables
+func configVariables() []string {
+	var names []string
+	for _, v := range config.Variables() {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// envSources records where each variable loaded by LoadEnv came from
+var envSources map[string]string
+
+// LoadEnv loads configuration into the process environment. Precedence, highest first:
+// the process environment, ./.env, then the azd environment file (.azure/<env>/.env)
+// when AZD_ENV_NAME is set or a .azure directory is found in the working directory
+// or one of its parents. Missing files are ignored.
+func LoadEnv() {
+	sources, err := loadEnv(".")
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	envSources = sources
+}
+
+// loadEnv merges the .env file in dir and the azd environment under the process
+// environment and returns the source of every variable it saw
+func loadEnv(dir string) (map[string]string, error) {
+	sources := make(map[string]string)
+	for _, name := range configVariables() {
+		if _, ok := os.LookupEnv(name); ok {
+			sources[name] = sourceProcess
+		}
+	}
+
+	local, localErr := readEnvFile(filepath.Join(dir, ".env"))
+	apply(local, sourceDotEnv, sources)
+
+	// AZD_ENV_NAME may itself come from the local .env
+	azdPath := azdEnvFile(dir, os.Getenv("AZD_ENV_NAME"))
+	if azdPath == "" {
+		if errors.Is(localErr, fs.ErrNotExist) {
+			return sources, errors.New(".env file not found and no azd environment detected")
+		}
+		return sources, localErr
+	}
+
+	azd, err := readEnvFile(azdPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return sources, err
+	}
+	apply(azd, azdPath, sources)
+
+	if errors.Is(localErr, fs.ErrNotExist) {
+		localErr = nil
+	}
+	return sources, localErr
+}
+
+// readEnvFile parses a dotenv file
+func readEnvFile(path string) (map[string]string, error) {
+	values, err := godotenv.Read(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return values, err
+}
+
+// apply sets the variables in values that are not already set, recording source for each
+func apply(values map[string]string, source string, sources map[string]string) {
+	for name, value := range values {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		os.Setenv(name, value)
+		sources[name] = source
+	}
+}
+
+// azdEnvFile returns the path of the azd environment file for envName, or for the
+// default environment in .azure/config.json when envName is empty. It returns ""
+// when there is no .azure directory or no environment can be chosen.
+func azdEnvFile(dir, envName string) string {
+	azureDir := findAzureDir(dir)
+	if azureDir == "" {
+		return ""
+	}
+
+	if envName == "" {
+		data, err := os.ReadFile(filepath.Join(azureDir, "config.json"))
+		if err != nil {
+			return ""
+		}
+		var config struct {
+			DefaultEnvironment string `json:"defaultEnvironment"`
+		}
+		if json.Unmarshal(data, &config) != nil {
+			return ""
+		}
+		envName = config.DefaultEnvironment
+	}
+	if envName == "" {
+		return ""
+	}
+
+	return filepath.Join(azureDir, envName, ".env")
+}
+
+// findAzureDir looks for a .azure directory in dir and its parents
+func findAzureDir(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		candidate := filepath.Join(dir, ".azure")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// logEnvSources reports where each configuration variable came from at debug level
+func logEnvSources(logger *slog.Logger) {
+	if envSources == nil {
+		return
+	}
+	for _, name := range configVariables() {
+		source, ok := envSources[name]
+		if !ok {
+			source = sourceUnset
+		}
+		logger.Debug("config variable", "name", name, "source", source)
+	}
+}
+
+// AddConfigFlag registers --config on fs, defaulting to CONFIG_FILE
+func AddConfigFlag(fs *flag.FlagSet, path *string) {
+	fs.StringVar(path, "config", os.Getenv("CONFIG_FILE"), "YAML or JSON config file; environment variables override its values (env CONFIG_FILE)")
+}
+
+// LoadConfig loads the settings from the optional config file at path and the environment,
+// then validates the variables req needs, reporting every problem at once. Commands that
+// can run offline open their services with the backend package instead; for every other
+// command, OFFLINE_MODE is a configuration error.
+func LoadConfig(path string, req config.Requirements) (*config.Config, error) {
+	if offline.Enabled(os.Getenv) {
+		return nil, fmt.Errorf("%w: OFFLINE_MODE is set, but this command only works against Azure; unset OFFLINE_MODE to run it", ErrConfig)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	if err := config.ValidateEnvironment(os.Getenv, req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	return cfg, nil
+}
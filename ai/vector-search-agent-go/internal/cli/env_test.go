@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+)
+
+// unsetenv unsets the variables for the duration of the test, restoring them afterwards
+func unsetenv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			t.Cleanup(func() { os.Setenv(name, value) })
+		} else {
+			t.Cleanup(func() { os.Unsetenv(name) })
+		}
+		os.Unsetenv(name)
+	}
+}
+
+// writeFile writes content to dir/name, creating parent directories
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadEnvPrecedence(t *testing.T) {
+	unsetenv(t, "AZD_ENV_NAME", "AZURE_OPENAI_ENDPOINT", "AZURE_DOCUMENTDB_CLUSTER", "AZURE_DOCUMENTDB_DATABASENAME", "AZURE_DOCUMENTDB_COLLECTION")
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://process.openai.azure.com")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "AZURE_OPENAI_ENDPOINT=https://dotenv.openai.azure.com\nAZURE_DOCUMENTDB_CLUSTER=dotenv-cluster\n")
+	writeFile(t, dir, ".azure/config.json", `{"version": 1, "defaultEnvironment": "dev"}`)
+	writeFile(t, dir, ".azure/dev/.env", "AZURE_OPENAI_ENDPOINT=\"https://azd.openai.azure.com\"\nAZURE_DOCUMENTDB_CLUSTER=\"azd-cluster\"\nAZURE_DOCUMENTDB_DATABASENAME=\"azd-db\"\n")
+
+	sources, err := loadEnv(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	azdFile := filepath.Join(dir, ".azure", "dev", ".env")
+	tests := []struct {
+		name, value, source string
+	}{
+		{"AZURE_OPENAI_ENDPOINT", "https://process.openai.azure.com", sourceProcess},
+		{"AZURE_DOCUMENTDB_CLUSTER", "dotenv-cluster", sourceDotEnv},
+		{"AZURE_DOCUMENTDB_DATABASENAME", "azd-db", azdFile},
+	}
+	for _, tt := range tests {
+		if got := os.Getenv(tt.name); got != tt.value {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.value)
+		}
+		if sources[tt.name] != tt.source {
+			t.Errorf("%s source = %q, want %q", tt.name, sources[tt.name], tt.source)
+		}
+	}
+	if _, ok := sources["AZURE_DOCUMENTDB_COLLECTION"]; ok {
+		t.Error("unset variable has a source")
+	}
+}
+
+func TestLoadEnvAzdEnvName(t *testing.T) {
+	unsetenv(t, "AZD_ENV_NAME", "AZURE_DOCUMENTDB_DATABASENAME")
+
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "AZD_ENV_NAME=prod\n")
+	writeFile(t, dir, ".azure/config.json", `{"defaultEnvironment": "dev"}`)
+	writeFile(t, dir, ".azure/dev/.env", "AZURE_DOCUMENTDB_DATABASENAME=dev-db\n")
+	writeFile(t, dir, ".azure/prod/.env", "AZURE_DOCUMENTDB_DATABASENAME=prod-db\n")
+
+	// AZD_ENV_NAME from the local .env selects the environment over the default
+	if _, err := loadEnv(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("AZURE_DOCUMENTDB_DATABASENAME"); got != "prod-db" {
+		t.Errorf("database = %q, want prod-db", got)
+	}
+}
+
+func TestLoadEnvFindsAzureDirInParent(t *testing.T) {
+	unsetenv(t, "AZD_ENV_NAME", "AZURE_DOCUMENTDB_DATABASENAME")
+
+	root := t.TempDir()
+	writeFile(t, root, ".azure/config.json", `{"defaultEnvironment": "dev"}`)
+	writeFile(t, root, ".azure/dev/.env", "AZURE_DOCUMENTDB_DATABASENAME=azd-db\n")
+	dir := filepath.Join(root, "ai", "vector-search-agent-go")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// No local .env is fine when an azd environment is found
+	if _, err := loadEnv(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("AZURE_DOCUMENTDB_DATABASENAME"); got != "azd-db" {
+		t.Errorf("database = %q, want azd-db", got)
+	}
+}
+
+func TestLoadEnvMissingAzd(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"no .azure directory", map[string]string{".env": "AZURE_DOCUMENTDB_DATABASENAME=local\n"}, ""},
+		{"no default environment", map[string]string{".env": "X=1\n", ".azure/config.json": `{}`}, ""},
+		{"missing environment directory", map[string]string{".env": "X=1\n", ".azure/config.json": `{"defaultEnvironment": "gone"}`}, ""},
+		{"nothing at all", nil, ".env file not found and no azd environment detected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "AZD_ENV_NAME", "AZURE_DOCUMENTDB_DATABASENAME", "X")
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, dir, name, content)
+			}
+
+			_, err := loadEnv(dir)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		args []string
+		want string
+	}{
+		{"unset", "", nil, ""},
+		{"env", "team.yaml", nil, "team.yaml"},
+		{"flag overrides env", "team.yaml", []string{"--config", "local.json"}, "local.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", tt.env)
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			var path string
+			AddConfigFlag(fs, &path)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if path != tt.want {
+				t.Errorf("config = %q, want %q", path, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrorIsConfigError(t *testing.T) {
+	_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), config.Requirements{})
+	if !errors.Is(err, ErrConfig) || ExitCode(err) != ExitUsage {
+		t.Errorf("err = %v (exit %d), want a configuration error", err, ExitCode(err))
+	}
+}
+
+func TestLoadConfigValidatesRequirements(t *testing.T) {
+	unsetenv(t, "AZURE_DOCUMENTDB_DATABASENAME", "AZURE_DOCUMENTDB_COLLECTION", "AZURE_DOCUMENTDB_CONNECTION_STRING", "AZURE_DOCUMENTDB_CLUSTER", "USE_PASSWORDLESS")
+
+	_, err := LoadConfig("", config.Requirements{DocumentDB: true})
+	var envErr *config.EnvironmentError
+	if !errors.As(err, &envErr) || ExitCode(err) != ExitUsage {
+		t.Fatalf("err = %v, want an environment error with the usage exit code", err)
+	}
+	if len(envErr.Problems) != 3 {
+		t.Errorf("problems = %v, want the database, collection, and credentials", envErr.Problems)
+	}
+}
+
+func TestLoadConfigRejectsOfflineMode(t *testing.T) {
+	t.Setenv("OFFLINE_MODE", "true")
+
+	_, err := LoadConfig("", config.Requirements{})
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "unset OFFLINE_MODE") {
+		t.Errorf("err = %v, want a config error naming OFFLINE_MODE", err)
+	}
+}
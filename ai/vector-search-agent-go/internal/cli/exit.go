@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/hints"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/leakcheck"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Exit codes shared by every command
+const (
+	ExitOK           = 0
+	ExitFailure      = 1 // any error not covered below
+	ExitUsage        = 2 // invalid flags or missing/invalid configuration
+	ExitAuth         = 3 // credentials rejected or unavailable
+	ExitConnectivity = 4 // service unreachable, timed out, throttled, or failing
+	ExitData         = 5 // malformed, missing, or inconsistent data
+	ExitPartial      = 6 // the command finished but some items failed
+)
+
+// ExitCodesHelp documents the exit codes for --help output
+const ExitCodesHelp = `
+Exit codes:
+  0    success
+  1    unclassified failure
+  2    invalid flags or configuration
+  3    authentication failure
+  4    connectivity failure or transient outage
+  5    invalid or missing data
+  6    partial failure: some items failed
+  130  interrupted
+`
+
+// ErrUsage marks errors that were already reported along with the usage text
+var ErrUsage = errors.New("usage error")
+
+// ErrConfig marks invalid configuration detected by a command
+var ErrConfig = errors.New("invalid configuration")
+
+// ErrData marks invalid or inconsistent data detected by a command
+var ErrData = errors.New("invalid data")
+
+// ErrPartial marks a run that finished with some failed items
+var ErrPartial = errors.New("partial failure")
+
+// Usage wraps a flag parsing or validation error that has already been reported.
+// flag.ErrHelp is returned unchanged so help exits successfully.
+func Usage(err error) error {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrUsage, err)
+}
+
+// ExitCode classifies err into one of the exit codes
+func ExitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, ErrUsage), errors.Is(err, ErrConfig),
+		errors.Is(err, clients.ErrMissingConfig), errors.Is(err, vectorstore.ErrMissingConfig):
+		return ExitUsage
+	case clients.IsAuthError(err), vectorstore.IsAuthError(err):
+		return ExitAuth
+	case errors.Is(err, context.DeadlineExceeded), clients.IsTransient(err), vectorstore.IsConnectivityError(err):
+		return ExitConnectivity
+	case errors.Is(err, ErrData), errors.Is(err, vectorstore.ErrInvalidData), errors.Is(err, vectorstore.ErrIndexMissing),
+		errors.Is(err, fs.ErrNotExist):
+		return ExitData
+	case errors.Is(err, ErrPartial):
+		return ExitPartial
+	default:
+		return ExitFailure
+	}
+}
+
+// leaks holds the snapshot taken as the command starts when LEAK_CHECK is true in the
+// process environment. It is taken before .env is loaded, so LEAK_CHECK there is ignored.
+var leaks = startLeakCheck()
+
+// startLeakCheck returns the leak checker of the command, or nil when LEAK_CHECK is off
+func startLeakCheck() *leakcheck.Checker {
+	if !leakcheck.Enabled() {
+		return nil
+	}
+	return leakcheck.Start(leakcheck.Default())
+}
+
+// Exit reports err, unless it was already reported, followed by a "What to check" block
+// when it is a failure with a hint, and exits with its exit code.
+// Commands call it from main with the result of run, so deferred cleanup in run,
+// such as disconnecting from the database, has finished before the process exits.
+// With LEAK_CHECK set, it then checks for leaks, and a leak fails a successful run.
+func Exit(err error) {
+	code := ExitCode(err)
+	switch {
+	case code == ExitOK, errors.Is(err, ErrUsage):
+	case code == ExitInterrupted:
+		fmt.Fprintln(os.Stderr, "Cancelled by user")
+	default:
+		log.Printf("Error: %v", err)
+		hints.Write(log.Writer(), err)
+	}
+	if leaks != nil {
+		if leakErr := leaks.Check(); leakErr != nil {
+			log.Printf("LEAK CHECK: %v", leakErr)
+			if code == ExitOK {
+				code = ExitFailure
+			}
+		}
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/openai/openai-go/v3"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitOK},
+		{"help", flag.ErrHelp, ExitOK},
+		{"usage", Usage(errors.New("--k must be positive")), ExitUsage},
+		{"config", fmt.Errorf("%w: BATCH_SIZE must be positive", ErrConfig), ExitUsage},
+		{"missing OpenAI setting", fmt.Errorf("failed to create OpenAI clients: %w", fmt.Errorf("%w: AZURE_OPENAI_API_KEY", clients.ErrMissingConfig)), ExitUsage},
+		{"missing store setting", fmt.Errorf("failed to connect: %w", vectorstore.ErrMissingConfig), ExitUsage},
+		{"OpenAI unauthorized", fmt.Errorf("failed to generate embedding: %w", &openai.Error{StatusCode: http.StatusUnauthorized}), ExitAuth},
+		{"store auth", fmt.Errorf("failed to connect: %w", mongo.CommandError{Code: 18}), ExitAuth},
+		{"OpenAI throttled", fmt.Errorf("planner: %w", &openai.Error{StatusCode: http.StatusTooManyRequests}), ExitConnectivity},
+		{"store unreachable", fmt.Errorf("failed to ping: %w", topology.ServerSelectionError{}), ExitConnectivity},
+		{"deadline", fmt.Errorf("agent run: %w", context.DeadlineExceeded), ExitConnectivity},
+		{"invalid data", fmt.Errorf("upload: %w", fmt.Errorf("%w: 2 hotels have no vectors", vectorstore.ErrInvalidData)), ExitData},
+		{"missing index", fmt.Errorf("search: %w on DescriptionVector: create it with cmd/upload", vectorstore.ErrIndexMissing), ExitData},
+		{"missing file", fmt.Errorf("failed to load data: %w", fs.ErrNotExist), ExitData},
+		{"command data", fmt.Errorf("%w: no results found", ErrData), ExitData},
+		{"partial", fmt.Errorf("%w: 3 documents failed to embed", ErrPartial), ExitPartial},
+		{"interrupted", fmt.Errorf("upload: %w", context.Canceled), ExitInterrupted},
+		{"unclassified", errors.New("boom"), ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if err := Usage(flag.ErrHelp); err != flag.ErrHelp {
+		t.Errorf("Usage(flag.ErrHelp) = %v, want flag.ErrHelp unchanged", err)
+	}
+	if err := Usage(nil); err != nil {
+		t.Errorf("Usage(nil) = %v, want nil", err)
+	}
+
+	inner := errors.New("invalid k 0")
+	if err := Usage(inner); !errors.Is(err, ErrUsage) || !errors.Is(err, inner) {
+		t.Errorf("Usage(err) = %v, want it to wrap ErrUsage and err", err)
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+)
+
+// SetupMetrics installs a Prometheus recorder and serves it at /metrics on METRICS_ADDR,
+// such as ":9090", when that is set; otherwise metrics are discarded. Defer the returned
+// function to stop the listener.
+func SetupMetrics(getenv func(string) string) (func(), error) {
+	addr := getenv("METRICS_ADDR")
+	if addr == "" {
+		return func() {}, nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot serve metrics on METRICS_ADDR %q: %w", ErrConfig, addr, err)
+	}
+
+	recorder := metrics.NewPrometheus()
+	metrics.SetDefault(recorder)
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", recorder.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("metrics listener stopped", "err", err)
+		}
+	}()
+	slog.Info("serving metrics", "addr", listener.Addr().String())
+
+	return func() {
+		_ = srv.Close()
+		metrics.SetDefault(nil)
+	}, nil
+}
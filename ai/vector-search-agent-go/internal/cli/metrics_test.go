@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+)
+
+func TestSetupMetrics(t *testing.T) {
+	previous := metrics.Default()
+	t.Cleanup(func() { metrics.SetDefault(previous) })
+
+	// Without METRICS_ADDR metrics stay discarded
+	stop, err := SetupMetrics(func(string) string { return "" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	if _, ok := metrics.Default().(*metrics.Prometheus); ok {
+		t.Error("installed a Prometheus recorder without METRICS_ADDR")
+	}
+
+	stop, err = SetupMetrics(func(string) string { return "127.0.0.1:0" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := metrics.Default().(*metrics.Prometheus); !ok {
+		t.Error("METRICS_ADDR did not install a Prometheus recorder")
+	}
+	stop()
+	if _, ok := metrics.Default().(*metrics.Prometheus); ok {
+		t.Error("stopping the listener kept the Prometheus recorder")
+	}
+
+	if _, err := SetupMetrics(func(string) string { return "not an address" }); !errors.Is(err, ErrConfig) {
+		t.Errorf("err = %v, want a configuration error", err)
+	}
+}
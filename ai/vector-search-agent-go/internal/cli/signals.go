@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// ExitInterrupted is the conventional exit status for a run stopped by SIGINT
+const ExitInterrupted = 130
+
+// NotifyContext returns a copy of parent that is cancelled on the first SIGINT or
+// SIGTERM, so the command can finish in-flight work, flush state, and disconnect.
+// A notice is written to w. A second signal exits immediately with ExitInterrupted.
+// Call stop to release the signal handler.
+func NotifyContext(parent context.Context, w io.Writer) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(w, "\nReceived %s: finishing in-flight work and shutting down (press Ctrl+C again to force exit)\n", sig)
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case <-signals:
+			fmt.Fprintln(w, "Forced exit")
+			os.Exit(ExitInterrupted)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			cancel()
+			close(done)
+		})
+	}
+}
+
+// RunContext returns the root context of a one-shot command: cancelled on SIGINT or
+// SIGTERM like NotifyContext, and bounded by run, the budget of the whole run, so a stuck
+// dependency ends the run instead of hanging it. The stages of the run derive their own
+// budgets from it. Defer stop with the address of the command's error: it releases the
+// signal handler and, when the run used up its budget, names the budget in the error.
+func RunContext(w io.Writer, run timeouts.Budget) (ctx context.Context, stop func(err *error)) {
+	ctx, stopSignals := NotifyContext(context.Background(), w)
+	ctx, cancel := timeouts.WithBudget(ctx, run)
+	return ctx, func(err *error) {
+		*err = timeouts.Explain(ctx, *err)
+		cancel()
+		stopSignals()
+	}
+}
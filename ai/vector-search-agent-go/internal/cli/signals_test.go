@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestRunContextNamesRunBudget(t *testing.T) {
+	budget := timeouts.Budget{Stage: timeouts.StageRun, Timeout: 10 * time.Millisecond, Setting: "RUN_TIMEOUT"}
+	ctx, stop := RunContext(io.Discard, budget)
+	<-ctx.Done()
+
+	// A driver reports the deadline without saying whose it was
+	err := fmt.Errorf("cursor: %w", ctx.Err())
+	stop(&err)
+	var timedOut *timeouts.Error
+	if !errors.As(err, &timedOut) || timedOut.Budget != budget {
+		t.Fatalf("err = %v, want the run budget named", err)
+	}
+
+	ctx, stop = RunContext(io.Discard, budget)
+	var ok error
+	stop(&ok)
+	if ok != nil || !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("after stop: err = %v, ctx.Err() = %v; want nil and the context cancelled", ok, ctx.Err())
+	}
+}
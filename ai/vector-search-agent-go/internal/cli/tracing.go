@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+)
+
+// SetupTracing installs the OpenTelemetry tracer provider configured by the OTEL_*
+// environment variables, naming the service after the command unless OTEL_SERVICE_NAME
+// is set. Defer the returned function to flush spans before the command exits.
+func SetupTracing(command string) (func(), error) {
+	flush, err := telemetry.Setup(context.Background(), "vector-search-agent-"+command)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	return flush, nil
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+)
+
+// Verbosity counts -v flags. -v, -vv, and -vvv select the info, debug, and trace log levels.
+type Verbosity int
+
+// verbosityFlag is a boolean flag that adds n to a Verbosity each time it is set
+type verbosityFlag struct {
+	v *Verbosity
+	n int
+}
+
+func (f verbosityFlag) String() string {
+	return ""
+}
+
+func (f verbosityFlag) Set(s string) error {
+	set, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if set {
+		*f.v += Verbosity(f.n)
+	}
+	return nil
+}
+
+func (f verbosityFlag) IsBoolFlag() bool {
+	return true
+}
+
+// AddVerbosityFlags registers -v, -vv, and -vvv on fs, counting into v
+func AddVerbosityFlags(fs *flag.FlagSet, v *Verbosity) {
+	fs.Var(verbosityFlag{v, 1}, "v", "Verbose output: stage progress (-vv adds parameters and counts, -vvv adds prompt bodies) (env LOG_LEVEL)")
+	fs.Var(verbosityFlag{v, 2}, "vv", "Same as -v -v")
+	fs.Var(verbosityFlag{v, 3}, "vvv", "Same as -v -v -v")
+}
+
+// LogLevel resolves the log level: -v flags take precedence over LOG_LEVEL, then
+// DEBUG=true (debug), then fallback
+func (v Verbosity) LogLevel(getenv func(string) string, fallback slog.Level) (slog.Level, error) {
+	if v > 0 {
+		return logging.VerbosityLevel(int(v)), nil
+	}
+	return logging.LevelFromEnv(getenv, fallback)
+}
+
+// SetupLogging installs the shared logger at the level chosen by v and the environment,
+// in the LOG_FORMAT format, then reports the source of each configuration variable
+// loaded by LoadEnv at debug level
+func SetupLogging(v Verbosity, getenv func(string) string, fallback slog.Level) (*slog.Logger, error) {
+	lvl, err := v.LogLevel(getenv, fallback)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	format, err := logging.ParseFormat(getenv("LOG_FORMAT"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	logger := logging.Setup(lvl, format)
+	logEnvSources(logger)
+	return logger, nil
+}
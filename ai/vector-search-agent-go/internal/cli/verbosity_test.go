@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+)
+
+func TestVerbosityFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want Verbosity
+	}{
+		{nil, 0},
+		{[]string{"-v"}, 1},
+		{[]string{"-v", "-v"}, 2},
+		{[]string{"-vv"}, 2},
+		{[]string{"-vvv"}, 3},
+		{[]string{"-v", "-vv"}, 3},
+		{[]string{"-v=false"}, 0},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		var v Verbosity
+		AddVerbosityFlags(fs, &v)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%v): %v", tt.args, err)
+		}
+		if v != tt.want {
+			t.Errorf("%v: verbosity = %d, want %d", tt.args, v, tt.want)
+		}
+	}
+}
+
+func TestVerbosityLogLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Verbosity
+		env  map[string]string
+		want slog.Level
+	}{
+		{"fallback", 0, nil, slog.LevelWarn},
+		{"LOG_LEVEL", 0, map[string]string{"LOG_LEVEL": "info"}, slog.LevelInfo},
+		{"DEBUG compatibility", 0, map[string]string{"DEBUG": "true"}, slog.LevelDebug},
+		{"flag wins over env", 3, map[string]string{"LOG_LEVEL": "error", "DEBUG": "true"}, logging.LevelTrace},
+		{"-vv", 2, nil, slog.LevelDebug},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.v.LogLevel(func(key string) string { return tt.env[key] }, slog.LevelWarn)
+			if err != nil || got != tt.want {
+				t.Errorf("LogLevel = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupLoggingRejectsUnknownFormat(t *testing.T) {
+	getenv := func(key string) string { return map[string]string{"LOG_FORMAT": "xml"}[key] }
+	if _, err := SetupLogging(0, getenv, slog.LevelWarn); !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), `invalid LOG_FORMAT "xml"`) {
+		t.Errorf("err = %v, want a configuration error naming LOG_FORMAT", err)
+	}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+)
+
+// AddVersionFlag registers --version on fs so it is listed in the usage text.
+// HandleVersion acts on it before the flags are parsed.
+func AddVersionFlag(fs *flag.FlagSet) {
+	fs.Bool("version", false, "Print version and build information, then exit (same as the version subcommand)")
+}
+
+// HandleVersion prints the build information to w and reports true when args ask for it,
+// either with --version before any -- terminator or as the single argument version
+func HandleVersion(args []string, w io.Writer) bool {
+	requested := len(args) == 1 && args[0] == "version"
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		switch arg {
+		case "-version", "--version", "-version=true", "--version=true":
+			requested = true
+		}
+	}
+	if requested {
+		buildinfo.Read().Render(w, filepath.Base(os.Args[0]))
+	}
+	return requested
+}
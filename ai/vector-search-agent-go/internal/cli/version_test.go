@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHandleVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"no args", nil, false},
+		{"flag", []string{"--version"}, true},
+		{"single dash", []string{"-k", "3", "-version"}, true},
+		{"explicit true", []string{"--version=true"}, true},
+		{"subcommand", []string{"version"}, true},
+		{"query named version", []string{"--query", "version"}, false},
+		{"after terminator", []string{"--", "--version"}, false},
+		{"explicit false", []string{"--version=false"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := HandleVersion(tt.args, &out); got != tt.want {
+				t.Errorf("HandleVersion = %v, want %v", got, tt.want)
+			}
+			if printed := strings.Contains(out.String(), "go:"); printed != tt.want {
+				t.Errorf("printed = %v, want %v:\n%s", printed, tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestAddVersionFlagListsVersion(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	AddVersionFlag(fs)
+	if fs.Lookup("version") == nil {
+		t.Fatal("--version is not registered")
+	}
+	// The flag parses, so commands that check it after HandleVersion don't fail
+	if err := fs.Parse([]string{"--version"}); err != nil {
+		t.Errorf("Parse: %v", err)
+	}
+}
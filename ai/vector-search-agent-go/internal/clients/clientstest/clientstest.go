@@ -0,0 +1,331 @@
+// Package clientstest fakes an Azure OpenAI resource, so the clients and the code built
+// on them can be tested without Azure. The fake serves the embeddings and chat
+// completions APIs of any deployment, answering each request from a per-deployment
+// script or, when the script has run out, with a default answer.
+package clientstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Token counts reported in the usage of the default and scripted answers
+const (
+	EmbeddingPromptTokens = 7
+	PromptTokens          = 40
+	CompletionTokens      = 5
+)
+
+// DefaultModel is the model the fake reports behind every deployment
+const DefaultModel = "gpt-4o-2024-08-06"
+
+// DefaultAnswer is the content of chat completions that aren't scripted
+const DefaultAnswer = "Hotel 1."
+
+// Operations of the Azure OpenAI API the fake serves
+const (
+	OperationEmbeddings = "embeddings"
+	OperationChat       = "chat/completions"
+)
+
+// deploymentPath matches the request paths of the Azure OpenAI data plane
+var deploymentPath = regexp.MustCompile(`^/openai/deployments/([^/]+)/(embeddings|chat/completions)$`)
+
+// Response is a scripted answer to one request. Streaming requests are answered with
+// Events as server-sent events when the response has any, and with Body otherwise.
+type Response struct {
+	Status int
+	Header map[string]string
+	Body   string
+	// Events are the data payloads of a streamed answer; the closing [DONE] is added
+	Events []string
+	// Delay holds the answer back, to fake a slow deployment. The wait ends early when
+	// the client gives up on the request.
+	Delay time.Duration
+}
+
+// Request is a request the fake received
+type Request struct {
+	Deployment string
+	Operation  string
+	APIVersion string
+	Header     http.Header
+	// Body is the decoded JSON body
+	Body map[string]any
+}
+
+// Server is a fake Azure OpenAI resource. Use URL as the endpoint of the clients
+// under test.
+type Server struct {
+	URL string
+
+	mu       sync.Mutex
+	scripts  map[string][]Response
+	requests []Request
+}
+
+// NewServer starts a fake Azure OpenAI resource that stops when the test finishes
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{scripts: map[string][]Response{}}
+	srv := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// Script queues responses for deployment. Each request to the deployment takes the
+// next one; once they are used up, requests get the default answer.
+func (s *Server) Script(deployment string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[deployment] = append(s.scripts[deployment], responses...)
+}
+
+// Requests returns the requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// serve records the request and writes its scripted or default answer
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	match := deploymentPath.FindStringSubmatch(r.URL.Path)
+	if match == nil || r.Method != http.MethodPost {
+		write(w, Error(http.StatusNotFound, "404", "Resource not found"))
+		return
+	}
+
+	req := Request{Deployment: match[1], Operation: match[2], APIVersion: r.URL.Query().Get("api-version"), Header: r.Header.Clone()}
+	data, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(data, &req.Body)
+	}
+	if err != nil {
+		write(w, Error(http.StatusBadRequest, "BadRequest", fmt.Sprintf("invalid request body: %v", err)))
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	resp, scripted := s.next(req.Deployment)
+	s.mu.Unlock()
+
+	if resp.Delay > 0 {
+		select {
+		case <-time.After(resp.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	stream, _ := req.Body["stream"].(bool)
+	switch {
+	case scripted && stream && len(resp.Events) > 0:
+		writeEvents(w, resp)
+	case scripted:
+		write(w, resp)
+	case req.Operation == OperationEmbeddings:
+		write(w, defaultEmbeddings(req.Body["input"]))
+	case stream:
+		writeEvents(w, Stream(DefaultAnswer))
+	default:
+		write(w, Completion(DefaultAnswer))
+	}
+}
+
+// next takes the next scripted response of deployment, if any. Callers hold s.mu.
+func (s *Server) next(deployment string) (Response, bool) {
+	script := s.scripts[deployment]
+	if len(script) == 0 {
+		return Response{}, false
+	}
+	s.scripts[deployment] = script[1:]
+	return script[0], true
+}
+
+// write writes a JSON response
+func write(w http.ResponseWriter, resp Response) {
+	for name, value := range resp.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, resp.Body)
+}
+
+// writeEvents writes a streamed response as server-sent events
+func writeEvents(w http.ResponseWriter, resp Response) {
+	for name, value := range resp.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	for _, event := range slices.Concat(resp.Events, []string{"[DONE]"}) {
+		fmt.Fprintf(w, "data: %s\n\n", event)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// defaultEmbeddings answers an embeddings request with one small vector per input
+func defaultEmbeddings(input any) Response {
+	count := 1
+	if inputs, ok := input.([]any); ok {
+		count = len(inputs)
+	}
+	vectors := make([][]float64, count)
+	for i := range vectors {
+		vectors[i] = []float64{0.1 * float64(i+1), 0.2}
+	}
+	return Embeddings(vectors...)
+}
+
+// Embeddings answers an embeddings request with vectors, indexed in order
+func Embeddings(vectors ...[]float64) Response {
+	data := make([]map[string]any, len(vectors))
+	for i, vector := range vectors {
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": vector}
+	}
+	return jsonResponse(http.StatusOK, map[string]any{
+		"object": "list",
+		"model":  DefaultModel,
+		"data":   data,
+		"usage":  map[string]any{"prompt_tokens": EmbeddingPromptTokens, "total_tokens": EmbeddingPromptTokens},
+	})
+}
+
+// Completion answers a chat completion request with content
+func Completion(content string) Response {
+	return completion("stop", map[string]any{"role": "assistant", "content": content})
+}
+
+// ToolCall is a function call in a scripted planner answer
+type ToolCall struct {
+	Name string
+	// Arguments is the JSON object of the call's arguments
+	Arguments string
+}
+
+// ToolCalls answers a chat completion request by calling the given tools
+func ToolCalls(calls ...ToolCall) Response {
+	toolCalls := make([]map[string]any, len(calls))
+	for i, call := range calls {
+		toolCalls[i] = map[string]any{
+			"id":       "call_" + strconv.Itoa(i+1),
+			"type":     "function",
+			"function": map[string]any{"name": call.Name, "arguments": call.Arguments},
+		}
+	}
+	return completion("tool_calls", map[string]any{"role": "assistant", "content": nil, "tool_calls": toolCalls})
+}
+
+// FilteredCompletion is a chat completion whose output the content filter withheld
+func FilteredCompletion() Response {
+	return completion("content_filter", map[string]any{"role": "assistant", "content": ""})
+}
+
+// completion is a chat completion ending for finishReason with message
+func completion(finishReason string, message map[string]any) Response {
+	return jsonResponse(http.StatusOK, map[string]any{
+		"id":      "chatcmpl-fake",
+		"object":  "chat.completion",
+		"created": 1700000000,
+		"model":   DefaultModel,
+		"choices": []any{map[string]any{"index": 0, "finish_reason": finishReason, "message": message}},
+		"usage":   map[string]any{"prompt_tokens": PromptTokens, "completion_tokens": CompletionTokens, "total_tokens": PromptTokens + CompletionTokens},
+	})
+}
+
+// Stream answers a streaming chat completion request with one chunk per delta, then a
+// chunk with the finish reason and a final chunk with the usage
+func Stream(deltas ...string) Response {
+	chunk := func(choices []any, usage any) string {
+		data, _ := json.Marshal(map[string]any{
+			"id":      "chatcmpl-fake",
+			"object":  "chat.completion.chunk",
+			"created": 1700000000,
+			"model":   DefaultModel,
+			"choices": choices,
+			"usage":   usage,
+		})
+		return string(data)
+	}
+
+	var events []string
+	for i, delta := range deltas {
+		content := map[string]any{"content": delta}
+		if i == 0 {
+			content["role"] = "assistant"
+		}
+		events = append(events, chunk([]any{map[string]any{"index": 0, "delta": content, "finish_reason": nil}}, nil))
+	}
+	events = append(events,
+		chunk([]any{map[string]any{"index": 0, "delta": map[string]any{}, "finish_reason": "stop"}}, nil),
+		chunk([]any{}, map[string]any{"prompt_tokens": PromptTokens, "completion_tokens": CompletionTokens, "total_tokens": PromptTokens + CompletionTokens}),
+	)
+	return Response{Events: events}
+}
+
+// Error is an Azure OpenAI error response
+func Error(status int, code, message string) Response {
+	return jsonResponse(status, map[string]any{"error": map[string]any{"code": code, "message": message}})
+}
+
+// NotFound is the error for a deployment the resource doesn't have
+func NotFound() Response {
+	return Error(http.StatusNotFound, "DeploymentNotFound", "The API deployment for this resource does not exist.")
+}
+
+// Throttled is a 429 asking the client to retry after retryAfter, in the retry-after-ms
+// header and, rounded up to whole seconds, in Retry-After, as Azure OpenAI does
+func Throttled(retryAfter time.Duration) Response {
+	resp := Error(http.StatusTooManyRequests, "429", "Requests to the deployment have exceeded the rate limit of your current pricing tier.")
+	resp.Header = map[string]string{
+		"retry-after-ms": strconv.FormatInt(retryAfter.Milliseconds(), 10),
+		"Retry-After":    strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))),
+	}
+	return resp
+}
+
+// ContentFiltered is the 400 Azure OpenAI returns when the prompt trips the content filter
+func ContentFiltered() Response {
+	return jsonResponse(http.StatusBadRequest, map[string]any{"error": map[string]any{
+		"code":    "content_filter",
+		"param":   "prompt",
+		"status":  http.StatusBadRequest,
+		"message": "The response was filtered due to the prompt triggering Azure OpenAI's content management policy.",
+		"innererror": map[string]any{
+			"code": "ResponsibleAIPolicyViolation",
+			"content_filter_result": map[string]any{
+				"violence": map[string]any{"filtered": true, "severity": "high"},
+			},
+		},
+	}})
+}
+
+// jsonResponse encodes body as a response with status
+func jsonResponse(status int, body any) Response {
+	data, err := json.Marshal(body)
+	if err != nil {
+		panic(err)
+	}
+	return Response{Status: status, Body: string(data)}
+}
@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/openai/openai-go/v3"
+)
+
+// ErrMissingConfig is wrapped by errors about required settings that are not set
+var ErrMissingConfig = errors.New("missing configuration")
+
+// ErrContentFiltered is wrapped by errors about completions whose output the Azure
+// OpenAI content filter withheld
+var ErrContentFiltered = errors.New("completion withheld by the content filter")
+
+// ErrInvalidToolCall is wrapped by errors about tool calls the model returned in a shape
+// that can't be read, such as arguments that aren't a JSON object
+var ErrInvalidToolCall = errors.New("invalid tool call")
+
+// contentFilter is both the error code of a request whose prompt the content filter
+// rejected and the finish reason of a completion it withheld
+const contentFilter = "content_filter"
+
+// StatusCode returns the HTTP status code of an Azure OpenAI API error, or 0 if err is not an API error
+func StatusCode(err error) int {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsModelUnavailable reports whether err means the model deployment can't serve requests:
+// the deployment is missing (404), quota or rate limits are exhausted (429), or the service is failing (5xx)
+func IsModelUnavailable(err error) bool {
+	switch code := StatusCode(err); {
+	case code == http.StatusNotFound, code == http.StatusTooManyRequests:
+		return true
+	case code >= http.StatusInternalServerError:
+		return true
+	}
+	return false
+}
+
+// IsContentFiltered reports whether err means the Azure OpenAI content filter rejected
+// the prompt (a 400 with code content_filter) or withheld the completion
+func IsContentFiltered(err error) bool {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) && apiErr.Code == contentFilter {
+		return true
+	}
+	return errors.Is(err, ErrContentFiltered)
+}
+
+// IsAuthError reports whether err means the credentials were rejected (401/403) or
+// an Azure Identity token could not be obtained
+func IsAuthError(err error) bool {
+	if code := StatusCode(err); code == http.StatusUnauthorized || code == http.StatusForbidden {
+		return true
+	}
+
+	var azErr *azidentity.AuthenticationFailedError
+	return errors.As(err, &azErr)
+}
+
+// IsTransient reports whether err is likely to succeed on retry: a network failure,
+// throttling (429), or a service error (5xx)
+func IsTransient(err error) bool {
+	switch code := StatusCode(err); {
+	case code == http.StatusTooManyRequests, code >= http.StatusInternalServerError:
+		return true
+	}
+
+	// Match concrete network errors: syscall.Errno also satisfies net.Error, so
+	// checking the interface would misclassify file system errors
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	return errors.As(err, &opErr) || errors.As(err, &dnsErr)
+}
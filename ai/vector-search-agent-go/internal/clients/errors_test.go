@@ -0,0 +1,100 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/openai/openai-go/v3"
+)
+
+func TestIsModelUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not found", &openai.Error{StatusCode: http.StatusNotFound}, true},
+		{"throttled", &openai.Error{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &openai.Error{StatusCode: http.StatusBadGateway}, true},
+		{"wrapped", fmt.Errorf("planner failed: %w", &openai.Error{StatusCode: http.StatusTooManyRequests}), true},
+		{"bad request", &openai.Error{StatusCode: http.StatusBadRequest}, false},
+		{"unauthorized", &openai.Error{StatusCode: http.StatusUnauthorized}, false},
+		{"not an API error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsModelUnavailable(tt.err); got != tt.want {
+			t.Errorf("%s: IsModelUnavailable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	if got := StatusCode(fmt.Errorf("wrapped: %w", &openai.Error{StatusCode: 404})); got != 404 {
+		t.Errorf("StatusCode = %d, want 404", got)
+	}
+	if got := StatusCode(errors.New("plain")); got != 0 {
+		t.Errorf("StatusCode(plain) = %d, want 0", got)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unauthorized", &openai.Error{StatusCode: http.StatusUnauthorized}, true},
+		{"forbidden", fmt.Errorf("embedding failed: %w", &openai.Error{StatusCode: http.StatusForbidden}), true},
+		{"token", fmt.Errorf("credential: %w", &azidentity.AuthenticationFailedError{}), true},
+		{"throttled", &openai.Error{StatusCode: http.StatusTooManyRequests}, false},
+		{"not an API error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsAuthError(tt.err); got != tt.want {
+			t.Errorf("%s: IsAuthError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"throttled", &openai.Error{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", fmt.Errorf("chat failed: %w", &openai.Error{StatusCode: http.StatusServiceUnavailable}), true},
+		{"connection refused", fmt.Errorf("post: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"dns", &net.DNSError{Err: "no such host", Name: "example.openai.azure.com"}, true},
+		{"not found", &openai.Error{StatusCode: http.StatusNotFound}, false},
+		{"bad request", &openai.Error{StatusCode: http.StatusBadRequest}, false},
+		{"not an API error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransient = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsContentFiltered(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"filtered prompt", &openai.Error{StatusCode: http.StatusBadRequest, Code: "content_filter"}, true},
+		{"withheld completion", fmt.Errorf("synthesis failed: %w", ErrContentFiltered), true},
+		{"other bad request", &openai.Error{StatusCode: http.StatusBadRequest, Code: "invalid_request"}, false},
+		{"not an API error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsContentFiltered(tt.err); got != tt.want {
+			t.Errorf("%s: IsContentFiltered = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
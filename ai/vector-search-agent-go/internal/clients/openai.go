@@ -4,140 +4,274 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/azure"
 	"github.com/openai/openai-go/v3/option"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
-// OpenAIConfig holds configuration for Azure OpenAI clients
-type OpenAIConfig struct {
-	Endpoint string
-	APIKey   string
-
-	EmbeddingDeployment string
-	EmbeddingAPIVersion string
-
-	PlannerDeployment string
-	PlannerAPIVersion string
-
-	SynthDeployment string
-	SynthAPIVersion string
+// DefaultAPIVersion is the Azure OpenAI API version used when
+// AZURE_OPENAI_EMBEDDING_API_VERSION is unset
+const DefaultAPIVersion = "2024-06-01"
 
-	UsePasswordless bool
-	Debug           bool
+// OpenAIConfig holds configuration for Azure OpenAI clients. The tags describe the
+// environment variable of each field; see config.Variables.
+type OpenAIConfig struct {
+	Endpoint string `env:"AZURE_OPENAI_ENDPOINT" required:"embedding,chat" format:"https-url" example:"https://my-openai.openai.azure.com/" desc:"Azure OpenAI endpoint"`
+	APIKey   string `env:"AZURE_OPENAI_API_KEY" desc:"Azure OpenAI API key; leave unset to authenticate with Azure Identity"`
+	// BaseURL, when set, overrides the scheme and host requests are sent to while
+	// keeping the Azure OpenAI request paths, for example to reach a proxy or a fake of
+	// the service in tests
+	BaseURL string `env:"-"`
+
+	EmbeddingDeployment string `env:"AZURE_OPENAI_EMBEDDING_DEPLOYMENT" required:"embedding" example:"text-embedding-3-small" desc:"Deployment that embeds queries and hotel descriptions"`
+	EmbeddingAPIVersion string `env:"AZURE_OPENAI_EMBEDDING_API_VERSION" default:"2024-06-01" desc:"API version of every Azure OpenAI request"`
+	// EmbeddingDimensions, when set, asks models that support it, such as
+	// text-embedding-3-large, for vectors of this length; 0 uses the model's default
+	EmbeddingDimensions int `env:"AZURE_OPENAI_EMBEDDING_DIMENSIONS" for:"embedding" example:"1536" desc:"Length of the vectors to ask the embedding model for; unset uses the model's own"`
+
+	PlannerDeployment string `env:"AZURE_OPENAI_PLANNER_DEPLOYMENT" required:"chat" example:"gpt-4o-mini" desc:"Deployment of the planner, which turns the request into a search"`
+	PlannerAPIVersion string `env:"AZURE_OPENAI_PLANNER_API_VERSION" desc:"Accepted but unused; every request uses AZURE_OPENAI_EMBEDDING_API_VERSION"`
+
+	SynthDeployment string `env:"AZURE_OPENAI_SYNTH_DEPLOYMENT" required:"chat" example:"gpt-4o" desc:"Deployment of the synthesizer, which writes the answer"`
+	SynthAPIVersion string `env:"AZURE_OPENAI_SYNTH_API_VERSION" desc:"Accepted but unused; every request uses AZURE_OPENAI_EMBEDDING_API_VERSION"`
+
+	UsePasswordless bool `env:"USE_PASSWORDLESS" for:"embedding,chat,documentdb" format:"bool" example:"true" desc:"Authenticate to Azure OpenAI and DocumentDB with Azure Identity instead of keys; unset picks it from the keys given"`
+
+	// MaxRequestsPerSecond caps the API requests started per second across all
+	// deployments; 0 means no limit
+	MaxRequestsPerSecond float64 `env:"AZURE_OPENAI_MAX_RPS" for:"embedding,chat" format:"non-negative-number" default:"0" example:"10" desc:"Cap on the Azure OpenAI requests started per second; 0 means no limit"`
+
+	// Timeouts bounds each embeddings request and chat completion; config.Load sets it
+	// from the config's Timeouts section, and zero leaves them unbounded
+	Timeouts timeouts.Config `env:"-"`
 }
 
 // OpenAIClients holds all Azure OpenAI clients
 type OpenAIClients struct {
-	config *OpenAIConfig
-	client *openai.Client
+	config  *OpenAIConfig
+	client  *openai.Client
+	usage   *UsageTracker
+	limiter *RateLimiter
 }
 
 // LoadConfigFromEnv loads OpenAI configuration from environment variables
 func LoadConfigFromEnv() *OpenAIConfig {
-	debug := os.Getenv("DEBUG") == "true" || os.Getenv("DEBUG") == "1"
-	usePasswordless := os.Getenv("USE_PASSWORDLESS") == "true" || os.Getenv("USE_PASSWORDLESS") == "1"
+	usePasswordless, _ := strconv.ParseBool(os.Getenv("USE_PASSWORDLESS"))
+	maxRPS, _ := strconv.ParseFloat(os.Getenv("AZURE_OPENAI_MAX_RPS"), 64)
+	embeddingDimensions, _ := strconv.Atoi(os.Getenv("AZURE_OPENAI_EMBEDDING_DIMENSIONS"))
+
+	embeddingAPIVersion := os.Getenv("AZURE_OPENAI_EMBEDDING_API_VERSION")
+	if embeddingAPIVersion == "" {
+		embeddingAPIVersion = DefaultAPIVersion
+	}
 
 	return &OpenAIConfig{
-		Endpoint:            os.Getenv("AZURE_OPENAI_ENDPOINT"),
-		APIKey:              os.Getenv("AZURE_OPENAI_API_KEY"),
-		EmbeddingDeployment: os.Getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT"),
-		EmbeddingAPIVersion: os.Getenv("AZURE_OPENAI_EMBEDDING_API_VERSION"),
-		PlannerDeployment:   os.Getenv("AZURE_OPENAI_PLANNER_DEPLOYMENT"),
-		PlannerAPIVersion:   os.Getenv("AZURE_OPENAI_PLANNER_API_VERSION"),
-		SynthDeployment:     os.Getenv("AZURE_OPENAI_SYNTH_DEPLOYMENT"),
-		SynthAPIVersion:     os.Getenv("AZURE_OPENAI_SYNTH_API_VERSION"),
-		UsePasswordless:     usePasswordless,
-		Debug:               debug,
+		Endpoint:             os.Getenv("AZURE_OPENAI_ENDPOINT"),
+		APIKey:               os.Getenv("AZURE_OPENAI_API_KEY"),
+		EmbeddingDeployment:  os.Getenv("AZURE_OPENAI_EMBEDDING_DEPLOYMENT"),
+		EmbeddingAPIVersion:  embeddingAPIVersion,
+		EmbeddingDimensions:  embeddingDimensions,
+		PlannerDeployment:    os.Getenv("AZURE_OPENAI_PLANNER_DEPLOYMENT"),
+		PlannerAPIVersion:    os.Getenv("AZURE_OPENAI_PLANNER_API_VERSION"),
+		SynthDeployment:      os.Getenv("AZURE_OPENAI_SYNTH_DEPLOYMENT"),
+		SynthAPIVersion:      os.Getenv("AZURE_OPENAI_SYNTH_API_VERSION"),
+		UsePasswordless:      usePasswordless,
+		MaxRequestsPerSecond: maxRPS,
 	}
 }
 
 // NewOpenAIClients creates all Azure OpenAI clients with passwordless authentication support
 func NewOpenAIClients(config *OpenAIConfig) (*OpenAIClients, error) {
 	if config.Endpoint == "" {
-		return nil, fmt.Errorf("AZURE_OPENAI_ENDPOINT is required")
+		return nil, fmt.Errorf("%w: AZURE_OPENAI_ENDPOINT is required", ErrMissingConfig)
 	}
 
 	// Use the default API version if not specified
 	apiVersion := config.EmbeddingAPIVersion
 	if apiVersion == "" {
-		apiVersion = "2024-06-01"
+		apiVersion = DefaultAPIVersion
 	}
 
-	var client openai.Client
+	opts := []option.RequestOption{azure.WithEndpoint(config.Endpoint, apiVersion)}
 
 	// Determine authentication method based on USE_PASSWORDLESS flag or auto-detection
 	usePasswordless := config.UsePasswordless || config.APIKey == ""
 
 	if usePasswordless {
 		// Use passwordless authentication with Azure Identity
-		if config.Debug {
-			fmt.Println("[clients] Using passwordless (Azure Identity) authentication")
-		}
+		slog.Debug("using passwordless (Azure Identity) authentication for Azure OpenAI")
 		credential, err := azidentity.NewDefaultAzureCredential(nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Azure credential: %w", err)
 		}
 
-		client = openai.NewClient(
-			azure.WithEndpoint(config.Endpoint, apiVersion),
-			azure.WithTokenCredential(credential),
-		)
+		opts = append(opts, azure.WithTokenCredential(credential))
 	} else {
 		// Use API key authentication
-		if config.Debug {
-			fmt.Println("[clients] Using API key authentication")
-		}
+		slog.Debug("using API key authentication for Azure OpenAI")
 		if config.APIKey == "" {
-			return nil, fmt.Errorf("AZURE_OPENAI_API_KEY is required when USE_PASSWORDLESS is not enabled")
+			return nil, fmt.Errorf("%w: AZURE_OPENAI_API_KEY is required when USE_PASSWORDLESS is not enabled", ErrMissingConfig)
 		}
-		client = openai.NewClient(
-			azure.WithEndpoint(config.Endpoint, apiVersion),
-			option.WithAPIKey(config.APIKey),
-		)
+		opts = append(opts, option.WithAPIKey(config.APIKey))
 	}
-
-	if config.Debug {
-		fmt.Printf("[clients] OpenAI client created for endpoint: %s\n", config.Endpoint)
-		fmt.Printf("[clients] Embedding deployment: %s\n", config.EmbeddingDeployment)
-		fmt.Printf("[clients] Planner deployment: %s\n", config.PlannerDeployment)
-		fmt.Printf("[clients] Synthesizer deployment: %s\n", config.SynthDeployment)
+	if config.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(config.BaseURL))
 	}
+	client := openai.NewClient(opts...)
+
+	slog.Debug("OpenAI client created",
+		"endpoint", config.Endpoint,
+		"baseURL", config.BaseURL,
+		"embeddingDeployment", config.EmbeddingDeployment,
+		"plannerDeployment", config.PlannerDeployment,
+		"synthDeployment", config.SynthDeployment,
+		"maxRequestsPerSecond", config.MaxRequestsPerSecond,
+	)
 
 	return &OpenAIClients{
-		config: config,
-		client: &client,
+		config:  config,
+		client:  &client,
+		usage:   NewUsageTracker(),
+		limiter: NewRateLimiter(config.MaxRequestsPerSecond),
 	}, nil
 }
 
+// Usage returns the tracker accumulating token usage across all calls made by these clients
+func (c *OpenAIClients) Usage() *UsageTracker {
+	return c.usage
+}
+
+// startSpan starts the span of one operation against deployment, such as
+// semconv.GenAIOperationNameChat. It is named after the operation and the deployment.
+func startSpan(ctx context.Context, operation attribute.KeyValue, deployment string) (context.Context, oteltrace.Span) {
+	return telemetry.StartClient(ctx, operation.Value.AsString()+" "+deployment,
+		operation,
+		semconv.GenAIProviderNameAzureAIOpenAI,
+		semconv.GenAIRequestModel(deployment),
+		telemetry.DeploymentKey.String(deployment),
+	)
+}
+
+// record adds the usage of one API call to the client-wide tracker, to the tracker
+// carried by ctx, if any, to the call's span, and to the token metrics
+func (c *OpenAIClients) record(ctx context.Context, deployment string, promptTokens, completionTokens int64) {
+	metrics.Default().Tokens(deployment, promptTokens, completionTokens)
+	oteltrace.SpanFromContext(ctx).SetAttributes(
+		semconv.GenAIUsageInputTokens(int(promptTokens)),
+		semconv.GenAIUsageOutputTokens(int(completionTokens)),
+	)
+	c.usage.Record(deployment, promptTokens, completionTokens)
+	if t, ok := UsageTrackerFromContext(ctx); ok && t != c.usage {
+		t.Record(deployment, promptTokens, completionTokens)
+	}
+}
+
+// requestStatus returns the metrics status of an API call that ended with err: the HTTP
+// status code of an API error, or the metrics.Status of any other outcome
+func requestStatus(err error) string {
+	if code := StatusCode(err); code != 0 {
+		return strconv.Itoa(code)
+	}
+	return metrics.Status(err)
+}
+
+// budget bounds a call to the API by the budget of stage. Call end with the call's error
+// on return: a deadline that passes is attributed to the budget that ran out.
+func (c *OpenAIClients) budget(ctx context.Context, stage string) (_ context.Context, end func(*error)) {
+	ctx, cancel := timeouts.WithBudget(ctx, c.config.Timeouts.Budget(stage))
+	return ctx, func(err *error) {
+		*err = timeouts.Explain(ctx, *err)
+		cancel()
+	}
+}
+
 // GenerateEmbedding generates an embedding for the given text
 func (c *OpenAIClients) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
-	resp, err := c.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
-		Input: openai.EmbeddingNewParamsInputUnion{
-			OfString: openai.String(text),
-		},
+	embeddings, err := c.embed(ctx, openai.EmbeddingNewParamsInputUnion{OfString: openai.String(text)}, 1)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings generates the embeddings of texts in one request. They are returned
+// in the order of texts.
+func (c *OpenAIClients) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	return c.embed(ctx, openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts}, len(texts))
+}
+
+// embed sends one embeddings request for count inputs and returns their embeddings,
+// ordered by the index the service gives each one
+func (c *OpenAIClients) embed(ctx context.Context, input openai.EmbeddingNewParamsInputUnion, count int) (_ [][]float32, err error) {
+	done := trace.Start(ctx, trace.EventEmbedding)
+	defer func() { done(err) }()
+	ctx, span := startSpan(ctx, semconv.GenAIOperationNameEmbeddings, c.config.EmbeddingDeployment)
+	defer func() { telemetry.End(span, err) }()
+	defer func() { metrics.Default().EmbeddingRequest(requestStatus(err)) }()
+	ctx, end := c.budget(ctx, timeouts.StageEmbedding)
+	defer end(&err)
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	params := openai.EmbeddingNewParams{
+		Input: input,
 		Model: openai.EmbeddingModel(c.config.EmbeddingDeployment),
-	})
+	}
+	if c.config.EmbeddingDimensions > 0 {
+		params.Dimensions = openai.Int(int64(c.config.EmbeddingDimensions))
+	}
+
+	resp, err := c.client.Embeddings.New(ctx, params)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
+	span.SetAttributes(semconv.GenAIResponseModel(resp.Model))
+
+	c.record(ctx, c.config.EmbeddingDeployment, resp.Usage.PromptTokens, 0)
 
 	if len(resp.Data) == 0 {
 		return nil, fmt.Errorf("no embeddings returned")
 	}
+	if len(resp.Data) != count {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(resp.Data), count)
+	}
 
-	// Convert []float64 to []float32
-	float64Embedding := resp.Data[0].Embedding
-	float32Embedding := make([]float32, len(float64Embedding))
-	for i, v := range float64Embedding {
-		float32Embedding[i] = float32(v)
+	embeddings := make([][]float32, count)
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= int64(count) || embeddings[data.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d for %d inputs", data.Index, count)
+		}
+		// Convert []float64 to []float32
+		embedding := make([]float32, len(data.Embedding))
+		for i, v := range data.Embedding {
+			embedding[i] = float32(v)
+		}
+		embeddings[data.Index] = embedding
 	}
 
-	return float32Embedding, nil
+	return embeddings, nil
 }
 
 // ChatMessage represents a chat message
@@ -158,16 +292,25 @@ type ToolCall struct {
 }
 
 // ChatCompletionWithTools calls the planner with tool definitions
-func (c *OpenAIClients) ChatCompletionWithTools(ctx context.Context, systemPrompt, userMessage string, tools []openai.ChatCompletionToolUnionParam) (*openai.ChatCompletion, error) {
+func (c *OpenAIClients) ChatCompletionWithTools(ctx context.Context, systemPrompt, userMessage string, tools []openai.ChatCompletionToolUnionParam) (_ *openai.ChatCompletion, err error) {
+	done := trace.Start(ctx, trace.EventPlannerCompletion)
+	defer func() { done(err) }()
+	ctx, span := startSpan(ctx, semconv.GenAIOperationNameChat, c.config.PlannerDeployment)
+	defer func() { telemetry.End(span, err) }()
+	ctx, end := c.budget(ctx, timeouts.StageChat)
+	defer end(&err)
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
 	if len(tools) == 0 {
 		return nil, fmt.Errorf("no tools provided to ChatCompletionWithTools")
 	}
 
-	if c.config.Debug {
-		fmt.Printf("[planner] Calling with temperature=0.0, tools enabled\n")
-		fmt.Printf("[planner] System prompt length: %d characters\n", len(systemPrompt))
-		fmt.Printf("[planner] User message length: %d characters\n", len(userMessage))
-	}
+	slog.DebugContext(ctx, "planner request", "deployment", c.config.PlannerDeployment, "temperature", 0.0, "tools", len(tools),
+		"systemPromptChars", len(systemPrompt), "userMessageChars", len(userMessage))
+	logging.Trace(ctx, "planner prompt", "system", systemPrompt, "user", userMessage)
 
 	resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Model: openai.ChatModel(c.config.PlannerDeployment),
@@ -200,15 +343,15 @@ func (c *OpenAIClients) ChatCompletionWithTools(ctx context.Context, systemPromp
 	if resp == nil {
 		return nil, fmt.Errorf("planner returned nil response")
 	}
+	span.SetAttributes(semconv.GenAIResponseModel(resp.Model))
 
-	if c.config.Debug {
-		fmt.Printf("[planner] Response received with %d choices\n", len(resp.Choices))
-		if len(resp.Choices) > 0 {
-			fmt.Printf("[planner] Finish reason: %s\n", resp.Choices[0].FinishReason)
-			fmt.Printf("[planner] Tool calls count: %d\n", len(resp.Choices[0].Message.ToolCalls))
-			if len(resp.Choices[0].Message.ToolCalls) == 0 && resp.Choices[0].Message.Content != "" {
-				fmt.Printf("[planner] Model response (text): %s\n", resp.Choices[0].Message.Content)
-			}
+	c.record(ctx, c.config.PlannerDeployment, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	if len(resp.Choices) > 0 {
+		message := resp.Choices[0].Message
+		slog.DebugContext(ctx, "planner response", "choices", len(resp.Choices), "finishReason", resp.Choices[0].FinishReason, "toolCalls", len(message.ToolCalls))
+		if len(message.ToolCalls) == 0 && message.Content != "" {
+			logging.Trace(ctx, "planner text response", "content", message.Content)
 		}
 	}
 
@@ -216,8 +359,131 @@ func (c *OpenAIClients) ChatCompletionWithTools(ctx context.Context, systemPromp
 }
 
 // ChatCompletion calls the synthesizer without tools
-func (c *OpenAIClients) ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+func (c *OpenAIClients) ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (_ string, err error) {
+	done := trace.Start(ctx, trace.EventSynthesizerCompletion)
+	defer func() { done(err) }()
+	ctx, span := startSpan(ctx, semconv.GenAIOperationNameChat, c.config.SynthDeployment)
+	defer func() { telemetry.End(span, err) }()
+	ctx, end := c.budget(ctx, timeouts.StageChat)
+	defer end(&err)
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+
+	logging.Trace(ctx, "synthesizer prompt", "system", systemPrompt, "user", userMessage)
+
+	resp, err := c.client.Chat.Completions.New(ctx, c.synthesizerParams(systemPrompt, userMessage))
+
+	if err != nil {
+		return "", fmt.Errorf("synthesizer chat completion failed: %w", err)
+	}
+	span.SetAttributes(semconv.GenAIResponseModel(resp.Model))
+
+	c.record(ctx, c.config.SynthDeployment, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no completion choices returned")
+	}
+	if resp.Choices[0].FinishReason == contentFilter {
+		return "", fmt.Errorf("synthesizer chat completion failed: %w", ErrContentFiltered)
+	}
+
+	content := resp.Choices[0].Message.Content
+
+	slog.DebugContext(ctx, "synthesizer response", "deployment", c.config.SynthDeployment, "chars", len(content))
+	logging.Trace(ctx, "synthesizer answer", "content", content)
+
+	return content, nil
+}
+
+// ChatCompletionStream calls the synthesizer without tools and writes each content
+// delta to w as it arrives. It returns the complete answer.
+func (c *OpenAIClients) ChatCompletionStream(ctx context.Context, systemPrompt, userMessage string, w io.Writer) (_ string, err error) {
+	done := trace.Start(ctx, trace.EventSynthesizerCompletion)
+	defer func() { done(err) }()
+	ctx, span := startSpan(ctx, semconv.GenAIOperationNameChat, c.config.SynthDeployment)
+	defer func() { telemetry.End(span, err) }()
+	ctx, end := c.budget(ctx, timeouts.StageChat)
+	defer end(&err)
+
+	if err := c.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+
+	logging.Trace(ctx, "synthesizer prompt", "system", systemPrompt, "user", userMessage)
+
+	params := c.synthesizerParams(systemPrompt, userMessage)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+
+	stream := c.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	acc := openai.ChatCompletionAccumulator{}
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			if _, err := io.WriteString(w, chunk.Choices[0].Delta.Content); err != nil {
+				return "", fmt.Errorf("failed to write streamed answer: %w", err)
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return "", fmt.Errorf("synthesizer chat completion stream failed: %w", err)
+	}
+	span.SetAttributes(semconv.GenAIResponseModel(acc.Model))
+
+	c.record(ctx, c.config.SynthDeployment, acc.Usage.PromptTokens, acc.Usage.CompletionTokens)
+
+	if len(acc.Choices) == 0 {
+		return "", fmt.Errorf("no completion choices returned")
+	}
+	if acc.Choices[0].FinishReason == contentFilter {
+		return "", fmt.Errorf("synthesizer chat completion stream failed: %w", ErrContentFiltered)
+	}
+
+	content := acc.Choices[0].Message.Content
+
+	slog.DebugContext(ctx, "synthesizer response streamed", "deployment", c.config.SynthDeployment, "chars", len(content))
+	logging.Trace(ctx, "synthesizer answer", "content", content)
+
+	return content, nil
+}
+
+// PingChatDeployment sends a minimal one-token completion to verify a chat deployment exists and is usable
+func (c *OpenAIClients) PingChatDeployment(ctx context.Context, deployment string) (err error) {
+	ctx, end := c.budget(ctx, timeouts.StageChat)
+	defer end(&err)
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
 	resp, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model: openai.ChatModel(deployment),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{
+				OfUser: &openai.ChatCompletionUserMessageParam{
+					Content: openai.ChatCompletionUserMessageParamContentUnion{
+						OfString: openai.String("ping"),
+					},
+				},
+			},
+		},
+		MaxTokens: openai.Int(1),
+	})
+	if err != nil {
+		return fmt.Errorf("deployment %s failed: %w", deployment, err)
+	}
+
+	c.record(ctx, deployment, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	return nil
+}
+
+// synthesizerParams builds the synthesizer request for a system prompt and user message
+func (c *OpenAIClients) synthesizerParams(systemPrompt, userMessage string) openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
 		Model: openai.ChatModel(c.config.SynthDeployment),
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			{
@@ -236,23 +502,7 @@ func (c *OpenAIClients) ChatCompletion(ctx context.Context, systemPrompt, userMe
 			},
 		},
 		Temperature: openai.Float(0.3),
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("synthesizer chat completion failed: %w", err)
 	}
-
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no completion choices returned")
-	}
-
-	content := resp.Choices[0].Message.Content
-
-	if c.config.Debug {
-		fmt.Printf("[synthesizer] Output: %d characters\n", len(content))
-	}
-
-	return content, nil
 }
 
 // extractToolCallRaw extracts the tool call from a chat completion response and returns raw JSON arguments
@@ -280,7 +530,7 @@ func extractToolCallRaw(resp *openai.ChatCompletion) (string, string, error) {
 		// Model decided not to call a tool - return the text content if available
 		content := choice.Message.Content
 		if content != "" {
-			return "", "", fmt.Errorf("no tool calls in response - model returned: %s", content)
+			return "", "", fmt.Errorf("no tool calls in response - model returned: %s", Fragment(content))
 		}
 		return "", "", fmt.Errorf("no tool calls in response and no text content - finish_reason: %s", choice.FinishReason)
 	}
@@ -288,7 +538,7 @@ func extractToolCallRaw(resp *openai.ChatCompletion) (string, string, error) {
 	toolCall := choice.Message.ToolCalls[0]
 
 	if toolCall.Type != "function" {
-		return "", "", fmt.Errorf("unexpected tool call type: %s (expected 'function')", toolCall.Type)
+		return "", "", fmt.Errorf("%w: unexpected tool call type: %s (expected 'function')", ErrInvalidToolCall, Fragment(toolCall.Type))
 	}
 
 	return toolCall.Function.Name, toolCall.Function.Arguments, nil
@@ -301,10 +551,76 @@ func ExtractToolCall(resp *openai.ChatCompletion) (string, map[string]any, error
 		return "", nil, err
 	}
 
-	var args map[string]any
-	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-		return "", nil, fmt.Errorf("failed to parse tool arguments: %w (raw arguments: %s)", err, argsJSON)
+	args, err := parseToolArguments(argsJSON)
+	if err != nil {
+		return "", nil, err
 	}
 
 	return toolName, args, nil
 }
+
+// parseToolArguments decodes the JSON object of a tool call's arguments. Empty
+// arguments and null read as no arguments, since models send both for calls without
+// any. Anything but an object is an error wrapping ErrInvalidToolCall that quotes the
+// start of the arguments.
+func parseToolArguments(raw string) (map[string]any, error) {
+	if strings.TrimSpace(raw) == "" {
+		return map[string]any{}, nil
+	}
+
+	var args map[string]any
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return nil, fmt.Errorf("%w: arguments are not a JSON object: %v (raw arguments: %s)", ErrInvalidToolCall, err, Fragment(raw))
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return args, nil
+}
+
+// maxFragmentLength bounds the model output quoted in an error message
+const maxFragmentLength = 200
+
+// Fragment shortens model output quoted in an error message to maxFragmentLength
+// bytes, marking the cut with "..." and never splitting a character
+func Fragment(s string) string {
+	if len(s) <= maxFragmentLength {
+		return s
+	}
+	cut := maxFragmentLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
+
+// ExtractedToolCall is a tool call with its parsed arguments
+type ExtractedToolCall struct {
+	Name string
+	Args map[string]any
+}
+
+// ExtractToolCalls extracts every tool call from a chat completion response, in the order the model returned them
+func ExtractToolCalls(resp *openai.ChatCompletion) ([]ExtractedToolCall, error) {
+	// Validate the response shape and the first call
+	if _, _, err := extractToolCallRaw(resp); err != nil {
+		return nil, err
+	}
+
+	toolCalls := resp.Choices[0].Message.ToolCalls
+	calls := make([]ExtractedToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		if toolCall.Type != "function" {
+			return nil, fmt.Errorf("%w: unexpected tool call type: %s (expected 'function')", ErrInvalidToolCall, Fragment(toolCall.Type))
+		}
+
+		args, err := parseToolArguments(toolCall.Function.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		calls = append(calls, ExtractedToolCall{Name: toolCall.Function.Name, Args: args})
+	}
+
+	return calls, nil
+}
@@ -0,0 +1,589 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients/clientstest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics/metricstest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry/telemetrytest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/openai/openai-go/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// completion decodes a chat completion from its JSON form
+func completion(t *testing.T, raw string) *openai.ChatCompletion {
+	t.Helper()
+	var c openai.ChatCompletion
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatal(err)
+	}
+	return &c
+}
+
+func TestExtractToolCallsKeepsOrder(t *testing.T) {
+	resp := completion(t, `{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+		{"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"query\":\"pool\"}"}},
+		{"id":"call_2","type":"function","function":{"name":"search","arguments":"{\"query\":\"parking\"}"}}]}}]}`)
+
+	calls, err := ExtractToolCalls(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0].Args["query"] != "pool" || calls[1].Args["query"] != "parking" {
+		t.Errorf("calls = %+v, want pool then parking", calls)
+	}
+}
+
+func TestExtractToolCallsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"no choices", `{"choices":[]}`},
+		{"cut off", `{"choices":[{"index":0,"finish_reason":"length","message":{"role":"assistant"}}]}`},
+		{"text only", `{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"no tool"}}]}`},
+		{"bad arguments", `{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"query\":\"pool\"}"}},
+			{"id":"call_2","type":"function","function":{"name":"search","arguments":"not json"}}]}}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ExtractToolCalls(completion(t, tt.raw)); err == nil {
+				t.Error("ExtractToolCalls succeeded, want an error")
+			}
+		})
+	}
+}
+
+// toolCallCompletion is a completion calling the search tool with raw arguments
+func toolCallCompletion(t testing.TB, arguments string) *openai.ChatCompletion {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"choices": []any{map[string]any{
+		"index":         0,
+		"finish_reason": "tool_calls",
+		"message": map[string]any{"role": "assistant", "tool_calls": []any{map[string]any{
+			"id":       "call_1",
+			"type":     "function",
+			"function": map[string]any{"name": "search", "arguments": arguments},
+		}}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c openai.ChatCompletion
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	return &c
+}
+
+func TestExtractToolCallArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      map[string]any
+	}{
+		{"object", `{"query":"pool","nearestNeighbors":3}`, map[string]any{"query": "pool", "nearestNeighbors": float64(3)}},
+		{"empty", "", map[string]any{}},
+		{"blank", " \n", map[string]any{}},
+		{"null", "null", map[string]any{}},
+		{"extra fields", `{"query":"pool","reasoning":{"steps":[1,2]}}`, map[string]any{"query": "pool", "reasoning": map[string]any{"steps": []any{float64(1), float64(2)}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, args, err := ExtractToolCall(toolCallCompletion(t, tt.arguments))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.want) {
+				t.Errorf("arguments = %v, want %v", args, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractToolCallArgumentErrors(t *testing.T) {
+	long := `["` + strings.Repeat("é", 300) + `"]`
+	tests := []struct {
+		name      string
+		arguments string
+		quoted    string
+	}{
+		{"not json", "not json", "(raw arguments: not json)"},
+		{"array", `["pool"]`, `(raw arguments: ["pool"])`},
+		{"string", `"pool"`, `(raw arguments: "pool")`},
+		{"truncated", `{"query":"po`, `(raw arguments: {"query":"po)`},
+		{"long", long, `(raw arguments: ["ééé`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ExtractToolCall(toolCallCompletion(t, tt.arguments))
+			if !errors.Is(err, ErrInvalidToolCall) {
+				t.Fatalf("err = %v, want ErrInvalidToolCall", err)
+			}
+			if !strings.Contains(err.Error(), tt.quoted) {
+				t.Errorf("err = %v, want it to quote %s", err, tt.quoted)
+			}
+			if len(err.Error()) > maxFragmentLength+200 {
+				t.Errorf("err is %d bytes, want the arguments shortened", len(err.Error()))
+			}
+		})
+	}
+}
+
+func TestFragment(t *testing.T) {
+	if got := Fragment("short"); got != "short" {
+		t.Errorf("Fragment(short) = %q", got)
+	}
+	got := Fragment(strings.Repeat("a", maxFragmentLength-1) + "éé")
+	if got != strings.Repeat("a", maxFragmentLength-1)+"..." || !utf8.ValidString(got) {
+		t.Errorf("Fragment cut a character: %q", got)
+	}
+}
+
+// FuzzExtractToolCalls feeds arbitrary tool call arguments and arbitrary completions to
+// the tool call parsers, which must return calls or an error but never panic
+func FuzzExtractToolCalls(f *testing.F) {
+	f.Add(`{"query":"pool","nearestNeighbors":3}`, `{"choices":[]}`)
+	f.Add("", `{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[{"type":"function","function":{"name":"search","arguments":"{}"}}]}}]}`)
+	f.Add("null", `{"choices":[{"finish_reason":"stop","message":{"content":"no tool"}}]}`)
+	f.Add(`[1,2]`, `{"choices":[{"finish_reason":"tool_calls","message":{"tool_calls":[{"type":"custom"}]}}]}`)
+	f.Add(`{"query":null}`, `null`)
+
+	f.Fuzz(func(t *testing.T, arguments, raw string) {
+		calls, err := ExtractToolCalls(toolCallCompletion(t, arguments))
+		if err == nil && (len(calls) != 1 || calls[0].Args == nil) {
+			t.Errorf("arguments %q gave calls %+v, want one call with arguments", arguments, calls)
+		}
+		if _, args, err := ExtractToolCall(toolCallCompletion(t, arguments)); err == nil && args == nil {
+			t.Errorf("arguments %q gave nil arguments", arguments)
+		}
+
+		var resp openai.ChatCompletion
+		if json.Unmarshal([]byte(raw), &resp) != nil {
+			return
+		}
+		calls, err = ExtractToolCalls(&resp)
+		for _, call := range calls {
+			if err == nil && call.Args == nil {
+				t.Errorf("completion %q gave nil arguments", raw)
+			}
+		}
+		ExtractToolCall(&resp)
+	})
+}
+
+// newFakeClients returns clients for the embed-deployment, plan-deployment, and
+// synth-deployment deployments of a fake Azure OpenAI resource, reached through the
+// base URL override
+func newFakeClients(t *testing.T) (*OpenAIClients, *clientstest.Server) {
+	t.Helper()
+	srv := clientstest.NewServer(t)
+	c, err := NewOpenAIClients(&OpenAIConfig{
+		Endpoint:            "https://fake.openai.azure.com",
+		BaseURL:             srv.URL,
+		APIKey:              "test-key",
+		EmbeddingDeployment: "embed-deployment",
+		PlannerDeployment:   "plan-deployment",
+		SynthDeployment:     "synth-deployment",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, srv
+}
+
+func TestOpenAIClientsRecordSpans(t *testing.T) {
+	exporter := telemetrytest.Record(t)
+	c, _ := newFakeClients(t)
+
+	ctx, parent := telemetry.Start(context.Background(), "agent.run")
+	if _, err := c.GenerateEmbedding(ctx, "quiet hotel"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ChatCompletion(ctx, "system", "user"); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	tests := []struct {
+		span       string
+		deployment string
+		input      int64
+		output     int64
+	}{
+		{"embeddings embed-deployment", "embed-deployment", 7, 0},
+		{"chat synth-deployment", "synth-deployment", 40, 5},
+	}
+	spans := exporter.GetSpans()
+	for _, tt := range tests {
+		span := telemetrytest.Span(t, spans, tt.span)
+		if span.SpanKind != oteltrace.SpanKindClient || span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s: kind %v, parent %v; want a client span under the caller's span", tt.span, span.SpanKind, span.Parent.SpanID())
+		}
+		want := map[attribute.Key]attribute.Value{
+			telemetry.DeploymentKey:           attribute.StringValue(tt.deployment),
+			semconv.GenAIRequestModelKey:      attribute.StringValue(tt.deployment),
+			semconv.GenAIResponseModelKey:     attribute.StringValue(clientstest.DefaultModel),
+			semconv.GenAIUsageInputTokensKey:  attribute.Int64Value(tt.input),
+			semconv.GenAIUsageOutputTokensKey: attribute.Int64Value(tt.output),
+		}
+		for key, value := range want {
+			if got, ok := telemetrytest.Attr(span, key); !ok || got != value {
+				t.Errorf("%s: %s = %v, want %v", tt.span, key, got.Emit(), value.Emit())
+			}
+		}
+	}
+}
+
+func TestOpenAIClientsCountRequestsAndTokens(t *testing.T) {
+	recorder := metricstest.Record(t)
+	c, srv := newFakeClients(t)
+	srv.Script("missing", clientstest.NotFound())
+
+	if _, err := c.GenerateEmbedding(context.Background(), "quiet hotel"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ChatCompletion(context.Background(), "system", "user"); err != nil {
+		t.Fatal(err)
+	}
+	c.config.EmbeddingDeployment = "missing"
+	if _, err := c.GenerateEmbedding(context.Background(), "quiet hotel"); err == nil {
+		t.Fatal("expected an error from the missing deployment")
+	}
+
+	tests := []struct {
+		name   string
+		labels []string
+		want   float64
+	}{
+		{"embedding_requests_total", []string{"status", metrics.StatusOK}, 1},
+		{"embedding_requests_total", []string{"status", "404"}, 1},
+		{"openai_tokens_total", []string{"deployment", "embed-deployment", "type", "input"}, 7},
+		{"openai_tokens_total", []string{"deployment", "synth-deployment", "type", "input"}, 40},
+		{"openai_tokens_total", []string{"deployment", "synth-deployment", "type", "output"}, 5},
+	}
+	for _, tt := range tests {
+		if got := metricstest.Value(t, recorder, tt.name, tt.labels...); got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAIClientsSpanRecordsError(t *testing.T) {
+	exporter := telemetrytest.Record(t)
+	c, srv := newFakeClients(t)
+	srv.Script("missing", clientstest.NotFound())
+	c.config.SynthDeployment = "missing"
+
+	if _, err := c.ChatCompletion(context.Background(), "system", "user"); err == nil {
+		t.Fatal("expected an error from the missing deployment")
+	}
+	span := telemetrytest.Span(t, exporter.GetSpans(), "chat missing")
+	if span.Status.Code != codes.Error || len(span.Events) == 0 {
+		t.Errorf("span status = %v with %d events, want an error with the exception recorded", span.Status.Code, len(span.Events))
+	}
+}
+
+func TestGenerateEmbedding(t *testing.T) {
+	c, srv := newFakeClients(t)
+	c.config.EmbeddingDimensions = 2
+	srv.Script("embed-deployment", clientstest.Embeddings([]float64{0.5, 0.25}))
+
+	got, err := c.GenerateEmbedding(context.Background(), "quiet hotel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []float32{0.5, 0.25}) {
+		t.Errorf("embedding = %v, want [0.5 0.25]", got)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.Deployment != "embed-deployment" || req.Operation != clientstest.OperationEmbeddings || req.APIVersion == "" {
+		t.Errorf("request = %s %s (api-version %q), want embeddings of embed-deployment", req.Deployment, req.Operation, req.APIVersion)
+	}
+	if req.Body["input"] != "quiet hotel" || req.Body["dimensions"] != float64(2) {
+		t.Errorf("body = %v, want the text and the configured dimensions", req.Body)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want the API key", got)
+	}
+}
+
+// TestStageTimeouts checks that each request is bounded by its own stage's budget: a
+// deployment slower than its budget fails naming the stage, while one just as slow
+// answers within a generous budget of another stage.
+func TestStageTimeouts(t *testing.T) {
+	embed := func(c *OpenAIClients) error {
+		_, err := c.GenerateEmbedding(context.Background(), "quiet hotel")
+		return err
+	}
+	chat := func(c *OpenAIClients) error {
+		_, err := c.ChatCompletion(context.Background(), "system", "user")
+		return err
+	}
+	stream := func(c *OpenAIClients) error {
+		_, err := c.ChatCompletionStream(context.Background(), "system", "user", io.Discard)
+		return err
+	}
+
+	tests := []struct {
+		name       string
+		deployment string
+		call       func(*OpenAIClients) error
+		other      func(*OpenAIClients) error
+		stage      string
+		setting    string
+	}{
+		{"embedding", "embed-deployment", embed, chat, timeouts.StageEmbedding, "EMBEDDING_TIMEOUT"},
+		{"chat", "synth-deployment", chat, embed, timeouts.StageChat, "CHAT_TIMEOUT"},
+		{"streamed chat", "synth-deployment", stream, embed, timeouts.StageChat, "CHAT_TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := newFakeClients(t)
+			c.config.Timeouts = timeouts.Config{Embedding: time.Minute, Chat: time.Minute}
+			if tt.stage == timeouts.StageEmbedding {
+				c.config.Timeouts.Embedding = 50 * time.Millisecond
+			} else {
+				c.config.Timeouts.Chat = 50 * time.Millisecond
+			}
+			srv.Script(tt.deployment, clientstest.Response{Delay: 5 * time.Second})
+
+			start := time.Now()
+			err := tt.call(c)
+			var timedOut *timeouts.Error
+			if !errors.As(err, &timedOut) || timedOut.Stage != tt.stage || timedOut.Setting != tt.setting {
+				t.Fatalf("err = %v, want a %s timeout naming %s", err, tt.stage, tt.setting)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("err = %v, want it to wrap context.DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("the call took %s, want it to give up after the 50ms budget", elapsed)
+			}
+
+			// The other stage's budget is its own
+			slow := clientstest.Completion(clientstest.DefaultAnswer)
+			other := "synth-deployment"
+			if tt.deployment == other {
+				slow, other = clientstest.Embeddings(make([]float64, 1536)), "embed-deployment"
+			}
+			slow.Delay = 100 * time.Millisecond
+			srv.Script(other, slow)
+			if err := tt.other(c); err != nil {
+				t.Errorf("slow call of the other stage: %v, want it within its own budget", err)
+			}
+		})
+	}
+}
+
+func TestGenerateEmbeddings(t *testing.T) {
+	c, srv := newFakeClients(t)
+	// The service may list the embeddings in any order; their index says which input each is for
+	srv.Script("embed-deployment", clientstest.Response{Body: `{"object":"list","model":"text-embedding-3-small","data":[
+		{"object":"embedding","index":2,"embedding":[3]},
+		{"object":"embedding","index":0,"embedding":[1]},
+		{"object":"embedding","index":1,"embedding":[2]}],"usage":{"prompt_tokens":9,"total_tokens":9}}`})
+
+	got, err := c.GenerateEmbeddings(context.Background(), []string{"pool", "beach", "parking"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0][0] != 1 || got[1][0] != 2 || got[2][0] != 3 {
+		t.Errorf("embeddings = %v, want them in input order", got)
+	}
+	if input, _ := srv.Requests()[0].Body["input"].([]any); len(input) != 3 || input[1] != "beach" {
+		t.Errorf("input = %v, want all three texts in one request", srv.Requests()[0].Body["input"])
+	}
+	if usage := c.Usage().Snapshot(); len(usage) != 1 || usage[0].Calls != 1 || usage[0].PromptTokens != 9 {
+		t.Errorf("usage = %+v, want one call of 9 prompt tokens", usage)
+	}
+
+	// An answer that doesn't cover every input is an error
+	srv.Script("embed-deployment", clientstest.Embeddings([]float64{1}))
+	if _, err := c.GenerateEmbeddings(context.Background(), []string{"pool", "beach"}); err == nil {
+		t.Error("GenerateEmbeddings accepted 1 embedding for 2 inputs")
+	}
+
+	if got, err := c.GenerateEmbeddings(context.Background(), nil); err != nil || got != nil {
+		t.Errorf("GenerateEmbeddings(nil) = %v, %v; want nothing and no request", got, err)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+}
+
+func TestChatCompletionWithToolsAndExtractToolCall(t *testing.T) {
+	c, srv := newFakeClients(t)
+	srv.Script("plan-deployment", clientstest.ToolCalls(clientstest.ToolCall{Name: "search_hotels_collection", Arguments: `{"query":"pool","nearestNeighbors":3}`}))
+
+	tools := []openai.ChatCompletionToolUnionParam{openai.ChatCompletionFunctionTool(openai.FunctionDefinitionParam{Name: "search_hotels_collection"})}
+	resp, err := c.ChatCompletionWithTools(context.Background(), "plan", "find a pool", tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, args, err := ExtractToolCall(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "search_hotels_collection" || args["query"] != "pool" || args["nearestNeighbors"] != float64(3) {
+		t.Errorf("tool call = %s %v, want search_hotels_collection with the scripted arguments", name, args)
+	}
+
+	body := srv.Requests()[0].Body
+	sent, _ := body["tools"].([]any)
+	if len(sent) != 1 || !strings.Contains(fmt.Sprint(sent[0]), "search_hotels_collection") || body["temperature"] != float64(0) {
+		t.Errorf("body = %v, want the tool definition at temperature 0", body)
+	}
+	if usage := c.Usage().Snapshot(); len(usage) != 1 || usage[0].Deployment != "plan-deployment" || usage[0].PromptTokens != clientstest.PromptTokens {
+		t.Errorf("usage = %+v, want the planner call", usage)
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	c, srv := newFakeClients(t)
+
+	got, err := c.ChatCompletion(context.Background(), "answer from the context", "quiet hotel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != clientstest.DefaultAnswer {
+		t.Errorf("answer = %q, want %q", got, clientstest.DefaultAnswer)
+	}
+
+	messages, _ := srv.Requests()[0].Body["messages"].([]any)
+	if len(messages) != 2 || !strings.Contains(fmt.Sprint(messages[0]), "role:system") || !strings.Contains(fmt.Sprint(messages[1]), "quiet hotel") {
+		t.Errorf("messages = %v, want the system prompt then the user message", messages)
+	}
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	c, srv := newFakeClients(t)
+	srv.Script("synth-deployment", clientstest.Stream("Try ", "Ocean ", "Retreat."))
+
+	var out strings.Builder
+	got, err := c.ChatCompletionStream(context.Background(), "system", "user", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Try Ocean Retreat." || out.String() != got {
+		t.Errorf("answer = %q, streamed %q; want the deltas joined", got, out.String())
+	}
+	if srv.Requests()[0].Body["stream"] != true {
+		t.Errorf("body = %v, want a streaming request", srv.Requests()[0].Body)
+	}
+	// Usage arrives in the final chunk
+	if usage := c.Usage().Snapshot(); len(usage) != 1 || usage[0].PromptTokens != clientstest.PromptTokens || usage[0].CompletionTokens != clientstest.CompletionTokens {
+		t.Errorf("usage = %+v, want the streamed usage", usage)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	unavailable := clientstest.Error(http.StatusServiceUnavailable, "ServiceUnavailable", "The service is temporarily unavailable.")
+	unavailable.Header = map[string]string{"retry-after-ms": "1"}
+
+	tests := []struct {
+		name     string
+		script   []clientstest.Response
+		requests int
+		status   int // of the final error; 0 means success
+	}{
+		{"throttled once", []clientstest.Response{clientstest.Throttled(20 * time.Millisecond)}, 2, 0},
+		{"service unavailable once", []clientstest.Response{unavailable}, 2, 0},
+		{"throttled throughout", []clientstest.Response{
+			clientstest.Throttled(20 * time.Millisecond),
+			clientstest.Throttled(20 * time.Millisecond),
+			clientstest.Throttled(20 * time.Millisecond),
+		}, 3, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := newFakeClients(t)
+			srv.Script("synth-deployment", tt.script...)
+
+			start := time.Now()
+			answer, err := c.ChatCompletion(context.Background(), "system", "user")
+			elapsed := time.Since(start)
+
+			if n := len(srv.Requests()); n != tt.requests {
+				t.Errorf("got %d requests, want %d", n, tt.requests)
+			}
+			if tt.status == 0 {
+				if err != nil || answer != clientstest.DefaultAnswer {
+					t.Errorf("answer = %q, %v; want the answer after the retry", answer, err)
+				}
+				return
+			}
+
+			if StatusCode(err) != tt.status || !IsTransient(err) || !IsModelUnavailable(err) {
+				t.Errorf("err = %v (status %d), want a transient %d", err, StatusCode(err), tt.status)
+			}
+			// The client waits as long as retry-after-ms asks, not the default backoff
+			// of half a second or the Retry-After of a whole second
+			if elapsed < 40*time.Millisecond || elapsed >= 500*time.Millisecond {
+				t.Errorf("retries took %s, want two waits of 20ms", elapsed)
+			}
+		})
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    clientstest.Response
+		unavailable bool
+		auth        bool
+		filtered    bool
+	}{
+		{"missing deployment", clientstest.NotFound(), true, false, false},
+		{"invalid key", clientstest.Error(http.StatusUnauthorized, "401", "Access denied due to invalid subscription key."), false, true, false},
+		{"filtered prompt", clientstest.ContentFiltered(), false, false, true},
+		{"filtered completion", clientstest.FilteredCompletion(), false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := newFakeClients(t)
+			srv.Script("synth-deployment", tt.response)
+
+			_, err := c.ChatCompletion(context.Background(), "system", "user")
+			if err == nil {
+				t.Fatal("ChatCompletion succeeded, want an error")
+			}
+			if n := len(srv.Requests()); n != 1 {
+				t.Errorf("got %d requests, want 1: the error is not worth retrying", n)
+			}
+			if IsTransient(err) || IsModelUnavailable(err) != tt.unavailable || IsAuthError(err) != tt.auth || IsContentFiltered(err) != tt.filtered {
+				t.Errorf("err = %v: transient %v, unavailable %v, auth %v, filtered %v", err,
+					IsTransient(err), IsModelUnavailable(err), IsAuthError(err), IsContentFiltered(err))
+			}
+		})
+	}
+}
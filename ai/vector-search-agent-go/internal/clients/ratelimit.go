@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by RateLimiter.Wait when the caller's deadline passes
+// before its turn comes
+var ErrRateLimited = errors.New("client-side rate limit reached")
+
+// RateLimiter spaces requests evenly so that at most a fixed number start per second.
+// A nil *RateLimiter allows every request immediately. It is safe for concurrent use.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a limiter allowing perSecond requests per second, or returns
+// nil, which allows everything, when perSecond is not positive
+func NewRateLimiter(perSecond float64) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / perSecond), now: time.Now}
+}
+
+// Wait blocks until the caller may start a request. When ctx's deadline would pass
+// first, it gives up the turn, waits out the deadline, and returns ErrRateLimited, so
+// callers retrying in a loop can't spin. It returns ctx's error if ctx ends otherwise.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	if deadline, ok := ctx.Deadline(); ok && slot.After(deadline) {
+		l.mu.Unlock()
+		<-ctx.Done()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrRateLimited
+		}
+		return ctx.Err()
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
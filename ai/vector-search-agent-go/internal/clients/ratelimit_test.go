@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	l := NewRateLimiter(50) // one request every 20ms
+
+	start := time.Now()
+	for range 4 {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request starts at once, the other three wait a slot each
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least 60ms", elapsed)
+	}
+}
+
+func TestRateLimiterConcurrentUse(t *testing.T) {
+	l := NewRateLimiter(1000)
+	l.now = func() time.Time { return time.Time{} } // every waiter asks at the same instant
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+			defer cancel()
+			_ = l.Wait(ctx)
+		}()
+	}
+	wg.Wait()
+
+	// Each waiter took its own slot
+	want := time.Time{}.Add(10 * time.Millisecond)
+	if !l.next.Equal(want) {
+		t.Errorf("next slot = %v, want %v", l.next, want)
+	}
+}
+
+func TestRateLimiterDeadline(t *testing.T) {
+	l := NewRateLimiter(1) // one request a second
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next slot is a second away, past the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+
+	// The abandoned turn is not reserved
+	if got := l.next.Sub(time.Now()); got > time.Second {
+		t.Errorf("next slot is %v away, want at most a second", got)
+	}
+}
+
+func TestRateLimiterCancelled(t *testing.T) {
+	l := NewRateLimiter(1)
+	_ = l.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestNilRateLimiterAllowsEverything(t *testing.T) {
+	l := NewRateLimiter(0)
+	if l != nil {
+		t.Fatalf("NewRateLimiter(0) = %+v, want nil", l)
+	}
+	for range 100 {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
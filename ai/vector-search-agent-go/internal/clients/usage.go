@@ -0,0 +1,124 @@
+package clients
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModelPricing holds estimated prices in USD per million tokens
+type ModelPricing struct {
+	PromptPer1M     float64
+	CompletionPer1M float64
+}
+
+// defaultPricing maps model names to list prices. Deployments are matched by the
+// longest model name contained in the deployment name, since Azure deployments are
+// usually named after the model they serve.
+var defaultPricing = map[string]ModelPricing{
+	"gpt-4o":                 {PromptPer1M: 2.50, CompletionPer1M: 10.00},
+	"gpt-4o-mini":            {PromptPer1M: 0.15, CompletionPer1M: 0.60},
+	"text-embedding-3-small": {PromptPer1M: 0.02},
+	"text-embedding-3-large": {PromptPer1M: 0.13},
+	"text-embedding-ada-002": {PromptPer1M: 0.10},
+}
+
+// PricingFor returns the estimated pricing for a deployment and whether a price is known
+func PricingFor(deployment string) (ModelPricing, bool) {
+	name := strings.ToLower(deployment)
+	best := ""
+	for model := range defaultPricing {
+		if strings.Contains(name, model) && len(model) > len(best) {
+			best = model
+		}
+	}
+	if best == "" {
+		return ModelPricing{}, false
+	}
+	return defaultPricing[best], true
+}
+
+// DeploymentUsage holds the token usage of one deployment
+type DeploymentUsage struct {
+	Deployment       string  `json:"deployment"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	EstimatedCost    float64 `json:"estimatedCost"`
+	PriceKnown       bool    `json:"priceKnown"`
+}
+
+// TotalTokens returns prompt plus completion tokens
+func (u DeploymentUsage) TotalTokens() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// UsageTracker accumulates token usage per deployment. It is safe for concurrent use.
+type UsageTracker struct {
+	mu          sync.Mutex
+	deployments map[string]*DeploymentUsage
+}
+
+// NewUsageTracker creates an empty usage tracker
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{deployments: make(map[string]*DeploymentUsage)}
+}
+
+// Record adds the usage of one API call
+func (t *UsageTracker) Record(deployment string, promptTokens, completionTokens int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage, ok := t.deployments[deployment]
+	if !ok {
+		usage = &DeploymentUsage{Deployment: deployment}
+		t.deployments[deployment] = usage
+	}
+	usage.Calls++
+	usage.PromptTokens += promptTokens
+	usage.CompletionTokens += completionTokens
+}
+
+// Snapshot returns the usage per deployment with estimated costs, sorted by deployment name
+func (t *UsageTracker) Snapshot() []DeploymentUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := make([]DeploymentUsage, 0, len(t.deployments))
+	for _, usage := range t.deployments {
+		u := *usage
+		if pricing, ok := PricingFor(u.Deployment); ok {
+			u.PriceKnown = true
+			u.EstimatedCost = float64(u.PromptTokens)*pricing.PromptPer1M/1e6 + float64(u.CompletionTokens)*pricing.CompletionPer1M/1e6
+		}
+		snapshot = append(snapshot, u)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Deployment < snapshot[j].Deployment
+	})
+	return snapshot
+}
+
+// Reset clears all recorded usage
+func (t *UsageTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deployments = make(map[string]*DeploymentUsage)
+}
+
+type usageContextKey struct{}
+
+// WithUsageTracker returns a copy of ctx carrying a tracker that records the usage of
+// calls made with that context, in addition to the client-wide tracker. Servers use it
+// to report usage per request.
+func WithUsageTracker(ctx context.Context, t *UsageTracker) context.Context {
+	return context.WithValue(ctx, usageContextKey{}, t)
+}
+
+// UsageTrackerFromContext returns the tracker added by WithUsageTracker, if any
+func UsageTrackerFromContext(ctx context.Context) (*UsageTracker, bool) {
+	t, ok := ctx.Value(usageContextKey{}).(*UsageTracker)
+	return t, ok
+}
@@ -0,0 +1,55 @@
+package clients
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPricingForMatchesLongestModel(t *testing.T) {
+	tests := []struct {
+		deployment string
+		want       ModelPricing
+		known      bool
+	}{
+		{"gpt-4o", defaultPricing["gpt-4o"], true},
+		{"prod-GPT-4o-mini-eastus", defaultPricing["gpt-4o-mini"], true},
+		{"text-embedding-3-small", defaultPricing["text-embedding-3-small"], true},
+		{"my-custom-model", ModelPricing{}, false},
+	}
+
+	for _, tt := range tests {
+		got, known := PricingFor(tt.deployment)
+		if got != tt.want || known != tt.known {
+			t.Errorf("PricingFor(%q) = %+v, %v, want %+v, %v", tt.deployment, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestUsageTrackerSnapshot(t *testing.T) {
+	tracker := NewUsageTracker()
+	tracker.Record("gpt-4o-mini", 1000, 200)
+	tracker.Record("gpt-4o-mini", 500, 100)
+	tracker.Record("custom", 10, 5)
+
+	snapshot := tracker.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Deployment != "custom" || snapshot[1].Deployment != "gpt-4o-mini" {
+		t.Fatalf("snapshot = %+v, want custom then gpt-4o-mini", snapshot)
+	}
+
+	custom, mini := snapshot[0], snapshot[1]
+	if custom.PriceKnown || custom.EstimatedCost != 0 {
+		t.Errorf("custom = %+v, want no price", custom)
+	}
+	if mini.Calls != 2 || mini.PromptTokens != 1500 || mini.CompletionTokens != 300 || mini.TotalTokens() != 1800 {
+		t.Errorf("gpt-4o-mini = %+v, want 2 calls, 1500 prompt, 300 completion tokens", mini)
+	}
+	// 1500 * $0.15/1M + 300 * $0.60/1M
+	if want := 0.000405; math.Abs(mini.EstimatedCost-want) > 1e-12 {
+		t.Errorf("gpt-4o-mini cost = %v, want %v", mini.EstimatedCost, want)
+	}
+
+	tracker.Reset()
+	if snapshot := tracker.Snapshot(); len(snapshot) != 0 {
+		t.Errorf("after Reset, snapshot = %+v", snapshot)
+	}
+}
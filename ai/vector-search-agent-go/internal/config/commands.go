@@ -0,0 +1,63 @@
+package config
+
+import "time"
+
+// Commands describes the settings that some commands read themselves, as the defaults of
+// their flags, before the config is loaded. Its sections are never filled in: each
+// command parses its own, so that a flag can override the variable. The tags put the
+// variables in Variables, the generated documentation, and the validation every
+// command runs at startup.
+type Commands struct {
+	Queries   QuerySettings   `section:"Queries"`
+	Upload    UploadSettings  `section:"Upload"`
+	Batch     BatchSettings   `section:"Batch"`
+	Serve     ServeSettings   `section:"Serve"`
+	Migration MigrateSettings `section:"Embedding migration"`
+}
+
+// QuerySettings are the flag defaults of the commands that search
+type QuerySettings struct {
+	Query            string `env:"QUERY" for:"embedding" example:"hotel with a pool near the beach" desc:"Request that agent and search run when none is given as an argument"`
+	NearestNeighbors int    `env:"NEAREST_NEIGHBORS" for:"embedding" default:"5" desc:"Number of nearest neighbors each search of agent, batch, chat, and search returns"`
+	WarmupSearches   int    `env:"WARMUP_SEARCHES" for:"embedding" example:"3" desc:"Throwaway searches that agent, serve, and benchmark issue first to warm up the vector index"`
+	SessionID        string `env:"SESSION_ID" for:"chat" example:"demo-1" desc:"Session ID of agent and chat runs, attached to their logs; unset generates a UUID per run"`
+}
+
+// UploadSettings are the flag defaults of upload, and the data files of the commands
+// that read the hotel data
+type UploadSettings struct {
+	DataFile           string        `env:"DATA_FILE_WITHOUT_VECTORS" default:"../data/Hotels.json" desc:"Hotel data file, a path or URL, that upload embeds and eval, generate, benchmark, loadtest, and offline mode read"`
+	VectorsFile        string        `env:"DATA_FILE_WITH_VECTORS" for:"vectorindex" example:"../data/Hotels_Vector.json" desc:"Pre-vectorized hotel data file that upload stores without embedding"`
+	SkipIndex          bool          `env:"UPLOAD_SKIP_INDEX" for:"vectorindex" example:"true" desc:"Upload without creating the vector index"`
+	IndexOnly          bool          `env:"UPLOAD_INDEX_ONLY" for:"vectorindex" example:"true" desc:"Only create the vector index, uploading nothing"`
+	SkipExisting       bool          `env:"UPLOAD_SKIP_EXISTING" for:"vectorindex" example:"true" desc:"Skip hotels whose HotelId is already stored"`
+	ChangedOnly        bool          `env:"UPLOAD_CHANGED_ONLY" for:"vectorindex" example:"true" desc:"Embed and upsert only the hotels whose content changed"`
+	Prune              bool          `env:"UPLOAD_PRUNE" for:"vectorindex" example:"true" desc:"Delete stored hotels missing from the data file; needs UPLOAD_CHANGED_ONLY"`
+	Strict             bool          `env:"UPLOAD_STRICT" for:"vectorindex" example:"true" desc:"Stop at the first invalid hotel instead of skipping it"`
+	EmbedFrench        bool          `env:"EMBED_FRENCH" for:"vectorindex" example:"true" desc:"Also embed Description_fr and create the French vector index"`
+	EmbedRooms         bool          `env:"EMBED_ROOMS" for:"vectorindex" example:"true" desc:"Also store each room as an embedded document in ROOMS_COLLECTION"`
+	FieldWeights       string        `env:"EMBED_FIELD_WEIGHTS" for:"vectorindex" example:"Description:2,Tags:1" desc:"Weighted hotel fields to compose the embedded text from; unset embeds the Description alone"`
+	BatchSize          int           `env:"UPLOAD_BATCH_SIZE" for:"vectorindex" format:"positive-int" default:"10" desc:"Hotels embedded and inserted per batch"`
+	Concurrency        int           `env:"EMBEDDING_CONCURRENCY" for:"vectorindex" format:"positive-int" default:"4" desc:"Embedding batches in flight at once"`
+	Checkpoint         string        `env:"UPLOAD_CHECKPOINT" for:"vectorindex" default:".upload-checkpoint.json" desc:"File recording the upload's progress, for UPLOAD_RESUME"`
+	CheckpointInterval time.Duration `env:"UPLOAD_CHECKPOINT_INTERVAL" for:"vectorindex" default:"5s" desc:"How often the checkpoint is written; 0 writes it after every batch"`
+	Resume             bool          `env:"UPLOAD_RESUME" for:"vectorindex" example:"true" desc:"Continue from the checkpoint of an interrupted upload"`
+	FailureReport      string        `env:"UPLOAD_FAILURE_REPORT" for:"vectorindex" example:"upload-failures.json" desc:"File to write the hotels that failed to upload to, for a retry"`
+}
+
+// BatchSettings are the flag defaults of batch
+type BatchSettings struct {
+	Concurrency int `env:"BATCH_CONCURRENCY" for:"chat" format:"positive-int" default:"2" desc:"Queries of a batch that run at once"`
+}
+
+// ServeSettings are the settings of serve
+type ServeSettings struct {
+	Port           string        `env:"PORT" for:"chat" default:"8080" desc:"Port serve listens on"`
+	RequestTimeout time.Duration `env:"SERVE_REQUEST_TIMEOUT" for:"chat" format:"positive-duration" default:"60s" desc:"Deadline of each request to serve"`
+}
+
+// MigrateSettings are the flag defaults of migrate-embeddings
+type MigrateSettings struct {
+	Deployment string `env:"MIGRATE_TARGET_DEPLOYMENT" for:"embedding" example:"text-embedding-3-large" desc:"Embedding deployment that migrate-embeddings moves to"`
+	Dimensions int    `env:"MIGRATE_TARGET_DIMENSIONS" for:"embedding" format:"positive-int" example:"3072" desc:"Dimensions of the embeddings migrate-embeddings creates"`
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"gopkg.in/yaml.v3"
+)
+
+// Config combines every setting shared by the commands. The section tag of each field
+// heads its variables in the generated documentation; the fields of the sections are
+// tagged with their environment variables (see Variables). The settings of a single
+// command are described by Commands instead.
+type Config struct {
+	OpenAI      *clients.OpenAIConfig          `section:"Azure OpenAI"`
+	VectorStore *vectorstore.VectorStoreConfig `section:"Azure DocumentDB"`
+	Index       vectorstore.VectorIndexSpec    `section:"Vector index"`
+	Data        vectorstore.LoadOptions        `section:"Data files"`
+	Source      vectorstore.SourceConfig       `section:"Data file URLs"`
+	Timeouts    timeouts.Config                `section:"Timeouts"`
+	Planner     *agents.PlannerConfig          `section:"Planner agent"`
+	Synthesizer *agents.SynthesizerConfig      `section:"Synthesizer agent"`
+	Tracing     telemetry.Config               `section:"Tracing"`
+	Runtime     Runtime                        `section:"Runtime"`
+}
+
+// Runtime holds the shared settings that belong to no client
+type Runtime struct {
+	OfflineMode    bool `env:"OFFLINE_MODE" format:"bool" default:"false" desc:"Run against the bundled data and deterministic stand-ins for the models, without Azure"`
+	HistoryEnabled bool `env:"HISTORY_ENABLED" for:"documentdb" format:"bool" default:"false" desc:"Record every query and answer in HISTORY_COLLECTION"`
+	// MetricsEnvironment tells apart the runs of several environments sharing one
+	// METRICS_COLLECTION; it defaults to the azd environment
+	MetricsEnvironment string `env:"METRICS_ENVIRONMENT" for:"documentdb" example:"dev" desc:"Environment recorded with each run in METRICS_COLLECTION; unset uses AZURE_ENV_NAME, the azd environment"`
+	LogLevel           string `env:"LOG_LEVEL" example:"info" desc:"Log level: error, warn, info, debug, or trace; unset follows the -v flags"`
+	LogFormat          string `env:"LOG_FORMAT" format:"oneof:text,json" default:"text" desc:"Log format: text or json"`
+	// MaxNearestNeighbors bounds the k of every search; see vectorstore.ClampK
+	MaxNearestNeighbors int  `env:"MAX_NEAREST_NEIGHBORS" format:"positive-int" default:"20" desc:"Largest number of nearest neighbors a search returns; larger requests are lowered to it"`
+	Debug               bool `env:"DEBUG" format:"bool" default:"false" desc:"Log at debug level when LOG_LEVEL is unset, like -vv"`
+	// The settings below are read before the config is loaded, while a command sets
+	// itself up, and are only recorded here
+	ConfigFile   string `env:"CONFIG_FILE" example:"config.yaml" desc:"Config file to read when --config is not given"`
+	AzdEnvName   string `env:"AZD_ENV_NAME" example:"dev" desc:"azd environment whose .azure/<name>/.env is loaded; unset uses the default environment in .azure/config.json"`
+	AzureEnvName string `env:"AZURE_ENV_NAME" example:"hotels-dev" desc:"Name of the azd environment, which azd sets"`
+	MetricsAddr  string `env:"METRICS_ADDR" example:":9090" desc:"Address to serve Prometheus metrics at /metrics on; unset discards them, except in serve"`
+	LeakCheck    bool   `env:"LEAK_CHECK" format:"bool" default:"false" desc:"Check for leaked goroutines, connections, and cursors on exit; set it in the shell, since it is read before .env"`
+}
+
+// loadRuntime reads the runtime settings from the environment, with defaults for
+// anything unset or invalid
+func loadRuntime(getenv func(string) string) Runtime {
+	history, _ := strconv.ParseBool(getenv("HISTORY_ENABLED"))
+	debug, _ := strconv.ParseBool(getenv("DEBUG"))
+	leakCheck, _ := strconv.ParseBool(getenv("LEAK_CHECK"))
+	format, err := logging.ParseFormat(getenv("LOG_FORMAT"))
+	if err != nil {
+		format = logging.FormatText
+	}
+	return Runtime{
+		OfflineMode:         offline.Enabled(getenv),
+		HistoryEnabled:      history,
+		MetricsEnvironment:  cmp.Or(getenv("METRICS_ENVIRONMENT"), getenv("AZURE_ENV_NAME")),
+		LogLevel:            getenv("LOG_LEVEL"),
+		LogFormat:           format,
+		MaxNearestNeighbors: vectorstore.MaxK(),
+		Debug:               debug,
+		ConfigFile:          getenv("CONFIG_FILE"),
+		AzdEnvName:          getenv("AZD_ENV_NAME"),
+		AzureEnvName:        getenv("AZURE_ENV_NAME"),
+		MetricsAddr:         getenv("METRICS_ADDR"),
+		LeakCheck:           leakCheck,
+	}
+}
+
+// File is the layout of a config file. Every value is optional.
+type File struct {
+	UsePasswordless *bool          `yaml:"usePasswordless" json:"usePasswordless"`
+	OpenAI          OpenAIFile     `yaml:"openai" json:"openai"`
+	DocumentDB      DocumentDBFile `yaml:"documentdb" json:"documentdb"`
+	Timeouts        TimeoutsFile   `yaml:"timeouts" json:"timeouts"`
+}
+
+// OpenAIFile is the openai section of a config file
+type OpenAIFile struct {
+	Endpoint            string `yaml:"endpoint" json:"endpoint"`
+	APIKey              string `yaml:"apiKey" json:"apiKey"`
+	EmbeddingDeployment string `yaml:"embeddingDeployment" json:"embeddingDeployment"`
+	EmbeddingAPIVersion string `yaml:"embeddingApiVersion" json:"embeddingApiVersion"`
+	PlannerDeployment   string `yaml:"plannerDeployment" json:"plannerDeployment"`
+	PlannerAPIVersion   string `yaml:"plannerApiVersion" json:"plannerApiVersion"`
+	SynthDeployment     string `yaml:"synthDeployment" json:"synthDeployment"`
+	SynthAPIVersion     string `yaml:"synthApiVersion" json:"synthApiVersion"`
+
+	MaxRequestsPerSecond *float64 `yaml:"maxRequestsPerSecond" json:"maxRequestsPerSecond"`
+}
+
+// DocumentDBFile is the documentdb section of a config file
+type DocumentDBFile struct {
+	ConnectionString   string `yaml:"connectionString" json:"connectionString"`
+	Cluster            string `yaml:"cluster" json:"cluster"`
+	Database           string `yaml:"database" json:"database"`
+	Collection         string `yaml:"collection" json:"collection"`
+	IndexName          string `yaml:"indexName" json:"indexName"`
+	EmbeddedField      string `yaml:"embeddedField" json:"embeddedField"`
+	FeedbackCollection string `yaml:"feedbackCollection" json:"feedbackCollection"`
+	HistoryCollection  string `yaml:"historyCollection" json:"historyCollection"`
+	MetricsCollection  string `yaml:"metricsCollection" json:"metricsCollection"`
+	RoomsCollection    string `yaml:"roomsCollection" json:"roomsCollection"`
+}
+
+// TimeoutsFile is the timeouts section of a config file; each value is a duration such
+// as 90s or 5m
+type TimeoutsFile struct {
+	Connect     string `yaml:"connect" json:"connect"`
+	Embedding   string `yaml:"embedding" json:"embedding"`
+	Search      string `yaml:"search" json:"search"`
+	Chat        string `yaml:"chat" json:"chat"`
+	Planner     string `yaml:"planner" json:"planner"`
+	Tool        string `yaml:"tool" json:"tool"`
+	Synthesizer string `yaml:"synthesizer" json:"synthesizer"`
+	Run         string `yaml:"run" json:"run"`
+}
+
+// setting links a config file key to the environment variable it provides
+type setting struct {
+	key   string
+	env   string
+	value func(f *File) string
+}
+
+// settings lists every config file key
+var settings = []setting{
+	{"usePasswordless", "USE_PASSWORDLESS", func(f *File) string {
+		if f.UsePasswordless == nil {
+			return ""
+		}
+		return strconv.FormatBool(*f.UsePasswordless)
+	}},
+	{"openai.endpoint", "AZURE_OPENAI_ENDPOINT", func(f *File) string { return f.OpenAI.Endpoint }},
+	{"openai.apiKey", "AZURE_OPENAI_API_KEY", func(f *File) string { return f.OpenAI.APIKey }},
+	{"openai.embeddingDeployment", "AZURE_OPENAI_EMBEDDING_DEPLOYMENT", func(f *File) string { return f.OpenAI.EmbeddingDeployment }},
+	{"openai.embeddingApiVersion", "AZURE_OPENAI_EMBEDDING_API_VERSION", func(f *File) string { return f.OpenAI.EmbeddingAPIVersion }},
+	{"openai.plannerDeployment", "AZURE_OPENAI_PLANNER_DEPLOYMENT", func(f *File) string { return f.OpenAI.PlannerDeployment }},
+	{"openai.plannerApiVersion", "AZURE_OPENAI_PLANNER_API_VERSION", func(f *File) string { return f.OpenAI.PlannerAPIVersion }},
+	{"openai.synthDeployment", "AZURE_OPENAI_SYNTH_DEPLOYMENT", func(f *File) string { return f.OpenAI.SynthDeployment }},
+	{"openai.synthApiVersion", "AZURE_OPENAI_SYNTH_API_VERSION", func(f *File) string { return f.OpenAI.SynthAPIVersion }},
+	{"openai.maxRequestsPerSecond", "AZURE_OPENAI_MAX_RPS", func(f *File) string {
+		if f.OpenAI.MaxRequestsPerSecond == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f.OpenAI.MaxRequestsPerSecond, 'f', -1, 64)
+	}},
+	{"documentdb.connectionString", "AZURE_DOCUMENTDB_CONNECTION_STRING", func(f *File) string { return f.DocumentDB.ConnectionString }},
+	{"documentdb.cluster", "AZURE_DOCUMENTDB_CLUSTER", func(f *File) string { return f.DocumentDB.Cluster }},
+	{"documentdb.database", "AZURE_DOCUMENTDB_DATABASENAME", func(f *File) string { return f.DocumentDB.Database }},
+	{"documentdb.collection", "AZURE_DOCUMENTDB_COLLECTION", func(f *File) string { return f.DocumentDB.Collection }},
+	{"documentdb.indexName", "AZURE_DOCUMENTDB_INDEX_NAME", func(f *File) string { return f.DocumentDB.IndexName }},
+	{"documentdb.embeddedField", "EMBEDDED_FIELD", func(f *File) string { return f.DocumentDB.EmbeddedField }},
+	{"documentdb.feedbackCollection", "FEEDBACK_COLLECTION", func(f *File) string { return f.DocumentDB.FeedbackCollection }},
+	{"documentdb.historyCollection", "HISTORY_COLLECTION", func(f *File) string { return f.DocumentDB.HistoryCollection }},
+	{"documentdb.metricsCollection", "METRICS_COLLECTION", func(f *File) string { return f.DocumentDB.MetricsCollection }},
+	{"documentdb.roomsCollection", "ROOMS_COLLECTION", func(f *File) string { return f.DocumentDB.RoomsCollection }},
+	{"timeouts.connect", "CONNECT_TIMEOUT", func(f *File) string { return f.Timeouts.Connect }},
+	{"timeouts.embedding", "EMBEDDING_TIMEOUT", func(f *File) string { return f.Timeouts.Embedding }},
+	{"timeouts.search", "SEARCH_TIMEOUT", func(f *File) string { return f.Timeouts.Search }},
+	{"timeouts.chat", "CHAT_TIMEOUT", func(f *File) string { return f.Timeouts.Chat }},
+	{"timeouts.planner", "AGENT_PLANNER_TIMEOUT", func(f *File) string { return f.Timeouts.Planner }},
+	{"timeouts.tool", "AGENT_TOOL_TIMEOUT", func(f *File) string { return f.Timeouts.Tool }},
+	{"timeouts.synthesizer", "AGENT_SYNTH_TIMEOUT", func(f *File) string { return f.Timeouts.Synthesizer }},
+	{"timeouts.run", "RUN_TIMEOUT", func(f *File) string { return f.Timeouts.Run }},
+}
+
+// Load reads the optional config file at path, then resolves the settings from the
+// environment. Environment variables override file values; command flags, applied by
+// each command afterwards, override both. An empty path loads from the environment only.
+func Load(path string) (*Config, error) {
+	if path != "" {
+		file, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range file.apply(os.LookupEnv, os.Setenv) {
+			slog.Debug("config variable", "name", name, "source", path)
+		}
+	}
+
+	cfg := &Config{
+		OpenAI:      clients.LoadConfigFromEnv(),
+		VectorStore: vectorstore.LoadConfigFromEnv(),
+		Index:       vectorstore.IndexSpecFromEnv(),
+		Data:        vectorstore.LoadOptionsFromEnv(),
+		Source:      vectorstore.SourceConfigFromEnv(),
+		Timeouts:    loadTimeouts(os.Getenv),
+		Planner:     agents.LoadPlannerConfigFromEnv(),
+		Synthesizer: agents.LoadSynthesizerConfigFromEnv(),
+		Tracing:     telemetry.LoadConfig(os.Getenv),
+		Runtime:     loadRuntime(os.Getenv),
+	}
+	// The clients bound their own calls by the same budgets
+	cfg.OpenAI.Timeouts = cfg.Timeouts
+	cfg.VectorStore.Timeouts = cfg.Timeouts
+	return cfg, nil
+}
+
+// loadTimeouts reads the budgets from the variables named by the env tags of
+// timeouts.Config. Unset and invalid values keep their defaults; ValidateEnvironment
+// reports the invalid ones.
+func loadTimeouts(getenv func(string) string) timeouts.Config {
+	c := timeouts.Default()
+	v := reflect.ValueOf(&c).Elem()
+	for i := range v.NumField() {
+		if d, err := time.ParseDuration(getenv(v.Type().Field(i).Tag.Get("env"))); err == nil && d > 0 {
+			v.Field(i).SetInt(int64(d))
+		}
+	}
+	return c
+}
+
+// ReadFile parses a YAML or JSON config file, chosen by extension. Unknown keys are
+// logged as warnings listing the valid keys.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]any
+	var file File
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &raw)
+		if err == nil {
+			err = json.Unmarshal(data, &file)
+		}
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+		if err == nil {
+			err = yaml.Unmarshal(data, &file)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if unknown := unknownKeys(raw, ""); len(unknown) > 0 {
+		slog.Warn("unknown keys in config file", "file", path, "keys", strings.Join(unknown, ", "), "valid", strings.Join(ValidKeys(), ", "))
+	}
+
+	return &file, nil
+}
+
+// ValidKeys returns every key a config file may set, in dotted form
+func ValidKeys() []string {
+	keys := make([]string, 0, len(settings))
+	for _, s := range settings {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// apply sets the environment variable for each value in the file that is not already
+// set and returns the names of the variables it set
+func (f *File) apply(lookupEnv func(string) (string, bool), setenv func(string, string) error) []string {
+	var applied []string
+	for _, s := range settings {
+		value := s.value(f)
+		if value == "" {
+			continue
+		}
+		if _, ok := lookupEnv(s.env); ok {
+			continue
+		}
+		setenv(s.env, value)
+		applied = append(applied, s.env)
+	}
+	return applied
+}
+
+// unknownKeys returns the dotted keys in raw that are not valid config keys
+func unknownKeys(raw map[string]any, prefix string) []string {
+	var unknown []string
+	for key, value := range raw {
+		dotted := prefix + key
+		if nested, ok := value.(map[string]any); ok && isSection(dotted) {
+			unknown = append(unknown, unknownKeys(nested, dotted+".")...)
+			continue
+		}
+		if !slices.Contains(ValidKeys(), dotted) {
+			unknown = append(unknown, dotted)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// isSection reports whether key is the name of a config file section
+func isSection(key string) bool {
+	return key == "openai" || key == "documentdb" || key == "timeouts"
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// configEnv lists every variable a config file can provide
+func configEnv() []string {
+	names := make([]string, 0, len(settings))
+	for _, s := range settings {
+		names = append(names, s.env)
+	}
+	return names
+}
+
+// clearEnv unsets every config variable for the duration of the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range configEnv() {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "env only",
+			env:  map[string]string{"AZURE_OPENAI_ENDPOINT": "https://env.openai.azure.com", "AZURE_DOCUMENTDB_DATABASENAME": "EnvDB"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenAI.Endpoint != "https://env.openai.azure.com" || cfg.VectorStore.DatabaseName != "EnvDB" {
+					t.Errorf("config = %+v %+v", cfg.OpenAI, cfg.VectorStore)
+				}
+			},
+		},
+		{
+			name: "yaml file only",
+			file: "testdata/config.yaml",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenAI.Endpoint != "https://file.openai.azure.com" || cfg.OpenAI.SynthDeployment != "gpt-4o" || !cfg.OpenAI.UsePasswordless {
+					t.Errorf("openai = %+v", cfg.OpenAI)
+				}
+				if cfg.VectorStore.ClusterName != "file-cluster" || cfg.VectorStore.CollectionName != "hotels_file" || !cfg.VectorStore.UsePasswordless {
+					t.Errorf("vector store = %+v", cfg.VectorStore)
+				}
+			},
+		},
+		{
+			name: "json file only",
+			file: "testdata/config.json",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenAI.APIKey != "file-key" || cfg.OpenAI.UsePasswordless {
+					t.Errorf("openai = %+v", cfg.OpenAI)
+				}
+				if cfg.VectorStore.EmbeddedField != "Vector" || !strings.HasPrefix(cfg.VectorStore.ConnectionString, "mongodb+srv://") {
+					t.Errorf("vector store = %+v", cfg.VectorStore)
+				}
+			},
+		},
+		{
+			name: "env overrides file",
+			file: "testdata/config.yaml",
+			env:  map[string]string{"AZURE_OPENAI_ENDPOINT": "https://env.openai.azure.com", "USE_PASSWORDLESS": "false", "AZURE_DOCUMENTDB_COLLECTION": "hotels_env"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OpenAI.Endpoint != "https://env.openai.azure.com" || cfg.OpenAI.UsePasswordless {
+					t.Errorf("openai = %+v, want env values", cfg.OpenAI)
+				}
+				if cfg.VectorStore.CollectionName != "hotels_env" {
+					t.Errorf("collection = %q, want hotels_env", cfg.VectorStore.CollectionName)
+				}
+				// Values the environment doesn't set still come from the file
+				if cfg.VectorStore.DatabaseName != "Hotels" || cfg.OpenAI.PlannerDeployment != "gpt-4o-mini" {
+					t.Errorf("config = %+v %+v, want file values", cfg.OpenAI, cfg.VectorStore)
+				}
+			},
+		},
+		{
+			name: "empty env value still overrides file",
+			file: "testdata/config.yaml",
+			env:  map[string]string{"AZURE_DOCUMENTDB_CLUSTER": ""},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.VectorStore.ClusterName != "" {
+					t.Errorf("cluster = %q, want the empty env value", cfg.VectorStore.ClusterName)
+				}
+			},
+		},
+		{
+			name: "timeouts from the file",
+			file: "testdata/config.yaml",
+			env:  map[string]string{"CHAT_TIMEOUT": "90s"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Timeouts.Run != 30*time.Minute || cfg.Timeouts.Chat != 90*time.Second || cfg.Timeouts.Search != timeouts.DefaultSearch {
+					t.Errorf("timeouts = %+v, want run from the file, chat from the env, and the default search", cfg.Timeouts)
+				}
+				if cfg.OpenAI.Timeouts != cfg.Timeouts || cfg.VectorStore.Timeouts != cfg.Timeouts {
+					t.Errorf("client timeouts = %+v and %+v, want the config's", cfg.OpenAI.Timeouts, cfg.VectorStore.Timeouts)
+				}
+			},
+		},
+		{
+			name: "metrics environment defaults to the azd environment",
+			env:  map[string]string{"AZURE_ENV_NAME": "hotels-dev"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Runtime.MetricsEnvironment != "hotels-dev" {
+					t.Errorf("metrics environment = %q, want AZURE_ENV_NAME", cfg.Runtime.MetricsEnvironment)
+				}
+			},
+		},
+		{
+			name: "metrics environment overrides the azd environment",
+			env:  map[string]string{"AZURE_ENV_NAME": "hotels-dev", "METRICS_ENVIRONMENT": "staging"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Runtime.MetricsEnvironment != "staging" {
+					t.Errorf("metrics environment = %q, want METRICS_ENVIRONMENT", cfg.Runtime.MetricsEnvironment)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("openai: [unclosed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wrongType := filepath.Join(dir, "wrong.json")
+	if err := os.WriteFile(wrongType, []byte(`{"usePasswordless": "yes"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"missing file", filepath.Join(dir, "missing.yaml"), "failed to read config file"},
+		{"invalid yaml", invalid, "invalid config file"},
+		{"wrong type", wrongType, "invalid config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			if _, err := Load(tt.path); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileWarnsAboutUnknownKeys(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	file, err := ReadFile("testdata/unknown.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.OpenAI.Endpoint != "https://file.openai.azure.com" || file.DocumentDB.Database != "Hotels" {
+		t.Errorf("known keys not read: %+v", file)
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, `keys="documentdb.databse, openai.deployment, timeout"`) {
+		t.Errorf("warning does not list the unknown keys:\n%s", out)
+	}
+	if !strings.Contains(out, "documentdb.database") || !strings.Contains(out, "openai.synthDeployment") {
+		t.Errorf("warning does not list the valid keys:\n%s", out)
+	}
+}
+
+func TestReadFileKnownKeysDoNotWarn(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	for _, path := range []string{"testdata/config.yaml", "testdata/config.json"} {
+		if _, err := ReadFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if logs.Len() != 0 {
+		t.Errorf("unexpected warnings:\n%s", logs.String())
+	}
+}
+
+func TestFileApplyReportsSetVariables(t *testing.T) {
+	file, err := ReadFile("testdata/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{"AZURE_OPENAI_API_KEY": "env-key"}
+	applied := file.apply(
+		func(name string) (string, bool) { v, ok := env[name]; return v, ok },
+		func(name, value string) error { env[name] = value; return nil },
+	)
+
+	want := "USE_PASSWORDLESS,AZURE_OPENAI_ENDPOINT,AZURE_DOCUMENTDB_CONNECTION_STRING,AZURE_DOCUMENTDB_DATABASENAME,EMBEDDED_FIELD"
+	if strings.Join(applied, ",") != want {
+		t.Errorf("applied = %v, want %s", applied, want)
+	}
+	if env["AZURE_OPENAI_API_KEY"] != "env-key" || env["USE_PASSWORDLESS"] != "false" {
+		t.Errorf("env = %v", env)
+	}
+}
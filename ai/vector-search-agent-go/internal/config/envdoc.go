@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// envExampleHeader opens the generated .env.example
+const envExampleHeader = `# Environment of the vector search agent, generated from the Go definitions by
+# go run ./cmd/envdoc; don't edit it by hand. Copy it to .env and fill in the
+# required values. Commented lines show the default, or an example when there is none.
+# The sections from Queries on hold settings of single commands, whose flags override them.
+`
+
+// WriteEnvExample writes a .env.example listing every variable, by section.
+// Variables some command requires are left uncommented with an example value.
+func WriteEnvExample(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, envExampleHeader)
+	section := ""
+	for _, v := range variables() {
+		if v.Section != section {
+			section = v.Section
+			fmt.Fprintf(bw, "\n# --- %s ---\n", section)
+		}
+		fmt.Fprintf(bw, "\n# %s\n", v.Description)
+		if len(v.Required) > 0 {
+			fmt.Fprintf(bw, "# Required for: %s\n", strings.Join(v.Required, ", "))
+			fmt.Fprintf(bw, "%s=%s\n", v.Name, v.example())
+			continue
+		}
+		fmt.Fprintf(bw, "# %s=%s\n", v.Name, v.example())
+	}
+	return bw.Flush()
+}
+
+// WriteMarkdown writes a markdown table of every variable per section, for the README
+func WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	section := ""
+	for _, v := range variables() {
+		if v.Section != section {
+			if section != "" {
+				fmt.Fprintln(bw)
+			}
+			section = v.Section
+			fmt.Fprintf(bw, "#### %s\n\n", section)
+			fmt.Fprintln(bw, "| Variable | Default | Required for | Description |")
+			fmt.Fprintln(bw, "|----------|---------|--------------|-------------|")
+		}
+		fmt.Fprintf(bw, "| `%s` | %s | %s | %s |\n", v.Name, code(v.Default), strings.Join(v.Required, ", "), v.Description)
+	}
+	return bw.Flush()
+}
+
+// code formats a value as inline code, or returns "" for none
+func code(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "`" + value + "`"
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// moduleRoot is the module's directory, relative to this package
+const moduleRoot = "../.."
+
+// envReaders are the functions whose string arguments name the environment variable they
+// read: the os functions, the getenv parameters that stand in for os.Getenv, and the
+// helpers wrapping them
+var envReaders = map[string]bool{"Getenv": true, "LookupEnv": true, "getenv": true, "envInt": true, "envBool": true}
+
+// envName matches a string literal that names an environment variable
+var envName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// envReads returns the position of every literal variable name passed to an envReader in
+// the module's non-test Go files
+func envReads(t *testing.T) map[string][]string {
+	t.Helper()
+	reads := make(map[string][]string)
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(moduleRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "testdata" {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !envReaders[calledName(call.Fun)] {
+				return true
+			}
+			for _, arg := range call.Args {
+				lit, ok := arg.(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				if name, err := strconv.Unquote(lit.Value); err == nil && envName.MatchString(name) {
+					reads[name] = append(reads[name], fset.Position(lit.Pos()).String())
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reads
+}
+
+// calledName returns the name of the function fun calls, without its package or receiver
+func calledName(fun ast.Expr) string {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+func TestEveryEnvReadIsTagged(t *testing.T) {
+	described := make(map[string]bool)
+	for _, v := range Variables() {
+		described[v.Name] = true
+	}
+
+	reads := envReads(t)
+	if len(reads) == 0 {
+		t.Fatal("found no environment reads; is moduleRoot right?")
+	}
+	for name, positions := range reads {
+		if !described[name] {
+			t.Errorf("%s is read at %s but has no env tag in Config or Commands, so it is missing from .env.example and the README", name, strings.Join(positions, ", "))
+		}
+	}
+}
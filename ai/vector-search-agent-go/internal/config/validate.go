@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Requirements selects the groups of variables a command needs; the required and for
+// tags of each variable name the groups that use it. The zero value requires nothing.
+type Requirements struct {
+	// Embedding requires the Azure OpenAI endpoint and embedding deployment
+	Embedding bool
+	// Chat requires the Azure OpenAI endpoint and the planner and synthesizer deployments
+	Chat bool
+	// DocumentDB requires the database, collection, and credentials for the selected auth mode
+	DocumentDB bool
+	// VectorIndex requires the index name and valid embedding dimensions
+	VectorIndex bool
+}
+
+// Problem describes one missing or malformed environment variable
+type Problem struct {
+	Name    string
+	Message string
+	Example string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s %s (example: %s=%s)", p.Name, p.Message, p.Name, p.Example)
+}
+
+// EnvironmentError lists every problem found by ValidateEnvironment
+type EnvironmentError struct {
+	Problems []Problem
+}
+
+func (e *EnvironmentError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("%d environment problem(s):", len(e.Problems)))
+	for _, p := range e.Problems {
+		lines = append(lines, "  "+p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// groups returns the requirement groups req selects
+func (req Requirements) groups() map[string]bool {
+	return map[string]bool{
+		groupEmbedding:   req.Embedding,
+		groupChat:        req.Chat,
+		groupDocumentDB:  req.DocumentDB,
+		groupVectorIndex: req.VectorIndex,
+	}
+}
+
+// ValidateEnvironment checks the variables of Config that a command with req uses: each
+// required one must be set, and each one set must have its format. It returns an
+// *EnvironmentError listing all problems, in the order of Variables, or nil.
+func ValidateEnvironment(getenv func(string) string, req Requirements) error {
+	var problems []Problem
+	add := func(name, message string) {
+		v, _ := lookup(name)
+		problems = append(problems, Problem{Name: name, Message: message, Example: v.example()})
+	}
+
+	use := req.groups()
+	for _, v := range variables() {
+		if !v.usedBy(use) {
+			continue
+		}
+		if v.Rule != "" {
+			rules[v.Rule](getenv, add)
+			continue
+		}
+		value := getenv(v.Name)
+		if strings.TrimSpace(value) == "" {
+			if v.requiredBy(use) {
+				add(v.Name, "is required")
+			}
+			continue
+		}
+		if v.Format != "" {
+			if message := checkFormat(v.Format, value); message != "" {
+				add(v.Name, message)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &EnvironmentError{Problems: problems}
+	}
+	return nil
+}
+
+// rules check variables that depend on each other, named by the rule tag
+var rules = map[string]func(getenv func(string) string, add func(name, message string)){
+	// Matches NewVectorStore: passwordless when enabled, or when only the cluster is set
+	"documentdb-credentials": func(getenv func(string) string, add func(name, message string)) {
+		usePasswordless, _ := strconv.ParseBool(getenv("USE_PASSWORDLESS"))
+		connectionString := getenv("AZURE_DOCUMENTDB_CONNECTION_STRING")
+		switch {
+		case usePasswordless:
+			if strings.TrimSpace(getenv("AZURE_DOCUMENTDB_CLUSTER")) == "" {
+				add("AZURE_DOCUMENTDB_CLUSTER", "is required")
+			}
+		case connectionString == "" && getenv("AZURE_DOCUMENTDB_CLUSTER") == "":
+			add("AZURE_DOCUMENTDB_CONNECTION_STRING", "is required when USE_PASSWORDLESS is not enabled (or set AZURE_DOCUMENTDB_CLUSTER)")
+		case connectionString != "" && !strings.HasPrefix(connectionString, "mongodb://") && !strings.HasPrefix(connectionString, "mongodb+srv://"):
+			add("AZURE_DOCUMENTDB_CONNECTION_STRING", "must start with mongodb:// or mongodb+srv://")
+		}
+	},
+}
+
+// Formats of the format tag
+const (
+	formatBool              = "bool"
+	formatPositiveInt       = "positive-int"
+	formatNonNegativeNumber = "non-negative-number"
+	formatPositiveDuration  = "positive-duration"
+	formatHTTPSURL          = "https-url"
+	formatOneOfPrefix       = "oneof:"
+)
+
+// validFormat reports whether format is one checkFormat knows
+func validFormat(format string) bool {
+	switch format {
+	case formatBool, formatPositiveInt, formatNonNegativeNumber, formatPositiveDuration, formatHTTPSURL:
+		return true
+	}
+	choices, ok := strings.CutPrefix(format, formatOneOfPrefix)
+	return ok && choices != ""
+}
+
+// checkFormat returns what is wrong with value for format, or ""
+func checkFormat(format, value string) string {
+	switch format {
+	case formatBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("must be true or false, got %q", value)
+		}
+	case formatPositiveInt:
+		if n, err := strconv.ParseInt(value, 10, 64); err != nil || n <= 0 {
+			return fmt.Sprintf("must be a positive integer, got %q", value)
+		}
+	case formatNonNegativeNumber:
+		if n, err := strconv.ParseFloat(value, 64); err != nil || n < 0 {
+			return fmt.Sprintf("must be a non-negative number, got %q", value)
+		}
+	case formatPositiveDuration:
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Sprintf("must be a positive duration, got %q", value)
+		}
+	case formatHTTPSURL:
+		if u, err := url.Parse(value); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Sprintf("must be an https URL, got %q", value)
+		}
+	default:
+		choices := strings.Split(strings.TrimPrefix(format, formatOneOfPrefix), ",")
+		if !slices.Contains(choices, strings.ToLower(value)) {
+			return fmt.Sprintf("must be %s, got %q", orList(choices), value)
+		}
+	}
+	return ""
+}
+
+// orList joins choices as "a", "a or b", or "a, b, or c"
+func orList(choices []string) string {
+	switch len(choices) {
+	case 1:
+		return choices[0]
+	case 2:
+		return choices[0] + " or " + choices[1]
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + ", or " + choices[len(choices)-1]
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// validEnv is a complete key-based environment for every requirement
+func validEnv() map[string]string {
+	return map[string]string{
+		"AZURE_OPENAI_ENDPOINT":              "https://my-openai.openai.azure.com/",
+		"AZURE_OPENAI_EMBEDDING_DEPLOYMENT":  "text-embedding-3-small",
+		"AZURE_OPENAI_PLANNER_DEPLOYMENT":    "gpt-4o-mini",
+		"AZURE_OPENAI_SYNTH_DEPLOYMENT":      "gpt-4o",
+		"AZURE_DOCUMENTDB_CONNECTION_STRING": "mongodb+srv://user:pw@cluster.mongocluster.cosmos.azure.com/",
+		"AZURE_DOCUMENTDB_DATABASENAME":      "Hotels",
+		"AZURE_DOCUMENTDB_COLLECTION":        "hotel_data",
+		"AZURE_DOCUMENTDB_INDEX_NAME":        "vectorIndex",
+		"EMBEDDING_DIMENSIONS":               "1536",
+	}
+}
+
+var all = Requirements{Embedding: true, Chat: true, DocumentDB: true, VectorIndex: true}
+
+func TestValidateEnvironment(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    Requirements
+		change map[string]string // "" removes the variable
+		want   []string          // variable names with problems, in order
+	}{
+		{"complete", all, nil, nil},
+		{"nothing required", Requirements{}, map[string]string{"AZURE_OPENAI_ENDPOINT": "", "EMBEDDING_DIMENSIONS": "x"}, nil},
+		{"cleanup ignores OpenAI", Requirements{DocumentDB: true}, map[string]string{"AZURE_OPENAI_ENDPOINT": "", "AZURE_OPENAI_SYNTH_DEPLOYMENT": ""}, nil},
+		{"every missing variable at once", all, map[string]string{
+			"AZURE_OPENAI_ENDPOINT": "", "AZURE_OPENAI_EMBEDDING_DEPLOYMENT": "", "AZURE_OPENAI_PLANNER_DEPLOYMENT": "",
+			"AZURE_OPENAI_SYNTH_DEPLOYMENT": "", "AZURE_DOCUMENTDB_CONNECTION_STRING": "", "AZURE_DOCUMENTDB_DATABASENAME": "",
+			"AZURE_DOCUMENTDB_COLLECTION": "", "AZURE_DOCUMENTDB_INDEX_NAME": "",
+		}, []string{
+			"AZURE_OPENAI_ENDPOINT", "AZURE_OPENAI_EMBEDDING_DEPLOYMENT", "AZURE_OPENAI_PLANNER_DEPLOYMENT", "AZURE_OPENAI_SYNTH_DEPLOYMENT",
+			"AZURE_DOCUMENTDB_DATABASENAME", "AZURE_DOCUMENTDB_COLLECTION", "AZURE_DOCUMENTDB_CONNECTION_STRING", "AZURE_DOCUMENTDB_INDEX_NAME",
+		}},
+		{"http endpoint", Requirements{Embedding: true}, map[string]string{"AZURE_OPENAI_ENDPOINT": "http://my-openai.openai.azure.com"}, []string{"AZURE_OPENAI_ENDPOINT"}},
+		{"endpoint without scheme", Requirements{Chat: true}, map[string]string{"AZURE_OPENAI_ENDPOINT": "my-openai.openai.azure.com"}, []string{"AZURE_OPENAI_ENDPOINT"}},
+		{"whitespace deployment", Requirements{Embedding: true}, map[string]string{"AZURE_OPENAI_EMBEDDING_DEPLOYMENT": "  "}, []string{"AZURE_OPENAI_EMBEDDING_DEPLOYMENT"}},
+		{"zero dimensions", Requirements{VectorIndex: true}, map[string]string{"EMBEDDING_DIMENSIONS": "0"}, []string{"EMBEDDING_DIMENSIONS"}},
+		{"non-integer dimensions", Requirements{Embedding: true}, map[string]string{"EMBEDDING_DIMENSIONS": "large"}, []string{"EMBEDDING_DIMENSIONS"}},
+		{"dimensions default", Requirements{Embedding: true, VectorIndex: true}, map[string]string{"EMBEDDING_DIMENSIONS": ""}, nil},
+		{"invalid boolean", Requirements{DocumentDB: true}, map[string]string{"USE_PASSWORDLESS": "yes"}, []string{"USE_PASSWORDLESS"}},
+		{"passwordless needs cluster", Requirements{DocumentDB: true}, map[string]string{"USE_PASSWORDLESS": "true"}, []string{"AZURE_DOCUMENTDB_CLUSTER"}},
+		{"passwordless with cluster", Requirements{DocumentDB: true}, map[string]string{"USE_PASSWORDLESS": "1", "AZURE_DOCUMENTDB_CLUSTER": "my-cluster"}, nil},
+		{"cluster without connection string", Requirements{DocumentDB: true}, map[string]string{"AZURE_DOCUMENTDB_CONNECTION_STRING": "", "AZURE_DOCUMENTDB_CLUSTER": "my-cluster"}, nil},
+		{"invalid history flag", Requirements{DocumentDB: true}, map[string]string{"HISTORY_ENABLED": "sometimes"}, []string{"HISTORY_ENABLED"}},
+		{"invalid offline flag", Requirements{}, map[string]string{"OFFLINE_MODE": "maybe"}, []string{"OFFLINE_MODE"}},
+		{"max rps", Requirements{Embedding: true}, map[string]string{"AZURE_OPENAI_MAX_RPS": "2.5"}, nil},
+		{"negative max rps", Requirements{Chat: true}, map[string]string{"AZURE_OPENAI_MAX_RPS": "-1"}, []string{"AZURE_OPENAI_MAX_RPS"}},
+		{"non-numeric max rps", Requirements{Embedding: true}, map[string]string{"AZURE_OPENAI_MAX_RPS": "fast"}, []string{"AZURE_OPENAI_MAX_RPS"}},
+		{"data url settings", Requirements{}, map[string]string{"DATA_URL_TIMEOUT": "2m", "DATA_URL_MAX_BYTES": "1048576"}, nil},
+		{"invalid data url settings", Requirements{}, map[string]string{"DATA_URL_TIMEOUT": "-1s", "DATA_URL_MAX_BYTES": "1MB"}, []string{"DATA_URL_TIMEOUT", "DATA_URL_MAX_BYTES"}},
+		{"data format", Requirements{}, map[string]string{"DATA_FORMAT": "CSV", "DATA_LENIENT": "true"}, nil},
+		{"jsonl data format", Requirements{}, map[string]string{"DATA_FORMAT": "jsonl"}, nil},
+		{"invalid data format", Requirements{}, map[string]string{"DATA_FORMAT": "xml", "DATA_LENIENT": "maybe"}, []string{"DATA_FORMAT", "DATA_LENIENT"}},
+		{"log format", Requirements{}, map[string]string{"LOG_FORMAT": "xml"}, []string{"LOG_FORMAT"}},
+		{"index tuning unchecked without the index", Requirements{DocumentDB: true}, map[string]string{"HNSW_M": "many"}, nil},
+		{"invalid index tuning", Requirements{VectorIndex: true}, map[string]string{"HNSW_M": "many", "IVF_NUM_LISTS": "0"}, []string{"IVF_NUM_LISTS", "HNSW_M"}},
+		{"invalid exact search limit", Requirements{DocumentDB: true}, map[string]string{"EXACT_SEARCH_MAX_DOCUMENTS": "-5"}, []string{"EXACT_SEARCH_MAX_DOCUMENTS"}},
+		{"malformed connection string", Requirements{DocumentDB: true}, map[string]string{"AZURE_DOCUMENTDB_CONNECTION_STRING": "Server=localhost"}, []string{"AZURE_DOCUMENTDB_CONNECTION_STRING"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := validEnv()
+			for name, value := range tt.change {
+				if value == "" {
+					delete(env, name)
+				} else {
+					env[name] = value
+				}
+			}
+
+			err := ValidateEnvironment(func(name string) string { return env[name] }, tt.req)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var envErr *EnvironmentError
+			if !errors.As(err, &envErr) {
+				t.Fatalf("err = %v, want *EnvironmentError", err)
+			}
+			var got []string
+			for _, p := range envErr.Problems {
+				got = append(got, p.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("problems = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentErrorMessage(t *testing.T) {
+	env := map[string]string{"AZURE_OPENAI_ENDPOINT": "http://example.com"}
+	err := ValidateEnvironment(func(name string) string { return env[name] }, Requirements{Embedding: true})
+
+	want := "2 environment problem(s):\n" +
+		"  AZURE_OPENAI_ENDPOINT must be an https URL, got \"http://example.com\" (example: AZURE_OPENAI_ENDPOINT=https://my-openai.openai.azure.com/)\n" +
+		"  AZURE_OPENAI_EMBEDDING_DEPLOYMENT is required (example: AZURE_OPENAI_EMBEDDING_DEPLOYMENT=text-embedding-3-small)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error =\n%v\nwant\n%s", err, want)
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Requirement groups named by the required and for tags, one per Requirements field
+const (
+	groupEmbedding   = "embedding"
+	groupChat        = "chat"
+	groupDocumentDB  = "documentdb"
+	groupVectorIndex = "vectorindex"
+)
+
+// Variable describes one environment variable. It is read from the struct tags of a
+// field of the Config or Commands sections:
+//
+//	env       the variable's name, or "-" for a field not read from the environment
+//	default   the value used when it is unset
+//	example   a value to show in .env.example and in validation problems
+//	required  the requirement groups that need it set
+//	for       further groups whose commands use it; a variable tagged with neither
+//	          required nor for is used by every command
+//	format    the form a set value must have: bool, positive-int, non-negative-number,
+//	          positive-duration, https-url, or oneof:a,b,c
+//	rule      a check of several variables at once, replacing required and format
+//	desc      what it does
+//
+// A variable read into two fields, like USE_PASSWORDLESS, is described by the first;
+// the second carries only its env tag.
+type Variable struct {
+	Name        string
+	Section     string
+	Default     string
+	Example     string
+	Required    []string
+	For         []string
+	Format      string
+	Rule        string
+	Description string
+}
+
+// usedBy reports whether a command with the requirement groups in use reads v
+func (v Variable) usedBy(use map[string]bool) bool {
+	if len(v.Required) == 0 && len(v.For) == 0 {
+		return true
+	}
+	return v.requiredBy(use) || slices.ContainsFunc(v.For, func(g string) bool { return use[g] })
+}
+
+// requiredBy reports whether a command with the requirement groups in use needs v set
+func (v Variable) requiredBy(use map[string]bool) bool {
+	return slices.ContainsFunc(v.Required, func(g string) bool { return use[g] })
+}
+
+// example returns the value to show for v
+func (v Variable) example() string {
+	if v.Example != "" {
+		return v.Example
+	}
+	return v.Default
+}
+
+// variables lists the variables of Config and Commands once; untagged fields are a
+// programming error that TestConfigTagsComplete catches
+var variables = sync.OnceValue(func() []Variable {
+	vars, err := variablesOf(reflect.TypeFor[Config](), reflect.TypeFor[Commands]())
+	if err != nil {
+		panic(err)
+	}
+	return vars
+})
+
+// Variables returns every environment variable of Config, then of Commands, in the order
+// of their sections and the sections' fields
+func Variables() []Variable {
+	return slices.Clone(variables())
+}
+
+// lookup returns the variable named name
+func lookup(name string) (Variable, bool) {
+	i := slices.IndexFunc(variables(), func(v Variable) bool { return v.Name == name })
+	if i < 0 {
+		return Variable{}, false
+	}
+	return variables()[i], true
+}
+
+// variablesOf reads the variables of configs, structs whose fields are sections: structs,
+// or pointers to them, tagged with a section name. Every field of a section must have an
+// env tag, so a setting added without one is caught; the error lists every such field
+// and every malformed tag.
+func variablesOf(configs ...reflect.Type) ([]Variable, error) {
+	var vars []Variable
+	var errs []error
+	seen := make(map[string]bool)
+	for _, config := range configs {
+		for i := range config.NumField() {
+			section := config.Field(i)
+			name := section.Tag.Get("section")
+			typ := section.Type
+			if typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			if name == "" || typ.Kind() != reflect.Struct {
+				errs = append(errs, fmt.Errorf("%s.%s: not a struct with a section tag", config.Name(), section.Name))
+				continue
+			}
+
+			for j := range typ.NumField() {
+				field := typ.Field(j)
+				env, ok := field.Tag.Lookup("env")
+				where := typ.Name() + "." + field.Name
+				switch {
+				case !ok || env == "":
+					errs = append(errs, fmt.Errorf("%s: no env tag; name its environment variable, or tag it env:\"-\"", where))
+					continue
+				case env == "-" || seen[env]:
+					continue
+				}
+				seen[env] = true
+
+				v := Variable{
+					Name:        env,
+					Section:     name,
+					Default:     field.Tag.Get("default"),
+					Example:     field.Tag.Get("example"),
+					Required:    splitTag(field.Tag.Get("required")),
+					For:         splitTag(field.Tag.Get("for")),
+					Format:      field.Tag.Get("format"),
+					Rule:        field.Tag.Get("rule"),
+					Description: field.Tag.Get("desc"),
+				}
+				if err := v.check(); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", where, err))
+					continue
+				}
+				vars = append(vars, v)
+			}
+		}
+	}
+	return vars, errors.Join(errs...)
+}
+
+// check reports the problems of v's tags
+func (v Variable) check() error {
+	var errs []error
+	if v.Description == "" {
+		errs = append(errs, fmt.Errorf("%s has no desc tag", v.Name))
+	}
+	for _, group := range slices.Concat(v.Required, v.For) {
+		if !slices.Contains([]string{groupEmbedding, groupChat, groupDocumentDB, groupVectorIndex}, group) {
+			errs = append(errs, fmt.Errorf("%s: unknown requirement group %q", v.Name, group))
+		}
+	}
+	if v.Format != "" && !validFormat(v.Format) {
+		errs = append(errs, fmt.Errorf("%s: unknown format %q", v.Name, v.Format))
+	}
+	if v.Rule != "" && rules[v.Rule] == nil {
+		errs = append(errs, fmt.Errorf("%s: unknown rule %q", v.Name, v.Rule))
+	}
+	if v.Format != "" && v.Default != "" && checkFormat(v.Format, v.Default) != "" {
+		errs = append(errs, fmt.Errorf("%s: default %q is not a valid %s", v.Name, v.Default, v.Format))
+	}
+	return errors.Join(errs...)
+}
+
+// splitTag splits a comma-separated tag value
+func splitTag(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
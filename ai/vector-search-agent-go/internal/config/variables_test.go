@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// taggedFields returns the env tag of every field of the sections of configs, "-" ones
+// excluded, walking the structs independently of variablesOf
+func taggedFields(configs ...reflect.Type) []string {
+	var names []string
+	for _, config := range configs {
+		for i := range config.NumField() {
+			typ := config.Field(i).Type
+			if typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			for j := range typ.NumField() {
+				if env := typ.Field(j).Tag.Get("env"); env != "" && env != "-" {
+					names = append(names, env)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestConfigTagsComplete(t *testing.T) {
+	vars, err := variablesOf(reflect.TypeFor[Config](), reflect.TypeFor[Commands]())
+	if err != nil {
+		t.Fatalf("config tags are incomplete:\n%v", err)
+	}
+	described := make(map[string]bool)
+	for _, v := range vars {
+		described[v.Name] = true
+	}
+	for _, name := range taggedFields(reflect.TypeFor[Config](), reflect.TypeFor[Commands]()) {
+		if !described[name] {
+			t.Errorf("%s is tagged but not in Variables", name)
+		}
+	}
+}
+
+func TestVariablesOfRejectsIncompleteTags(t *testing.T) {
+	type section struct {
+		Tagged    string `env:"TAGGED" desc:"a tagged setting"`
+		Untagged  string
+		Skipped   string `env:"-"`
+		NoDesc    int    `env:"NO_DESC"`
+		BadFormat int    `env:"BAD_FORMAT" format:"integer" desc:"a setting"`
+		BadGroup  bool   `env:"BAD_GROUP" for:"database" desc:"a setting"`
+		BadValue  int    `env:"BAD_DEFAULT" format:"positive-int" default:"none" desc:"a setting"`
+	}
+	type config struct {
+		Section    *section `section:"Section"`
+		NotSection string
+	}
+
+	vars, err := variablesOf(reflect.TypeFor[config]())
+	if err == nil {
+		t.Fatal("variablesOf accepted incomplete tags")
+	}
+	for _, want := range []string{
+		"section.Untagged: no env tag",
+		"config.NotSection: not a struct with a section tag",
+		"NO_DESC has no desc tag",
+		`BAD_FORMAT: unknown format "integer"`,
+		`BAD_GROUP: unknown requirement group "database"`,
+		`BAD_DEFAULT: default "none" is not a valid positive-int`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error is missing %q:\n%v", want, err)
+		}
+	}
+	if len(vars) != 1 || vars[0].Name != "TAGGED" || vars[0].Section != "Section" {
+		t.Errorf("variables = %+v, want only TAGGED", vars)
+	}
+}
+
+func TestGeneratedDocsListEveryVariable(t *testing.T) {
+	var env, markdown bytes.Buffer
+	if err := WriteEnvExample(&env); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMarkdown(&markdown); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range Variables() {
+		line := "# " + v.Name + "="
+		if len(v.Required) > 0 {
+			// Required variables are left uncommented, ready to fill in
+			line = "\n" + v.Name + "="
+		}
+		if !strings.Contains(env.String(), line+v.example()+"\n") {
+			t.Errorf(".env.example is missing %q", line+v.example())
+		}
+		if !strings.Contains(markdown.String(), "| `"+v.Name+"` |") {
+			t.Errorf("markdown is missing %s", v.Name)
+		}
+	}
+	for _, section := range []string{"Azure OpenAI", "Azure DocumentDB", "Vector index", "Data files", "Data file URLs", "Timeouts", "Planner agent", "Synthesizer agent", "Tracing", "Runtime", "Queries", "Upload", "Batch", "Serve", "Embedding migration"} {
+		if !strings.Contains(env.String(), "# --- "+section+" ---\n") || !strings.Contains(markdown.String(), "#### "+section+"\n") {
+			t.Errorf("section %s is missing", section)
+		}
+	}
+}
+
+func TestDefaultsMatchLoaders(t *testing.T) {
+	for _, v := range Variables() {
+		t.Setenv(v.Name, "")
+		os.Unsetenv(v.Name)
+	}
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare each default tag with the value the loaders chose
+	loaded := reflect.ValueOf(*cfg)
+	for i := range loaded.NumField() {
+		section := reflect.Indirect(loaded.Field(i))
+		for j := range section.NumField() {
+			field := section.Type().Field(j)
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			if got, want := section.Field(j).Interface(), parseDefault(t, def, section.Field(j).Interface()); got != want {
+				t.Errorf("%s loads as %v with nothing set, but its default tag says %q", field.Tag.Get("env"), got, def)
+			}
+		}
+	}
+}
+
+// parseDefault reads def as a value of like's type
+func parseDefault(t *testing.T, def string, like any) any {
+	t.Helper()
+	var value any
+	var err error
+	switch like.(type) {
+	case string:
+		value = def
+	case bool:
+		value, err = strconv.ParseBool(def)
+	case int:
+		value, err = strconv.Atoi(def)
+	case int64:
+		value, err = strconv.ParseInt(def, 10, 64)
+	case float64:
+		value, err = strconv.ParseFloat(def, 64)
+	case time.Duration:
+		value, err = time.ParseDuration(def)
+	default:
+		t.Fatalf("no parser for a default of %T", like)
+	}
+	if err != nil {
+		t.Fatalf("default %q: %v", def, err)
+	}
+	return value
+}
@@ -0,0 +1,195 @@
+// Package hints attaches remediation hints to the failures new users hit most: missing
+// permissions, a firewall blocking their IP, vectors of the wrong dimensions, a missing
+// vector index, and an expired Azure sign-in. The raw driver and SDK errors say what
+// failed but not what to do about it; a hint says what to check and links to the docs.
+package hints
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/openai/openai-go/v3"
+)
+
+// Kinds of failure that have a hint
+const (
+	KindTokenExpired = "token_expired"
+	KindRBAC         = "rbac"
+	KindFirewall     = "firewall"
+	KindDimensions   = "dimensions"
+	KindIndexMissing = "index_missing"
+)
+
+// readme is the README of the sample, whose Troubleshooting sections the hints link to
+const readme = "https://github.com/Azure-Samples/documentdb-samples/blob/main/ai/vector-search-agent-go/README.md"
+
+// Error is a failure with a hint on what to check. It reports the error it wraps
+// unchanged, and errors.Unwrap returns it, so errors.Is and errors.As see through the hint.
+type Error struct {
+	Err error
+	// Kind is one of the Kind constants
+	Kind string
+	// Hint says what to check, in a sentence or two
+	Hint string
+	// DocURL links to the documentation of the fix
+	DocURL string
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// hint is one entry of the catalog: the failures it matches and what to tell the user
+type hint struct {
+	kind    string
+	matches func(error) bool
+	text    string
+	docURL  string
+}
+
+// catalog lists the hints in the order they are tried. An expired sign-in is also an
+// authentication failure, so it is tried before the permission hints.
+var catalog = []hint{
+	{
+		kind:    KindTokenExpired,
+		matches: tokenExpired,
+		text:    "Your Azure sign-in could not provide a token, most often because it expired. Sign in again with az login (or azd auth login), in the tenant of the resources, and rerun the command.",
+		docURL:  readme + "#expired-azure-sign-in",
+	},
+	{
+		kind:    KindRBAC,
+		matches: clusterAccessDenied,
+		text:    "The cluster rejected your identity. With USE_PASSWORDLESS=true, the signed-in identity must be a user of the cluster with a role on the database; otherwise check the user name and password of AZURE_DOCUMENTDB_CONNECTION_STRING.",
+		docURL:  readme + "#permission-denied",
+	},
+	{
+		kind:    KindRBAC,
+		matches: openAIAccessDenied,
+		text:    "Azure OpenAI rejected your identity. With USE_PASSWORDLESS=true, grant it the Cognitive Services OpenAI User role on the Azure OpenAI resource (role assignments take a few minutes to apply); otherwise check AZURE_OPENAI_API_KEY.",
+		docURL:  readme + "#permission-denied",
+	},
+	{
+		kind:    KindIndexMissing,
+		matches: indexMissing,
+		text:    "The collection has no vector index on the searched field. Create it with go run ./cmd/upload, and check that AZURE_DOCUMENTDB_COLLECTION names the collection you uploaded to.",
+		docURL:  readme + "#missing-vector-index",
+	},
+	{
+		kind:    KindDimensions,
+		matches: dimensionMismatch,
+		text:    "The vectors don't have the dimensions expected. EMBEDDING_DIMENSIONS must match the embedding deployment (1536 for text-embedding-3-small and text-embedding-ada-002), AZURE_OPENAI_EMBEDDING_DIMENSIONS when set, and the vector index; rebuild the index with go run ./cmd/reindex after changing it.",
+		docURL:  readme + "#embedding-dimensions",
+	},
+	{
+		kind:    KindFirewall,
+		matches: vectorstore.IsUnreachableError,
+		text:    "The cluster could not be reached. Check that its firewall allows your public IP address, that the cluster is running, and that the connection string or AZURE_DOCUMENTDB_CLUSTER names it.",
+		docURL:  "https://learn.microsoft.com/azure/documentdb/how-to-configure-firewall#grant-access-from-your-ip-address",
+	},
+}
+
+// Attach returns err wrapped in an *Error with the hint of the first failure it matches.
+// It returns err unchanged when it is nil, matches none, or already has a hint.
+func Attach(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := Of(err); ok {
+		return err
+	}
+	for _, h := range catalog {
+		if h.matches(err) {
+			return &Error{Err: err, Kind: h.kind, Hint: h.text, DocURL: h.docURL}
+		}
+	}
+	return err
+}
+
+// Of returns the hint attached to err
+func Of(err error) (*Error, bool) {
+	var hinted *Error
+	if errors.As(err, &hinted) {
+		return hinted, true
+	}
+	return nil, false
+}
+
+// Lookup returns the hint for err: the one attached to it, or the one it matches
+func Lookup(err error) (*Error, bool) {
+	return Of(Attach(err))
+}
+
+// Write writes the "What to check" block of the hint for err, if it has one
+func Write(w io.Writer, err error) {
+	h, ok := Lookup(err)
+	if !ok {
+		return
+	}
+	fmt.Fprintf(w, "\nWhat to check:\n  %s\n  See: %s\n", h.Hint, h.DocURL)
+}
+
+// tokenExpiredMessages are fragments of the errors of a sign-in that can no longer
+// provide tokens. Azure Identity reports most of them without a type of their own.
+var tokenExpiredMessages = []string{
+	"az login",
+	"azd auth login",
+	"aadsts700082", // the refresh token has expired due to inactivity
+	"aadsts50173",  // the grant has expired because it was revoked
+	"token is expired",
+	"token has expired",
+	"expired token",
+}
+
+// tokenExpired reports whether err means Azure Identity could not get a token, whether
+// the driver's OIDC callback or the Azure OpenAI client asked for it
+func tokenExpired(err error) bool {
+	var failed *azidentity.AuthenticationFailedError
+	var required *azidentity.AuthenticationRequiredError
+	if errors.As(err, &failed) || errors.As(err, &required) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, fragment := range tokenExpiredMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterAccessDenied reports whether the cluster rejected the credentials. A data URL
+// refusing a download is an authorization failure too, but not of the cluster.
+func clusterAccessDenied(err error) bool {
+	return vectorstore.IsAuthError(err) && !errors.Is(err, vectorstore.ErrSourceUnauthorized)
+}
+
+// openAIAccessDenied reports whether Azure OpenAI rejected the credentials
+func openAIAccessDenied(err error) bool {
+	code := clients.StatusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// indexMissing reports whether a vector search failed for want of a vector index
+func indexMissing(err error) bool {
+	return errors.Is(err, vectorstore.ErrIndexMissing) || vectorstore.IsIndexMissingError(err)
+}
+
+// dimensionMismatch reports whether vectors of the wrong dimensions were stored,
+// searched for, or asked of an embedding model that doesn't support the dimensions
+func dimensionMismatch(err error) bool {
+	if vectorstore.IsDimensionMismatchError(err) {
+		return true
+	}
+	var apiErr *openai.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(apiErr.Message), "dimensions")
+}
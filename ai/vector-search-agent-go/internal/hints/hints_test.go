@@ -0,0 +1,145 @@
+package hints
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/openai/openai-go/v3"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// apiError returns an Azure OpenAI error as the client reports it
+func apiError(status int, message string) *openai.Error {
+	return &openai.Error{
+		StatusCode: status,
+		Message:    message,
+		Request:    &http.Request{Method: http.MethodPost, URL: &url.URL{Path: "/openai/deployments/embeddings"}},
+		Response:   &http.Response{StatusCode: status},
+	}
+}
+
+func TestAttach(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"OIDC token", fmt.Errorf("failed to connect: %w", &azidentity.AuthenticationFailedError{}), KindTokenExpired},
+		{"refresh token expired", errors.New("DefaultAzureCredential: failed to acquire a token.\n\tAzureCLICredential: ERROR: AADSTS700082: The refresh token has expired due to inactivity."), KindTokenExpired},
+		{"not signed in", fmt.Errorf("failed to generate embedding: %w", errors.New("AzureCLICredential: Please run 'az login' to set up an account")), KindTokenExpired},
+		{"cluster unauthorized", fmt.Errorf("insert: %w", mongo.CommandError{Code: 13, Name: "Unauthorized", Message: "not authorized on hotels to execute command"}), KindRBAC},
+		{"cluster authentication failed", fmt.Errorf("failed to ping: %w", mongo.CommandError{Code: 18, Name: "AuthenticationFailed"}), KindRBAC},
+		{"OpenAI forbidden", fmt.Errorf("failed to generate embedding: %w", apiError(http.StatusForbidden, "The principal lacks the required data action")), KindRBAC},
+		{"OpenAI unauthorized", apiError(http.StatusUnauthorized, "Access denied due to invalid subscription key"), KindRBAC},
+		{"index not found", fmt.Errorf("vector search failed: %w", mongo.CommandError{Code: 2, Message: "Similarity index was not found for a vector similarity search query."}), KindIndexMissing},
+		{"index missing", fmt.Errorf("search: %w on DescriptionVector: create it with cmd/upload", vectorstore.ErrIndexMissing), KindIndexMissing},
+		{"stored vectors", fmt.Errorf("upload: %w", vectorstore.ValidateVectorDimensions(models.Hotel{HotelID: "2", DescriptionVector: make([]float32, 3)}, 1536)), KindDimensions},
+		{"query vector", fmt.Errorf("vector search failed: %w", mongo.CommandError{Code: 2, Message: "Query vector dimensions 768 do not match the index dimensions 1536"}), KindDimensions},
+		{"model dimensions", apiError(http.StatusBadRequest, "This model does not support specifying dimensions."), KindDimensions},
+		{"server selection", fmt.Errorf("failed to ping: %w", topology.ServerSelectionError{Wrapped: errors.New("connection() error occurred during connection handshake: i/o timeout")}), KindFirewall},
+		{"connection refused", mongo.CommandError{Labels: []string{"NetworkError"}, Wrapped: errors.New("dial tcp: connection refused")}, KindFirewall},
+		{"data URL unauthorized", fmt.Errorf("download: %w", vectorstore.ErrSourceUnauthorized), ""},
+		{"OpenAI throttled", apiError(http.StatusTooManyRequests, "Rate limit exceeded"), ""},
+		{"OpenAI bad request", apiError(http.StatusBadRequest, "Invalid value for 'temperature'"), ""},
+		{"deadline", fmt.Errorf("agent run: %w", context.DeadlineExceeded), ""},
+		{"other invalid data", fmt.Errorf("%w: hotel 2 has no description", vectorstore.ErrInvalidData), ""},
+		{"plain", errors.New("boom"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Attach(tt.err)
+			h, ok := Of(got)
+			if tt.want == "" {
+				if ok || got != tt.err {
+					t.Fatalf("Attach(%v) = %#v, want it unchanged", tt.err, got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Attach(%v) has no hint, want %s", tt.err, tt.want)
+			}
+			if h.Kind != tt.want || h.Hint == "" || !strings.HasPrefix(h.DocURL, "https://") {
+				t.Errorf("hint = %+v, want kind %s with a hint and a doc link", h, tt.want)
+			}
+		})
+	}
+}
+
+func TestHintKeepsTheError(t *testing.T) {
+	raw := fmt.Errorf("vector search failed: %w", vectorstore.ErrIndexMissing)
+	hinted := Attach(raw)
+
+	if hinted.Error() != raw.Error() {
+		t.Errorf("Error() = %q, want the underlying %q", hinted.Error(), raw.Error())
+	}
+	if errors.Unwrap(hinted) != raw {
+		t.Errorf("errors.Unwrap = %v, want the underlying error", errors.Unwrap(hinted))
+	}
+	if !errors.Is(hinted, vectorstore.ErrIndexMissing) {
+		t.Error("errors.Is doesn't see the sentinel through the hint")
+	}
+	var cmdErr mongo.CommandError
+	if !errors.As(Attach(mongo.CommandError{Code: 13}), &cmdErr) || cmdErr.Code != 13 {
+		t.Errorf("errors.As = %v, want the command error", cmdErr)
+	}
+
+	// A hint is attached once, however often the error passes through Attach
+	wrapped := fmt.Errorf("run: %w", hinted)
+	if again := Attach(wrapped); again != wrapped {
+		t.Errorf("Attach of a hinted error = %#v, want it unchanged", again)
+	}
+	if h, ok := Lookup(wrapped); !ok || h != hinted {
+		t.Errorf("Lookup = %v, %v, want the attached hint", h, ok)
+	}
+	if Attach(nil) != nil {
+		t.Error("Attach(nil) != nil")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	Write(&buf, fmt.Errorf("failed to ping: %w", topology.ServerSelectionError{}))
+	got := buf.String()
+	for _, want := range []string{"\nWhat to check:\n", "firewall allows your public IP address", "See: https://learn.microsoft.com/azure/documentdb/how-to-configure-firewall"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("block is missing %q:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	Write(&buf, errors.New("boom"))
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q for an error without a hint", buf.String())
+	}
+}
+
+func TestDocLinksResolve(t *testing.T) {
+	data, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchors := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if heading, ok := strings.CutPrefix(line, "### "); ok {
+			anchors[strings.ReplaceAll(strings.ToLower(heading), " ", "-")] = true
+		}
+	}
+	for _, h := range catalog {
+		anchor, ok := strings.CutPrefix(h.docURL, readme+"#")
+		if ok && !anchors[anchor] {
+			t.Errorf("%s hint links to #%s, which is not a section of the README", h.kind, anchor)
+		}
+	}
+}
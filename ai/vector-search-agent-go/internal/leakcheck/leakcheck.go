@@ -0,0 +1,162 @@
+// Package leakcheck catches goroutines, DocumentDB connections, and server cursors that
+// outlive the work that started them. A Checker snapshots what is in use when it starts
+// and reports what is left over once the work has finished and settled. Commands check
+// themselves on exit when LEAK_CHECK is true; tests call Verify.
+package leakcheck
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of a Checker
+const (
+	// DefaultTolerance is how many goroutines may outlive the snapshot: ones the
+	// runtime and libraries start once and keep, such as the os/signal watcher
+	DefaultTolerance = 2
+	// DefaultSettle is how long Check waits for finishing goroutines to exit
+	DefaultSettle = 2 * time.Second
+)
+
+// pollInterval is how often Check looks again while the goroutines settle
+const pollInterval = 10 * time.Millisecond
+
+// Enabled reports whether LEAK_CHECK is true
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("LEAK_CHECK"))
+	return enabled
+}
+
+// Checker compares the goroutines and DocumentDB resources in use with the snapshot
+// taken by Start
+type Checker struct {
+	// Tolerance is how many new goroutines Check accepts
+	Tolerance int
+	// Settle is how long Check waits for the goroutines to wind down
+	Settle time.Duration
+
+	monitor    *Monitor
+	goroutines map[string]bool
+	stats      Stats
+}
+
+// Start snapshots the goroutines running now and the resources monitor has seen in use
+func Start(monitor *Monitor) *Checker {
+	return &Checker{
+		Tolerance:  DefaultTolerance,
+		Settle:     DefaultSettle,
+		monitor:    monitor,
+		goroutines: goroutineIDs(goroutineStacks()),
+		stats:      monitor.Stats(),
+	}
+}
+
+// Check returns an error describing what is in use beyond the snapshot: more new
+// goroutines than the tolerance, with their stacks, connections checked out or left
+// open, and cursors left open on the server. It gives the goroutines up to Settle to
+// exit first. Call it once the work is done and its resources should be released.
+func (c *Checker) Check() error {
+	deadline := time.Now().Add(c.Settle)
+	var leaked []string
+	for {
+		leaked = c.newGoroutines()
+		if len(leaked) <= c.Tolerance || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+
+	var problems []string
+	stats := c.monitor.Stats()
+	if n := stats.CheckedOut - c.stats.CheckedOut; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d DocumentDB connection(s) still checked out", n))
+	}
+	if n := stats.Connections - c.stats.Connections; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d DocumentDB connection(s) still open; is the store closed?", n))
+	}
+	if n := stats.Cursors - c.stats.Cursors; n > 0 {
+		problems = append(problems, fmt.Sprintf("%d cursor(s) left open on the server", n))
+	}
+	if len(leaked) > c.Tolerance {
+		problems = append(problems, fmt.Sprintf("%d goroutine(s) started and still running, %d tolerated:\n\n%s",
+			len(leaked), c.Tolerance, strings.Join(leaked, "\n\n")))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("leak check failed: %s", strings.Join(problems, "; "))
+}
+
+// newGoroutines returns the stacks of the goroutines that weren't running at the
+// snapshot, other than the caller's
+func (c *Checker) newGoroutines() []string {
+	self := goroutineID(goroutineStack())
+	var leaked []string
+	for _, stack := range goroutineStacks() {
+		if id := goroutineID(stack); !c.goroutines[id] && id != self {
+			leaked = append(leaked, stack)
+		}
+	}
+	return leaked
+}
+
+// TB is the part of testing.TB Verify uses
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+// Verify checks for leaks once the test and its other cleanups have finished, against
+// the goroutines and Default resources in use when it is called. Call it first in the
+// test. Tests that verify must not run in parallel, since their goroutines would mix.
+func Verify(t TB) {
+	t.Helper()
+	checker := Start(Default())
+	t.Cleanup(func() {
+		if err := checker.Check(); err != nil {
+			t.Errorf("%v", err)
+		}
+	})
+}
+
+// goroutineStacks returns the stack of every goroutine
+func goroutineStacks() []string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return strings.Split(strings.TrimSpace(string(buf[:n])), "\n\n")
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// goroutineStack returns the stack of the calling goroutine
+func goroutineStack() string {
+	buf := make([]byte, 1024)
+	return string(buf[:runtime.Stack(buf, false)])
+}
+
+// goroutineIDs returns the IDs of the goroutines of stacks
+func goroutineIDs(stacks []string) map[string]bool {
+	ids := make(map[string]bool, len(stacks))
+	for _, stack := range stacks {
+		ids[goroutineID(stack)] = true
+	}
+	return ids
+}
+
+// goroutineID returns the ID in the "goroutine 12 [running]:" header of a stack
+func goroutineID(stack string) string {
+	header, _, _ := strings.Cut(stack, "\n")
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
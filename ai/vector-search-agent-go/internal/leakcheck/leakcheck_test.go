@@ -0,0 +1,196 @@
+package leakcheck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// blockUntil parks a goroutine until release is closed
+func blockUntil(release chan struct{}) {
+	<-release
+}
+
+func TestCheckerFindsLeakedGoroutines(t *testing.T) {
+	checker := Start(NewMonitor())
+	checker.Tolerance = 0
+	checker.Settle = 50 * time.Millisecond
+
+	release := make(chan struct{})
+	go blockUntil(release)
+
+	err := checker.Check()
+	if err == nil || !strings.Contains(err.Error(), "1 goroutine(s) started and still running") || !strings.Contains(err.Error(), "leakcheck.blockUntil") {
+		t.Errorf("Check() = %v, want the leaked goroutine and its stack", err)
+	}
+
+	close(release)
+	if err := checker.Check(); err != nil {
+		t.Errorf("Check() after the goroutine exited = %v", err)
+	}
+}
+
+func TestCheckerWaitsForGoroutinesToFinish(t *testing.T) {
+	checker := Start(NewMonitor())
+	checker.Tolerance = 0
+	go time.Sleep(20 * time.Millisecond)
+
+	if err := checker.Check(); err != nil {
+		t.Errorf("Check() = %v, want the finishing goroutine waited for", err)
+	}
+}
+
+func TestCheckerTolerance(t *testing.T) {
+	checker := Start(NewMonitor())
+	checker.Tolerance = 1
+	checker.Settle = 0
+
+	release := make(chan struct{})
+	defer close(release)
+	go blockUntil(release)
+
+	if err := checker.Check(); err != nil {
+		t.Errorf("Check() = %v, want one goroutine tolerated", err)
+	}
+}
+
+// marshal returns doc as raw BSON
+func marshal(t *testing.T, doc bson.D) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// monitorHarness replays command events to a monitor
+type monitorHarness struct {
+	t         *testing.T
+	monitor   *event.CommandMonitor
+	requestID int64
+}
+
+// run sends the started event of command and its reply, or a failure when reply is nil
+func (h *monitorHarness) run(name string, command, reply bson.D) {
+	h.t.Helper()
+	h.requestID++
+	ctx := context.Background()
+	h.monitor.Started(ctx, &event.CommandStartedEvent{CommandName: name, RequestID: h.requestID, Command: marshal(h.t, command)})
+	finished := event.CommandFinishedEvent{CommandName: name, RequestID: h.requestID}
+	if reply == nil {
+		h.monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished, Failure: "CursorNotFound"})
+		return
+	}
+	h.monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished, Reply: marshal(h.t, reply)})
+}
+
+// cursorReply is a find, aggregate, or getMore reply naming cursor id
+func cursorReply(id int64) bson.D {
+	return bson.D{{Key: "cursor", Value: bson.D{{Key: "id", Value: id}, {Key: "firstBatch", Value: bson.A{}}}}, {Key: "ok", Value: 1}}
+}
+
+func TestMonitorCursors(t *testing.T) {
+	monitor := NewMonitor()
+	h := &monitorHarness{t: t, monitor: monitor.CommandMonitor()}
+	cursors := func() int { return monitor.Stats().Cursors }
+
+	// A search whose results fit the first batch leaves no cursor
+	h.run("aggregate", bson.D{{Key: "aggregate", Value: "hotels"}}, cursorReply(0))
+	if n := cursors(); n != 0 {
+		t.Fatalf("cursors after an exhausted aggregate = %d, want 0", n)
+	}
+
+	h.run("find", bson.D{{Key: "find", Value: "hotels"}}, cursorReply(7))
+	h.run("getMore", bson.D{{Key: "getMore", Value: int64(7)}}, cursorReply(7))
+	if n := cursors(); n != 1 {
+		t.Fatalf("cursors after a partial getMore = %d, want 1", n)
+	}
+	h.run("getMore", bson.D{{Key: "getMore", Value: int64(7)}}, cursorReply(0))
+	if n := cursors(); n != 0 {
+		t.Fatalf("cursors after the last getMore = %d, want 0", n)
+	}
+
+	h.run("aggregate", bson.D{{Key: "aggregate", Value: "hotels"}}, cursorReply(8))
+	h.run("aggregate", bson.D{{Key: "aggregate", Value: "hotels"}}, cursorReply(9))
+	h.run("killCursors", bson.D{{Key: "killCursors", Value: "hotels"}, {Key: "cursors", Value: bson.A{int64(8)}}}, bson.D{{Key: "ok", Value: 1}})
+	if n := cursors(); n != 1 {
+		t.Fatalf("cursors after killing one of two = %d, want 1", n)
+	}
+	h.run("getMore", bson.D{{Key: "getMore", Value: int64(9)}}, nil)
+	if n := cursors(); n != 0 {
+		t.Fatalf("cursors after a failed getMore = %d, want 0", n)
+	}
+}
+
+func TestMonitorPool(t *testing.T) {
+	monitor := NewMonitor()
+	pool := monitor.PoolMonitor()
+	for _, typ := range []string{event.ConnectionCreated, event.ConnectionCreated, event.GetSucceeded, event.GetSucceeded, event.ConnectionReturned} {
+		pool.Event(&event.PoolEvent{Type: typ})
+	}
+	if got := monitor.Stats(); got != (Stats{CheckedOut: 1, Connections: 2}) {
+		t.Errorf("Stats() = %+v, want 1 checked out of 2", got)
+	}
+}
+
+func TestCheckerReportsDocumentDBLeaks(t *testing.T) {
+	monitor := NewMonitor()
+	pool := monitor.PoolMonitor()
+	pool.Event(&event.PoolEvent{Type: event.ConnectionCreated})
+	checker := Start(monitor)
+	checker.Settle = 0
+
+	pool.Event(&event.PoolEvent{Type: event.GetSucceeded})
+	h := &monitorHarness{t: t, monitor: monitor.CommandMonitor()}
+	h.run("find", bson.D{{Key: "find", Value: "hotels"}}, cursorReply(3))
+
+	err := checker.Check()
+	for _, want := range []string{"1 DocumentDB connection(s) still checked out", "1 cursor(s) left open on the server"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Check() = %v, want %q", err, want)
+		}
+	}
+	// The connection open at the snapshot is not a leak
+	if err != nil && strings.Contains(err.Error(), "still open") {
+		t.Errorf("Check() = %v, counted the connection open at the snapshot", err)
+	}
+}
+
+// fakeTB records the cleanups and errors of a test
+type fakeTB struct {
+	cleanups []func()
+	errors   []string
+}
+
+func (f *fakeTB) Helper()                {}
+func (f *fakeTB) Cleanup(cleanup func()) { f.cleanups = append(f.cleanups, cleanup) }
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestVerify(t *testing.T) {
+	tb := &fakeTB{}
+	Verify(tb)
+	if len(tb.cleanups) != 1 || len(tb.errors) != 0 {
+		t.Fatalf("Verify registered %d cleanups and reported %v, want one cleanup and nothing yet", len(tb.cleanups), tb.errors)
+	}
+	tb.cleanups[0]()
+	if len(tb.errors) != 0 {
+		t.Errorf("Verify reported %v without a leak", tb.errors)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"true": true, "1": true, "false": false, "": false, "yes": false} {
+		t.Setenv("LEAK_CHECK", value)
+		if got := Enabled(); got != want {
+			t.Errorf("Enabled() with LEAK_CHECK=%q = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package leakcheck
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Stats counts the DocumentDB resources a process holds
+type Stats struct {
+	// CheckedOut is the connections checked out of the pool by an operation in progress
+	CheckedOut int
+	// Connections is the open connections; they close when the client disconnects
+	Connections int
+	// Cursors is the cursors open on the server: created by find or aggregate with
+	// more results to fetch, and neither exhausted nor killed since
+	Cursors int
+}
+
+// Monitor counts the pool's connections and the server's cursors from the events of
+// the Mongo client. Install both of its monitors on the client options.
+type Monitor struct {
+	mu          sync.Mutex
+	checkedOut  int
+	connections int
+	cursors     map[int64]bool
+	// started holds the cursors named by getMore and killCursors commands in flight,
+	// by request ID, since only the command, not its reply, says which cursor it was
+	started map[int64][]int64
+}
+
+// defaultMonitor watches the clients the vector store connects with LEAK_CHECK set
+var defaultMonitor = NewMonitor()
+
+// Default returns the monitor of the vector store's clients
+func Default() *Monitor {
+	return defaultMonitor
+}
+
+// NewMonitor returns a monitor that has seen nothing yet
+func NewMonitor() *Monitor {
+	return &Monitor{cursors: make(map[int64]bool), started: make(map[int64][]int64)}
+}
+
+// Stats returns the resources held now
+func (m *Monitor) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Stats{CheckedOut: m.checkedOut, Connections: m.connections, Cursors: len(m.cursors)}
+}
+
+// PoolMonitor returns the pool monitor for options.ClientOptions.SetPoolMonitor
+func (m *Monitor) PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: m.poolEvent}
+}
+
+// CommandMonitor returns the command monitor for options.ClientOptions.SetMonitor
+func (m *Monitor) CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{Started: m.commandStarted, Succeeded: m.commandSucceeded, Failed: m.commandFailed}
+}
+
+func (m *Monitor) poolEvent(e *event.PoolEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch e.Type {
+	case event.GetSucceeded:
+		m.checkedOut++
+	case event.ConnectionReturned:
+		m.checkedOut--
+	case event.ConnectionCreated:
+		m.connections++
+	case event.ConnectionClosed:
+		m.connections--
+	}
+}
+
+func (m *Monitor) commandStarted(_ context.Context, e *event.CommandStartedEvent) {
+	var ids []int64
+	switch e.CommandName {
+	case "getMore":
+		if id, ok := e.Command.Lookup("getMore").Int64OK(); ok {
+			ids = []int64{id}
+		}
+	case "killCursors":
+		array, _ := e.Command.Lookup("cursors").ArrayOK()
+		values, _ := array.Values()
+		for _, v := range values {
+			if id, ok := v.Int64OK(); ok {
+				ids = append(ids, id)
+			}
+		}
+	default:
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started[e.RequestID] = ids
+}
+
+func (m *Monitor) commandSucceeded(_ context.Context, e *event.CommandSucceededEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := m.started[e.RequestID]
+	delete(m.started, e.RequestID)
+
+	switch e.CommandName {
+	case "killCursors":
+		for _, id := range ids {
+			delete(m.cursors, id)
+		}
+	case "getMore":
+		// The cursor is exhausted when the reply no longer names it
+		if cursorID(e.Reply) == 0 {
+			for _, id := range ids {
+				delete(m.cursors, id)
+			}
+		}
+	default:
+		if id := cursorID(e.Reply); id != 0 {
+			m.cursors[id] = true
+		}
+	}
+}
+
+func (m *Monitor) commandFailed(_ context.Context, e *event.CommandFailedEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := m.started[e.RequestID]
+	delete(m.started, e.RequestID)
+	// A failed getMore kills its cursor on the server
+	if e.CommandName == "getMore" {
+		for _, id := range ids {
+			delete(m.cursors, id)
+		}
+	}
+}
+
+// cursorID returns the ID of the cursor in a reply, or 0 when there is none to fetch more from
+func cursorID(reply bson.Raw) int64 {
+	id, _ := reply.Lookup("cursor", "id").Int64OK()
+	return id
+}
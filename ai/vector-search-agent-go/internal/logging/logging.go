@@ -0,0 +1,271 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+)
+
+// ContextHandler adds the component that logged each record, the package it was
+// logged from, and the values carried by the context, the session ID and the request's
+// correlation ID
+type ContextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the component and context attributes and forwards the record to the
+// wrapped handler
+func (h ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if name := component(record.PC); name != "" {
+		record.AddAttrs(slog.String(ComponentKey, name))
+	}
+	if id := session.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String(sessionIDKey, id))
+	}
+	if id := session.RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String(requestIDKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a ContextHandler wrapping the handler with the given attributes
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a ContextHandler wrapping the handler with the given group
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+	return ContextHandler{h.Handler.WithGroup(name)}
+}
+
+// Attributes ContextHandler adds from the context
+const (
+	sessionIDKey = "sessionId"
+	requestIDKey = "requestId"
+)
+
+// ComponentKey is the attribute naming the package a record was logged from, such as
+// agents, vectorstore, or serve
+const ComponentKey = "component"
+
+// components caches the component of each program counter records are logged from
+var components sync.Map
+
+// component names the package of the code at pc: the directory of its source file
+func component(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	if name, ok := components.Load(pc); ok {
+		return name.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	var name string
+	if frame.File != "" {
+		name = path.Base(path.Dir(frame.File))
+	}
+	components.Store(pc, name)
+	return name
+}
+
+// Log output formats
+const (
+	// FormatText writes key=value lines for people reading a terminal
+	FormatText = "text"
+	// FormatJSON writes one JSON object per line for log collectors such as Log Analytics
+	FormatJSON = "json"
+)
+
+// ParseFormat parses a LOG_FORMAT value: text or json, with empty meaning text
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("invalid LOG_FORMAT %q: must be text or json", s)
+}
+
+// JSON field names that differ from the text output, chosen for log collectors
+const (
+	jsonTimeKey      = "ts"
+	jsonRequestIDKey = "correlationId"
+)
+
+// LevelTrace is below slog.LevelDebug and includes prompt and context bodies
+const LevelTrace = slog.Level(-8)
+
+// level is shared by every logger so SetLevel can change verbosity at runtime
+var level slog.LevelVar
+
+// New creates a logger writing text records at lvl and above to w. Secrets in
+// attribute values are redacted.
+func New(w io.Writer, lvl slog.Level) *slog.Logger {
+	SetLevel(lvl)
+	return slog.New(ContextHandler{slog.NewTextHandler(w, &slog.HandlerOptions{Level: &level, ReplaceAttr: replaceAttr})})
+}
+
+// NewJSON creates a logger writing records at lvl and above to w as one JSON object per
+// line. The time is ts and the request ID correlationId; multi-line values such as
+// prompts stay single string fields. Secrets in attribute values are redacted.
+func NewJSON(w io.Writer, lvl slog.Level) *slog.Logger {
+	SetLevel(lvl)
+	return slog.New(ContextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: &level, ReplaceAttr: replaceJSONAttr})})
+}
+
+// SetLevel changes the level of every logger created by New
+func SetLevel(lvl slog.Level) {
+	level.Set(lvl)
+}
+
+// Level returns the current level of every logger created by New
+func Level() slog.Level {
+	return level.Level()
+}
+
+// Setup creates the shared logger writing to stderr in format and installs it as the
+// slog default. With text, the log package keeps writing straight to stderr:
+// slog.SetDefault would otherwise route it through the handler at info level, hiding
+// warnings and errors printed with log.Printf below -v. With JSON, every stderr line
+// must be a record, so log.Printf messages become warnings.
+func Setup(lvl slog.Level, format string) *slog.Logger {
+	if format == FormatJSON {
+		logger := NewJSON(os.Stderr, lvl)
+		slog.SetDefault(logger)
+		log.SetOutput(logWriter{logger.Handler()})
+		log.SetFlags(0)
+		return logger
+	}
+
+	logger := New(os.Stderr, lvl)
+	slog.SetDefault(logger)
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags)
+	return logger
+}
+
+// logWriter logs each message of the log package as a warning through handler
+type logWriter struct {
+	handler slog.Handler
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	ctx := context.Background()
+	if !w.handler.Enabled(ctx, slog.LevelWarn) {
+		return len(p), nil
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, strings.TrimSuffix(string(p), "\n"), logCaller())
+	return len(p), w.handler.Handle(ctx, record)
+}
+
+// logCaller returns the program counter of the code that called the log package, so
+// its records name the right component
+func logCaller() uintptr {
+	var pcs [16]uintptr
+	// Skip runtime.Callers, logCaller, and logWriter.Write
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "log.") {
+			return frame.PC
+		}
+		if !more {
+			return 0
+		}
+	}
+}
+
+// Trace logs a record at LevelTrace with the default logger, attributed to the caller
+func Trace(ctx context.Context, msg string, args ...any) {
+	handler := slog.Default().Handler()
+	if !handler.Enabled(ctx, LevelTrace) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip runtime.Callers and Trace
+	runtime.Callers(2, pcs[:])
+	record := slog.NewRecord(time.Now(), LevelTrace, msg, pcs[0])
+	record.Add(args...)
+	_ = handler.Handle(ctx, record)
+}
+
+// ParseLevel parses a LOG_LEVEL value: error, warn, info, debug, or trace
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error":
+		return slog.LevelError, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "trace":
+		return LevelTrace, nil
+	}
+	return 0, fmt.Errorf("invalid LOG_LEVEL %q: must be error, warn, info, debug, or trace", s)
+}
+
+// VerbosityLevel maps a count of -v flags to a level: none is warn, then info, debug, and trace
+func VerbosityLevel(verbosity int) slog.Level {
+	switch {
+	case verbosity <= 0:
+		return slog.LevelWarn
+	case verbosity == 1:
+		return slog.LevelInfo
+	case verbosity == 2:
+		return slog.LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+// LevelFromEnv reads LOG_LEVEL, falling back to debug when DEBUG is true and to
+// fallback when neither is set
+func LevelFromEnv(getenv func(string) string, fallback slog.Level) (slog.Level, error) {
+	if value := getenv("LOG_LEVEL"); value != "" {
+		return ParseLevel(value)
+	}
+	if debug, _ := strconv.ParseBool(getenv("DEBUG")); debug {
+		return slog.LevelDebug, nil
+	}
+	return fallback, nil
+}
+
+// replaceJSONAttr renames the time and request ID for log collectors, then applies
+// replaceAttr
+func replaceJSONAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 {
+		switch attr.Key {
+		case slog.TimeKey:
+			attr.Key = jsonTimeKey
+		case requestIDKey:
+			attr.Key = jsonRequestIDKey
+		}
+	}
+	return replaceAttr(groups, attr)
+}
+
+// replaceAttr names the trace level and redacts secrets from attribute values
+func replaceAttr(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.LevelKey && len(groups) == 0 {
+		if lvl, ok := attr.Value.Any().(slog.Level); ok && lvl <= LevelTrace {
+			return slog.String(slog.LevelKey, "TRACE")
+		}
+		return attr
+	}
+	return redactAttr(attr)
+}
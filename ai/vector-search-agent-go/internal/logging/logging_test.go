@@ -0,0 +1,259 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+)
+
+func TestContextHandlerAddsSessionID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+	ctx := session.WithID(context.Background(), "session-123")
+
+	logger.InfoContext(ctx, "with session")
+	logger.With("component", "planner").InfoContext(ctx, "with attrs")
+	logger.WithGroup("search").InfoContext(ctx, "with group")
+	logger.Info("without session")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines[:2] {
+		if !strings.Contains(line, "sessionId=session-123") {
+			t.Errorf("line missing session ID: %s", line)
+		}
+	}
+	if !strings.Contains(lines[2], "session-123") {
+		t.Errorf("grouped line missing session ID: %s", lines[2])
+	}
+	if strings.Contains(lines[3], "sessionId") {
+		t.Errorf("line without a session in the context has one: %s", lines[3])
+	}
+}
+
+func TestContextHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+	ctx := session.WithRequestID(session.WithID(context.Background(), "session-123"), "req-1")
+
+	logger.InfoContext(ctx, "with request")
+	logger.InfoContext(session.WithRequestID(context.Background(), "req-2"), "request only")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "sessionId=session-123 requestId=req-1") {
+		t.Errorf("line missing session and request IDs: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "requestId=req-2") || strings.Contains(lines[1], "sessionId") {
+		t.Errorf("line with only a request ID = %s", lines[1])
+	}
+}
+
+func TestNewLevel(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  []string
+	}{
+		{slog.LevelWarn, []string{"warn"}},
+		{slog.LevelInfo, []string{"info", "warn"}},
+		{slog.LevelDebug, []string{"debug", "info", "warn"}},
+		{LevelTrace, []string{"trace", "debug", "info", "warn"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, tt.level)
+			ctx := context.Background()
+			logger.Log(ctx, LevelTrace, "trace")
+			logger.DebugContext(ctx, "debug")
+			logger.InfoContext(ctx, "info")
+			logger.WarnContext(ctx, "warn")
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				_, msg, _ := strings.Cut(line, "msg=")
+				msg, _, _ = strings.Cut(msg, " ")
+				got = append(got, msg)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceLevelName(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, LevelTrace).Log(context.Background(), LevelTrace, "prompt")
+	if !strings.Contains(buf.String(), "level=TRACE") {
+		t.Errorf("trace record = %q, want level=TRACE", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"error":   slog.LevelError,
+		"WARN":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		" info ":  slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"trace":   LevelTrace,
+	}
+	for input, want := range tests {
+		got, err := ParseLevel(input)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil || !strings.Contains(err.Error(), `invalid LOG_LEVEL "verbose"`) {
+		t.Errorf("ParseLevel(verbose) err = %v", err)
+	}
+}
+
+func TestVerbosityLevel(t *testing.T) {
+	want := []slog.Level{slog.LevelWarn, slog.LevelInfo, slog.LevelDebug, LevelTrace, LevelTrace}
+	for v, level := range want {
+		if got := VerbosityLevel(v); got != level {
+			t.Errorf("VerbosityLevel(%d) = %v, want %v", v, got, level)
+		}
+	}
+}
+
+func TestLevelFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want slog.Level
+	}{
+		{"unset", nil, slog.LevelWarn},
+		{"LOG_LEVEL", map[string]string{"LOG_LEVEL": "info"}, slog.LevelInfo},
+		{"DEBUG true", map[string]string{"DEBUG": "true"}, slog.LevelDebug},
+		{"DEBUG 1", map[string]string{"DEBUG": "1"}, slog.LevelDebug},
+		{"DEBUG false", map[string]string{"DEBUG": "false"}, slog.LevelWarn},
+		{"LOG_LEVEL wins over DEBUG", map[string]string{"LOG_LEVEL": "trace", "DEBUG": "true"}, LevelTrace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LevelFromEnv(func(key string) string { return tt.env[key] }, slog.LevelWarn)
+			if err != nil || got != tt.want {
+				t.Errorf("LevelFromEnv = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LevelFromEnv(func(string) string { return "loud" }, slog.LevelWarn); err == nil {
+		t.Error("LevelFromEnv accepted an invalid LOG_LEVEL")
+	}
+}
+
+// decodeRecords parses each line of out as a JSON log record
+func decodeRecords(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %v\n%s", err, line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSON(&buf, LevelTrace)
+	ctx := session.WithRequestID(session.WithID(context.Background(), "session-123"), "req-1")
+	logger.InfoContext(ctx, "stage finished", "stage", "planner")
+	logger.Log(ctx, LevelTrace, "planner prompt", "prompt", "line one\nline two\n\tindented", "apiKey", "sk-secret")
+	logger.WarnContext(context.Background(), "failed to record history", "err", errors.New("boom\ngoroutine 1 [running]:\nmain.main()"))
+
+	records := decodeRecords(t, buf.String())
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3:\n%s", len(records), buf.String())
+	}
+	for _, record := range records {
+		for _, key := range []string{"ts", "level", "msg", "component"} {
+			if _, ok := record[key]; !ok {
+				t.Errorf("record missing %s: %v", key, record)
+			}
+		}
+		if record["component"] != "logging" {
+			t.Errorf("component = %v, want the package that logged, logging", record["component"])
+		}
+	}
+
+	if records[0]["sessionId"] != "session-123" || records[0]["correlationId"] != "req-1" || records[0]["stage"] != "planner" {
+		t.Errorf("record = %v, want the session, the request as correlationId, and the attributes", records[0])
+	}
+	if _, ok := records[0]["requestId"]; ok {
+		t.Errorf("record = %v, want the request ID only as correlationId", records[0])
+	}
+	if records[1]["level"] != "TRACE" || records[1]["prompt"] != "line one\nline two\n\tindented" || records[1]["apiKey"] != redacted {
+		t.Errorf("record = %v, want the multi-line prompt as one field and the key redacted", records[1])
+	}
+	if records[2]["err"] != "boom\ngoroutine 1 [running]:\nmain.main()" {
+		t.Errorf("record = %v, want the multi-line error as one err field", records[2])
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for input, want := range map[string]string{"": FormatText, "text": FormatText, " JSON ": FormatJSON} {
+		if got, err := ParseFormat(input); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("logfmt"); err == nil || !strings.Contains(err.Error(), `invalid LOG_FORMAT "logfmt"`) {
+		t.Errorf("ParseFormat(logfmt) err = %v", err)
+	}
+}
+
+func TestLogWriterWritesJSONRecords(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(logWriter{NewJSON(&buf, slog.LevelWarn).Handler()}, "", 0)
+	logger.Printf("Warning: no vector index found on %s", "DescriptionVector")
+
+	records := decodeRecords(t, buf.String())
+	if len(records) != 1 || records[0]["level"] != "WARN" || records[0]["msg"] != "Warning: no vector index found on DescriptionVector" {
+		t.Errorf("records = %v, want one warning with the message", records)
+	}
+	if records[0]["component"] != "logging" {
+		t.Errorf("component = %v, want the caller of the log package", records[0]["component"])
+	}
+
+	buf.Reset()
+	SetLevel(slog.LevelError)
+	logger.Print("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("logged %q below the level", buf.String())
+	}
+}
+
+func TestTraceNamesCaller(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(NewJSON(&buf, LevelTrace))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	Trace(context.Background(), "hotel context", "context", "a\nb")
+	records := decodeRecords(t, buf.String())
+	if len(records) != 1 || records[0]["component"] != "logging" || records[0]["context"] != "a\nb" {
+		t.Errorf("records = %v, want the trace record attributed to its caller", records)
+	}
+	if source, _ := records[0]["msg"].(string); source != "hotel context" {
+		t.Errorf("msg = %q", source)
+	}
+}
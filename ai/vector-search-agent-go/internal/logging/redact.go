@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are attribute keys whose values are always redacted, compared case-insensitively
+// after removing underscores and dashes
+var secretKeys = map[string]bool{
+	"apikey":           true,
+	"password":         true,
+	"secret":           true,
+	"token":            true,
+	"accesstoken":      true,
+	"authorization":    true,
+	"connectionstring": true,
+}
+
+// secretPatterns match secrets embedded in free text such as prompts or error messages
+var secretPatterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// Credentials in a MongoDB connection string
+	{regexp.MustCompile(`(mongodb(?:\+srv)?://[^:/@\s]+:)[^@\s]+@`), "${1}" + redacted + "@"},
+	// key=value or key: value pairs with secret-looking keys
+	{regexp.MustCompile(`(?i)\b(api[-_]?key|password|pwd|secret|token|accountkey|sharedaccesskey)(["']?\s*[:=]\s*["']?)[^\s"';,&]+`), "${1}${2}" + redacted},
+	// Bearer tokens in Authorization headers
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`), "Bearer " + redacted},
+}
+
+// Redact replaces credentials found in s with a placeholder
+func Redact(s string) string {
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// redactAttr hides the value of secret attributes and redacts string values
+func redactAttr(attr slog.Attr) slog.Attr {
+	key := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(attr.Key))
+	if secretKeys[key] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+	return attr
+}
@@ -0,0 +1,77 @@
+// Package metrics counts searches, Azure OpenAI requests and tokens, DocumentDB errors,
+// and agent runs. The clients, store, and pipeline report to the default Recorder, which
+// discards everything until a command installs a Prometheus recorder.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Status label values
+const (
+	StatusOK       = "ok"
+	StatusError    = "error"
+	StatusCanceled = "canceled"
+)
+
+// Recorder receives the measurements of the clients, store, and pipeline
+type Recorder interface {
+	// Search records one vector search, how long it took, and whether it failed
+	Search(elapsed time.Duration, err error)
+	// EmbeddingRequest records one embedding API call that ended with status, such as
+	// StatusOK or the HTTP status code of a failed call
+	EmbeddingRequest(status string)
+	// Tokens records the input and output tokens one call to deployment used
+	Tokens(deployment string, input, output int64)
+	// MongoError records one failed DocumentDB operation of the given error class
+	MongoError(class string)
+	// Run records one agent pipeline run and whether it failed
+	Run(err error)
+}
+
+// nop is the Recorder used until SetDefault installs another
+type nop struct{}
+
+func (nop) Search(time.Duration, error) {}
+func (nop) EmbeddingRequest(string)     {}
+func (nop) Tokens(string, int64, int64) {}
+func (nop) MongoError(string)           {}
+func (nop) Run(error)                   {}
+
+// holder lets the default recorder be swapped atomically, whatever its concrete type
+type holder struct {
+	Recorder
+}
+
+var current atomic.Pointer[holder]
+
+func init() {
+	current.Store(&holder{nop{}})
+}
+
+// Default returns the recorder the clients, store, and pipeline report to
+func Default() Recorder {
+	return current.Load().Recorder
+}
+
+// SetDefault makes r the default recorder; a nil r restores the no-op recorder
+func SetDefault(r Recorder) {
+	if r == nil {
+		r = nop{}
+	}
+	current.Store(&holder{r})
+}
+
+// Status returns the status label of an operation that ended with err
+func Status(err error) string {
+	switch {
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, context.Canceled):
+		return StatusCanceled
+	}
+	return StatusError
+}
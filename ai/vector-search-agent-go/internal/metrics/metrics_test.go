@@ -0,0 +1,105 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics/metricstest"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, metrics.StatusOK},
+		{errors.New("boom"), metrics.StatusError},
+		{fmt.Errorf("search: %w", context.Canceled), metrics.StatusCanceled},
+		{context.DeadlineExceeded, metrics.StatusError},
+	}
+
+	for _, tt := range tests {
+		if got := metrics.Status(tt.err); got != tt.want {
+			t.Errorf("Status(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPrometheusCountersMove(t *testing.T) {
+	recorder := metricstest.Record(t)
+	r := metrics.Default()
+
+	r.Search(20*time.Millisecond, nil)
+	r.Search(time.Second, errors.New("cursor error"))
+	r.EmbeddingRequest(metrics.StatusOK)
+	r.EmbeddingRequest("429")
+	r.Tokens("gpt-4o", 120, 30)
+	r.Tokens("text-embedding-3-small", 8, 0)
+	r.MongoError("connectivity")
+	r.Run(nil)
+
+	tests := []struct {
+		name   string
+		labels []string
+		want   float64
+	}{
+		{"searches_total", []string{"status", "ok"}, 1},
+		{"searches_total", []string{"status", "error"}, 1},
+		{"search_latency_seconds", nil, 2},
+		{"embedding_requests_total", []string{"status", "429"}, 1},
+		{"openai_tokens_total", []string{"deployment", "gpt-4o", "type", "input"}, 120},
+		{"openai_tokens_total", []string{"deployment", "gpt-4o", "type", "output"}, 30},
+		{"openai_tokens_total", []string{"deployment", "text-embedding-3-small", "type", "output"}, 0},
+		{"mongo_errors_total", []string{"class", "connectivity"}, 1},
+		{"agent_runs_total", []string{"status", "ok"}, 1},
+	}
+	for _, tt := range tests {
+		if got := metricstest.Value(t, recorder, tt.name, tt.labels...); got != tt.want {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestHandlerRendersExpositionFormat(t *testing.T) {
+	recorder := metrics.NewPrometheus()
+	recorder.Search(50*time.Millisecond, nil)
+	recorder.Tokens("gpt-4o", 10, 2)
+
+	rec := httptest.NewRecorder()
+	recorder.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	parser := expfmt.NewTextParser(model.UTF8Validation)
+	families, err := parser.TextToMetricFamilies(rec.Body)
+	if err != nil {
+		t.Fatalf("invalid exposition format: %v", err)
+	}
+	for _, name := range []string{"searches_total", "search_latency_seconds", "openai_tokens_total", "go_goroutines"} {
+		if _, ok := families[name]; !ok {
+			t.Errorf("exposition is missing %s", name)
+		}
+	}
+}
+
+func TestSetDefaultNilRestoresNop(t *testing.T) {
+	previous := metrics.Default()
+	t.Cleanup(func() { metrics.SetDefault(previous) })
+
+	metrics.SetDefault(metrics.NewPrometheus())
+	metrics.SetDefault(nil)
+	if _, ok := metrics.Default().(*metrics.Prometheus); ok {
+		t.Error("SetDefault(nil) kept the Prometheus recorder")
+	}
+	// The no-op recorder accepts every measurement
+	metrics.Default().Search(time.Second, nil)
+}
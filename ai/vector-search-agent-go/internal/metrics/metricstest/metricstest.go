@@ -0,0 +1,68 @@
+// Package metricstest records the metrics a test produces, so tests can assert that
+// counters move without scraping an endpoint.
+package metricstest
+
+import (
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Record installs a Prometheus recorder as the default until the test finishes, then
+// restores the previous one. Tests that record metrics must not run in parallel.
+func Record(t testing.TB) *metrics.Prometheus {
+	t.Helper()
+	recorder := metrics.NewPrometheus()
+	previous := metrics.Default()
+	metrics.SetDefault(recorder)
+	t.Cleanup(func() { metrics.SetDefault(previous) })
+	return recorder
+}
+
+// Value returns the value of the counter, or the sample count of the histogram, named
+// name whose labels include labels, given as name and value pairs. It is 0 when no
+// such series has been recorded.
+func Value(t testing.TB, recorder *metrics.Prometheus, name string, labels ...string) float64 {
+	t.Helper()
+	families, err := recorder.Gatherer().Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		var total float64
+		for _, metric := range family.GetMetric() {
+			if !hasLabels(metric.GetLabel(), labels) {
+				continue
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				total += metric.GetCounter().GetValue()
+			case metric.GetHistogram() != nil:
+				total += float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+		return total
+	}
+	return 0
+}
+
+// hasLabels reports whether pairs, name and value in turn, are all among labels
+func hasLabels(labels []*dto.LabelPair, pairs []string) bool {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		found := false
+		for _, label := range labels {
+			if label.GetName() == pairs[i] && label.GetValue() == pairs[i+1] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus is a Recorder keeping counters and histograms in a Prometheus registry
+type Prometheus struct {
+	registry          *prometheus.Registry
+	searches          *prometheus.CounterVec
+	searchLatency     prometheus.Histogram
+	embeddingRequests *prometheus.CounterVec
+	tokens            *prometheus.CounterVec
+	mongoErrors       *prometheus.CounterVec
+	runs              *prometheus.CounterVec
+}
+
+// NewPrometheus creates a recorder with its own registry, which also holds the Go
+// runtime and process collectors
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+		searches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "searches_total",
+			Help: "Vector searches run against the hotel collection, by status.",
+		}, []string{"status"}),
+		searchLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "search_latency_seconds",
+			Help:    "Duration of vector searches.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		embeddingRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "embedding_requests_total",
+			Help: "Azure OpenAI embedding requests, by status: ok, or the HTTP status code or error of a failed request.",
+		}, []string{"status"}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "openai_tokens_total",
+			Help: "Azure OpenAI tokens used, by deployment and type (input or output).",
+		}, []string{"deployment", "type"}),
+		mongoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongo_errors_total",
+			Help: "Failed DocumentDB operations, by error class.",
+		}, []string{"class"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_runs_total",
+			Help: "Agent pipeline runs, by status.",
+		}, []string{"status"}),
+	}
+	p.registry.MustRegister(
+		p.searches, p.searchLatency, p.embeddingRequests, p.tokens, p.mongoErrors, p.runs,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return p
+}
+
+// Search counts the search by status and observes its latency
+func (p *Prometheus) Search(elapsed time.Duration, err error) {
+	p.searches.WithLabelValues(Status(err)).Inc()
+	p.searchLatency.Observe(elapsed.Seconds())
+}
+
+// EmbeddingRequest counts the request by status
+func (p *Prometheus) EmbeddingRequest(status string) {
+	p.embeddingRequests.WithLabelValues(status).Inc()
+}
+
+// Tokens adds the tokens to the deployment's input and output counters
+func (p *Prometheus) Tokens(deployment string, input, output int64) {
+	if input > 0 {
+		p.tokens.WithLabelValues(deployment, "input").Add(float64(input))
+	}
+	if output > 0 {
+		p.tokens.WithLabelValues(deployment, "output").Add(float64(output))
+	}
+}
+
+// MongoError counts the error by class
+func (p *Prometheus) MongoError(class string) {
+	p.mongoErrors.WithLabelValues(class).Inc()
+}
+
+// Run counts the run by status
+func (p *Prometheus) Run(err error) {
+	p.runs.WithLabelValues(Status(err)).Inc()
+}
+
+// Gatherer returns the registry holding the recorder's metrics
+func (p *Prometheus) Gatherer() prometheus.Gatherer {
+	return p.registry
+}
+
+// Handler serves the recorder's metrics in the Prometheus exposition format
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Document is a record the vector store can embed, store, and show to the synthesizer.
+// Hotel and HotelForVectorStore implement it; another corpus, such as products or
+// FAQs, only needs its own type and a decoder registered with the vector store.
+type Document interface {
+	// ID identifies the document within its collection
+	ID() string
+	// EmbeddingText is the text the document's vector is generated from
+	EmbeddingText() string
+	// DisplayFields are the fields shown to the synthesizer, by name
+	DisplayFields() map[string]string
+}
+
+// FieldOrderer is implemented by documents whose display fields have a natural order.
+// Fields it leaves out follow in name order.
+type FieldOrderer interface {
+	FieldOrder() []string
+}
+
+// DocumentSearchResult represents a document with similarity score
+type DocumentSearchResult struct {
+	Document Document
+	Score    float64
+}
+
+// hotelFieldOrder is the order of the hotel display fields
+var hotelFieldOrder = []string{
+	"HotelId", "HotelName", "Description", "Category", "Tags", "ParkingIncluded", "IsDeleted",
+	"LastRenovationDate", "Rating", "Address.StreetAddress", "Address.City", "Address.StateProvince",
+	"Address.PostalCode", "Address.Country", "Location", "Rooms.Count", "Rooms.Rates", "Rooms.Types",
+}
+
+// ID returns the HotelId
+func (h *HotelForVectorStore) ID() string {
+	return h.HotelID
+}
+
+// EmbeddingText returns the Description, which the upload embeds
+func (h *HotelForVectorStore) EmbeddingText() string {
+	return h.Description
+}
+
+// DisplayFields returns the hotel's fields as the synthesizer reads them. Location and
+// the Rooms fields are left out when the hotel has none.
+func (h *HotelForVectorStore) DisplayFields() map[string]string {
+	fields := map[string]string{
+		"HotelId":               h.HotelID,
+		"HotelName":             h.HotelName,
+		"Description":           h.Description,
+		"Category":              h.Category,
+		"Tags":                  strings.Join(h.Tags, ", "),
+		"ParkingIncluded":       fmt.Sprint(h.ParkingIncluded),
+		"IsDeleted":             fmt.Sprint(h.IsDeleted),
+		"LastRenovationDate":    h.LastRenovationDate.Format("2006-01-02"),
+		"Rating":                fmt.Sprintf("%.1f", h.Rating),
+		"Address.StreetAddress": h.Address.StreetAddress,
+		"Address.City":          h.Address.City,
+		"Address.StateProvince": h.Address.StateProvince,
+		"Address.PostalCode":    h.Address.PostalCode,
+		"Address.Country":       h.Address.Country,
+	}
+	if loc := h.Location; loc != nil && len(loc.Coordinates) == 2 {
+		fields["Location"] = fmt.Sprintf("%s (lat %.6f, lng %.6f)", h.Address.City, loc.Coordinates[1], loc.Coordinates[0])
+	}
+	if rooms := h.Rooms; rooms != nil {
+		fields["Rooms.Count"] = fmt.Sprint(rooms.Count)
+		fields["Rooms.Rates"] = fmt.Sprintf("%.2f-%.2f", rooms.MinRate, rooms.MaxRate)
+		fields["Rooms.Types"] = strings.Join(rooms.Types, ", ")
+	}
+	return fields
+}
+
+// FieldOrder lists the display fields in the order the synthesizer has always seen them
+func (h *HotelForVectorStore) FieldOrder() []string {
+	return hotelFieldOrder
+}
+
+// ID returns the HotelId
+func (h *Hotel) ID() string {
+	return h.HotelID
+}
+
+// EmbeddingText returns the Description, which the upload embeds
+func (h *Hotel) EmbeddingText() string {
+	return h.Description
+}
+
+// DisplayFields returns the fields of the hotel's vector store document
+func (h *Hotel) DisplayFields() map[string]string {
+	doc := h.ToVectorStore()
+	return doc.DisplayFields()
+}
+
+// FieldOrder lists the display fields in the order the synthesizer has always seen them
+func (h *Hotel) FieldOrder() []string {
+	return hotelFieldOrder
+}
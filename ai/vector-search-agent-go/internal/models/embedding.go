@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxFieldWeight is the largest weight FieldWeights accepts. Each unit of weight repeats
+// the field's section once, so large weights mostly add tokens.
+const MaxFieldWeight = 5
+
+// weightedFields are the hotel fields FieldWeights can name, with how each is rendered
+var weightedFields = map[string]func(h *HotelForVectorStore) string{
+	"HotelName":       func(h *HotelForVectorStore) string { return h.HotelName },
+	"Description":     func(h *HotelForVectorStore) string { return h.Description },
+	"Category":        func(h *HotelForVectorStore) string { return h.Category },
+	"Tags":            func(h *HotelForVectorStore) string { return strings.Join(h.Tags, ", ") },
+	"Address.City":    func(h *HotelForVectorStore) string { return h.Address.City },
+	"Address.Country": func(h *HotelForVectorStore) string { return h.Address.Country },
+}
+
+// FieldWeight is how many times one field's section appears in the embedding text
+type FieldWeight struct {
+	Field  string
+	Weight int
+}
+
+// FieldWeights composes the text a hotel is embedded from. Fields appear in order, each
+// as a "Field: value" section repeated Weight times, so amenity terms in Tags, say, can
+// count for more than the description prose. A weight of zero drops the field. Nil
+// FieldWeights embed the Description alone.
+type FieldWeights []FieldWeight
+
+// ParseFieldWeights parses a spec such as "Description:1,Tags:2,Category:1". An empty
+// spec returns nil. Each field may appear once, weights are integers from 0 to
+// MaxFieldWeight, and at least one weight must be above zero.
+func ParseFieldWeights(spec string) (FieldWeights, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	var weights FieldWeights
+	for _, entry := range strings.Split(spec, ",") {
+		field, weightStr, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		field = strings.TrimSpace(field)
+		if !ok || field == "" {
+			return nil, fmt.Errorf("field weight %q is not Field:weight", entry)
+		}
+		if _, known := weightedFields[field]; !known {
+			return nil, fmt.Errorf("unknown field %q: use %s", field, strings.Join(WeightedFieldNames(), ", "))
+		}
+		if slices.ContainsFunc(weights, func(w FieldWeight) bool { return w.Field == field }) {
+			return nil, fmt.Errorf("field %q is weighted twice", field)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil || weight < 0 || weight > MaxFieldWeight {
+			return nil, fmt.Errorf("weight %q of %s must be an integer from 0 to %d", weightStr, field, MaxFieldWeight)
+		}
+		weights = append(weights, FieldWeight{Field: field, Weight: weight})
+	}
+
+	if !slices.ContainsFunc(weights, func(w FieldWeight) bool { return w.Weight > 0 }) {
+		return nil, fmt.Errorf("every field weight in %q is zero", spec)
+	}
+	return weights, nil
+}
+
+// WeightedFieldNames returns the fields FieldWeights can name, sorted
+func WeightedFieldNames() []string {
+	names := make([]string, 0, len(weightedFields))
+	for name := range weightedFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// String returns the weights in ParseFieldWeights form, or "Description" when nil
+func (w FieldWeights) String() string {
+	if w == nil {
+		return "Description"
+	}
+	entries := make([]string, len(w))
+	for i, fw := range w {
+		entries[i] = fmt.Sprintf("%s:%d", fw.Field, fw.Weight)
+	}
+	return strings.Join(entries, ",")
+}
+
+// EmbeddingText renders the text h is embedded from. Empty fields are left out.
+func (w FieldWeights) EmbeddingText(h *HotelForVectorStore) string {
+	if w == nil {
+		return h.Description
+	}
+
+	var sections []string
+	for _, fw := range w {
+		value := weightedFields[fw.Field](h)
+		if fw.Weight == 0 || strings.TrimSpace(value) == "" {
+			continue
+		}
+		line := fw.Field + ": " + value
+		sections = append(sections, strings.TrimSuffix(strings.Repeat(line+"\n", fw.Weight), "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// Composition describes which fields EmbeddingText rendered for h and how often, such
+// as "Description x1, Tags x2"; fields that were empty or weighted zero are left out
+func (w FieldWeights) Composition(h *HotelForVectorStore) string {
+	if w == nil {
+		return "Description x1"
+	}
+
+	var parts []string
+	for _, fw := range w {
+		if fw.Weight > 0 && strings.TrimSpace(weightedFields[fw.Field](h)) != "" {
+			parts = append(parts, fmt.Sprintf("%s x%d", fw.Field, fw.Weight))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ContentHash returns the hash h should carry for model when embedded with these
+// weights. Nil weights give HashContent, so collections uploaded before weights
+// existed keep their hashes; otherwise the rendered text is hashed, so any change to
+// the weights that changes the text re-embeds the hotel under --changed-only.
+func (w FieldWeights) ContentHash(model string, h *HotelForVectorStore) string {
+	if w == nil {
+		return h.HashContent(model)
+	}
+	return contentHash(model, w.EmbeddingText(h))
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFieldWeights(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{"", "Description", ""},
+		{"Description:1,Tags:2,Category:1", "Description:1,Tags:2,Category:1", ""},
+		{" Tags : 3 , HotelName:0 ,Description:1", "Tags:3,HotelName:0,Description:1", ""},
+		{"Address.City:1", "Address.City:1", ""},
+		{"Description", "", "is not Field:weight"},
+		{":2", "", "is not Field:weight"},
+		{"Amenities:2", "", `unknown field "Amenities"`},
+		{"Tags:1,Tags:2", "", "weighted twice"},
+		{"Tags:1.5", "", "must be an integer from 0 to 5"},
+		{"Tags:-1", "", "must be an integer from 0 to 5"},
+		{"Tags:6", "", "must be an integer from 0 to 5"},
+		{"Tags:0,Category:0", "", "every field weight"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			weights, err := ParseFieldWeights(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := weights.String(); got != tt.want {
+				t.Errorf("weights = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldWeightsEmbeddingText(t *testing.T) {
+	hotel := &HotelForVectorStore{
+		HotelID:     "1",
+		HotelName:   "Stay-Kay City Hotel",
+		Description: "Close to Times Square.",
+		Category:    "Boutique",
+		Tags:        []string{"view", "concierge"},
+		Address:     Address{City: "New York"},
+	}
+
+	tests := []struct {
+		spec        string
+		want        string
+		composition string
+	}{
+		{"", "Close to Times Square.", "Description x1"},
+		{"Description:1,Tags:2,Category:1",
+			"Description: Close to Times Square.\n\nTags: view, concierge\nTags: view, concierge\n\nCategory: Boutique",
+			"Description x1, Tags x2, Category x1"},
+		// A zero weight drops the field
+		{"Tags:1,Description:0,Address.City:1", "Tags: view, concierge\n\nAddress.City: New York", "Tags x1, Address.City x1"},
+		// An empty field is left out however it is weighted
+		{"Description:1,Address.Country:3", "Description: Close to Times Square.", "Description x1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			weights, err := ParseFieldWeights(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := weights.EmbeddingText(hotel)
+			if got != tt.want {
+				t.Errorf("text =\n%s\nwant\n%s", got, tt.want)
+			}
+			// Rendering is deterministic
+			if again := weights.EmbeddingText(hotel); again != got {
+				t.Errorf("second rendering differs:\n%s", again)
+			}
+			if composition := weights.Composition(hotel); composition != tt.composition {
+				t.Errorf("composition = %q, want %q", composition, tt.composition)
+			}
+		})
+	}
+}
+
+func TestFieldWeightsContentHash(t *testing.T) {
+	hotel := &HotelForVectorStore{HotelID: "1", HotelName: "Stay-Kay City Hotel", Description: "Close to Times Square.", Tags: []string{"view"}}
+	const model = "text-embedding-3-small"
+
+	var none FieldWeights
+	if none.ContentHash(model, hotel) != hotel.HashContent(model) {
+		t.Error("unweighted hash differs from HashContent")
+	}
+
+	light, _ := ParseFieldWeights("Description:1,Tags:1")
+	heavy, _ := ParseFieldWeights("Description:1,Tags:2")
+	if light.ContentHash(model, hotel) == heavy.ContentHash(model, hotel) || light.ContentHash(model, hotel) == none.ContentHash(model, hotel) {
+		t.Error("changing the weights does not change the hash")
+	}
+	if light.ContentHash(model, hotel) != light.ContentHash(model, hotel) {
+		t.Error("hash is not deterministic")
+	}
+}
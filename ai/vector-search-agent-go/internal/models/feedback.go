@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ratings accepted in feedback
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// MaxFeedbackComment bounds the length of a feedback comment, in characters
+const MaxFeedbackComment = 2000
+
+// Feedback is a rating of one agent answer. There is at most one per ResultID;
+// rating the same answer again replaces the rating and comment.
+type Feedback struct {
+	ResultID  string    `json:"resultId" bson:"resultId"`
+	SessionID string    `json:"sessionId,omitempty" bson:"sessionId,omitempty"`
+	Rating    string    `json:"rating" bson:"rating"`
+	Comment   string    `json:"comment,omitempty" bson:"comment,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// Validate normalizes the rating and checks the result ID, rating, and comment
+func (f *Feedback) Validate() error {
+	if _, err := uuid.Parse(f.ResultID); err != nil {
+		return fmt.Errorf("invalid result ID %q: expected the resultId printed with the answer", f.ResultID)
+	}
+	f.Rating = strings.ToLower(strings.TrimSpace(f.Rating))
+	if f.Rating != RatingUp && f.Rating != RatingDown {
+		return fmt.Errorf("invalid rating %q: use %s or %s", f.Rating, RatingUp, RatingDown)
+	}
+	f.Comment = strings.TrimSpace(f.Comment)
+	if n := len([]rune(f.Comment)); n > MaxFeedbackComment {
+		return fmt.Errorf("comment is %d characters; the limit is %d", n, MaxFeedbackComment)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFeedbackValidate(t *testing.T) {
+	const resultID = "3f2b8c1e-6d4a-4f0e-9b7a-2c5d8e1f0a93"
+
+	tests := []struct {
+		name        string
+		feedback    Feedback
+		wantErr     string
+		wantRating  string
+		wantComment string
+	}{
+		{"up", Feedback{ResultID: resultID, Rating: "up"}, "", RatingUp, ""},
+		{"normalized", Feedback{ResultID: resultID, Rating: " DOWN ", Comment: "  too far  "}, "", RatingDown, "too far"},
+		{"comment at limit", Feedback{ResultID: resultID, Rating: "up", Comment: strings.Repeat("é", MaxFeedbackComment)}, "", RatingUp, strings.Repeat("é", MaxFeedbackComment)},
+		{"missing result ID", Feedback{Rating: "up"}, `invalid result ID ""`, "", ""},
+		{"malformed result ID", Feedback{ResultID: "result-1", Rating: "up"}, `invalid result ID "result-1"`, "", ""},
+		{"unknown rating", Feedback{ResultID: resultID, Rating: "meh"}, `invalid rating "meh": use up or down`, "", ""},
+		{"comment too long", Feedback{ResultID: resultID, Rating: "up", Comment: strings.Repeat("x", MaxFeedbackComment+1)}, "comment is 2001 characters; the limit is 2000", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb := tt.feedback
+			err := fb.Validate()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fb.Rating != tt.wantRating || fb.Comment != tt.wantComment {
+				t.Errorf("feedback = %+v, want rating %q comment %q", fb, tt.wantRating, tt.wantComment)
+			}
+		})
+	}
+}
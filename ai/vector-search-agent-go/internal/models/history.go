@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+)
+
+// HistoryRecord is one agent run stored in the history collection. ResultID matches
+// the resultId of any feedback recorded for the answer.
+type HistoryRecord struct {
+	ResultID    string          `json:"resultId" bson:"resultId"`
+	SessionID   string          `json:"sessionId" bson:"sessionId"`
+	RequestID   string          `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	Query       string          `json:"query" bson:"query"`
+	SearchQuery string          `json:"searchQuery,omitempty" bson:"searchQuery,omitempty"`
+	Results     []HistoryResult `json:"results" bson:"results"`
+	Answer      string          `json:"answer" bson:"answer"`
+	Usage       []HistoryUsage  `json:"usage" bson:"usage"`
+	LatencyMs   int64           `json:"latencyMs" bson:"latencyMs"`
+	Error       string          `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt" bson:"createdAt"`
+	// Build identifies the binary that produced the record
+	Build buildinfo.Info `json:"build" bson:"build"`
+}
+
+// HistoryResult is one retrieved hotel in a history record
+type HistoryResult struct {
+	HotelID string  `json:"hotelId" bson:"hotelId"`
+	Score   float64 `json:"score" bson:"score"`
+}
+
+// HistoryUsage is the token usage of one deployment in a history record
+type HistoryUsage struct {
+	Deployment       string  `json:"deployment" bson:"deployment"`
+	PromptTokens     int64   `json:"promptTokens" bson:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens" bson:"completionTokens"`
+	EstimatedCost    float64 `json:"estimatedCost" bson:"estimatedCost"`
+}
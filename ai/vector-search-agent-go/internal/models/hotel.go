@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // Address represents a hotel address
 type Address struct {
@@ -11,26 +18,78 @@ type Address struct {
 	Country       string `json:"Country" bson:"Country"`
 }
 
+// GeoJSONPoint is a GeoJSON point; Coordinates holds longitude then latitude
+type GeoJSONPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// NewGeoJSONPoint returns the point at lng, lat
+func NewGeoJSONPoint(lng, lat float64) *GeoJSONPoint {
+	return &GeoJSONPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+// Validate checks that p is a Point with a longitude in [-180, 180] and a latitude
+// in [-90, 90], in that order
+func (p *GeoJSONPoint) Validate() error {
+	switch {
+	case p.Type != "Point":
+		return fmt.Errorf("type %q is not Point", p.Type)
+	case len(p.Coordinates) != 2:
+		return fmt.Errorf("%d coordinates, want [longitude, latitude]", len(p.Coordinates))
+	case p.Coordinates[0] < -180 || p.Coordinates[0] > 180:
+		return fmt.Errorf("longitude %g is outside [-180, 180]", p.Coordinates[0])
+	case p.Coordinates[1] < -90 || p.Coordinates[1] > 90:
+		return fmt.Errorf("latitude %g is outside [-90, 90] (coordinates are [longitude, latitude])", p.Coordinates[1])
+	}
+	return nil
+}
+
+// Room represents one entry of a hotel's Rooms
+type Room struct {
+	Description    string   `json:"Description" bson:"Description"`
+	DescriptionFr  string   `json:"Description_fr,omitempty" bson:"Description_fr,omitempty"`
+	Type           string   `json:"Type" bson:"Type"`
+	BaseRate       float64  `json:"BaseRate" bson:"BaseRate"`
+	BedOptions     string   `json:"BedOptions" bson:"BedOptions"`
+	SleepsCount    int      `json:"SleepsCount" bson:"SleepsCount"`
+	SmokingAllowed bool     `json:"SmokingAllowed" bson:"SmokingAllowed"`
+	Tags           []string `json:"Tags" bson:"Tags"`
+}
+
+// RoomSummary condenses a hotel's rooms for the vector store and the synthesizer
+type RoomSummary struct {
+	Count   int     `json:"Count" bson:"Count"`
+	MinRate float64 `json:"MinRate" bson:"MinRate"`
+	MaxRate float64 `json:"MaxRate" bson:"MaxRate"`
+	// Types lists the distinct room types in order of first appearance
+	Types []string `json:"Types" bson:"Types"`
+}
+
 // Hotel represents a hotel document (full model from JSON file)
 type Hotel struct {
-	HotelID            string    `json:"HotelId" bson:"HotelId"`
-	HotelName          string    `json:"HotelName" bson:"HotelName"`
-	Description        string    `json:"Description" bson:"Description"`
-	DescriptionFr      string    `json:"Description_fr,omitempty" bson:"Description_fr,omitempty"`
-	Category           string    `json:"Category" bson:"Category"`
-	Tags               []string  `json:"Tags" bson:"Tags"`
-	ParkingIncluded    bool      `json:"ParkingIncluded" bson:"ParkingIncluded"`
-	IsDeleted          bool      `json:"IsDeleted" bson:"IsDeleted"`
-	LastRenovationDate time.Time `json:"LastRenovationDate" bson:"LastRenovationDate"`
-	Rating             float64   `json:"Rating" bson:"Rating"`
-	Address            Address   `json:"Address" bson:"Address"`
-	Location           *struct {
-		Type        string    `json:"type" bson:"type"`
-		Coordinates []float64 `json:"coordinates" bson:"coordinates"`
-	} `json:"Location,omitempty" bson:"Location,omitempty"`
-	Rooms []any `json:"Rooms,omitempty" bson:"Rooms,omitempty"`
+	HotelID            string        `json:"HotelId" bson:"HotelId"`
+	HotelName          string        `json:"HotelName" bson:"HotelName"`
+	Description        string        `json:"Description" bson:"Description"`
+	DescriptionFr      string        `json:"Description_fr,omitempty" bson:"Description_fr,omitempty"`
+	Category           string        `json:"Category" bson:"Category"`
+	Tags               []string      `json:"Tags" bson:"Tags"`
+	ParkingIncluded    bool          `json:"ParkingIncluded" bson:"ParkingIncluded"`
+	IsDeleted          bool          `json:"IsDeleted" bson:"IsDeleted"`
+	LastRenovationDate time.Time     `json:"LastRenovationDate" bson:"LastRenovationDate"`
+	Rating             float64       `json:"Rating" bson:"Rating"`
+	Address            Address       `json:"Address" bson:"Address"`
+	Location           *GeoJSONPoint `json:"Location,omitempty" bson:"Location,omitempty"`
+	Rooms              []Room        `json:"Rooms,omitempty" bson:"Rooms,omitempty"`
+	// DescriptionVector is only present in pre-vectorized data files
+	DescriptionVector []float32 `json:"DescriptionVector,omitempty" bson:"DescriptionVector,omitempty"`
 }
 
+// CurrentSchemaVersion is the SchemaVersion of the hotel documents this code writes.
+// Raise it, and register a migration from the previous version in the vector store,
+// whenever the stored shape changes in a way older documents would be misread.
+const CurrentSchemaVersion = 1
+
 // HotelForVectorStore represents hotel data stored in vector database (excludes certain fields)
 type HotelForVectorStore struct {
 	HotelID            string    `json:"HotelId" bson:"HotelId"`
@@ -43,13 +102,51 @@ type HotelForVectorStore struct {
 	LastRenovationDate time.Time `json:"LastRenovationDate" bson:"LastRenovationDate"`
 	Rating             float64   `json:"Rating" bson:"Rating"`
 	Address            Address   `json:"Address" bson:"Address"`
-	DescriptionVector  []float32 `json:"DescriptionVector,omitempty" bson:"DescriptionVector,omitempty"`
+	// Location is nil for hotels without coordinates
+	Location *GeoJSONPoint `json:"Location,omitempty" bson:"Location,omitempty"`
+	// Rooms is nil for hotels without rooms
+	Rooms             *RoomSummary `json:"Rooms,omitempty" bson:"Rooms,omitempty"`
+	DescriptionVector []float32    `json:"DescriptionVector,omitempty" bson:"DescriptionVector,omitempty"`
+	// DescriptionFrVector embeds Description_fr; it is only set when French embeddings
+	// are enabled and the hotel has French text
+	DescriptionFrVector []float32 `json:"DescriptionFrVector,omitempty" bson:"DescriptionFrVector,omitempty"`
+	// ContentHash identifies the text and model the vector was made from (see Hotel.ContentHash)
+	ContentHash string `json:"ContentHash,omitempty" bson:"ContentHash,omitempty"`
+	// SchemaVersion is the shape the document was written in; documents written before
+	// versions were recorded have none and read as 0
+	SchemaVersion int `json:"SchemaVersion,omitempty" bson:"SchemaVersion"`
 }
 
+// Provenance values record how a search result's Score was produced
+const (
+	// ProvenanceANN is a similarity from the approximate vector index
+	ProvenanceANN = "ann"
+	// ProvenanceExact is a similarity computed against every stored vector
+	ProvenanceExact = "exact"
+	// ProvenanceFused is the best score of a hotel found by several searches
+	ProvenanceFused = "fused"
+	// ProvenanceReranked is a score a re-ranker gave after the search
+	ProvenanceReranked = "reranked"
+)
+
 // HotelSearchResult represents a hotel with similarity score
 type HotelSearchResult struct {
 	Hotel HotelForVectorStore
+	// Score is the final score the results are ordered by
 	Score float64
+	// Rank is the 1-based position in the final results, or 0 when not ranked yet
+	Rank int
+	// RawScore is the score the search engine returned, before fusion or re-ranking
+	RawScore float64
+	// Provenance is one of the Provenance values, or empty when unknown
+	Provenance string
+}
+
+// RankResults numbers results from 1 in their current order
+func RankResults(results []HotelSearchResult) {
+	for i := range results {
+		results[i].Rank = i + 1
+	}
 }
 
 // ToVectorStore converts a Hotel to HotelForVectorStore (excludes certain fields)
@@ -65,10 +162,95 @@ func (h *Hotel) ToVectorStore() HotelForVectorStore {
 		LastRenovationDate: h.LastRenovationDate,
 		Rating:             h.Rating,
 		Address:            h.Address,
+		Location:           h.Location,
+		Rooms:              SummarizeRooms(h.Rooms),
+		DescriptionVector:  h.DescriptionVector,
+		SchemaVersion:      CurrentSchemaVersion,
 	}
 }
 
+// SummarizeRooms returns the count, rate range, and types of rooms, or nil when there are none
+func SummarizeRooms(rooms []Room) *RoomSummary {
+	if len(rooms) == 0 {
+		return nil
+	}
+	summary := &RoomSummary{Count: len(rooms), MinRate: rooms[0].BaseRate, MaxRate: rooms[0].BaseRate}
+	for _, room := range rooms {
+		summary.MinRate = min(summary.MinRate, room.BaseRate)
+		summary.MaxRate = max(summary.MaxRate, room.BaseRate)
+		if room.Type != "" && !slices.Contains(summary.Types, room.Type) {
+			summary.Types = append(summary.Types, room.Type)
+		}
+	}
+	return summary
+}
+
+// ValidationError lists every problem Hotel.Validate found with one hotel
+type ValidationError struct {
+	HotelID  string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	id := e.HotelID
+	if id == "" {
+		id = "without HotelId"
+	}
+	return fmt.Sprintf("hotel %s is invalid: %s", id, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks the fields a hotel needs before it is embedded and stored: a HotelId,
+// HotelName, and Description, a Rating from 0 to 5, and a valid Location when one is
+// given. It returns a *ValidationError listing all the problems, or nil.
+func (h *Hotel) Validate() error {
+	var problems []string
+	if strings.TrimSpace(h.HotelID) == "" {
+		problems = append(problems, "no HotelId")
+	}
+	if strings.TrimSpace(h.HotelName) == "" {
+		problems = append(problems, "empty HotelName")
+	}
+	if strings.TrimSpace(h.Description) == "" {
+		problems = append(problems, "empty Description")
+	}
+	if h.Rating < 0 || h.Rating > 5 {
+		problems = append(problems, fmt.Sprintf("Rating %g is outside 0-5", h.Rating))
+	}
+	if h.Location != nil {
+		if err := h.Location.Validate(); err != nil {
+			problems = append(problems, "invalid Location: "+err.Error())
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{HotelID: h.HotelID, Problems: problems}
+}
+
 // PageContent generates the text content for embedding
 func (h *Hotel) PageContent() string {
-	return "Hotel: " + h.HotelName + "\n\n" + h.Description
+	return pageContent(h.HotelName, h.Description)
+}
+
+// ContentHash returns the hex SHA-256 of the embedding model name and PageContent, so a
+// stored hotel needs a new vector exactly when its hash changes
+func (h *Hotel) ContentHash(model string) string {
+	return contentHash(model, h.PageContent())
+}
+
+// HashContent returns the ContentHash the document should carry for model: the same
+// hash as Hotel.ContentHash for the hotel it was made from
+func (h *HotelForVectorStore) HashContent(model string) string {
+	return contentHash(model, pageContent(h.HotelName, h.Description))
+}
+
+// pageContent joins the fields a hotel is embedded from
+func pageContent(name, description string) string {
+	return "Hotel: " + name + "\n\n" + description
+}
+
+// contentHash hashes the model name and page content
+func contentHash(model, content string) string {
+	sum := sha256.Sum256([]byte(model + "\n" + content))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestHotelContentHash(t *testing.T) {
+	hotel := Hotel{HotelID: "1", HotelName: "Stay-Kay City Hotel", Description: "Close to Times Square.", Rating: 3.6}
+	hash := hotel.ContentHash("text-embedding-3-small")
+	if len(hash) != 64 {
+		t.Fatalf("hash %q is not a hex SHA-256", hash)
+	}
+
+	tests := []struct {
+		name   string
+		change func(h *Hotel)
+		model  string
+		same   bool
+	}{
+		{"same content", func(h *Hotel) {}, "text-embedding-3-small", true},
+		{"field outside the page content", func(h *Hotel) { h.Rating = 4.8; h.Tags = []string{"pool"} }, "text-embedding-3-small", true},
+		{"description", func(h *Hotel) { h.Description += " Renovated." }, "text-embedding-3-small", false},
+		{"name", func(h *Hotel) { h.HotelName = "Stay-Kay Hotel" }, "text-embedding-3-small", false},
+		{"model", func(h *Hotel) {}, "text-embedding-3-large", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := hotel
+			tt.change(&changed)
+			if got := changed.ContentHash(tt.model) == hash; got != tt.same {
+				t.Errorf("hash unchanged = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestHotelForVectorStoreHashContent(t *testing.T) {
+	hotel := Hotel{HotelID: "1", HotelName: "Stay-Kay City Hotel", Description: "Close to Times Square."}
+	doc := hotel.ToVectorStore()
+	if got, want := doc.HashContent("text-embedding-3-large"), hotel.ContentHash("text-embedding-3-large"); got != want {
+		t.Errorf("HashContent = %q, want the hotel's ContentHash %q", got, want)
+	}
+}
+
+func TestSummarizeRooms(t *testing.T) {
+	tests := []struct {
+		name  string
+		rooms []Room
+		want  *RoomSummary
+	}{
+		{"no rooms", nil, nil},
+		{"one room", []Room{{Type: "Suite", BaseRate: 250.99}}, &RoomSummary{Count: 1, MinRate: 250.99, MaxRate: 250.99, Types: []string{"Suite"}}},
+		{
+			"types in order of appearance",
+			[]Room{{Type: "Deluxe Room", BaseRate: 150.99}, {Type: "Budget Room", BaseRate: 80.99}, {Type: "Deluxe Room", BaseRate: 160.99}, {BaseRate: 99}},
+			&RoomSummary{Count: 4, MinRate: 80.99, MaxRate: 160.99, Types: []string{"Deluxe Room", "Budget Room"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummarizeRooms(tt.rooms); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SummarizeRooms() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeoJSONPointValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		point   GeoJSONPoint
+		wantErr string
+	}{
+		{"valid", *NewGeoJSONPoint(-73.975403, 40.760586), ""},
+		{"bounds", *NewGeoJSONPoint(180, -90), ""},
+		{"type", GeoJSONPoint{Type: "LineString", Coordinates: []float64{0, 0}}, `type "LineString" is not Point`},
+		{"missing latitude", GeoJSONPoint{Type: "Point", Coordinates: []float64{-73.9}}, "1 coordinates"},
+		{"longitude", *NewGeoJSONPoint(-200, 40), "longitude -200 is outside"},
+		{"latitude then longitude", *NewGeoJSONPoint(40.760586, -173.975403), "latitude -173.975403 is outside"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.point.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHotelForVectorStoreLocationRoundTrip(t *testing.T) {
+	located := Hotel{HotelID: "1", Location: NewGeoJSONPoint(-73.975403, 40.760586)}
+	codecs := []struct {
+		name      string
+		marshal   func(any) ([]byte, error)
+		unmarshal func([]byte, any) error
+	}{
+		{"json", json.Marshal, json.Unmarshal},
+		{"bson", bson.Marshal, bson.Unmarshal},
+	}
+
+	for _, codec := range codecs {
+		for _, hotel := range []Hotel{located, {HotelID: "2"}} {
+			t.Run(codec.name+"/"+hotel.HotelID, func(t *testing.T) {
+				data, err := codec.marshal(hotel.ToVectorStore())
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got HotelForVectorStore
+				if err := codec.unmarshal(data, &got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got.Location, hotel.Location) {
+					t.Errorf("Location = %+v, want %+v", got.Location, hotel.Location)
+				}
+				// Absent stays absent rather than becoming a zero point
+				if hotel.Location == nil && bytes.Contains(data, []byte("Location")) {
+					t.Errorf("encoded %q, want no Location key", data)
+				}
+			})
+		}
+	}
+}
+
+func TestHotelValidate(t *testing.T) {
+	valid := Hotel{HotelID: "1", HotelName: "Stay-Kay City Hotel", Description: "Close to Times Square.", Rating: 3.6, Location: NewGeoJSONPoint(-73.975403, 40.760586)}
+
+	tests := []struct {
+		name   string
+		change func(h *Hotel)
+		want   []string
+	}{
+		{"valid", func(h *Hotel) {}, nil},
+		{"no location", func(h *Hotel) { h.Location = nil }, nil},
+		{"rating bounds", func(h *Hotel) { h.Rating = 5 }, nil},
+		{"missing HotelId", func(h *Hotel) { h.HotelID = " " }, []string{"no HotelId"}},
+		{"empty HotelName", func(h *Hotel) { h.HotelName = "" }, []string{"empty HotelName"}},
+		{"rating", func(h *Hotel) { h.Rating = 12 }, []string{"Rating 12 is outside 0-5"}},
+		{"location", func(h *Hotel) { h.Location = NewGeoJSONPoint(0, 91) }, []string{"invalid Location: latitude 91 is outside"}},
+		{
+			"every problem",
+			func(h *Hotel) { *h = Hotel{Rating: -0.5, Location: &GeoJSONPoint{}} },
+			[]string{"no HotelId", "empty HotelName", "empty Description", "Rating -0.5 is outside 0-5", `invalid Location: type "" is not Point`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotel := valid
+			tt.change(&hotel)
+			err := hotel.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Problems) != len(tt.want) {
+				t.Fatalf("err = %v, want the problems %q", err, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(verr.Problems[i], want) {
+					t.Errorf("problem %d = %q, want %q", i, verr.Problems[i], want)
+				}
+			}
+			if verr.HotelID != hotel.HotelID || !strings.HasPrefix(err.Error(), "hotel ") {
+				t.Errorf("err = %q", err)
+			}
+		})
+	}
+}
+
+func TestHotelDocument(t *testing.T) {
+	hotel := Hotel{HotelID: "1", HotelName: "Stay-Kay City Hotel", Description: "Close to Times Square.", Rating: 3.6, Location: NewGeoJSONPoint(-73.975403, 40.760586)}
+	doc := hotel.ToVectorStore()
+	for _, d := range []Document{&hotel, &doc} {
+		if d.ID() != "1" || d.EmbeddingText() != "Close to Times Square." {
+			t.Errorf("%T: ID %q, EmbeddingText %q", d, d.ID(), d.EmbeddingText())
+		}
+		fields := d.DisplayFields()
+		if fields["Rating"] != "3.6" || fields["Location"] != " (lat 40.760586, lng -73.975403)" {
+			t.Errorf("%T fields = %v", d, fields)
+		}
+		if _, ok := fields["Rooms.Count"]; ok {
+			t.Errorf("%T has room fields without rooms", d)
+		}
+		// Every display field has a place in the order
+		order := d.(FieldOrderer).FieldOrder()
+		for name := range fields {
+			if !slices.Contains(order, name) {
+				t.Errorf("%T field %s is not in FieldOrder", d, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RoomForVectorStore is one room of a hotel stored as its own document in the rooms
+// collection, so searches can match individual rooms
+type RoomForVectorStore struct {
+	// RoomID is the parent HotelId and the room's 1-based position in Rooms, e.g. "1:2"
+	RoomID         string   `json:"RoomId" bson:"RoomId"`
+	HotelID        string   `json:"HotelId" bson:"HotelId"`
+	HotelName      string   `json:"HotelName" bson:"HotelName"`
+	Description    string   `json:"Description" bson:"Description"`
+	Type           string   `json:"Type" bson:"Type"`
+	BaseRate       float64  `json:"BaseRate" bson:"BaseRate"`
+	BedOptions     string   `json:"BedOptions" bson:"BedOptions"`
+	SleepsCount    int      `json:"SleepsCount" bson:"SleepsCount"`
+	SmokingAllowed bool     `json:"SmokingAllowed" bson:"SmokingAllowed"`
+	Tags           []string `json:"Tags" bson:"Tags"`
+	// DescriptionVector embeds EmbeddingText
+	DescriptionVector []float32 `json:"DescriptionVector,omitempty" bson:"DescriptionVector,omitempty"`
+}
+
+// RoomSearchResult represents a room with similarity score
+type RoomSearchResult struct {
+	Room  RoomForVectorStore
+	Score float64
+}
+
+// HotelRooms groups the rooms a search matched in one hotel
+type HotelRooms struct {
+	HotelID   string
+	HotelName string
+	// Score is the best score of the hotel's rooms
+	Score float64
+	// Rooms are in descending score order
+	Rooms []RoomSearchResult
+}
+
+// RoomDocuments returns one document per room of the hotel, or nil when it has none
+func (h *Hotel) RoomDocuments() []RoomForVectorStore {
+	if len(h.Rooms) == 0 {
+		return nil
+	}
+	docs := make([]RoomForVectorStore, len(h.Rooms))
+	for i, room := range h.Rooms {
+		docs[i] = RoomForVectorStore{
+			RoomID:         fmt.Sprintf("%s:%d", h.HotelID, i+1),
+			HotelID:        h.HotelID,
+			HotelName:      h.HotelName,
+			Description:    room.Description,
+			Type:           room.Type,
+			BaseRate:       room.BaseRate,
+			BedOptions:     room.BedOptions,
+			SleepsCount:    room.SleepsCount,
+			SmokingAllowed: room.SmokingAllowed,
+			Tags:           room.Tags,
+		}
+	}
+	return docs
+}
+
+// GroupRoomsByHotel groups room results by parent hotel. Hotels are ordered by their
+// best room's score, and ties keep the order in which the hotels first appear.
+func GroupRoomsByHotel(results []RoomSearchResult) []HotelRooms {
+	var groups []HotelRooms
+	index := make(map[string]int)
+	for _, result := range results {
+		i, ok := index[result.Room.HotelID]
+		if !ok {
+			i = len(groups)
+			index[result.Room.HotelID] = i
+			groups = append(groups, HotelRooms{HotelID: result.Room.HotelID, HotelName: result.Room.HotelName, Score: result.Score})
+		}
+		groups[i].Rooms = append(groups[i].Rooms, result)
+		groups[i].Score = max(groups[i].Score, result.Score)
+	}
+	for _, group := range groups {
+		sort.SliceStable(group.Rooms, func(a, b int) bool {
+			return group.Rooms[a].Score > group.Rooms[b].Score
+		})
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].Score > groups[b].Score
+	})
+	return groups
+}
+
+// ID returns the RoomId
+func (r *RoomForVectorStore) ID() string {
+	return r.RoomID
+}
+
+// EmbeddingText describes the room with its type, beds, occupancy, rate, and tags, so
+// that queries such as "suite with two queen beds under $200" can match it
+func (r *RoomForVectorStore) EmbeddingText() string {
+	parts := []string{r.Description}
+	if r.Type != "" {
+		parts = append(parts, "Type: "+r.Type)
+	}
+	if r.BedOptions != "" {
+		parts = append(parts, "Beds: "+r.BedOptions)
+	}
+	if r.SleepsCount > 0 {
+		parts = append(parts, fmt.Sprintf("Sleeps %d", r.SleepsCount))
+	}
+	parts = append(parts, fmt.Sprintf("Rate: $%.2f per night", r.BaseRate))
+	if r.SmokingAllowed {
+		parts = append(parts, "Smoking allowed")
+	} else {
+		parts = append(parts, "Non-smoking")
+	}
+	if len(r.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(r.Tags, ", "))
+	}
+	return strings.Join(parts, ". ")
+}
+
+// DisplayFields returns the room's fields as the synthesizer reads them
+func (r *RoomForVectorStore) DisplayFields() map[string]string {
+	return map[string]string{
+		"RoomId":         r.RoomID,
+		"Description":    r.Description,
+		"Type":           r.Type,
+		"BaseRate":       fmt.Sprintf("%.2f", r.BaseRate),
+		"BedOptions":     r.BedOptions,
+		"SleepsCount":    fmt.Sprint(r.SleepsCount),
+		"SmokingAllowed": fmt.Sprint(r.SmokingAllowed),
+		"Tags":           strings.Join(r.Tags, ", "),
+	}
+}
+
+// FieldOrder lists the room display fields from most to least descriptive
+func (r *RoomForVectorStore) FieldOrder() []string {
+	return roomFieldOrder
+}
+
+// roomFieldOrder is the order of the room display fields
+var roomFieldOrder = []string{"RoomId", "Type", "Description", "BedOptions", "SleepsCount", "BaseRate", "SmokingAllowed", "Tags"}
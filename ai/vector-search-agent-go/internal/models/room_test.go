@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoomDocuments(t *testing.T) {
+	hotel := Hotel{HotelID: "7", HotelName: "Roach Motel", Rooms: []Room{
+		{Description: "Suite, 2 Queen Beds (Amenities)", Type: "Suite", BaseRate: 189.99, BedOptions: "2 Queen Beds", SleepsCount: 4, Tags: []string{"jacuzzi tub"}},
+		{Description: "Budget Room, 1 King Bed", Type: "Budget Room", BaseRate: 79.99, BedOptions: "1 King Bed", SleepsCount: 2, SmokingAllowed: true},
+	}}
+
+	docs := hotel.RoomDocuments()
+	if len(docs) != 2 || docs[0].RoomID != "7:1" || docs[1].RoomID != "7:2" || docs[1].HotelID != "7" || docs[1].HotelName != "Roach Motel" {
+		t.Fatalf("docs = %+v", docs)
+	}
+
+	want := "Suite, 2 Queen Beds (Amenities). Type: Suite. Beds: 2 Queen Beds. Sleeps 4. Rate: $189.99 per night. Non-smoking. Tags: jacuzzi tub"
+	if got := docs[0].EmbeddingText(); got != want {
+		t.Errorf("EmbeddingText =\n%s\nwant\n%s", got, want)
+	}
+	if got := docs[1].EmbeddingText(); !strings.Contains(got, "Smoking allowed") || strings.Contains(got, "Tags:") {
+		t.Errorf("EmbeddingText = %s", got)
+	}
+
+	if (&Hotel{HotelID: "8"}).RoomDocuments() != nil {
+		t.Error("hotel without rooms produced room documents")
+	}
+}
+
+func TestGroupRoomsByHotel(t *testing.T) {
+	room := func(hotelID, roomID string, score float64) RoomSearchResult {
+		return RoomSearchResult{Room: RoomForVectorStore{RoomID: roomID, HotelID: hotelID, HotelName: "Hotel " + hotelID}, Score: score}
+	}
+	groups := GroupRoomsByHotel([]RoomSearchResult{
+		room("1", "1:1", 0.70),
+		room("2", "2:3", 0.90),
+		room("1", "1:2", 0.80),
+		room("3", "3:1", 0.80),
+	})
+
+	var got []string
+	for _, group := range groups {
+		ids := make([]string, len(group.Rooms))
+		for i, r := range group.Rooms {
+			ids[i] = r.Room.RoomID
+		}
+		got = append(got, group.HotelID+"="+strings.Join(ids, ","))
+	}
+	// Hotel 1 and 3 tie on their best room; hotel 1 appeared first
+	want := "2=2:3 1=1:2,1:1 3=3:1"
+	if strings.Join(got, " ") != want {
+		t.Errorf("groups = %v, want %s", got, want)
+	}
+	if groups[1].Score != 0.80 || groups[1].HotelName != "Hotel 1" {
+		t.Errorf("group = %+v, want the best room's score", groups[1])
+	}
+	if GroupRoomsByHotel(nil) != nil {
+		t.Error("no results produced groups")
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+)
+
+// RunMetrics is the token usage of one command run, stored in the metrics collection to
+// track spend over time. Documents counts what the run read or wrote, by a name the
+// command chooses, such as "inserted" or "results".
+type RunMetrics struct {
+	Command     string `json:"command" bson:"command"`
+	Environment string `json:"environment,omitempty" bson:"environment,omitempty"`
+	SessionID   string `json:"sessionId,omitempty" bson:"sessionId,omitempty"`
+	RequestID   string `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	// Usage holds the tokens of each deployment the run called
+	Usage            []RunUsage       `json:"usage" bson:"usage"`
+	PromptTokens     int64            `json:"promptTokens" bson:"promptTokens"`
+	CompletionTokens int64            `json:"completionTokens" bson:"completionTokens"`
+	EmbeddingTokens  int64            `json:"embeddingTokens" bson:"embeddingTokens"`
+	EstimatedCost    float64          `json:"estimatedCost" bson:"estimatedCost"`
+	Documents        map[string]int64 `json:"documents,omitempty" bson:"documents,omitempty"`
+	DurationMs       int64            `json:"durationMs" bson:"durationMs"`
+	Error            string           `json:"error,omitempty" bson:"error,omitempty"`
+	// CreatedAt is the key of the TTL index that ages records out
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	// Build identifies the binary that produced the record
+	Build buildinfo.Info `json:"build" bson:"build"`
+}
+
+// RunUsage is the token usage of one deployment in a run. The input tokens of an
+// embedding deployment are counted as EmbeddingTokens, not PromptTokens.
+type RunUsage struct {
+	Deployment       string  `json:"deployment" bson:"deployment"`
+	Calls            int     `json:"calls" bson:"calls"`
+	PromptTokens     int64   `json:"promptTokens" bson:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens" bson:"completionTokens"`
+	EmbeddingTokens  int64   `json:"embeddingTokens" bson:"embeddingTokens"`
+	EstimatedCost    float64 `json:"estimatedCost" bson:"estimatedCost"`
+}
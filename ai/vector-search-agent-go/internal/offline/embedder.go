@@ -0,0 +1,87 @@
+package offline
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// stopWords are left out of embeddings so that shared filler words don't make
+// unrelated texts look similar
+var stopWords = map[string]bool{
+	"and": true, "are": true, "but": true, "for": true, "from": true, "has": true,
+	"have": true, "into": true, "its": true, "near": true, "not": true, "our": true,
+	"the": true, "this": true, "that": true, "with": true, "you": true, "your": true,
+}
+
+// FakeEmbedder generates deterministic embeddings without a model. Each word is hashed
+// to a dimension and a sign, so texts that share words get similar vectors and the same
+// text always gets the same vector.
+type FakeEmbedder struct {
+	dimensions int
+}
+
+// NewFakeEmbedder creates an embedder producing vectors with the given number of dimensions,
+// or vectorstore.DefaultEmbeddingDimensions when dimensions is not positive
+func NewFakeEmbedder(dimensions int) *FakeEmbedder {
+	if dimensions <= 0 {
+		dimensions = vectorstore.DefaultEmbeddingDimensions
+	}
+	return &FakeEmbedder{dimensions: dimensions}
+}
+
+// Dimensions returns the length of the generated vectors
+func (e *FakeEmbedder) Dimensions() int {
+	return e.dimensions
+}
+
+// Embed returns the unit-length vector for text. Text without any words gets a zero vector.
+func (e *FakeEmbedder) Embed(text string) []float32 {
+	vector := make([]float32, e.dimensions)
+	for _, word := range words(text) {
+		h := fnv.New64a()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(e.dimensions)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
+
+// words splits text into lowercase words of three or more letters, without stop
+// words and with a plural "s" removed
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	out := fields[:0]
+	for _, word := range fields {
+		if len(word) < 3 || stopWords[word] {
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = word[:len(word)-1]
+		}
+		out = append(out, word)
+	}
+	return out
+}
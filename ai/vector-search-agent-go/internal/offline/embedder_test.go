@@ -0,0 +1,54 @@
+package offline
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+func norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+func TestFakeEmbedderIsDeterministic(t *testing.T) {
+	e := NewFakeEmbedder(64)
+	first := e.Embed("Quiet hotel with a pool near the beach")
+	second := NewFakeEmbedder(64).Embed("Quiet hotel with a pool near the beach")
+
+	if len(first) != 64 || !slices.Equal(first, second) {
+		t.Errorf("embeddings differ between embedders: %v vs %v", first, second)
+	}
+	if n := norm(first); math.Abs(n-1) > 1e-6 {
+		t.Errorf("norm = %v, want 1", n)
+	}
+}
+
+func TestFakeEmbedderSimilarity(t *testing.T) {
+	e := NewFakeEmbedder(256)
+	query := e.Embed("beach resort with pools")
+	related := e.Embed("A resort on the beach with two pools and a spa")
+	unrelated := e.Embed("Downtown business motel near the convention center")
+
+	// Plurals and stop words don't matter
+	if got := cosine(query, e.Embed("the beach resort with a pool")); got < 0.999 {
+		t.Errorf("similarity ignoring plurals and stop words = %v, want 1", got)
+	}
+	if cosine(query, related) <= cosine(query, unrelated) {
+		t.Errorf("related text scored %v, unrelated %v", cosine(query, related), cosine(query, unrelated))
+	}
+}
+
+func TestFakeEmbedderEdgeCases(t *testing.T) {
+	if got := NewFakeEmbedder(0).Dimensions(); got != vectorstore.DefaultEmbeddingDimensions {
+		t.Errorf("default dimensions = %d, want %d", got, vectorstore.DefaultEmbeddingDimensions)
+	}
+	if v := NewFakeEmbedder(8).Embed("a an the"); len(v) != 8 || norm(v) != 0 {
+		t.Errorf("embedding of stop words = %v, want a zero vector", v)
+	}
+}
@@ -0,0 +1,226 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"github.com/openai/openai-go/v3"
+)
+
+// Deployment names reported in usage for the offline model
+const (
+	EmbeddingDeployment = "offline-embedding"
+	ChatDeployment      = "offline-chat"
+)
+
+// AnswerPrefix starts every offline answer, so it can't be mistaken for model output
+const AnswerPrefix = "[OFFLINE DEMO] "
+
+// maxAnswerHotels is how many retrieved hotels an offline answer describes
+const maxAnswerHotels = 3
+
+var (
+	// plannerRequest and plannerNeighbors pick the request and k out of the planner's user message
+	plannerRequest   = regexp.MustCompile(`"(.+)"`)
+	plannerNeighbors = regexp.MustCompile(`nearestNeighbors=(\d+)`)
+)
+
+// Model stands in for the Azure OpenAI deployments. Embeddings come from a FakeEmbedder,
+// the planner always calls the first tool with the user's request, and the synthesizer
+// fills a template from the hotels in its context.
+type Model struct {
+	embedder *FakeEmbedder
+	usage    *clients.UsageTracker
+}
+
+// NewModel creates an offline model embedding with embedder
+func NewModel(embedder *FakeEmbedder) *Model {
+	return &Model{embedder: embedder, usage: clients.NewUsageTracker()}
+}
+
+// Usage returns the tracker accumulating the approximate token usage of all calls
+func (m *Model) Usage() *clients.UsageTracker {
+	return m.usage
+}
+
+// record adds one call to the model-wide tracker and to the tracker carried by ctx, if any.
+// Tokens are approximated by counting words.
+func (m *Model) record(ctx context.Context, deployment, prompt, completion string) {
+	promptTokens, completionTokens := int64(len(strings.Fields(prompt))), int64(len(strings.Fields(completion)))
+	m.usage.Record(deployment, promptTokens, completionTokens)
+	if t, ok := clients.UsageTrackerFromContext(ctx); ok && t != m.usage {
+		t.Record(deployment, promptTokens, completionTokens)
+	}
+}
+
+// GenerateEmbedding returns the fake embedding of text
+func (m *Model) GenerateEmbedding(ctx context.Context, text string) (_ []float32, err error) {
+	done := trace.Start(ctx, trace.EventEmbedding)
+	defer func() { done(err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.record(ctx, EmbeddingDeployment, text, "")
+	return m.embedder.Embed(text), nil
+}
+
+// ChatCompletionWithTools answers the planner with one call of the first tool, passing the
+// quoted request from the user message as the query
+func (m *Model) ChatCompletionWithTools(ctx context.Context, systemPrompt, userMessage string, tools []openai.ChatCompletionToolUnionParam) (_ *openai.ChatCompletion, err error) {
+	done := trace.Start(ctx, trace.EventPlannerCompletion)
+	defer func() { done(err) }()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(tools) == 0 || tools[0].GetFunction() == nil {
+		return nil, errors.New("offline planner needs a function tool")
+	}
+
+	query := userMessage
+	if match := plannerRequest.FindStringSubmatch(userMessage); match != nil {
+		query = match[1]
+	}
+	args := map[string]any{"query": query}
+	if match := plannerNeighbors.FindStringSubmatch(userMessage); match != nil {
+		k, _ := strconv.Atoi(match[1])
+		args["nearestNeighbors"] = k
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode from JSON, as the SDK does, so the response behaves like a real one
+	body, err := json.Marshal(map[string]any{
+		"id":     "offline-planner",
+		"object": "chat.completion",
+		"model":  ChatDeployment,
+		"choices": []any{map[string]any{
+			"index":         0,
+			"finish_reason": "tool_calls",
+			"message": map[string]any{
+				"role": "assistant",
+				"tool_calls": []any{map[string]any{
+					"id":       "offline-call-1",
+					"type":     "function",
+					"function": map[string]any{"name": tools[0].GetFunction().Name, "arguments": string(argsJSON)},
+				}},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp openai.ChatCompletion
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to build offline planner response: %w", err)
+	}
+
+	m.record(ctx, ChatDeployment, systemPrompt+" "+userMessage, string(argsJSON))
+	slog.DebugContext(ctx, "planner response", "deployment", ChatDeployment, "tool", tools[0].GetFunction().Name)
+	return &resp, nil
+}
+
+// ChatCompletion answers the synthesizer with a templated summary of the hotels in the
+// user message
+func (m *Model) ChatCompletion(ctx context.Context, systemPrompt, userMessage string) (_ string, err error) {
+	done := trace.Start(ctx, trace.EventSynthesizerCompletion)
+	defer func() { done(err) }()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	answer := templateAnswer(userMessage)
+	m.record(ctx, ChatDeployment, systemPrompt+" "+userMessage, answer)
+	slog.DebugContext(ctx, "synthesizer response", "deployment", ChatDeployment, "chars", len(answer))
+	return answer, nil
+}
+
+// ChatCompletionStream writes the templated answer to w word by word and returns it
+func (m *Model) ChatCompletionStream(ctx context.Context, systemPrompt, userMessage string, w io.Writer) (string, error) {
+	answer, err := m.ChatCompletion(ctx, systemPrompt, userMessage)
+	if err != nil {
+		return "", err
+	}
+	for _, word := range strings.SplitAfter(answer, " ") {
+		if _, err := io.WriteString(w, word); err != nil {
+			return "", fmt.Errorf("failed to write stream: %w", err)
+		}
+	}
+	return answer, nil
+}
+
+// contextHotel is a hotel read back from the synthesizer context
+type contextHotel struct {
+	name, category, city, rating, description string
+}
+
+// templateAnswer describes the first hotels in the context written by a
+// vectorstore.Formatter, in either style
+func templateAnswer(message string) string {
+	var hotels []contextHotel
+	var current *contextHotel
+	set := func(key, value string) {
+		if key == "HotelName" {
+			hotels = append(hotels, contextHotel{name: value})
+			current = &hotels[len(hotels)-1]
+			return
+		}
+		if current == nil {
+			return
+		}
+		switch key {
+		case "Category":
+			current.category = value
+		case "Address.City":
+			current.city = value
+		case "Rating":
+			current.rating = value
+		case "Description":
+			current.description = value
+		}
+	}
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		// The JSON style writes each hotel as one object of string fields
+		var fields map[string]any
+		if strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &fields) == nil {
+			if name, ok := fields["HotelName"].(string); ok {
+				set("HotelName", name)
+				for key, value := range fields {
+					if text, ok := value.(string); ok && key != "HotelName" {
+						set(key, text)
+					}
+				}
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		set(key, value)
+	}
+
+	if len(hotels) == 0 {
+		return AnswerPrefix + "No hotels were retrieved, so there is nothing to recommend."
+	}
+
+	var b strings.Builder
+	b.WriteString(AnswerPrefix + "These are the closest matches in the local sample data:\n")
+	for i, hotel := range hotels[:min(len(hotels), maxAnswerHotels)] {
+		fmt.Fprintf(&b, "\n%d. %s (%s, %s, rated %s)", i+1, hotel.name, hotel.category, hotel.city, hotel.rating)
+		if sentence, _, _ := strings.Cut(hotel.description, ". "); sentence != "" {
+			fmt.Fprintf(&b, ": %s.", strings.TrimSuffix(sentence, "."))
+		}
+	}
+	return b.String()
+}
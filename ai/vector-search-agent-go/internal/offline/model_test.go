@@ -0,0 +1,94 @@
+package offline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/openai/openai-go/v3"
+)
+
+func TestModelPlannerCallsFirstTool(t *testing.T) {
+	m := NewModel(NewFakeEmbedder(16))
+	tool := openai.ChatCompletionFunctionTool(openai.FunctionDefinitionParam{Name: "search_hotels_collection"})
+
+	resp, err := m.ChatCompletionWithTools(context.Background(), "system", `Call the tool for "quiet hotel near the beach" with nearestNeighbors=4`, []openai.ChatCompletionToolUnionParam{tool})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "search_hotels_collection" {
+		t.Fatalf("tool calls = %+v", calls)
+	}
+	var args struct {
+		Query            string `json:"query"`
+		NearestNeighbors int    `json:"nearestNeighbors"`
+	}
+	if err := json.Unmarshal([]byte(calls[0].Function.Arguments), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Query != "quiet hotel near the beach" || args.NearestNeighbors != 4 {
+		t.Errorf("arguments = %+v", args)
+	}
+
+	if _, err := m.ChatCompletionWithTools(context.Background(), "system", "hi", nil); err == nil {
+		t.Error("planner without tools succeeded")
+	}
+}
+
+func TestModelSynthesizerTemplatesHotels(t *testing.T) {
+	m := NewModel(NewFakeEmbedder(16))
+	contexts := make(map[string]*strings.Builder)
+	for _, style := range []string{vectorstore.StyleText, vectorstore.StyleJSON} {
+		contexts[style] = &strings.Builder{}
+		for _, name := range []string{"Ocean Retreat", "City Inn", "Lake Lodge", "Hill House"} {
+			hotel := models.HotelForVectorStore{HotelID: name, HotelName: name, Category: "Resort", Rating: 4.5, Description: "Near the water. Lots of rooms."}
+			hotel.Address.City = "Seattle"
+			contexts[style].WriteString(vectorstore.Formatter{Style: style}.FormatHotel(models.HotelSearchResult{Hotel: hotel, Score: 0.9}))
+			contexts[style].WriteString("\n")
+		}
+	}
+	hotelContext := contexts[vectorstore.StyleText]
+
+	var stream bytes.Buffer
+	answer, err := m.ChatCompletionStream(context.Background(), "system", hotelContext.String(), &stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.String() != answer {
+		t.Errorf("streamed %q, returned %q", stream.String(), answer)
+	}
+	if !strings.HasPrefix(answer, AnswerPrefix) || !strings.Contains(answer, "1. Ocean Retreat (Resort, Seattle, rated 4.5): Near the water.") {
+		t.Errorf("answer = %q", answer)
+	}
+	// Only the first maxAnswerHotels are described
+	if !strings.Contains(answer, "3. Lake Lodge") || strings.Contains(answer, "Hill House") {
+		t.Errorf("answer = %q, want three hotels", answer)
+	}
+
+	empty, err := m.ChatCompletion(context.Background(), "system", "no hotels here")
+	if err != nil || !strings.Contains(empty, "No hotels were retrieved") {
+		t.Errorf("answer without hotels = %q, %v", empty, err)
+	}
+
+	// Every call is counted against the offline deployments
+	usage := m.Usage().Snapshot()
+	if len(usage) != 1 || usage[0].Deployment != ChatDeployment || usage[0].Calls != 2 {
+		t.Errorf("usage = %+v", usage)
+	}
+
+	// The JSON style reads back as the same hotels
+	jsonAnswer, err := m.ChatCompletion(context.Background(), "system", contexts[vectorstore.StyleJSON].String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	textAnswer, err := m.ChatCompletion(context.Background(), "system", hotelContext.String())
+	if err != nil || jsonAnswer != textAnswer {
+		t.Errorf("JSON context answer = %q, want the text context answer %q (%v)", jsonAnswer, textAnswer, err)
+	}
+}
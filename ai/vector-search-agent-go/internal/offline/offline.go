@@ -0,0 +1,43 @@
+// Package offline provides stand-ins for Azure OpenAI and DocumentDB so the sample can
+// run end to end without network access: a deterministic embedder, a canned chat model,
+// and an in-memory vector store loaded from the local hotel data. The results only
+// demonstrate the flow; they are not model output.
+package offline
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DefaultDataFile is the hotel data loaded when DATA_FILE_WITHOUT_VECTORS is unset
+const DefaultDataFile = "../data/Hotels.json"
+
+// banner is printed by every command running offline
+const banner = `
+************************************************************************
+*  OFFLINE MODE (OFFLINE_MODE=true)                                    *
+*  No Azure services are called. Embeddings are hashed words, answers  *
+*  are filled-in templates, and data lives in memory only. These are   *
+*  NOT real results.                                                   *
+************************************************************************
+`
+
+// Enabled reports whether OFFLINE_MODE is set to true
+func Enabled(getenv func(string) string) bool {
+	enabled, _ := strconv.ParseBool(getenv("OFFLINE_MODE"))
+	return enabled
+}
+
+// PrintBanner writes the offline mode warning to w
+func PrintBanner(w io.Writer) {
+	fmt.Fprint(w, banner)
+}
+
+// DataFile returns the hotel data file to load: DATA_FILE_WITHOUT_VECTORS, or DefaultDataFile
+func DataFile(getenv func(string) string) string {
+	if path := getenv("DATA_FILE_WITHOUT_VECTORS"); path != "" {
+		return path
+	}
+	return DefaultDataFile
+}
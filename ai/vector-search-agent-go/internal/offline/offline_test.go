@@ -0,0 +1,224 @@
+package offline_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/leakcheck"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics/metricstest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+// sampleData is the hotel data shipped with the sample, relative to this package
+const sampleData = "../../../data/Hotels.json"
+
+func sampleHotelNames(t *testing.T) map[string]bool {
+	t.Helper()
+	data, err := os.ReadFile(sampleData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hotels []models.Hotel
+	if err := json.Unmarshal(data, &hotels); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool, len(hotels))
+	for _, hotel := range hotels {
+		names[hotel.HotelName] = true
+	}
+	return names
+}
+
+func TestOfflineAgentEndToEnd(t *testing.T) {
+	leakcheck.Verify(t)
+	recorder := metricstest.Record(t)
+	ctx := context.Background()
+	embedder := offline.NewFakeEmbedder(256)
+	store, err := offline.LoadStore(ctx, sampleData, embedder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipeline := agents.NewDefaultPipeline(offline.NewModel(embedder), store, &agents.PlannerConfig{}, &agents.SynthesizerConfig{}, timeouts.Default())
+	pipeline.SetOutput(io.Discard)
+	state := agents.NewPipelineState("quiet hotel near the beach with a pool", 3)
+	if err := pipeline.Run(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(state.Answer, offline.AnswerPrefix) || len(state.Answer) <= len(offline.AnswerPrefix) {
+		t.Fatalf("answer = %q, want an offline answer", state.Answer)
+	}
+	if len(state.Results) != 3 {
+		t.Fatalf("retrieved %d hotels, want 3", len(state.Results))
+	}
+
+	// The answer names the retrieved hotels, which come from the sample data
+	names := sampleHotelNames(t)
+	for _, result := range state.Results {
+		if !names[result.Hotel.HotelName] {
+			t.Errorf("retrieved %q, which is not in the sample data", result.Hotel.HotelName)
+		}
+		if !strings.Contains(state.Answer, result.Hotel.HotelName) {
+			t.Errorf("answer does not mention %q:\n%s", result.Hotel.HotelName, state.Answer)
+		}
+	}
+	if len(state.Citations) == 0 {
+		t.Errorf("no citations for answer:\n%s", state.Answer)
+	}
+
+	if got := metricstest.Value(t, recorder, "agent_runs_total", "status", metrics.StatusOK); got != 1 {
+		t.Errorf("agent_runs_total = %v, want 1", got)
+	}
+	if got := metricstest.Value(t, recorder, "searches_total", "status", metrics.StatusOK); got < 1 {
+		t.Errorf("searches_total = %v, want the planner's searches counted", got)
+	}
+	if got := metricstest.Value(t, recorder, "search_latency_seconds"); got != metricstest.Value(t, recorder, "searches_total") {
+		t.Errorf("search_latency_seconds has %v observations, want one per search", got)
+	}
+}
+
+func TestOfflineRunCorrelatesLogs(t *testing.T) {
+	var logs bytes.Buffer
+	previous, level := slog.Default(), logging.Level()
+	slog.SetDefault(logging.New(&logs, slog.LevelDebug))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		logging.SetLevel(level)
+	})
+
+	ctx := session.WithRequestID(context.Background(), "req-e2e")
+	embedder := offline.NewFakeEmbedder(256)
+	store, err := offline.LoadStore(ctx, sampleData, embedder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := agents.NewDefaultPipeline(offline.NewModel(embedder), store, &agents.PlannerConfig{}, &agents.SynthesizerConfig{}, timeouts.Default())
+	pipeline.SetOutput(io.Discard)
+	state := agents.NewPipelineState("quiet hotel near the beach with a pool", 3)
+	if err := pipeline.Run(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every record of the run carries its request ID, whichever layer logged it: the
+	// store, the model clients, or the agents
+	messages := []string{"vector search returned", "planner response", "synthesizer response", "pipeline stage finished"}
+	seen := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if !strings.Contains(line, "requestId=req-e2e") {
+			t.Errorf("log record without the request ID: %s", line)
+		}
+		for _, msg := range messages {
+			if strings.Contains(line, `msg="`+msg+`"`) {
+				seen[msg] = true
+			}
+		}
+	}
+	for _, msg := range messages {
+		if !seen[msg] {
+			t.Errorf("no %q record from the run:\n%s", msg, logs.String())
+		}
+	}
+
+	// So do the trace events and the history record
+	if state.RequestID != "req-e2e" {
+		t.Errorf("state request ID = %q, want req-e2e", state.RequestID)
+	}
+	for _, event := range state.Trace.Events() {
+		if event.RequestID != "req-e2e" {
+			t.Errorf("trace event %s has request ID %q, want req-e2e", event.Name, event.RequestID)
+		}
+	}
+	summary := agents.BuildRunSummary(state, nil, 0, nil)
+	if got := agents.NewHistoryRecord(state, summary).RequestID; got != "req-e2e" {
+		t.Errorf("history request ID = %q, want req-e2e", got)
+	}
+}
+
+func TestOfflineRunLogsJSON(t *testing.T) {
+	var logs bytes.Buffer
+	previous, level := slog.Default(), logging.Level()
+	slog.SetDefault(logging.NewJSON(&logs, logging.LevelTrace))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		logging.SetLevel(level)
+	})
+
+	ctx := session.WithRequestID(session.WithID(context.Background(), "session-e2e"), "req-e2e")
+	embedder := offline.NewFakeEmbedder(256)
+	store, err := offline.LoadStore(ctx, sampleData, embedder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline := agents.NewDefaultPipeline(offline.NewModel(embedder), store, &agents.PlannerConfig{}, &agents.SynthesizerConfig{}, timeouts.Default())
+	pipeline.SetOutput(io.Discard)
+	if err := pipeline.Run(ctx, agents.NewPipelineState("quiet hotel near the beach with a pool", 3)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every line is one JSON record with the shared field names, including the trace
+	// records whose prompts and hotel context span many lines
+	components := map[string]bool{}
+	multiLine := false
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %v\n%s", err, line)
+		}
+		for _, key := range []string{"ts", "level", "msg", "component", "sessionId", "correlationId"} {
+			if _, ok := record[key]; !ok {
+				t.Errorf("record missing %s: %s", key, line)
+			}
+		}
+		if record["correlationId"] != "req-e2e" || record["sessionId"] != "session-e2e" {
+			t.Errorf("record not correlated with the run: %s", line)
+		}
+		component, _ := record["component"].(string)
+		components[component] = true
+		for _, value := range record {
+			if s, ok := value.(string); ok && strings.Contains(s, "\n") {
+				multiLine = true
+			}
+		}
+	}
+	for _, component := range []string{"offline", "agents"} {
+		if !components[component] {
+			t.Errorf("no records from %s; got components %v", component, components)
+		}
+	}
+	if !multiLine {
+		t.Errorf("no multi-line field in the trace records:\n%s", logs.String())
+	}
+}
+
+func TestEnabledAndDataFile(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(name string) string { return env[name] }
+
+	if offline.Enabled(getenv) || offline.DataFile(getenv) != offline.DefaultDataFile {
+		t.Error("offline mode enabled, or data file changed, with an empty environment")
+	}
+	env["OFFLINE_MODE"] = "true"
+	env["DATA_FILE_WITHOUT_VECTORS"] = "hotels.json"
+	if !offline.Enabled(getenv) || offline.DataFile(getenv) != "hotels.json" {
+		t.Error("OFFLINE_MODE or DATA_FILE_WITHOUT_VECTORS ignored")
+	}
+
+	var out bytes.Buffer
+	offline.PrintBanner(&out)
+	if !strings.Contains(out.String(), "OFFLINE MODE") || !strings.Contains(out.String(), "NOT real results") {
+		t.Errorf("banner = %q", out.String())
+	}
+}
@@ -0,0 +1,342 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// EmbeddingModel names the fake embedder in content hashes
+const EmbeddingModel = "offline"
+
+// Store is an in-memory stand-in for the DocumentDB collections. Searches compare the
+// query against every document, so results are exact. Nothing outlives the process.
+type Store struct {
+	mu       sync.RWMutex
+	ids      []string // insertion order, so equal scores rank the same way every run
+	hotels   map[string]models.HotelForVectorStore
+	feedback map[string]models.Feedback
+	history  []models.HistoryRecord
+	rooms    []models.RoomForVectorStore
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		hotels:   make(map[string]models.HotelForVectorStore),
+		feedback: make(map[string]models.Feedback),
+	}
+}
+
+// LoadStore creates a store holding the hotels in the JSON file at path, embedded with embedder.
+// Any vectors in the file are replaced, since they don't match the fake query embeddings.
+// Hotels with a Description_fr get a French vector too, and every room is stored as a
+// room document.
+func LoadStore(ctx context.Context, path string, embedder *FakeEmbedder) (*Store, error) {
+	hotels, err := vectorstore.LoadHotels(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load offline data from %s: %w", path, err)
+	}
+
+	docs := make([]models.HotelForVectorStore, len(hotels))
+	var rooms []models.RoomForVectorStore
+	for i, hotel := range hotels {
+		docs[i] = hotel.ToVectorStore()
+		docs[i].DescriptionVector = embedder.Embed(hotel.Description)
+		if hotel.DescriptionFr != "" {
+			docs[i].DescriptionFrVector = embedder.Embed(hotel.DescriptionFr)
+		}
+		docs[i].ContentHash = hotel.ContentHash(EmbeddingModel)
+		for _, room := range hotel.RoomDocuments() {
+			room.DescriptionVector = embedder.Embed(room.EmbeddingText())
+			rooms = append(rooms, room)
+		}
+	}
+
+	store := NewStore()
+	if err := store.InsertHotelsWithEmbeddings(ctx, docs); err != nil {
+		return nil, err
+	}
+	if err := store.InsertRooms(ctx, rooms); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// InsertHotelsWithEmbeddings adds hotels, replacing any with the same HotelId
+func (s *Store) InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, hotel := range hotels {
+		if _, ok := s.hotels[hotel.HotelID]; !ok {
+			s.ids = append(s.ids, hotel.HotelID)
+		}
+		s.hotels[hotel.HotelID] = hotel
+	}
+	return nil
+}
+
+// CreateVectorIndex does nothing; searches are exact and need no index
+func (s *Store) CreateVectorIndex(ctx context.Context) error {
+	return nil
+}
+
+// CreateFrenchVectorIndex does nothing, as CreateVectorIndex does
+func (s *Store) CreateFrenchVectorIndex(ctx context.Context) error {
+	return nil
+}
+
+// InsertRooms adds room documents
+func (s *Store) InsertRooms(ctx context.Context, rooms []models.RoomForVectorStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rooms = append(s.rooms, rooms...)
+	return nil
+}
+
+// DeleteRooms removes the rooms of the hotels with the given HotelIds and returns how
+// many were deleted
+func (s *Store) DeleteRooms(ctx context.Context, hotelIDs []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.rooms)
+	s.rooms = slices.DeleteFunc(s.rooms, func(room models.RoomForVectorStore) bool {
+		return slices.Contains(hotelIDs, room.HotelID)
+	})
+	return int64(before - len(s.rooms)), nil
+}
+
+// CreateRoomsVectorIndex does nothing, as CreateVectorIndex does
+func (s *Store) CreateRoomsVectorIndex(ctx context.Context) error {
+	return nil
+}
+
+// SearchRooms returns the k rooms with the highest cosine similarity to queryVector.
+// Rooms without a vector of the same length are skipped.
+func (s *Store) SearchRooms(ctx context.Context, queryVector []float32, k int) (_ []models.RoomSearchResult, err error) {
+	k = vectorstore.ClampK(ctx, k)
+	done := trace.Start(ctx, trace.EventVectorSearch)
+	defer func() { done(err) }()
+	defer func(start time.Time) { metrics.Default().Search(time.Since(start), err) }(time.Now())
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]models.RoomSearchResult, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		if len(room.DescriptionVector) != len(queryVector) {
+			continue
+		}
+		results = append(results, models.RoomSearchResult{Room: room, Score: cosine(queryVector, room.DescriptionVector)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k >= 0 && k < len(results) {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// ExistingHotelIDs returns the set of stored HotelIds
+func (s *Store) ExistingHotelIDs(ctx context.Context) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make(map[string]bool, len(s.ids))
+	for _, id := range s.ids {
+		ids[id] = true
+	}
+	return ids, nil
+}
+
+// ContentHashes returns the ContentHash of every stored hotel by HotelId
+func (s *Store) ContentHashes(ctx context.Context) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hashes := make(map[string]string, len(s.ids))
+	for _, id := range s.ids {
+		hashes[id] = s.hotels[id].ContentHash
+	}
+	return hashes, nil
+}
+
+// SchemaVersions counts the stored hotels by SchemaVersion
+func (s *Store) SchemaVersions(ctx context.Context) (map[int]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := make(map[int]int64)
+	for _, id := range s.ids {
+		versions[s.hotels[id].SchemaVersion]++
+	}
+	return versions, nil
+}
+
+// UpsertHotels adds hotels, replacing any with the same HotelId, as
+// InsertHotelsWithEmbeddings does
+func (s *Store) UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	return s.InsertHotelsWithEmbeddings(ctx, hotels)
+}
+
+// DeleteHotels removes the hotels with the given HotelIds and returns how many were deleted
+func (s *Store) DeleteHotels(ctx context.Context, hotelIDs []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for _, id := range hotelIDs {
+		if _, ok := s.hotels[id]; ok {
+			delete(s.hotels, id)
+			deleted++
+		}
+	}
+	s.ids = slices.DeleteFunc(s.ids, func(id string) bool {
+		_, ok := s.hotels[id]
+		return !ok
+	})
+	return deleted, nil
+}
+
+// VectorSearch returns the k hotels with the highest cosine similarity to queryVector.
+// Hotels without a vector of the same length are skipped.
+func (s *Store) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	return s.vectorSearch(ctx, descriptionVector, queryVector, k)
+}
+
+// ExactSearch is VectorSearch: the in-memory search already compares every hotel, so
+// its recall against itself is 1
+func (s *Store) ExactSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	return s.VectorSearch(ctx, queryVector, k)
+}
+
+// VectorSearchLanguage is VectorSearch over the descriptions in language, as
+// VectorStore.VectorSearchLanguage
+func (s *Store) VectorSearchLanguage(ctx context.Context, language string, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	normalized, ok := vectorstore.NormalizeLanguage(language)
+	if !ok {
+		return nil, fmt.Errorf("unsupported search language %q: use %s or %s", language, vectorstore.LanguageEnglish, vectorstore.LanguageFrench)
+	}
+	if normalized == vectorstore.LanguageFrench {
+		return s.vectorSearch(ctx, descriptionFrVector, queryVector, k)
+	}
+	return s.vectorSearch(ctx, descriptionVector, queryVector, k)
+}
+
+// descriptionVector and descriptionFrVector select the vector a search compares
+func descriptionVector(h *models.HotelForVectorStore) []float32   { return h.DescriptionVector }
+func descriptionFrVector(h *models.HotelForVectorStore) []float32 { return h.DescriptionFrVector }
+
+// vectorSearch ranks the hotels by the cosine similarity of their field vector to queryVector
+func (s *Store) vectorSearch(ctx context.Context, field func(*models.HotelForVectorStore) []float32, queryVector []float32, k int) (_ []models.HotelSearchResult, err error) {
+	k = vectorstore.ClampK(ctx, k)
+	done := trace.Start(ctx, trace.EventVectorSearch)
+	defer func() { done(err) }()
+	defer func(start time.Time) { metrics.Default().Search(time.Since(start), err) }(time.Now())
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]models.HotelSearchResult, 0, len(s.ids))
+	for _, id := range s.ids {
+		hotel := s.hotels[id]
+		vector := field(&hotel)
+		if len(vector) != len(queryVector) {
+			continue
+		}
+		score := cosine(queryVector, vector)
+		results = append(results, models.HotelSearchResult{Hotel: hotel, Score: score, RawScore: score, Provenance: models.ProvenanceExact})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k >= 0 && k < len(results) {
+		results = results[:k]
+	}
+	models.RankResults(results)
+	slog.DebugContext(ctx, "vector search returned", "results", len(results))
+	return results, nil
+}
+
+// cosine returns the cosine similarity of two vectors of the same length, or 0 when either is zero
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// InsertHistory keeps one agent run in memory
+func (s *Store) InsertHistory(ctx context.Context, record models.HistoryRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, record)
+	return nil
+}
+
+// SaveFeedback records feedback in memory with the same replace semantics as
+// VectorStore.SaveFeedback. It reports whether a new record was created.
+func (s *Store) SaveFeedback(ctx context.Context, feedback models.Feedback) (bool, error) {
+	if err := feedback.Validate(); err != nil {
+		return false, fmt.Errorf("%w: %w", vectorstore.ErrInvalidData, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	existing, found := s.feedback[feedback.ResultID]
+	if found {
+		feedback.CreatedAt = existing.CreatedAt
+		if feedback.SessionID == "" {
+			feedback.SessionID = existing.SessionID
+		}
+	} else {
+		feedback.CreatedAt = now
+	}
+	feedback.UpdatedAt = now
+	s.feedback[feedback.ResultID] = feedback
+	return !found, nil
+}
+
+// WarmUp issues n searches with random vectors, as VectorStore.WarmUp. There is no index
+// to load, so it only exercises the search path.
+func (s *Store) WarmUp(ctx context.Context, n int) error {
+	return vectorstore.WarmUpSearches(ctx, s, vectorstore.EmbeddingDimensionsFromEnv(), n)
+}
+
+// Close does nothing; the store is discarded with the process
+func (s *Store) Close(ctx context.Context) error {
+	return nil
+}
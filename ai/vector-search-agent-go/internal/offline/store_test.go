@@ -0,0 +1,152 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const resultID = "3f2b8c1e-6d4a-4f0e-9b7a-2c5d8e1f0a93"
+
+func TestStoreVectorSearch(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	docs := []models.HotelForVectorStore{
+		{HotelID: "1", HotelName: "North", DescriptionVector: []float32{1, 0}},
+		{HotelID: "2", HotelName: "East", DescriptionVector: []float32{0, 1}},
+		{HotelID: "3", HotelName: "North-east", DescriptionVector: []float32{1, 1}},
+		{HotelID: "4", HotelName: "Wrong dimensions", DescriptionVector: []float32{1, 0, 0}},
+	}
+	if err := store.InsertHotelsWithEmbeddings(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	// Inserting a hotel again replaces it
+	if err := store.InsertHotelsWithEmbeddings(ctx, []models.HotelForVectorStore{{HotelID: "2", HotelName: "East", DescriptionVector: []float32{-1, 0}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := store.VectorSearch(ctx, []float32{1, 0.1}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.Hotel.HotelID)
+	}
+	if len(ids) != 3 || ids[0] != "1" || ids[1] != "3" || ids[2] != "2" {
+		t.Errorf("results = %v, want 1, 3, 2", ids)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("scores = %v, %v, want descending", results[0].Score, results[1].Score)
+	}
+	for i, r := range results {
+		if r.Rank != i+1 || r.RawScore != r.Score || r.Provenance != models.ProvenanceExact {
+			t.Errorf("result %d rank %d, raw score %v of %v, provenance %q; want exact", i, r.Rank, r.RawScore, r.Score, r.Provenance)
+		}
+	}
+
+	if top, _ := store.VectorSearch(ctx, []float32{1, 0.1}, 1); len(top) != 1 || top[0].Hotel.HotelID != "1" {
+		t.Errorf("k=1 results = %+v", top)
+	}
+	// k is clamped like the DocumentDB store clamps it
+	t.Setenv("MAX_NEAREST_NEIGHBORS", "2")
+	if top, _ := store.VectorSearch(ctx, []float32{1, 0.1}, 10); len(top) != 2 {
+		t.Errorf("k=10 above MAX_NEAREST_NEIGHBORS=2 returned %d results", len(top))
+	}
+	if top, _ := store.VectorSearch(ctx, []float32{1, 0.1}, 0); len(top) != 1 {
+		t.Errorf("k=0 returned %d results, want 1", len(top))
+	}
+	existing, err := store.ExistingHotelIDs(ctx)
+	if err != nil || len(existing) != 4 || !existing["4"] {
+		t.Errorf("ExistingHotelIDs = %v, %v", existing, err)
+	}
+}
+
+func TestStoreSaveFeedback(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	created, err := store.SaveFeedback(ctx, models.Feedback{ResultID: resultID, SessionID: "session-1", Rating: "up"})
+	if err != nil || !created {
+		t.Fatalf("first save = %v, %v, want created", created, err)
+	}
+	first := store.feedback[resultID]
+
+	created, err = store.SaveFeedback(ctx, models.Feedback{ResultID: resultID, Rating: "down", Comment: "too noisy"})
+	if err != nil || created {
+		t.Fatalf("second save = %v, %v, want an update", created, err)
+	}
+	got := store.feedback[resultID]
+	if len(store.feedback) != 1 || got.Rating != "down" || got.Comment != "too noisy" || got.SessionID != "session-1" || !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("feedback = %+v, want the update keeping the session and creation time", got)
+	}
+
+	if _, err := store.SaveFeedback(ctx, models.Feedback{ResultID: "abc", Rating: "up"}); !errors.Is(err, vectorstore.ErrInvalidData) {
+		t.Errorf("invalid feedback err = %v, want ErrInvalidData", err)
+	}
+}
+
+func TestStoreChanges(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	docs := []models.HotelForVectorStore{
+		{HotelID: "1", HotelName: "North", ContentHash: "a"},
+		{HotelID: "2", HotelName: "East", ContentHash: "b"},
+		{HotelID: "3", HotelName: "West", ContentHash: "c", SchemaVersion: models.CurrentSchemaVersion},
+	}
+	if err := store.InsertHotelsWithEmbeddings(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertHotels(ctx, []models.HotelForVectorStore{{HotelID: "2", HotelName: "East", ContentHash: "b2"}, {HotelID: "4", ContentHash: "d"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := store.DeleteHotels(ctx, []string{"3", "missing"})
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteHotels = %d, %v, want 1 deleted", deleted, err)
+	}
+
+	hashes, err := store.ContentHashes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"1": "a", "2": "b2", "4": "d"}
+	if fmt.Sprint(hashes) != fmt.Sprint(want) {
+		t.Errorf("ContentHashes = %v, want %v", hashes, want)
+	}
+	if existing, _ := store.ExistingHotelIDs(ctx); len(existing) != 3 || existing["3"] {
+		t.Errorf("ExistingHotelIDs = %v, want hotel 3 gone", existing)
+	}
+	if versions, _ := store.SchemaVersions(ctx); fmt.Sprint(versions) != fmt.Sprint(map[int]int64{0: 3}) {
+		t.Errorf("SchemaVersions = %v, want the 3 remaining hotels at version 0", versions)
+	}
+}
+
+func TestStoreVectorSearchLanguage(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	// Only hotel 2 has French text
+	docs := []models.HotelForVectorStore{
+		{HotelID: "1", DescriptionVector: []float32{1, 0}},
+		{HotelID: "2", DescriptionVector: []float32{0, 1}, DescriptionFrVector: []float32{1, 0}},
+	}
+	if err := store.InsertHotelsWithEmbeddings(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	french, err := store.VectorSearchLanguage(ctx, "fr", []float32{1, 0}, 5)
+	if err != nil || len(french) != 1 || french[0].Hotel.HotelID != "2" {
+		t.Errorf("French results = %+v, %v, want hotel 2 only", french, err)
+	}
+	english, err := store.VectorSearchLanguage(ctx, "", []float32{1, 0}, 5)
+	if err != nil || len(english) != 2 || english[0].Hotel.HotelID != "1" {
+		t.Errorf("English results = %+v, %v, want 1 then 2", english, err)
+	}
+	if _, err := store.VectorSearchLanguage(ctx, "de", []float32{1, 0}, 5); err == nil {
+		t.Error("unsupported language searched")
+	}
+}
@@ -0,0 +1,190 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultLogInterval is how often progress lines are printed when the output is not a terminal
+const DefaultLogInterval = 5 * time.Second
+
+// UnknownTotal is passed to New when the number of items is not known in advance, as
+// when they are streamed from a file
+const UnknownTotal = -1
+
+// redrawInterval throttles updates of the single terminal line
+const redrawInterval = 100 * time.Millisecond
+
+// Snapshot is the progress of a run at one point in time
+type Snapshot struct {
+	Processed int
+	Failed    int
+	Total     int
+	Elapsed   time.Duration
+}
+
+// Percent returns the share of items processed, from 0 to 100, or 0 when the total
+// is unknown
+func (s Snapshot) Percent() float64 {
+	if s.Total == UnknownTotal {
+		return 0
+	}
+	if s.Total == 0 {
+		return 100
+	}
+	return float64(s.Processed) / float64(s.Total) * 100
+}
+
+// Rate returns items processed per second
+func (s Snapshot) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Processed) / s.Elapsed.Seconds()
+}
+
+// ETA estimates the time remaining at the current rate, or 0 when unknown
+func (s Snapshot) ETA() time.Duration {
+	rate := s.Rate()
+	if rate == 0 || s.Total == UnknownTotal || s.Processed >= s.Total {
+		return 0
+	}
+	return time.Duration(float64(s.Total-s.Processed) / rate * float64(time.Second))
+}
+
+// String renders the snapshot as "30/50 (60.0%) 12.3 docs/s ETA 2s, 1 failed", or
+// "30 done 12.3 docs/s, 1 failed" when the total is unknown
+func (s Snapshot) String() string {
+	if s.Total == UnknownTotal {
+		line := fmt.Sprintf("%d done %.1f docs/s", s.Processed, s.Rate())
+		if s.Failed > 0 {
+			line += fmt.Sprintf(", %d failed", s.Failed)
+		}
+		return line
+	}
+	line := fmt.Sprintf("%d/%d (%.1f%%) %.1f docs/s", s.Processed, s.Total, s.Percent(), s.Rate())
+	if eta := s.ETA(); eta > 0 {
+		line += fmt.Sprintf(" ETA %s", eta.Round(time.Second))
+	}
+	if s.Failed > 0 {
+		line += fmt.Sprintf(", %d failed", s.Failed)
+	}
+	return line
+}
+
+// Reporter tracks processed items and renders progress to w. On a terminal it redraws a
+// single line; otherwise it prints a plain line every log interval. It is safe for
+// concurrent use.
+type Reporter struct {
+	mu         sync.Mutex
+	w          io.Writer
+	tty        bool
+	interval   time.Duration
+	total      int
+	processed  int
+	failed     int
+	start      time.Time
+	lastRender time.Time
+	rendered   int
+	now        func() time.Time
+}
+
+// New creates a reporter for total items, or UnknownTotal. tty selects single-line
+// terminal rendering.
+func New(w io.Writer, total int, tty bool) *Reporter {
+	start := time.Now()
+	return &Reporter{
+		w:          w,
+		tty:        tty,
+		interval:   DefaultLogInterval,
+		total:      total,
+		start:      start,
+		lastRender: start,
+		rendered:   -1,
+		now:        time.Now,
+	}
+}
+
+// IsTerminal reports whether f is a character device such as an interactive terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// SetLogInterval changes how often plain progress lines are printed
+func (r *Reporter) SetLogInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interval = interval
+}
+
+// Done records one processed item that succeeded
+func (r *Reporter) Done() {
+	r.add(false)
+}
+
+// Fail records one processed item that failed
+func (r *Reporter) Fail() {
+	r.add(true)
+}
+
+func (r *Reporter) add(failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.processed++
+	if failed {
+		r.failed++
+	}
+
+	now := r.now()
+	wait := r.interval
+	if r.tty {
+		wait = redrawInterval
+	}
+	if now.Sub(r.lastRender) >= wait || r.processed == r.total {
+		r.render(now)
+	}
+}
+
+// Snapshot returns the current progress
+func (r *Reporter) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot(r.now())
+}
+
+func (r *Reporter) snapshot(now time.Time) Snapshot {
+	return Snapshot{Processed: r.processed, Failed: r.failed, Total: r.total, Elapsed: now.Sub(r.start)}
+}
+
+// Finish renders the final progress and ends the terminal line
+func (r *Reporter) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rendered != r.processed {
+		r.render(r.now())
+	}
+	if r.tty {
+		fmt.Fprintln(r.w)
+	}
+}
+
+// render writes the current progress; the caller holds the lock
+func (r *Reporter) render(now time.Time) {
+	r.lastRender = now
+	r.rendered = r.processed
+	if r.tty {
+		// Return to the start of the line and clear it before redrawing
+		fmt.Fprintf(r.w, "\r\033[K%s", r.snapshot(now))
+		return
+	}
+	fmt.Fprintf(r.w, "Progress: %s\n", r.snapshot(now))
+}
@@ -0,0 +1,140 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced time source for a reporter
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestReporter(total int, tty bool) (*Reporter, *bytes.Buffer, *fakeClock) {
+	var out bytes.Buffer
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r := New(&out, total, tty)
+	r.start = clock.now
+	r.lastRender = clock.now
+	r.now = clock.Now
+	return r, &out, clock
+}
+
+func TestSnapshotString(t *testing.T) {
+	tests := []struct {
+		name string
+		snap Snapshot
+		want string
+	}{
+		{"in progress", Snapshot{Processed: 30, Total: 50, Elapsed: 10 * time.Second}, "30/50 (60.0%) 3.0 docs/s ETA 7s"},
+		{"with failures", Snapshot{Processed: 10, Failed: 2, Total: 40, Elapsed: 5 * time.Second}, "10/40 (25.0%) 2.0 docs/s ETA 15s, 2 failed"},
+		{"complete", Snapshot{Processed: 50, Total: 50, Elapsed: 25 * time.Second}, "50/50 (100.0%) 2.0 docs/s"},
+		{"not started", Snapshot{Total: 50}, "0/50 (0.0%) 0.0 docs/s"},
+		{"empty", Snapshot{}, "0/0 (100.0%) 0.0 docs/s"},
+		{"unknown total", Snapshot{Processed: 30, Total: UnknownTotal, Elapsed: 10 * time.Second}, "30 done 3.0 docs/s"},
+		{"unknown total with failures", Snapshot{Processed: 30, Failed: 1, Total: UnknownTotal, Elapsed: 10 * time.Second}, "30 done 3.0 docs/s, 1 failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.snap.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReporterPlainLinesEveryInterval(t *testing.T) {
+	r, out, clock := newTestReporter(6, false)
+	r.SetLogInterval(10 * time.Second)
+
+	for range 3 {
+		clock.Advance(2 * time.Second)
+		r.Done()
+	}
+	if out.Len() != 0 {
+		t.Fatalf("printed before the log interval elapsed: %q", out.String())
+	}
+
+	clock.Advance(4 * time.Second)
+	r.Fail()
+	clock.Advance(2 * time.Second)
+	r.Done()
+	clock.Advance(2 * time.Second)
+	r.Done()
+	r.Finish()
+
+	want := "Progress: 4/6 (66.7%) 0.4 docs/s ETA 5s, 1 failed\n" +
+		"Progress: 6/6 (100.0%) 0.4 docs/s, 1 failed\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestReporterRedrawsTerminalLine(t *testing.T) {
+	r, out, clock := newTestReporter(3, true)
+
+	clock.Advance(time.Second)
+	r.Done()
+	clock.Advance(10 * time.Millisecond)
+	r.Done() // within the redraw interval: not drawn
+	clock.Advance(time.Second)
+	r.Done()
+	r.Finish()
+
+	want := "\r\033[K1/3 (33.3%) 1.0 docs/s ETA 2s" +
+		"\r\033[K3/3 (100.0%) 1.5 docs/s" +
+		"\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestReporterFinishRendersPendingProgress(t *testing.T) {
+	r, out, clock := newTestReporter(10, false)
+
+	clock.Advance(time.Second)
+	r.Done()
+	r.Done()
+	r.Finish()
+
+	if got := strings.TrimSpace(out.String()); got != "Progress: 2/10 (20.0%) 2.0 docs/s ETA 4s" {
+		t.Errorf("output = %q, want the final progress line", got)
+	}
+
+	snap := r.Snapshot()
+	if snap.Processed != 2 || snap.Failed != 0 || snap.Total != 10 {
+		t.Errorf("Snapshot() = %+v", snap)
+	}
+}
+
+func TestReporterConcurrentUse(t *testing.T) {
+	r := New(&bytes.Buffer{}, 100, false)
+
+	done := make(chan struct{})
+	for range 4 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := range 25 {
+				if j%5 == 0 {
+					r.Fail()
+				} else {
+					r.Done()
+				}
+			}
+		}()
+	}
+	for range 4 {
+		<-done
+	}
+
+	if snap := r.Snapshot(); snap.Processed != 100 || snap.Failed != 20 {
+		t.Errorf("Snapshot() = %+v, want 100 processed and 20 failed", snap)
+	}
+}
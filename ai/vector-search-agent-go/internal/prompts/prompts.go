@@ -1,7 +1,26 @@
 package prompts
 
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
 const ToolName = "search_hotels_collection"
 
+// RoomsToolName is the tool that searches individual rooms, offered when ROOM_SEARCH is set
+const RoomsToolName = "search_rooms"
+
+const RoomsToolDescription = `Performs vector similarity search on the individual rooms of the hotels, for requests about a specific kind of room (room type, bed options, occupancy, nightly rate, room amenities).
+
+INPUT REQUIREMENTS:
+- query (string, REQUIRED): Natural language description of the room wanted (e.g., "suite with two queen beds under $200 a night").
+- nearestNeighbors (number, REQUIRED): Number of rooms to return (1-20).
+
+SEARCH BEHAVIOR:
+- Returns the best-matching rooms grouped by hotel, best hotel first
+- Each room includes its type, description, bed options, occupancy, base rate, smoking policy, and tags`
+
 const ToolDescription = `REQUIRED TOOL - You MUST call this tool for EVERY hotel search request. This is the ONLY way to search the hotel database.
 
 Performs vector similarity search on the Hotels collection using Azure DocumentDB (with MongoDB compatibility).
@@ -9,6 +28,7 @@ Performs vector similarity search on the Hotels collection using Azure DocumentD
 INPUT REQUIREMENTS:
 - query (string, REQUIRED): Natural language search query describing desired hotel characteristics. Should be detailed and specific (e.g., "budget hotel near downtown with parking and wifi" not just "hotel").
 - nearestNeighbors (number, REQUIRED): Number of results to return (1-20). Use 3-5 for specific requests, 10-15 for broader searches.
+- language (string, optional): "fr" to search the French hotel descriptions when the user writes in French; defaults to "en".
 
 SEARCH BEHAVIOR:
 - Uses semantic vector search to find hotels matching the query description
@@ -24,47 +44,164 @@ CRITICAL INSTRUCTION: You MUST call the "search_hotels_collection" tool for ever
 When you call the tool, use these parameters:
 - query: A clear, detailed natural language description of what the user is looking for. Expand vague requests (e.g., "nice hotel" → "hotel with high ratings, good reviews, and quality amenities").
 - nearestNeighbors: Number of results (1-20). Use 3-5 for specific requests, 10-15 for broader searches.
+- language: "fr" when the user writes in French, so the French hotel descriptions are searched; leave it out otherwise.
 
 EXAMPLES of how you should call the tool:
 - User: "cheap hotel" → Call tool with query: "budget-friendly hotel with good value and affordable rates", nearestNeighbors: 10
 - User: "hotel near downtown with parking" → Call tool with query: "hotel near downtown with good parking and wifi", nearestNeighbors: 5
 
+If a request combines several distinct needs, you may call the tool once per need with a focused sub-query (e.g., "family resort with pool" and "hotel near the convention center"). The results are merged by similarity score.
+
+If a "search_rooms" tool is available and the request is about a specific kind of room (room type, beds, occupancy, nightly rate), call "search_rooms" with the room requirements as the query, in place of or alongside the hotel search (e.g., User: "suite with two queen beds under $200" → query: "suite with 2 queen beds, rate under $200 per night", nearestNeighbors: 10).
+
 IMPORTANT: Always call the tool. Do not provide answers without calling the tool first.`
 
-const SynthesizerSystemPrompt = `You are an expert hotel recommendation assistant using vector search results.
-Only use the TOP 3 results provided. Do not request additional searches or call other tools.
+// RepairToolCallMessage asks the planner again for the search in userMessage, after its
+// tool call was rejected for problem
+func RepairToolCallMessage(userMessage, problem string) string {
+	return fmt.Sprintf("%s\n\nYour previous tool call was rejected: %s. Call the tool again with arguments that match its parameter schema.", userMessage, problem)
+}
+
+// NoResultsAnswer is the deterministic answer returned without calling the model when no hotels matched
+const NoResultsAnswer = "No matching hotels were found. Try broadening your search, for example by removing specific amenities or locations."
+
+// Default values for the synthesizer prompt settings
+const (
+	DefaultTopN     = 3
+	DefaultMaxWords = 220
+	DefaultLanguage = "English"
+)
+
+// SynthesizerPromptData holds the dynamic values rendered into the synthesizer prompts
+type SynthesizerPromptData struct {
+	UserQuery   string
+	ToolSummary string
+	TopN        int
+	MaxWords    int
+	Language    string
+}
+
+// DefaultSynthesizerPromptData returns prompt data populated with the default settings
+func DefaultSynthesizerPromptData(userQuery, toolSummary string) SynthesizerPromptData {
+	return SynthesizerPromptData{
+		UserQuery:   userQuery,
+		ToolSummary: toolSummary,
+		TopN:        DefaultTopN,
+		MaxWords:    DefaultMaxWords,
+		Language:    DefaultLanguage,
+	}
+}
+
+// formatRules is shared by the system and user prompts so the top-N, word limit,
+// and formatting rules are only defined once
+const formatRules = `{{define "formatRules"}}FORMAT CONSTRAINTS:
+- Plain text only (no markdown formatting like ** or ###).
+- Keep the entire response under {{.MaxWords}} words.
+- Use simple bullets (•) or numbered lists and short sentences (preferably <25 words per sentence).
+- Preserve hotel names exactly as provided in the tool summary (original capitalization).
+- Respond in {{.Language}}.{{end}}`
+
+const synthesizerSystemTemplate = `You are an expert hotel recommendation assistant using vector search results.
+Only use the TOP {{.TopN}} results provided. Do not request additional searches or call other tools.
 
-GOAL: Provide a concise comparative recommendation to help the user choose between the top 3 options.
+GOAL: Provide a concise comparative recommendation to help the user choose between the top {{.TopN}} options.
 
 REQUIREMENTS:
-- Compare only the top 3 results across the most important attributes: rating, score, location, price-level (if available), and key tags (parking, wifi, pool).
+- Compare only the top {{.TopN}} results across the most important attributes: rating, score, location, price-level (if available), and key tags (parking, wifi, pool).
 - Identify the main tradeoffs in one short sentence per tradeoff.
 - Give a single clear recommendation with one short justification sentence.
 - Provide up to two alternative picks (one sentence each) explaining when they are preferable.
 
-FORMAT CONSTRAINTS:
-- Plain text only (no markdown).
-- Keep the entire response under 220 words.
-- Use simple bullets (•) or numbered lists and short sentences (preferably <25 words per sentence).
-- Preserve hotel names exactly as provided in the tool summary.
+{{template "formatRules" .}}
 
 Do not add extra commentary, marketing language, or follow-up questions. If information is missing and necessary to choose, state it in one sentence and still provide the best recommendation based on available data.`
 
-// CreateSynthesizerUserPrompt creates the user prompt for synthesizer agent
-func CreateSynthesizerUserPrompt(userQuery, toolSummary string) string {
-	return `User asked: ` + userQuery + `
+const synthesizerUserTemplate = `User asked: {{.UserQuery}}
 
 Tool summary:
-` + toolSummary + `
+{{.ToolSummary}}
 
-Analyze the TOP 3 results by COMPARING them across all attributes (rating, score, tags, parking, location, category, rooms).
+Analyze the TOP {{.TopN}} results by COMPARING them across all attributes (rating, score, tags, parking, location, category, rooms).
 
 Structure your response:
-1. COMPARISON SUMMARY: Compare the top 3 options highlighting key differences and tradeoffs
+1. COMPARISON SUMMARY: Compare the top {{.TopN}} options highlighting key differences and tradeoffs
 2. BEST OVERALL: Recommend the single best option with clear reasoning
 3. ALTERNATIVE PICKS: Briefly explain when the other options might be preferred (e.g., "Choose X if budget is priority" or "Choose Y if location matters most")
 
 Your goal is to help the user DECIDE between the options, not just describe them.
 
-Format your response using plain text (NO markdown formatting like ** or ###). Use simple numbered lists, bullet points (•), and use the exact hotel names from the tool summary (preserve original capitalization).`
+{{template "formatRules" .}}`
+
+// FollowUpPromptData holds the dynamic values rendered into the follow-up prompts
+type FollowUpPromptData struct {
+	Question      string
+	PreviousQuery string
+	ToolSummary   string
+	Reference     string
+	MaxWords      int
+	Language      string
+}
+
+const followUpSystemTemplate = `You are an expert hotel recommendation assistant answering a follow-up question about hotels you already presented to the user.
+Only use the hotels listed in the tool summary. Do not request additional searches or call other tools.
+
+REQUIREMENTS:
+- Hotels are numbered in the order they were presented (Hotel #1 is the first one, Hotel #2 the second one, and so on).
+- Resolve references like "the second one" or "that hotel" against this numbering.
+- Answer the question directly in one to three short sentences.
+- If the tool summary does not contain the requested information, say so in one sentence.
+
+{{template "formatRules" .}}`
+
+const followUpUserTemplate = `Previous request: {{.PreviousQuery}}
+
+Previously presented hotels:
+{{.ToolSummary}}
+{{if .Reference}}
+The user appears to be referring to: {{.Reference}}
+{{end}}
+Follow-up question: {{.Question}}
+
+{{template "formatRules" .}}`
+
+// Templates are parsed once at init so a malformed template fails immediately
+var (
+	synthesizerSystemTmpl = mustParse("synthesizerSystem", synthesizerSystemTemplate)
+	synthesizerUserTmpl   = mustParse("synthesizerUser", synthesizerUserTemplate)
+	followUpSystemTmpl    = mustParse("followUpSystem", followUpSystemTemplate)
+	followUpUserTmpl      = mustParse("followUpUser", followUpUserTemplate)
+)
+
+// mustParse parses a prompt template together with the shared format rules
+func mustParse(name, text string) *template.Template {
+	return template.Must(template.Must(template.New(name).Parse(formatRules)).Parse(text))
+}
+
+// RenderSynthesizerSystemPrompt renders the synthesizer system prompt
+func RenderSynthesizerSystemPrompt(data SynthesizerPromptData) (string, error) {
+	return render(synthesizerSystemTmpl, data)
+}
+
+// RenderSynthesizerUserPrompt renders the user prompt for the synthesizer agent
+func RenderSynthesizerUserPrompt(data SynthesizerPromptData) (string, error) {
+	return render(synthesizerUserTmpl, data)
+}
+
+// RenderFollowUpSystemPrompt renders the system prompt for follow-up questions
+func RenderFollowUpSystemPrompt(data FollowUpPromptData) (string, error) {
+	return render(followUpSystemTmpl, data)
+}
+
+// RenderFollowUpUserPrompt renders the user prompt for follow-up questions
+func RenderFollowUpUserPrompt(data FollowUpPromptData) (string, error) {
+	return render(followUpUserTmpl, data)
+}
+
+// render executes a template with the given data
+func render(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
 }
@@ -0,0 +1,111 @@
+package prompts
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestTemplatesParse(t *testing.T) {
+	for name, source := range map[string]string{
+		"synthesizerSystem": synthesizerSystemTemplate,
+		"synthesizerUser":   synthesizerUserTemplate,
+		"followUpSystem":    followUpSystemTemplate,
+		"followUpUser":      followUpUserTemplate,
+	} {
+		tmpl, err := template.New(name).Parse(formatRules)
+		if err != nil {
+			t.Fatalf("formatRules: %v", err)
+		}
+		if _, err := tmpl.Parse(source); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRenderDefaultsGolden(t *testing.T) {
+	data := DefaultSynthesizerPromptData("quiet hotel near the beach", "1. Ocean Retreat (score 0.91)")
+
+	tests := []struct {
+		golden string
+		render func(SynthesizerPromptData) (string, error)
+	}{
+		{"synthesizer_system.golden", RenderSynthesizerSystemPrompt},
+		{"synthesizer_user.golden", RenderSynthesizerUserPrompt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := tt.render(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, tt.golden, got)
+		})
+	}
+}
+
+func TestRenderFollowUpGolden(t *testing.T) {
+	data := FollowUpPromptData{
+		Question:      "Does the second one have parking?",
+		PreviousQuery: "quiet hotel near the beach",
+		ToolSummary:   "1. Ocean Retreat (score 0.91)\n2. Harbor Inn (score 0.87)",
+		Reference:     "Hotel #2: Harbor Inn",
+		MaxWords:      DefaultMaxWords,
+		Language:      DefaultLanguage,
+	}
+
+	tests := []struct {
+		golden string
+		render func(FollowUpPromptData) (string, error)
+	}{
+		{"followup_system.golden", RenderFollowUpSystemPrompt},
+		{"followup_user.golden", RenderFollowUpUserPrompt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := tt.render(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, tt.golden, got)
+		})
+	}
+}
+
+func TestRenderUsesSettings(t *testing.T) {
+	data := SynthesizerPromptData{UserQuery: "q", ToolSummary: "s", TopN: 5, MaxWords: 100, Language: "French"}
+	for _, render := range []func(SynthesizerPromptData) (string, error){RenderSynthesizerSystemPrompt, RenderSynthesizerUserPrompt} {
+		got, err := render(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"TOP 5", "under 100 words", "Respond in French"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("rendered prompt missing %q:\n%s", want, got)
+			}
+		}
+	}
+}
+
+// assertGolden compares got with testdata/name, rewriting the file when -update is set
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s does not match the rendered prompt; run go test -update and review the diff\ngot:\n%s", path, got)
+	}
+}
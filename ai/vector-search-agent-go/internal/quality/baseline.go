@@ -0,0 +1,50 @@
+package quality
+
+import (
+	"context"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// vectorSearcher runs approximate nearest-neighbor searches
+type vectorSearcher interface {
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// Baseline adds an exact search over vectors held in memory to a store whose only
+// search is its index, such as a DocumentDB collection. Load the corpus once, with
+// ExportHotels and IncludeVectors.
+type Baseline struct {
+	// Searcher runs the approximate searches. It may be nil when only ExactSearch is used.
+	Searcher vectorSearcher
+	Corpus   []bench.Doc
+	// Similarity is the metric of the index, COS, IP, or L2, so both searches agree
+	// on what nearest means
+	Similarity string
+}
+
+// VectorSearch runs the approximate search of the store
+func (b *Baseline) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	return b.Searcher.VectorSearch(ctx, queryVector, k)
+}
+
+// ExactSearch returns the k hotels of the corpus closest to queryVector by brute force,
+// followed by any tied with the k-th. The results carry only the hotel ID and score.
+func (b *Baseline) ExactSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	neighbors := bench.ExactScored(queryVector, b.Corpus, k, b.Similarity)
+	results := make([]models.HotelSearchResult, len(neighbors))
+	for i, n := range neighbors {
+		results[i] = models.HotelSearchResult{
+			Hotel:      models.HotelForVectorStore{HotelID: n.ID},
+			Score:      n.Score,
+			Rank:       i + 1,
+			RawScore:   n.Score,
+			Provenance: models.ProvenanceExact,
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,148 @@
+// Package quality measures how well the vector index finds the true nearest neighbors:
+// recall@k of its approximate searches against an exact search of the same store.
+package quality
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// Searcher runs the two searches recall compares. *offline.Store implements it, and
+// Baseline adds the exact search to a store that only has its index.
+type Searcher interface {
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+	ExactSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// QueryRecall is the recall of one query vector
+type QueryRecall struct {
+	// Query is the position of the vector in the queries given to Recall
+	Query  int     `json:"query"`
+	Recall float64 `json:"recall"`
+	// Approximate and Exact are the hotel IDs each search returned, nearest first
+	Approximate []string `json:"approximate"`
+	Exact       []string `json:"exact"`
+}
+
+// Report is the recall@k of a set of query vectors
+type Report struct {
+	K       int           `json:"k"`
+	Mean    float64       `json:"mean"`
+	Min     float64       `json:"min"`
+	Queries []QueryRecall `json:"queries"`
+}
+
+// Below returns the queries whose recall fell below threshold, lowest first
+func (r *Report) Below(threshold float64) []QueryRecall {
+	var below []QueryRecall
+	for _, q := range r.Queries {
+		if q.Recall < threshold {
+			below = append(below, q)
+		}
+	}
+	slices.SortStableFunc(below, func(a, b QueryRecall) int {
+		switch {
+		case a.Recall < b.Recall:
+			return -1
+		case a.Recall > b.Recall:
+			return 1
+		}
+		return 0
+	})
+	return below
+}
+
+// Recall runs the approximate and the exact search for every query vector and reports
+// the overlap of their results. The first failed search stops it.
+func Recall(ctx context.Context, store Searcher, queries [][]float32, k int) (*Report, error) {
+	report := &Report{K: k, Queries: make([]QueryRecall, 0, len(queries))}
+	var sum float64
+	for i, query := range queries {
+		approximate, err := store.VectorSearch(ctx, query, k)
+		if err != nil {
+			return nil, fmt.Errorf("vector search for query %d failed: %w", i, err)
+		}
+		exact, err := store.ExactSearch(ctx, query, k)
+		if err != nil {
+			return nil, fmt.Errorf("exact search for query %d failed: %w", i, err)
+		}
+
+		q := QueryRecall{Query: i, Recall: Overlap(approximate, exact, k), Approximate: ids(approximate), Exact: ids(exact)}
+		report.Queries = append(report.Queries, q)
+		sum += q.Recall
+		if i == 0 || q.Recall < report.Min {
+			report.Min = q.Recall
+		}
+	}
+	if n := len(report.Queries); n > 0 {
+		report.Mean = sum / float64(n)
+	}
+	return report, nil
+}
+
+// Overlap returns the recall@k of one query: the fraction of the k exact nearest
+// neighbors found among the first k distinct hotels of the approximate results. Hotels
+// are compared by ID, so a duplicate counts once in either list. exact is nearest first and may go on past
+// the k-th with results tied with it; the index may return any of the tied hotels, so
+// finding them counts toward the places the tie takes in the top k. An empty exact
+// result has nothing to miss, and has recall 1.
+func Overlap(approximate, exact []models.HotelSearchResult, k int) float64 {
+	exact = unique(exact, len(exact))
+	need := min(k, len(exact))
+	if need <= 0 {
+		return 1
+	}
+
+	found := make(map[string]bool, k)
+	for _, r := range unique(approximate, k) {
+		found[r.Hotel.HotelID] = true
+	}
+
+	// Above the k-th score every exact neighbor must be found; at it, any of the
+	// tied ones fills one of the places the tie takes in the top k
+	boundary := exact[need-1].Score
+	hits, tiedPlaces, tiedFound := 0, 0, 0
+	for i, r := range exact {
+		switch {
+		case r.Score == boundary:
+			if i < need {
+				tiedPlaces++
+			}
+			if found[r.Hotel.HotelID] {
+				tiedFound++
+			}
+		case i < need && found[r.Hotel.HotelID]:
+			hits++
+		}
+	}
+	hits += min(tiedFound, tiedPlaces)
+	return float64(hits) / float64(need)
+}
+
+// unique returns the first n results with distinct hotel IDs
+func unique(results []models.HotelSearchResult, n int) []models.HotelSearchResult {
+	seen := make(map[string]bool, len(results))
+	kept := make([]models.HotelSearchResult, 0, min(n, len(results)))
+	for _, r := range results {
+		if len(kept) == n {
+			break
+		}
+		if !seen[r.Hotel.HotelID] {
+			seen[r.Hotel.HotelID] = true
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// ids returns the hotel IDs of results in order
+func ids(results []models.HotelSearchResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Hotel.HotelID
+	}
+	return ids
+}
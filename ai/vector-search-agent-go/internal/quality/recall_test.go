@@ -0,0 +1,191 @@
+package quality
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/offline"
+)
+
+// results returns search results for ids, nearest first, with descending scores
+func results(ids ...string) []models.HotelSearchResult {
+	scores := make([]float64, len(ids))
+	for i := range ids {
+		scores[i] = 1 - float64(i)/10
+	}
+	return scored(ids, scores)
+}
+
+// scored returns search results for ids with the given scores
+func scored(ids []string, scores []float64) []models.HotelSearchResult {
+	out := make([]models.HotelSearchResult, len(ids))
+	for i, id := range ids {
+		out[i] = models.HotelSearchResult{Hotel: models.HotelForVectorStore{HotelID: id}, Score: scores[i]}
+	}
+	return out
+}
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		name        string
+		approximate []models.HotelSearchResult
+		exact       []models.HotelSearchResult
+		k           int
+		want        float64
+	}{
+		{"identical", results("1", "2", "3"), results("1", "2", "3"), 3, 1},
+		{"order does not matter", results("3", "1", "2"), results("1", "2", "3"), 3, 1},
+		{"partial", results("1", "9", "3", "8"), results("1", "2", "3", "4"), 4, 0.5},
+		{"disjoint", results("7", "8"), results("1", "2"), 2, 0},
+		{"no approximate results", nil, results("1", "2"), 2, 0},
+		{"empty baseline", results("1"), nil, 1, 1},
+		{"fewer exact than k", results("1", "2", "9"), results("1", "2"), 3, 1},
+		{"approximate beyond k ignored", results("9", "8", "1", "2"), results("1", "2"), 2, 0},
+		{"duplicate approximate counts once", results("1", "1", "1"), results("1", "2", "3"), 3, 1.0 / 3},
+		{"duplicate approximate takes no place", results("1", "1", "2"), results("1", "2"), 2, 1},
+		{"duplicate exact counts once", results("1", "2"), results("1", "1", "2"), 2, 1},
+		{
+			// 2 and 3 tie for the 2nd place: the index may return either
+			"tie at the k-th, other side found",
+			results("1", "3"),
+			scored([]string{"1", "2", "3"}, []float64{0.9, 0.5, 0.5}),
+			2, 1,
+		},
+		{
+			// Both tied hotels found still fill only the one place the tie takes
+			"tie at the k-th, both found",
+			results("2", "3"),
+			scored([]string{"1", "2", "3"}, []float64{0.9, 0.5, 0.5}),
+			2, 0.5,
+		},
+		{
+			"tie inside the top k",
+			results("3", "4", "1"),
+			scored([]string{"1", "2", "3", "4"}, []float64{0.9, 0.5, 0.5, 0.5}),
+			3, 1,
+		},
+		{
+			// A tie above the k-th is not a tie at the boundary: each must be found
+			"tie above the k-th",
+			results("1", "9", "3"),
+			scored([]string{"1", "2", "3"}, []float64{0.9, 0.9, 0.5}),
+			3, 2.0 / 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlap(tt.approximate, tt.exact, tt.k); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Overlap = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSearcher returns canned results per query vector, keyed by its first component
+type fakeSearcher struct {
+	approximate map[float32][]models.HotelSearchResult
+	exact       map[float32][]models.HotelSearchResult
+	err         error
+}
+
+func (f *fakeSearcher) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	return f.approximate[queryVector[0]], f.err
+}
+
+func (f *fakeSearcher) ExactSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	return f.exact[queryVector[0]], nil
+}
+
+func TestRecall(t *testing.T) {
+	store := &fakeSearcher{
+		approximate: map[float32][]models.HotelSearchResult{0: results("a", "b"), 1: results("a", "x"), 2: results("x", "y")},
+		exact:       map[float32][]models.HotelSearchResult{0: results("a", "b"), 1: results("a", "c"), 2: results("a", "c")},
+	}
+	report, err := Recall(context.Background(), store, [][]float32{{0}, {1}, {2}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.K != 2 || math.Abs(report.Mean-0.5) > 1e-9 || report.Min != 0 || len(report.Queries) != 3 {
+		t.Fatalf("report = %+v, want k 2, mean 0.5, min 0 over 3 queries", report)
+	}
+	if q := report.Queries[1]; q.Query != 1 || q.Recall != 0.5 || !slices.Equal(q.Approximate, []string{"a", "x"}) || !slices.Equal(q.Exact, []string{"a", "c"}) {
+		t.Errorf("query 1 = %+v", q)
+	}
+
+	below := report.Below(0.9)
+	if len(below) != 2 || below[0].Query != 2 || below[1].Query != 1 {
+		t.Errorf("Below(0.9) = %+v, want queries 2 and 1, lowest first", below)
+	}
+	if below := report.Below(0); len(below) != 0 {
+		t.Errorf("Below(0) = %+v, want none", below)
+	}
+}
+
+func TestRecallStopsAtFailedSearch(t *testing.T) {
+	store := &fakeSearcher{err: errors.New("throttled")}
+	_, err := Recall(context.Background(), store, [][]float32{{0}}, 2)
+	if err == nil || !strings.Contains(err.Error(), "vector search for query 0 failed: throttled") {
+		t.Errorf("err = %v, want the failed search", err)
+	}
+}
+
+func TestRecallNoQueries(t *testing.T) {
+	report, err := Recall(context.Background(), &fakeSearcher{}, nil, 5)
+	if err != nil || report.Mean != 0 || report.Min != 0 || len(report.Queries) != 0 {
+		t.Errorf("report = %+v, err %v; want an empty report", report, err)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	baseline := &Baseline{
+		Searcher: &fakeSearcher{approximate: map[float32][]models.HotelSearchResult{1: results("a", "far")}},
+		Corpus: []bench.Doc{
+			{ID: "a", Vector: []float32{1, 0}},
+			{ID: "b", Vector: []float32{1, 1}},
+			{ID: "c", Vector: []float32{1, 1}},
+			{ID: "far", Vector: []float32{0, 1}},
+		},
+		Similarity: "COS",
+	}
+
+	exact, err := baseline.ExactSearch(context.Background(), []float32{1, 0}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b and c tie for the 2nd place, so both are returned
+	if got := ids(exact); !slices.Equal(got, []string{"a", "b", "c"}) || exact[0].Provenance != models.ProvenanceExact || exact[2].Rank != 3 {
+		t.Errorf("ExactSearch = %+v, want a, then b and c tied", exact)
+	}
+
+	report, err := Recall(context.Background(), baseline, [][]float32{{1, 0}}, 2)
+	if err != nil || report.Mean != 0.5 {
+		t.Errorf("recall = %+v, err %v; want 0.5", report, err)
+	}
+}
+
+func TestRecallOfflineStore(t *testing.T) {
+	ctx := context.Background()
+	store := offline.NewStore()
+	err := store.InsertHotelsWithEmbeddings(ctx, []models.HotelForVectorStore{
+		{HotelID: "1", DescriptionVector: []float32{1, 0}},
+		{HotelID: "2", DescriptionVector: []float32{0, 1}},
+		{HotelID: "3", DescriptionVector: []float32{1, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The offline search is exact, so it finds every nearest neighbor
+	report, err := Recall(ctx, store, [][]float32{{1, 0.1}, {0.1, 1}}, 2)
+	if err != nil || report.Mean != 1 || report.Min != 1 {
+		t.Errorf("report = %+v, err %v; want recall 1", report, err)
+	}
+}
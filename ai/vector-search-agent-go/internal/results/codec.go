@@ -0,0 +1,133 @@
+package results
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+)
+
+// ErrUnsupportedVersion is returned by Decode for a schemaVersion it doesn't know
+var ErrUnsupportedVersion = errors.New("unsupported result schema version")
+
+// Marshal encodes r as compact JSON, as in one line of a JSONL file
+func Marshal(r RunResult) ([]byte, error) {
+	return json.Marshal(stamp(r))
+}
+
+// MarshalIndent encodes r as indented JSON followed by a newline, for display and files
+func MarshalIndent(r RunResult) ([]byte, error) {
+	data, err := json.MarshalIndent(stamp(r), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// stamp fills in the schema version of envelopes built without New
+func stamp(r RunResult) RunResult {
+	if r.SchemaVersion == 0 {
+		r.SchemaVersion = SchemaVersion
+	}
+	return r
+}
+
+// Decode reads an envelope of the current version, of versions 2 to 6, or of version 1, which
+// has no schemaVersion field. Older documents are converted to the current layout.
+// Unknown fields are ignored, so batch records decode too.
+func Decode(data []byte) (RunResult, error) {
+	var probe struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return RunResult{}, fmt.Errorf("failed to decode result: %w", err)
+	}
+
+	switch probe.SchemaVersion {
+	case SchemaVersion, 6, 5, 4, 3, 2:
+		// Version 6 only lacks the degradedSearch, version 5 also the ephemeralDatabase,
+		// version 4 also the requestId, version 3 also the stages' percent, and version 2
+		// also the results' rawScore and provenance; they stay zero
+		var r RunResult
+		if err := json.Unmarshal(data, &r); err != nil {
+			return RunResult{}, fmt.Errorf("failed to decode result: %w", err)
+		}
+		r.SchemaVersion = SchemaVersion
+		return r, nil
+	case 0, 1:
+		var v1 resultV1
+		if err := json.Unmarshal(data, &v1); err != nil {
+			return RunResult{}, fmt.Errorf("failed to decode version 1 result: %w", err)
+		}
+		return v1.upgrade(), nil
+	default:
+		return RunResult{}, fmt.Errorf("%w: %d", ErrUnsupportedVersion, probe.SchemaVersion)
+	}
+}
+
+// resultV1 is the cmd/agent --json document of schema version 1. Durations were
+// time.Duration nanoseconds inside a run summary.
+type resultV1 struct {
+	SessionID        string     `json:"sessionId"`
+	ResultID         string     `json:"resultId"`
+	Query            string     `json:"query"`
+	SearchQuery      string     `json:"searchQuery"`
+	PlanningBypassed bool       `json:"planningBypassed"`
+	Answer           string     `json:"answer"`
+	Citations        []Citation `json:"citations"`
+	Results          []struct {
+		Rank      int     `json:"rank"`
+		HotelID   string  `json:"hotelId"`
+		HotelName string  `json:"hotelName"`
+		Score     float64 `json:"score"`
+	} `json:"results"`
+	Summary struct {
+		Total  time.Duration `json:"total"`
+		Stages []struct {
+			Stage    string        `json:"stage"`
+			Duration time.Duration `json:"duration"`
+			Count    int           `json:"count"`
+		} `json:"stages"`
+		Usage         []Usage `json:"usage"`
+		EstimatedCost float64 `json:"estimatedCost"`
+		Err           string  `json:"error"`
+	} `json:"summary"`
+	Build buildinfo.Info `json:"build"`
+}
+
+// upgrade converts a version 1 document. K wasn't recorded, so it is left at 0.
+func (v resultV1) upgrade() RunResult {
+	r := RunResult{
+		SchemaVersion:    SchemaVersion,
+		SessionID:        v.SessionID,
+		ResultID:         v.ResultID,
+		Query:            v.Query,
+		SearchQuery:      v.SearchQuery,
+		PlanningBypassed: v.PlanningBypassed,
+		Answer:           v.Answer,
+		Citations:        v.Citations,
+		Results:          make([]RetrievedHotel, 0, len(v.Results)),
+		Usage:            v.Summary.Usage,
+		EstimatedCost:    v.Summary.EstimatedCost,
+		Durations:        Durations{TotalMs: milliseconds(v.Summary.Total), Stages: make([]StageDuration, 0, len(v.Summary.Stages))},
+		Build:            v.Build,
+	}
+	if r.Citations == nil {
+		r.Citations = []Citation{}
+	}
+	if r.Usage == nil {
+		r.Usage = []Usage{}
+	}
+	for _, h := range v.Results {
+		r.Results = append(r.Results, RetrievedHotel{Rank: h.Rank, HotelID: h.HotelID, HotelName: h.HotelName, Score: h.Score})
+	}
+	for _, s := range v.Summary.Stages {
+		r.Durations.Stages = append(r.Durations.Stages, StageDuration{Stage: s.Stage, Ms: milliseconds(s.Duration), Count: s.Count})
+	}
+	if v.Summary.Err != "" {
+		r.Error = &RunError{Message: v.Summary.Err}
+	}
+	return r
+}
@@ -0,0 +1,252 @@
+package results
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fixedBuild stands in for the build information, which differs between machines
+var fixedBuild = buildinfo.Info{
+	Module:      "github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go",
+	Version:     "v0.0.0",
+	Revision:    "0123456789abcdef",
+	GoVersion:   "go1.24.0",
+	MongoDriver: "v1.17.0",
+	OpenAI:      "v3.0.0",
+}
+
+// fixture is the envelope stored in testdata/result_v7.json
+func fixture() RunResult {
+	state, summary := runState()
+	state.ResultID = "5f0c7a3e-8d1b-4c2a-9e6f-1a2b3c4d5e6f"
+	state.EphemeralDatabase = "hotels-ci-20261016t093000-3fa2c1"
+	summary.DegradedSearch = "exact search (no vector index on DescriptionVector; compared all 50 documents); latency is higher than with the vector index, create it with cmd/upload"
+	r := New(state, summary, nil)
+	r.Build = fixedBuild
+	return r
+}
+
+// readTestdata returns the contents of testdata/name
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMarshalIndentGolden(t *testing.T) {
+	got, err := MarshalIndent(fixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", "result_v7.json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := readTestdata(t, "result_v7.json")
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match the encoded envelope; if the wire format change is intended, bump SchemaVersion, run go test -update, and review the diff\ngot:\n%s", path, got)
+	}
+}
+
+func TestDecodeCurrentVersion(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v7.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fixture(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesVersion6(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v6.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 6 recorded no degraded search
+	want := fixture()
+	want.DegradedSearch = ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesVersion5(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v5.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 5 recorded neither the degraded search nor the ephemeral database
+	want := fixture()
+	want.DegradedSearch, want.EphemeralDatabase = "", ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesVersion4(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v4.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 4 recorded neither the degraded search, the ephemeral database, nor the
+	// request ID
+	want := fixture()
+	want.DegradedSearch, want.EphemeralDatabase, want.RequestID = "", "", ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesVersion3(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v3.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 3 recorded neither the degraded search, the ephemeral database, the request
+	// ID, nor the stage percentages
+	want := fixture()
+	want.DegradedSearch, want.EphemeralDatabase, want.RequestID = "", "", ""
+	for i := range want.Durations.Stages {
+		want.Durations.Stages[i].Percent = 0
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesVersion2(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 2 recorded neither the degraded search, the ephemeral database, the request
+	// ID, the raw scores, the provenance, nor the stage percentages
+	want := fixture()
+	want.DegradedSearch, want.EphemeralDatabase, want.RequestID = "", "", ""
+	for i := range want.Results {
+		want.Results[i].RawScore, want.Results[i].Provenance = 0, ""
+	}
+	for i := range want.Durations.Stages {
+		want.Durations.Stages[i].Percent = 0
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesVersion1(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 1 recorded neither the degraded search, the ephemeral database, the request
+	// ID, k, the hotels' category, rating, city, raw score, and provenance, nor the stage
+	// percentages
+	want := fixture()
+	want.DegradedSearch, want.EphemeralDatabase, want.RequestID, want.K = "", "", "", 0
+	for i := range want.Results {
+		want.Results[i].Category, want.Results[i].Rating, want.Results[i].City = "", 0, ""
+		want.Results[i].RawScore, want.Results[i].Provenance = 0, ""
+	}
+	for i := range want.Durations.Stages {
+		want.Durations.Stages[i].Percent = 0
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeUpgradesFailedVersion1(t *testing.T) {
+	got, err := Decode(readTestdata(t, "result_v1_failed.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.SchemaVersion != SchemaVersion || got.Error == nil || got.Error.Message != "planner stage timed out: context deadline exceeded" {
+		t.Errorf("error = %+v, want the version 1 summary error", got.Error)
+	}
+	// Null lists in version 1 become empty ones
+	if got.Citations == nil || got.Usage == nil || len(got.Results) != 0 || got.Durations.TotalMs != 20000 {
+		t.Errorf("envelope = %+v", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	upgraded, err := Decode(readTestdata(t, "result_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := fixture()
+	failed.Error = &RunError{Message: "synthesizer stage failed: content filtered", Stage: "synthesizer"}
+
+	tests := []struct {
+		name string
+		r    RunResult
+	}{
+		{"current", fixture()},
+		{"failed", failed},
+		{"upgraded from version 1", upgraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, marshal := range []func(RunResult) ([]byte, error){Marshal, MarshalIndent} {
+				data, err := marshal(tt.r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := Decode(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.r) {
+					t.Errorf("round trip = %+v\nwant %+v", got, tt.r)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalStampsSchemaVersion(t *testing.T) {
+	data, err := Marshal(RunResult{Query: "pool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `{"schemaVersion":7,`) || bytes.ContainsRune(data, '\n') {
+		t.Errorf("Marshal = %s, want one line starting with the schema version", data)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode([]byte(`{"schemaVersion": 8, "query": "pool"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("future version: err = %v, want ErrUnsupportedVersion", err)
+	}
+	if _, err := Decode([]byte(`not json`)); err == nil {
+		t.Error("Decode accepted invalid JSON")
+	}
+	if _, err := Decode([]byte(`{"schemaVersion": 3, "results": "none"}`)); err == nil {
+		t.Error("Decode accepted a malformed envelope")
+	}
+}
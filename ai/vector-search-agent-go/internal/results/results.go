@@ -0,0 +1,182 @@
+// Package results defines the result envelope shared by every surface that reports an
+// agent run: cmd/agent --json, the HTTP /chat response, and the cmd/batch output file.
+// Keeping one definition means the wire format can't drift between them.
+package results
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// SchemaVersion is the version written in the schemaVersion field. Version 1 was the
+// cmd/agent --json document before the envelope existed, version 2 had no rawScore or
+// provenance on its results, version 3 had no percent on its stage durations, and
+// version 4 had no requestId, version 5 had no ephemeralDatabase, and version 6 had no
+// degradedSearch; Decode still reads them all.
+const SchemaVersion = 7
+
+// RunResult is the outcome of one agent run
+type RunResult struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	SessionID     string `json:"sessionId"`
+	ResultID      string `json:"resultId"`
+	// RequestID correlates the run with its log records; quote it when reporting a problem
+	RequestID string `json:"requestId,omitempty"`
+	Query     string `json:"query"`
+	K         int    `json:"k"`
+	// EphemeralDatabase is the database created for the run when EPHEMERAL_DATABASE is
+	// set; drop it with cmd/cleanup if the run failed before cleaning up
+	EphemeralDatabase string `json:"ephemeralDatabase,omitempty"`
+	// DegradedSearch is set when a search ran without its vector index, by comparing
+	// every stored vector; the results are exact but the latency is higher than usual
+	DegradedSearch   string           `json:"degradedSearch,omitempty"`
+	SearchQuery      string           `json:"searchQuery,omitempty"`
+	PlanningBypassed bool             `json:"planningBypassed"`
+	Answer           string           `json:"answer"`
+	Citations        []Citation       `json:"citations"`
+	Results          []RetrievedHotel `json:"results"`
+	Usage            []Usage          `json:"usage"`
+	EstimatedCost    float64          `json:"estimatedCost"`
+	Durations        Durations        `json:"durations"`
+	// Error is set when the run failed; the other fields hold whatever was produced first
+	Error *RunError      `json:"error,omitempty"`
+	Build buildinfo.Info `json:"build"`
+}
+
+// RetrievedHotel is one search result, ranked from 1. Score is the final score and
+// RawScore the one the search engine returned; Provenance says how Score was produced
+// (ann, exact, fused, or reranked).
+type RetrievedHotel struct {
+	Rank       int     `json:"rank"`
+	HotelID    string  `json:"hotelId"`
+	HotelName  string  `json:"hotelName"`
+	Category   string  `json:"category,omitempty"`
+	Rating     float64 `json:"rating,omitempty"`
+	City       string  `json:"city,omitempty"`
+	Score      float64 `json:"score"`
+	RawScore   float64 `json:"rawScore"`
+	Provenance string  `json:"provenance,omitempty"`
+}
+
+// Citation is a retrieved hotel the answer mentions
+type Citation struct {
+	Rank      int     `json:"rank"`
+	HotelID   string  `json:"hotelId"`
+	HotelName string  `json:"hotelName"`
+	Score     float64 `json:"score"`
+}
+
+// Usage is the token usage of one deployment during the run
+type Usage struct {
+	Deployment       string  `json:"deployment"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	EstimatedCost    float64 `json:"estimatedCost"`
+	PriceKnown       bool    `json:"priceKnown"`
+}
+
+// Durations records where the run's wall time went, in milliseconds
+type Durations struct {
+	TotalMs float64         `json:"totalMs"`
+	Stages  []StageDuration `json:"stages"`
+}
+
+// StageDuration is the accumulated time of one kind of step and its share of the total
+type StageDuration struct {
+	Stage   string  `json:"stage"`
+	Ms      float64 `json:"ms"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// RunError describes why a run failed
+type RunError struct {
+	Message string `json:"message"`
+	// Stage is the pipeline stage that failed, when known
+	Stage    string `json:"stage,omitempty"`
+	TimedOut bool   `json:"timedOut,omitempty"`
+}
+
+// New builds the envelope from a finished or failed run. runErr is the error the
+// pipeline returned, or nil.
+func New(state *agents.PipelineState, summary agents.RunSummary, runErr error) RunResult {
+	r := RunResult{
+		SchemaVersion:     SchemaVersion,
+		SessionID:         state.SessionID,
+		ResultID:          state.ResultID,
+		RequestID:         state.RequestID,
+		Query:             state.Query,
+		K:                 state.NearestNeighbors,
+		EphemeralDatabase: state.EphemeralDatabase,
+		DegradedSearch:    summary.DegradedSearch,
+		SearchQuery:       state.SearchQuery,
+		PlanningBypassed:  state.PlanningBypassed,
+		Answer:            state.Answer,
+		Citations:         make([]Citation, 0, len(state.Citations)),
+		Results:           NewRetrievedHotels(state.Results),
+		Usage:             NewUsage(summary.Usage),
+		EstimatedCost:     summary.EstimatedCost,
+		Durations:         Durations{TotalMs: milliseconds(summary.Total), Stages: make([]StageDuration, 0, len(summary.Stages))},
+		Build:             buildinfo.Read(),
+	}
+	for _, c := range state.Citations {
+		r.Citations = append(r.Citations, Citation{Rank: c.Rank, HotelID: c.HotelID, HotelName: c.HotelName, Score: c.Score})
+	}
+	for _, s := range summary.Stages {
+		r.Durations.Stages = append(r.Durations.Stages, StageDuration{Stage: s.Stage, Ms: milliseconds(s.Duration), Count: s.Count, Percent: s.Percent})
+	}
+
+	if runErr != nil {
+		r.Error = &RunError{Message: runErr.Error()}
+		var stageErr *agents.StageError
+		if errors.As(runErr, &stageErr) {
+			r.Error.Stage = stageErr.Stage
+			r.Error.TimedOut = stageErr.TimedOut()
+		}
+	}
+	return r
+}
+
+// NewRetrievedHotels converts search results, keeping their rank, or ranking them from 1
+// by position when they have none
+func NewRetrievedHotels(found []models.HotelSearchResult) []RetrievedHotel {
+	hotels := make([]RetrievedHotel, 0, len(found))
+	for i, result := range found {
+		rank := result.Rank
+		if rank == 0 {
+			rank = i + 1
+		}
+		hotels = append(hotels, RetrievedHotel{
+			Rank:       rank,
+			HotelID:    result.Hotel.HotelID,
+			HotelName:  result.Hotel.HotelName,
+			Category:   result.Hotel.Category,
+			Rating:     result.Hotel.Rating,
+			City:       result.Hotel.Address.City,
+			Score:      result.Score,
+			RawScore:   result.RawScore,
+			Provenance: result.Provenance,
+		})
+	}
+	return hotels
+}
+
+// NewUsage converts a usage snapshot
+func NewUsage(usage []clients.DeploymentUsage) []Usage {
+	converted := make([]Usage, 0, len(usage))
+	for _, u := range usage {
+		converted = append(converted, Usage(u))
+	}
+	return converted
+}
+
+// milliseconds converts d to fractional milliseconds, keeping microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
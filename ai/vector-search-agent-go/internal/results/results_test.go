@@ -0,0 +1,89 @@
+package results
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/agents"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// runState is a finished run with two results, one of them cited
+func runState() (*agents.PipelineState, agents.RunSummary) {
+	state := agents.NewPipelineState("quiet hotel near the beach", 2)
+	state.SessionID = "session-1"
+	state.RequestID = "req-1"
+	state.SearchQuery = "quiet beach hotel"
+	state.Answer = "Try Ocean Retreat [1]."
+	state.Results = []models.HotelSearchResult{
+		{Hotel: models.HotelForVectorStore{HotelID: "7", HotelName: "Ocean Retreat", Category: "Resort and Spa", Rating: 4.5, Address: models.Address{City: "Miami"}}, Score: 0.91, Rank: 1, RawScore: 0.83, Provenance: models.ProvenanceReranked},
+		{Hotel: models.HotelForVectorStore{HotelID: "12", HotelName: "City Inn", Category: "Budget", Rating: 3.1, Address: models.Address{City: "Austin"}}, Score: 0.77, Rank: 2, RawScore: 0.86, Provenance: models.ProvenanceReranked},
+	}
+	state.Citations = agents.BuildCitations(state.Answer, state.Results)
+	summary := agents.RunSummary{
+		Total:         1500*time.Millisecond + 250*time.Microsecond,
+		Stages:        []agents.StageLatency{{Stage: "planner", Duration: 400 * time.Millisecond, Count: 1, Percent: 26.7}},
+		Usage:         []clients.DeploymentUsage{{Deployment: "gpt-4o", Calls: 1, PromptTokens: 900, CompletionTokens: 80, EstimatedCost: 0.003, PriceKnown: true}},
+		EstimatedCost: 0.003,
+	}
+	return state, summary
+}
+
+func TestNew(t *testing.T) {
+	state, summary := runState()
+	r := New(state, summary, nil)
+
+	if r.SchemaVersion != SchemaVersion || r.SessionID != "session-1" || r.ResultID != state.ResultID || r.RequestID != "req-1" || r.K != 2 ||
+		r.SearchQuery != "quiet beach hotel" || r.Answer != state.Answer || r.Error != nil {
+		t.Errorf("envelope = %+v", r)
+	}
+	want := RetrievedHotel{Rank: 2, HotelID: "12", HotelName: "City Inn", Category: "Budget", Rating: 3.1, City: "Austin", Score: 0.77, RawScore: 0.86, Provenance: "reranked"}
+	if len(r.Results) != 2 || r.Results[1] != want {
+		t.Errorf("results = %+v, want %+v second", r.Results, want)
+	}
+	if len(r.Citations) != 1 || r.Citations[0] != (Citation{Rank: 1, HotelID: "7", HotelName: "Ocean Retreat", Score: 0.91}) {
+		t.Errorf("citations = %+v", r.Citations)
+	}
+	if len(r.Usage) != 1 || r.Usage[0] != Usage(summary.Usage[0]) || r.EstimatedCost != 0.003 {
+		t.Errorf("usage = %+v, cost %v", r.Usage, r.EstimatedCost)
+	}
+	// Durations keep microsecond precision
+	if r.Durations.TotalMs != 1500.25 || len(r.Durations.Stages) != 1 || r.Durations.Stages[0] != (StageDuration{Stage: "planner", Ms: 400, Count: 1, Percent: 26.7}) {
+		t.Errorf("durations = %+v", r.Durations)
+	}
+	if r.Build != buildinfo.Read() {
+		t.Errorf("build = %+v, want the running binary", r.Build)
+	}
+}
+
+func TestNewFailedRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantStage    string
+		wantTimedOut bool
+	}{
+		{"stage failure", &agents.StageError{Stage: "synthesizer", Err: errors.New("content filtered")}, "synthesizer", false},
+		{"stage timeout", fmt.Errorf("run: %w", &agents.StageError{Stage: "planner", Err: context.DeadlineExceeded}), "planner", true},
+		{"other", errors.New("connection reset"), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(agents.NewPipelineState("pool", 3), agents.RunSummary{}, tt.err)
+			want := RunError{Message: tt.err.Error(), Stage: tt.wantStage, TimedOut: tt.wantTimedOut}
+			if r.Error == nil || *r.Error != want {
+				t.Errorf("error = %+v, want %+v", r.Error, want)
+			}
+			// A failed run still has empty lists rather than nulls
+			if r.Citations == nil || r.Results == nil || r.Usage == nil || r.Durations.Stages == nil {
+				t.Errorf("envelope has nil lists: %+v", r)
+			}
+		})
+	}
+}
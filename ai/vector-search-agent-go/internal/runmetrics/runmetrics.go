@@ -0,0 +1,150 @@
+// Package runmetrics records the token usage, estimated cost, document counts, and
+// duration of each command run in METRICS_COLLECTION, so spend can be followed over time
+// and per environment. cmd/stats --runs summarizes the recorded runs.
+package runmetrics
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+)
+
+// writeTimeout bounds a write, which runs even when the run's own context has expired
+// so that failed and interrupted runs are still recorded
+const writeTimeout = 10 * time.Second
+
+// closeTimeout bounds how long Close waits for the writes still in flight when a
+// command exits
+const closeTimeout = 5 * time.Second
+
+// processStart is when the command started; every run is timed from it, so a run's
+// duration includes loading the config and connecting
+var processStart = time.Now()
+
+// Store persists run metrics. *vectorstore.VectorStore implements it.
+type Store interface {
+	InsertRunMetrics(ctx context.Context, record models.RunMetrics) error
+}
+
+// Run is what a command reports of its run
+type Run struct {
+	Command string
+	// Usage is the snapshot of the usage tracker of the run's clients
+	Usage []clients.DeploymentUsage
+	// Documents counts what the run read or wrote, such as "inserted" or "results"
+	Documents map[string]int64
+	Err       error
+}
+
+// Recorder records command runs in the metrics collection. A nil Recorder records
+// nothing, so callers need not check whether METRICS_COLLECTION is set.
+type Recorder struct {
+	store       Store
+	environment string
+	// embedding is the deployment whose input tokens are embedding tokens
+	embedding string
+	start     time.Time
+	// closeTimeout bounds the wait of Close
+	closeTimeout time.Duration
+	// writes counts the writes in flight
+	writes sync.WaitGroup
+}
+
+// New returns a recorder writing to store. A run's duration is measured from the start
+// of the process, and the tokens of embeddingDeployment are counted as embedding tokens.
+// Close the recorder before closing store.
+func New(store Store, environment, embeddingDeployment string) *Recorder {
+	return &Recorder{store: store, environment: environment, embedding: embeddingDeployment, start: processStart, closeTimeout: closeTimeout}
+}
+
+// FromConfig returns a recorder writing to store when cfg sets METRICS_COLLECTION, or nil
+func FromConfig(store Store, cfg *config.Config) *Recorder {
+	if cfg == nil || cfg.VectorStore.MetricsCollection == "" {
+		return nil
+	}
+	return New(store, cfg.Runtime.MetricsEnvironment, cfg.OpenAI.EmbeddingDeployment)
+}
+
+// Record stores one document describing run, with the session and request IDs of ctx,
+// in the background; Close waits for it. The write never fails the run: an error is
+// logged as a warning.
+func (r *Recorder) Record(ctx context.Context, run Run) {
+	if r == nil {
+		return
+	}
+
+	record := r.newRecord(ctx, run, time.Now())
+
+	r.writes.Add(1)
+	go func() {
+		defer r.writes.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+		defer cancel()
+
+		if err := r.store.InsertRunMetrics(ctx, record); err != nil {
+			slog.WarnContext(ctx, "failed to record run metrics", "command", run.Command, "err", err)
+		}
+	}()
+}
+
+// Close waits for the writes in flight, for at most a few seconds so that a slow store
+// can't hold up the exit; a write still running then is abandoned with a warning
+func (r *Recorder) Close() {
+	if r == nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(r.closeTimeout):
+		slog.Warn("gave up waiting for the run metrics to be written", "timeout", r.closeTimeout)
+	}
+}
+
+// newRecord builds the record of run, finished at now
+func (r *Recorder) newRecord(ctx context.Context, run Run, now time.Time) models.RunMetrics {
+	record := models.RunMetrics{
+		Command:     run.Command,
+		Environment: r.environment,
+		SessionID:   session.FromContext(ctx),
+		RequestID:   session.RequestID(ctx),
+		Usage:       make([]models.RunUsage, 0, len(run.Usage)),
+		Documents:   run.Documents,
+		DurationMs:  now.Sub(r.start).Milliseconds(),
+		CreatedAt:   now.UTC(),
+		Build:       buildinfo.Read(),
+	}
+	if run.Err != nil {
+		record.Error = run.Err.Error()
+	}
+	for _, u := range run.Usage {
+		usage := models.RunUsage{
+			Deployment:       u.Deployment,
+			Calls:            u.Calls,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			EstimatedCost:    u.EstimatedCost,
+		}
+		if u.Deployment == r.embedding {
+			usage.EmbeddingTokens, usage.PromptTokens = u.PromptTokens, 0
+		}
+		record.Usage = append(record.Usage, usage)
+		record.PromptTokens += usage.PromptTokens
+		record.CompletionTokens += usage.CompletionTokens
+		record.EmbeddingTokens += usage.EmbeddingTokens
+		record.EstimatedCost += usage.EstimatedCost
+	}
+	return record
+}
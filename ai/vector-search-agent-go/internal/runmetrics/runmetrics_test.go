@@ -0,0 +1,182 @@
+package runmetrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/config"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/session"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// fakeStore records inserted run metrics, or fails with err. Each insert waits for
+// release first, when it is set.
+type fakeStore struct {
+	records []models.RunMetrics
+	err     error
+	ctxErr  error
+	release chan struct{}
+}
+
+func (f *fakeStore) InsertRunMetrics(ctx context.Context, record models.RunMetrics) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.ctxErr = ctx.Err()
+	if f.err != nil {
+		return f.err
+	}
+	f.records = append(f.records, record)
+	return nil
+}
+
+// usage is the usage of an agent run: one embedding and two chat completions
+var usage = []clients.DeploymentUsage{
+	{Deployment: "gpt-4o-mini", Calls: 2, PromptTokens: 400, CompletionTokens: 60, EstimatedCost: 0.0001},
+	{Deployment: "text-embedding-3-small", Calls: 1, PromptTokens: 7, EstimatedCost: 0.0000002},
+}
+
+func TestNewRecord(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	r := &Recorder{environment: "dev", embedding: "text-embedding-3-small", start: start}
+	ctx := session.WithRequestID(session.WithID(context.Background(), "session-1"), "req-1")
+
+	record := r.newRecord(ctx, Run{Command: "agent", Usage: usage, Documents: map[string]int64{"results": 3}}, start.Add(1500*time.Millisecond))
+
+	if record.Command != "agent" || record.Environment != "dev" || record.SessionID != "session-1" || record.RequestID != "req-1" ||
+		record.DurationMs != 1500 || record.Error != "" || !maps.Equal(record.Documents, map[string]int64{"results": 3}) {
+		t.Errorf("record = %+v", record)
+	}
+	// The input tokens of the embedding deployment are embedding tokens
+	want := []models.RunUsage{
+		{Deployment: "gpt-4o-mini", Calls: 2, PromptTokens: 400, CompletionTokens: 60, EstimatedCost: 0.0001},
+		{Deployment: "text-embedding-3-small", Calls: 1, EmbeddingTokens: 7, EstimatedCost: 0.0000002},
+	}
+	if !slices.Equal(record.Usage, want) {
+		t.Errorf("usage = %+v, want %+v", record.Usage, want)
+	}
+	if record.PromptTokens != 400 || record.CompletionTokens != 60 || record.EmbeddingTokens != 7 || math.Abs(record.EstimatedCost-0.0001002) > 1e-12 {
+		t.Errorf("totals = %d prompt, %d completion, %d embedding, $%g; want 400, 60, 7, and the sum of the costs",
+			record.PromptTokens, record.CompletionTokens, record.EmbeddingTokens, record.EstimatedCost)
+	}
+	if record.Build != buildinfo.Read() {
+		t.Errorf("build = %+v, want the running binary", record.Build)
+	}
+	if want := start.Add(1500 * time.Millisecond).UTC(); record.CreatedAt != want || record.CreatedAt.Location() != time.UTC {
+		t.Errorf("createdAt = %v, want %v in UTC", record.CreatedAt, want)
+	}
+}
+
+func TestNewRecordOfFailedRun(t *testing.T) {
+	r := New(&fakeStore{}, "", "text-embedding-3-small")
+
+	record := r.newRecord(context.Background(), Run{Command: "upload", Err: errors.New("embedding failed")}, time.Now())
+	if record.Error != "embedding failed" || record.Usage == nil || len(record.Usage) != 0 || record.Documents != nil {
+		t.Errorf("record = %+v, want the error and an empty usage list", record)
+	}
+}
+
+func TestRecorderRecords(t *testing.T) {
+	store := &fakeStore{}
+	r := New(store, "dev", "text-embedding-3-small")
+
+	// A run whose context has already expired is still recorded
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Record(ctx, Run{Command: "search", Usage: usage})
+	r.Close()
+
+	if len(store.records) != 1 || store.records[0].Command != "search" || store.records[0].EmbeddingTokens != 7 {
+		t.Fatalf("records = %+v, want the search run", store.records)
+	}
+	if store.ctxErr != nil {
+		t.Errorf("write context err = %v, want a live context", store.ctxErr)
+	}
+}
+
+func TestRecorderFailsSoftly(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	r := New(&fakeStore{err: errors.New("connection reset")}, "", "")
+	r.Record(context.Background(), Run{Command: "agent"})
+	r.Close()
+
+	if got := logs.String(); strings.Count(got, "level=WARN") != 1 || !strings.Contains(got, "failed to record run metrics") || !strings.Contains(got, "connection reset") {
+		t.Errorf("logged %q, want one warning with the error", got)
+	}
+
+	// A nil recorder records nothing
+	var disabled *Recorder
+	disabled.Record(context.Background(), Run{Command: "agent"})
+	disabled.Close()
+}
+
+func TestRecorderWritesInBackground(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	store := &fakeStore{release: make(chan struct{})}
+	defer close(store.release)
+	r := New(store, "", "")
+	r.closeTimeout = 20 * time.Millisecond
+
+	// Record returns while the store is still busy, and Close stops waiting for it
+	recorded := make(chan struct{})
+	go func() {
+		r.Record(context.Background(), Run{Command: "upload"})
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Record waited for the store")
+	}
+	start := time.Now()
+	r.Close()
+	if waited := time.Since(start); waited < r.closeTimeout || waited > 5*time.Second {
+		t.Errorf("Close waited %s, want about %s", waited, r.closeTimeout)
+	}
+	if got := logs.String(); !strings.Contains(got, "gave up waiting for the run metrics to be written") {
+		t.Errorf("logged %q, want a warning about the abandoned write", got)
+	}
+}
+
+func TestRecorderTimesTheProcess(t *testing.T) {
+	if r := New(&fakeStore{}, "", ""); !r.start.Equal(processStart) {
+		t.Errorf("start = %v, want the process start %v", r.start, processStart)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		OpenAI:      &clients.OpenAIConfig{EmbeddingDeployment: "text-embedding-3-small"},
+		VectorStore: &vectorstore.VectorStoreConfig{},
+		Runtime:     config.Runtime{MetricsEnvironment: "prod"},
+	}
+	if r := FromConfig(&fakeStore{}, cfg); r != nil {
+		t.Errorf("recorder = %+v without METRICS_COLLECTION, want nil", r)
+	}
+	if r := FromConfig(&fakeStore{}, nil); r != nil {
+		t.Errorf("recorder = %+v without a config, want nil", r)
+	}
+
+	cfg.VectorStore.MetricsCollection = "run_metrics"
+	r := FromConfig(&fakeStore{}, cfg)
+	if r == nil || r.environment != "prod" || r.embedding != "text-embedding-3-small" {
+		t.Errorf("recorder = %+v, want one for prod counting text-embedding-3-small as embeddings", r)
+	}
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+// NewID returns the session ID from SESSION_ID, or a new random UUID when unset
+func NewID() string {
+	if id := os.Getenv("SESSION_ID"); id != "" {
+		return id
+	}
+	return uuid.NewString()
+}
+
+// WithID returns a copy of ctx carrying the session ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the session ID carried by ctx, or "" if there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// RequestIDHeader is the HTTP header that carries a request's correlation ID
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the correlation IDs accepted from callers
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// NewRequestID returns a new random correlation ID for a run or request
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// ValidRequestID reports whether id is acceptable as a correlation ID from a caller: 1
+// to 128 printable ASCII characters without spaces, so it can't break a log line
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a copy of ctx carrying the correlation ID of the current run
+// or request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the correlation ID carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewID(t *testing.T) {
+	t.Setenv("SESSION_ID", "")
+	id := NewID()
+	if _, err := uuid.Parse(id); err != nil {
+		t.Errorf("NewID() = %q, want a UUID: %v", id, err)
+	}
+	if NewID() == id {
+		t.Error("NewID returned the same ID twice")
+	}
+
+	t.Setenv("SESSION_ID", "demo-session")
+	if got := NewID(); got != "demo-session" {
+		t.Errorf("NewID() with SESSION_ID set = %q, want demo-session", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != "" {
+		t.Errorf("FromContext(empty) = %q, want empty", got)
+	}
+	ctx := WithID(context.Background(), "abc")
+	if got := FromContext(ctx); got != "abc" {
+		t.Errorf("FromContext = %q, want abc", got)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	id := NewRequestID()
+	if !ValidRequestID(id) || NewRequestID() == id {
+		t.Errorf("NewRequestID() = %q, want a fresh valid ID", id)
+	}
+
+	if got := RequestID(context.Background()); got != "" {
+		t.Errorf("RequestID(empty) = %q, want empty", got)
+	}
+	ctx := WithRequestID(WithID(context.Background(), "session"), "req-1")
+	if got := RequestID(ctx); got != "req-1" {
+		t.Errorf("RequestID = %q, want req-1", got)
+	}
+	if got := FromContext(ctx); got != "session" {
+		t.Errorf("FromContext = %q, want the session ID alongside the request ID", got)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"req-1", true},
+		{"0f8fad5b-d9cb-469f-a165-70867728950e", true},
+		{"", false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{"café", false},
+		{strings.Repeat("a", 128), true},
+		{strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
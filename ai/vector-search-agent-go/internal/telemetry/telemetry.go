@@ -0,0 +1,160 @@
+// Package telemetry exports OpenTelemetry traces of agent runs: the pipeline stages, the
+// Azure OpenAI calls, and the DocumentDB operations. Tracing is configured from the
+// standard OTEL_* environment variables and is a no-op unless an OTLP endpoint is set.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer every span of this module comes from
+const instrumentationName = "github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go"
+
+// shutdownTimeout bounds flushing the spans still queued when a command exits
+const shutdownTimeout = 5 * time.Second
+
+// propagator reads and writes W3C trace context and baggage headers
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Attribute keys the semantic conventions don't cover
+const (
+	// DeploymentKey is the Azure OpenAI deployment a request was sent to
+	DeploymentKey = attribute.Key("azure.openai.deployment")
+	// KKey is the number of nearest neighbors a vector search asked for
+	KKey = attribute.Key("vector_search.k")
+	// ResultCountKey is the number of results a search or pipeline run produced
+	ResultCountKey = attribute.Key("vector_search.result_count")
+	// StageKey is the pipeline stage a span covers
+	StageKey = attribute.Key("agent.stage")
+)
+
+// Config holds the OTEL_* variables that decide whether and how spans are exported. The
+// tags describe the environment variable of each field; see config.Variables. The OTLP
+// exporter reads the rest of the OTEL_EXPORTER_OTLP_* variables itself.
+type Config struct {
+	SDKDisabled    bool   `env:"OTEL_SDK_DISABLED" format:"bool" default:"false" desc:"Turn tracing off even when an OTLP endpoint is set"`
+	TracesExporter string `env:"OTEL_TRACES_EXPORTER" example:"otlp" desc:"Trace exporter; anything other than otlp turns tracing off"`
+	Endpoint       string `env:"OTEL_EXPORTER_OTLP_ENDPOINT" example:"http://localhost:4318" desc:"OTLP/HTTP endpoint to export traces to; unset turns tracing off"`
+	TracesEndpoint string `env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT" example:"http://localhost:4318/v1/traces" desc:"OTLP/HTTP endpoint for traces, overriding OTEL_EXPORTER_OTLP_ENDPOINT"`
+	Protocol       string `env:"OTEL_EXPORTER_OTLP_PROTOCOL" format:"oneof:http/protobuf" example:"http/protobuf" desc:"OTLP protocol; only http/protobuf is supported"`
+	TracesProtocol string `env:"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL" format:"oneof:http/protobuf" example:"http/protobuf" desc:"OTLP protocol for traces, overriding OTEL_EXPORTER_OTLP_PROTOCOL"`
+}
+
+// LoadConfig reads the tracing settings from getenv
+func LoadConfig(getenv func(string) string) Config {
+	disabled, _ := strconv.ParseBool(getenv("OTEL_SDK_DISABLED"))
+	return Config{
+		SDKDisabled:    disabled,
+		TracesExporter: getenv("OTEL_TRACES_EXPORTER"),
+		Endpoint:       getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		TracesEndpoint: getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		Protocol:       getenv("OTEL_EXPORTER_OTLP_PROTOCOL"),
+		TracesProtocol: getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"),
+	}
+}
+
+// Enabled reports whether c configures an OTLP trace exporter: an endpoint is set, the
+// SDK isn't disabled, and OTEL_TRACES_EXPORTER, if set, is otlp
+func (c Config) Enabled() bool {
+	if c.SDKDisabled {
+		return false
+	}
+	if c.TracesExporter != "" && !strings.EqualFold(c.TracesExporter, "otlp") {
+		return false
+	}
+	return c.Endpoint != "" || c.TracesEndpoint != ""
+}
+
+// Enabled reports whether getenv configures an OTLP trace exporter; see Config.Enabled
+func Enabled(getenv func(string) string) bool {
+	return LoadConfig(getenv).Enabled()
+}
+
+// Setup installs the W3C trace context propagator and, when Enabled, a tracer provider
+// exporting spans over OTLP/HTTP. The exporter reads its endpoint, headers, and timeout
+// from the OTEL_EXPORTER_OTLP_* variables, and the resource takes OTEL_SERVICE_NAME and
+// OTEL_RESOURCE_ATTRIBUTES, with service as the default service name. Call the returned
+// function before exiting to flush queued spans.
+func Setup(ctx context.Context, service string) (func(), error) {
+	otel.SetTextMapPropagator(propagator)
+	config := LoadConfig(os.Getenv)
+	if !config.Enabled() {
+		return func() {}, nil
+	}
+
+	protocol := config.TracesProtocol
+	if protocol == "" {
+		protocol = config.Protocol
+	}
+	if protocol != "" && protocol != "http/protobuf" {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q; only http/protobuf is supported", protocol)
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	slog.DebugContext(ctx, "exporting traces over OTLP", "service", service)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			slog.Warn("failed to flush traces", "err", err)
+		}
+	}, nil
+}
+
+// Start starts an internal span named name, a child of the span carried by ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartClient starts a span for a call to a remote service, such as Azure OpenAI or
+// DocumentDB
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// StartServer starts a span for an incoming request, continuing the trace whose W3C
+// trace context the carrier holds, if any
+func StartServer(ctx context.Context, carrier propagation.TextMapCarrier, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx = propagator.Extract(ctx, carrier)
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// End records err on span, marking it failed, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
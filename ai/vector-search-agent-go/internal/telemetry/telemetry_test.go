@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"unset", nil, false},
+		{"endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, true},
+		{"traces endpoint", map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4318/v1/traces"}, true},
+		{"otlp exporter", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_TRACES_EXPORTER": "OTLP"}, true},
+		{"exporter none", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_TRACES_EXPORTER": "none"}, false},
+		{"sdk disabled", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_SDK_DISABLED": "true"}, false},
+		{"exporter without endpoint", map[string]string{"OTEL_TRACES_EXPORTER": "otlp"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := Enabled(getenv); got != tt.want {
+				t.Errorf("Enabled = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupWithoutEndpointKeepsProvider(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	previous := otel.GetTracerProvider()
+
+	flush, err := Setup(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flush()
+	if otel.GetTracerProvider() != previous {
+		t.Error("Setup replaced the tracer provider without an OTLP endpoint")
+	}
+}
+
+func TestSetupRejectsGRPC(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4317")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+
+	if _, err := Setup(context.Background(), "test"); err == nil {
+		t.Fatal("expected an error for the grpc protocol")
+	}
+}
+
+func TestStartServerContinuesTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	carrier := map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx, server := StartServer(context.Background(), propagation.MapCarrier(carrier), "POST /chat")
+	_, child := Start(ctx, "agent.run")
+	End(child, errors.New("planner failed"))
+	End(server, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	run, request := spans[0], spans[1]
+	if request.SpanKind != trace.SpanKindServer || request.Parent.SpanID().String() != "00f067aa0ba902b7" || !request.Parent.IsRemote() {
+		t.Errorf("request span = kind %v, parent %v; want a server span under the remote caller", request.SpanKind, request.Parent.SpanID())
+	}
+	if run.Parent.SpanID() != request.SpanContext.SpanID() || run.SpanContext.TraceID() != request.SpanContext.TraceID() {
+		t.Error("child span is not in the request's trace")
+	}
+	if run.Status.Code != codes.Error || request.Status.Code != codes.Unset {
+		t.Errorf("statuses = %v, %v; want the child failed and the request unset", run.Status.Code, request.Status.Code)
+	}
+}
@@ -0,0 +1,63 @@
+// Package telemetrytest records the spans a test produces, so tests can assert the span
+// hierarchy and attributes without an OTLP collector.
+package telemetrytest
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Record installs a tracer provider that keeps every ended span in memory until the test
+// finishes, then restores the previous provider. Tests that record spans must not run
+// in parallel.
+func Record(t testing.TB) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// Span returns the span named name, failing the test unless exactly one was recorded
+func Span(t testing.TB, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	var found []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("recorded %d spans named %q, want 1; spans: %v", len(found), name, names(spans))
+	}
+	return found[0]
+}
+
+// Attr returns the value of the span's attribute key, and whether the span has it
+func Attr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+// names lists the names of spans, for failure messages
+func names(spans tracetest.SpanStubs) []string {
+	var out []string
+	for _, span := range spans {
+		out = append(out, span.Name)
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+// Package timeouts bounds the stages of a command: connecting to DocumentDB, embedding,
+// searching, chat completions, the stages of an agent run, and the whole run. Each stage
+// gets a context derived from its caller's, so a stuck dependency fails its own stage
+// instead of hanging the command, and a stage that runs out of time fails with an *Error
+// naming the stage and the setting of its budget.
+package timeouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Stages with a budget in Config
+const (
+	StageConnect     = "connect"
+	StageEmbedding   = "embedding"
+	StageSearch      = "search"
+	StageChat        = "chat"
+	StagePlanner     = "planner"
+	StageTool        = "tool"
+	StageSynthesizer = "synthesizer"
+	StageRun         = "run"
+)
+
+// Defaults of the budgets, generous enough that a healthy environment never hits them
+const (
+	DefaultConnect     = 30 * time.Second
+	DefaultEmbedding   = time.Minute
+	DefaultSearch      = time.Minute
+	DefaultChat        = 2 * time.Minute
+	DefaultPlanner     = time.Minute
+	DefaultTool        = time.Minute
+	DefaultSynthesizer = 2 * time.Minute
+	DefaultRun         = time.Hour
+)
+
+// Config holds the budget of each stage; zero leaves a stage bounded only by its caller.
+// The stage tag names the stage of each field and the env tag the environment variable
+// that sets it, which config.Load reads; see config.Variables.
+type Config struct {
+	Connect   time.Duration `stage:"connect" env:"CONNECT_TIMEOUT" for:"documentdb" format:"positive-duration" default:"30s" desc:"Time allowed to connect to DocumentDB and ping it"`
+	Embedding time.Duration `stage:"embedding" env:"EMBEDDING_TIMEOUT" for:"embedding" format:"positive-duration" default:"1m" desc:"Time allowed for each Azure OpenAI embeddings request"`
+	Search    time.Duration `stage:"search" env:"SEARCH_TIMEOUT" for:"documentdb" format:"positive-duration" default:"1m" desc:"Time allowed for each vector search"`
+	Chat      time.Duration `stage:"chat" env:"CHAT_TIMEOUT" for:"chat" format:"positive-duration" default:"2m" desc:"Time allowed for each Azure OpenAI chat completion, including a streamed one"`
+	// Planner, Tool, and Synthesizer bound the stages of an agent run; each holds the
+	// chat completions and searches made within it
+	Planner     time.Duration `stage:"planner" env:"AGENT_PLANNER_TIMEOUT" for:"chat" format:"positive-duration" default:"1m" desc:"Time allowed for the planner stage of an agent run"`
+	Tool        time.Duration `stage:"tool" env:"AGENT_TOOL_TIMEOUT" for:"chat" format:"positive-duration" default:"1m" desc:"Time allowed for each search tool call of the planner: embedding the query and searching"`
+	Synthesizer time.Duration `stage:"synthesizer" env:"AGENT_SYNTH_TIMEOUT" for:"chat" format:"positive-duration" default:"2m" desc:"Time allowed for the synthesizer stage of an agent run"`
+	// Run bounds the one-shot commands; cmd/serve, cmd/chat, and cmd/loadtest run until
+	// stopped and bound each request, turn, or operation instead
+	Run time.Duration `stage:"run" env:"RUN_TIMEOUT" format:"positive-duration" default:"1h" example:"3h" desc:"Time allowed for a whole command run, including an agent run; raise it for long uploads and migrations"`
+}
+
+// Default returns the default budgets
+func Default() Config {
+	return Config{
+		Connect:     DefaultConnect,
+		Embedding:   DefaultEmbedding,
+		Search:      DefaultSearch,
+		Chat:        DefaultChat,
+		Planner:     DefaultPlanner,
+		Tool:        DefaultTool,
+		Synthesizer: DefaultSynthesizer,
+		Run:         DefaultRun,
+	}
+}
+
+// Budget returns the budget of stage, one of the Stage constants: the field of c with
+// that stage tag, and the variable of its env tag
+func (c Config) Budget(stage string) Budget {
+	v := reflect.ValueOf(c)
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Tag.Get("stage") == stage {
+			return Budget{Stage: stage, Timeout: time.Duration(v.Field(i).Int()), Setting: field.Tag.Get("env")}
+		}
+	}
+	return Budget{Stage: stage}
+}
+
+// Budget is the time one stage may take
+type Budget struct {
+	Stage string
+	// Timeout is the time allowed; zero leaves the stage bounded only by its caller
+	Timeout time.Duration
+	// Setting names the variable or flag that sets Timeout
+	Setting string
+}
+
+// Error reports a stage that ran out of its budget. It wraps the error the stage failed
+// with, which wraps context.DeadlineExceeded.
+type Error struct {
+	Budget
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s timed out after %s (%s): %v", e.Stage, e.Timeout, e.Setting, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithBudget returns a copy of parent that expires after b.Timeout, recording b as the
+// cause, so Explain can tell which budget ran out. A zero Timeout only adds a cancel.
+func WithBudget(parent context.Context, b Budget) (context.Context, context.CancelFunc) {
+	if b.Timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, b.Timeout, &Error{Budget: b, Err: context.DeadlineExceeded})
+}
+
+// Run runs fn under budget b. When fn fails because a deadline passed, the error is
+// attributed by Explain to the budget that ran out: b, or one of its callers'.
+func Run(ctx context.Context, b Budget, fn func(ctx context.Context) error) error {
+	stageCtx, cancel := WithBudget(ctx, b)
+	defer cancel()
+	return Explain(stageCtx, fn(stageCtx))
+}
+
+// Explain returns err wrapped in an *Error naming the budget of ctx, or of one of its
+// parents, that ran out. It returns err unchanged when err isn't a deadline error, when
+// it already names its budget, or when the deadline that passed isn't a Budget's.
+func Explain(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var named *Error
+	if errors.As(err, &named) {
+		return err
+	}
+	var cause *Error
+	if !errors.As(context.Cause(ctx), &cause) {
+		return err
+	}
+	return &Error{Budget: cause.Budget, Err: err}
+}
@@ -0,0 +1,127 @@
+package timeouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// slow returns a stage that takes d unless its context ends first
+func slow(d time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("slow stage: %w", ctx.Err())
+		}
+	}
+}
+
+func TestRunEnforcesBudget(t *testing.T) {
+	b := Budget{Stage: StageSearch, Timeout: 20 * time.Millisecond, Setting: "SEARCH_TIMEOUT"}
+
+	start := time.Now()
+	err := Run(context.Background(), b, slow(time.Second))
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("Run took %s; the budget did not cut it short", elapsed)
+	}
+	var timedOut *Error
+	if !errors.As(err, &timedOut) || timedOut.Budget != b {
+		t.Fatalf("err = %v, want an *Error of the search budget", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it to wrap context.DeadlineExceeded", err)
+	}
+	if want := "search timed out after 20ms (SEARCH_TIMEOUT): slow stage: context deadline exceeded"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+
+	if err := Run(context.Background(), b, slow(0)); err != nil {
+		t.Errorf("a stage within its budget failed: %v", err)
+	}
+}
+
+func TestNestedBudgets(t *testing.T) {
+	run := Budget{Stage: StageRun, Timeout: 50 * time.Millisecond, Setting: "RUN_TIMEOUT"}
+	tests := []struct {
+		name  string
+		inner Budget
+		want  string
+	}{
+		{"inner budget runs out first", Budget{Stage: StageChat, Timeout: 10 * time.Millisecond, Setting: "CHAT_TIMEOUT"}, StageChat},
+		{"outer budget runs out first", Budget{Stage: StageChat, Timeout: time.Minute, Setting: "CHAT_TIMEOUT"}, StageRun},
+		{"inner stage unbounded", Budget{Stage: StageChat}, StageRun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Run(context.Background(), run, func(ctx context.Context) error {
+				return Run(ctx, tt.inner, slow(time.Second))
+			})
+			var timedOut *Error
+			if !errors.As(err, &timedOut) || timedOut.Stage != tt.want {
+				t.Fatalf("err = %v, want the %s budget named", err, tt.want)
+			}
+			// Only the budget that ran out is named, once
+			if inner := new(Error); errors.As(timedOut.Err, &inner) {
+				t.Errorf("err = %v names two budgets", err)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	ctx, cancel := WithBudget(context.Background(), Budget{Stage: StageEmbedding, Timeout: time.Millisecond, Setting: "EMBEDDING_TIMEOUT"})
+	defer cancel()
+	<-ctx.Done()
+
+	boom := errors.New("boom")
+	if got := Explain(ctx, boom); got != boom {
+		t.Errorf("Explain(boom) = %v, want it unchanged", got)
+	}
+	if got := Explain(ctx, nil); got != nil {
+		t.Errorf("Explain(nil) = %v", got)
+	}
+	named := &Error{Budget: Budget{Stage: StageChat}, Err: context.DeadlineExceeded}
+	if got := Explain(ctx, named); got != named {
+		t.Errorf("Explain of a named timeout = %v, want it unchanged", got)
+	}
+
+	// A deadline that isn't a budget's is left as it is
+	plain, cancelPlain := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelPlain()
+	<-plain.Done()
+	if got := Explain(plain, plain.Err()); got != context.DeadlineExceeded {
+		t.Errorf("Explain of a plain deadline = %v, want it unchanged", got)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	c := Default()
+	c.Connect = 5 * time.Second
+	tests := []struct {
+		stage   string
+		timeout time.Duration
+		setting string
+	}{
+		{StageConnect, 5 * time.Second, "CONNECT_TIMEOUT"},
+		{StageEmbedding, DefaultEmbedding, "EMBEDDING_TIMEOUT"},
+		{StageSearch, DefaultSearch, "SEARCH_TIMEOUT"},
+		{StageChat, DefaultChat, "CHAT_TIMEOUT"},
+		{StagePlanner, DefaultPlanner, "AGENT_PLANNER_TIMEOUT"},
+		{StageTool, DefaultTool, "AGENT_TOOL_TIMEOUT"},
+		{StageSynthesizer, DefaultSynthesizer, "AGENT_SYNTH_TIMEOUT"},
+		{StageRun, DefaultRun, "RUN_TIMEOUT"},
+	}
+	for _, tt := range tests {
+		if b := c.Budget(tt.stage); b != (Budget{Stage: tt.stage, Timeout: tt.timeout, Setting: tt.setting}) {
+			t.Errorf("Budget(%s) = %+v, want %s from %s", tt.stage, b, tt.timeout, tt.setting)
+		}
+	}
+	if b := c.Budget("unknown"); b != (Budget{Stage: "unknown"}) {
+		t.Errorf("Budget(unknown) = %+v, want an unbounded budget", b)
+	}
+}
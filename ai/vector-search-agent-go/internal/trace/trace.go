@@ -0,0 +1,122 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Names of the events recorded by the clients, vectorstore, and agents layers
+const (
+	EventEmbedding             = "embedding"
+	EventVectorSearch          = "vector_search"
+	EventPlannerCompletion     = "planner_completion"
+	EventSynthesizerCompletion = "synthesizer_completion"
+	// EventToolExecution covers one search tool call, including the embedding and
+	// vector search it makes
+	EventToolExecution = "tool_execution"
+	// EventContextAssembly covers formatting the retrieved hotels for the synthesizer
+	EventContextAssembly = "context_assembly"
+	// EventExactFallback notes a vector search answered by comparing every stored
+	// vector, because the field has no vector index
+	EventExactFallback = "exact_fallback"
+)
+
+// Event records one timed step or annotation in an execution trace
+type Event struct {
+	Name     string        `json:"name"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Err      string        `json:"error,omitempty"`
+	Note     string        `json:"note,omitempty"`
+	// RequestID is the correlation ID of the run the event belongs to
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Trace collects the events of one run. It is safe for concurrent use, and a nil
+// *Trace silently discards events so callers never need to check for one.
+type Trace struct {
+	SessionID string
+	// RequestID, when set, is stamped on every event added without one
+	RequestID string
+
+	now    func() time.Time
+	mu     sync.Mutex
+	events []Event
+}
+
+// New creates an empty trace for the given session
+func New(sessionID string) *Trace {
+	return &Trace{SessionID: sessionID}
+}
+
+// NewWithClock creates an empty trace that times events with now instead of the wall
+// clock, so tests can script how long each step takes
+func NewWithClock(sessionID string, now func() time.Time) *Trace {
+	return &Trace{SessionID: sessionID, now: now}
+}
+
+// Now returns the current time on the trace's clock. A nil trace uses the wall clock.
+func (t *Trace) Now() time.Time {
+	if t == nil || t.now == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+// Add appends an event to the trace
+func (t *Trace) Add(event Event) {
+	if t == nil {
+		return
+	}
+	if event.RequestID == "" {
+		event.RequestID = t.RequestID
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+// Annotate appends an untimed note to the trace
+func (t *Trace) Annotate(name, note string) {
+	t.Add(Event{Name: name, Start: t.Now(), Note: note})
+}
+
+// Events returns a copy of the recorded events in the order they were added
+func (t *Trace) Events() []Event {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := make([]Event, len(t.events))
+	copy(events, t.events)
+	return events
+}
+
+type contextKey struct{}
+
+// WithTrace returns a copy of ctx carrying the trace
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the trace carried by ctx, or nil if there is none
+func FromContext(ctx context.Context) *Trace {
+	t, _ := ctx.Value(contextKey{}).(*Trace)
+	return t
+}
+
+// Start begins a timed event on the trace carried by ctx. Call the returned function
+// with the step's error (or nil) when the step completes.
+func Start(ctx context.Context, name string) func(err error) {
+	t := FromContext(ctx)
+	start := t.Now()
+	return func(err error) {
+		event := Event{Name: name, Start: start, Duration: t.Now().Sub(start)}
+		if err != nil {
+			event.Err = err.Error()
+		}
+		t.Add(event)
+	}
+}
@@ -0,0 +1,69 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNilTraceDiscardsEvents(t *testing.T) {
+	var tr *Trace
+	tr.Add(Event{Name: "planner"})
+	tr.Annotate("planner", "note")
+	Start(context.Background(), "tool")(nil)
+
+	if events := tr.Events(); events != nil {
+		t.Errorf("Events() = %v, want nil", events)
+	}
+}
+
+func TestStartRecordsOnContextTrace(t *testing.T) {
+	tr := New("session-1")
+	ctx := WithTrace(context.Background(), tr)
+
+	Start(ctx, "tool")(errors.New("boom"))
+	tr.Annotate("planner", "planning bypassed")
+
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Name != "tool" || events[0].Err != "boom" {
+		t.Errorf("events[0] = %+v, want the tool step with its error", events[0])
+	}
+	if events[1].Note != "planning bypassed" {
+		t.Errorf("events[1] = %+v, want the annotation", events[1])
+	}
+}
+
+func TestStartTimesEventsOnTraceClock(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tr := NewWithClock("session-1", func() time.Time { return now })
+	ctx := WithTrace(context.Background(), tr)
+
+	done := Start(ctx, EventVectorSearch)
+	now = now.Add(250 * time.Millisecond)
+	done(nil)
+
+	events := tr.Events()
+	if len(events) != 1 || events[0].Duration != 250*time.Millisecond || !events[0].Start.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("events = %+v, want one 250ms search starting at the clock's time", events)
+	}
+}
+
+func TestAddStampsRequestID(t *testing.T) {
+	tr := New("session-1")
+	tr.RequestID = "req-1"
+
+	tr.Annotate("planner", "planning bypassed")
+	tr.Add(Event{Name: "embedding", RequestID: "req-other"})
+
+	events := tr.Events()
+	if events[0].RequestID != "req-1" {
+		t.Errorf("annotation request ID = %q, want the trace's", events[0].RequestID)
+	}
+	if events[1].RequestID != "req-other" {
+		t.Errorf("event request ID = %q, want the one it was added with", events[1].RequestID)
+	}
+}
@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const testEmbeddingModel = "text-embedding-3-small"
+
+// storedHashes returns the content hashes of the test hotels as a previous upload
+// would have stored them
+func storedHashes(t *testing.T) map[string]string {
+	t.Helper()
+	hotels, err := vectorstore.LoadOptions{}.Load(testDataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make(map[string]string, len(hotels))
+	for _, hotel := range hotels {
+		hashes[hotel.HotelID] = hotel.ContentHash(testEmbeddingModel)
+	}
+	return hashes
+}
+
+func upsertedIDs(docs []models.HotelForVectorStore) []string {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.HotelID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestUploadChangedOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		prune       bool
+		wantDeleted []string
+	}{
+		{"keeps removed hotels", false, nil},
+		{"prunes removed hotels", true, []string{"99"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Hotel 1 is unchanged, 10 was modified, 11 is new, and 99 was removed from the file
+			hashes := storedHashes(t)
+			hashes["10"] = "stale"
+			delete(hashes, "11")
+			hashes["99"] = "0123"
+
+			embedder := &fakeEmbedder{}
+			store := &fakeStore{hashes: hashes}
+			var out bytes.Buffer
+			u := &Uploader{Embedder: embedder, Store: store, Out: &out, EmbeddingModel: testEmbeddingModel}
+
+			opts := withSkipIndex(testOptions(t))
+			opts.ChangedOnly = true
+			opts.Prune = tt.prune
+			summary, err := u.Run(context.Background(), &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if summary.New != 1 || summary.Updated != 1 || summary.Unchanged != 1 {
+				t.Errorf("new %d, updated %d, unchanged %d; want 1 each", summary.New, summary.Updated, summary.Unchanged)
+			}
+			if embedder.calls != 2 || len(store.inserted) != 0 {
+				t.Errorf("embedded %d, inserted %d; want 2 embedded and only upserts", embedder.calls, len(store.inserted))
+			}
+			if got := upsertedIDs(store.upserted); !slices.Equal(got, []string{"10", "11"}) {
+				t.Errorf("upserted %v, want 10 and 11", got)
+			}
+			for _, doc := range store.upserted {
+				hotel := models.Hotel{HotelName: doc.HotelName, Description: doc.Description}
+				if doc.ContentHash != hotel.ContentHash(testEmbeddingModel) {
+					t.Errorf("hotel %s stored with hash %q, want the hash of its content", doc.HotelID, doc.ContentHash)
+				}
+			}
+
+			if !slices.Equal(store.deleted, tt.wantDeleted) || summary.Pruned != int64(len(tt.wantDeleted)) {
+				t.Errorf("deleted %v (Pruned %d), want %v", store.deleted, summary.Pruned, tt.wantDeleted)
+			}
+
+			summary.Render(&out)
+			if !strings.Contains(out.String(), "Changes: 1 new, 1 updated, 1 unchanged") {
+				t.Errorf("output does not report the changes:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestUploadChangedOnlyRerunEmbedsNothing(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	store := &fakeStore{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard, EmbeddingModel: testEmbeddingModel}
+
+	for run := range 2 {
+		opts := withSkipIndex(testOptions(t))
+		opts.ChangedOnly = true
+		summary, err := u.Run(context.Background(), &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run == 1 && (summary.Unchanged != 3 || summary.New+summary.Updated != 0) {
+			t.Errorf("second run: new %d, updated %d, unchanged %d; want all unchanged", summary.New, summary.Updated, summary.Unchanged)
+		}
+	}
+	if embedder.calls != 3 {
+		t.Errorf("embedder called %d times over two runs, want 3", embedder.calls)
+	}
+
+	// A different model invalidates every stored vector
+	u.EmbeddingModel = "text-embedding-3-large"
+	opts := withSkipIndex(testOptions(t))
+	opts.ChangedOnly = true
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Updated != 3 {
+		t.Errorf("after a model change, updated %d, want 3", summary.Updated)
+	}
+}
+
+func TestUploadPruneSkippedWhenLoadFails(t *testing.T) {
+	store := &fakeStore{hashes: map[string]string{"99": "0123"}}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard, EmbeddingModel: testEmbeddingModel}
+
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = "testdata/missing.json"
+	opts.ChangedOnly = true
+	opts.Prune = true
+	if _, err := u.Run(context.Background(), &opts); err == nil {
+		t.Fatal("Run succeeded with a missing data file")
+	}
+	if len(store.deleted) != 0 {
+		t.Errorf("deleted %v after the load failed", store.deleted)
+	}
+}
+
+func TestOptionsValidateChangedOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"with index only", Options{ChangedOnly: true, IndexOnly: true}, "--changed-only and --index-only"},
+		{"with skip existing", Options{ChangedOnly: true, SkipExisting: true}, "--changed-only and --skip-existing"},
+		{"prune alone", Options{Prune: true}, "--prune requires --changed-only"},
+		{"prune with only", Options{ChangedOnly: true, Prune: true, Only: []string{"1"}}, "--prune cannot be used"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package upload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// checkpointFile is the on-disk format of a checkpoint
+type checkpointFile struct {
+	HotelIDs  []string  `json:"hotelIds"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// checkpoint records the HotelIds that have been embedded and inserted so an
+// interrupted upload can resume without redoing them. It is safe for concurrent use,
+// since the loader checks it while the batcher adds to it.
+type checkpoint struct {
+	mu        sync.Mutex
+	path      string
+	interval  time.Duration
+	done      map[string]bool
+	dirty     bool
+	lastFlush time.Time
+}
+
+// newCheckpoint creates an empty checkpoint that flushes to path at most once per interval
+func newCheckpoint(path string, interval time.Duration) *checkpoint {
+	return &checkpoint{
+		path:      path,
+		interval:  interval,
+		done:      make(map[string]bool),
+		lastFlush: time.Now(),
+	}
+}
+
+// loadCheckpoint reads the checkpoint at path. A missing file yields an empty checkpoint.
+func loadCheckpoint(path string, interval time.Duration) (*checkpoint, error) {
+	c := newCheckpoint(path, interval)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	for _, id := range file.HotelIDs {
+		c.done[id] = true
+	}
+
+	return c, nil
+}
+
+// Done reports whether the hotel was already uploaded
+func (c *checkpoint) Done(hotelID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[hotelID]
+}
+
+// Len returns the number of uploaded hotels recorded
+func (c *checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.done)
+}
+
+// Add records uploaded hotels and flushes if the interval has elapsed
+func (c *checkpoint) Add(hotelIDs ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range hotelIDs {
+		c.done[id] = true
+	}
+	c.dirty = c.dirty || len(hotelIDs) > 0
+
+	if c.dirty && time.Since(c.lastFlush) >= c.interval {
+		return c.flush()
+	}
+	return nil
+}
+
+// Flush writes the checkpoint atomically: to a temporary file that is synced and
+// then renamed over the previous checkpoint
+func (c *checkpoint) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flush()
+}
+
+// flush writes the checkpoint; the caller holds the lock
+func (c *checkpoint) flush() error {
+	file := checkpointFile{HotelIDs: make([]string, 0, len(c.done)), UpdatedAt: time.Now().UTC()}
+	for id := range c.done {
+		file.HotelIDs = append(file.HotelIDs, id)
+	}
+	sort.Strings(file.HotelIDs)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+
+	c.dirty = false
+	c.lastFlush = time.Now()
+	return nil
+}
+
+// Remove deletes the checkpoint file after a completed run
+func (c *checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
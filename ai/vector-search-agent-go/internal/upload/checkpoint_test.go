@@ -0,0 +1,197 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpointFlushAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp := newCheckpoint(path, time.Hour)
+	if err := cp.Add("2", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadCheckpoint(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 2 || !loaded.Done("1") || !loaded.Done("2") || loaded.Done("3") {
+		t.Errorf("loaded checkpoint has %d hotels, want 1 and 2", loaded.Len())
+	}
+
+	// The temporary file is renamed over the checkpoint, never left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries after flush, want only the checkpoint", len(entries))
+	}
+}
+
+func TestCheckpointAddFlushesOnInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	cp := newCheckpoint(path, time.Hour)
+	if err := cp.Add("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("checkpoint written before the interval elapsed: %v", err)
+	}
+
+	cp.interval = 0
+	if err := cp.Add("2"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadCheckpoint(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 2 {
+		t.Errorf("flushed checkpoint has %d hotels, want 2", loaded.Len())
+	}
+}
+
+func TestLoadCheckpointMissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+
+	cp, err := loadCheckpoint(filepath.Join(dir, "missing.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Len() != 0 {
+		t.Errorf("missing checkpoint has %d hotels, want 0", cp.Len())
+	}
+	if err := cp.Remove(); err != nil {
+		t.Errorf("Remove of a missing checkpoint: %v", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCheckpoint(corrupt, 0); err == nil {
+		t.Error("loadCheckpoint accepted a corrupt file")
+	}
+}
+
+func TestUploadResumesAfterInterruption(t *testing.T) {
+	opts := testOptions(t)
+	opts.SkipIndex = true
+
+	// The first run inserts one batch, then the store fails
+	interrupted := &fakeStore{insertErr: errors.New("connection reset"), failAfter: 1}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: interrupted, Out: io.Discard}
+	if _, err := u.Run(context.Background(), &opts); err == nil {
+		t.Fatal("interrupted run returned no error")
+	}
+	if len(interrupted.inserted) != 1 {
+		t.Fatalf("interrupted run inserted %d hotels, want 1", len(interrupted.inserted))
+	}
+
+	cp, err := loadCheckpoint(opts.Checkpoint, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Len() != 1 || !cp.Done(interrupted.inserted[0].HotelID) {
+		t.Fatalf("checkpoint after interruption has %d hotels, want the inserted one", cp.Len())
+	}
+
+	// The resumed run only embeds and inserts the remaining hotels
+	opts.Resume = true
+	embedder := &fakeEmbedder{}
+	resumed := &fakeStore{}
+	u = &Uploader{Embedder: embedder, Store: resumed, Out: io.Discard}
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Loaded != 3 || summary.Resumed != 1 || summary.Inserted != 2 {
+		t.Errorf("summary = loaded %d, resumed %d, inserted %d, want 3, 1, 2", summary.Loaded, summary.Resumed, summary.Inserted)
+	}
+	if embedder.calls != 2 {
+		t.Errorf("resumed run embedded %d hotels, want 2", embedder.calls)
+	}
+	for _, doc := range resumed.inserted {
+		if doc.HotelID == interrupted.inserted[0].HotelID {
+			t.Errorf("resumed run re-inserted hotel %s", doc.HotelID)
+		}
+	}
+	if _, err := os.Stat(opts.Checkpoint); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint not removed after a completed run: %v", err)
+	}
+}
+
+func TestUploadWithoutResumeIgnoresCheckpoint(t *testing.T) {
+	opts := testOptions(t)
+	opts.SkipIndex = true
+
+	stale := newCheckpoint(opts.Checkpoint, 0)
+	if err := stale.Add("1", "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Resumed != 0 || len(store.inserted) != 3 {
+		t.Errorf("resumed %d and inserted %d hotels, want 0 and 3", summary.Resumed, len(store.inserted))
+	}
+}
+
+func TestUploadKeepsCheckpointAfterEmbeddingFailures(t *testing.T) {
+	opts := testOptions(t)
+	opts.SkipIndex = true
+
+	u := &Uploader{Embedder: &fakeEmbedder{failOn: "Times Square"}, Store: &fakeStore{}, Out: io.Discard}
+	if _, err := u.Run(context.Background(), &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := loadCheckpoint(opts.Checkpoint, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Len() != 2 || cp.Done("1") {
+		t.Errorf("checkpoint has %d hotels, want the 2 that embedded", cp.Len())
+	}
+}
+
+func TestUploadCancelledFlushesCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := testOptions(t)
+	opts.SkipIndex = true
+
+	// Cancel once the first hotel has been inserted
+	store := &fakeStore{onInsert: func(total int) { cancel() }}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+	summary, err := u.Run(ctx, &opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	cp, err := loadCheckpoint(opts.Checkpoint, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Len() == 0 || cp.Len() != len(store.inserted) || cp.Len() != summary.Inserted {
+		t.Errorf("checkpoint has %d hotels, store %d, summary %d, want the same non-zero count", cp.Len(), len(store.inserted), summary.Inserted)
+	}
+}
@@ -0,0 +1,221 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+const testDataFile = "testdata/hotels.json"
+
+// testOptions returns upload options for the test data with one-hotel batches and a
+// checkpoint in a temporary directory that is flushed after every batch
+func testOptions(t *testing.T) Options {
+	return Options{
+		DataFile:    testDataFile,
+		BatchSize:   1,
+		Concurrency: 1,
+		Checkpoint:  filepath.Join(t.TempDir(), "checkpoint.json"),
+	}
+}
+
+// fakeEmbedder embeds every text as a fixed vector, failing texts that contain failOn.
+// With record set, the texts it was asked to embed are kept in texts in order of arrival.
+// When wait is set it is called before each embedding returns, and the peak number of
+// concurrent calls is recorded in maxInFlight.
+type fakeEmbedder struct {
+	mu          sync.Mutex
+	failOn      string
+	calls       int
+	record      bool
+	texts       []string
+	wait        func(ctx context.Context, text string) error
+	inFlight    int
+	maxInFlight int
+}
+
+func (f *fakeEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	f.mu.Lock()
+	f.calls++
+	if f.record {
+		f.texts = append(f.texts, text)
+	}
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.wait != nil {
+		if err := f.wait(ctx, text); err != nil {
+			return nil, err
+		}
+	}
+	if f.failOn != "" && strings.Contains(text, f.failOn) {
+		return nil, errors.New("embedding failed")
+	}
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+// fakeStore records inserted documents and index creation. Like the real store, it
+// rejects inserts made with a cancelled context. After failAfter successful
+// inserts (when set), every insert fails with insertErr. onInsert is called with the
+// running total of inserted documents after each successful insert. existing holds the
+// HotelIds already in the collection before the run, hashes the content hashes of
+// stored hotels by HotelId, and versions the stored hotels counted by schema version.
+// Upserted documents and deleted HotelIds are recorded separately from inserts.
+type fakeStore struct {
+	mu          sync.Mutex
+	existing    []string
+	existingErr error
+	hashes      map[string]string
+	versions    map[int]int64
+	inserted    []models.HotelForVectorStore
+	upserted    []models.HotelForVectorStore
+	deleted     []string
+	inserts     int
+	indexed     int
+	// frenchIndexed counts CreateFrenchVectorIndex calls
+	frenchIndexed int
+	// rooms holds the inserted room documents, deletedRooms the HotelIds whose rooms
+	// were deleted, and roomsIndexed counts CreateRoomsVectorIndex calls
+	rooms        []models.RoomForVectorStore
+	deletedRooms []string
+	roomsIndexed int
+	insertErr    error
+	failAfter    int
+	onInsert     func(total int)
+}
+
+func (f *fakeStore) InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.insertErr != nil && f.inserts >= f.failAfter {
+		return f.insertErr
+	}
+	f.inserts++
+	f.inserted = append(f.inserted, hotels...)
+	if f.onInsert != nil {
+		f.onInsert(len(f.inserted))
+	}
+	return nil
+}
+
+func (f *fakeStore) CreateVectorIndex(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.indexed++
+	return nil
+}
+
+func (f *fakeStore) CreateFrenchVectorIndex(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.frenchIndexed++
+	return nil
+}
+
+func (f *fakeStore) InsertRooms(ctx context.Context, rooms []models.RoomForVectorStore) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.rooms = append(f.rooms, rooms...)
+	return nil
+}
+
+func (f *fakeStore) DeleteRooms(ctx context.Context, hotelIDs []string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deletedRooms = append(f.deletedRooms, hotelIDs...)
+	before := len(f.rooms)
+	f.rooms = slices.DeleteFunc(f.rooms, func(room models.RoomForVectorStore) bool {
+		return slices.Contains(hotelIDs, room.HotelID)
+	})
+	return int64(before - len(f.rooms)), nil
+}
+
+func (f *fakeStore) CreateRoomsVectorIndex(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.roomsIndexed++
+	return nil
+}
+
+func (f *fakeStore) ExistingHotelIDs(ctx context.Context) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.existingErr != nil {
+		return nil, f.existingErr
+	}
+	ids := make(map[string]bool, len(f.existing)+len(f.inserted))
+	for _, id := range f.existing {
+		ids[id] = true
+	}
+	for _, doc := range f.inserted {
+		ids[doc.HotelID] = true
+	}
+	return ids, nil
+}
+
+func (f *fakeStore) ContentHashes(ctx context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hashes := make(map[string]string, len(f.hashes))
+	for id, hash := range f.hashes {
+		hashes[id] = hash
+	}
+	for _, doc := range slices.Concat(f.inserted, f.upserted) {
+		hashes[doc.HotelID] = doc.ContentHash
+	}
+	return hashes, nil
+}
+
+func (f *fakeStore) SchemaVersions(ctx context.Context) (map[int]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return maps.Clone(f.versions), nil
+}
+
+func (f *fakeStore) UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if f.insertErr != nil && f.inserts >= f.failAfter {
+		return f.insertErr
+	}
+	f.inserts++
+	f.upserted = append(f.upserted, hotels...)
+	return nil
+}
+
+func (f *fakeStore) DeleteHotels(ctx context.Context, hotelIDs []string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deleted int64
+	for _, id := range hotelIDs {
+		if _, ok := f.hashes[id]; ok {
+			delete(f.hashes, id)
+			f.deleted = append(f.deleted, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,89 @@
+package upload
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+const frenchDataFile = "testdata/french_hotels.json"
+
+func TestUploadEmbedsFrenchDescriptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		embedFrench  bool
+		wantCalls    int
+		wantFrench   []string
+		wantEmbedded int
+		wantSkipped  int
+		wantIndexed  int
+	}{
+		{"disabled", false, 4, nil, 0, 0, 0},
+		// Hotels 2 and 3 have no French text and keep only their English vector
+		{"enabled", true, 6, []string{"1", "4"}, 2, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			embedder := &fakeEmbedder{}
+			u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+			opts := testOptions(t)
+			opts.DataFile = frenchDataFile
+			opts.EmbedFrench = tt.embedFrench
+
+			summary, err := u.Run(context.Background(), &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(store.inserted) != 4 || embedder.calls != tt.wantCalls {
+				t.Fatalf("inserted %d with %d embeddings, want 4 with %d", len(store.inserted), embedder.calls, tt.wantCalls)
+			}
+
+			var french []string
+			for _, doc := range store.inserted {
+				if doc.DescriptionVector == nil {
+					t.Errorf("hotel %s has no English vector", doc.HotelID)
+				}
+				if doc.DescriptionFrVector != nil {
+					french = append(french, doc.HotelID)
+				}
+			}
+			if strings.Join(french, ",") != strings.Join(tt.wantFrench, ",") {
+				t.Errorf("French vectors on %v, want %v", french, tt.wantFrench)
+			}
+			if summary.FrenchEmbedded != tt.wantEmbedded || summary.FrenchSkipped != tt.wantSkipped {
+				t.Errorf("French embedded %d, skipped %d, want %d and %d", summary.FrenchEmbedded, summary.FrenchSkipped, tt.wantEmbedded, tt.wantSkipped)
+			}
+			if store.indexed != 1 || store.frenchIndexed != tt.wantIndexed {
+				t.Errorf("indexes created = %d, French = %d, want 1 and %d", store.indexed, store.frenchIndexed, tt.wantIndexed)
+			}
+		})
+	}
+}
+
+func TestUploadFrenchEmbeddingFailure(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{failOn: "Bienvenue"}, Store: store, Out: io.Discard}
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = frenchDataFile
+	opts.EmbedFrench = true
+
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Failed != 1 || summary.FailedHotels[0].HotelID != "4" || !strings.Contains(summary.FailedHotels[0].Err, "Description_fr") {
+		t.Errorf("summary = %+v, want hotel 4 failed on its French description", summary)
+	}
+}
+
+func TestOptionsValidateEmbedFrench(t *testing.T) {
+	opts := testOptions(t)
+	opts.VectorsFile = testVectorsFile
+	opts.EmbedFrench = true
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "pre-vectorized") {
+		t.Errorf("Validate() = %v, want EMBED_FRENCH rejected with a pre-vectorized file", err)
+	}
+}
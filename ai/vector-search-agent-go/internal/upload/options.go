@@ -0,0 +1,104 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// Defaults for the batching and checkpoint settings
+const (
+	DefaultBatchSize          = 10
+	DefaultConcurrency        = 4
+	DefaultCheckpointInterval = 5 * time.Second
+)
+
+// Options selects what an upload does
+type Options struct {
+	DataFile string
+	// VectorsFile is a pre-vectorized data file; when set, embedding generation is skipped
+	VectorsFile string
+	// Hotels, when set, are uploaded instead of reading a data file
+	Hotels []models.Hotel
+	// Only, when set, restricts the upload to these HotelIds, as when retrying the
+	// documents listed in a failure report
+	Only         []string
+	SkipIndex    bool
+	IndexOnly    bool
+	SkipExisting bool
+	// ChangedOnly embeds and upserts only the hotels whose content hash differs from the
+	// stored one, or that are not stored yet
+	ChangedOnly bool
+	// Prune deletes stored hotels that are not in the data file; it needs ChangedOnly
+	Prune bool
+	// Strict stops the upload at the first invalid hotel instead of skipping it
+	Strict bool
+	// FieldWeights composes the embedded text from weighted hotel fields; nil embeds
+	// the Description alone
+	FieldWeights models.FieldWeights
+	// EmbedFrench also embeds Description_fr, for hotels that have it, and creates the
+	// French vector index
+	EmbedFrench bool
+	// EmbedRooms also stores each hotel room as its own embedded document in the rooms
+	// collection and creates that collection's vector index
+	EmbedRooms bool
+
+	BatchSize          int
+	Concurrency        int
+	Checkpoint         string
+	CheckpointInterval time.Duration
+	Resume             bool
+}
+
+// Validate checks that the options are consistent
+func (o *Options) Validate() error {
+	if o.SkipIndex && o.IndexOnly {
+		return errors.New("--skip-index and --index-only cannot be used together")
+	}
+	if o.ChangedOnly && o.IndexOnly {
+		return errors.New("--changed-only and --index-only cannot be used together")
+	}
+	if o.ChangedOnly && o.SkipExisting {
+		return errors.New("--changed-only and --skip-existing cannot be used together; --changed-only already skips unchanged hotels")
+	}
+	if o.Prune && !o.ChangedOnly {
+		return errors.New("--prune requires --changed-only")
+	}
+	if o.Prune && o.Only != nil {
+		return errors.New("--prune cannot be used when uploading only some HotelIds")
+	}
+	if o.EmbedFrench && o.Precomputed() {
+		return errors.New("--embed-french cannot be used with --data-with-vectors; pre-vectorized files have no French vectors")
+	}
+	if o.EmbedRooms && o.Precomputed() {
+		return errors.New("--embed-rooms cannot be used with --data-with-vectors; pre-vectorized files have no room vectors")
+	}
+	if o.FieldWeights != nil && o.Precomputed() {
+		return errors.New("--field-weights cannot be used with --data-with-vectors; pre-vectorized files were embedded from the Description")
+	}
+	if o.BatchSize < 1 {
+		return fmt.Errorf("invalid batch size %d: must be at least 1", o.BatchSize)
+	}
+	if o.Concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d: must be at least 1", o.Concurrency)
+	}
+	if o.CheckpointInterval < 0 {
+		return errors.New("invalid checkpoint interval: must not be negative")
+	}
+	return nil
+}
+
+// Precomputed reports whether the upload uses a pre-vectorized data file
+func (o *Options) Precomputed() bool {
+	return o.VectorsFile != ""
+}
+
+// dataFile returns the file the upload loads; the pre-vectorized file wins when both are set
+func (o *Options) dataFile() string {
+	if o.Precomputed() {
+		return o.VectorsFile
+	}
+	return o.DataFile
+}
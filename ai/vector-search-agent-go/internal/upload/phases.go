@@ -0,0 +1,665 @@
+// Package upload loads hotel data, embeds the descriptions, inserts the documents in
+// batches, and creates the vector index, checkpointing progress so an interrupted
+// upload can resume.
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/logging"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Upload phase names, in the order they run. Hotels are validated as they load; the
+// validate phase only appears in failures.
+const (
+	phaseLoad     = "load"
+	phaseValidate = "validate"
+	phaseEmbed    = "embed"
+	phaseInsert   = "insert"
+	phaseIndex    = "index"
+)
+
+// retryPasses is the number of extra passes over hotels whose embedding failed
+const retryPasses = 2
+
+// DefaultRetryBackoff is the wait before the first retry pass
+const DefaultRetryBackoff = 2 * time.Second
+
+// Embedder generates embeddings for document text
+type Embedder interface {
+	GenerateEmbedding(ctx context.Context, text string) ([]float32, error)
+}
+
+// Store is the part of the vector store the upload writes to
+type Store interface {
+	InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error
+	CreateVectorIndex(ctx context.Context) error
+	CreateFrenchVectorIndex(ctx context.Context) error
+	ExistingHotelIDs(ctx context.Context) (map[string]bool, error)
+	ContentHashes(ctx context.Context) (map[string]string, error)
+	SchemaVersions(ctx context.Context) (map[int]int64, error)
+	UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) error
+	DeleteHotels(ctx context.Context, hotelIDs []string) (int64, error)
+	InsertRooms(ctx context.Context, rooms []models.RoomForVectorStore) error
+	DeleteRooms(ctx context.Context, hotelIDs []string) (int64, error)
+	CreateRoomsVectorIndex(ctx context.Context) error
+}
+
+// phaseResult records whether a phase ran and how long it took
+type phaseResult struct {
+	Name     string
+	Ran      bool
+	Duration time.Duration
+}
+
+// Summary describes what an upload run did
+type Summary struct {
+	Total    time.Duration
+	Tokens   int64
+	Phases   []phaseResult
+	Loaded   int
+	Resumed  int
+	Existing int
+	Embedded int
+	Inserted int
+	Failed   int
+	// ChangedOnly is set when only changed hotels were uploaded; New, Updated, and
+	// Unchanged count the loaded hotels by how they compare with the stored ones
+	ChangedOnly bool
+	New         int
+	Updated     int
+	Unchanged   int
+	// FrenchEmbedded and FrenchSkipped count, with EmbedFrench, the embedded hotels
+	// with and without a French description
+	FrenchEmbedded int
+	FrenchSkipped  int
+	// Rooms is the number of room documents inserted with EmbedRooms
+	Rooms int
+	// Invalid is the number of hotels skipped because they failed Hotel.Validate
+	Invalid int
+	// Pruned is the number of stored hotels deleted because the data file lacks them
+	Pruned int64
+	// FailedHotels lists the invalid hotels, those whose insert failed, and those still
+	// failing to embed after the retry passes
+	FailedHotels []FailedHotel
+}
+
+// FailedHotel identifies a hotel that could not be stored, and why
+type FailedHotel struct {
+	HotelID   string `json:"hotelId"`
+	HotelName string `json:"hotelName"`
+	// Phase is validate, embed, or insert
+	Phase string `json:"phase"`
+	// Class is one of the Class constants
+	Class string `json:"errorClass"`
+	Err   string `json:"error"`
+}
+
+// Uploader runs the upload phases against an embedder and a store
+type Uploader struct {
+	// Embedder is only needed when embeddings are generated
+	Embedder Embedder
+	Store    Store
+	// Usage, when set, reports the tokens spent on embeddings
+	Usage *clients.UsageTracker
+	Out   io.Writer
+	// Dimensions is the expected length of pre-computed vectors
+	Dimensions int
+	// EmbeddingModel is hashed with each document's content, so changing the model
+	// makes --changed-only re-embed every hotel
+	EmbeddingModel string
+	// TTY renders progress as a single updating line
+	TTY bool
+	// RetryBackoff is the wait before the first retry pass; it doubles for each later pass
+	RetryBackoff time.Duration
+}
+
+// Run executes the phases selected by opts and returns a summary of what ran
+func (u *Uploader) Run(ctx context.Context, opts *Options) (*Summary, error) {
+	summary := &Summary{}
+	start := time.Now()
+	defer func() {
+		summary.Total = time.Since(start)
+		if u.Usage != nil {
+			for _, usage := range u.Usage.Snapshot() {
+				summary.Tokens += usage.TotalTokens()
+			}
+		}
+	}()
+
+	if opts.IndexOnly {
+		summary.skip(phaseLoad, phaseEmbed, phaseInsert)
+	} else if err := u.upload(ctx, opts, summary); err != nil {
+		return summary, err
+	}
+
+	if opts.SkipIndex {
+		summary.skip(phaseIndex)
+		return summary, nil
+	}
+
+	indexStart := time.Now()
+	err := u.ensureIndex(ctx, opts)
+	summary.record(phaseIndex, time.Since(indexStart))
+	return summary, err
+}
+
+// upload streams the hotels from the data file through the embed-and-insert
+// pipeline, recording each inserted batch in the checkpoint. Only the hotels in
+// flight, and those whose embedding failed, are held in memory.
+func (u *Uploader) upload(ctx context.Context, opts *Options, summary *Summary) (err error) {
+	cp, err := u.openCheckpoint(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := u.warnOutdatedSchema(ctx); err != nil {
+		return err
+	}
+
+	var existing map[string]bool
+	if opts.SkipExisting {
+		existing, err = u.Store.ExistingHotelIDs(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// With ChangedOnly, seen collects the HotelIds in the file, for Prune
+	var hashes map[string]string
+	var seen map[string]bool
+	if opts.ChangedOnly {
+		summary.ChangedOnly = true
+		hashes, err = u.Store.ContentHashes(ctx)
+		if err != nil {
+			return err
+		}
+		seen = make(map[string]bool, len(hashes))
+	}
+
+	var only map[string]bool
+	if opts.Only != nil {
+		only = make(map[string]bool, len(opts.Only))
+		for _, id := range opts.Only {
+			only[id] = true
+		}
+	}
+
+	// The load runs inside the first pass, so its time is what the feed spends
+	// reading and decoding, without the time it waits for a free worker
+	source := u.hotelSource(opts)
+	var loadTime time.Duration
+	selected := 0
+	var invalid []FailedHotel
+	feed := func(emit func(models.Hotel) error) error {
+		start := time.Now()
+		var waiting time.Duration
+		defer func() { loadTime = time.Since(start) - waiting }()
+
+		err := source(func(hotel models.Hotel) error {
+			summary.Loaded++
+			if seen != nil {
+				seen[hotel.HotelID] = true
+			}
+			if opts.Precomputed() {
+				if err := vectorstore.ValidateVectorDimensions(hotel, u.Dimensions); err != nil {
+					return err
+				}
+			}
+			if only != nil {
+				if !only[hotel.HotelID] {
+					return nil
+				}
+				delete(only, hotel.HotelID)
+				selected++
+			}
+			if err := hotel.Validate(); err != nil {
+				err = fmt.Errorf("%w: %w", vectorstore.ErrInvalidData, err)
+				if opts.Strict {
+					return err
+				}
+				invalid = append(invalid, newFailedHotel(hotel.HotelID, hotel.HotelName, phaseValidate, err))
+				return nil
+			}
+			if cp.Done(hotel.HotelID) {
+				summary.Resumed++
+				return nil
+			}
+			if existing[hotel.HotelID] {
+				summary.Existing++
+				return nil
+			}
+			if hashes != nil {
+				stored, ok := hashes[hotel.HotelID]
+				switch {
+				case !ok:
+					summary.New++
+				case stored == u.contentHash(hotel, opts.FieldWeights):
+					summary.Unchanged++
+					return nil
+				default:
+					summary.Updated++
+				}
+			}
+
+			sent := time.Now()
+			err := emit(hotel)
+			waiting += time.Since(sent)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load hotels: %w", err)
+		}
+		return nil
+	}
+
+	var stats pipelineStats
+	defer func() {
+		summary.record(phaseLoad, loadTime)
+		if opts.Precomputed() {
+			summary.skip(phaseEmbed)
+		} else {
+			summary.record(phaseEmbed, stats.embedTime)
+		}
+		summary.record(phaseInsert, stats.insertTime)
+
+		// Keep the checkpoint after failures so --resume only redoes what is missing
+		if err != nil || summary.Failed > 0 {
+			if flushErr := cp.Flush(); flushErr != nil {
+				log.Printf("Warning: %v", flushErr)
+			}
+			return
+		}
+		if removeErr := cp.Remove(); removeErr != nil {
+			log.Printf("Warning: %v", removeErr)
+		}
+	}()
+
+	if opts.Precomputed() {
+		u.printf("\nUsing pre-computed vectors; inserting documents in batches of %d...\n", opts.BatchSize)
+	} else {
+		u.printf("\nGenerating embeddings with %d workers and inserting documents in batches of %d...\n", opts.Concurrency, opts.BatchSize)
+		if opts.FieldWeights != nil {
+			u.printf("Embedding weighted fields: %s\n", opts.FieldWeights)
+		}
+	}
+
+	var failures []embedFailure
+	for pass := 0; pass <= retryPasses; pass++ {
+		total := progress.UnknownTotal
+		if pass > 0 {
+			if len(failures) == 0 {
+				break
+			}
+			backoff := u.RetryBackoff << (pass - 1)
+			u.printf("\nRetry pass %d/%d: %d hotels after %s\n", pass, retryPasses, len(failures), backoff)
+			if err := sleep(ctx, backoff); err != nil {
+				summary.recordFailures(failures)
+				return err
+			}
+			pending := make([]models.Hotel, 0, len(failures))
+			for _, failure := range failures {
+				pending = append(pending, failure.hotel)
+			}
+			feed = feedSlice(pending)
+			total = len(pending)
+		}
+
+		reporter := progress.New(u.Out, total, u.TTY)
+		var passStats pipelineStats
+		passStats, failures, err = u.embedAndInsert(ctx, feed, opts, cp, summary, reporter)
+		reporter.Finish()
+		if pass == 0 {
+			// The feed has finished, so invalid is complete
+			summary.Invalid = len(invalid)
+			summary.FailedHotels = append(summary.FailedHotels, invalid...)
+		}
+		stats.embedTime += passStats.embedTime
+		stats.insertTime += passStats.insertTime
+		if err != nil {
+			summary.recordFailures(failures)
+			return err
+		}
+		if pass == 0 {
+			u.printLoaded(opts, summary, selected, len(only))
+		}
+	}
+	summary.recordFailures(failures)
+
+	if opts.Prune {
+		if err := u.prune(ctx, hashes, seen, opts.EmbedRooms, summary); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Precomputed() {
+		u.printf("Generated embeddings for %d hotels\n", summary.Embedded)
+	}
+	u.printf("Successfully inserted %d documents\n", summary.Inserted)
+	if opts.EmbedRooms {
+		u.printf("Successfully inserted %d room documents\n", summary.Rooms)
+	}
+	return nil
+}
+
+// prune deletes the stored hotels whose HotelIds the data file lacks, and with rooms
+// their rooms. It only runs after the whole file was read, so a failed load never
+// deletes anything.
+func (u *Uploader) prune(ctx context.Context, stored map[string]string, seen map[string]bool, rooms bool, summary *Summary) error {
+	var removed []string
+	for id := range stored {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	deleted, err := u.Store.DeleteHotels(ctx, removed)
+	summary.Pruned = deleted
+	if err != nil {
+		return fmt.Errorf("failed to prune hotels: %w", err)
+	}
+	if rooms {
+		if _, err := u.Store.DeleteRooms(ctx, removed); err != nil {
+			return fmt.Errorf("failed to prune rooms: %w", err)
+		}
+	}
+	u.printf("Pruned %d hotels that are no longer in the data file\n", deleted)
+	return nil
+}
+
+// hotelSource returns the hotels given in opts, or a stream over the data file
+func (u *Uploader) hotelSource(opts *Options) hotelFeed {
+	if opts.Hotels != nil {
+		u.printf("Uploading %d hotels\n", len(opts.Hotels))
+		return feedSlice(opts.Hotels)
+	}
+
+	dataFile := opts.dataFile()
+	u.printf("Loading hotels from: %s\n", dataFile)
+	return func(emit func(models.Hotel) error) error {
+		return vectorstore.LoadHotelsStream(dataFile, emit)
+	}
+}
+
+// printLoaded reports what the first pass found in the data, once it has all been read.
+// missing is the number of requested HotelIds the data lacks.
+func (u *Uploader) printLoaded(opts *Options, summary *Summary, selected, missing int) {
+	if opts.Hotels == nil {
+		u.printf("Loaded %d hotels\n", summary.Loaded)
+	}
+	if opts.Only != nil {
+		if missing > 0 {
+			log.Printf("Warning: %d of the requested HotelIds are not in the data file", missing)
+		}
+		u.printf("Selected %d of %d hotels\n", selected, summary.Loaded)
+	}
+	if summary.Resumed > 0 {
+		u.printf("Resuming: skipped %d hotels already uploaded\n", summary.Resumed)
+	}
+	if opts.SkipExisting {
+		u.printf("Skipped %d hotels already in the collection\n", summary.Existing)
+	}
+	if opts.ChangedOnly {
+		u.printf("Changes: %d new, %d updated, %d unchanged\n", summary.New, summary.Updated, summary.Unchanged)
+	}
+	if summary.Invalid > 0 {
+		log.Printf("Warning: skipped %d invalid hotels; they are listed with the failed documents", summary.Invalid)
+	}
+}
+
+// openCheckpoint loads the existing checkpoint when resuming, or starts a new one
+func (u *Uploader) openCheckpoint(opts *Options) (*checkpoint, error) {
+	if !opts.Resume {
+		return newCheckpoint(opts.Checkpoint, opts.CheckpointInterval), nil
+	}
+
+	cp, err := loadCheckpoint(opts.Checkpoint, opts.CheckpointInterval)
+	if err != nil {
+		return nil, err
+	}
+	u.printf("Loaded checkpoint %s with %d uploaded hotels\n", opts.Checkpoint, cp.Len())
+	return cp, nil
+}
+
+// printf writes progress output
+func (u *Uploader) printf(format string, args ...any) {
+	fmt.Fprintf(u.Out, format, args...)
+}
+
+// recordFailures adds the hotels that are still failing to embed and sets the final
+// failure count
+func (s *Summary) recordFailures(failures []embedFailure) {
+	for _, failure := range failures {
+		s.addFailure(failure.hotel.HotelID, failure.hotel.HotelName, phaseEmbed, failure.err)
+	}
+	s.Failed = len(s.FailedHotels)
+}
+
+// addFailure records one hotel that could not be stored
+func (s *Summary) addFailure(id, name, phase string, err error) {
+	s.FailedHotels = append(s.FailedHotels, newFailedHotel(id, name, phase, err))
+}
+
+// newFailedHotel describes one hotel that could not be stored
+func newFailedHotel(id, name, phase string, err error) FailedHotel {
+	return FailedHotel{HotelID: id, HotelName: name, Phase: phase, Class: classifyError(err), Err: err.Error()}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record marks a phase as run with the given duration
+func (s *Summary) record(name string, duration time.Duration) {
+	s.Phases = append(s.Phases, phaseResult{Name: name, Ran: true, Duration: duration})
+}
+
+// skip marks phases as skipped
+func (s *Summary) skip(names ...string) {
+	for _, name := range names {
+		s.Phases = append(s.Phases, phaseResult{Name: name})
+	}
+}
+
+// warnOutdatedSchema warns when the collection holds documents below the current
+// schema version, since this upload writes current ones next to them
+func (u *Uploader) warnOutdatedSchema(ctx context.Context) error {
+	versions, err := u.Store.SchemaVersions(ctx)
+	if err != nil {
+		return err
+	}
+	var outdated int64
+	for version, count := range versions {
+		if version != models.CurrentSchemaVersion {
+			outdated += count
+		}
+	}
+	if outdated > 0 {
+		log.Printf("Warning: the collection mixes schema versions (%s); run `go run ./cmd/stats --migrate` to upgrade %d older documents to version %d",
+			vectorstore.FormatSchemaVersions(versions), outdated, models.CurrentSchemaVersion)
+	}
+	return nil
+}
+
+// contentHash returns the ContentHash of hotel embedded with weights
+func (u *Uploader) contentHash(hotel models.Hotel, weights models.FieldWeights) string {
+	doc := hotel.ToVectorStore()
+	return weights.ContentHash(u.EmbeddingModel, &doc)
+}
+
+// embedHotel converts a hotel to a vector store document with an embedding of its description,
+// or of the fields in opts.FieldWeights. Pre-computed vectors are used as is. With
+// opts.EmbedFrench, Description_fr is embedded too; hotels without it get no French vector.
+func (u *Uploader) embedHotel(ctx context.Context, hotel models.Hotel, opts *Options) (models.HotelForVectorStore, error) {
+	// Convert to vector store format
+	hotelVS := hotel.ToVectorStore()
+	hotelVS.ContentHash = opts.FieldWeights.ContentHash(u.EmbeddingModel, &hotelVS)
+	if opts.Precomputed() {
+		return hotelVS, nil
+	}
+
+	// Generate embedding from the weighted fields, by default the Description alone
+	text := opts.FieldWeights.EmbeddingText(&hotelVS)
+	logging.Trace(ctx, "embedding content", "hotel", hotel.HotelID, "composition", opts.FieldWeights.Composition(&hotelVS), "chars", len(text))
+	embedding, err := u.Embedder.GenerateEmbedding(ctx, text)
+	if err != nil {
+		return hotelVS, err
+	}
+	hotelVS.DescriptionVector = embedding
+
+	if opts.EmbedFrench && strings.TrimSpace(hotel.DescriptionFr) != "" {
+		embedding, err := u.Embedder.GenerateEmbedding(ctx, hotel.DescriptionFr)
+		if err != nil {
+			return hotelVS, fmt.Errorf("failed to embed Description_fr: %w", err)
+		}
+		hotelVS.DescriptionFrVector = embedding
+	}
+	return hotelVS, nil
+}
+
+// embedRooms returns the hotel's room documents, each with an embedding of its text
+func (u *Uploader) embedRooms(ctx context.Context, hotel models.Hotel) ([]models.RoomForVectorStore, error) {
+	rooms := hotel.RoomDocuments()
+	for i := range rooms {
+		embedding, err := u.Embedder.GenerateEmbedding(ctx, rooms[i].EmbeddingText())
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed room %s: %w", rooms[i].RoomID, err)
+		}
+		rooms[i].DescriptionVector = embedding
+	}
+	return rooms, nil
+}
+
+// insertRooms writes the embedded room documents of the hotels with hotelIDs. replace
+// first deletes the rooms stored for those hotels, as an upsert of the hotels would.
+func (u *Uploader) insertRooms(ctx context.Context, hotelIDs []string, rooms []models.RoomForVectorStore, replace bool) error {
+	if replace {
+		if _, err := u.Store.DeleteRooms(ctx, hotelIDs); err != nil {
+			return fmt.Errorf("failed to replace rooms: %w", err)
+		}
+	}
+	if len(rooms) == 0 {
+		return nil
+	}
+	if err := u.Store.InsertRooms(ctx, rooms); err != nil {
+		return fmt.Errorf("failed to insert rooms: %w", err)
+	}
+	return nil
+}
+
+// insertHotels writes the embedded documents to the store. upsert replaces stored
+// hotels with the same HotelIds instead of adding duplicates.
+func (u *Uploader) insertHotels(ctx context.Context, docs []models.HotelForVectorStore, upsert bool) error {
+	if upsert {
+		if err := u.Store.UpsertHotels(ctx, docs); err != nil {
+			return fmt.Errorf("failed to upsert hotels: %w", err)
+		}
+		return nil
+	}
+	if err := u.Store.InsertHotelsWithEmbeddings(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert hotels: %w", err)
+	}
+	return nil
+}
+
+// ensureIndex creates the vector index, and the French and rooms indexes when opts
+// embeds those; an identical existing index is left as is
+func (u *Uploader) ensureIndex(ctx context.Context, opts *Options) error {
+	fmt.Fprintln(u.Out, "\nCreating vector index...")
+	if err := u.Store.CreateVectorIndex(ctx); err != nil {
+		return fmt.Errorf("failed to create vector index: %w", err)
+	}
+	fmt.Fprintln(u.Out, "Vector index created successfully")
+
+	if opts.EmbedFrench {
+		if err := u.Store.CreateFrenchVectorIndex(ctx); err != nil {
+			return fmt.Errorf("failed to create French vector index: %w", err)
+		}
+		fmt.Fprintln(u.Out, "French vector index created successfully")
+	}
+	if opts.EmbedRooms {
+		if err := u.Store.CreateRoomsVectorIndex(ctx); err != nil {
+			return fmt.Errorf("failed to create rooms vector index: %w", err)
+		}
+		fmt.Fprintln(u.Out, "Rooms vector index created successfully")
+	}
+	return nil
+}
+
+// Render prints which phases ran and the document counts
+func (s *Summary) Render(w io.Writer) {
+	fmt.Fprintln(w, "\n--- UPLOAD SUMMARY ---")
+	fmt.Fprintf(w, "Total time: %s\n", s.Total.Round(time.Millisecond))
+	for _, phase := range s.Phases {
+		if phase.Ran {
+			fmt.Fprintf(w, "%-7s ran     (%s)\n", phase.Name, phase.Duration.Round(time.Millisecond))
+		} else {
+			fmt.Fprintf(w, "%-7s skipped\n", phase.Name)
+		}
+	}
+	if s.ran(phaseEmbed) {
+		fmt.Fprintf(w, "Documents: %d loaded, %d embedded, %d inserted, %d failed\n", s.Loaded, s.Embedded, s.Inserted, s.Failed)
+		fmt.Fprintf(w, "Embedding tokens: %d\n", s.Tokens)
+	} else if s.ran(phaseLoad) {
+		fmt.Fprintf(w, "Documents: %d loaded with vectors, %d inserted\n", s.Loaded, s.Inserted)
+	}
+	if s.ran(phaseLoad) {
+		if s.Resumed > 0 {
+			fmt.Fprintf(w, "Resumed: %d hotels skipped from the checkpoint\n", s.Resumed)
+		}
+		if s.Existing > 0 {
+			fmt.Fprintf(w, "Existing: %d hotels skipped because they are already in the collection\n", s.Existing)
+		}
+		if s.ChangedOnly {
+			fmt.Fprintf(w, "Changes: %d new, %d updated, %d unchanged\n", s.New, s.Updated, s.Unchanged)
+		}
+		if s.FrenchEmbedded > 0 || s.FrenchSkipped > 0 {
+			fmt.Fprintf(w, "French: %d embedded, %d skipped without Description_fr\n", s.FrenchEmbedded, s.FrenchSkipped)
+		}
+		if s.Rooms > 0 {
+			fmt.Fprintf(w, "Rooms: %d room documents inserted\n", s.Rooms)
+		}
+		if s.Invalid > 0 {
+			fmt.Fprintf(w, "Invalid: %d hotels skipped because they failed validation\n", s.Invalid)
+		}
+		if s.Pruned > 0 {
+			fmt.Fprintf(w, "Pruned: %d hotels deleted because they are no longer in the data file\n", s.Pruned)
+		}
+		if len(s.FailedHotels) > 0 {
+			fmt.Fprintf(w, "Failed documents (embedding is retried %d times):\n", retryPasses)
+			for _, hotel := range s.FailedHotels {
+				fmt.Fprintf(w, "  %s (%s) %s/%s: %s\n", hotel.HotelID, hotel.HotelName, hotel.Phase, hotel.Class, hotel.Err)
+			}
+		}
+	}
+}
+
+// ran reports whether the named phase ran
+func (s *Summary) ran(name string) bool {
+	for _, phase := range s.Phases {
+		if phase.Name == name {
+			return phase.Ran
+		}
+	}
+	return false
+}
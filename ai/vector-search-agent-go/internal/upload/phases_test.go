@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadPhaseCombinations(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		wantRan   []string
+		inserted  int
+		indexed   int
+		embedded  int
+		summaryOK []string
+	}{
+		{
+			name:      "all phases",
+			opts:      testOptions(t),
+			wantRan:   []string{phaseLoad, phaseEmbed, phaseInsert, phaseIndex},
+			inserted:  3,
+			indexed:   1,
+			embedded:  3,
+			summaryOK: []string{"load    ran", "index   ran", "Documents: 3 loaded, 3 embedded, 3 inserted, 0 failed"},
+		},
+		{
+			name:      "skip index",
+			opts:      withSkipIndex(testOptions(t)),
+			wantRan:   []string{phaseLoad, phaseEmbed, phaseInsert},
+			inserted:  3,
+			embedded:  3,
+			summaryOK: []string{"insert  ran", "index   skipped"},
+		},
+		{
+			name:      "index only",
+			opts:      withIndexOnly(testOptions(t), "does-not-exist.json"),
+			wantRan:   []string{phaseIndex},
+			indexed:   1,
+			summaryOK: []string{"load    skipped", "embed   skipped", "insert  skipped", "index   ran"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := &fakeEmbedder{}
+			store := &fakeStore{}
+			u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+
+			summary, err := u.Run(context.Background(), &tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ran []string
+			for _, phase := range summary.Phases {
+				if phase.Ran {
+					ran = append(ran, phase.Name)
+				}
+			}
+			if strings.Join(ran, ",") != strings.Join(tt.wantRan, ",") {
+				t.Errorf("ran %v, want %v", ran, tt.wantRan)
+			}
+			if len(summary.Phases) != 4 {
+				t.Errorf("summary lists %d phases, want all 4", len(summary.Phases))
+			}
+			if len(store.inserted) != tt.inserted || store.indexed != tt.indexed || embedder.calls != tt.embedded {
+				t.Errorf("inserted %d, indexed %d, embedded %d; want %d, %d, %d", len(store.inserted), store.indexed, embedder.calls, tt.inserted, tt.indexed, tt.embedded)
+			}
+
+			var out bytes.Buffer
+			summary.Render(&out)
+			for _, want := range tt.summaryOK {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("summary is missing %q:\n%s", want, out.String())
+				}
+			}
+			if tt.opts.IndexOnly && strings.Contains(out.String(), "Documents:") {
+				t.Errorf("index-only summary reports document counts:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestUploadEmbeddingFailuresAreCounted(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{failOn: "Times Square"}, Store: store, Out: io.Discard}
+
+	opts := testOptions(t)
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Loaded != 3 || summary.Embedded != 2 || summary.Inserted != 2 || summary.Failed != 1 {
+		t.Errorf("summary = %+v, want 3 loaded, 2 embedded and inserted, 1 failed", summary)
+	}
+}
+
+func TestUploadStopsAtFailedPhase(t *testing.T) {
+	errInsert := errors.New("write failed")
+	store := &fakeStore{insertErr: errInsert}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+
+	opts := testOptions(t)
+	summary, err := u.Run(context.Background(), &opts)
+	if !errors.Is(err, errInsert) {
+		t.Fatalf("err = %v, want %v", err, errInsert)
+	}
+	if store.indexed != 0 || summary.ran(phaseIndex) {
+		t.Error("index phase ran after the insert failed")
+	}
+
+	opts.DataFile = "testdata/missing.json"
+	_, err = u.Run(context.Background(), &opts)
+	if err == nil || !strings.Contains(err.Error(), "failed to load hotels") {
+		t.Errorf("missing data file err = %v", err)
+	}
+}
+
+func withSkipIndex(opts Options) Options {
+	opts.SkipIndex = true
+	return opts
+}
+
+func withIndexOnly(opts Options, dataFile string) Options {
+	opts.IndexOnly = true
+	opts.DataFile = dataFile
+	return opts
+}
+
+func TestUploadSummaryRender(t *testing.T) {
+	summary := &Summary{
+		Total:    2500 * time.Millisecond,
+		Tokens:   1234,
+		Loaded:   50,
+		Resumed:  5,
+		Embedded: 43,
+		Inserted: 43,
+		Failed:   2,
+	}
+	summary.record(phaseLoad, 10*time.Millisecond)
+	summary.record(phaseEmbed, 1800*time.Millisecond)
+	summary.record(phaseInsert, 600*time.Millisecond)
+	summary.skip(phaseIndex)
+
+	var out bytes.Buffer
+	summary.Render(&out)
+
+	want := `
+--- UPLOAD SUMMARY ---
+Total time: 2.5s
+load    ran     (10ms)
+embed   ran     (1.8s)
+insert  ran     (600ms)
+index   skipped
+Documents: 50 loaded, 43 embedded, 43 inserted, 2 failed
+Embedding tokens: 1234
+Resumed: 5 hotels skipped from the checkpoint
+`
+	if out.String() != want {
+		t.Errorf("summary =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,193 @@
+package upload
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+)
+
+// insertTimeout bounds one batch insert, which is not cancelled with the run
+const insertTimeout = 2 * time.Minute
+
+// embedOutcome is the result of embedding one hotel
+type embedOutcome struct {
+	hotel models.Hotel
+	doc   models.HotelForVectorStore
+	// rooms are the hotel's embedded room documents, with EmbedRooms
+	rooms []models.RoomForVectorStore
+	err   error
+}
+
+// embedFailure records a hotel whose embedding failed and why
+type embedFailure struct {
+	hotel models.Hotel
+	err   error
+}
+
+// hotelFeed calls emit for each hotel to upload and returns the first error, including
+// one returned by emit
+type hotelFeed func(emit func(models.Hotel) error) error
+
+// feedSlice feeds the hotels of a slice
+func feedSlice(hotels []models.Hotel) hotelFeed {
+	return func(emit func(models.Hotel) error) error {
+		for _, hotel := range hotels {
+			if err := emit(hotel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// pipelineStats holds the timings of one embed-and-insert pipeline run
+type pipelineStats struct {
+	embedTime  time.Duration
+	insertTime time.Duration
+}
+
+// embedAndInsert runs the upload pipeline: a producer passes the hotels from feed to
+// opts.Concurrency embedding workers, and a batcher inserts the embedded documents
+// every opts.BatchSize documents and records them in the checkpoint. The feed is read
+// as the workers take hotels, so only the hotels in flight are held in memory. Hotels
+// whose embedding fails are returned for a later pass. When the feed fails, the hotels
+// it already produced are still embedded and inserted before its error is returned. Counts in the summary are exact even
+// when the run is cancelled or an insert fails; in both cases the remaining work is
+// drained before returning. On cancellation, documents that were already embedded are
+// still inserted and checkpointed before ctx.Err() is returned.
+func (u *Uploader) embedAndInsert(ctx context.Context, feed hotelFeed, opts *Options, cp *checkpoint, summary *Summary, reporter *progress.Reporter) (pipelineStats, []embedFailure, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var stats pipelineStats
+	start := time.Now()
+
+	// Producer
+	jobs := make(chan models.Hotel)
+	var feedErr error
+	go func() {
+		defer close(jobs)
+		feedErr = feed(func(hotel models.Hotel) error {
+			select {
+			case jobs <- hotel:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	// Embedding workers
+	outcomes := make(chan embedOutcome)
+	var wg sync.WaitGroup
+	for range max(opts.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for hotel := range jobs {
+				doc, err := u.embedHotel(ctx, hotel, opts)
+				var rooms []models.RoomForVectorStore
+				if err == nil && opts.EmbedRooms {
+					rooms, err = u.embedRooms(ctx, hotel)
+				}
+				outcomes <- embedOutcome{hotel: hotel, doc: doc, rooms: rooms, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		stats.embedTime = time.Since(start)
+		close(outcomes)
+	}()
+
+	// Batcher
+	var runErr error
+	var failures []embedFailure
+	batch := make([]models.HotelForVectorStore, 0, opts.BatchSize)
+	var roomBatch []models.RoomForVectorStore
+	flush := func() {
+		if len(batch) == 0 || runErr != nil {
+			return
+		}
+		// Inserts are detached from cancellation so an interrupted run still stores and
+		// checkpoints the documents it already paid to embed
+		insertCtx, cancelInsert := context.WithTimeout(context.WithoutCancel(ctx), insertTimeout)
+		insertStart := time.Now()
+		ids := make([]string, len(batch))
+		for i, doc := range batch {
+			ids[i] = doc.HotelID
+		}
+		err := u.insertHotels(insertCtx, batch, opts.ChangedOnly)
+		if err == nil && opts.EmbedRooms {
+			err = u.insertRooms(insertCtx, ids, roomBatch, opts.ChangedOnly)
+		}
+		stats.insertTime += time.Since(insertStart)
+		cancelInsert()
+		if err != nil {
+			for _, doc := range batch {
+				summary.addFailure(doc.HotelID, doc.HotelName, phaseInsert, err)
+			}
+			runErr = err
+			cancel()
+			return
+		}
+		summary.Inserted += len(batch)
+		summary.Rooms += len(roomBatch)
+
+		if err := cp.Add(ids...); err != nil {
+			runErr = err
+			cancel()
+			return
+		}
+
+		batch = batch[:0]
+		roomBatch = roomBatch[:0]
+	}
+
+	for outcome := range outcomes {
+		switch {
+		case outcome.err != nil && ctx.Err() != nil:
+			// Cancelled mid-call: neither embedded nor a genuine failure
+		case outcome.err != nil:
+			failures = append(failures, embedFailure{hotel: outcome.hotel, err: outcome.err})
+			reporter.Fail()
+			slog.DebugContext(ctx, "failed to generate embedding", "hotel", outcome.hotel.HotelName, "err", outcome.err)
+		default:
+			summary.Embedded++
+			if opts.EmbedFrench {
+				if outcome.doc.DescriptionFrVector != nil {
+					summary.FrenchEmbedded++
+				} else {
+					summary.FrenchSkipped++
+				}
+			}
+			reporter.Done()
+			if runErr == nil {
+				batch = append(batch, outcome.doc)
+				roomBatch = append(roomBatch, outcome.rooms...)
+				if len(batch) >= opts.BatchSize {
+					flush()
+				}
+			}
+		}
+	}
+
+	if runErr != nil {
+		return stats, failures, runErr
+	}
+
+	flush()
+	if runErr != nil {
+		return stats, failures, runErr
+	}
+	// The producer finished before the workers closed outcomes. A cancelled run
+	// reports the cancellation rather than the feed stopping because of it.
+	if feedErr != nil && ctx.Err() == nil {
+		return stats, failures, feedErr
+	}
+	return stats, failures, ctx.Err()
+}
@@ -0,0 +1,215 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/leakcheck"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/progress"
+)
+
+// pipelineHotels returns n hotels; every fifth one has "unembeddable" in its description
+func pipelineHotels(n int) []models.Hotel {
+	hotels := make([]models.Hotel, n)
+	for i := range hotels {
+		description := fmt.Sprintf("Hotel number %d", i)
+		if i%5 == 4 {
+			description += " (unembeddable)"
+		}
+		hotels[i] = models.Hotel{HotelID: fmt.Sprint(i), HotelName: fmt.Sprintf("Hotel %d", i), Description: description}
+	}
+	return hotels
+}
+
+func pipelineOptions(t *testing.T, concurrency, batchSize int) (*Options, *checkpoint) {
+	opts := &Options{
+		BatchSize:   batchSize,
+		Concurrency: concurrency,
+		Checkpoint:  filepath.Join(t.TempDir(), "checkpoint.json"),
+	}
+	return opts, newCheckpoint(opts.Checkpoint, time.Hour)
+}
+
+func TestEmbedAndInsertCountsWithFailures(t *testing.T) {
+	leakcheck.Verify(t)
+	embedder := &fakeEmbedder{failOn: "unembeddable"}
+	store := &fakeStore{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+	opts, cp := pipelineOptions(t, 4, 3)
+
+	summary := &Summary{}
+	reporter := progress.New(io.Discard, 20, false)
+	_, failures, err := u.embedAndInsert(context.Background(), feedSlice(pipelineHotels(20)), opts, cp, summary, reporter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snap := reporter.Snapshot(); snap.Processed != 20 || snap.Failed != 4 {
+		t.Errorf("progress = %d processed, %d failed, want 20, 4", snap.Processed, snap.Failed)
+	}
+	if summary.Embedded != 16 || len(failures) != 4 || summary.Inserted != 16 {
+		t.Errorf("embedded %d, failed %d, inserted %d, want 16, 4, 16", summary.Embedded, len(failures), summary.Inserted)
+	}
+	for _, failure := range failures {
+		if !strings.Contains(failure.hotel.Description, "unembeddable") || failure.err == nil {
+			t.Errorf("unexpected failure for hotel %s: %v", failure.hotel.HotelID, failure.err)
+		}
+	}
+	if embedder.calls != 20 {
+		t.Errorf("embedder called %d times, want 20", embedder.calls)
+	}
+
+	seen := make(map[string]bool)
+	for _, doc := range store.inserted {
+		if seen[doc.HotelID] {
+			t.Errorf("hotel %s inserted twice", doc.HotelID)
+		}
+		seen[doc.HotelID] = true
+		if strings.Contains(doc.Description, "unembeddable") {
+			t.Errorf("hotel %s inserted without an embedding", doc.HotelID)
+		}
+	}
+	if len(seen) != 16 || cp.Len() != 16 {
+		t.Errorf("store has %d hotels and checkpoint %d, want 16", len(seen), cp.Len())
+	}
+	// 16 documents in batches of 3: five full batches and a final partial one
+	if store.inserts != 6 {
+		t.Errorf("store received %d inserts, want 6", store.inserts)
+	}
+}
+
+func TestEmbedAndInsertBoundsConcurrency(t *testing.T) {
+	embedder := &fakeEmbedder{wait: func(ctx context.Context, text string) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}}
+	u := &Uploader{Embedder: embedder, Store: &fakeStore{}, Out: io.Discard}
+	opts, cp := pipelineOptions(t, 3, 4)
+
+	summary := &Summary{}
+	if _, _, err := u.embedAndInsert(context.Background(), feedSlice(pipelineHotels(24)), opts, cp, summary, progress.New(io.Discard, 24, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	if embedder.maxInFlight > 3 {
+		t.Errorf("%d embeddings in flight, want at most 3", embedder.maxInFlight)
+	}
+	if embedder.maxInFlight < 2 {
+		t.Errorf("embeddings never overlapped (max in flight %d)", embedder.maxInFlight)
+	}
+	if summary.Embedded != 24 || summary.Inserted != 24 {
+		t.Errorf("embedded %d, inserted %d, want 24, 24", summary.Embedded, summary.Inserted)
+	}
+}
+
+func TestEmbedAndInsertInsertFailure(t *testing.T) {
+	leakcheck.Verify(t)
+	insertErr := errors.New("connection reset")
+	store := &fakeStore{insertErr: insertErr, failAfter: 2}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+	opts, cp := pipelineOptions(t, 4, 3)
+
+	summary := &Summary{}
+	_, failures, err := u.embedAndInsert(context.Background(), feedSlice(pipelineHotels(20)), opts, cp, summary, progress.New(io.Discard, 20, false))
+	if !errors.Is(err, insertErr) {
+		t.Fatalf("err = %v, want %v", err, insertErr)
+	}
+
+	if summary.Inserted != 6 || len(store.inserted) != 6 || cp.Len() != 6 {
+		t.Errorf("inserted %d, store %d, checkpoint %d, want 6 each", summary.Inserted, len(store.inserted), cp.Len())
+	}
+	if summary.Embedded+len(failures) > 20 || len(failures) != 0 {
+		t.Errorf("embedded %d, failed %d after an insert failure", summary.Embedded, len(failures))
+	}
+}
+
+func TestEmbedAndInsertCancellationDrains(t *testing.T) {
+	leakcheck.Verify(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first four hotels embed; the rest block until the run is cancelled,
+	// which happens once the second batch has been inserted
+	embedder := &fakeEmbedder{wait: func(ctx context.Context, text string) error {
+		var n int
+		fmt.Sscanf(text, "Hotel number %d", &n)
+		if n < 4 {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	store := &fakeStore{onInsert: func(total int) {
+		if total == 4 {
+			cancel()
+		}
+	}}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+	opts, cp := pipelineOptions(t, 3, 2)
+
+	done := make(chan struct{})
+	var err error
+	var failures []embedFailure
+	summary := &Summary{}
+	go func() {
+		defer close(done)
+		_, failures, err = u.embedAndInsert(ctx, feedSlice(pipelineHotels(20)), opts, cp, summary, progress.New(io.Discard, 20, false))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("embedAndInsert did not return after cancellation")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if summary.Embedded != 4 || summary.Inserted != 4 || len(failures) != 0 {
+		t.Errorf("embedded %d, inserted %d, failed %d, want 4, 4, 0", summary.Embedded, summary.Inserted, len(failures))
+	}
+	if len(store.inserted) != 4 || cp.Len() != 4 {
+		t.Errorf("store has %d hotels and checkpoint %d, want 4", len(store.inserted), cp.Len())
+	}
+}
+
+func TestEmbedAndInsertCancellationInsertsEmbeddedDocuments(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Three hotels embed, then the run is cancelled while the fourth is embedding.
+	// The partial batch is still inserted and checkpointed.
+	embedder := &fakeEmbedder{wait: func(ctx context.Context, text string) error {
+		var n int
+		fmt.Sscanf(text, "Hotel number %d", &n)
+		if n < 3 {
+			return nil
+		}
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	store := &fakeStore{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+	opts, cp := pipelineOptions(t, 1, 10)
+
+	summary := &Summary{}
+	_, _, err := u.embedAndInsert(ctx, feedSlice(pipelineHotels(10)), opts, cp, summary, progress.New(io.Discard, 10, false))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	if summary.Embedded != 3 || summary.Inserted != 3 || len(store.inserted) != 3 {
+		t.Errorf("embedded %d, inserted %d, store %d, want 3 each", summary.Embedded, summary.Inserted, len(store.inserted))
+	}
+	if cp.Len() != 3 {
+		t.Errorf("checkpoint has %d hotels, want 3", cp.Len())
+	}
+}
@@ -0,0 +1,59 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+const testVectorsFile = "testdata/hotels_with_vectors.json"
+
+func TestUploadPrecomputedVectors(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	store := &fakeStore{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: &bytes.Buffer{}, Dimensions: 3}
+
+	opts := testOptions(t)
+	opts.VectorsFile = testVectorsFile
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if embedder.calls != 0 {
+		t.Errorf("embedder called %d times for a pre-vectorized file", embedder.calls)
+	}
+	if len(store.inserted) != 3 || store.indexed != 1 {
+		t.Fatalf("inserted %d and indexed %d, want 3 and 1", len(store.inserted), store.indexed)
+	}
+	for _, doc := range store.inserted {
+		if len(doc.DescriptionVector) != 3 {
+			t.Errorf("hotel %s inserted with vector %v, want the file's vector", doc.HotelID, doc.DescriptionVector)
+		}
+	}
+	if summary.ran(phaseEmbed) || !summary.ran(phaseInsert) {
+		t.Errorf("phases = %+v, want embed skipped and insert run", summary.Phases)
+	}
+
+	var out bytes.Buffer
+	summary.Render(&out)
+	if !strings.Contains(out.String(), "Documents: 3 loaded with vectors, 3 inserted") {
+		t.Errorf("summary does not report the pre-vectorized upload:\n%s", out.String())
+	}
+}
+
+func TestUploadPrecomputedVectorsDimensionMismatch(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: &bytes.Buffer{}, Dimensions: 1536}
+
+	opts := testOptions(t)
+	opts.VectorsFile = testVectorsFile
+	_, err := u.Run(context.Background(), &opts)
+	if err == nil || !strings.Contains(err.Error(), "hotel 1 has a vector of 3 dimensions, not 1536") {
+		t.Fatalf("err = %v, want a dimension mismatch", err)
+	}
+	if len(store.inserted) != 0 || store.indexed != 0 {
+		t.Error("documents were written after the dimension check failed")
+	}
+}
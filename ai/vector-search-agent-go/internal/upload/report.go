@@ -0,0 +1,174 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/buildinfo"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+// Error classes recorded for failed documents
+const (
+	ClassAuth        = "auth"
+	ClassThrottled   = "throttled"
+	ClassRateLimited = "rate_limited"
+	ClassTimeout     = "timeout"
+	ClassTransient   = "transient"
+	ClassInvalidData = "invalid_data"
+	ClassOther       = "other"
+)
+
+// ReportConfig identifies what an upload wrote where. Settings that only affect speed,
+// such as batch size and concurrency, are left out so a retry may change them.
+type ReportConfig struct {
+	DataFile            string `json:"dataFile"`
+	Precomputed         bool   `json:"precomputed"`
+	EmbeddingDeployment string `json:"embeddingDeployment"`
+	Dimensions          int    `json:"dimensions"`
+	Database            string `json:"database"`
+	Collection          string `json:"collection"`
+}
+
+// Fingerprint returns a short hash of the configuration
+func (c ReportConfig) Fingerprint() string {
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ReportCounts summarizes the run a failure report describes
+type ReportCounts struct {
+	Loaded       int `json:"loaded"`
+	Embedded     int `json:"embedded"`
+	Inserted     int `json:"inserted"`
+	Failed       int `json:"failed"`
+	Invalid      int `json:"invalid"`
+	EmbedFailed  int `json:"embedFailed"`
+	InsertFailed int `json:"insertFailed"`
+}
+
+// FailureReport lists the documents an upload could not store, so they can be
+// inspected and retried with Options.Only
+type FailureReport struct {
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Build       buildinfo.Info `json:"build"`
+	Fingerprint string         `json:"fingerprint"`
+	Config      ReportConfig   `json:"config"`
+	Counts      ReportCounts   `json:"counts"`
+	// Error is the error that stopped the run early, if any; documents it kept from
+	// being attempted are not listed as failures
+	Error    string        `json:"error,omitempty"`
+	Failures []FailedHotel `json:"failures"`
+}
+
+// NewFailureReport builds the report of a run from its summary and error
+func NewFailureReport(summary *Summary, config ReportConfig, runErr error) *FailureReport {
+	r := &FailureReport{
+		GeneratedAt: time.Now().UTC(),
+		Build:       buildinfo.Read(),
+		Fingerprint: config.Fingerprint(),
+		Config:      config,
+		Counts: ReportCounts{
+			Loaded:   summary.Loaded,
+			Embedded: summary.Embedded,
+			Inserted: summary.Inserted,
+			Failed:   summary.Failed,
+		},
+		Failures: summary.FailedHotels,
+	}
+	if r.Failures == nil {
+		r.Failures = []FailedHotel{}
+	}
+	for _, f := range r.Failures {
+		switch f.Phase {
+		case phaseValidate:
+			r.Counts.Invalid++
+		case phaseEmbed:
+			r.Counts.EmbedFailed++
+		case phaseInsert:
+			r.Counts.InsertFailed++
+		}
+	}
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+	return r
+}
+
+// HotelIDs returns the IDs of the failed documents
+func (r *FailureReport) HotelIDs() []string {
+	ids := make([]string, len(r.Failures))
+	for i, f := range r.Failures {
+		ids[i] = f.HotelID
+	}
+	return ids
+}
+
+// Write saves the report to path through a temporary file, so a reader never sees a
+// partial report
+func (r *FailureReport) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode failure report: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write failure report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write failure report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write failure report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write failure report: %w", err)
+	}
+	return nil
+}
+
+// LoadFailureReport reads a report written by Write
+func LoadFailureReport(path string) (*FailureReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read failure report: %w", err)
+	}
+	var r FailureReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse failure report %s: %w", vectorstore.ErrInvalidData, path, err)
+	}
+	return &r, nil
+}
+
+// classifyError names the kind of failure, for the report and the summary
+func classifyError(err error) string {
+	switch {
+	case clients.IsAuthError(err), vectorstore.IsAuthError(err):
+		return ClassAuth
+	case clients.StatusCode(err) == http.StatusTooManyRequests:
+		return ClassThrottled
+	case errors.Is(err, clients.ErrRateLimited):
+		return ClassRateLimited
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case clients.IsTransient(err), vectorstore.IsConnectivityError(err):
+		return ClassTransient
+	case errors.Is(err, vectorstore.ErrInvalidData), clients.StatusCode(err) == http.StatusBadRequest:
+		return ClassInvalidData
+	}
+	return ClassOther
+}
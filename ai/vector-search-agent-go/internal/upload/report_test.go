@@ -0,0 +1,164 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/clients"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+	"github.com/openai/openai-go/v3"
+)
+
+var testReportConfig = ReportConfig{
+	DataFile:            testDataFile,
+	EmbeddingDeployment: "text-embedding-3-small",
+	Dimensions:          1536,
+	Database:            "Hotels",
+	Collection:          "hotels",
+}
+
+// failingRun uploads the test data with hotel 1 failing to embed and the insert
+// after the first one failing, and returns the store and the run's failure report
+func failingRun(t *testing.T) (*fakeStore, *FailureReport) {
+	t.Helper()
+	store := &fakeStore{insertErr: errors.New("write failed"), failAfter: 1}
+	u := &Uploader{Embedder: &fakeEmbedder{failOn: "Times Square"}, Store: store, Out: io.Discard}
+
+	opts := withSkipIndex(testOptions(t))
+	summary, err := u.Run(context.Background(), &opts)
+	if err == nil {
+		t.Fatal("Run succeeded, want the insert error")
+	}
+	return store, NewFailureReport(summary, testReportConfig, err)
+}
+
+func TestFailureReportFromInjectedFailures(t *testing.T) {
+	_, report := failingRun(t)
+
+	phases := make(map[string]string)
+	for _, f := range report.Failures {
+		phases[f.HotelID] = f.Phase + "/" + f.Class
+	}
+	want := map[string]string{"1": "embed/other", "11": "insert/other"}
+	if fmt.Sprint(phases) != fmt.Sprint(want) {
+		t.Errorf("failures = %v, want %v", phases, want)
+	}
+
+	wantCounts := ReportCounts{Loaded: 3, Embedded: 2, Inserted: 1, Failed: 2, EmbedFailed: 1, InsertFailed: 1}
+	if report.Counts != wantCounts {
+		t.Errorf("counts = %+v, want %+v", report.Counts, wantCounts)
+	}
+	if !strings.Contains(report.Error, "write failed") {
+		t.Errorf("Error = %q, want the run error", report.Error)
+	}
+	if report.Fingerprint != testReportConfig.Fingerprint() {
+		t.Errorf("Fingerprint = %q, want %q", report.Fingerprint, testReportConfig.Fingerprint())
+	}
+}
+
+func TestFailureReportDrivesRetryRun(t *testing.T) {
+	store, report := failingRun(t)
+
+	path := filepath.Join(t.TempDir(), "failures.json")
+	if err := report.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFailureReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.HotelIDs(), report.HotelIDs()) || loaded.Counts != report.Counts {
+		t.Fatalf("loaded report = %+v, want %+v", loaded, report)
+	}
+
+	store.insertErr = nil
+	embedder := &fakeEmbedder{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+	opts := withSkipIndex(testOptions(t))
+	opts.Only = loaded.HotelIDs()
+	opts.SkipExisting = true
+
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Failed != 0 || summary.Inserted != 2 || embedder.calls != 2 {
+		t.Errorf("retry inserted %d, embedded %d times, failed %d; want only the 2 reported hotels", summary.Inserted, embedder.calls, summary.Failed)
+	}
+
+	var retried []string
+	for _, doc := range store.inserted[1:] {
+		retried = append(retried, doc.HotelID)
+	}
+	slices.Sort(retried)
+	if want := []string{"1", "11"}; !slices.Equal(retried, want) {
+		t.Errorf("retried hotels = %v, want %v", retried, want)
+	}
+}
+
+func TestFailureReportWithoutFailures(t *testing.T) {
+	report := NewFailureReport(&Summary{Loaded: 3, Inserted: 3}, testReportConfig, nil)
+	if report.Failures == nil || len(report.HotelIDs()) != 0 || report.Error != "" {
+		t.Errorf("report = %+v, want an empty failure list", report)
+	}
+}
+
+func TestLoadFailureReportErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadFailureReport(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing report loaded")
+	}
+
+	path := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFailureReport(path); !errors.Is(err, vectorstore.ErrInvalidData) {
+		t.Errorf("err = %v, want ErrInvalidData", err)
+	}
+}
+
+func TestReportConfigFingerprint(t *testing.T) {
+	if testReportConfig.Fingerprint() != testReportConfig.Fingerprint() {
+		t.Error("fingerprint is not stable")
+	}
+
+	changed := testReportConfig
+	changed.Collection = "hotels-v2"
+	if changed.Fingerprint() == testReportConfig.Fingerprint() {
+		t.Error("fingerprint ignores the collection")
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"unauthorized", &openai.Error{StatusCode: http.StatusUnauthorized}, ClassAuth},
+		{"throttled", fmt.Errorf("embedding: %w", &openai.Error{StatusCode: http.StatusTooManyRequests}), ClassThrottled},
+		{"client rate limit", fmt.Errorf("embedding: %w", clients.ErrRateLimited), ClassRateLimited},
+		{"timeout", context.DeadlineExceeded, ClassTimeout},
+		{"server error", &openai.Error{StatusCode: http.StatusBadGateway}, ClassTransient},
+		{"bad request", &openai.Error{StatusCode: http.StatusBadRequest}, ClassInvalidData},
+		{"invalid data", fmt.Errorf("%w: empty description", vectorstore.ErrInvalidData), ClassInvalidData},
+		{"other", errors.New("boom"), ClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// scriptedEmbedder fails each text containing a key of failures for the first n attempts,
+// where n is the key's value, and succeeds after that
+type scriptedEmbedder struct {
+	mu       sync.Mutex
+	failures map[string]int
+	attempts map[string]int
+}
+
+func (s *scriptedEmbedder) GenerateEmbedding(ctx context.Context, text string) ([]float32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, n := range s.failures {
+		if !strings.Contains(text, key) {
+			continue
+		}
+		s.attempts[key]++
+		if s.attempts[key] <= n {
+			return nil, fmt.Errorf("rate limited (attempt %d)", s.attempts[key])
+		}
+	}
+	return []float32{0.1, 0.2, 0.3}, nil
+}
+
+func newScriptedEmbedder(failures map[string]int) *scriptedEmbedder {
+	return &scriptedEmbedder{failures: failures, attempts: make(map[string]int)}
+}
+
+func insertedIDs(docs []models.HotelForVectorStore) map[string]bool {
+	ids := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		ids[doc.HotelID] = true
+	}
+	return ids
+}
+
+func TestUploadRetriesFailedEmbeddings(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   map[string]int
+		wantFailed []string
+	}{
+		{"succeeds on first retry", map[string]int{"Times Square": 1}, nil},
+		{"succeeds on final retry", map[string]int{"Times Square": 2, "Royal Cottage": 1}, nil},
+		{"fails every pass", map[string]int{"Times Square": 3}, []string{"1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := newScriptedEmbedder(tt.failures)
+			store := &fakeStore{}
+			u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+
+			opts := withSkipIndex(testOptions(t))
+			summary, err := u.Run(context.Background(), &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var failed []string
+			for _, hotel := range summary.FailedHotels {
+				failed = append(failed, hotel.HotelID)
+			}
+			if strings.Join(failed, ",") != strings.Join(tt.wantFailed, ",") {
+				t.Errorf("failed hotels = %v, want %v", failed, tt.wantFailed)
+			}
+			if summary.Failed != len(tt.wantFailed) {
+				t.Errorf("Failed = %d, want %d", summary.Failed, len(tt.wantFailed))
+			}
+
+			wantInserted := 3 - len(tt.wantFailed)
+			ids := insertedIDs(store.inserted)
+			if summary.Embedded != wantInserted || summary.Inserted != wantInserted || len(ids) != wantInserted || len(store.inserted) != wantInserted {
+				t.Errorf("embedded %d, inserted %d (%d unique), want %d each", summary.Embedded, len(store.inserted), len(ids), wantInserted)
+			}
+			for _, id := range tt.wantFailed {
+				if ids[id] {
+					t.Errorf("failed hotel %s was inserted", id)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadRetryPassesOnlyRetryFailures(t *testing.T) {
+	embedder := newScriptedEmbedder(map[string]int{"Times Square": 3})
+	u := &Uploader{Embedder: embedder, Store: &fakeStore{}, Out: io.Discard}
+
+	opts := withSkipIndex(testOptions(t))
+	if _, err := u.Run(context.Background(), &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// One first pass and two retry passes, each retrying only the failing hotel
+	if got := embedder.attempts["Times Square"]; got != 1+retryPasses {
+		t.Errorf("failing hotel attempted %d times, want %d", got, 1+retryPasses)
+	}
+}
+
+func TestUploadRetryBackoffHonoursCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	embedder := newScriptedEmbedder(map[string]int{"Times Square": 1})
+	u := &Uploader{Embedder: embedder, Store: &fakeStore{}, Out: io.Discard, RetryBackoff: time.Hour}
+
+	opts := withSkipIndex(testOptions(t))
+	time.AfterFunc(10*time.Millisecond, cancel)
+	summary, err := u.Run(ctx, &opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if summary.Failed != 1 || len(summary.FailedHotels) != 1 || summary.FailedHotels[0].HotelID != "1" {
+		t.Errorf("summary = %+v, want hotel 1 recorded as failed", summary)
+	}
+}
+
+func TestUploadSummaryListsFailedHotels(t *testing.T) {
+	summary := &Summary{}
+	summary.record(phaseLoad, 0)
+	summary.recordFailures([]embedFailure{
+		{hotel: models.Hotel{HotelID: "1", HotelName: "Stay-Kay City Hotel"}, err: fmt.Errorf("rate limited")},
+	})
+
+	var out bytes.Buffer
+	summary.Render(&out)
+
+	want := fmt.Sprintf("Failed documents (embedding is retried %d times):\n  1 (Stay-Kay City Hotel) embed/other: rate limited\n", retryPasses)
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("summary is missing %q:\n%s", want, out.String())
+	}
+}
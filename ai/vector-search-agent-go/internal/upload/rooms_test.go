@@ -0,0 +1,119 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// roomsDataFile holds two hotels in the shape of the sample data, the first with three rooms
+const roomsDataFile = "testdata/rooms.json"
+
+func TestUploadEmbedsRooms(t *testing.T) {
+	store := &fakeStore{}
+	embedder := &fakeEmbedder{}
+	var out bytes.Buffer
+	u := &Uploader{Embedder: embedder, Store: store, Out: &out}
+	opts := testOptions(t)
+	opts.DataFile = roomsDataFile
+	opts.EmbedRooms = true
+
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two hotel descriptions and three rooms
+	if embedder.calls != 5 || len(store.inserted) != 2 || summary.Rooms != 3 {
+		t.Fatalf("embedded %d, inserted %d hotels and %d rooms; want 5, 2, and 3", embedder.calls, len(store.inserted), summary.Rooms)
+	}
+	var ids []string
+	for _, room := range store.rooms {
+		if room.HotelID != "1" || len(room.DescriptionVector) == 0 {
+			t.Errorf("room %+v, want an embedded room of hotel 1", room)
+		}
+		ids = append(ids, room.RoomID)
+	}
+	if !slices.Equal(ids, []string{"1:1", "1:2", "1:3"}) {
+		t.Errorf("rooms = %v", ids)
+	}
+	if store.indexed != 1 || store.roomsIndexed != 1 {
+		t.Errorf("indexes created = %d, rooms = %d, want 1 each", store.indexed, store.roomsIndexed)
+	}
+
+	summary.Render(&out)
+	if !strings.Contains(out.String(), "Rooms: 3 room documents inserted") {
+		t.Errorf("summary does not report the rooms:\n%s", out.String())
+	}
+}
+
+func TestUploadWithoutRoomsFlag(t *testing.T) {
+	store := &fakeStore{}
+	embedder := &fakeEmbedder{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+	opts := testOptions(t)
+	opts.DataFile = roomsDataFile
+
+	if _, err := u.Run(context.Background(), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if embedder.calls != 2 || len(store.rooms) != 0 || store.roomsIndexed != 0 {
+		t.Errorf("embedded %d with %d rooms and %d rooms indexes, want 2 and none", embedder.calls, len(store.rooms), store.roomsIndexed)
+	}
+}
+
+func TestUploadRoomEmbeddingFailure(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{failOn: "Deluxe Room"}, Store: store, Out: io.Discard}
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = roomsDataFile
+	opts.EmbedRooms = true
+
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hotel whose room fails is not stored at all, so its rooms stay complete
+	if summary.Failed != 1 || summary.FailedHotels[0].HotelID != "1" || !strings.Contains(summary.FailedHotels[0].Err, "room 1:3") {
+		t.Errorf("summary = %+v, want hotel 1 failed on room 1:3", summary)
+	}
+	if len(store.inserted) != 1 || len(store.rooms) != 0 {
+		t.Errorf("inserted %d hotels and %d rooms, want hotel 10 only", len(store.inserted), len(store.rooms))
+	}
+}
+
+func TestUploadChangedOnlyReplacesRooms(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard, EmbeddingModel: testEmbeddingModel}
+
+	for range 2 {
+		opts := withSkipIndex(testOptions(t))
+		opts.DataFile = roomsDataFile
+		opts.ChangedOnly = true
+		opts.EmbedRooms = true
+		if _, err := u.Run(context.Background(), &opts); err != nil {
+			t.Fatal(err)
+		}
+		// A different model makes every hotel changed on the second run
+		u.EmbeddingModel = "text-embedding-3-large"
+	}
+
+	if len(store.rooms) != 3 {
+		t.Errorf("rooms = %d after two runs, want the 3 rooms once", len(store.rooms))
+	}
+	if !slices.Equal(store.deletedRooms, []string{"1", "10", "1", "10"}) {
+		t.Errorf("rooms deleted for %v, want both hotels on each run", store.deletedRooms)
+	}
+}
+
+func TestOptionsValidateEmbedRooms(t *testing.T) {
+	opts := testOptions(t)
+	opts.VectorsFile = testVectorsFile
+	opts.EmbedRooms = true
+	if err := opts.Validate(); err == nil || !strings.Contains(err.Error(), "--embed-rooms") {
+		t.Errorf("Validate() = %v, want --embed-rooms rejected with a pre-vectorized file", err)
+	}
+}
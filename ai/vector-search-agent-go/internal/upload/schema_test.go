@@ -0,0 +1,62 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// captureLog redirects the standard logger to a buffer for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	out, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(out)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+func TestUploadWarnsOnOutdatedSchemaVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions map[int]int64
+		want     string
+	}{
+		{"empty collection", nil, ""},
+		{"current", map[int]int64{models.CurrentSchemaVersion: 5}, ""},
+		{"mixed", map[int]int64{0: 2, models.CurrentSchemaVersion: 5}, "the collection mixes schema versions (v0: 2, v1: 5); run `go run ./cmd/stats --migrate` to upgrade 2 older documents to version 1"},
+		{"all outdated", map[int]int64{0: 3}, "upgrade 3 older documents"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			store := &fakeStore{versions: tt.versions}
+			u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+			opts := withSkipIndex(testOptions(t))
+
+			if _, err := u.Run(context.Background(), &opts); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" && logs.Len() > 0 {
+				t.Errorf("unexpected warning: %s", logs.String())
+			}
+			if tt.want != "" && !strings.Contains(logs.String(), tt.want) {
+				t.Errorf("log is missing %q:\n%s", tt.want, logs.String())
+			}
+			for _, doc := range store.inserted {
+				if doc.SchemaVersion != models.CurrentSchemaVersion {
+					t.Errorf("hotel %s was written at schema version %d", doc.HotelID, doc.SchemaVersion)
+				}
+			}
+		})
+	}
+}
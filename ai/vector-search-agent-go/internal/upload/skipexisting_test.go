@@ -0,0 +1,104 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUploadSkipExisting(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     []string
+		wantExisting int
+		wantInserted []string
+	}{
+		{"empty collection", nil, 0, []string{"1", "10", "11"}},
+		{"partial overlap", []string{"10", "99"}, 1, []string{"1", "11"}},
+		{"full overlap", []string{"1", "10", "11"}, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := &fakeEmbedder{}
+			store := &fakeStore{existing: tt.existing}
+			var out bytes.Buffer
+			u := &Uploader{Embedder: embedder, Store: store, Out: &out}
+
+			opts := withSkipIndex(testOptions(t))
+			opts.SkipExisting = true
+			summary, err := u.Run(context.Background(), &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if summary.Existing != tt.wantExisting {
+				t.Errorf("Existing = %d, want %d", summary.Existing, tt.wantExisting)
+			}
+			// Existing hotels are filtered out before any embedding happens
+			if embedder.calls != len(tt.wantInserted) {
+				t.Errorf("embedder called %d times, want %d", embedder.calls, len(tt.wantInserted))
+			}
+			var inserted []string
+			for _, doc := range store.inserted {
+				inserted = append(inserted, doc.HotelID)
+			}
+			if strings.Join(inserted, ",") != strings.Join(tt.wantInserted, ",") {
+				t.Errorf("inserted %v, want %v", inserted, tt.wantInserted)
+			}
+			if want := fmt.Sprintf("Skipped %d hotels already in the collection", tt.wantExisting); !strings.Contains(out.String(), want) {
+				t.Errorf("output does not report skipped hotels:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestUploadSkipExistingMakesRerunsCheap(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	store := &fakeStore{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: &bytes.Buffer{}}
+
+	opts := withSkipIndex(testOptions(t))
+	opts.SkipExisting = true
+	for range 2 {
+		if _, err := u.Run(context.Background(), &opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if embedder.calls != 3 || len(store.inserted) != 3 {
+		t.Errorf("two runs embedded %d and inserted %d hotels, want 3 each", embedder.calls, len(store.inserted))
+	}
+}
+
+func TestUploadWithoutSkipExistingIgnoresCollection(t *testing.T) {
+	store := &fakeStore{existing: []string{"1", "10", "11"}}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: &bytes.Buffer{}}
+
+	opts := withSkipIndex(testOptions(t))
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Existing != 0 || len(store.inserted) != 3 {
+		t.Errorf("existing %d, inserted %d, want 0 and 3", summary.Existing, len(store.inserted))
+	}
+}
+
+func TestUploadSkipExistingLookupFailure(t *testing.T) {
+	errLookup := errors.New("distinct failed")
+	embedder := &fakeEmbedder{}
+	u := &Uploader{Embedder: embedder, Store: &fakeStore{existingErr: errLookup}, Out: &bytes.Buffer{}}
+
+	opts := withSkipIndex(testOptions(t))
+	opts.SkipExisting = true
+	if _, err := u.Run(context.Background(), &opts); !errors.Is(err, errLookup) {
+		t.Fatalf("err = %v, want %v", err, errLookup)
+	}
+	if embedder.calls != 0 {
+		t.Errorf("embedder called %d times after the lookup failed", embedder.calls)
+	}
+}
@@ -0,0 +1,118 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// writeSyntheticJSONL writes n hotels with descriptions of about descLen bytes and
+// returns the file path and size
+func writeSyntheticJSONL(t *testing.T, n, descLen int) (string, int64) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hotels.jsonl")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	description := strings.Repeat("Quiet rooms near the river. ", descLen/28+1)[:descLen]
+	for i := range n {
+		hotel := models.Hotel{HotelID: fmt.Sprint(i + 1), HotelName: fmt.Sprintf("Hotel %d", i+1), Description: description}
+		if err := enc.Encode(hotel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, info.Size()
+}
+
+// countingStore counts inserted documents without keeping them, and samples the live
+// heap every sampleEvery documents
+type countingStore struct {
+	fakeStore
+	mu          sync.Mutex
+	count       int
+	ids         map[string]bool
+	sampleEvery int
+	peakHeap    uint64
+}
+
+func (s *countingStore) InsertHotelsWithEmbeddings(ctx context.Context, hotels []models.HotelForVectorStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hotel := range hotels {
+		s.ids[hotel.HotelID] = true
+		s.count++
+		if s.count%s.sampleEvery == 0 {
+			s.peakHeap = max(s.peakHeap, liveHeap())
+		}
+	}
+	return nil
+}
+
+// liveHeap returns the bytes of reachable heap objects
+func liveHeap() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func TestUploadStreamsLargeFileInFlatMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes a large data file")
+	}
+	const hotels = 20000
+	path, size := writeSyntheticJSONL(t, hotels, 1000)
+
+	store := &countingStore{ids: make(map[string]bool, hotels), sampleEvery: hotels / 20}
+	embedder := &fakeEmbedder{}
+	u := &Uploader{Embedder: embedder, Store: store, Out: io.Discard}
+
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = path
+	opts.BatchSize = 50
+	opts.Concurrency = 4
+	opts.CheckpointInterval = time.Hour
+
+	baseline := liveHeap()
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Loaded != hotels || summary.Embedded != hotels || summary.Inserted != hotels || summary.Failed != 0 {
+		t.Errorf("loaded %d, embedded %d, inserted %d, failed %d; want %d each and none failed", summary.Loaded, summary.Embedded, summary.Inserted, summary.Failed, hotels)
+	}
+	if store.count != hotels || len(store.ids) != hotels || embedder.calls != hotels {
+		t.Errorf("store got %d documents (%d unique), embedder called %d times; want %d", store.count, len(store.ids), embedder.calls, hotels)
+	}
+
+	// Holding every hotel would take more than the file size. The ceiling leaves room
+	// for the checkpoint's and the test store's sets of IDs.
+	ceiling := uint64(size) / 4
+	if store.peakHeap > baseline && store.peakHeap-baseline > ceiling {
+		t.Errorf("live heap grew by %d bytes while uploading a %d byte file, want at most %d", store.peakHeap-baseline, size, ceiling)
+	}
+}
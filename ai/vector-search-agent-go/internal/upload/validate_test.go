@@ -0,0 +1,91 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/vectorstore"
+)
+
+const invalidDataFile = "testdata/invalid_hotels.json"
+
+func TestUploadSkipsInvalidHotels(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = invalidDataFile
+
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.inserted) != 2 || summary.Loaded != 6 || summary.Invalid != 4 || summary.Failed != 4 {
+		t.Fatalf("inserted %d, summary %+v; want hotels 1 and 6 inserted and 4 invalid", len(store.inserted), summary)
+	}
+
+	tests := []struct {
+		hotelID string
+		want    []string
+	}{
+		{"2", []string{"empty HotelName", "Rating 12 is outside 0-5"}},
+		{"3", []string{"empty Description"}},
+		{"4", []string{"invalid Location: latitude -173.975403 is outside [-90, 90]"}},
+		{"5", []string{"Rating -1 is outside 0-5", `invalid Location: type "Polygon" is not Point`}},
+	}
+	report := NewFailureReport(summary, testReportConfig, err)
+	for i, tt := range tests {
+		t.Run(tt.hotelID, func(t *testing.T) {
+			f := report.Failures[i]
+			if f.HotelID != tt.hotelID || f.Phase != phaseValidate || f.Class != ClassInvalidData {
+				t.Fatalf("failure = %+v, want hotel %s in phase validate with class invalid_data", f, tt.hotelID)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(f.Err, want) {
+					t.Errorf("error %q does not mention %q", f.Err, want)
+				}
+			}
+			if got := strings.Count(f.Err, ";") + 1; got != len(tt.want) {
+				t.Errorf("error %q lists %d problems, want %d", f.Err, got, len(tt.want))
+			}
+		})
+	}
+	if report.Counts.Invalid != 4 || report.Counts.EmbedFailed != 0 {
+		t.Errorf("counts = %+v, want 4 invalid", report.Counts)
+	}
+}
+
+func TestUploadStrictStopsAtInvalidHotel(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = invalidDataFile
+	opts.Strict = true
+
+	summary, err := u.Run(context.Background(), &opts)
+	if !errors.Is(err, vectorstore.ErrInvalidData) || !strings.Contains(err.Error(), "hotel 2 is invalid: empty HotelName; Rating 12") {
+		t.Fatalf("err = %v, want hotel 2's problems", err)
+	}
+	// Only the hotel before the invalid one was fed to the pipeline
+	if len(store.inserted) != 1 || store.inserted[0].HotelID != "1" || summary.Invalid != 0 {
+		t.Errorf("inserted %v with %d invalid, want hotel 1 only", store.inserted, summary.Invalid)
+	}
+}
+
+func TestUploadValidatesOnlySelectedHotels(t *testing.T) {
+	store := &fakeStore{}
+	u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard}
+	opts := withSkipIndex(testOptions(t))
+	opts.DataFile = invalidDataFile
+	opts.Only = []string{"3", "6"}
+
+	summary, err := u.Run(context.Background(), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Invalid != 1 || len(summary.FailedHotels) != 1 || summary.FailedHotels[0].HotelID != "3" || len(store.inserted) != 1 {
+		t.Errorf("summary = %+v, want only hotel 3 reported invalid", summary)
+	}
+}
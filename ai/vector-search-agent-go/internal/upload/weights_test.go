@@ -0,0 +1,118 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+func TestUploadEmbedsWeightedFields(t *testing.T) {
+	weights, err := models.ParseFieldWeights("Description:1,Tags:2,Category:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embedder := &fakeEmbedder{record: true}
+	store := &fakeStore{}
+	var out bytes.Buffer
+	u := &Uploader{Embedder: embedder, Store: store, Out: &out, EmbeddingModel: testEmbeddingModel}
+	opts := withSkipIndex(testOptions(t))
+	opts.FieldWeights = weights
+
+	if _, err := u.Run(context.Background(), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.inserted) != 3 || len(embedder.texts) != 3 {
+		t.Fatalf("inserted %d with %d embeddings, want 3 of each", len(store.inserted), len(embedder.texts))
+	}
+
+	for _, doc := range store.inserted {
+		want := weights.EmbeddingText(&doc)
+		if !slices.Contains(embedder.texts, want) {
+			t.Errorf("hotel %s was not embedded from its weighted text %q", doc.HotelID, want)
+		}
+		if doc.ContentHash != weights.ContentHash(testEmbeddingModel, &doc) {
+			t.Errorf("hotel %s stored hash %q, want the hash of its weighted text", doc.HotelID, doc.ContentHash)
+		}
+		if doc.ContentHash == doc.HashContent(testEmbeddingModel) {
+			t.Errorf("hotel %s stored the Description-only hash", doc.HotelID)
+		}
+	}
+	for _, text := range embedder.texts {
+		if strings.Count(text, "Tags: ") != 2 || strings.Contains(text, "Category: ") {
+			t.Errorf("embedded text does not follow the weights:\n%s", text)
+		}
+	}
+	if !strings.Contains(out.String(), "Embedding weighted fields: Description:1,Tags:2,Category:0") {
+		t.Errorf("output does not name the weights:\n%s", out.String())
+	}
+}
+
+func TestUploadChangedOnlyReembedsWhenWeightsChange(t *testing.T) {
+	previous, err := models.ParseFieldWeights("Description:1,Tags:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := models.ParseFieldWeights("Description:1,Tags:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		weights       models.FieldWeights
+		wantUpdated   int
+		wantUnchanged int
+	}{
+		{"same weights", previous, 0, 3},
+		{"changed weights", changed, 3, 0},
+		{"back to the description", nil, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A previous upload stored every hotel embedded with the previous weights
+			store := &fakeStore{}
+			u := &Uploader{Embedder: &fakeEmbedder{}, Store: store, Out: io.Discard, EmbeddingModel: testEmbeddingModel}
+			opts := withSkipIndex(testOptions(t))
+			opts.FieldWeights = previous
+			if _, err := u.Run(context.Background(), &opts); err != nil {
+				t.Fatal(err)
+			}
+
+			embedder := &fakeEmbedder{}
+			u.Embedder = embedder
+			opts = withSkipIndex(testOptions(t))
+			opts.ChangedOnly = true
+			opts.FieldWeights = tt.weights
+			summary, err := u.Run(context.Background(), &opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if summary.Updated != tt.wantUpdated || summary.Unchanged != tt.wantUnchanged || summary.New != 0 {
+				t.Errorf("new %d, updated %d, unchanged %d; want 0, %d, %d", summary.New, summary.Updated, summary.Unchanged, tt.wantUpdated, tt.wantUnchanged)
+			}
+			if embedder.calls != tt.wantUpdated {
+				t.Errorf("embedded %d hotels, want %d", embedder.calls, tt.wantUpdated)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsFieldWeightsWithPrecomputedVectors(t *testing.T) {
+	opts := testOptions(t)
+	opts.DataFile = ""
+	opts.VectorsFile = testDataFile
+	opts.FieldWeights = models.FieldWeights{{Field: "Tags", Weight: 1}}
+
+	err := opts.Validate()
+	if err == nil || !strings.Contains(err.Error(), "--field-weights cannot be used with --data-with-vectors") {
+		t.Errorf("Validate() = %v, want the field weights conflict", err)
+	}
+}
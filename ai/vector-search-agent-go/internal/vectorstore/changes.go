@@ -0,0 +1,90 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// deleteChunkSize bounds the number of HotelIds in one delete filter
+const deleteChunkSize = 1000
+
+// ContentHashes returns the ContentHash of every stored hotel by HotelId. Hotels
+// inserted before content hashes were recorded map to an empty string.
+func (vs *VectorStore) ContentHashes(ctx context.Context) (map[string]string, error) {
+	findOpts := options.Find().SetProjection(bson.D{{Key: "HotelId", Value: 1}, {Key: "ContentHash", Value: 1}, {Key: "_id", Value: 0}})
+	cursor, err := vs.collection.Find(ctx, bson.D{}, findOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list content hashes: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	hashes := make(map[string]string)
+	for cursor.Next(ctx) {
+		var doc struct {
+			HotelID     string `bson:"HotelId"`
+			ContentHash string `bson:"ContentHash"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+		hashes[doc.HotelID] = doc.ContentHash
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return hashes, nil
+}
+
+// UpsertHotels replaces the stored hotels with the same HotelIds, inserting those
+// that are new
+func (vs *VectorStore) UpsertHotels(ctx context.Context, hotels []models.HotelForVectorStore) (err error) {
+	if len(hotels) == 0 {
+		return nil
+	}
+	ctx, span := vs.startSpan(ctx, "bulkWrite", vs.collection.Name(), semconv.DBOperationBatchSize(len(hotels)))
+	defer func() { endSpan(span, err) }()
+
+	writes := make([]mongo.WriteModel, len(hotels))
+	for i, hotel := range hotels {
+		writes[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "HotelId", Value: hotel.HotelID}}).
+			SetReplacement(hotel).
+			SetUpsert(true)
+	}
+
+	result, err := vs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("failed to upsert documents: %w", err)
+	}
+
+	slog.DebugContext(ctx, "upserted documents", "inserted", result.UpsertedCount, "replaced", result.ModifiedCount)
+
+	return nil
+}
+
+// DeleteHotels removes the hotels with the given HotelIds and returns how many were deleted
+func (vs *VectorStore) DeleteHotels(ctx context.Context, hotelIDs []string) (_ int64, err error) {
+	ctx, span := vs.startSpan(ctx, "deleteMany", vs.collection.Name())
+	defer func() { endSpan(span, err) }()
+
+	var deleted int64
+	for start := 0; start < len(hotelIDs); start += deleteChunkSize {
+		chunk := hotelIDs[start:min(start+deleteChunkSize, len(hotelIDs))]
+		result, err := vs.collection.DeleteMany(ctx, bson.D{{Key: "HotelId", Value: bson.D{{Key: "$in", Value: chunk}}}})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete documents: %w", err)
+		}
+		deleted += result.DeletedCount
+	}
+
+	slog.InfoContext(ctx, "deleted documents", "collection", vs.config.CollectionName, "count", deleted)
+
+	return deleted, nil
+}
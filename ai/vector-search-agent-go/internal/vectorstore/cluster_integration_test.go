@@ -0,0 +1,64 @@
+//go:build integration
+
+package vectorstore
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// TestStoreAgainstCluster runs the store operations, then creates each kind of vector
+// index and searches it, against the Azure DocumentDB cluster named by
+// DOCUMENTDB_TEST_CONNECTION_STRING or, for passwordless authentication,
+// DOCUMENTDB_TEST_CLUSTER. It works in a database of its own, which it drops.
+func TestStoreAgainstCluster(t *testing.T) {
+	connectionString := os.Getenv("DOCUMENTDB_TEST_CONNECTION_STRING")
+	cluster := os.Getenv("DOCUMENTDB_TEST_CLUSTER")
+	if connectionString == "" && cluster == "" {
+		t.Skip("set DOCUMENTDB_TEST_CONNECTION_STRING or DOCUMENTDB_TEST_CLUSTER to test against a DocumentDB cluster")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), integrationTimeout)
+	defer cancel()
+
+	vs := openIntegrationStore(t, ctx, integrationConfig(connectionString, cluster))
+	testStoreOperations(t, ctx, vs)
+
+	specs := []VectorIndexSpec{
+		{Algorithm: AlgorithmIVF, Dimensions: 3, Similarity: "COS", NumLists: 1},
+		{Algorithm: AlgorithmHNSW, Dimensions: 3, Similarity: "COS", M: 16, EfConstruction: 64},
+		{Algorithm: AlgorithmDiskANN, Dimensions: 3, Similarity: "COS", MaxDegree: 20, LBuild: 10},
+	}
+	for i, spec := range specs {
+		t.Run(spec.Algorithm, func(t *testing.T) {
+			// Each algorithm gets a collection of its own: a field holds one vector index
+			store := vs.WithCollection("hotels_" + strconv.Itoa(i))
+			if err := store.InsertHotelsWithEmbeddings(ctx, integrationHotels()); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.CreateVectorIndexWithSpec(ctx, spec); err != nil {
+				t.Fatal(err)
+			}
+
+			index, err := store.FindVectorIndex(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if index == nil || index.Kind != spec.Algorithm || index.Dimensions != 3 || index.Similarity != "COS" {
+				t.Errorf("vector index = %+v, want %s", index, spec)
+			}
+
+			results, err := store.VectorSearch(ctx, []float32{1, 0.1, 0}, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 2 || results[0].Hotel.HotelID != "1" || results[1].Hotel.HotelID != "3" {
+				t.Fatalf("results = %+v, want hotels 1 and 3", results)
+			}
+			if results[0].Rank != 1 || results[0].Score < results[1].Score || results[0].Hotel.HotelName != "Beach Resort" {
+				t.Errorf("first result = %+v, want the beach resort ranked first with the higher score", results[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// defaultTestImage is the MongoDB image the container suite runs against; set
+// VECTORSTORE_TEST_IMAGE to use another MongoDB-compatible image
+const defaultTestImage = "mongo:7.0"
+
+// TestStoreAgainstContainer runs the store operations against a throwaway MongoDB
+// container. It drives the docker CLI directly rather than pulling a container library
+// into go.mod for one test, and skips when Docker isn't available.
+func TestStoreAgainstContainer(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not installed")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skipf("Docker is not running: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), integrationTimeout)
+	defer cancel()
+
+	image := os.Getenv("VECTORSTORE_TEST_IMAGE")
+	if image == "" {
+		image = defaultTestImage
+	}
+	// Publish the MongoDB port on a free loopback port and remove the container on stop
+	out, err := exec.CommandContext(ctx, "docker", "run", "--detach", "--rm", "--publish", "127.0.0.1::27017", image).Output()
+	if err != nil {
+		t.Fatalf("start %s: %v", image, commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	t.Cleanup(func() {
+		if err := exec.Command("docker", "stop", id).Run(); err != nil {
+			t.Errorf("stop %s: %v", image, commandError(err))
+		}
+	})
+
+	out, err = exec.CommandContext(ctx, "docker", "port", id, "27017/tcp").Output()
+	if err != nil {
+		t.Fatalf("find the published port: %v", commandError(err))
+	}
+	// docker port prints one address per line, such as 127.0.0.1:49153
+	address, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+
+	// Connecting waits for the server to accept connections, for up to the driver's
+	// 30-second server selection timeout
+	uri := fmt.Sprintf("mongodb://%s/?directConnection=true", address)
+	vs := openIntegrationStore(t, ctx, integrationConfig(uri, ""))
+	testStoreOperations(t, ctx, vs)
+}
+
+// commandError adds the standard error of a failed docker command to err
+func commandError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+package vectorstore
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// CSVTagSeparator separates the values of the Tags column
+const CSVTagSeparator = "|"
+
+// csvSetter parses one cell into a hotel field
+type csvSetter func(hotel *models.Hotel, value string) error
+
+// csvColumns maps lower-cased header names to the hotel fields they fill. Address
+// columns may be named with or without the "Address." prefix.
+var csvColumns = map[string]csvSetter{
+	"hotelid":            func(h *models.Hotel, v string) error { h.HotelID = v; return nil },
+	"hotelname":          func(h *models.Hotel, v string) error { h.HotelName = v; return nil },
+	"description":        func(h *models.Hotel, v string) error { h.Description = v; return nil },
+	"description_fr":     func(h *models.Hotel, v string) error { h.DescriptionFr = v; return nil },
+	"category":           func(h *models.Hotel, v string) error { h.Category = v; return nil },
+	"tags":               setCSVTags,
+	"parkingincluded":    func(h *models.Hotel, v string) error { return parseCSVBool(v, &h.ParkingIncluded) },
+	"isdeleted":          func(h *models.Hotel, v string) error { return parseCSVBool(v, &h.IsDeleted) },
+	"lastrenovationdate": setCSVRenovationDate,
+	"rating":             setCSVRating,
+	"streetaddress":      func(h *models.Hotel, v string) error { h.Address.StreetAddress = v; return nil },
+	"city":               func(h *models.Hotel, v string) error { h.Address.City = v; return nil },
+	"stateprovince":      func(h *models.Hotel, v string) error { h.Address.StateProvince = v; return nil },
+	"postalcode":         func(h *models.Hotel, v string) error { h.Address.PostalCode = v; return nil },
+	"country":            func(h *models.Hotel, v string) error { h.Address.Country = v; return nil },
+	"latitude":           func(h *models.Hotel, v string) error { return setCSVCoordinate(h, v, 1) },
+	"longitude":          func(h *models.Hotel, v string) error { return setCSVCoordinate(h, v, 0) },
+}
+
+// streamCSV reads hotels from CSV with a header row, calling fn for each. Empty cells
+// leave the field at its zero value, as a missing JSON property does. Unknown columns
+// and rows that fail to convert are errors, unless lenient is set, in which case they
+// are skipped with a warning. shown names the source in messages.
+func streamCSV(r io.Reader, shown string, lenient bool, fn func(models.Hotel) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return csvReadError(shown, err)
+	}
+
+	setters := make([]csvSetter, len(header))
+	var unknown []string
+	coordinates := 0
+	for i, name := range header {
+		// Spreadsheet exports often start with a byte order mark
+		key := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "\ufeff"))
+		key = strings.TrimPrefix(key, "address.")
+		setter, ok := csvColumns[key]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		setters[i] = setter
+		if key == "latitude" || key == "longitude" {
+			coordinates++
+		}
+	}
+	if coordinates == 1 {
+		return fmt.Errorf("%w: %s: Latitude and Longitude columns must be used together", ErrInvalidData, shown)
+	}
+	if len(unknown) > 0 {
+		if !lenient {
+			return fmt.Errorf("%w: %s: unknown CSV columns %s (set DATA_LENIENT=true to ignore them)", ErrInvalidData, shown, strings.Join(unknown, ", "))
+		}
+		log.Printf("Warning: %s: ignoring unknown CSV columns %s", shown, strings.Join(unknown, ", "))
+	}
+
+	var rowErrors []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+				rowErrors = append(rowErrors, parseErr.Error())
+				continue
+			}
+			return csvReadError(shown, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		hotel, err := parseCSVRow(record, header, setters, line)
+		if err == nil {
+			if err = checkHotel(hotel); err != nil {
+				err = fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, err.Error())
+			continue
+		}
+		if lenient || len(rowErrors) == 0 {
+			if err := fn(hotel); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		if !lenient {
+			return fmt.Errorf("%w: %s: %d bad CSV rows: %s", ErrInvalidData, shown, len(rowErrors), listErrors(rowErrors))
+		}
+		log.Printf("Warning: %s: skipped %d bad CSV rows: %s", shown, len(rowErrors), listErrors(rowErrors))
+	}
+	return nil
+}
+
+// parseCSVRow converts one record. line is the record's line in the file, for errors.
+func parseCSVRow(record, header []string, setters []csvSetter, line int) (models.Hotel, error) {
+	var hotel models.Hotel
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if setters[i] == nil || value == "" {
+			continue
+		}
+		if err := setters[i](&hotel, value); err != nil {
+			return hotel, fmt.Errorf("line %d, column %s: %w", line, header[i], err)
+		}
+	}
+	return hotel, nil
+}
+
+// setCSVTags splits the Tags column on CSVTagSeparator
+func setCSVTags(hotel *models.Hotel, value string) error {
+	for _, tag := range strings.Split(value, CSVTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			hotel.Tags = append(hotel.Tags, tag)
+		}
+	}
+	return nil
+}
+
+// parseCSVBool accepts the values strconv.ParseBool does, plus yes and no
+func parseCSVBool(value string, target *bool) error {
+	switch strings.ToLower(value) {
+	case "yes", "y":
+		*target = true
+		return nil
+	case "no", "n":
+		*target = false
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", value)
+	}
+	*target = b
+	return nil
+}
+
+// setCSVRenovationDate accepts RFC 3339 timestamps and plain dates
+func setCSVRenovationDate(hotel *models.Hotel, value string) error {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			hotel.LastRenovationDate = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+}
+
+// setCSVRating parses the Rating column
+func setCSVRating(hotel *models.Hotel, value string) error {
+	rating, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", value)
+	}
+	hotel.Rating = rating
+	return nil
+}
+
+// setCSVCoordinate sets one GeoJSON coordinate: index 0 is longitude, 1 is latitude
+func setCSVCoordinate(hotel *models.Hotel, value string, index int) error {
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid coordinate %q", value)
+	}
+	if hotel.Location == nil {
+		hotel.Location = models.NewGeoJSONPoint(0, 0)
+	}
+	hotel.Location.Coordinates[index] = coordinate
+	return nil
+}
+
+// csvReadError reports a CSV syntax error, or a failure reading the source
+func csvReadError(shown string, err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidData, shown, err)
+	}
+	return fmt.Errorf("failed to read file: %w", err)
+}
@@ -0,0 +1,184 @@
+package vectorstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadCSVMatchesJSON(t *testing.T) {
+	fromJSON, err := LoadOptions{}.Load("testdata/without_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromCSV, err := LoadOptions{}.Load("testdata/without_vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromCSV, fromJSON) {
+		t.Errorf("CSV hotels = %+v\nJSON hotels = %+v", fromCSV, fromJSON)
+	}
+}
+
+func TestLoadCSVQuoting(t *testing.T) {
+	hotels, err := LoadOptions{}.Load("testdata/quoting.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 {
+		t.Fatalf("loaded %d hotels, want 2", len(hotels))
+	}
+
+	hotel := hotels[0]
+	checks := []struct {
+		field     string
+		got, want any
+	}{
+		{"HotelName", hotel.HotelName, `Hotel "Central"`},
+		{"Description", hotel.Description, "Rooftop bar, pool, and spa.\nPets welcome."},
+		{"Category", hotel.Category, "Resort and Spa"},
+		{"Tags", hotel.Tags, []string{"pool", "bar", "spa"}},
+		{"Rating", hotel.Rating, 4.5},
+		{"ParkingIncluded", hotel.ParkingIncluded, true},
+		{"LastRenovationDate", hotel.LastRenovationDate, time.Date(2015, 9, 20, 0, 0, 0, 0, time.UTC)},
+		{"StreetAddress", hotel.Address.StreetAddress, "140 University Town Center Dr, Suite 2"},
+		{"City", hotel.Address.City, "Sarasota"},
+		{"PostalCode", hotel.Address.PostalCode, "34243"},
+		{"Coordinates", hotel.Location.Coordinates, []float64{-82.452843, 27.384417}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %#v, want %#v", c.field, c.got, c.want)
+		}
+	}
+
+	plain := hotels[1]
+	if plain.HotelID != "4" || plain.Tags != nil || plain.Rating != 0 || plain.Location != nil {
+		t.Errorf("empty cells did not leave zero values: %+v", plain)
+	}
+}
+
+func TestLoadCSVBadRows(t *testing.T) {
+	_, err := LoadOptions{}.Load("testdata/bad_rows.csv")
+	if !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("err = %v, want ErrInvalidData", err)
+	}
+	for _, want := range []string{
+		"testdata/bad_rows.csv: 2 bad CSV rows",
+		`line 3, column Rating: invalid number "four"`,
+		`line 4, column ParkingIncluded: invalid boolean "sometimes"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %q, want it to contain %q", err, want)
+		}
+	}
+
+	hotels, err := LoadOptions{Lenient: true}.Load("testdata/bad_rows.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 || hotels[0].HotelID != "1" || hotels[1].HotelID != "4" || hotels[1].ParkingIncluded {
+		t.Errorf("lenient load = %+v, want hotels 1 and 4", hotels)
+	}
+}
+
+func TestLoadCSVFieldCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short.csv")
+	data := "HotelId,HotelName\n1,Stay-Kay City Hotel\n2\n3,Old Century Hotel\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (LoadOptions{}).Load(path); !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("strict err = %v, want ErrInvalidData naming line 3", err)
+	}
+	hotels, err := LoadOptions{Lenient: true}.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 || hotels[1].HotelID != "3" {
+		t.Errorf("lenient load = %+v, want hotels 1 and 3", hotels)
+	}
+}
+
+func TestLoadCSVUnknownColumns(t *testing.T) {
+	_, err := LoadOptions{}.Load("testdata/unknown_columns.csv")
+	if !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), "unknown CSV columns Stars, Manager") {
+		t.Errorf("strict err = %v, want the unknown columns listed", err)
+	}
+
+	hotels, err := LoadOptions{Lenient: true}.Load("testdata/unknown_columns.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 1 || hotels[0].HotelName != "Stay-Kay City Hotel" {
+		t.Errorf("lenient load = %+v", hotels)
+	}
+}
+
+func TestLoadSharesValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing_ids.json")
+	data := `[{"HotelId": "1", "HotelName": "Stay-Kay City Hotel"}, {"HotelName": "Nameless Hotel"}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, want := range map[string]string{"testdata/missing_ids.csv": "line 3: no HotelId", path: "hotel 2: no HotelId"} {
+		_, err := LoadOptions{}.Load(file)
+		if !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", file, err, want)
+		}
+	}
+}
+
+func TestLoadFormatOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hotels.txt")
+	data, err := os.ReadFile("testdata/without_vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (LoadOptions{}).Load(path); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("err = %v, want the CSV rejected as JSON", err)
+	}
+	t.Setenv("DATA_FORMAT", "CSV")
+	hotels, err := LoadHotels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 {
+		t.Errorf("hotels = %+v", hotels)
+	}
+
+	if _, err := (LoadOptions{Format: "xml"}).Load(path); !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), `unknown data format "xml"`) {
+		t.Errorf("err = %v, want the unknown format rejected", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"hotels.json", FormatJSON},
+		{"hotels.CSV", FormatCSV},
+		{"hotels.csv.gz", FormatCSV},
+		{"hotels.jsonl", FormatJSONL},
+		{"hotels.ndjson.gz", FormatJSONL},
+		{"https://example.blob.core.windows.net/data/hotels.csv?sv=2023&sig=x", FormatCSV},
+		{"hotels", FormatJSON},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.path); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"go.mongodb.org/mongo-driver/bson"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+)
+
+// DocumentTypeHotel is the registered name of the hotel document type
+const DocumentTypeHotel = "hotel"
+
+// DocumentDecoder decodes one stored document of a registered type
+type DocumentDecoder func(raw bson.Raw) (models.Document, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]DocumentDecoder{
+		DocumentTypeHotel: func(raw bson.Raw) (models.Document, error) {
+			var hotel models.HotelForVectorStore
+			if err := bson.Unmarshal(raw, &hotel); err != nil {
+				return nil, err
+			}
+			return &hotel, nil
+		},
+		DocumentTypeRoom: func(raw bson.Raw) (models.Document, error) {
+			var room models.RoomForVectorStore
+			if err := bson.Unmarshal(raw, &room); err != nil {
+				return nil, err
+			}
+			return &room, nil
+		},
+	}
+)
+
+// RegisterDocumentType makes SearchDocuments able to decode documents of type name.
+// Registering a name again replaces its decoder.
+func RegisterDocumentType(name string, decode DocumentDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[name] = decode
+}
+
+// documentDecoder returns the decoder registered for name
+func documentDecoder(name string) (DocumentDecoder, error) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	decode, ok := decoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown document type %q: register it with RegisterDocumentType", name)
+	}
+	return decode, nil
+}
+
+// InsertDocuments inserts documents of any type; each is stored as its BSON encoding
+func (vs *VectorStore) InsertDocuments(ctx context.Context, docs []models.Document) (err error) {
+	if len(docs) == 0 {
+		return nil
+	}
+	ctx, span := vs.startSpan(ctx, "insertMany", vs.collection.Name(), semconv.DBOperationBatchSize(len(docs)))
+	defer func() { endSpan(span, err) }()
+
+	values := make([]any, len(docs))
+	for i, doc := range docs {
+		values[i] = doc
+	}
+
+	result, err := vs.collection.InsertMany(ctx, values)
+	if err != nil {
+		return fmt.Errorf("failed to insert documents: %w", err)
+	}
+
+	slog.DebugContext(ctx, "inserted documents", "count", len(result.InsertedIDs))
+
+	return nil
+}
+
+// SearchDocuments performs a vector similarity search, decoding each match with the
+// decoder registered for docType
+func (vs *VectorStore) SearchDocuments(ctx context.Context, docType string, queryVector []float32, k int) (_ []models.DocumentSearchResult, err error) {
+	decode, err := documentDecoder(docType)
+	if err != nil {
+		return nil, err
+	}
+	k = ClampK(ctx, k)
+
+	done := trace.Start(ctx, trace.EventVectorSearch)
+	defer func() { done(err) }()
+	ctx, span := vs.startSpan(ctx, "aggregate", vs.collection.Name(), telemetry.KKey.Int(k))
+	defer func() { endSpan(span, err) }()
+	defer func(start time.Time) { metrics.Default().Search(time.Since(start), err) }(time.Now())
+	ctx, end := vs.budget(ctx, timeouts.StageSearch)
+	defer end(&err)
+
+	scored, err := vs.search(ctx, vs.config.EmbeddedField, queryVector, k)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.DocumentSearchResult
+	for _, result := range scored {
+		decoded, err := documentResult(result, decode)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, decoded)
+	}
+
+	slog.DebugContext(ctx, "vector search returned", "results", len(results), "type", docType)
+	span.SetAttributes(telemetry.ResultCountKey.Int(len(results)))
+
+	return results, nil
+}
+
+// documentResult decodes the document of one search result
+func documentResult(result scoredDocument, decode DocumentDecoder) (models.DocumentSearchResult, error) {
+	doc, err := decode(result.Document)
+	if err != nil {
+		return models.DocumentSearchResult{}, fmt.Errorf("failed to decode result: %w", err)
+	}
+	return models.DocumentSearchResult{Document: doc, Score: result.Score}, nil
+}
+
+// FormatDocumentForSynthesizer formats a search result of any document type for the
+// synthesizer agent in the default text layout
+func FormatDocumentForSynthesizer(result models.DocumentSearchResult) string {
+	return Formatter{}.FormatDocument(result)
+}
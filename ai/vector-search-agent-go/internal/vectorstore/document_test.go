@@ -0,0 +1,119 @@
+package vectorstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// faq is a second document type, to check that nothing in the generic path assumes hotels
+type faq struct {
+	FaqID    string    `bson:"FaqId"`
+	Question string    `bson:"Question"`
+	Answer   string    `bson:"Answer"`
+	Vector   []float32 `bson:"Vector,omitempty"`
+}
+
+func (f *faq) ID() string            { return f.FaqID }
+func (f *faq) EmbeddingText() string { return f.Question + "\n" + f.Answer }
+func (f *faq) DisplayFields() map[string]string {
+	return map[string]string{"Question": f.Question, "Answer": f.Answer}
+}
+
+func init() {
+	RegisterDocumentType("faq", func(raw bson.Raw) (models.Document, error) {
+		var f faq
+		if err := bson.Unmarshal(raw, &f); err != nil {
+			return nil, err
+		}
+		return &f, nil
+	})
+}
+
+// searchResult encodes doc the way the search pipeline returns it, and decodes it as
+// the search does
+func searchResult(t *testing.T, doc any, score float64) scoredDocument {
+	t.Helper()
+	raw, err := bson.Marshal(bson.D{{Key: "score", Value: score}, {Key: "document", Value: doc}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result scoredDocument
+	if err := bson.Unmarshal(raw, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestDocumentResult(t *testing.T) {
+	tests := []struct {
+		docType string
+		doc     any
+		wantID  string
+		want    string
+	}{
+		{"faq", &faq{FaqID: "q1", Question: "Is breakfast included?", Answer: "Yes, until 10am.", Vector: []float32{0.1}}, "q1", "Is breakfast included?\nYes, until 10am."},
+		{DocumentTypeHotel, &models.HotelForVectorStore{HotelID: "1", HotelName: "Stay-Kay City Hotel", Description: "Close to Times Square."}, "1", "Close to Times Square."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.docType, func(t *testing.T) {
+			decode, err := documentDecoder(tt.docType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := documentResult(searchResult(t, tt.doc, 0.87), decode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Score != 0.87 || result.Document.ID() != tt.wantID || result.Document.EmbeddingText() != tt.want {
+				t.Errorf("result = %+v, want %s with score 0.87", result, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestDocumentResultErrors(t *testing.T) {
+	decode, err := documentDecoder("faq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Question that is not a string can't be decoded into the faq type
+	result := searchResult(t, bson.D{{Key: "FaqId", Value: "q1"}, {Key: "Question", Value: 42}}, 0.5)
+	if _, err := documentResult(result, decode); err == nil || !strings.Contains(err.Error(), "failed to decode result") {
+		t.Errorf("err = %v, want a decode error", err)
+	}
+}
+
+func TestSearchDocumentsUnknownType(t *testing.T) {
+	_, err := (&VectorStore{}).SearchDocuments(context.Background(), "product", []float32{0.1}, 3)
+	if err == nil || !strings.Contains(err.Error(), `unknown document type "product"`) {
+		t.Errorf("err = %v, want the unknown type", err)
+	}
+}
+
+func TestFormatDocumentForSynthesizer(t *testing.T) {
+	got := FormatDocumentForSynthesizer(models.DocumentSearchResult{
+		Document: &faq{FaqID: "q1", Question: "Is breakfast included?", Answer: "Yes, until 10am."},
+		Score:    0.5,
+	})
+	// Without a FieldOrder, fields are sorted by name
+	want := "--- DOCUMENT START ---\nAnswer: Yes, until 10am.\nQuestion: Is breakfast included?\nScore: 0.500000\n--- DOCUMENT END ---"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Hotels keep their field order through the generic formatter
+	hotel := &models.HotelForVectorStore{HotelID: "1", HotelName: "Stay-Kay City Hotel", Rating: 3.6}
+	got = FormatDocumentForSynthesizer(models.DocumentSearchResult{Document: hotel, Score: 0.9})
+	if !strings.HasPrefix(got, "--- DOCUMENT START ---\nHotelId: 1\nHotelName: Stay-Kay City Hotel\nDescription: \n") {
+		t.Errorf("hotel formatted as:\n%s", got)
+	}
+	// and differ from FormatHotelForSynthesizer only in the markers
+	if hotelOnly := FormatHotelForSynthesizer(models.HotelSearchResult{Hotel: *hotel, Score: 0.9}); hotelOnly != strings.ReplaceAll(got, "DOCUMENT", "HOTEL") {
+		t.Errorf("FormatHotelForSynthesizer and FormatDocumentForSynthesizer differ:\n%s\n%s", hotelOnly, got)
+	}
+}
@@ -0,0 +1,177 @@
+package vectorstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Defaults of the ephemeral database settings, used when EPHEMERAL_DATABASE_PREFIX and
+// EPHEMERAL_DATABASE_FILE are unset
+const (
+	DefaultEphemeralPrefix = "hotels-ci-"
+	DefaultEphemeralFile   = ".ephemeral-database"
+)
+
+// ephemeralTimeLayout is the creation time in an ephemeral database name. It is UTC to
+// the second, and sorts in creation order.
+const ephemeralTimeLayout = "20060102t150405"
+
+// maxDatabaseNameLength is the longest database name DocumentDB accepts
+const maxDatabaseNameLength = 63
+
+// NewEphemeralDatabaseName returns a database name for one run: prefix, the creation
+// time, and a random suffix that keeps runs started in the same second apart
+func NewEphemeralDatabaseName(prefix string, now time.Time) (string, error) {
+	if prefix == "" || strings.ContainsAny(prefix, `/\. "$*<>:|?`) {
+		return "", fmt.Errorf("invalid EPHEMERAL_DATABASE_PREFIX %q: must be non-empty and use none of /\\.\"$*<>:|? or spaces", prefix)
+	}
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate ephemeral database name: %w", err)
+	}
+	name := prefix + now.UTC().Format(ephemeralTimeLayout) + "-" + hex.EncodeToString(suffix)
+	if len(name) > maxDatabaseNameLength {
+		return "", fmt.Errorf("invalid EPHEMERAL_DATABASE_PREFIX %q: too long for a database name", prefix)
+	}
+	return name, nil
+}
+
+// ephemeralCreated returns the creation time in an ephemeral database name, and false
+// when name wasn't made by NewEphemeralDatabaseName with prefix
+func ephemeralCreated(name, prefix string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, suffix, ok := strings.Cut(rest, "-")
+	if !ok || len(suffix) != 6 {
+		return time.Time{}, false
+	}
+	if _, err := hex.DecodeString(suffix); err != nil {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(ephemeralTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// expiredEphemeral returns the names made with prefix that were created more than
+// olderThan before now. Other names, including ones that only share the prefix, are
+// never selected.
+func expiredEphemeral(names []string, prefix string, olderThan time.Duration, now time.Time) []string {
+	var expired []string
+	for _, name := range names {
+		if created, ok := ephemeralCreated(name, prefix); ok && now.Sub(created) > olderThan {
+			expired = append(expired, name)
+		}
+	}
+	return expired
+}
+
+// resolveEphemeralDatabase returns the database of this run. The name recorded in
+// config.EphemeralFile is reused, so the commands of one CI run share the database the
+// first of them created; otherwise a new name is generated and recorded.
+func resolveEphemeralDatabase(config *VectorStoreConfig, now time.Time) (string, error) {
+	prefix := config.EphemeralPrefix
+	if prefix == "" {
+		prefix = DefaultEphemeralPrefix
+	}
+	if config.EphemeralFile != "" {
+		data, err := os.ReadFile(config.EphemeralFile)
+		if err == nil {
+			if name := strings.TrimSpace(string(data)); strings.HasPrefix(name, prefix) {
+				return name, nil
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to read ephemeral database record: %w", err)
+		}
+	}
+
+	name, err := NewEphemeralDatabaseName(prefix, now)
+	if err != nil {
+		return "", err
+	}
+	if config.EphemeralFile != "" {
+		if err := os.WriteFile(config.EphemeralFile, []byte(name+"\n"), 0o644); err != nil {
+			return "", fmt.Errorf("failed to record ephemeral database: %w", err)
+		}
+	}
+	return name, nil
+}
+
+// forgetEphemeralDatabase removes the record of the ephemeral database once it is dropped
+func forgetEphemeralDatabase(config *VectorStoreConfig) {
+	if !config.Ephemeral || config.EphemeralFile == "" {
+		return
+	}
+	if err := os.Remove(config.EphemeralFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove ephemeral database record", "file", config.EphemeralFile, "err", err)
+	}
+}
+
+// DatabaseAdmin lists and drops the databases of a cluster. *VectorStore implements it.
+type DatabaseAdmin interface {
+	ListDatabaseNames(ctx context.Context) ([]string, error)
+	DropDatabase(ctx context.Context, name string) error
+}
+
+// ListDatabaseNames returns the names of the databases in the cluster
+func (vs *VectorStore) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	names, err := vs.client.ListDatabaseNames(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+	return names, nil
+}
+
+// DropDatabase drops the named database of the cluster
+func (vs *VectorStore) DropDatabase(ctx context.Context, name string) error {
+	if err := vs.client.Database(name).Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// CleanupEphemeral drops the ephemeral databases made with prefix more than olderThan
+// ago and returns their names. confirm, when not nil, is shown the databases first and
+// nothing is dropped unless it agrees. Every database is attempted; the failures are
+// joined into the error.
+func CleanupEphemeral(ctx context.Context, admin DatabaseAdmin, prefix string, olderThan time.Duration, confirm func(expired []string) (bool, error)) ([]string, error) {
+	names, err := admin.ListDatabaseNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	expired := expiredEphemeral(names, prefix, olderThan, time.Now())
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	if confirm != nil {
+		ok, err := confirm(expired)
+		if err != nil || !ok {
+			return nil, err
+		}
+	}
+
+	var dropped []string
+	var errs []error
+	for _, name := range expired {
+		if err := admin.DropDatabase(ctx, name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		slog.InfoContext(ctx, "dropped ephemeral database", "database", name)
+		dropped = append(dropped, name)
+	}
+	return dropped, errors.Join(errs...)
+}
@@ -0,0 +1,165 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEphemeralDatabaseName(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.FixedZone("PDT", -7*3600))
+
+	// Runs started in the same second still get their own database. The suffix has 24
+	// random bits, so a few runs collide about once in 10^5 test runs; a thousand would
+	// in a few percent.
+	seen := make(map[string]bool)
+	for range 20 {
+		name, err := NewEphemeralDatabaseName("hotels-ci-", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[name] {
+			t.Fatalf("name %s was generated twice", name)
+		}
+		seen[name] = true
+
+		if !regexp.MustCompile(`^hotels-ci-20261016t163000-[0-9a-f]{6}$`).MatchString(name) {
+			t.Fatalf("name = %s, want the prefix, the UTC time, and a random suffix", name)
+		}
+		if created, ok := ephemeralCreated(name, "hotels-ci-"); !ok || !created.Equal(now) {
+			t.Fatalf("ephemeralCreated(%s) = %v, %v, want %v", name, created, ok, now)
+		}
+	}
+}
+
+func TestNewEphemeralDatabaseNameInvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "hotels.ci-", "hotels ci-", "ci/", strings.Repeat("x", 50)} {
+		if _, err := NewEphemeralDatabaseName(prefix, time.Now()); err == nil || !strings.Contains(err.Error(), "invalid EPHEMERAL_DATABASE_PREFIX") {
+			t.Errorf("prefix %q: err = %v, want it rejected", prefix, err)
+		}
+	}
+}
+
+func TestExpiredEphemeral(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	names := []string{
+		"hotels-ci-20261015t110000-0a1b2c", // 25h old
+		"hotels-ci-20261016t110000-3d4e5f", // 1h old
+		"hotels-ci-20261014t120000-6a7b8c", // 2 days old
+		"Hotels",                           // the configured database
+		"hotels-ci-shared",                 // only shares the prefix
+		"hotels-ci-20261001t000000-zzzzzz", // suffix isn't hex
+		"other-20261001t000000-0a1b2c",     // another prefix
+	}
+
+	tests := []struct {
+		olderThan time.Duration
+		want      []string
+	}{
+		{24 * time.Hour, []string{"hotels-ci-20261015t110000-0a1b2c", "hotels-ci-20261014t120000-6a7b8c"}},
+		{30 * time.Minute, []string{"hotels-ci-20261015t110000-0a1b2c", "hotels-ci-20261016t110000-3d4e5f", "hotels-ci-20261014t120000-6a7b8c"}},
+		{72 * time.Hour, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.olderThan.String(), func(t *testing.T) {
+			if got := expiredEphemeral(names, "hotels-ci-", tt.olderThan, now); !slices.Equal(got, tt.want) {
+				t.Errorf("expired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeAdmin is a cluster holding databases
+type fakeAdmin struct {
+	databases []string
+	dropped   []string
+	dropErr   map[string]error
+}
+
+func (f *fakeAdmin) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	return f.databases, nil
+}
+
+func (f *fakeAdmin) DropDatabase(ctx context.Context, name string) error {
+	if err := f.dropErr[name]; err != nil {
+		return err
+	}
+	f.dropped = append(f.dropped, name)
+	return nil
+}
+
+func TestCleanupEphemeral(t *testing.T) {
+	old, _ := NewEphemeralDatabaseName("hotels-ci-", time.Now().Add(-48*time.Hour))
+	older, _ := NewEphemeralDatabaseName("hotels-ci-", time.Now().Add(-72*time.Hour))
+	recent, _ := NewEphemeralDatabaseName("hotels-ci-", time.Now().Add(-time.Hour))
+	databases := []string{"Hotels", old, recent, older}
+
+	t.Run("drops the expired databases", func(t *testing.T) {
+		admin := &fakeAdmin{databases: databases}
+		var shown []string
+		dropped, err := CleanupEphemeral(context.Background(), admin, "hotels-ci-", 24*time.Hour, func(expired []string) (bool, error) {
+			shown = expired
+			return true, nil
+		})
+		want := []string{old, older}
+		if err != nil || !slices.Equal(dropped, want) || !slices.Equal(admin.dropped, want) || !slices.Equal(shown, want) {
+			t.Errorf("dropped %v (%v), admin dropped %v, confirm shown %v; want %v", dropped, err, admin.dropped, shown, want)
+		}
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		admin := &fakeAdmin{databases: databases}
+		dropped, err := CleanupEphemeral(context.Background(), admin, "hotels-ci-", 24*time.Hour, func([]string) (bool, error) { return false, nil })
+		if err != nil || dropped != nil || admin.dropped != nil {
+			t.Errorf("dropped %v (%v), admin dropped %v; want nothing", dropped, err, admin.dropped)
+		}
+	})
+
+	t.Run("failed drop", func(t *testing.T) {
+		errDenied := errors.New("not authorized")
+		admin := &fakeAdmin{databases: databases, dropErr: map[string]error{old: errDenied}}
+		dropped, err := CleanupEphemeral(context.Background(), admin, "hotels-ci-", 24*time.Hour, nil)
+		if !errors.Is(err, errDenied) || !slices.Equal(dropped, []string{older}) {
+			t.Errorf("dropped %v (%v), want %s dropped and the error of the other", dropped, err, older)
+		}
+	})
+}
+
+func TestResolveEphemeralDatabase(t *testing.T) {
+	record := filepath.Join(t.TempDir(), ".ephemeral-database")
+	config := &VectorStoreConfig{Ephemeral: true, EphemeralPrefix: "hotels-ci-", EphemeralFile: record}
+
+	first, err := resolveEphemeralDatabase(config, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(record); err != nil || strings.TrimSpace(string(data)) != first {
+		t.Fatalf("record = %q, %v, want %s", data, err, first)
+	}
+
+	// Later commands of the run reuse the recorded database
+	if again, err := resolveEphemeralDatabase(config, time.Now()); err != nil || again != first {
+		t.Errorf("second resolve = %s, %v, want %s", again, err, first)
+	}
+
+	// Dropping it forgets the record, so the next run gets a new database
+	forgetEphemeralDatabase(config)
+	if _, err := os.Stat(record); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("record still exists after forgetting: %v", err)
+	}
+
+	// Without a record file every store gets its own database
+	config.EphemeralFile = ""
+	a, _ := resolveEphemeralDatabase(config, time.Now())
+	b, _ := resolveEphemeralDatabase(config, time.Now())
+	if a == b {
+		t.Errorf("resolved %s twice without a record file", a)
+	}
+}
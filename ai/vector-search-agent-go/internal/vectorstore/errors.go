@@ -0,0 +1,143 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/auth"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// ErrMissingConfig is wrapped by errors about required settings that are not set
+var ErrMissingConfig = errors.New("missing configuration")
+
+// ErrInvalidData is wrapped by errors about malformed or inconsistent input data
+var ErrInvalidData = errors.New("invalid data")
+
+// ErrIndexMissing is wrapped by errors about a vector search of a field that has no
+// vector index, when it can't be answered by the exact-search fallback instead
+var ErrIndexMissing = errors.New("vector index missing")
+
+// ErrDimensionMismatch is wrapped by errors about vectors whose number of dimensions
+// differs from EMBEDDING_DIMENSIONS or from the vector index
+var ErrDimensionMismatch = errors.New("vector dimensions don't match")
+
+// indexMissingMessages are fragments of the server errors of a vector search on a field
+// without a vector index. DocumentDB reports it as a failed search, not by an error code
+// of its own. Each fragment names the vector (similarity) index, so errors about other
+// missing indexes, such as an unknown hint or TTL index, don't match.
+var indexMissingMessages = []string{
+	"similarity index was not found",
+	"no similarity index",
+	"vector index was not found",
+	"no vector index",
+}
+
+// codeIndexNotFound is the server's code for a named index that doesn't exist, as when
+// dropping, modifying, or hinting it; a vector search never fails with it
+const codeIndexNotFound = 27
+
+// IsIndexMissingError reports whether err is the server's error for a vector search of
+// a field that has no vector index
+func IsIndexMissingError(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) || serverErr.HasErrorCode(codeIndexNotFound) {
+		return false
+	}
+	message := strings.ToLower(serverErr.Error())
+	for _, fragment := range indexMissingMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDimensionMismatchError reports whether err is about vectors of the wrong number of
+// dimensions: ErrDimensionMismatch, or a server error of a search or an index whose
+// vectors don't have the dimensions it expects
+func IsDimensionMismatchError(err error) bool {
+	if errors.Is(err, ErrDimensionMismatch) {
+		return true
+	}
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && strings.Contains(strings.ToLower(serverErr.Error()), "dimension")
+}
+
+// MongoDB server error codes for authorization failures
+const (
+	codeUnauthorized         = 13
+	codeAuthenticationFailed = 18
+)
+
+// IsAuthError reports whether err means the credentials were rejected or could not be obtained
+func IsAuthError(err error) bool {
+	if errors.Is(err, ErrSourceUnauthorized) {
+		return true
+	}
+
+	var authErr *auth.Error
+	if errors.As(err, &authErr) {
+		return true
+	}
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == codeUnauthorized || cmdErr.Code == codeAuthenticationFailed) {
+		return true
+	}
+
+	var azErr *azidentity.AuthenticationFailedError
+	return errors.As(err, &azErr)
+}
+
+// IsConnectivityError reports whether err means the cluster could not be reached:
+// a network failure, a timeout, or no server available for selection
+func IsConnectivityError(err error) bool {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var selErr topology.ServerSelectionError
+	return errors.As(err, &selErr)
+}
+
+// IsUnreachableError reports whether err means no connection to the cluster could be
+// made at all: no server available for selection, or a network failure. Unlike
+// IsConnectivityError, it doesn't include timeouts of operations on a working connection.
+func IsUnreachableError(err error) bool {
+	var selErr topology.ServerSelectionError
+	return errors.As(err, &selErr) || mongo.IsNetworkError(err)
+}
+
+// Error classes reported by ErrorClass
+const (
+	ErrorClassAuth         = "auth"
+	ErrorClassConnectivity = "connectivity"
+	ErrorClassDuplicateKey = "duplicate_key"
+	ErrorClassCanceled     = "canceled"
+	ErrorClassServer       = "server"
+	ErrorClassOther        = "other"
+)
+
+// ErrorClass returns the class of a failed DocumentDB operation's error, for metrics:
+// rejected credentials, an unreachable cluster, a duplicate key, a canceled operation,
+// another server error, or anything else
+func ErrorClass(err error) string {
+	var serverErr mongo.ServerError
+	switch {
+	case IsAuthError(err):
+		return ErrorClassAuth
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case IsConnectivityError(err):
+		return ErrorClassConnectivity
+	case mongo.IsDuplicateKeyError(err):
+		return ErrorClassDuplicateKey
+	case errors.As(err, &serverErr):
+		return ErrorClassServer
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,136 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/auth"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"handshake", fmt.Errorf("failed to ping: %w", &auth.Error{}), true},
+		{"authentication failed", mongo.CommandError{Code: codeAuthenticationFailed}, true},
+		{"unauthorized", fmt.Errorf("insert: %w", mongo.CommandError{Code: codeUnauthorized}), true},
+		{"token", fmt.Errorf("oidc: %w", &azidentity.AuthenticationFailedError{}), true},
+		{"other command error", mongo.CommandError{Code: 2}, false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsAuthError(tt.err); got != tt.want {
+			t.Errorf("%s: IsAuthError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsConnectivityError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server selection", fmt.Errorf("failed to ping: %w", topology.ServerSelectionError{}), true},
+		{"network", mongo.CommandError{Labels: []string{"NetworkError"}}, true},
+		{"timeout", fmt.Errorf("find: %w", mongo.CommandError{Labels: []string{"NetworkError"}, Wrapped: errors.New("i/o timeout")}), true},
+		{"auth", mongo.CommandError{Code: codeAuthenticationFailed}, false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsConnectivityError(tt.err); got != tt.want {
+			t.Errorf("%s: IsConnectivityError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"auth", mongo.CommandError{Code: codeAuthenticationFailed}, ErrorClassAuth},
+		{"server selection", fmt.Errorf("vector search failed: %w", topology.ServerSelectionError{}), ErrorClassConnectivity},
+		{"duplicate key", mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}, ErrorClassDuplicateKey},
+		{"canceled", fmt.Errorf("insert: %w", context.Canceled), ErrorClassCanceled},
+		{"command", mongo.CommandError{Code: 2, Message: "bad $search"}, ErrorClassServer},
+		{"decode", errors.New("failed to decode result"), ErrorClassOther},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.want {
+			t.Errorf("%s: ErrorClass = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsIndexMissingError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"index not found", fmt.Errorf("vector search failed: %w", errNoIndex), true},
+		{"documentdb", mongo.CommandError{Code: 2, Message: "Similarity index was not found for a vector similarity search query."}, true},
+		{"other command error", mongo.CommandError{Code: 2, Message: "$vectorSearch.k must be positive"}, false},
+		{"auth", mongo.CommandError{Code: codeAuthenticationFailed}, false},
+		{"plain", errors.New("similarity index was not found"), false},
+		// Other missing indexes must not send a search to the exact fallback
+		{"dropped ttl index", mongo.CommandError{Code: codeIndexNotFound, Name: "IndexNotFound", Message: "index not found with name [createdAt_ttl]"}, false},
+		{"unknown hint", mongo.CommandError{Code: 2, Message: "error processing query: planner returned error :: caused by :: hint provided does not correspond to an existing index"}, false},
+		{"secondary index", mongo.CommandError{Code: 2, Message: "index not found: HotelId_1"}, false},
+		{"unindexed path", mongo.CommandError{Code: 2, Message: "Path not indexed: Rating"}, false},
+		{"index not found code", mongo.CommandError{Code: codeIndexNotFound, Message: "no vector index named vectorIndex"}, false},
+	}
+	for _, tt := range tests {
+		if got := IsIndexMissingError(tt.err); got != tt.want {
+			t.Errorf("%s: IsIndexMissingError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsDimensionMismatchError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"validation", ValidateVectorDimensions(models.Hotel{HotelID: "2", DescriptionVector: make([]float32, 3)}, 4), true},
+		{"search", fmt.Errorf("vector search failed: %w", mongo.CommandError{Code: 2, Message: "Query vector has 768 dimensions, but the index expects 1536"}), true},
+		{"other command error", mongo.CommandError{Code: 2, Message: "$vectorSearch.k must be positive"}, false},
+		{"other invalid data", fmt.Errorf("%w: hotel 2 has no description", ErrInvalidData), false},
+		{"plain", errors.New("wrong dimensions"), false},
+	}
+	for _, tt := range tests {
+		if got := IsDimensionMismatchError(tt.err); got != tt.want {
+			t.Errorf("%s: IsDimensionMismatchError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsUnreachableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server selection", fmt.Errorf("failed to ping: %w", topology.ServerSelectionError{}), true},
+		{"network", mongo.CommandError{Labels: []string{"NetworkError"}}, true},
+		{"deadline", fmt.Errorf("find: %w", context.DeadlineExceeded), false},
+		{"auth", mongo.CommandError{Code: codeAuthenticationFailed}, false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsUnreachableError(tt.err); got != tt.want {
+			t.Errorf("%s: IsUnreachableError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
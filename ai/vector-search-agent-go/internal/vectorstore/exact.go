@@ -0,0 +1,97 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Defaults of the exact-search fallback, used when DEGRADE_TO_EXACT and
+// EXACT_SEARCH_MAX_DOCUMENTS are unset
+const (
+	DefaultDegradeToExact          = true
+	DefaultExactSearchMaxDocuments = 10000
+)
+
+// ExactSearch returns the k hotels whose EmbeddedField vector is closest to queryVector,
+// comparing every stored vector with the similarity of the store's VECTOR_SIMILARITY. It
+// needs no vector index but reads the whole collection, so it suits small collections
+// and measuring the recall of the index.
+func (vs *VectorStore) ExactSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	scored, err := vs.exactSearch(ctx, vs.config.EmbeddedField, queryVector, k)
+	if err != nil {
+		return nil, err
+	}
+	return hotelResults(scored)
+}
+
+// exactSearch returns the k documents of the collection whose vector in field is
+// closest to queryVector, closest first, scored like the search pipeline scores them
+func (vs *VectorStore) exactSearch(ctx context.Context, field string, queryVector []float32, k int) ([]scoredDocument, error) {
+	k = ClampK(ctx, k)
+	cursor, err := vs.collection.Find(ctx, bson.D{{Key: field, Value: bson.D{{Key: "$exists", Value: true}}}})
+	if err != nil {
+		return nil, fmt.Errorf("exact search failed: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var corpus []bench.Doc
+	documents := make(map[string]bson.Raw)
+	for cursor.Next(ctx) {
+		// Ties are broken by _id, which every document has whatever its type
+		id := cursor.Current.Lookup("_id").String()
+		var vector []float32
+		if err := cursor.Current.Lookup(field).Unmarshal(&vector); err != nil {
+			return nil, fmt.Errorf("failed to decode %s of document %s: %w", field, id, err)
+		}
+		corpus = append(corpus, bench.Doc{ID: id, Vector: vector})
+		documents[id] = slices.Clone(cursor.Current)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	// Ties past the k-th are dropped: the search returns k documents like the index does
+	neighbors := bench.ExactScored(queryVector, corpus, k, vs.config.Similarity)
+	scored := make([]scoredDocument, 0, k)
+	for _, n := range neighbors[:min(k, len(neighbors))] {
+		scored = append(scored, scoredDocument{Score: n.Score, Document: documents[n.ID], Provenance: models.ProvenanceExact})
+	}
+	return scored, nil
+}
+
+// exactSearcher is the part of the store the exact-search fallback uses
+type exactSearcher interface {
+	CountDocuments(ctx context.Context) (int64, error)
+	exactSearch(ctx context.Context, field string, queryVector []float32, k int) ([]scoredDocument, error)
+}
+
+// searchWithoutIndex answers a vector search of field that failed with searchErr
+// because the field has no vector index. With config.DegradeToExact it runs the search as an
+// exact search, warning in the log and noting it on the trace for the result envelope.
+// Without it, or when the collection is larger than ExactSearchMaxDocuments, it returns
+// an ErrIndexMissing error that says to create the index.
+func searchWithoutIndex(ctx context.Context, store exactSearcher, config *VectorStoreConfig, field string, queryVector []float32, k int, searchErr error) ([]scoredDocument, error) {
+	if !config.DegradeToExact {
+		return nil, fmt.Errorf("%w on %s: create it with cmd/upload, or set DEGRADE_TO_EXACT to search without it: %w", ErrIndexMissing, field, searchErr)
+	}
+	count, err := store.CountDocuments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w on %s, and the documents to search without it could not be counted: %w", ErrIndexMissing, field, err)
+	}
+	if count > int64(config.ExactSearchMaxDocuments) {
+		return nil, fmt.Errorf("%w on %s: create it with cmd/upload; the collection's %d documents are more than EXACT_SEARCH_MAX_DOCUMENTS (%d) to search without it",
+			ErrIndexMissing, field, count, config.ExactSearchMaxDocuments)
+	}
+
+	slog.WarnContext(ctx, "VECTOR INDEX MISSING: searching every document instead, which is slower; create the index with cmd/upload",
+		"field", field, "documents", count)
+	trace.FromContext(ctx).Annotate(trace.EventExactFallback, fmt.Sprintf("no vector index on %s; compared all %d documents", field, count))
+	return store.exactSearch(ctx, field, queryVector, k)
+}
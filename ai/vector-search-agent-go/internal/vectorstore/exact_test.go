@@ -0,0 +1,146 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeExactSearcher is a collection of count documents that answers exact searches
+// with one hotel
+type fakeExactSearcher struct {
+	count    int64
+	countErr error
+	searched []string
+}
+
+func (f *fakeExactSearcher) CountDocuments(ctx context.Context) (int64, error) {
+	return f.count, f.countErr
+}
+
+func (f *fakeExactSearcher) exactSearch(ctx context.Context, field string, queryVector []float32, k int) ([]scoredDocument, error) {
+	f.searched = append(f.searched, field)
+	hotel, err := bson.Marshal(models.HotelForVectorStore{HotelID: "7"})
+	if err != nil {
+		return nil, err
+	}
+	return []scoredDocument{{Score: 0.9, Document: hotel, Provenance: models.ProvenanceExact}}, nil
+}
+
+// errNoIndex is the server's error for a search of a field without a vector index
+var errNoIndex = mongo.CommandError{Code: 2, Message: "$vectorSearch: similarity index was not found for path DescriptionVector"}
+
+func TestSearchWithoutIndexDegrades(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	tr := trace.New("session-1")
+	ctx := trace.WithTrace(context.Background(), tr)
+	store := &fakeExactSearcher{count: 50}
+	config := &VectorStoreConfig{DegradeToExact: true, ExactSearchMaxDocuments: 100}
+
+	results, err := searchWithoutIndex(ctx, store, config, "DescriptionVector", []float32{1, 0}, 3, errNoIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Provenance != models.ProvenanceExact || len(store.searched) != 1 || store.searched[0] != "DescriptionVector" {
+		t.Errorf("results = %+v after searching %v, want the exact search of DescriptionVector", results, store.searched)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "VECTOR INDEX MISSING") {
+		t.Errorf("log is missing the warning:\n%s", logs.String())
+	}
+	events := tr.Events()
+	if len(events) != 1 || events[0].Name != trace.EventExactFallback || events[0].Note != "no vector index on DescriptionVector; compared all 50 documents" {
+		t.Errorf("trace = %+v, want the exact fallback noted", events)
+	}
+}
+
+func TestSearchWithoutIndexRefuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		store  *fakeExactSearcher
+		config VectorStoreConfig
+		want   string
+	}{
+		{
+			name:   "collection too large",
+			store:  &fakeExactSearcher{count: 101},
+			config: VectorStoreConfig{DegradeToExact: true, ExactSearchMaxDocuments: 100},
+			want:   "create it with cmd/upload; the collection's 101 documents are more than EXACT_SEARCH_MAX_DOCUMENTS (100)",
+		},
+		{
+			name:   "degrading disabled",
+			store:  &fakeExactSearcher{count: 5},
+			config: VectorStoreConfig{DegradeToExact: false, ExactSearchMaxDocuments: 100},
+			want:   "create it with cmd/upload, or set DEGRADE_TO_EXACT",
+		},
+		{
+			name:   "count failed",
+			store:  &fakeExactSearcher{countErr: errors.New("connection reset")},
+			config: VectorStoreConfig{DegradeToExact: true, ExactSearchMaxDocuments: 100},
+			want:   "could not be counted: connection reset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := trace.New("session-1")
+			ctx := trace.WithTrace(context.Background(), tr)
+
+			results, err := searchWithoutIndex(ctx, tt.store, &tt.config, "DescriptionVector", []float32{1, 0}, 3, errNoIndex)
+			if !errors.Is(err, ErrIndexMissing) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want ErrIndexMissing saying %q", err, tt.want)
+			}
+			if results != nil || tt.store.searched != nil || len(tr.Events()) != 0 {
+				t.Errorf("results = %+v, searched %v, trace %+v; want no search", results, tt.store.searched, tr.Events())
+			}
+		})
+	}
+}
+
+func TestHotelResults(t *testing.T) {
+	scored := []scoredDocument{
+		searchResult(t, models.HotelForVectorStore{HotelID: "3", HotelName: "Harbor Inn"}, 0.9),
+		searchResult(t, models.HotelForVectorStore{HotelID: "1", HotelName: "Pool Hotel"}, 0.7),
+	}
+	scored[0].Provenance, scored[1].Provenance = models.ProvenanceExact, models.ProvenanceExact
+
+	results, err := hotelResults(scored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Hotel.HotelName != "Harbor Inn" || results[0].Rank != 1 || results[1].Rank != 2 ||
+		results[1].RawScore != 0.7 || results[1].Provenance != models.ProvenanceExact {
+		t.Errorf("results = %+v, want both hotels ranked in order with exact scores", results)
+	}
+
+	if _, err := hotelResults([]scoredDocument{{Score: 1, Document: bson.Raw{0x05}}}); err == nil || !strings.Contains(err.Error(), "failed to decode result") {
+		t.Errorf("err = %v, want a decode error", err)
+	}
+}
+
+func TestExactFallbackConfigFromEnv(t *testing.T) {
+	t.Setenv("DEGRADE_TO_EXACT", "")
+	t.Setenv("EXACT_SEARCH_MAX_DOCUMENTS", "")
+	t.Setenv("VECTOR_SIMILARITY", "")
+	config := LoadConfigFromEnv()
+	if !config.DegradeToExact || config.ExactSearchMaxDocuments != DefaultExactSearchMaxDocuments || config.Similarity != "COS" {
+		t.Errorf("defaults: DegradeToExact = %v, ExactSearchMaxDocuments = %d, Similarity = %q", config.DegradeToExact, config.ExactSearchMaxDocuments, config.Similarity)
+	}
+
+	t.Setenv("DEGRADE_TO_EXACT", "false")
+	t.Setenv("EXACT_SEARCH_MAX_DOCUMENTS", "500")
+	t.Setenv("VECTOR_SIMILARITY", "IP")
+	config = LoadConfigFromEnv()
+	if config.DegradeToExact || config.ExactSearchMaxDocuments != 500 || config.Similarity != "IP" {
+		t.Errorf("from env: DegradeToExact = %v, ExactSearchMaxDocuments = %d, Similarity = %q", config.DegradeToExact, config.ExactSearchMaxDocuments, config.Similarity)
+	}
+}
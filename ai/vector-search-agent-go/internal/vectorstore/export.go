@@ -0,0 +1,53 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExportOptions controls which fields ExportHotels reads
+type ExportOptions struct {
+	// IncludeVectors keeps the embedded field; when false it is projected out server-side
+	IncludeVectors bool
+}
+
+// ExportHotels streams every hotel in the collection to fn in HotelId order and returns
+// the number exported. Documents are decoded one at a time from the cursor, so memory
+// stays flat regardless of collection size. Iteration stops at the first error from fn.
+func (vs *VectorStore) ExportHotels(ctx context.Context, opts ExportOptions, fn func(models.HotelForVectorStore) error) (int, error) {
+	findOpts := options.Find().SetSort(bson.D{{Key: "HotelId", Value: 1}})
+	if !opts.IncludeVectors {
+		findOpts.SetProjection(bson.D{{Key: vs.config.EmbeddedField, Value: 0}})
+	}
+
+	cursor, err := vs.collection.Find(ctx, bson.D{}, findOpts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query collection: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var hotel models.HotelForVectorStore
+		if err := cursor.Decode(&hotel); err != nil {
+			return count, fmt.Errorf("failed to decode document: %w", err)
+		}
+		if err := fn(hotel); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := cursor.Err(); err != nil {
+		return count, fmt.Errorf("cursor error: %w", err)
+	}
+
+	slog.InfoContext(ctx, "exported documents", "collection", vs.config.CollectionName, "count", count)
+
+	return count, nil
+}
@@ -0,0 +1,52 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultFeedbackCollection is used when FEEDBACK_COLLECTION is unset
+const DefaultFeedbackCollection = "feedback"
+
+// SaveFeedback records feedback for an answer, replacing the rating and comment of any
+// earlier feedback for the same result ID. It reports whether a new record was created.
+// CreatedAt is kept from the first record; UpdatedAt is set to now.
+func (vs *VectorStore) SaveFeedback(ctx context.Context, feedback models.Feedback) (bool, error) {
+	if err := feedback.Validate(); err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+
+	coll := vs.database.Collection(vs.config.FeedbackCollection)
+	result, err := coll.UpdateOne(ctx, bson.D{{Key: "resultId", Value: feedback.ResultID}}, feedbackUpdate(feedback, time.Now().UTC()), options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("failed to save feedback: %w", err)
+	}
+
+	created := result.UpsertedCount > 0
+	slog.InfoContext(ctx, "saved feedback", "collection", vs.config.FeedbackCollection, "resultId", feedback.ResultID, "rating", feedback.Rating, "created", created)
+
+	return created, nil
+}
+
+// feedbackUpdate returns the upsert that records feedback at now
+func feedbackUpdate(feedback models.Feedback, now time.Time) bson.D {
+	set := bson.D{
+		{Key: "rating", Value: feedback.Rating},
+		{Key: "comment", Value: feedback.Comment},
+		{Key: "updatedAt", Value: now},
+	}
+	// An update without a session ID keeps the one already linked
+	if feedback.SessionID != "" {
+		set = append(set, bson.E{Key: "sessionId", Value: feedback.SessionID})
+	}
+	return bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$setOnInsert", Value: bson.D{{Key: "createdAt", Value: now}}},
+	}
+}
@@ -0,0 +1,62 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFeedbackUpdate(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		feedback models.Feedback
+		wantSet  bson.D
+	}{
+		{
+			"with session",
+			models.Feedback{ResultID: "r", SessionID: "session-1", Rating: models.RatingUp, Comment: "great"},
+			bson.D{{Key: "rating", Value: "up"}, {Key: "comment", Value: "great"}, {Key: "updatedAt", Value: now}, {Key: "sessionId", Value: "session-1"}},
+		},
+		{
+			// The session linked by earlier feedback is kept
+			"without session",
+			models.Feedback{ResultID: "r", Rating: models.RatingDown},
+			bson.D{{Key: "rating", Value: "down"}, {Key: "comment", Value: ""}, {Key: "updatedAt", Value: now}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bson.MarshalExtJSON(feedbackUpdate(tt.feedback, now), false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := bson.MarshalExtJSON(bson.D{
+				{Key: "$set", Value: tt.wantSet},
+				{Key: "$setOnInsert", Value: bson.D{{Key: "createdAt", Value: now}}},
+			}, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("update = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSaveFeedbackRejectsInvalidFeedback(t *testing.T) {
+	// Validation runs before the database is touched
+	vs := &VectorStore{config: &VectorStoreConfig{FeedbackCollection: DefaultFeedbackCollection}}
+
+	_, err := vs.SaveFeedback(context.Background(), models.Feedback{ResultID: "not-a-uuid", Rating: "up"})
+	if !errors.Is(err, ErrInvalidData) {
+		t.Errorf("err = %v, want ErrInvalidData", err)
+	}
+}
@@ -0,0 +1,153 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// Formatter output styles
+const (
+	// StyleText writes "Name: value" lines between START and END markers
+	StyleText = "text"
+	// StyleJSON writes one compact JSON object per result
+	StyleJSON = "json"
+)
+
+// Formatter renders search results for the synthesizer. The zero Formatter writes every
+// display field as text in the document's FieldOrder, which is the layout
+// FormatHotelForSynthesizer has always produced.
+type Formatter struct {
+	// Fields, when set, lists the display fields to show, in the order to show them.
+	// Names the document doesn't have are skipped.
+	Fields []string
+	// Exclude lists display fields to leave out, such as IsDeleted or Address.PostalCode
+	Exclude []string
+	// Style is StyleText or StyleJSON; empty means StyleText
+	Style string
+}
+
+// ParseFormatterStyle returns the Formatter style named by s, case-insensitively, and
+// reports whether it is one
+func ParseFormatterStyle(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", StyleText:
+		return StyleText, true
+	case StyleJSON:
+		return StyleJSON, true
+	}
+	return "", false
+}
+
+// FormatHotel formats a hotel result for the synthesizer
+func (f Formatter) FormatHotel(result models.HotelSearchResult) string {
+	return f.format("HOTEL", &result.Hotel, resultScore{score: result.Score, rank: result.Rank, provenance: result.Provenance})
+}
+
+// FormatDocument formats a search result of any document type for the synthesizer
+func (f Formatter) FormatDocument(result models.DocumentSearchResult) string {
+	return f.format("DOCUMENT", result.Document, resultScore{score: result.Score})
+}
+
+// resultScore is the score of a result and, for ranked hotel results, its rank and
+// provenance
+type resultScore struct {
+	score      float64
+	rank       int
+	provenance string
+}
+
+// format writes doc's selected display fields and score in the formatter's style. Text
+// blocks are delimited by start and end markers named after kind; JSON objects keep
+// the field order, so the same results always give the same prompt. The score comes
+// after the fields, followed by the rank and provenance when the result has them.
+func (f Formatter) format(kind string, doc models.Document, score resultScore) string {
+	fields := doc.DisplayFields()
+	names := f.fieldNames(doc, fields)
+
+	if f.Style == StyleJSON {
+		var b strings.Builder
+		b.WriteByte('{')
+		for _, name := range names {
+			writeJSONString(&b, name)
+			b.WriteByte(':')
+			writeJSONString(&b, fields[name])
+			b.WriteByte(',')
+		}
+		b.WriteString(`"Score":`)
+		b.WriteString(strconv.FormatFloat(score.score, 'f', 6, 64))
+		if score.rank > 0 {
+			b.WriteString(`,"Rank":`)
+			b.WriteString(strconv.Itoa(score.rank))
+		}
+		if score.provenance != "" {
+			b.WriteString(`,"Provenance":`)
+			writeJSONString(&b, score.provenance)
+		}
+		b.WriteByte('}')
+		return b.String()
+	}
+
+	lines := []string{"--- " + kind + " START ---"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, fields[name]))
+	}
+	lines = append(lines, fmt.Sprintf("Score: %.6f", score.score))
+	if score.rank > 0 {
+		lines = append(lines, fmt.Sprintf("Rank: %d", score.rank))
+	}
+	if score.provenance != "" {
+		lines = append(lines, "Provenance: "+score.provenance)
+	}
+	lines = append(lines, "--- "+kind+" END ---")
+	return strings.Join(lines, "\n")
+}
+
+// fieldNames returns the names of the fields to show, in order. Without Fields, they
+// come in the document's FieldOrder, when it has one, and then by name.
+func (f Formatter) fieldNames(doc models.Document, fields map[string]string) []string {
+	var order []string
+	if f.Fields != nil {
+		for _, name := range f.Fields {
+			if _, ok := fields[name]; ok && !slices.Contains(order, name) {
+				order = append(order, name)
+			}
+		}
+	} else {
+		if orderer, ok := doc.(models.FieldOrderer); ok {
+			for _, name := range orderer.FieldOrder() {
+				if _, ok := fields[name]; ok {
+					order = append(order, name)
+				}
+			}
+		}
+		var rest []string
+		for name := range fields {
+			if !slices.Contains(order, name) {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		order = append(order, rest...)
+	}
+
+	return slices.DeleteFunc(order, func(name string) bool {
+		return slices.Contains(f.Exclude, name)
+	})
+}
+
+// writeJSONString writes s as a JSON string without escaping HTML characters, which
+// the model reads as they are
+func writeJSONString(b *strings.Builder, s string) {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// Encoding a string cannot fail
+	_ = enc.Encode(s)
+	b.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+}
@@ -0,0 +1,179 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// formatFixture is a representative hotel with every field set, and a sparse one with
+// empty Tags, a zero LastRenovationDate, and quotes in its name
+func formatFixture() []models.HotelSearchResult {
+	return []models.HotelSearchResult{
+		{
+			Hotel: models.HotelForVectorStore{
+				HotelID:            "1",
+				HotelName:          "Stay-Kay City Hotel",
+				Description:        "This classic hotel is fully-refurbished and ideally located on the main commercial artery of the city in the heart of New York.",
+				Category:           "Boutique",
+				Tags:               []string{"view", "air conditioning", "concierge"},
+				LastRenovationDate: time.Date(2022, 1, 18, 0, 0, 0, 0, time.UTC),
+				Rating:             3.6,
+				Address:            models.Address{StreetAddress: "677 5th Ave", City: "New York", StateProvince: "NY", PostalCode: "10022", Country: "USA"},
+				Location:           models.NewGeoJSONPoint(-73.975403, 40.760586),
+				Rooms:              &models.RoomSummary{Count: 3, MinRate: 80.99, MaxRate: 150.99, Types: []string{"Budget Room", "Deluxe Room"}},
+			},
+			Score: 0.9123456,
+		},
+		{
+			Hotel: models.HotelForVectorStore{
+				HotelID:         "2",
+				HotelName:       `Old Century "Plaza" Hotel`,
+				Description:     "A nineteenth century plaza.",
+				Category:        "Luxury",
+				ParkingIncluded: true,
+				Rating:          4,
+			},
+			Score: 0.5,
+		},
+	}
+}
+
+// rankedFixture is formatFixture as a fused search returns it, ranked and with
+// provenance
+func rankedFixture() []models.HotelSearchResult {
+	results := formatFixture()
+	for i := range results {
+		results[i].RawScore = results[i].Score
+		results[i].Provenance = models.ProvenanceFused
+	}
+	models.RankResults(results)
+	return results
+}
+
+// longFixture is a hotel whose description runs to several paragraphs, with line breaks
+// and characters JSON escapes, as generated or scraped descriptions do
+func longFixture() []models.HotelSearchResult {
+	description := strings.Repeat("Set among quiet gardens, the lodge offers spacious suites, a heated pool & spa, and views of the valley. ", 8) +
+		"\n\nGuests say: \"the <best> breakfast in town\"\tand free parking."
+	return []models.HotelSearchResult{{
+		Hotel: models.HotelForVectorStore{
+			HotelID:     "3",
+			HotelName:   "Valley Lodge",
+			Description: description,
+			Category:    "Resort and Spa",
+			Tags:        []string{},
+			Rating:      0,
+		},
+		Score: 0,
+	}}
+}
+
+// formatAll formats results with f, separated by blank lines as the search tool joins them
+func formatAll(f Formatter, results []models.HotelSearchResult) string {
+	formatted := make([]string, len(results))
+	for i, result := range results {
+		formatted[i] = f.FormatHotel(result)
+	}
+	return strings.Join(formatted, "\n\n") + "\n"
+}
+
+// assertGolden compares got with testdata/format/name, rewriting the file when -update is set
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "format", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s does not match the formatted hotels; run go test -update and review the diff\ngot:\n%s", path, got)
+	}
+}
+
+func TestFormatterGolden(t *testing.T) {
+	noisy := []string{"IsDeleted", "Address.PostalCode"}
+	tests := []struct {
+		golden    string
+		formatter Formatter
+		results   func() []models.HotelSearchResult
+	}{
+		// For unranked results the default must stay byte for byte what
+		// FormatHotelForSynthesizer wrote before the Formatter existed
+		{"hotels_text.golden", Formatter{}, formatFixture},
+		{"hotels_json.golden", Formatter{Style: StyleJSON}, formatFixture},
+		{"hotels_text_selected.golden", Formatter{Fields: []string{"HotelName", "Rating", "Tags", "Address.City", "Missing"}}, formatFixture},
+		{"hotels_json_excluded.golden", Formatter{Style: StyleJSON, Exclude: noisy}, formatFixture},
+		{"hotels_text_ranked.golden", Formatter{}, rankedFixture},
+		{"hotels_json_ranked.golden", Formatter{Style: StyleJSON}, rankedFixture},
+		{"hotels_long_text.golden", Formatter{}, longFixture},
+		{"hotels_long_json.golden", Formatter{Style: StyleJSON}, longFixture},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got := formatAll(tt.formatter, tt.results())
+			assertGolden(t, tt.golden, got)
+			// Formatting is deterministic, so prompts are reproducible
+			if again := formatAll(tt.formatter, tt.results()); again != got {
+				t.Errorf("second run differs:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatHotelForSynthesizerIsTheDefaultFormatter(t *testing.T) {
+	for _, result := range formatFixture() {
+		if got, want := FormatHotelForSynthesizer(result), (Formatter{}).FormatHotel(result); got != want {
+			t.Errorf("FormatHotelForSynthesizer =\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestFormatterJSONIsValid(t *testing.T) {
+	f := Formatter{Style: StyleJSON, Exclude: []string{"IsDeleted"}}
+	for _, result := range formatFixture() {
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(f.FormatHotel(result)), &decoded); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, f.FormatHotel(result))
+		}
+		if score, ok := decoded["Score"].(float64); decoded["HotelName"] != result.Hotel.HotelName || !ok || math.Abs(score-result.Score) > 1e-6 {
+			t.Errorf("decoded = %v, want HotelName %q and Score %v", decoded, result.Hotel.HotelName, result.Score)
+		}
+		if _, ok := decoded["IsDeleted"]; ok {
+			t.Error("excluded field IsDeleted is present")
+		}
+	}
+}
+
+func TestParseFormatterStyle(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"", StyleText, true},
+		{"text", StyleText, true},
+		{" JSON ", StyleJSON, true},
+		{"yaml", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseFormatterStyle(tt.in); got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseFormatterStyle(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
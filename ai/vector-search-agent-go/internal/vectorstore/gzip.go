@@ -0,0 +1,81 @@
+package vectorstore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompress returns r unchanged unless name ends in .gz or the data starts with the
+// gzip magic bytes, in which case it returns a reader that decompresses r as it is
+// read. shown names the source in errors. Closing the result closes r.
+func decompress(r io.ReadCloser, name, shown string) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		r.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", shown, err)
+	}
+
+	if !strings.HasSuffix(strings.ToLower(name), ".gz") && !bytes.Equal(magic, gzipMagic) {
+		return &bufferedSource{Reader: buffered, closer: r}, nil
+	}
+
+	zr, err := gzip.NewReader(buffered)
+	if err != nil {
+		r.Close()
+		return nil, gzipError(shown, err)
+	}
+	return &gzipSource{zr: zr, closer: r, shown: shown}, nil
+}
+
+// bufferedSource reads through the buffer that was peeked into
+type bufferedSource struct {
+	*bufio.Reader
+	closer io.Closer
+}
+
+// Close closes the underlying source
+func (b *bufferedSource) Close() error {
+	return b.closer.Close()
+}
+
+// gzipSource decompresses a source as it is read
+type gzipSource struct {
+	zr     *gzip.Reader
+	closer io.Closer
+	shown  string
+}
+
+// Read decompresses the next bytes
+func (g *gzipSource) Read(p []byte) (int, error) {
+	n, err := g.zr.Read(p)
+	if err != nil && err != io.EOF {
+		return n, gzipError(g.shown, err)
+	}
+	return n, err
+}
+
+// Close releases the decompressor and closes the underlying source
+func (g *gzipSource) Close() error {
+	g.zr.Close()
+	return g.closer.Close()
+}
+
+// gzipError names the source of a corrupt or truncated archive. Errors from reading the
+// source itself, such as a failed download, are returned as they are.
+func gzipError(shown string, err error) error {
+	var corrupt flate.CorruptInputError
+	if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &corrupt) {
+		return fmt.Errorf("%w: %s is not a valid gzip archive: %w", ErrInvalidData, shown, err)
+	}
+	return err
+}
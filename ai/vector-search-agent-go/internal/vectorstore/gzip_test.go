@@ -0,0 +1,94 @@
+package vectorstore
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyFile copies src into a temporary directory under the given name
+func copyFile(t *testing.T, src, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadHotelsFromGzip(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"gz suffix", "testdata/without_vectors.json.gz"},
+		{"magic bytes only", copyFile(t, "testdata/without_vectors.json.gz", "hotels.json")},
+		{"plain file", "testdata/without_vectors.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotels, err := LoadHotels(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hotels) != 2 || hotels[0].HotelID != "1" || hotels[1].HotelName != "Old Century Hotel" {
+				t.Errorf("hotels = %+v", hotels)
+			}
+		})
+	}
+}
+
+func TestLoadHotelsFromGzipURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		http.ServeFile(w, r, "testdata"+r.URL.Path)
+	}))
+	defer srv.Close()
+
+	hotels, err := LoadHotels(srv.URL + "/without_vectors.json.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 {
+		t.Errorf("hotels = %+v", hotels)
+	}
+
+	_, err = LoadHotels(srv.URL + "/truncated.json.gz?sig=secret")
+	if !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), srv.URL+"/truncated.json.gz") {
+		t.Errorf("truncated download err = %v, want ErrInvalidData naming the URL", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "secret") {
+		t.Errorf("err = %q leaks the query string", err)
+	}
+}
+
+func TestLoadHotelsFromCorruptGzip(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"truncated", "testdata/truncated.json.gz"},
+		{"not gzip", copyFile(t, "testdata/without_vectors.json", "hotels.json.gz")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadHotels(tt.file)
+			if !errors.Is(err, ErrInvalidData) {
+				t.Fatalf("err = %v, want ErrInvalidData", err)
+			}
+			if !strings.Contains(err.Error(), tt.file+" is not a valid gzip archive") {
+				t.Errorf("err = %q, want it to name %s", err, tt.file)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultHistoryCollection is used when HISTORY_COLLECTION is unset
+const DefaultHistoryCollection = "history"
+
+// HistoryFilter selects history records by creation time. Zero times leave that end of
+// the range open, and a zero Limit returns every match.
+type HistoryFilter struct {
+	From  time.Time // inclusive
+	To    time.Time // exclusive
+	Limit int
+}
+
+// InsertHistory stores one agent run in the history collection
+func (vs *VectorStore) InsertHistory(ctx context.Context, record models.HistoryRecord) error {
+	if _, err := vs.database.Collection(vs.config.HistoryCollection).InsertOne(ctx, record); err != nil {
+		return fmt.Errorf("failed to insert history record: %w", err)
+	}
+
+	slog.DebugContext(ctx, "inserted history record", "collection", vs.config.HistoryCollection, "resultId", record.ResultID)
+
+	return nil
+}
+
+// ListHistory returns the history records matching filter, newest first
+func (vs *VectorStore) ListHistory(ctx context.Context, filter HistoryFilter) ([]models.HistoryRecord, error) {
+	query, findOpts := historyQuery(filter)
+	cursor, err := vs.database.Collection(vs.config.HistoryCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var records []models.HistoryRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return records, nil
+}
+
+// historyQuery returns the query and find options that select filter, newest first
+func historyQuery(filter HistoryFilter) (bson.D, *options.FindOptions) {
+	createdAt := bson.D{}
+	if !filter.From.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$lt", Value: filter.To})
+	}
+	query := bson.D{}
+	if len(createdAt) > 0 {
+		query = bson.D{{Key: "createdAt", Value: createdAt}}
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	if filter.Limit > 0 {
+		findOpts.SetLimit(int64(filter.Limit))
+	}
+	return query, findOpts
+}
@@ -0,0 +1,60 @@
+package vectorstore
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestHistoryQuery(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		filter    HistoryFilter
+		wantQuery bson.D
+		wantLimit int64
+	}{
+		{"everything", HistoryFilter{}, bson.D{}, 0},
+		{"from", HistoryFilter{From: from}, bson.D{{Key: "createdAt", Value: bson.D{{Key: "$gte", Value: from}}}}, 0},
+		{"to", HistoryFilter{To: to}, bson.D{{Key: "createdAt", Value: bson.D{{Key: "$lt", Value: to}}}}, 0},
+		{
+			"range with limit",
+			HistoryFilter{From: from, To: to, Limit: 20},
+			bson.D{{Key: "createdAt", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}}},
+			20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, opts := historyQuery(tt.filter)
+
+			got, err := bson.MarshalExtJSON(query, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := bson.MarshalExtJSON(tt.wantQuery, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("query = %s, want %s", got, want)
+			}
+
+			var limit int64
+			if opts.Limit != nil {
+				limit = *opts.Limit
+			}
+			if limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d", limit, tt.wantLimit)
+			}
+			// Newest first
+			if sort, ok := opts.Sort.(bson.D); !ok || len(sort) != 1 || sort[0].Key != "createdAt" || sort[0].Value != -1 {
+				t.Errorf("sort = %v, want createdAt descending", opts.Sort)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Supported vector index algorithms
+const (
+	AlgorithmIVF     = "vector-ivf"
+	AlgorithmHNSW    = "vector-hnsw"
+	AlgorithmDiskANN = "vector-diskann"
+)
+
+// VectorIndexSpec describes the vector index to create. Only the parameters for
+// the chosen algorithm are used. The env tags describe the environment variable of each
+// field; see config.Variables.
+type VectorIndexSpec struct {
+	Algorithm  string `json:"algorithm" env:"VECTOR_INDEX_ALGORITHM" default:"vector-ivf" desc:"Vector index algorithm: vector-ivf, vector-hnsw, or vector-diskann"`
+	Dimensions int    `json:"dimensions" env:"EMBEDDING_DIMENSIONS" for:"embedding,vectorindex" format:"positive-int" default:"1536" desc:"Length of the stored vectors and of the vector index"`
+	Similarity string `json:"similarity" env:"VECTOR_SIMILARITY" default:"COS" desc:"Similarity of the vector index: COS, L2, or IP"`
+
+	NumLists       int `json:"numLists,omitempty" env:"IVF_NUM_LISTS" for:"vectorindex" format:"positive-int" default:"10" desc:"vector-ivf: number of clusters"`
+	M              int `json:"m,omitempty" env:"HNSW_M" for:"vectorindex" format:"positive-int" default:"16" desc:"vector-hnsw: connections per node"`
+	EfConstruction int `json:"efConstruction,omitempty" env:"HNSW_EF_CONSTRUCTION" for:"vectorindex" format:"positive-int" default:"64" desc:"vector-hnsw: candidates considered while building"`
+	MaxDegree      int `json:"maxDegree,omitempty" env:"DISKANN_MAX_DEGREE" for:"vectorindex" format:"positive-int" default:"20" desc:"vector-diskann: edges per node"`
+	LBuild         int `json:"lBuild,omitempty" env:"DISKANN_L_BUILD" for:"vectorindex" format:"positive-int" default:"10" desc:"vector-diskann: candidates considered while building"`
+}
+
+// IndexSpecFromEnv loads the index settings from the environment, with defaults
+// for anything unset or invalid
+func IndexSpecFromEnv() VectorIndexSpec {
+	spec := VectorIndexSpec{
+		Algorithm:      os.Getenv("VECTOR_INDEX_ALGORITHM"),
+		Dimensions:     EmbeddingDimensionsFromEnv(),
+		Similarity:     os.Getenv("VECTOR_SIMILARITY"),
+		NumLists:       envInt("IVF_NUM_LISTS", 10),
+		M:              envInt("HNSW_M", 16),
+		EfConstruction: envInt("HNSW_EF_CONSTRUCTION", 64),
+		MaxDegree:      envInt("DISKANN_MAX_DEGREE", 20),
+		LBuild:         envInt("DISKANN_L_BUILD", 10),
+	}
+	if spec.Algorithm == "" {
+		spec.Algorithm = AlgorithmIVF
+	}
+	if spec.Similarity == "" {
+		spec.Similarity = "COS"
+	}
+	return spec
+}
+
+// String describes the spec, including only the parameters its algorithm uses
+func (s VectorIndexSpec) String() string {
+	params := []string{
+		fmt.Sprintf("similarity=%s", s.Similarity),
+		fmt.Sprintf("dimensions=%d", s.Dimensions),
+	}
+	switch s.Algorithm {
+	case AlgorithmIVF:
+		params = append(params, fmt.Sprintf("numLists=%d", s.NumLists))
+	case AlgorithmHNSW:
+		params = append(params, fmt.Sprintf("m=%d", s.M), fmt.Sprintf("efConstruction=%d", s.EfConstruction))
+	case AlgorithmDiskANN:
+		params = append(params, fmt.Sprintf("maxDegree=%d", s.MaxDegree), fmt.Sprintf("lBuild=%d", s.LBuild))
+	}
+	return fmt.Sprintf("%s (%s)", s.Algorithm, strings.Join(params, ", "))
+}
+
+// EnvLines returns the NAME=value lines that make IndexSpecFromEnv return this spec's
+// algorithm, similarity, and the parameters its algorithm uses
+func (s VectorIndexSpec) EnvLines() []string {
+	lines := []string{
+		"VECTOR_INDEX_ALGORITHM=" + s.Algorithm,
+		"VECTOR_SIMILARITY=" + s.Similarity,
+	}
+	switch s.Algorithm {
+	case AlgorithmIVF:
+		lines = append(lines, fmt.Sprintf("IVF_NUM_LISTS=%d", s.NumLists))
+	case AlgorithmHNSW:
+		lines = append(lines, fmt.Sprintf("HNSW_M=%d", s.M), fmt.Sprintf("HNSW_EF_CONSTRUCTION=%d", s.EfConstruction))
+	case AlgorithmDiskANN:
+		lines = append(lines, fmt.Sprintf("DISKANN_MAX_DEGREE=%d", s.MaxDegree), fmt.Sprintf("DISKANN_L_BUILD=%d", s.LBuild))
+	}
+	return lines
+}
+
+// searchOptions builds the cosmosSearchOptions document for the spec
+func (s VectorIndexSpec) searchOptions() (bson.D, error) {
+	switch s.Algorithm {
+	case AlgorithmIVF:
+		return bson.D{
+			{Key: "kind", Value: AlgorithmIVF},
+			{Key: "numLists", Value: s.NumLists},
+			{Key: "dimensions", Value: s.Dimensions},
+			{Key: "similarity", Value: s.Similarity},
+		}, nil
+
+	case AlgorithmHNSW:
+		return bson.D{
+			{Key: "kind", Value: AlgorithmHNSW},
+			{Key: "m", Value: s.M},
+			{Key: "efConstruction", Value: s.EfConstruction},
+			{Key: "dimensions", Value: s.Dimensions},
+			{Key: "similarity", Value: s.Similarity},
+		}, nil
+
+	case AlgorithmDiskANN:
+		return bson.D{
+			{Key: "kind", Value: AlgorithmDiskANN},
+			{Key: "maxDegree", Value: s.MaxDegree},
+			{Key: "lBuild", Value: s.LBuild},
+			{Key: "dimensions", Value: s.Dimensions},
+			{Key: "similarity", Value: s.Similarity},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported vector index algorithm: %s", s.Algorithm)
+	}
+}
+
+// DropVectorIndex drops the named index
+func (vs *VectorStore) DropVectorIndex(ctx context.Context, name string) error {
+	if _, err := vs.collection.Indexes().DropOne(ctx, name); err != nil {
+		return fmt.Errorf("failed to drop index %s: %w", name, err)
+	}
+
+	slog.InfoContext(ctx, "dropped index", "index", name)
+
+	return nil
+}
+
+// SampleVector returns the stored embedding of one document and its HotelId,
+// for canary searches that should not depend on the embedding service
+func (vs *VectorStore) SampleVector(ctx context.Context) (string, []float32, error) {
+	findOpts := options.FindOne().SetProjection(bson.D{
+		{Key: "HotelId", Value: 1},
+		{Key: vs.config.EmbeddedField, Value: 1},
+	})
+
+	var doc bson.Raw
+	err := vs.collection.FindOne(ctx, bson.D{{Key: vs.config.EmbeddedField, Value: bson.D{{Key: "$exists", Value: true}}}}, findOpts).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return "", nil, fmt.Errorf("no documents with %s in collection %s", vs.config.EmbeddedField, vs.config.CollectionName)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read sample document: %w", err)
+	}
+
+	id, _ := doc.Lookup("HotelId").StringValueOK()
+
+	var vector []float32
+	if err := doc.Lookup(vs.config.EmbeddedField).Unmarshal(&vector); err != nil {
+		return "", nil, fmt.Errorf("failed to decode %s: %w", vs.config.EmbeddedField, err)
+	}
+
+	return id, vector, nil
+}
+
+// envInt returns the integer value of an environment variable, or fallback when unset or invalid
+func envInt(name string, fallback int) int {
+	if str := os.Getenv(name); str != "" {
+		if v, err := strconv.Atoi(str); err == nil {
+			return v
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,48 @@
+package vectorstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVectorIndexSpecEnvLines(t *testing.T) {
+	tests := []struct {
+		name string
+		spec VectorIndexSpec
+		want []string
+	}{
+		{"ivf", VectorIndexSpec{Algorithm: AlgorithmIVF, Similarity: "COS", NumLists: 50}, []string{"IVF_NUM_LISTS=50"}},
+		{"hnsw", VectorIndexSpec{Algorithm: AlgorithmHNSW, Similarity: "IP", M: 32, EfConstruction: 128}, []string{"HNSW_M=32", "HNSW_EF_CONSTRUCTION=128"}},
+		{"diskann", VectorIndexSpec{Algorithm: AlgorithmDiskANN, Similarity: "L2", MaxDegree: 40, LBuild: 50}, []string{"DISKANN_MAX_DEGREE=40", "DISKANN_L_BUILD=50"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.spec.EnvLines()
+			if want := append([]string{"VECTOR_INDEX_ALGORITHM=" + tt.spec.Algorithm, "VECTOR_SIMILARITY=" + tt.spec.Similarity}, tt.want...); strings.Join(lines, "\n") != strings.Join(want, "\n") {
+				t.Fatalf("EnvLines() = %q, want %q", lines, want)
+			}
+
+			// The lines configure IndexSpecFromEnv to return the spec's parameters
+			t.Setenv("EMBEDDING_DIMENSIONS", "1536")
+			for _, line := range lines {
+				name, value, _ := strings.Cut(line, "=")
+				t.Setenv(name, value)
+			}
+			got := IndexSpecFromEnv()
+			want := tt.spec
+			want.Dimensions = 1536
+			switch tt.spec.Algorithm {
+			case AlgorithmIVF:
+				got.M, got.EfConstruction, got.MaxDegree, got.LBuild = 0, 0, 0, 0
+			case AlgorithmHNSW:
+				got.NumLists, got.MaxDegree, got.LBuild = 0, 0, 0
+			case AlgorithmDiskANN:
+				got.NumLists, got.M, got.EfConstruction = 0, 0, 0
+			}
+			if got != want {
+				t.Errorf("IndexSpecFromEnv() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultEmbeddingDimensions matches text-embedding-3-small
+const DefaultEmbeddingDimensions = 1536
+
+// EmbeddingDimensionsFromEnv returns EMBEDDING_DIMENSIONS, or the default when unset or invalid
+func EmbeddingDimensionsFromEnv() int {
+	if dimStr := os.Getenv("EMBEDDING_DIMENSIONS"); dimStr != "" {
+		if d, err := strconv.Atoi(dimStr); err == nil {
+			return d
+		}
+	}
+	return DefaultEmbeddingDimensions
+}
+
+// VectorIndexInfo describes an existing vector index on the collection
+type VectorIndexInfo struct {
+	Name       string
+	Field      string
+	Kind       string
+	Similarity string
+	Dimensions int
+	// Parameters holds the algorithm-specific settings, such as numLists or m
+	Parameters map[string]int
+}
+
+// String describes the index and its parameters
+func (i *VectorIndexInfo) String() string {
+	keys := make([]string, 0, len(i.Parameters))
+	for key := range i.Parameters {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	params := []string{
+		fmt.Sprintf("similarity=%s", i.Similarity),
+		fmt.Sprintf("dimensions=%d", i.Dimensions),
+	}
+	for _, key := range keys {
+		params = append(params, fmt.Sprintf("%s=%d", key, i.Parameters[key]))
+	}
+	return fmt.Sprintf("%s on %s: %s (%s)", i.Name, i.Field, i.Kind, strings.Join(params, ", "))
+}
+
+// Ping verifies the connection and credentials
+func (vs *VectorStore) Ping(ctx context.Context) error {
+	if err := vs.client.Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+	return nil
+}
+
+// CollectionExists reports whether the configured collection exists in the database
+func (vs *VectorStore) CollectionExists(ctx context.Context) (bool, error) {
+	names, err := vs.database.ListCollectionNames(ctx, bson.D{{Key: "name", Value: vs.config.CollectionName}})
+	if err != nil {
+		return false, fmt.Errorf("failed to list collections: %w", err)
+	}
+	return len(names) > 0, nil
+}
+
+// ListCollections returns the names of the collections in the database
+func (vs *VectorStore) ListCollections(ctx context.Context) ([]string, error) {
+	names, err := vs.database.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	return names, nil
+}
+
+// CountDocuments returns the number of documents in the collection
+func (vs *VectorStore) CountDocuments(ctx context.Context) (int64, error) {
+	count, err := vs.collection.CountDocuments(ctx, bson.D{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return count, nil
+}
+
+// ExistingHotelIDs returns the set of HotelIds already stored in the collection
+func (vs *VectorStore) ExistingHotelIDs(ctx context.Context) (map[string]bool, error) {
+	values, err := vs.collection.Distinct(ctx, "HotelId", bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing hotel ids: %w", err)
+	}
+
+	ids := make(map[string]bool, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// IndexInfo describes one index on the collection
+type IndexInfo struct {
+	Name string
+	// Keys lists the indexed fields and their index type, in key order
+	Keys []IndexKey
+	// Vector is set for vector indexes
+	Vector *VectorIndexInfo
+}
+
+// IndexKey is one field of an index key
+type IndexKey struct {
+	Field string
+	Type  string
+}
+
+// CollectionStats holds storage statistics for the collection, in bytes
+type CollectionStats struct {
+	Count          int64
+	Size           int64
+	AvgObjSize     int64
+	StorageSize    int64
+	TotalIndexSize int64
+	IndexCount     int
+}
+
+// Stats returns storage statistics for the collection
+func (vs *VectorStore) Stats(ctx context.Context) (*CollectionStats, error) {
+	var result bson.M
+	if err := vs.database.RunCommand(ctx, bson.D{{Key: "collStats", Value: vs.config.CollectionName}}).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to read collection stats: %w", err)
+	}
+
+	return &CollectionStats{
+		Count:          int64(toInt(result["count"])),
+		Size:           int64(toInt(result["size"])),
+		AvgObjSize:     int64(toInt(result["avgObjSize"])),
+		StorageSize:    int64(toInt(result["storageSize"])),
+		TotalIndexSize: int64(toInt(result["totalIndexSize"])),
+		IndexCount:     toInt(result["nindexes"]),
+	}, nil
+}
+
+// ListIndexes returns every index on the collection
+func (vs *VectorStore) ListIndexes(ctx context.Context) ([]IndexInfo, error) {
+	cursor, err := vs.collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var indexes []IndexInfo
+	for cursor.Next(ctx) {
+		var index struct {
+			Name    string `bson:"name"`
+			Key     bson.D `bson:"key"`
+			Options bson.M `bson:"cosmosSearchOptions"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return nil, fmt.Errorf("failed to decode index: %w", err)
+		}
+
+		info := IndexInfo{Name: index.Name}
+		for _, key := range index.Key {
+			kind := fmt.Sprint(key.Value)
+			info.Keys = append(info.Keys, IndexKey{Field: key.Key, Type: kind})
+			if kind != "cosmosSearch" || info.Vector != nil {
+				continue
+			}
+			vector := &VectorIndexInfo{Name: index.Name, Field: key.Key}
+			vector.Kind, _ = index.Options["kind"].(string)
+			vector.Similarity, _ = index.Options["similarity"].(string)
+			vector.Dimensions = toInt(index.Options["dimensions"])
+			vector.Parameters = make(map[string]int)
+			for _, param := range []string{"numLists", "m", "efConstruction", "maxDegree", "lBuild"} {
+				if value, ok := index.Options[param]; ok {
+					vector.Parameters[param] = toInt(value)
+				}
+			}
+			info.Vector = vector
+		}
+		indexes = append(indexes, info)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	return indexes, nil
+}
+
+// FindVectorIndex returns the vector index on the embedded field, or nil if there is none
+func (vs *VectorStore) FindVectorIndex(ctx context.Context) (*VectorIndexInfo, error) {
+	indexes, err := vs.ListIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range indexes {
+		if index.Vector != nil && index.Vector.Field == vs.config.EmbeddedField {
+			return index.Vector, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// toInt converts a BSON numeric value to int
+func toInt(value any) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
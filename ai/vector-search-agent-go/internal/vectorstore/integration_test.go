@@ -0,0 +1,226 @@
+//go:build integration
+
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/bench"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// integrationTimeout bounds each integration test, including starting a container
+const integrationTimeout = 5 * time.Minute
+
+// integrationHotels are three hotels whose vectors point along different axes, so the
+// nearest neighbors of any query along an axis are known
+func integrationHotels() []models.HotelForVectorStore {
+	return []models.HotelForVectorStore{
+		{HotelID: "1", HotelName: "Beach Resort", Description: "On the beach", DescriptionVector: []float32{1, 0, 0}, ContentHash: "h1"},
+		{HotelID: "2", HotelName: "Pool Hotel", Description: "Rooftop pool", DescriptionVector: []float32{0, 1, 0}, ContentHash: "h2"},
+		{HotelID: "3", HotelName: "Beach and Pool", Description: "Both", DescriptionVector: []float32{0.7, 0.7, 0}, ContentHash: "h3"},
+	}
+}
+
+// integrationConfig is the configuration of a store for a database made for one test
+// run, so concurrent runs against the same server don't collide
+func integrationConfig(connectionString, cluster string) *VectorStoreConfig {
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	return &VectorStoreConfig{
+		ConnectionString:   connectionString,
+		ClusterName:        cluster,
+		DatabaseName:       "vectorstore_it_" + suffix,
+		CollectionName:     "hotels",
+		IndexName:          "vectorIndex",
+		EmbeddedField:      "DescriptionVector",
+		FeedbackCollection: DefaultFeedbackCollection,
+		HistoryCollection:  DefaultHistoryCollection,
+		MetricsCollection:  "run_metrics",
+		MetricsTTL:         DefaultMetricsTTL,
+		RoomsCollection:    DefaultRoomsCollection,
+		Similarity:         "COS",
+	}
+}
+
+// openIntegrationStore connects to the server in config and drops the test database
+// and closes the connection when the test finishes
+func openIntegrationStore(t *testing.T, ctx context.Context, config *VectorStoreConfig) *VectorStore {
+	t.Helper()
+	vs, err := NewVectorStore(ctx, config)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := vs.DeleteDatabase(ctx); err != nil {
+			t.Errorf("drop test database: %v", err)
+		}
+		vs.Close(ctx)
+	})
+	return vs
+}
+
+// testStoreOperations runs the operations every MongoDB-compatible server supports:
+// insert, a unique HotelId index, lookups by ID, upserts, deletes, counts, and the
+// exact-search baseline read back from the collection
+func testStoreOperations(t *testing.T, ctx context.Context, vs *VectorStore) {
+	if err := vs.Ping(ctx); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		if err := vs.InsertHotelsWithEmbeddings(ctx, integrationHotels()); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := vs.CountDocuments(ctx); err != nil || n != 3 {
+			t.Fatalf("count = %d, %v; want 3", n, err)
+		}
+		if exists, err := vs.CollectionExists(ctx); err != nil || !exists {
+			t.Errorf("collection exists = %v, %v; want true", exists, err)
+		}
+	})
+
+	t.Run("unique index", func(t *testing.T) {
+		index := mongo.IndexModel{Keys: bson.D{{Key: "HotelId", Value: 1}}, Options: options.Index().SetName("HotelId_unique").SetUnique(true)}
+		if _, err := vs.collection.Indexes().CreateOne(ctx, index); err != nil {
+			t.Fatal(err)
+		}
+		indexes, err := vs.ListIndexes(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.ContainsFunc(indexes, func(i IndexInfo) bool { return i.Name == "HotelId_unique" }) {
+			t.Errorf("indexes = %+v, want HotelId_unique", indexes)
+		}
+
+		err = vs.InsertHotelsWithEmbeddings(ctx, integrationHotels()[:1])
+		if !mongo.IsDuplicateKeyError(err) {
+			t.Errorf("inserting hotel 1 again: err = %v, want a duplicate key error", err)
+		}
+		if n, _ := vs.CountDocuments(ctx); n != 3 {
+			t.Errorf("count = %d after the rejected insert, want 3", n)
+		}
+	})
+
+	t.Run("get by id", func(t *testing.T) {
+		var hotel models.HotelForVectorStore
+		if err := vs.collection.FindOne(ctx, bson.D{{Key: "HotelId", Value: "2"}}).Decode(&hotel); err != nil {
+			t.Fatal(err)
+		}
+		if hotel.HotelName != "Pool Hotel" || !slices.Equal(hotel.DescriptionVector, []float32{0, 1, 0}) {
+			t.Errorf("hotel 2 = %+v, want the inserted hotel", hotel)
+		}
+
+		ids, err := vs.ExistingHotelIDs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 3 || !ids["1"] || !ids["2"] || !ids["3"] {
+			t.Errorf("hotel IDs = %v, want 1, 2, and 3", ids)
+		}
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		changed := integrationHotels()[1]
+		changed.HotelName = "Pool Hotel Renovated"
+		changed.ContentHash = "h2-new"
+		added := models.HotelForVectorStore{HotelID: "4", HotelName: "Mountain Lodge", DescriptionVector: []float32{0, 0, 1}, ContentHash: "h4"}
+		if err := vs.UpsertHotels(ctx, []models.HotelForVectorStore{changed, added}); err != nil {
+			t.Fatal(err)
+		}
+
+		hashes, err := vs.ContentHashes(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hashes) != 4 || hashes["2"] != "h2-new" || hashes["4"] != "h4" {
+			t.Errorf("content hashes = %v, want hotel 2 replaced and hotel 4 added", hashes)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		deleted, err := vs.DeleteHotels(ctx, []string{"4", "missing"})
+		if err != nil || deleted != 1 {
+			t.Fatalf("deleted = %d, %v; want 1", deleted, err)
+		}
+		if n, _ := vs.CountDocuments(ctx); n != 3 {
+			t.Errorf("count = %d after the delete, want 3", n)
+		}
+	})
+
+	t.Run("exact search", func(t *testing.T) {
+		var corpus []bench.Doc
+		exported, err := vs.ExportHotels(ctx, ExportOptions{IncludeVectors: true}, func(hotel models.HotelForVectorStore) error {
+			corpus = append(corpus, bench.Doc{ID: hotel.HotelID, Vector: hotel.DescriptionVector})
+			return nil
+		})
+		if err != nil || exported != 3 {
+			t.Fatalf("exported %d, %v; want 3", exported, err)
+		}
+
+		got := bench.ExactNeighbors([]float32{1, 0.1, 0}, corpus, 2, "COS")
+		if !slices.Equal(got, []string{"1", "3"}) {
+			t.Errorf("exact neighbors = %v, want [1 3]", got)
+		}
+
+		// The store's own exact search, which answers searches without a vector index
+		results, err := vs.ExactSearch(ctx, []float32{1, 0.1, 0}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 || results[0].Hotel.HotelID != "1" || results[1].Hotel.HotelID != "3" || results[0].Provenance != models.ProvenanceExact {
+			t.Errorf("exact search = %+v, want hotels 1 and 3", results)
+		}
+
+		id, vector, err := vs.SampleVector(ctx)
+		if err != nil || id == "" || len(vector) != 3 {
+			t.Errorf("sample vector = %q %v, %v; want a stored 3-dimensional vector", id, vector, err)
+		}
+	})
+
+	t.Run("run metrics", func(t *testing.T) {
+		start := time.Now().UTC().Truncate(time.Millisecond)
+		for i, command := range []string{"upload", "agent", "search"} {
+			record := models.RunMetrics{Command: command, EmbeddingTokens: int64(i + 1), CreatedAt: start.Add(time.Duration(i) * time.Second)}
+			if err := vs.InsertRunMetrics(ctx, record); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		records, err := vs.ListRunMetrics(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 || records[0].Command != "search" || records[1].Command != "agent" {
+			t.Errorf("last 2 runs = %+v, want search then agent", records)
+		}
+
+		coll := vs.database.Collection(vs.config.MetricsCollection)
+		if seconds, found, err := ttlIndexExpiry(ctx, coll); err != nil || !found || seconds != int(DefaultMetricsTTL/time.Second) {
+			t.Errorf("TTL index expires after %ds (found %v, %v), want %s after %s", seconds, found, err, metricsTTLIndexName, DefaultMetricsTTL)
+		}
+
+		// A store with another METRICS_TTL changes the expiry of the existing index
+		changed := *vs.config
+		changed.MetricsTTL = time.Hour
+		other, err := NewVectorStore(ctx, &changed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer other.Close(ctx)
+		if err := other.InsertRunMetrics(ctx, models.RunMetrics{Command: "stats", CreatedAt: start.Add(3 * time.Second)}); err != nil {
+			t.Fatal(err)
+		}
+		if seconds, found, err := ttlIndexExpiry(ctx, coll); err != nil || !found || seconds != 3600 {
+			t.Errorf("TTL index expires after %ds (found %v, %v), want 3600s after the TTL changed", seconds, found, err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package vectorstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// streamJSONL reads newline-delimited JSON, one hotel object per line, calling fn for
+// each. Blank lines are skipped and CRLF line endings are accepted. Lines that fail to
+// parse are errors, unless lenient is set, in which case they are skipped with a
+// warning. shown names the source in messages.
+func streamJSONL(r io.Reader, shown string, lenient bool, fn func(models.Hotel) error) error {
+	reader := bufio.NewReader(r)
+	var lineErrors []string
+
+	for line := 1; ; line++ {
+		// ReadBytes has no line length limit, unlike bufio.Scanner, so hotels with
+		// vectors fit on one line
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read file: %w", readErr)
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			hotel, err := decodeJSONLine(data)
+			if err == nil {
+				err = checkHotel(hotel)
+			}
+			switch {
+			case err != nil:
+				lineErrors = append(lineErrors, fmt.Sprintf("line %d: %v", line, err))
+			case lenient || len(lineErrors) == 0:
+				if err := fn(hotel); err != nil {
+					return err
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if len(lineErrors) > 0 {
+		if !lenient {
+			return fmt.Errorf("%w: %s: %d bad JSONL lines: %s", ErrInvalidData, shown, len(lineErrors), listErrors(lineErrors))
+		}
+		log.Printf("Warning: %s: skipped %d bad JSONL lines: %s", shown, len(lineErrors), listErrors(lineErrors))
+	}
+	return nil
+}
+
+// decodeJSONLine decodes one line, which must hold exactly one JSON object
+func decodeJSONLine(data []byte) (models.Hotel, error) {
+	var hotel models.Hotel
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&hotel); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "" {
+			return hotel, errors.New("expected a JSON object")
+		}
+		if err == io.ErrUnexpectedEOF {
+			return hotel, errors.New("unexpected end of JSON")
+		}
+		return hotel, err
+	}
+	if decoder.More() {
+		return hotel, errors.New("more than one JSON value on the line")
+	}
+	return hotel, nil
+}
+
+// listErrors joins up to maxListedErrors messages, noting how many were left out
+func listErrors(messages []string) string {
+	if len(messages) > maxListedErrors {
+		messages = append(messages[:maxListedErrors:maxListedErrors], fmt.Sprintf("and %d more", len(messages)-maxListedErrors))
+	}
+	return strings.Join(messages, "; ")
+}
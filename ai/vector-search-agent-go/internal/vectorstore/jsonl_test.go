@@ -0,0 +1,89 @@
+package vectorstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadJSONLMatchesJSON(t *testing.T) {
+	fromJSON, err := LoadOptions{}.Load("testdata/without_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile("testdata/without_vectors.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlf := filepath.Join(t.TempDir(), "crlf.jsonl")
+	if err := os.WriteFile(crlf, []byte(strings.ReplaceAll(string(data), "\n", "\r\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"testdata/without_vectors.jsonl", crlf} {
+		fromJSONL, err := LoadOptions{}.Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(fromJSONL, fromJSON) {
+			t.Errorf("%s: JSONL hotels = %+v\nJSON hotels = %+v", file, fromJSONL, fromJSON)
+		}
+	}
+}
+
+func TestLoadJSONLBadLines(t *testing.T) {
+	_, err := LoadOptions{}.Load("testdata/bad_lines.jsonl")
+	if !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("err = %v, want ErrInvalidData", err)
+	}
+	for _, want := range []string{
+		"testdata/bad_lines.jsonl: 2 bad JSONL lines",
+		"line 2: unexpected end of JSON",
+		"line 4: expected a JSON object",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %q, want it to contain %q", err, want)
+		}
+	}
+
+	hotels, err := LoadOptions{Lenient: true}.Load("testdata/bad_lines.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, hotel := range hotels {
+		ids = append(ids, hotel.HotelID)
+	}
+	if strings.Join(ids, ",") != "1,3,5" {
+		t.Errorf("lenient load kept hotels %v, want 1,3,5", ids)
+	}
+}
+
+func TestDecodeJSONLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"HotelId": "1"}`, ""},
+		{`{"HotelId": "1"} {"HotelId": "2"}`, "more than one JSON value"},
+		{`"hotel"`, "expected a JSON object"},
+		{`{"HotelId": 1}`, "HotelId"},
+	}
+
+	for _, tt := range tests {
+		_, err := decodeJSONLine([]byte(tt.line))
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("decodeJSONLine(%s): %v", tt.line, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("decodeJSONLine(%s) err = %v, want %q", tt.line, err, tt.want)
+		}
+	}
+}
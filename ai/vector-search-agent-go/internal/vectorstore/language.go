@@ -0,0 +1,67 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// Search languages. English searches the EmbeddedField vectors of Description, French
+// the FrenchVectorField vectors of Description_fr written when EMBED_FRENCH is set.
+const (
+	LanguageEnglish = "en"
+	LanguageFrench  = "fr"
+)
+
+// FrenchVectorField is the field holding the embedding of Description_fr
+const FrenchVectorField = "DescriptionFrVector"
+
+// frenchIndexSuffix names the French vector index after the main one
+const frenchIndexSuffix = "_fr"
+
+// NormalizeLanguage returns the search language for a code or name such as "fr" or
+// "French", with "" meaning English, and reports whether it is supported
+func NormalizeLanguage(language string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "", LanguageEnglish, "english":
+		return LanguageEnglish, true
+	case LanguageFrench, "french", "français", "francais":
+		return LanguageFrench, true
+	}
+	return "", false
+}
+
+// vectorField returns the field searched for language
+func (vs *VectorStore) vectorField(language string) (string, error) {
+	normalized, ok := NormalizeLanguage(language)
+	if !ok {
+		return "", fmt.Errorf("unsupported search language %q: use %s or %s", language, LanguageEnglish, LanguageFrench)
+	}
+	if normalized == LanguageFrench {
+		return FrenchVectorField, nil
+	}
+	return vs.config.EmbeddedField, nil
+}
+
+// VectorSearchLanguage performs a vector similarity search over the descriptions in
+// language. Hotels without a vector in that language are not matched.
+func (vs *VectorStore) VectorSearchLanguage(ctx context.Context, language string, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	field, err := vs.vectorField(language)
+	if err != nil {
+		return nil, err
+	}
+	return vs.vectorSearch(ctx, field, queryVector, k)
+}
+
+// FrenchIndexName returns the name of the vector index on FrenchVectorField
+func (vs *VectorStore) FrenchIndexName() string {
+	return vs.config.IndexName + frenchIndexSuffix
+}
+
+// CreateFrenchVectorIndex creates the vector index on FrenchVectorField with the
+// settings in the environment
+func (vs *VectorStore) CreateFrenchVectorIndex(ctx context.Context) error {
+	return vs.createVectorIndex(ctx, vs.FrenchIndexName(), FrenchVectorField, IndexSpecFromEnv())
+}
@@ -0,0 +1,54 @@
+package vectorstore
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestVectorFieldSelection(t *testing.T) {
+	vs := &VectorStore{config: &VectorStoreConfig{EmbeddedField: "DescriptionVector", IndexName: "vectorIndex"}}
+
+	tests := []struct {
+		language string
+		want     string
+		wantErr  bool
+	}{
+		{"", "DescriptionVector", false},
+		{"en", "DescriptionVector", false},
+		{"English", "DescriptionVector", false},
+		{"fr", FrenchVectorField, false},
+		{" French ", FrenchVectorField, false},
+		{"de", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			field, err := vs.vectorField(tt.language)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "unsupported search language") {
+					t.Errorf("err = %v, want unsupported language", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if field != tt.want {
+				t.Errorf("field = %q, want %q", field, tt.want)
+			}
+
+			// The pipeline searches the selected field
+			stage := searchPipeline(field, []float32{0.5}, 3)[0]
+			search := stage[0].Value.(bson.D)[0].Value.(bson.D)
+			if path := search[1]; path.Key != "path" || path.Value != tt.want {
+				t.Errorf("pipeline path = %v, want %q", path, tt.want)
+			}
+		})
+	}
+
+	if name := vs.FrenchIndexName(); name != "vectorIndex_fr" {
+		t.Errorf("FrenchIndexName = %q", name)
+	}
+}
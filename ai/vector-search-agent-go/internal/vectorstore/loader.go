@@ -0,0 +1,176 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// Data file formats, selected by DATA_FORMAT or the file extension
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// maxListedErrors is the number of bad records listed in an error or warning
+const maxListedErrors = 5
+
+// LoadOptions controls how data files are parsed. The tags describe the environment
+// variable of each field; see config.Variables.
+type LoadOptions struct {
+	// Format is FormatJSON, FormatJSONL, or FormatCSV; empty picks it from the file
+	// extension
+	Format string `env:"DATA_FORMAT" format:"oneof:json,jsonl,csv" example:"csv" desc:"Format of the data files; unset picks it from the file extension"`
+	// Lenient skips CSV rows and JSONL lines that can't be parsed, and CSV columns that
+	// don't map to a hotel field, with a warning, instead of failing the load
+	Lenient bool `env:"DATA_LENIENT" format:"bool" default:"false" desc:"Skip unparsable CSV rows, JSONL lines, and unknown CSV columns with a warning instead of failing"`
+}
+
+// LoadOptionsFromEnv reads DATA_FORMAT and DATA_LENIENT
+func LoadOptionsFromEnv() LoadOptions {
+	lenient, _ := strconv.ParseBool(os.Getenv("DATA_LENIENT"))
+	return LoadOptions{Format: strings.ToLower(os.Getenv("DATA_FORMAT")), Lenient: lenient}
+}
+
+// LoadHotels loads hotels from a data file in any supported format, using the settings
+// in the environment. The file may be local or a URL, and may be gzip-compressed
+// (see OpenDataSource). Use LoadHotelsStream for files too large to hold in memory.
+func LoadHotels(filePath string) ([]models.Hotel, error) {
+	return LoadOptionsFromEnv().Load(filePath)
+}
+
+// LoadHotelsStream calls fn for each hotel in a data file, in file order, using the
+// settings in the environment. Only the hotel being decoded is held in memory. An
+// error from fn stops the load and is returned as is.
+func LoadHotelsStream(filePath string, fn func(models.Hotel) error) error {
+	return LoadOptionsFromEnv().Stream(filePath, fn)
+}
+
+// Load loads all hotels from filePath in the selected format
+func (o LoadOptions) Load(filePath string) ([]models.Hotel, error) {
+	hotels := []models.Hotel{}
+	err := o.Stream(filePath, func(hotel models.Hotel) error {
+		hotels = append(hotels, hotel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hotels, nil
+}
+
+// Stream decodes filePath in the selected format and calls fn for each hotel. In
+// strict mode, hotels that come after a bad record are not passed to fn; the rest of
+// the file is still read, so the error can list every bad record.
+func (o LoadOptions) Stream(filePath string, fn func(models.Hotel) error) error {
+	format := o.Format
+	if format == "" {
+		format = DetectFormat(filePath)
+	}
+
+	var decode func(r io.Reader, shown string, lenient bool, fn func(models.Hotel) error) error
+	switch format {
+	case FormatJSON:
+		decode = streamJSONArray
+	case FormatJSONL:
+		decode = streamJSONL
+	case FormatCSV:
+		decode = streamCSV
+	default:
+		return fmt.Errorf("%w: unknown data format %q (use %s, %s, or %s)", ErrInvalidData, format, FormatJSON, FormatJSONL, FormatCSV)
+	}
+
+	file, err := OpenDataSource(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	shown := filePath
+	if IsURL(filePath) {
+		shown = redactURL(filePath)
+	}
+	return decode(file, shown, o.Lenient, fn)
+}
+
+// DetectFormat picks the format from the file extension, ignoring a trailing .gz and
+// any URL query string. Files without a recognized extension are read as JSON.
+func DetectFormat(filePath string) string {
+	name := filePath
+	if IsURL(filePath) {
+		if u, err := url.Parse(filePath); err == nil {
+			name = u.Path
+		}
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".gz")
+
+	switch path.Ext(name) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".csv":
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// streamJSONArray decodes a JSON array of hotels one element at a time. lenient does
+// not apply: a syntax error leaves no way to find the next element.
+func streamJSONArray(r io.Reader, shown string, _ bool, fn func(models.Hotel) error) error {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return jsonError(err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("%w: failed to parse JSON: %s does not hold an array of hotels", ErrInvalidData, shown)
+	}
+
+	for i := 1; decoder.More(); i++ {
+		var hotel models.Hotel
+		if err := decoder.Decode(&hotel); err != nil {
+			return jsonError(err)
+		}
+		if err := checkHotel(hotel); err != nil {
+			return fmt.Errorf("%w: %s: hotel %d: %w", ErrInvalidData, shown, i, err)
+		}
+		if err := fn(hotel); err != nil {
+			return err
+		}
+	}
+
+	// The closing bracket
+	if _, err := decoder.Token(); err != nil {
+		return jsonError(err)
+	}
+	return nil
+}
+
+// jsonError reports a JSON syntax or type error as invalid data, or a failure reading
+// the source
+func jsonError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || err == io.ErrUnexpectedEOF || err == io.EOF {
+		return fmt.Errorf("%w: failed to parse JSON: %w", ErrInvalidData, err)
+	}
+	return fmt.Errorf("failed to read file: %w", err)
+}
+
+// checkHotel applies the checks every format shares: each hotel needs a HotelId. The
+// other fields are checked by Hotel.Validate before upload, which skips the hotel
+// rather than the file.
+func checkHotel(hotel models.Hotel) error {
+	if strings.TrimSpace(hotel.HotelID) == "" {
+		return errors.New("no HotelId")
+	}
+	return nil
+}
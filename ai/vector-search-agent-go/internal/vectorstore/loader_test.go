@@ -0,0 +1,64 @@
+package vectorstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+func TestLoadHotelsStreamInFileOrder(t *testing.T) {
+	for _, file := range []string{"testdata/without_vectors.json", "testdata/without_vectors.jsonl", "testdata/without_vectors.csv", "testdata/without_vectors.json.gz"} {
+		t.Run(file, func(t *testing.T) {
+			var ids []string
+			err := LoadHotelsStream(file, func(hotel models.Hotel) error {
+				ids = append(ids, hotel.HotelID)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(ids, ",") != "1,2" {
+				t.Errorf("streamed hotels %v, want 1,2", ids)
+			}
+		})
+	}
+}
+
+func TestLoadHotelsStreamStopsOnCallbackError(t *testing.T) {
+	errStop := errors.New("stop")
+	for _, file := range []string{"testdata/without_vectors.json", "testdata/without_vectors.jsonl", "testdata/without_vectors.csv"} {
+		t.Run(file, func(t *testing.T) {
+			calls := 0
+			err := LoadHotelsStream(file, func(models.Hotel) error {
+				calls++
+				return errStop
+			})
+			if err != errStop || calls != 1 {
+				t.Errorf("err = %v after %d calls, want the callback error after 1", err, calls)
+			}
+		})
+	}
+}
+
+func TestStreamStrictStopsAtBadRecord(t *testing.T) {
+	var ids []string
+	err := LoadOptions{}.Stream("testdata/bad_lines.jsonl", func(hotel models.Hotel) error {
+		ids = append(ids, hotel.HotelID)
+		return nil
+	})
+	if !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("err = %v, want ErrInvalidData", err)
+	}
+	if strings.Join(ids, ",") != "1" {
+		t.Errorf("streamed hotels %v, want only those before the first bad line", ids)
+	}
+}
+
+func TestStreamJSONRejectsNonArray(t *testing.T) {
+	err := streamJSONArray(strings.NewReader(`{"HotelId": "1"}`), "hotels.json", false, func(models.Hotel) error { return nil })
+	if !errors.Is(err, ErrInvalidData) || !strings.Contains(err.Error(), "hotels.json does not hold an array of hotels") {
+		t.Errorf("err = %v, want a non-array rejected", err)
+	}
+}
@@ -0,0 +1,68 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithVectorField returns a store for the same collection that searches, indexes, and
+// writes vectors in another field under another index name. It shares the connection,
+// so only the original store should be closed.
+func (vs *VectorStore) WithVectorField(field, indexName string) *VectorStore {
+	config := *vs.config
+	config.EmbeddedField = field
+	config.IndexName = indexName
+	return &VectorStore{
+		config:     &config,
+		client:     vs.client,
+		database:   vs.database,
+		collection: vs.collection,
+	}
+}
+
+// HotelIDsWithVectors returns the set of HotelIds whose documents have the store's
+// vector field
+func (vs *VectorStore) HotelIDsWithVectors(ctx context.Context) (map[string]bool, error) {
+	filter := bson.D{{Key: vs.config.EmbeddedField, Value: bson.D{{Key: "$exists", Value: true}}}}
+	values, err := vs.collection.Distinct(ctx, "HotelId", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotel ids with %s: %w", vs.config.EmbeddedField, err)
+	}
+
+	ids := make(map[string]bool, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+// SetVectors writes vectors, by HotelId, to the store's vector field of existing
+// documents, leaving every other field as it is
+func (vs *VectorStore) SetVectors(ctx context.Context, vectors map[string][]float32) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(vectors))
+	for id, vector := range vectors {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "HotelId", Value: id}}).
+			SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: vs.config.EmbeddedField, Value: vector}}}}))
+	}
+
+	result, err := vs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", vs.config.EmbeddedField, err)
+	}
+
+	slog.DebugContext(ctx, "wrote vectors", "field", vs.config.EmbeddedField, "count", result.ModifiedCount)
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Bounds of the number of nearest neighbors a search returns. The maximum can be
+// raised or lowered with MAX_NEAREST_NEIGHBORS.
+const (
+	MinK        = 1
+	DefaultMaxK = 20
+)
+
+// MaxK returns the largest number of nearest neighbors a search may ask for:
+// MAX_NEAREST_NEIGHBORS, or DefaultMaxK when it is unset or not a positive integer
+func MaxK() int {
+	if k := envInt("MAX_NEAREST_NEIGHBORS", DefaultMaxK); k >= MinK {
+		return k
+	}
+	return DefaultMaxK
+}
+
+// ClampK returns k within MinK and MaxK. Values below MinK become MinK; values above
+// MaxK are lowered to it with a warning. Every layer that takes k from outside, the
+// command flags, the planner's tool calls, and the searches, applies it, so they all
+// agree on the k that reaches the store.
+func ClampK(ctx context.Context, k int) int {
+	if k < MinK {
+		slog.DebugContext(ctx, "nearest neighbors raised to the minimum", "k", k, "min", MinK)
+		return MinK
+	}
+	if maxK := MaxK(); k > maxK {
+		slog.WarnContext(ctx, "nearest neighbors lowered to MAX_NEAREST_NEIGHBORS", "k", k, "max", maxK)
+		return maxK
+	}
+	return k
+}
+
+// ParseK reads a number of nearest neighbors from a flag, an environment variable, or
+// a request and clamps it with ClampK. Anything but an integer is an error.
+func ParseK(ctx context.Context, s string) (int, error) {
+	k, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("nearest neighbors %q is not an integer", s)
+	}
+	return ClampK(ctx, k), nil
+}
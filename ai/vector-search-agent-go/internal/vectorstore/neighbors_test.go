@@ -0,0 +1,48 @@
+package vectorstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestClampK(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxEnv string
+		k      int
+		want   int
+	}{
+		{"in range", "", 7, 7},
+		{"bounds", "", DefaultMaxK, DefaultMaxK},
+		{"zero", "", 0, MinK},
+		{"negative", "", -3, MinK},
+		{"too large", "", 500, DefaultMaxK},
+		{"raised max", "50", 40, 40},
+		{"lowered max", "10", 15, 10},
+		{"invalid max", "many", 500, DefaultMaxK},
+		{"zero max", "0", 500, DefaultMaxK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAX_NEAREST_NEIGHBORS", tt.maxEnv)
+			if got := ClampK(context.Background(), tt.k); got != tt.want {
+				t.Errorf("ClampK(%d) = %d, want %d", tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseK(t *testing.T) {
+	for s, want := range map[string]int{"5": 5, " 12 ": 12, "0": MinK, "21": DefaultMaxK} {
+		if got, err := ParseK(context.Background(), s); err != nil || got != want {
+			t.Errorf("ParseK(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "many", "2.5", "1e3"} {
+		if _, err := ParseK(context.Background(), s); err == nil || !strings.Contains(err.Error(), "is not an integer") {
+			t.Errorf("ParseK(%q) err = %v, want it to reject a non-integer", s, err)
+		}
+	}
+}
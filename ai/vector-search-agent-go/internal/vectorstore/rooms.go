@@ -0,0 +1,102 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/trace"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DefaultRoomsCollection is used when ROOMS_COLLECTION is unset
+const DefaultRoomsCollection = "rooms"
+
+// DocumentTypeRoom is the registered name of the room document type
+const DocumentTypeRoom = "room"
+
+// RoomVectorField is the field holding the embedding of each room document
+const RoomVectorField = "DescriptionVector"
+
+// rooms returns a store for the rooms collection
+func (vs *VectorStore) rooms() *VectorStore {
+	return vs.WithCollection(vs.config.RoomsCollection)
+}
+
+// InsertRooms inserts room documents with their embeddings into the rooms collection
+func (vs *VectorStore) InsertRooms(ctx context.Context, rooms []models.RoomForVectorStore) error {
+	docs := make([]models.Document, len(rooms))
+	for i := range rooms {
+		docs[i] = &rooms[i]
+	}
+	if err := vs.rooms().InsertDocuments(ctx, docs); err != nil {
+		return fmt.Errorf("failed to insert rooms: %w", err)
+	}
+	return nil
+}
+
+// DeleteRooms removes the rooms of the hotels with the given HotelIds and returns how
+// many were deleted
+func (vs *VectorStore) DeleteRooms(ctx context.Context, hotelIDs []string) (int64, error) {
+	return vs.rooms().DeleteHotels(ctx, hotelIDs)
+}
+
+// CreateRoomsVectorIndex creates the vector index of the rooms collection with the
+// settings in the environment. It has the same name as the hotel index.
+func (vs *VectorStore) CreateRoomsVectorIndex(ctx context.Context) error {
+	return vs.rooms().createVectorIndex(ctx, vs.config.IndexName, RoomVectorField, IndexSpecFromEnv())
+}
+
+// SearchRooms performs a vector similarity search over the rooms collection, falling
+// back to an exact search like VectorSearch when the rooms have no vector index
+func (vs *VectorStore) SearchRooms(ctx context.Context, queryVector []float32, k int) (_ []models.RoomSearchResult, err error) {
+	k = ClampK(ctx, k)
+	done := trace.Start(ctx, trace.EventVectorSearch)
+	defer func() { done(err) }()
+	ctx, span := vs.startSpan(ctx, "aggregate", vs.config.RoomsCollection, telemetry.KKey.Int(k))
+	defer func() { endSpan(span, err) }()
+	defer func(start time.Time) { metrics.Default().Search(time.Since(start), err) }(time.Now())
+	ctx, end := vs.budget(ctx, timeouts.StageSearch)
+	defer end(&err)
+
+	scored, err := vs.rooms().search(ctx, RoomVectorField, queryVector, k)
+	if err != nil {
+		return nil, fmt.Errorf("room search failed: %w", err)
+	}
+
+	var results []models.RoomSearchResult
+	for _, result := range scored {
+		var room models.RoomForVectorStore
+		if err := bson.Unmarshal(result.Document, &room); err != nil {
+			return nil, fmt.Errorf("failed to decode result: %w", err)
+		}
+		results = append(results, models.RoomSearchResult{Room: room, Score: result.Score})
+	}
+
+	slog.DebugContext(ctx, "room search returned", "results", len(results))
+	span.SetAttributes(telemetry.ResultCountKey.Int(len(results)))
+
+	return results, nil
+}
+
+// FormatHotelRoomsForSynthesizer formats the rooms a search matched in one hotel for
+// the synthesizer agent: the hotel, then each room in score order
+func FormatHotelRoomsForSynthesizer(group models.HotelRooms) string {
+	lines := []string{
+		"--- HOTEL ROOMS START ---",
+		"HotelId: " + group.HotelID,
+		"HotelName: " + group.HotelName,
+		fmt.Sprintf("Score: %.6f", group.Score),
+	}
+	for _, result := range group.Rooms {
+		lines = append(lines, Formatter{}.format("ROOM", &result.Room, resultScore{score: result.Score}))
+	}
+	lines = append(lines, "--- HOTEL ROOMS END ---")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,72 @@
+package vectorstore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+func TestRoomDocumentsFromSampleData(t *testing.T) {
+	hotels, err := LoadHotels("testdata/rooms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rooms := hotels[0].RoomDocuments()
+	if len(rooms) != 3 {
+		t.Fatalf("rooms = %d, want 3", len(rooms))
+	}
+	if rooms[2].RoomID != "1:3" || rooms[2].Type != "Deluxe Room" || rooms[2].BaseRate != 150.99 || rooms[2].HotelName != "Stay-Kay City Hotel" {
+		t.Errorf("room = %+v", rooms[2])
+	}
+	// The second hotel has no rooms, so it produces no room documents
+	if docs := hotels[1].RoomDocuments(); docs != nil {
+		t.Errorf("hotel without rooms produced %d room documents", len(docs))
+	}
+
+	decode, err := documentDecoder(DocumentTypeRoom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := documentResult(searchResult(t, &rooms[2], 0.5), decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Document.ID() != "1:3" || result.Document.EmbeddingText() != rooms[2].EmbeddingText() {
+		t.Errorf("decoded room = %+v", result.Document)
+	}
+}
+
+func TestFormatHotelRoomsForSynthesizer(t *testing.T) {
+	hotels, err := LoadHotels("testdata/rooms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rooms := hotels[0].RoomDocuments()
+	group := models.GroupRoomsByHotel([]models.RoomSearchResult{{Room: rooms[2], Score: 0.9}, {Room: rooms[0], Score: 0.8}})[0]
+
+	got := FormatHotelRoomsForSynthesizer(group)
+	want := strings.Join([]string{
+		"--- HOTEL ROOMS START ---",
+		"HotelId: 1",
+		"HotelName: Stay-Kay City Hotel",
+		"Score: 0.900000",
+		"--- ROOM START ---",
+		"RoomId: 1:3",
+		"Type: Deluxe Room",
+		"Description: Deluxe Room, 2 Double Beds (City View)",
+		"BedOptions: 2 Double Beds",
+		"SleepsCount: 2",
+		"BaseRate: 150.99",
+		"SmokingAllowed: false",
+		"Tags: suite, bathroom shower, coffee maker",
+		"Score: 0.900000",
+		"--- ROOM END ---",
+		"--- ROOM START ---",
+		"RoomId: 1:1",
+	}, "\n")
+	if !strings.HasPrefix(got, want) || !strings.HasSuffix(got, "--- ROOM END ---\n--- HOTEL ROOMS END ---") {
+		t.Errorf("got:\n%s", got)
+	}
+}
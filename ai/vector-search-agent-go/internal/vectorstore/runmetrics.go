@@ -0,0 +1,134 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultMetricsTTL is how long run metrics are kept when METRICS_TTL is unset
+const DefaultMetricsTTL = 90 * 24 * time.Hour
+
+// metricsTTLIndexName names the TTL index of the metrics collection
+const metricsTTLIndexName = "createdAt_ttl"
+
+// InsertRunMetrics stores the usage of one command run in the metrics collection. The
+// first insert of the store also makes sure the collection's TTL index on createdAt
+// expires records after MetricsTTL.
+func (vs *VectorStore) InsertRunMetrics(ctx context.Context, record models.RunMetrics) error {
+	if vs.config.MetricsCollection == "" {
+		return fmt.Errorf("%w: METRICS_COLLECTION is not set", ErrMissingConfig)
+	}
+
+	coll := vs.database.Collection(vs.config.MetricsCollection)
+	if _, err := coll.InsertOne(ctx, record); err != nil {
+		return fmt.Errorf("failed to insert run metrics: %w", err)
+	}
+	if err := vs.ensureMetricsTTLIndex(ctx, coll); err != nil {
+		return fmt.Errorf("failed to set up the TTL index of %s: %w", vs.config.MetricsCollection, err)
+	}
+
+	slog.DebugContext(ctx, "inserted run metrics", "collection", vs.config.MetricsCollection, "command", record.Command)
+
+	return nil
+}
+
+// ensureMetricsTTLIndex creates the TTL index of coll, or changes its expiry with collMod
+// when MetricsTTL has changed since it was created. It checks once per store; a failed
+// check is retried by the next insert.
+func (vs *VectorStore) ensureMetricsTTLIndex(ctx context.Context, coll *mongo.Collection) error {
+	vs.metricsIndexMu.Lock()
+	defer vs.metricsIndexMu.Unlock()
+	if vs.metricsIndexed {
+		return nil
+	}
+
+	want := metricsTTLSeconds(vs.config.MetricsTTL)
+	current, found, err := ttlIndexExpiry(ctx, coll)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		if _, err := coll.Indexes().CreateOne(ctx, metricsTTLIndex(vs.config.MetricsTTL)); err != nil {
+			return err
+		}
+	case current != int(want):
+		command := bson.D{
+			{Key: "collMod", Value: coll.Name()},
+			{Key: "index", Value: bson.D{{Key: "name", Value: metricsTTLIndexName}, {Key: "expireAfterSeconds", Value: want}}},
+		}
+		if err := vs.database.RunCommand(ctx, command).Err(); err != nil {
+			return fmt.Errorf("failed to change the expiry from %ds to %ds: %w", current, want, err)
+		}
+		slog.InfoContext(ctx, "changed the run metrics TTL", "collection", coll.Name(), "from", time.Duration(current)*time.Second, "to", time.Duration(want)*time.Second)
+	}
+	vs.metricsIndexed = true
+	return nil
+}
+
+// ttlIndexExpiry returns the expireAfterSeconds of the TTL index of coll, and whether
+// coll has that index
+func ttlIndexExpiry(ctx context.Context, coll *mongo.Collection) (int, bool, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	for cursor.Next(ctx) {
+		var index struct {
+			Name               string `bson:"name"`
+			ExpireAfterSeconds any    `bson:"expireAfterSeconds"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return 0, false, fmt.Errorf("failed to decode index: %w", err)
+		}
+		if index.Name == metricsTTLIndexName {
+			return toInt(index.ExpireAfterSeconds), true, nil
+		}
+	}
+	return 0, false, cursor.Err()
+}
+
+// ListRunMetrics returns the last limit runs of the metrics collection, newest first;
+// a zero limit returns every run
+func (vs *VectorStore) ListRunMetrics(ctx context.Context, limit int) ([]models.RunMetrics, error) {
+	if vs.config.MetricsCollection == "" {
+		return nil, fmt.Errorf("%w: METRICS_COLLECTION is not set", ErrMissingConfig)
+	}
+
+	query, findOpts := historyQuery(HistoryFilter{Limit: limit})
+	cursor, err := vs.database.Collection(vs.config.MetricsCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query run metrics: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var records []models.RunMetrics
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to read run metrics: %w", err)
+	}
+	return records, nil
+}
+
+// metricsTTLIndex returns the index that removes run metrics ttl after they were
+// recorded
+func metricsTTLIndex(ttl time.Duration) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdAt", Value: 1}},
+		Options: options.Index().SetName(metricsTTLIndexName).SetExpireAfterSeconds(metricsTTLSeconds(ttl)),
+	}
+}
+
+// metricsTTLSeconds returns ttl in the whole seconds of expireAfterSeconds. A ttl under
+// a second keeps records for a second, the shortest TTL there is.
+func metricsTTLSeconds(ttl time.Duration) int32 {
+	return int32(min(max(ttl/time.Second, 1), math.MaxInt32))
+}
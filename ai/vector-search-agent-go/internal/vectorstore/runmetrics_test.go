@@ -0,0 +1,87 @@
+package vectorstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRunMetricsDocumentShape(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	record := models.RunMetrics{
+		Command:     "upload",
+		Environment: "dev",
+		SessionID:   "session-1",
+		Usage: []models.RunUsage{
+			{Deployment: "text-embedding-3-small", Calls: 2, EmbeddingTokens: 900, EstimatedCost: 0.000018},
+		},
+		EmbeddingTokens: 900,
+		EstimatedCost:   0.000018,
+		Documents:       map[string]int64{"inserted": 50},
+		DurationMs:      4200,
+		CreatedAt:       createdAt,
+	}
+
+	data, err := bson.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"command", "environment", "sessionId", "usage", "promptTokens", "completionTokens", "embeddingTokens", "estimatedCost", "documents", "durationMs", "createdAt", "build"} {
+		if _, ok := doc[key]; !ok {
+			t.Errorf("document is missing %q: %v", key, doc)
+		}
+	}
+	// A successful run stores no error, and a run without a request ID none either
+	for _, key := range []string{"error", "requestId"} {
+		if _, ok := doc[key]; ok {
+			t.Errorf("document stores %q for a run without one: %v", key, doc)
+		}
+	}
+	// createdAt is a BSON date, which the TTL index needs
+	if got, ok := doc["createdAt"].(primitive.DateTime); !ok || got.Time().UTC() != createdAt {
+		t.Errorf("createdAt = %#v, want the date %v", doc["createdAt"], createdAt)
+	}
+	usage, ok := doc["usage"].(bson.A)
+	if !ok || len(usage) != 1 {
+		t.Fatalf("usage = %#v, want one deployment", doc["usage"])
+	}
+	if u := usage[0].(bson.M); u["deployment"] != "text-embedding-3-small" || u["embeddingTokens"] != int64(900) || u["calls"] != int32(2) {
+		t.Errorf("usage = %v", u)
+	}
+	if documents := doc["documents"].(bson.M); documents["inserted"] != int64(50) {
+		t.Errorf("documents = %v, want inserted: 50", documents)
+	}
+}
+
+func TestMetricsTTLIndex(t *testing.T) {
+	tests := []struct {
+		ttl  time.Duration
+		want int32
+	}{
+		{DefaultMetricsTTL, 90 * 24 * 60 * 60},
+		{90 * time.Minute, 5400},
+		{time.Millisecond, 1},
+	}
+
+	for _, tt := range tests {
+		index := metricsTTLIndex(tt.ttl)
+		keys, ok := index.Keys.(bson.D)
+		if !ok || len(keys) != 1 || keys[0].Key != "createdAt" || keys[0].Value != 1 {
+			t.Errorf("keys = %v, want createdAt ascending", index.Keys)
+		}
+		if got := index.Options.ExpireAfterSeconds; got == nil || *got != tt.want {
+			t.Errorf("TTL %s: expireAfterSeconds = %v, want %d", tt.ttl, got, tt.want)
+		}
+		if index.Options.Name == nil || *index.Options.Name != metricsTTLIndexName {
+			t.Errorf("name = %v, want %s", index.Options.Name, metricsTTLIndexName)
+		}
+	}
+}
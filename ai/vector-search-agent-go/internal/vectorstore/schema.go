@@ -0,0 +1,203 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrateBatchSize is the number of documents Migrate rewrites in one bulk write
+const migrateBatchSize = 500
+
+// schemaMigration upgrades a stored hotel document from one schema version to the
+// next. Apply edits the document in place and must leave fields it doesn't know alone.
+type schemaMigration struct {
+	Description string
+	Apply       func(doc bson.M) error
+}
+
+// schemaMigrations holds the migration from each schema version to the next, by the
+// version it upgrades from. Raising models.CurrentSchemaVersion needs an entry for
+// the previous version.
+var schemaMigrations = map[int]schemaMigration{
+	0: {
+		Description: "summarize Rooms arrays and fill Tags and IsDeleted",
+		Apply:       migrateV0,
+	},
+}
+
+// migrateV0 upgrades documents written before schema versions were recorded.
+// Documents imported straight from the data file hold the full Rooms array, which
+// doesn't decode into a RoomSummary, so it is replaced with the summary an upload
+// would have stored. Missing Tags and IsDeleted are written out, so filters on them
+// match old documents too.
+func migrateV0(doc bson.M) error {
+	if rooms, ok := doc["Rooms"].(bson.A); ok {
+		data, err := bson.Marshal(bson.M{"rooms": rooms})
+		if err != nil {
+			return fmt.Errorf("failed to read Rooms: %w", err)
+		}
+		var parsed struct {
+			Rooms []models.Room `bson:"rooms"`
+		}
+		if err := bson.Unmarshal(data, &parsed); err != nil {
+			return fmt.Errorf("failed to read Rooms: %w", err)
+		}
+		if summary := models.SummarizeRooms(parsed.Rooms); summary != nil {
+			doc["Rooms"] = summary
+		} else {
+			delete(doc, "Rooms")
+		}
+	}
+	if doc["Tags"] == nil {
+		doc["Tags"] = bson.A{}
+	}
+	if _, ok := doc["IsDeleted"]; !ok {
+		doc["IsDeleted"] = false
+	}
+	return nil
+}
+
+// MigrateDocument upgrades doc in place to models.CurrentSchemaVersion, running the
+// migration of every version in between, and returns the version it had. Documents
+// without a SchemaVersion are version 0. A document already at the current version is
+// left unchanged, so migrating twice is harmless.
+func MigrateDocument(doc bson.M) (int, error) {
+	from := toInt(doc["SchemaVersion"])
+	if from > models.CurrentSchemaVersion {
+		return from, fmt.Errorf("document %v has schema version %d, newer than %d; upgrade this sample", doc["HotelId"], from, models.CurrentSchemaVersion)
+	}
+	for version := from; version < models.CurrentSchemaVersion; version++ {
+		migration, ok := schemaMigrations[version]
+		if !ok {
+			return from, fmt.Errorf("no migration from schema version %d", version)
+		}
+		if err := migration.Apply(doc); err != nil {
+			return from, fmt.Errorf("failed to migrate document %v from schema version %d (%s): %w", doc["HotelId"], version, migration.Description, err)
+		}
+		doc["SchemaVersion"] = version + 1
+	}
+	return from, nil
+}
+
+// outdatedFilter matches documents below the current schema version, including those
+// without one
+func outdatedFilter() bson.D {
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "SchemaVersion", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "SchemaVersion", Value: bson.D{{Key: "$lt", Value: models.CurrentSchemaVersion}}}},
+	}}}
+}
+
+// MigrationResult counts the documents Migrate upgraded
+type MigrationResult struct {
+	Migrated int
+	// FromVersions counts the migrated documents by the schema version they had
+	FromVersions map[int]int
+}
+
+// Migrate upgrades every document below models.CurrentSchemaVersion, replacing them in
+// batches. A document that was rewritten at the current version since it was read,
+// for example by a concurrent upload, is not replaced. Running it again finds nothing
+// to do.
+func (vs *VectorStore) Migrate(ctx context.Context) (*MigrationResult, error) {
+	cursor, err := vs.collection.Find(ctx, outdatedFilter(), options.Find().SetBatchSize(migrateBatchSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents to migrate: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	result := &MigrationResult{FromVersions: make(map[int]int)}
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		if _, err := vs.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("failed to write migrated documents: %w", err)
+		}
+		writes = writes[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return result, fmt.Errorf("failed to decode document: %w", err)
+		}
+		from, err := MigrateDocument(doc)
+		if err != nil {
+			return result, err
+		}
+		filter := append(bson.D{{Key: "_id", Value: doc["_id"]}}, outdatedFilter()...)
+		writes = append(writes, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc))
+		result.Migrated++
+		result.FromVersions[from]++
+		if len(writes) == migrateBatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return result, fmt.Errorf("cursor error: %w", err)
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	slog.InfoContext(ctx, "migrated documents", "collection", vs.config.CollectionName, "count", result.Migrated, "version", models.CurrentSchemaVersion)
+
+	return result, nil
+}
+
+// SchemaVersions counts the stored hotels by SchemaVersion; documents without one
+// count as version 0
+func (vs *VectorStore) SchemaVersions(ctx context.Context) (map[int]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$SchemaVersion"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+	}
+	cursor, err := vs.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count schema versions: %w", err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	versions := make(map[int]int64)
+	for cursor.Next(ctx) {
+		var group struct {
+			Version any `bson:"_id"`
+			Count   any `bson:"count"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, fmt.Errorf("failed to decode schema version count: %w", err)
+		}
+		versions[toInt(group.Version)] += int64(toInt(group.Count))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return versions, nil
+}
+
+// FormatSchemaVersions renders version counts in version order, such as "v0: 12, v1: 38"
+func FormatSchemaVersions(versions map[int]int64) string {
+	keys := make([]int, 0, len(versions))
+	for version := range versions {
+		keys = append(keys, version)
+	}
+	slices.Sort(keys)
+
+	parts := make([]string, len(keys))
+	for i, version := range keys {
+		parts[i] = fmt.Sprintf("v%d: %d", version, versions[version])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,145 @@
+package vectorstore
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// toStored round-trips v through BSON into the generic form Migrate reads documents in
+func toStored(t *testing.T, v any) bson.M {
+	t.Helper()
+	data, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// decodeStored decodes a migrated document the way searches do
+func decodeStored(t *testing.T, doc bson.M) models.HotelForVectorStore {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hotel models.HotelForVectorStore
+	if err := bson.Unmarshal(data, &hotel); err != nil {
+		t.Fatalf("migrated document does not decode: %v", err)
+	}
+	return hotel
+}
+
+// v0Documents fabricates documents written before schema versions were recorded: the
+// hotels of rooms.json imported straight from the file, with full Rooms arrays, and
+// one uploaded document without Tags or IsDeleted
+func v0Documents(t *testing.T) []bson.M {
+	t.Helper()
+	hotels, err := LoadHotels("testdata/rooms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []bson.M
+	for _, hotel := range hotels {
+		docs = append(docs, toStored(t, hotel))
+	}
+
+	uploaded := toStored(t, models.HotelForVectorStore{HotelID: "7", HotelName: "Uploaded Inn", Description: "Quiet."})
+	delete(uploaded, "SchemaVersion")
+	delete(uploaded, "IsDeleted")
+	uploaded["Tags"] = nil
+	return append(docs, uploaded)
+}
+
+func TestSchemaMigrationsCoverEveryVersion(t *testing.T) {
+	for version := 0; version < models.CurrentSchemaVersion; version++ {
+		if _, ok := schemaMigrations[version]; !ok {
+			t.Errorf("no migration from schema version %d", version)
+		}
+	}
+}
+
+func TestMigrateDocumentFromV0(t *testing.T) {
+	docs := v0Documents(t)
+	if _, ok := docs[0]["Rooms"].(bson.A); !ok {
+		t.Fatalf("fixture Rooms = %T, want an array", docs[0]["Rooms"])
+	}
+
+	for _, doc := range docs {
+		from, err := MigrateDocument(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != 0 {
+			t.Errorf("hotel %v migrated from version %d, want 0", doc["HotelId"], from)
+		}
+	}
+
+	first := decodeStored(t, docs[0])
+	wantRooms := &models.RoomSummary{Count: 3, MinRate: 80.99, MaxRate: 150.99, Types: []string{"Budget Room", "Deluxe Room"}}
+	if first.SchemaVersion != models.CurrentSchemaVersion || !reflect.DeepEqual(first.Rooms, wantRooms) {
+		t.Errorf("hotel 1 = version %d, rooms %+v; want version %d, rooms %+v", first.SchemaVersion, first.Rooms, models.CurrentSchemaVersion, wantRooms)
+	}
+	if first.HotelName != "Stay-Kay City Hotel" || first.Address.City != "New York" {
+		t.Errorf("migration changed other fields: %+v", first)
+	}
+
+	// The second hotel has no rooms, and the uploaded document gains the defaults
+	if second := decodeStored(t, docs[1]); second.Rooms != nil {
+		t.Errorf("hotel without rooms got a summary %+v", second.Rooms)
+	}
+	uploaded := docs[2]
+	if tags, ok := uploaded["Tags"].(bson.A); !ok || len(tags) != 0 || uploaded["IsDeleted"] != false {
+		t.Errorf("uploaded document Tags = %#v, IsDeleted = %#v; want an empty array and false", uploaded["Tags"], uploaded["IsDeleted"])
+	}
+}
+
+func TestMigrateDocumentIsIdempotent(t *testing.T) {
+	for _, doc := range v0Documents(t) {
+		if _, err := MigrateDocument(doc); err != nil {
+			t.Fatal(err)
+		}
+		migrated := toStored(t, doc)
+
+		// A migrated document, as read back from the collection, is left as it is
+		again := toStored(t, doc)
+		from, err := MigrateDocument(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != models.CurrentSchemaVersion || !reflect.DeepEqual(again, migrated) {
+			t.Errorf("second migration of hotel %v from version %d changed it:\n%v\nwant\n%v", doc["HotelId"], from, again, migrated)
+		}
+	}
+}
+
+func TestMigrateDocumentCurrentUpload(t *testing.T) {
+	hotels, err := LoadHotels("testdata/rooms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := toStored(t, hotels[0].ToVectorStore())
+	want := toStored(t, hotels[0].ToVectorStore())
+
+	from, err := MigrateDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != models.CurrentSchemaVersion || !reflect.DeepEqual(doc, want) {
+		t.Errorf("uploaded document at version %d was changed: %v", from, doc)
+	}
+}
+
+func TestMigrateDocumentRejectsNewerVersions(t *testing.T) {
+	doc := bson.M{"HotelId": "1", "SchemaVersion": int32(models.CurrentSchemaVersion + 1)}
+	if _, err := MigrateDocument(doc); err == nil || !strings.Contains(err.Error(), "newer than") {
+		t.Errorf("MigrateDocument = %v, want a newer version error", err)
+	}
+}
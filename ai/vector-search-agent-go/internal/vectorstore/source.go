@@ -0,0 +1,204 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for downloading a data file from a URL
+const (
+	DefaultSourceTimeout  = 60 * time.Second
+	DefaultSourceMaxBytes = 256 << 20
+)
+
+// ErrSourceUnauthorized is returned when a data file URL answers 401 or 403
+var ErrSourceUnauthorized = errors.New("data file URL rejected the credentials")
+
+// SourceConfig controls how data files given as http:// or https:// URLs are downloaded.
+// The tags describe the environment variable of each field; see config.Variables.
+type SourceConfig struct {
+	Timeout time.Duration `env:"DATA_URL_TIMEOUT" format:"positive-duration" default:"60s" example:"2m" desc:"Time allowed to download a data file URL"`
+	// MaxBytes caps the response size, so a wrong URL can't fill memory
+	MaxBytes int64 `env:"DATA_URL_MAX_BYTES" format:"positive-int" default:"268435456" example:"536870912" desc:"Largest data file downloaded from a URL, in bytes"`
+	// BearerToken, when set, is sent in the Authorization header, for private blobs
+	BearerToken string `env:"DATA_URL_BEARER_TOKEN" desc:"Token sent in the Authorization header of data file downloads, for private blobs"`
+}
+
+// SourceConfigFromEnv reads DATA_URL_TIMEOUT, DATA_URL_MAX_BYTES, and DATA_URL_BEARER_TOKEN.
+// Invalid values fall back to the defaults; config validation reports them.
+func SourceConfigFromEnv() SourceConfig {
+	cfg := SourceConfig{
+		Timeout:     DefaultSourceTimeout,
+		MaxBytes:    DefaultSourceMaxBytes,
+		BearerToken: os.Getenv("DATA_URL_BEARER_TOKEN"),
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("DATA_URL_TIMEOUT")); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	if maxBytes, err := strconv.ParseInt(os.Getenv("DATA_URL_MAX_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		cfg.MaxBytes = maxBytes
+	}
+	return cfg
+}
+
+// IsURL reports whether path names an http:// or https:// data file
+func IsURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// OpenDataSource opens a local data file, or downloads one from an http:// or https://
+// URL using the settings in the environment. Gzip-compressed data, named *.gz or
+// starting with the gzip magic bytes, is decompressed as it is read. Close the
+// returned reader when done.
+func OpenDataSource(path string) (io.ReadCloser, error) {
+	if IsURL(path) {
+		body, err := SourceConfigFromEnv().Open(context.Background(), path)
+		if err != nil {
+			return nil, err
+		}
+		name := path
+		if u, err := url.Parse(path); err == nil {
+			name = u.Path
+		}
+		return decompress(body, name, redactURL(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return decompress(file, path, path)
+}
+
+// Open starts downloading rawURL and returns the body. The timeout covers the whole
+// download, including reading the body; reading past MaxBytes fails.
+func (c SourceConfig) Open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	shown := redactURL(rawURL)
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("invalid data file URL %s: %w", shown, err)
+	}
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+		// Azure Blob Storage requires a version header for bearer token requests
+		req.Header.Set("x-ms-version", "2023-11-03")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to download %s within DATA_URL_TIMEOUT (%s): %w", shown, c.Timeout, unwrapURLError(err))
+		}
+		return nil, fmt.Errorf("failed to download %s: %w", shown, unwrapURLError(err))
+	}
+
+	if err := checkResponse(resp, shown, c.MaxBytes); err != nil {
+		resp.Body.Close()
+		cancel()
+		return nil, err
+	}
+
+	return &sourceBody{body: resp.Body, limit: c.MaxBytes, url: shown, cancel: cancel}, nil
+}
+
+// checkResponse rejects error statuses, content types that can't be hotel data, and
+// bodies declared larger than maxBytes
+func checkResponse(resp *http.Response, shown string, maxBytes int64) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("failed to download %s: HTTP %s: %w", shown, resp.Status, fs.ErrNotExist)
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("failed to download %s: HTTP %s: %w; check DATA_URL_BEARER_TOKEN or the SAS token", shown, resp.Status, ErrSourceUnauthorized)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("failed to download %s: HTTP %s", shown, resp.Status)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || !acceptedMediaType(mediaType) {
+			return fmt.Errorf("%w: %s returned Content-Type %q, not JSON, JSONL, or CSV; check that the URL points at the data file itself", ErrInvalidData, shown, contentType)
+		}
+	}
+
+	if resp.ContentLength > maxBytes {
+		return fmt.Errorf("%w: %s is %d bytes, more than the %d allowed by DATA_URL_MAX_BYTES", ErrInvalidData, shown, resp.ContentLength, maxBytes)
+	}
+	return nil
+}
+
+// acceptedMediaType reports whether a response of this type may hold the data file.
+// Blob storage often serves data files as a generic binary or text type.
+func acceptedMediaType(mediaType string) bool {
+	switch mediaType {
+	case "application/json", "text/json", "text/plain", "application/octet-stream", "binary/octet-stream",
+		"application/x-ndjson", "application/jsonl", "text/csv", "application/csv", "application/gzip", "application/x-gzip":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json")
+}
+
+// sourceBody is a downloaded body that fails once more than limit bytes are read
+type sourceBody struct {
+	body   io.ReadCloser
+	limit  int64
+	read   int64
+	url    string
+	cancel context.CancelFunc
+}
+
+// Read reads from the body, enforcing the size cap
+func (b *sourceBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n, fmt.Errorf("%w: %s is larger than the %d bytes allowed by DATA_URL_MAX_BYTES", ErrInvalidData, b.url, b.limit)
+	}
+	if err != nil && err != io.EOF {
+		return n, fmt.Errorf("failed to download %s: %w", b.url, err)
+	}
+	return n, err
+}
+
+// Close closes the body and releases the download's timeout
+func (b *sourceBody) Close() error {
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
+
+// redactURL drops the query string, which holds the signature of a SAS URL
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = "REDACTED"
+	}
+	u.User = nil
+	return u.String()
+}
+
+// unwrapURLError strips the *url.Error wrapper, whose message repeats the unredacted URL
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
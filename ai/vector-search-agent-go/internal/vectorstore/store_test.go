@@ -0,0 +1,191 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/timeouts"
+)
+
+func TestLoadHotelsFromJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		wantVectors bool
+	}{
+		{"without vectors", "testdata/without_vectors.json", false},
+		{"with vectors", "testdata/with_vectors.json", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotels, err := LoadHotels(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hotels) != 2 || hotels[0].HotelID != "1" || hotels[1].HotelName != "Old Century Hotel" {
+				t.Fatalf("hotels = %+v", hotels)
+			}
+
+			for _, hotel := range hotels {
+				if got := len(hotel.DescriptionVector) > 0; got != tt.wantVectors {
+					t.Errorf("hotel %s has vector %v, want vector %v", hotel.HotelID, hotel.DescriptionVector, tt.wantVectors)
+				}
+				doc := hotel.ToVectorStore()
+				if len(doc.DescriptionVector) != len(hotel.DescriptionVector) {
+					t.Errorf("hotel %s lost its vector converting to the vector store format", hotel.HotelID)
+				}
+			}
+			if tt.wantVectors {
+				if got := hotels[0].DescriptionVector; got[0] != 0.11 || got[2] != -0.27 {
+					t.Errorf("vector = %v, want [0.11 0.52 -0.27]", got)
+				}
+				for _, hotel := range hotels {
+					if err := ValidateVectorDimensions(hotel, 3); err != nil {
+						t.Errorf("ValidateVectorDimensions: %v", err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoadHotelsFromJSONErrors(t *testing.T) {
+	if _, err := LoadHotels("testdata/missing.json"); err == nil || !strings.Contains(err.Error(), "failed to open file") {
+		t.Errorf("missing file err = %v", err)
+	}
+}
+
+func TestValidateVectorDimensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		dims    int
+		wantErr string
+	}{
+		{"match", 4, ""},
+		{"wrong length", 3, "hotel 2 has a vector of 3 dimensions, not 4"},
+		{"missing vector", 0, "hotel 2 has a vector of 0 dimensions, not 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVectorDimensions(models.Hotel{HotelID: "2", DescriptionVector: make([]float32, tt.dims)}, 4)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidData) || !errors.Is(err, ErrDimensionMismatch) || err.Error() != "invalid data: vector dimensions don't match: "+tt.wantErr {
+				t.Errorf("err = %v, want ErrInvalidData and ErrDimensionMismatch with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadHotelsRooms(t *testing.T) {
+	// rooms.json holds rooms in the shape of ../data/Hotels.json, one with a field the model doesn't know
+	hotels, err := LoadHotels("testdata/rooms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hotels) != 2 || len(hotels[0].Rooms) != 3 || hotels[1].Rooms != nil {
+		t.Fatalf("hotels = %+v, want 3 rooms on the first hotel only", hotels)
+	}
+
+	want := models.Room{
+		Description:    "Deluxe Room, 2 Double Beds (City View)",
+		DescriptionFr:  "Chambre Deluxe, 2 lits doubles (vue ville)",
+		Type:           "Deluxe Room",
+		BaseRate:       150.99,
+		BedOptions:     "2 Double Beds",
+		SleepsCount:    2,
+		SmokingAllowed: false,
+		Tags:           []string{"suite", "bathroom shower", "coffee maker"},
+	}
+	if got := hotels[0].Rooms[2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("room = %+v, want %+v", got, want)
+	}
+
+	summary := hotels[0].ToVectorStore().Rooms
+	if summary == nil || summary.Count != 3 || summary.MinRate != 80.99 || summary.MaxRate != 150.99 ||
+		!slices.Equal(summary.Types, []string{"Budget Room", "Deluxe Room"}) {
+		t.Errorf("summary = %+v", summary)
+	}
+	if hotels[1].ToVectorStore().Rooms != nil {
+		t.Error("hotel without rooms has a room summary")
+	}
+}
+
+func TestFormatHotelForSynthesizer(t *testing.T) {
+	hotel := models.HotelForVectorStore{HotelID: "1", HotelName: "Stay-Kay City Hotel", Tags: []string{"view", "concierge"}, Rating: 3.6}
+	hotel.Address.City = "New York"
+
+	tests := []struct {
+		name     string
+		rooms    *models.RoomSummary
+		location *models.GeoJSONPoint
+		want     []string
+		not      []string
+	}{
+		{
+			name:     "with location",
+			location: models.NewGeoJSONPoint(-73.975403, 40.760586),
+			want:     []string{"Address.Country: \nLocation: New York (lat 40.760586, lng -73.975403)\nScore: "},
+		},
+		{
+			name:  "with rooms",
+			rooms: &models.RoomSummary{Count: 13, MinRate: 80.99, MaxRate: 260, Types: []string{"Budget Room", "Suite"}},
+			want:  []string{"Rooms.Count: 13\n", "Rooms.Rates: 80.99-260.00\n", "Rooms.Types: Budget Room, Suite\nScore: 0.912300\n"},
+		},
+		{name: "without rooms or location", not: []string{"Rooms.", "Location:"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hotel.Rooms, hotel.Location = tt.rooms, tt.location
+			got := FormatHotelForSynthesizer(models.HotelSearchResult{Hotel: hotel, Score: 0.9123})
+			want := append([]string{"--- HOTEL START ---\nHotelId: 1\nHotelName: Stay-Kay City Hotel\n", "Tags: view, concierge\n", "Address.City: New York\n", "--- HOTEL END ---"}, tt.want...)
+			for _, w := range want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output is missing %q:\n%s", w, got)
+				}
+			}
+			for _, n := range tt.not {
+				if strings.Contains(got, n) {
+					t.Errorf("output contains %q:\n%s", n, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNewVectorStoreConnectTimeout(t *testing.T) {
+	// Nothing listens on port 1, so the ping waits for a server until the connect budget
+	// runs out, well before the driver's own 30s server selection timeout
+	config := &VectorStoreConfig{
+		ConnectionString: "mongodb://127.0.0.1:1/",
+		DatabaseName:     "Hotels",
+		CollectionName:   "hotels",
+		Timeouts:         timeouts.Config{Connect: 200 * time.Millisecond},
+	}
+
+	start := time.Now()
+	_, err := NewVectorStore(context.Background(), config)
+	var timedOut *timeouts.Error
+	if !errors.As(err, &timedOut) || timedOut.Stage != timeouts.StageConnect || timedOut.Setting != "CONNECT_TIMEOUT" {
+		t.Fatalf("err = %v, want a connect timeout naming CONNECT_TIMEOUT", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("NewVectorStore took %s, want it to give up after the 200ms budget", elapsed)
+	}
+	if !IsUnreachableError(err) {
+		t.Errorf("err = %v, want it still classified as an unreachable cluster", err)
+	}
+}
@@ -0,0 +1,32 @@
+package vectorstore
+
+import (
+	"context"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts the span of one DocumentDB operation, such as "aggregate", on the
+// named collection. It is named after the operation and the collection.
+func (vs *VectorStore) startSpan(ctx context.Context, operation, collection string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append([]attribute.KeyValue{
+		semconv.DBSystemNameMongoDB,
+		semconv.DBNamespace(vs.config.DatabaseName),
+		semconv.DBCollectionName(collection),
+		semconv.DBOperationName(operation),
+	}, attrs...)
+	return telemetry.StartClient(ctx, operation+" "+collection, attrs...)
+}
+
+// endSpan ends the span of a DocumentDB operation that returned err, counting the
+// error by its class
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		metrics.Default().MongoError(ErrorClass(err))
+	}
+	telemetry.End(span, err)
+}
@@ -0,0 +1,60 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/metrics/metricstest"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry"
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/telemetry/telemetrytest"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpanDescribesOperation(t *testing.T) {
+	exporter := telemetrytest.Record(t)
+	vs := &VectorStore{config: &VectorStoreConfig{DatabaseName: "Hotels"}}
+
+	ctx, parent := telemetry.Start(context.Background(), "planner")
+	_, span := vs.startSpan(ctx, "aggregate", "hotels_diskann", telemetry.KKey.Int(5))
+	span.End()
+	parent.End()
+
+	got := telemetrytest.Span(t, exporter.GetSpans(), "aggregate hotels_diskann")
+	if got.SpanKind != trace.SpanKindClient || got.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span = kind %v, parent %v; want a client span under the caller's span", got.SpanKind, got.Parent.SpanID())
+	}
+	want := map[attribute.Key]attribute.Value{
+		semconv.DBSystemNameKey:     attribute.StringValue("mongodb"),
+		semconv.DBNamespaceKey:      attribute.StringValue("Hotels"),
+		semconv.DBCollectionNameKey: attribute.StringValue("hotels_diskann"),
+		semconv.DBOperationNameKey:  attribute.StringValue("aggregate"),
+		telemetry.KKey:              attribute.IntValue(5),
+	}
+	for key, value := range want {
+		if attr, ok := telemetrytest.Attr(got, key); !ok || attr != value {
+			t.Errorf("%s = %v, want %v", key, attr.Emit(), value.Emit())
+		}
+	}
+}
+
+func TestEndSpanCountsMongoErrors(t *testing.T) {
+	telemetrytest.Record(t)
+	recorder := metricstest.Record(t)
+	vs := &VectorStore{config: &VectorStoreConfig{DatabaseName: "Hotels"}}
+
+	_, span := vs.startSpan(context.Background(), "aggregate", "hotels_diskann")
+	endSpan(span, fmt.Errorf("vector search failed: %w", topology.ServerSelectionError{}))
+	_, span = vs.startSpan(context.Background(), "aggregate", "hotels_diskann")
+	endSpan(span, nil)
+
+	if got := metricstest.Value(t, recorder, "mongo_errors_total", "class", ErrorClassConnectivity); got != 1 {
+		t.Errorf("mongo_errors_total{class=%q} = %v, want 1", ErrorClassConnectivity, got)
+	}
+	if got := metricstest.Value(t, recorder, "mongo_errors_total"); got != 1 {
+		t.Errorf("mongo_errors_total = %v, want only the failed operation counted", got)
+	}
+}
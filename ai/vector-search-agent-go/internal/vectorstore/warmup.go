@@ -0,0 +1,58 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// warmUpK is the number of neighbors each warm-up search asks for
+const warmUpK = 5
+
+// vectorSearcher is the search WarmUpSearches issues
+type vectorSearcher interface {
+	VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error)
+}
+
+// WarmUp issues n throwaway searches with random unit vectors of the configured
+// dimensions, so the first searches after an index build or an idle period don't pay
+// for loading the index. The results are discarded.
+func (vs *VectorStore) WarmUp(ctx context.Context, n int) error {
+	return WarmUpSearches(ctx, vs, EmbeddingDimensionsFromEnv(), n)
+}
+
+// WarmUpSearches issues n searches with random unit vectors of dimensions on s. It
+// stops at the first failure.
+func WarmUpSearches(ctx context.Context, s vectorSearcher, dimensions, n int) error {
+	for i := range n {
+		if _, err := s.VectorSearch(ctx, randomUnitVector(dimensions), warmUpK); err != nil {
+			return fmt.Errorf("warm-up search %d of %d failed: %w", i+1, n, err)
+		}
+	}
+	slog.DebugContext(ctx, "warmed up vector search", "searches", n)
+	return nil
+}
+
+// randomUnitVector returns a vector of the given dimensions pointing in a random
+// direction, with length 1
+func randomUnitVector(dimensions int) []float32 {
+	vector := make([]float32, dimensions)
+	var norm float64
+	for i := range vector {
+		v := rand.NormFloat64()
+		vector[i] = float32(v)
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return vector
+	}
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}
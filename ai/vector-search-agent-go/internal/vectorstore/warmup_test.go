@@ -0,0 +1,65 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Azure-Samples/documentdb-samples/ai/vector-search-agent-go/internal/models"
+)
+
+// countingSearcher records the searches it receives, failing from the failAt-th on
+type countingSearcher struct {
+	vectors [][]float32
+	ks      []int
+	failAt  int
+}
+
+func (c *countingSearcher) VectorSearch(ctx context.Context, queryVector []float32, k int) ([]models.HotelSearchResult, error) {
+	c.vectors = append(c.vectors, queryVector)
+	c.ks = append(c.ks, k)
+	if c.failAt > 0 && len(c.vectors) >= c.failAt {
+		return nil, errors.New("index not ready")
+	}
+	return []models.HotelSearchResult{{Hotel: models.HotelForVectorStore{HotelID: "1"}}}, nil
+}
+
+func TestWarmUpSearches(t *testing.T) {
+	searcher := &countingSearcher{}
+	if err := WarmUpSearches(context.Background(), searcher, 64, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(searcher.vectors) != 4 {
+		t.Fatalf("issued %d searches, want 4", len(searcher.vectors))
+	}
+	for i, vector := range searcher.vectors {
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v) * float64(v)
+		}
+		if len(vector) != 64 || math.Abs(math.Sqrt(norm)-1) > 1e-5 || searcher.ks[i] != warmUpK {
+			t.Errorf("search %d: %d dimensions, length %v, k %d; want a unit vector of 64 dimensions and k %d", i, len(vector), math.Sqrt(norm), searcher.ks[i], warmUpK)
+		}
+	}
+	if searcher.vectors[0][0] == searcher.vectors[1][0] {
+		t.Error("the warm-up searches used the same vector")
+	}
+
+	if err := WarmUpSearches(context.Background(), &countingSearcher{}, 64, 0); err != nil {
+		t.Errorf("no warm-up: err = %v", err)
+	}
+}
+
+func TestWarmUpSearchesStopsAtFailure(t *testing.T) {
+	searcher := &countingSearcher{failAt: 2}
+	err := WarmUpSearches(context.Background(), searcher, 8, 5)
+	if err == nil || !strings.Contains(err.Error(), "warm-up search 2 of 5 failed: index not ready") {
+		t.Errorf("err = %v, want the failed search", err)
+	}
+	if len(searcher.vectors) != 2 {
+		t.Errorf("issued %d searches, want 2", len(searcher.vectors))
+	}
+}